@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"strings"
 	"syscall"
 	"time"
@@ -23,10 +24,20 @@ import (
 
 	"github.com/regrada-ai/regrada-be/internal/api/handlers"
 	apimiddleware "github.com/regrada-ai/regrada-be/internal/api/middleware"
+	"github.com/regrada-ai/regrada-be/internal/archive"
 	"github.com/regrada-ai/regrada-be/internal/auth"
 	"github.com/regrada-ai/regrada-be/internal/email"
+	"github.com/regrada-ai/regrada-be/internal/evaluation"
+	"github.com/regrada-ai/regrada-be/internal/export"
+	"github.com/regrada-ai/regrada-be/internal/ingest"
+	"github.com/regrada-ai/regrada-be/internal/judge"
+	"github.com/regrada-ai/regrada-be/internal/live"
 	"github.com/regrada-ai/regrada-be/internal/migrations"
+	"github.com/regrada-ai/regrada-be/internal/partition"
+	"github.com/regrada-ai/regrada-be/internal/retention"
+	"github.com/regrada-ai/regrada-be/internal/review"
 	"github.com/regrada-ai/regrada-be/internal/storage"
+	"github.com/regrada-ai/regrada-be/internal/storage/local"
 	"github.com/regrada-ai/regrada-be/internal/storage/postgres"
 	"github.com/regrada-ai/regrada-be/internal/storage/s3"
 
@@ -65,9 +76,32 @@ func main() {
 	cognitoClientSecret := getEnv("COGNITO_CLIENT_SECRET", "")
 	cognitoDomain := getEnv("COGNITO_DOMAIN", "") // e.g., "auth.regrada.com" or "yourapp.auth.us-east-1.amazoncognito.com"
 	secureCookies := getEnv("SECURE_COOKIES", "false") == "true"
-	cookieDomain := getEnv("COOKIE_DOMAIN", "") // e.g., ".regrada.com" for cross-subdomain cookies
+	cookieDomain := getEnv("COOKIE_DOMAIN", "")   // e.g., ".regrada.com" for cross-subdomain cookies
+	storageDriver := getEnv("STORAGE_DRIVER", "") // "s3" or "local"; defaults to "s3" when S3_BUCKET is set, otherwise storage is disabled
 	s3Bucket := getEnv("S3_BUCKET", "")
+	s3Endpoint := getEnv("S3_ENDPOINT", "") // e.g., "http://localhost:9000" for MinIO
+	s3ForcePathStyle := getEnv("S3_FORCE_PATH_STYLE", "false") == "true"
+	s3PublicURL := getEnv("S3_PUBLIC_URL", "") // e.g., "http://localhost:9000/regrada" for a public MinIO bucket
 	cloudFrontDomain := getEnv("CLOUDFRONT_DOMAIN", "")
+	localStorageDir := getEnv("LOCAL_STORAGE_DIR", "./data/files")
+	localStorageSigningKey := getEnv("LOCAL_STORAGE_SIGNING_KEY", "")
+	publicAPIURL := getEnv("PUBLIC_API_URL", "http://localhost:"+port)
+	internalAPIToken := getEnv("INTERNAL_API_TOKEN", "")
+	ingestWorkers := getEnvInt("INGEST_WORKERS", 4) // 0 disables background ingestion on this instance
+	ingestBatchSize := getEnvInt("INGEST_BATCH_SIZE", 500)
+	ingestMaxBacklog := getEnvInt("INGEST_MAX_BACKLOG", 1000000)
+	retentionPurgeInterval := getEnvDuration("RETENTION_PURGE_INTERVAL", time.Hour)    // 0 disables the purge job on this instance
+	partitionInterval := getEnvDuration("PARTITION_MAINTENANCE_INTERVAL", 6*time.Hour) // 0 disables partition maintenance on this instance
+	archiveInterval := getEnvDuration("ARCHIVE_INTERVAL", 6*time.Hour)                 // 0 disables archiving and restores on this instance
+	exportPoll := getEnvDuration("EXPORT_POLL_INTERVAL", 30*time.Second)               // 0 disables the export worker on this instance
+	reviewSampleInterval := getEnvDuration("REVIEW_SAMPLE_INTERVAL", 5*time.Minute)    // 0 disables review queue sampling on this instance
+	evaluationInterval := getEnvDuration("EVALUATION_INTERVAL", 10*time.Second)        // 0 disables trace evaluation on this instance
+	judgePoll := getEnvDuration("JUDGE_POLL_INTERVAL", 30*time.Second)                 // 0 disables the judge worker on this instance
+	judgeBaseURL := getEnv("JUDGE_BASE_URL", "")                                       // OpenAI-compatible API; defaults to OpenAI
+	judgeAPIKey := getEnv("JUDGE_API_KEY", "")
+	judgeConcurrency := getEnvInt("JUDGE_CONCURRENCY", 4)
+	judgeTimeout := getEnvDuration("JUDGE_TIMEOUT", time.Minute)
+	judgePrices := getEnv("JUDGE_PRICES", "") // e.g., "gpt-4o-mini=0.15:0.60" (USD per million input:output tokens)
 
 	// Connect to PostgreSQL with Bun
 	sqldb := sql.OpenDB(pgdriver.NewConnector(pgdriver.WithDSN(dbURL)))
@@ -112,6 +146,12 @@ func main() {
 	}
 	log.Println("✓ Connected to Redis")
 
+	// Initialize asynchronous ingestion queue
+	ingestQueue := ingest.NewQueue(redisClient, int64(ingestMaxBacklog))
+	if err := ingestQueue.EnsureGroup(ctx); err != nil {
+		log.Fatalf("Failed to initialize ingestion queue: %v", err)
+	}
+
 	// Initialize storage repositories
 	orgRepo := postgres.NewOrganizationRepository(db)
 	apiKeyRepo := postgres.NewAPIKeyRepository(db)
@@ -121,6 +161,89 @@ func main() {
 	userRepo := postgres.NewUserRepository(db)
 	memberRepo := postgres.NewOrganizationMemberRepository(db)
 	inviteRepo := postgres.NewInviteRepository(db)
+	redactionRepo := postgres.NewRedactionPolicyRepository(db)
+	retentionRepo := postgres.NewRetentionRepository(db)
+	partitionRepo := postgres.NewTracePartitionRepository(db)
+	archiveRepo := postgres.NewArchiveRepository(db)
+	exportRepo := postgres.NewExportRepository(db)
+	datasetRepo := postgres.NewDatasetRepository(db)
+	annotationRepo := postgres.NewAnnotationRepository(db)
+	reviewRepo := postgres.NewReviewRepository(db)
+	evaluationRepo := postgres.NewEvaluationRepository(db)
+	judgeRepo := postgres.NewJudgeRepository(db)
+	policyRepo := postgres.NewPolicyRepository(db)
+	gateRepo := postgres.NewQualityGateRepository(db)
+	quarantineRepo := postgres.NewQuarantineRepository(db)
+	regressionRepo := postgres.NewRegressionRepository(db)
+	badgeRepo := postgres.NewBadgeRepository(db)
+	auditRepo := postgres.NewAuditLogRepository(db)
+	promptRepo := postgres.NewPromptRepository(db)
+
+	// Start live event fan-out
+	liveHub := live.NewHub(redisClient)
+	liveHub.Start(ctx)
+
+	// Start ingestion workers
+	var ingestPool *ingest.Pool
+	if ingestWorkers > 0 {
+		poolConfig := ingest.DefaultPoolConfig()
+		poolConfig.Workers = ingestWorkers
+		poolConfig.BatchSize = int64(ingestBatchSize)
+		ingestPool = ingest.NewPool(ingestQueue, traceRepo, liveHub, poolConfig)
+		ingestPool.Start(ctx)
+		log.Printf("✓ Ingestion workers started (%d)", ingestWorkers)
+	} else {
+		log.Println("⚠ Ingestion workers disabled (INGEST_WORKERS=0)")
+	}
+
+	// Start trace partition maintenance
+	var partitionManager *partition.Manager
+	if partitionInterval > 0 {
+		managerConfig := partition.DefaultManagerConfig()
+		managerConfig.Interval = partitionInterval
+		partitionManager = partition.NewManager(partitionRepo, managerConfig)
+		partitionManager.Start(ctx)
+		log.Printf("✓ Trace partition maintenance started (every %s)", partitionInterval)
+	} else {
+		log.Println("⚠ Trace partition maintenance disabled (PARTITION_MAINTENANCE_INTERVAL=0)")
+	}
+
+	// Start trace evaluation
+	var evaluationWorker *evaluation.Worker
+	if evaluationInterval > 0 {
+		workerConfig := evaluation.DefaultWorkerConfig()
+		workerConfig.Interval = evaluationInterval
+		evaluationWorker = evaluation.NewWorker(evaluationRepo, workerConfig)
+		evaluationWorker.Start(ctx)
+		log.Printf("✓ Trace evaluation started (every %s)", evaluationInterval)
+	} else {
+		log.Println("⚠ Trace evaluation disabled (EVALUATION_INTERVAL=0)")
+	}
+
+	// Start review queue sampler
+	var reviewSampler *review.Sampler
+	if reviewSampleInterval > 0 {
+		samplerConfig := review.DefaultSamplerConfig()
+		samplerConfig.Interval = reviewSampleInterval
+		reviewSampler = review.NewSampler(reviewRepo, samplerConfig)
+		reviewSampler.Start(ctx)
+		log.Printf("✓ Review queue sampler started (every %s)", reviewSampleInterval)
+	} else {
+		log.Println("⚠ Review queue sampler disabled (REVIEW_SAMPLE_INTERVAL=0)")
+	}
+
+	// Start retention purge job
+	purgerConfig := retention.DefaultPurgerConfig()
+	if retentionPurgeInterval > 0 {
+		purgerConfig.Interval = retentionPurgeInterval
+	}
+	purger := retention.NewPurger(retentionRepo, partitionRepo, redisClient, purgerConfig)
+	if retentionPurgeInterval > 0 {
+		purger.Start(ctx)
+		log.Printf("✓ Retention purge job started (every %s)", retentionPurgeInterval)
+	} else {
+		log.Println("⚠ Retention purge job disabled (RETENTION_PURGE_INTERVAL=0)")
+	}
 
 	// Initialize authentication service (Cognito or Mock)
 	var authService auth.Service
@@ -156,28 +279,144 @@ func main() {
 		log.Println("⚠ Email service disabled (missing EMAIL_FROM_ADDRESS)")
 	}
 
-	// Initialize file storage service (S3) (optional)
+	// Initialize file storage service (S3, S3-compatible, or local filesystem) (optional)
+	explicitStorageDriver := storageDriver != ""
+	if storageDriver == "" && s3Bucket != "" {
+		storageDriver = "s3"
+	}
+
 	var storageService storage.FileStorageService
-	if s3Bucket != "" && cloudFrontDomain != "" {
+	var localStorage *local.Service
+	switch storageDriver {
+	case "":
+		log.Println("⚠ File storage service disabled (set S3_BUCKET, or STORAGE_DRIVER=local)")
+	case "s3":
+		if s3Bucket == "" || (cloudFrontDomain == "" && s3Endpoint == "" && s3PublicURL == "") {
+			if explicitStorageDriver {
+				log.Fatalf("S3 storage requires S3_BUCKET and one of CLOUDFRONT_DOMAIN, S3_ENDPOINT, or S3_PUBLIC_URL")
+			}
+			log.Println("⚠ File storage service disabled (missing CLOUDFRONT_DOMAIN, S3_ENDPOINT, or S3_PUBLIC_URL)")
+			break
+		}
 		var err error
-		storageService, err = s3.NewService(awsRegion, s3Bucket, cloudFrontDomain)
+		storageService, err = s3.NewService(s3.Config{
+			Region:           awsRegion,
+			Bucket:           s3Bucket,
+			CloudFrontDomain: cloudFrontDomain,
+			Endpoint:         s3Endpoint,
+			UsePathStyle:     s3ForcePathStyle,
+			PublicBaseURL:    s3PublicURL,
+		})
 		if err != nil {
 			log.Fatalf("Failed to initialize file storage service: %v", err)
 		}
-		log.Println("✓ File storage service initialized")
-	} else {
-		log.Println("⚠ File storage service disabled (missing S3_BUCKET or CLOUDFRONT_DOMAIN)")
+		if s3Endpoint != "" {
+			log.Printf("✓ File storage service initialized (S3-compatible at %s)", s3Endpoint)
+		} else {
+			log.Println("✓ File storage service initialized (S3)")
+		}
+	case "local":
+		// Signed URLs must stay valid across restarts and instances
+		if localStorageSigningKey == "" {
+			log.Fatalf("Local storage requires LOCAL_STORAGE_SIGNING_KEY")
+		}
+		var err error
+		localStorage, err = local.NewService(localStorageDir, strings.TrimSuffix(publicAPIURL, "/")+"/v1/files", []byte(localStorageSigningKey), 24*time.Hour)
+		if err != nil {
+			log.Fatalf("Failed to initialize file storage service: %v", err)
+		}
+		storageService = localStorage
+		log.Printf("✓ File storage service initialized (local at %s)", localStorageDir)
+	default:
+		log.Fatalf("Unknown STORAGE_DRIVER %q (expected \"s3\" or \"local\")", storageDriver)
+	}
+
+	// Start cold archive job
+	archiveConfig := archive.DefaultConfig()
+	if archiveInterval > 0 {
+		archiveConfig.Interval = archiveInterval
+	}
+	archiver := archive.NewArchiver(archiveRepo, retentionRepo, traceRepo, storageService, redisClient, archiveConfig)
+	switch {
+	case archiveInterval == 0:
+		log.Println("⚠ Trace archive job disabled (ARCHIVE_INTERVAL=0)")
+	case storageService == nil:
+		log.Println("⚠ Trace archive job disabled (file storage not configured)")
+	default:
+		archiver.Start(ctx)
+		log.Printf("✓ Trace archive job started (every %s)", archiveInterval)
+	}
+
+	// Start export worker
+	exportConfig := export.DefaultConfig()
+	if exportPoll > 0 {
+		exportConfig.Poll = exportPoll
+	}
+	exporter := export.NewExporter(exportRepo, traceRepo, testRunRepo, projectRepo, storageService, emailService, exportConfig)
+	switch {
+	case exportPoll == 0:
+		log.Println("⚠ Export worker disabled (EXPORT_POLL_INTERVAL=0)")
+	case storageService == nil:
+		log.Println("⚠ Export worker disabled (file storage not configured)")
+	default:
+		exporter.Start(ctx)
+		log.Printf("✓ Export worker started (polling every %s)", exportPoll)
+	}
+
+	// Start judge worker
+	judgeConfig := judge.DefaultConfig()
+	if judgePoll > 0 {
+		judgeConfig.Poll = judgePoll
+	}
+	if judgeConcurrency > 0 {
+		judgeConfig.Concurrency = judgeConcurrency
+	}
+	judgeConfig.Prices, err = judge.ParsePrices(judgePrices)
+	if err != nil {
+		log.Fatalf("Failed to parse JUDGE_PRICES: %v", err)
+	}
+	judgeClient := judge.NewOpenAIClient(judgeBaseURL, judgeAPIKey, judgeTimeout)
+	judgeWorker := judge.NewWorker(judgeRepo, traceRepo, datasetRepo, judgeClient, judgeConfig)
+	switch {
+	case judgePoll == 0:
+		log.Println("⚠ Judge worker disabled (JUDGE_POLL_INTERVAL=0)")
+	case judgeBaseURL == "" && judgeAPIKey == "":
+		log.Println("⚠ Judge worker disabled (set JUDGE_API_KEY or JUDGE_BASE_URL)")
+	default:
+		judgeWorker.Start(ctx)
+		log.Printf("✓ Judge worker started (polling every %s, %d concurrent calls)", judgePoll, judgeConfig.Concurrency)
 	}
 
 	// Initialize handlers
-	orgHandler := handlers.NewOrganizationHandler(orgRepo, memberRepo, userRepo, apiKeyRepo)
-	apiKeyHandler := handlers.NewAPIKeyHandler(apiKeyRepo, orgRepo)
-	projectHandler := handlers.NewProjectHandler(projectRepo)
-	traceHandler := handlers.NewTraceHandler(traceRepo, projectRepo)
-	testRunHandler := handlers.NewTestRunHandler(testRunRepo, projectRepo)
+	auditor := handlers.NewAuditor(auditRepo, apiKeyRepo)
+	orgHandler := handlers.NewOrganizationHandler(orgRepo, memberRepo, userRepo, apiKeyRepo, auditor)
+	apiKeyHandler := handlers.NewAPIKeyHandler(apiKeyRepo, orgRepo, auditor)
+	projectHandler := handlers.NewProjectHandler(projectRepo, auditor)
+	traceHandler := handlers.NewTraceHandler(traceRepo, projectRepo, redactionRepo, evaluationRepo, ingestQueue, liveHub)
+	ingestHandler := handlers.NewIngestHandler(ingestQueue)
+	retentionHandler := handlers.NewRetentionHandler(retentionRepo, purger)
+	archiveHandler := handlers.NewArchiveHandler(archiveRepo, retentionRepo, archiver)
+	exportHandler := handlers.NewExportHandler(exportRepo, retentionRepo, userRepo, storageService, exporter)
+	datasetHandler := handlers.NewDatasetHandler(datasetRepo, traceRepo, retentionRepo)
+	annotationHandler := handlers.NewAnnotationHandler(annotationRepo, traceRepo, retentionRepo)
+	reviewHandler := handlers.NewReviewHandler(reviewRepo, traceRepo, testRunRepo, memberRepo, retentionRepo)
+	evaluatorHandler := handlers.NewEvaluatorHandler(evaluationRepo, retentionRepo)
+	judgeHandler := handlers.NewJudgeHandler(judgeRepo, datasetRepo, retentionRepo, judgeWorker)
+	redactionHandler := handlers.NewRedactionHandler(redactionRepo, retentionRepo)
+	testRunHandler := handlers.NewTestRunHandler(testRunRepo, projectRepo, policyRepo, retentionRepo, gateRepo, quarantineRepo, liveHub)
+	policyHandler := handlers.NewPolicyHandler(policyRepo, retentionRepo)
+	promptHandler := handlers.NewPromptHandler(promptRepo, retentionRepo)
+	qualityGateHandler := handlers.NewQualityGateHandler(gateRepo, testRunRepo, quarantineRepo, retentionRepo)
+	flakyCaseHandler := handlers.NewFlakyCaseHandler(testRunRepo, quarantineRepo, retentionRepo)
+	regressionHandler := handlers.NewRegressionHandler(regressionRepo, testRunRepo, quarantineRepo, retentionRepo)
+	diffHandler := handlers.NewDiffHandler(testRunRepo, traceRepo, retentionRepo)
+	badgeHandler := handlers.NewBadgeHandler(badgeRepo, testRunRepo, regressionRepo, retentionRepo, redisClient, publicAPIURL)
+	liveHandler := handlers.NewLiveHandler(liveHub, retentionRepo)
+	modelComparisonHandler := handlers.NewModelComparisonHandler(testRunRepo, retentionRepo)
 	healthHandler := handlers.NewHealthHandler(sqldb, redisClient)
-	userHandler := handlers.NewUserHandler(userRepo, memberRepo, storageService)
-	inviteHandler := handlers.NewInviteHandler(inviteRepo, userRepo, memberRepo, orgRepo, emailService)
+	userHandler := handlers.NewUserHandler(userRepo, memberRepo, storageService, auditor)
+	inviteHandler := handlers.NewInviteHandler(inviteRepo, userRepo, memberRepo, orgRepo, emailService, auditor)
+	auditLogHandler := handlers.NewAuditLogHandler(auditRepo)
 
 	var emailHandler *handlers.EmailHandler
 	// Auth handler is always initialized now (either Cognito or Mock)
@@ -187,10 +426,16 @@ func main() {
 		emailHandler = handlers.NewEmailHandler(emailService)
 	}
 
+	var fileHandler *handlers.FileHandler
+	if localStorage != nil {
+		fileHandler = handlers.NewFileHandler(localStorage)
+	}
+
 	// Initialize middleware
 	apiKeyAuthMiddleware := apimiddleware.NewAuthMiddleware(apiKeyRepo, redisClient)
 	rateLimitMiddleware := apimiddleware.NewRateLimitMiddleware(redisClient)
 	usageMiddleware := apimiddleware.NewUsageMiddleware(orgRepo)
+	idempotencyMiddleware := apimiddleware.NewIdempotencyMiddleware(redisClient)
 
 	// Initialize cookie-based auth middleware (always enabled now with either Cognito or Mock)
 	cookieAuthMiddleware := apimiddleware.NewCookieAuthMiddleware(authService, userRepo, memberRepo)
@@ -230,6 +475,17 @@ func main() {
 		// Public routes (no auth required)
 		v1.GET("/invites/:token", inviteHandler.GetInvite)
 
+		// Locally stored files (public, access controlled by URL signature)
+		if fileHandler != nil {
+			v1.GET("/files/*key", fileHandler.ServeFile)
+		}
+
+		// Status badges (public, access controlled by the token in the URL)
+		v1.GET("/badges/:token/:badge", badgeHandler.GetBadge)
+
+		// Deployment-wide status (operators only)
+		v1.GET("/ingest/status", apimiddleware.NewInternalAuthMiddleware(internalAPIToken), ingestHandler.GetQueueStatus)
+
 		// Newsletter signup (public, no auth required)
 		if emailHandler != nil {
 			v1.POST("/newsletter/signup", emailHandler.NewsletterSignup)
@@ -240,6 +496,7 @@ func main() {
 		protected := v1.Group("")
 		protected.Use(eitherAuth.Authenticate())
 		protected.Use(rateLimitMiddleware.Limit())
+		protected.Use(idempotencyMiddleware.Handle())
 		{
 			// Organization routes
 			protected.POST("/organizations", orgHandler.CreateOrganization)
@@ -250,6 +507,14 @@ func main() {
 			protected.GET("/organizations/:orgID/users", userHandler.ListOrganizationUsers)
 			protected.PUT("/organizations/:orgID/members/:userID", userHandler.UpdateOrganizationMemberRole)
 			protected.DELETE("/organizations/:orgID/members/:userID", userHandler.RemoveOrganizationMember)
+			protected.GET("/organizations/:orgID/retention", retentionHandler.GetOrganizationRetention)
+			protected.PUT("/organizations/:orgID/retention", retentionHandler.UpdateOrganizationRetention)
+			protected.GET("/organizations/:orgID/policies", policyHandler.ListOrganizationPolicies)
+			protected.GET("/organizations/:orgID/policies/:policyID", policyHandler.GetOrganizationPolicy)
+			protected.PUT("/organizations/:orgID/policies/:policyID", policyHandler.PutOrganizationPolicy)
+			protected.DELETE("/organizations/:orgID/policies/:policyID", policyHandler.DeleteOrganizationPolicy)
+			protected.GET("/organizations/:orgID/audit-log", auditLogHandler.ListAuditLog)
+			protected.GET("/organizations/:orgID/audit-log/export", auditLogHandler.ExportAuditLog)
 
 			// Invite routes
 			protected.POST("/organizations/:orgID/invites", inviteHandler.CreateInvite)
@@ -285,6 +550,7 @@ func main() {
 			projects := protected.Group("/projects/:projectID")
 			{
 				projects.GET("", projectHandler.GetProject)
+				projects.DELETE("", projectHandler.DeleteProject)
 
 				// Read-only routes (not metered)
 				projects.GET("/traces", traceHandler.ListTraces)
@@ -292,12 +558,151 @@ func main() {
 				projects.GET("/test-runs", testRunHandler.ListTestRuns)
 				projects.GET("/test-runs/:runID", testRunHandler.GetTestRun)
 
+				// Live stream routes
+				projects.GET("/traces/stream", liveHandler.StreamTraces)
+				projects.GET("/test-runs/stream", liveHandler.StreamTestRuns)
+
+				// Redaction policy routes
+				projects.GET("/redaction-policy", redactionHandler.GetRedactionPolicy)
+				projects.PUT("/redaction-policy", redactionHandler.UpdateRedactionPolicy)
+				projects.POST("/redaction-policy/test", redactionHandler.TestRedactionPolicy)
+
+				// Retention routes
+				projects.GET("/retention", retentionHandler.GetProjectRetention)
+				projects.PUT("/retention", retentionHandler.UpdateProjectRetention)
+
+				// Archive routes
+				projects.GET("/archive-policy", archiveHandler.GetArchivePolicy)
+				projects.PUT("/archive-policy", archiveHandler.UpdateArchivePolicy)
+				projects.GET("/archives", archiveHandler.ListArchives)
+				if storageService != nil { // restores read archives from file storage
+					projects.POST("/archives/restores", archiveHandler.CreateRestore)
+				}
+				projects.GET("/archives/restores", archiveHandler.ListRestores)
+				projects.GET("/archives/restores/:restoreID", archiveHandler.GetRestore)
+
+				// Export routes
+				if storageService != nil { // exports are written to file storage
+					projects.POST("/exports", exportHandler.CreateExport)
+				}
+				projects.GET("/exports", exportHandler.ListExports)
+				projects.GET("/exports/:exportID", exportHandler.GetExport)
+
+				// Dataset routes
+				projects.POST("/datasets", datasetHandler.CreateDataset)
+				projects.GET("/datasets", datasetHandler.ListDatasets)
+				projects.GET("/datasets/:datasetID", datasetHandler.GetDataset)
+				projects.PATCH("/datasets/:datasetID", datasetHandler.UpdateDataset)
+				projects.DELETE("/datasets/:datasetID", datasetHandler.DeleteDataset)
+				projects.POST("/datasets/:datasetID/items", datasetHandler.AddDatasetItems)
+				projects.GET("/datasets/:datasetID/items", datasetHandler.ListDatasetItems)
+				projects.DELETE("/datasets/:datasetID/items/:itemID", datasetHandler.RemoveDatasetItem)
+				projects.PUT("/datasets/:datasetID/items/:itemID/expected-output", datasetHandler.SetDatasetItemExpectedOutput)
+				projects.DELETE("/datasets/:datasetID/items/:itemID/expected-output", datasetHandler.ClearDatasetItemExpectedOutput)
+				projects.GET("/datasets/:datasetID/export", datasetHandler.ExportDataset)
+
+				// Annotation routes
+				projects.POST("/traces/:traceID/annotations", annotationHandler.CreateAnnotation)
+				projects.GET("/traces/:traceID/annotations", annotationHandler.ListAnnotations)
+				projects.PUT("/traces/:traceID/annotations/:annotationID", annotationHandler.UpdateAnnotation)
+				projects.DELETE("/traces/:traceID/annotations/:annotationID", annotationHandler.DeleteAnnotation)
+				projects.GET("/analytics/feedback", annotationHandler.GetFeedbackAnalytics)
+
+				// Review queue routes
+				projects.POST("/review-queues", reviewHandler.CreateReviewQueue)
+				projects.GET("/review-queues", reviewHandler.ListReviewQueues)
+				projects.GET("/review-queues/:queueID", reviewHandler.GetReviewQueue)
+				projects.PUT("/review-queues/:queueID", reviewHandler.UpdateReviewQueue)
+				projects.DELETE("/review-queues/:queueID", reviewHandler.DeleteReviewQueue)
+				projects.GET("/review-queues/:queueID/progress", reviewHandler.GetReviewProgress)
+				projects.POST("/review-queues/:queueID/claim", reviewHandler.ClaimNextReviewItem)
+				projects.POST("/review-queues/:queueID/items", reviewHandler.AddReviewItems)
+				projects.GET("/review-queues/:queueID/items", reviewHandler.ListReviewItems)
+				projects.PUT("/review-queues/:queueID/items/:itemID/assignee", reviewHandler.AssignReviewItem)
+				projects.POST("/review-queues/:queueID/items/:itemID/claim", reviewHandler.ClaimReviewItem)
+				projects.POST("/review-queues/:queueID/items/:itemID/release", reviewHandler.ReleaseReviewItem)
+				projects.POST("/review-queues/:queueID/items/:itemID/complete", reviewHandler.CompleteReviewItem)
+
+				// Evaluator routes
+				projects.POST("/evaluators", evaluatorHandler.CreateEvaluator)
+				projects.GET("/evaluators", evaluatorHandler.ListEvaluators)
+				projects.GET("/evaluators/:evaluatorID", evaluatorHandler.GetEvaluator)
+				projects.PATCH("/evaluators/:evaluatorID", evaluatorHandler.UpdateEvaluator)
+				projects.DELETE("/evaluators/:evaluatorID", evaluatorHandler.DeleteEvaluator)
+				projects.GET("/analytics/evaluations", evaluatorHandler.GetEvaluationAnalytics)
+
+				// LLM judge routes
+				projects.POST("/judges", judgeHandler.CreateJudge)
+				projects.GET("/judges", judgeHandler.ListJudges)
+				projects.GET("/judges/:judgeID", judgeHandler.GetJudge)
+				projects.PATCH("/judges/:judgeID", judgeHandler.UpdateJudge)
+				projects.DELETE("/judges/:judgeID", judgeHandler.DeleteJudge)
+				projects.POST("/judges/:judgeID/runs", judgeHandler.CreateJudgeRun)
+				projects.GET("/judges/:judgeID/runs", judgeHandler.ListJudgeRuns)
+				projects.GET("/judge-runs/:runID", judgeHandler.GetJudgeRun)
+				projects.GET("/judge-runs/:runID/scores", judgeHandler.ListJudgeScores)
+				projects.GET("/analytics/judge-costs", judgeHandler.GetJudgeCostAnalytics)
+
+				// Policy routes
+				projects.GET("/policies", policyHandler.ListProjectPolicies)
+				projects.GET("/policies/:policyID", policyHandler.GetProjectPolicy)
+				projects.PUT("/policies/:policyID", policyHandler.PutProjectPolicy)
+				projects.DELETE("/policies/:policyID", policyHandler.DeleteProjectPolicy)
+				projects.GET("/analytics/violations", policyHandler.GetViolationAnalytics)
+
+				// Prompt registry routes
+				projects.POST("/prompts", promptHandler.CreatePrompt)
+				projects.GET("/prompts", promptHandler.ListPrompts)
+				projects.GET("/prompts/:name", promptHandler.GetPrompt)
+				projects.PATCH("/prompts/:name", promptHandler.UpdatePrompt)
+				projects.DELETE("/prompts/:name", promptHandler.DeletePrompt)
+				projects.GET("/prompts/:name/fetch", promptHandler.FetchPrompt)
+				projects.POST("/prompts/:name/versions", promptHandler.CreatePromptVersion)
+				projects.GET("/prompts/:name/versions", promptHandler.ListPromptVersions)
+				projects.GET("/prompts/:name/versions/:version", promptHandler.GetPromptVersion)
+				projects.PUT("/prompts/:name/labels/:label", promptHandler.SetPromptLabel)
+				projects.DELETE("/prompts/:name/labels/:label", promptHandler.DeletePromptLabel)
+				projects.GET("/analytics/prompts", promptHandler.GetPromptAnalytics)
+
+				// Quality gate routes
+				projects.GET("/quality-gate", qualityGateHandler.GetQualityGate)
+				projects.PUT("/quality-gate", qualityGateHandler.PutQualityGate)
+				projects.DELETE("/quality-gate", qualityGateHandler.DeleteQualityGate)
+				projects.GET("/test-runs/:runID/verdict", qualityGateHandler.GetTestRunVerdict)
+				projects.GET("/test-runs/:runID/compare", qualityGateHandler.CompareTestRuns)
+				projects.GET("/significance", qualityGateHandler.GetSignificance)
+				projects.PUT("/significance", qualityGateHandler.PutSignificance)
+
+				// Flaky case routes
+				projects.GET("/cases/flaky", flakyCaseHandler.ListFlakyCases)
+				projects.GET("/cases/quarantined", flakyCaseHandler.ListQuarantinedCases)
+				projects.PUT("/cases/:caseID/quarantine", flakyCaseHandler.QuarantineCase)
+				projects.DELETE("/cases/:caseID/quarantine", flakyCaseHandler.UnquarantineCase)
+
+				// Regression routes
+				projects.GET("/regressions", regressionHandler.ListRegressions)
+				projects.POST("/regressions/bisect", regressionHandler.BisectRegression)
+
+				// Output diff routes
+				projects.GET("/test-runs/:runID/cases/:caseID/diff", diffHandler.DiffCaseOutput)
+				projects.GET("/traces/:traceID/diff", diffHandler.DiffTraceOutput)
+
+				// Badge token routes
+				projects.POST("/badges", badgeHandler.CreateBadgeToken)
+				projects.GET("/badges", badgeHandler.ListBadgeTokens)
+				projects.DELETE("/badges/:badgeID", badgeHandler.RevokeBadgeToken)
+
+				// Model comparison routes
+				projects.GET("/analytics/models", modelComparisonHandler.GetModelMatrix)
+				projects.GET("/analytics/models/export", modelComparisonHandler.ExportModelMatrix)
+
 				// Metered routes (count against monthly usage)
 				metered := projects.Group("")
 				metered.Use(usageMiddleware.TrackUsage())
 				{
 					metered.POST("/traces", traceHandler.UploadTrace)
 					metered.POST("/traces/batch", traceHandler.UploadTracesBatch)
+					metered.POST("/traces/bulk", traceHandler.UploadTracesBulk)
 					metered.POST("/test-runs", testRunHandler.UploadTestRun)
 				}
 			}
@@ -309,6 +714,8 @@ func main() {
 		Addr:    ":" + port,
 		Handler: r,
 	}
+	// Shutdown does not interrupt open streams, so end them when it begins
+	server.RegisterOnShutdown(liveHub.Stop)
 
 	// Graceful shutdown
 	go func() {
@@ -331,6 +738,23 @@ func main() {
 		log.Fatalf("Server forced to shutdown: %v", err)
 	}
 
+	if ingestPool != nil {
+		ingestPool.Stop()
+	}
+	if partitionManager != nil {
+		partitionManager.Stop()
+	}
+	if evaluationWorker != nil {
+		evaluationWorker.Stop()
+	}
+	if reviewSampler != nil {
+		reviewSampler.Stop()
+	}
+	purger.Stop()
+	archiver.Stop()
+	exporter.Stop()
+	judgeWorker.Stop()
+
 	db.Close()
 	log.Println("Server stopped")
 }
@@ -341,3 +765,27 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+func getEnvInt(key string, defaultValue int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		log.Fatalf("Invalid %s %q: must be an integer", key, value)
+	}
+	return n
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		log.Fatalf("Invalid %s %q: must be a duration such as 30m or 1h", key, value)
+	}
+	return d
+}
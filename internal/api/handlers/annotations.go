@@ -0,0 +1,410 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/regrada-ai/regrada-be/internal/storage"
+)
+
+const (
+	maxAnnotationCriteria = 20
+	maxCriterionLength    = 64
+	maxTopLabels          = 20
+)
+
+type AnnotationHandler struct {
+	annotationRepo storage.AnnotationRepository
+	traceRepo      storage.TraceRepository
+	retentionRepo  storage.RetentionRepository
+}
+
+func NewAnnotationHandler(annotationRepo storage.AnnotationRepository, traceRepo storage.TraceRepository, retentionRepo storage.RetentionRepository) *AnnotationHandler {
+	return &AnnotationHandler{
+		annotationRepo: annotationRepo,
+		traceRepo:      traceRepo,
+		retentionRepo:  retentionRepo,
+	}
+}
+
+type annotationRequest struct {
+	Rating  storage.Rating     `json:"rating" binding:"omitempty,oneof=up down"`
+	Scores  map[string]float64 `json:"scores"`
+	Comment string             `json:"comment" binding:"max=10000"`
+	Labels  []string           `json:"labels" binding:"max=20,dive,min=1,max=64"`
+	// EndUserID identifies the end user giving feedback through an API key
+	EndUserID string `json:"end_user_id" binding:"max=255"`
+}
+
+// validate returns why the annotation is invalid, or "" if it is valid
+func (r *annotationRequest) validate() string {
+	if r.Rating == "" && len(r.Scores) == 0 && r.Comment == "" && len(r.Labels) == 0 {
+		return "An annotation needs a rating, scores, a comment, or labels"
+	}
+	if len(r.Scores) > maxAnnotationCriteria {
+		return fmt.Sprintf("At most %d scores are allowed", maxAnnotationCriteria)
+	}
+	for criterion, score := range r.Scores {
+		if criterion == "" || len(criterion) > maxCriterionLength {
+			return fmt.Sprintf("Score criteria must be 1 to %d characters", maxCriterionLength)
+		}
+		if math.IsNaN(score) || math.IsInf(score, 0) {
+			return fmt.Sprintf("Score for %q must be a finite number", criterion)
+		}
+	}
+	return ""
+}
+
+// CreateAnnotation records feedback on a trace
+// @Summary      Annotate trace
+// @Description  Record a thumbs up or down, scores on named criteria, a comment, and/or labels on a trace. Annotations from signed-in org members record their author; annotations sent with an API key are recorded as end-user feedback, optionally with an end_user_id.
+// @Tags         annotations
+// @Accept       json
+// @Produce      json
+// @Param        projectID  path      string                  true  "Project ID"
+// @Param        traceID    path      string                  true  "Trace ID"
+// @Param        request    body      map[string]interface{}  true  "rating, scores, comment, labels, end_user_id"
+// @Success      201        {object}  map[string]interface{} "Annotation"
+// @Failure      400        {object}  map[string]interface{} "Invalid request"
+// @Failure      401        {object}  map[string]interface{} "Unauthorized"
+// @Failure      404        {object}  map[string]interface{} "Trace not found"
+// @Failure      500        {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/traces/{traceID}/annotations [post]
+func (h *AnnotationHandler) CreateAnnotation(c *gin.Context) {
+	project := loadProject(c, h.retentionRepo)
+	if project == nil {
+		return
+	}
+
+	var req annotationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("[CreateAnnotation] binding error: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": "Invalid request parameters",
+			},
+		})
+		return
+	}
+	if reason := req.validate(); reason != "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": reason,
+			},
+		})
+		return
+	}
+
+	traceID := c.Param("traceID")
+	if !h.traceExists(c, project.ProjectID, traceID) {
+		return
+	}
+
+	annotation := &storage.Annotation{
+		ProjectID: project.ProjectID,
+		TraceID:   traceID,
+		Rating:    req.Rating,
+		Scores:    req.Scores,
+		Comment:   req.Comment,
+		Labels:    req.Labels,
+	}
+	if userID := c.GetString("user_id"); userID != "" {
+		annotation.Source = storage.AnnotationSourceMember
+		annotation.AuthorID = userID
+	} else {
+		annotation.Source = storage.AnnotationSourceEndUser
+		annotation.EndUserID = req.EndUserID
+	}
+
+	if err := h.annotationRepo.Create(c.Request.Context(), annotation); err != nil {
+		log.Printf("Failed to create annotation on trace %s: %v", traceID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Failed to create annotation",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, annotation)
+}
+
+// ListAnnotations returns the annotations on a trace
+// @Summary      List trace annotations
+// @Description  List the feedback recorded on a trace, oldest first
+// @Tags         annotations
+// @Produce      json
+// @Param        projectID  path      string  true  "Project ID"
+// @Param        traceID    path      string  true  "Trace ID"
+// @Success      200        {object}  map[string]interface{} "Annotations"
+// @Failure      401        {object}  map[string]interface{} "Unauthorized"
+// @Failure      404        {object}  map[string]interface{} "Project not found"
+// @Failure      500        {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/traces/{traceID}/annotations [get]
+func (h *AnnotationHandler) ListAnnotations(c *gin.Context) {
+	project := loadProject(c, h.retentionRepo)
+	if project == nil {
+		return
+	}
+
+	annotations, err := h.annotationRepo.ListByTrace(c.Request.Context(), project.ProjectID, c.Param("traceID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Failed to fetch annotations",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"annotations": annotations,
+		"count":       len(annotations),
+	})
+}
+
+// UpdateAnnotation replaces the content of an annotation
+// @Summary      Update annotation
+// @Description  Replace the rating, scores, comment, and labels of an annotation. Only its author can edit a member annotation; end-user feedback cannot be edited.
+// @Tags         annotations
+// @Accept       json
+// @Produce      json
+// @Param        projectID     path      string                  true  "Project ID"
+// @Param        traceID       path      string                  true  "Trace ID"
+// @Param        annotationID  path      string                  true  "Annotation ID"
+// @Param        request       body      map[string]interface{}  true  "rating, scores, comment, labels"
+// @Success      200           {object}  map[string]interface{} "Annotation"
+// @Failure      400           {object}  map[string]interface{} "Invalid request"
+// @Failure      401           {object}  map[string]interface{} "Unauthorized"
+// @Failure      403           {object}  map[string]interface{} "Not the author"
+// @Failure      404           {object}  map[string]interface{} "Annotation not found"
+// @Failure      500           {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/traces/{traceID}/annotations/{annotationID} [put]
+func (h *AnnotationHandler) UpdateAnnotation(c *gin.Context) {
+	project := loadProject(c, h.retentionRepo)
+	if project == nil {
+		return
+	}
+
+	var req annotationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("[UpdateAnnotation] binding error: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": "Invalid request parameters",
+			},
+		})
+		return
+	}
+	if reason := req.validate(); reason != "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": reason,
+			},
+		})
+		return
+	}
+
+	annotation := h.loadAnnotation(c, project.ProjectID)
+	if annotation == nil {
+		return
+	}
+
+	userID := c.GetString("user_id")
+	if userID == "" || annotation.AuthorID != userID {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": gin.H{
+				"code":    "FORBIDDEN",
+				"message": "Only the author can edit an annotation",
+			},
+		})
+		return
+	}
+
+	annotation.Rating = req.Rating
+	annotation.Scores = req.Scores
+	annotation.Comment = req.Comment
+	annotation.Labels = req.Labels
+	if err := h.annotationRepo.Update(c.Request.Context(), annotation); err != nil {
+		log.Printf("Failed to update annotation %s: %v", annotation.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Failed to update annotation",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, annotation)
+}
+
+// DeleteAnnotation deletes an annotation
+// @Summary      Delete annotation
+// @Description  Delete an annotation. Members can delete their own annotations; admins can delete any.
+// @Tags         annotations
+// @Produce      json
+// @Param        projectID     path      string  true  "Project ID"
+// @Param        traceID       path      string  true  "Trace ID"
+// @Param        annotationID  path      string  true  "Annotation ID"
+// @Success      204           "No content"
+// @Failure      401           {object}  map[string]interface{} "Unauthorized"
+// @Failure      403           {object}  map[string]interface{} "Forbidden"
+// @Failure      404           {object}  map[string]interface{} "Annotation not found"
+// @Failure      500           {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/traces/{traceID}/annotations/{annotationID} [delete]
+func (h *AnnotationHandler) DeleteAnnotation(c *gin.Context) {
+	project := loadProject(c, h.retentionRepo)
+	if project == nil {
+		return
+	}
+
+	annotation := h.loadAnnotation(c, project.ProjectID)
+	if annotation == nil {
+		return
+	}
+
+	userID := c.GetString("user_id")
+	isAuthor := userID != "" && annotation.AuthorID == userID
+	if !isAuthor && c.GetString("role") != string(storage.UserRoleAdmin) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": gin.H{
+				"code":    "FORBIDDEN",
+				"message": "Only the author or an admin can delete an annotation",
+			},
+		})
+		return
+	}
+
+	if err := h.annotationRepo.Delete(c.Request.Context(), project.ProjectID, annotation.ID); err != nil && err != storage.ErrNotFound {
+		log.Printf("Failed to delete annotation %s: %v", annotation.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Failed to delete annotation",
+			},
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetFeedbackAnalytics aggregates the project's annotations
+// @Summary      Feedback analytics
+// @Description  Aggregate the project's annotations created in a time range: thumbs up and down counts, per-criterion score statistics, and the most common labels
+// @Tags         annotations
+// @Produce      json
+// @Param        projectID  path      string  true   "Project ID"
+// @Param        from       query     string  false  "Earliest annotation time, inclusive (RFC 3339)"
+// @Param        to         query     string  false  "Latest annotation time, exclusive (RFC 3339)"
+// @Success      200        {object}  map[string]interface{} "Feedback summary"
+// @Failure      400        {object}  map[string]interface{} "Invalid time range"
+// @Failure      401        {object}  map[string]interface{} "Unauthorized"
+// @Failure      404        {object}  map[string]interface{} "Project not found"
+// @Failure      500        {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/analytics/feedback [get]
+func (h *AnnotationHandler) GetFeedbackAnalytics(c *gin.Context) {
+	project := loadProject(c, h.retentionRepo)
+	if project == nil {
+		return
+	}
+
+	from, to, ok := parseTimeRange(c)
+	if !ok {
+		return
+	}
+
+	summary, err := h.annotationRepo.Summarize(c.Request.Context(), project.ProjectID, from, to, maxTopLabels)
+	if err != nil {
+		log.Printf("Failed to summarize annotations for project %s: %v", project.ProjectID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Failed to fetch feedback analytics",
+			},
+		})
+		return
+	}
+
+	var upRate *float64
+	if rated := summary.Up + summary.Down; rated > 0 {
+		rate := float64(summary.Up) / float64(rated)
+		upRate = &rate
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"summary": summary,
+		"up_rate": upRate,
+	})
+}
+
+// traceExists writes a not found response and returns false if the trace
+// does not exist
+func (h *AnnotationHandler) traceExists(c *gin.Context, projectID, traceID string) bool {
+	_, err := h.traceRepo.Get(c.Request.Context(), projectID, traceID)
+	if err == nil {
+		return true
+	}
+	if err == storage.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": gin.H{
+				"code":    "NOT_FOUND",
+				"message": "Trace not found",
+			},
+		})
+		return false
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"error": gin.H{
+			"code":    "INTERNAL_ERROR",
+			"message": "Failed to fetch trace",
+		},
+	})
+	return false
+}
+
+// loadAnnotation fetches the annotation named in the path, checking that it
+// belongs to the trace in the path. It writes an error response and returns
+// nil if it cannot.
+func (h *AnnotationHandler) loadAnnotation(c *gin.Context, projectID string) *storage.Annotation {
+	annotation, err := h.annotationRepo.Get(c.Request.Context(), projectID, c.Param("annotationID"))
+	if err == nil && annotation.TraceID != c.Param("traceID") {
+		err = storage.ErrNotFound
+	}
+	if err != nil {
+		if err == storage.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": gin.H{
+					"code":    "NOT_FOUND",
+					"message": "Annotation not found",
+				},
+			})
+			return nil
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Failed to fetch annotation",
+			},
+		})
+		return nil
+	}
+	return annotation
+}
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/regrada-ai/regrada-be/internal/audit"
 	"github.com/regrada-ai/regrada-be/internal/storage"
 )
 
@@ -19,10 +20,11 @@ var defaultAPIKeyScopes = []string{"traces:write", "tests:write", "projects:read
 type APIKeyHandler struct {
 	apiKeyRepo storage.APIKeyRepository
 	orgRepo    storage.OrganizationRepository
+	auditor    *Auditor
 }
 
-func NewAPIKeyHandler(apiKeyRepo storage.APIKeyRepository, orgRepo storage.OrganizationRepository) *APIKeyHandler {
-	return &APIKeyHandler{apiKeyRepo: apiKeyRepo, orgRepo: orgRepo}
+func NewAPIKeyHandler(apiKeyRepo storage.APIKeyRepository, orgRepo storage.OrganizationRepository, auditor *Auditor) *APIKeyHandler {
+	return &APIKeyHandler{apiKeyRepo: apiKeyRepo, orgRepo: orgRepo, auditor: auditor}
 }
 
 type apiKeyResponse struct {
@@ -38,6 +40,18 @@ type apiKeyResponse struct {
 	RevokedAt    *time.Time `json:"revoked_at,omitempty"`
 }
 
+// apiKeyAuditFields returns the fields of an API key recorded in the audit
+// log
+func apiKeyAuditFields(key *storage.APIKey) audit.Fields {
+	return audit.Fields{
+		"name":       key.Name,
+		"key_prefix": key.KeyPrefix,
+		"scopes":     key.Scopes,
+		"expires_at": key.ExpiresAt,
+		"revoked":    key.RevokedAt != nil,
+	}
+}
+
 func toAPIKeyResponse(key *storage.APIKey) apiKeyResponse {
 	return apiKeyResponse{
 		ID:           key.ID,
@@ -202,6 +216,8 @@ func (h *APIKeyHandler) CreateAPIKey(c *gin.Context) {
 		return
 	}
 
+	h.auditor.Record(c, orgID, audit.ActionAPIKeyCreate, audit.TargetAPIKey, apiKey.ID, nil, apiKeyAuditFields(apiKey))
+
 	c.JSON(http.StatusCreated, gin.H{
 		"api_key": toAPIKeyResponse(apiKey),
 		"secret":  secret,
@@ -456,6 +472,11 @@ func (h *APIKeyHandler) RevokeAPIKey(c *gin.Context) {
 		return
 	}
 
+	before := apiKeyAuditFields(key)
+	after := apiKeyAuditFields(key)
+	after["revoked"] = true
+	h.auditor.Record(c, orgID, audit.ActionAPIKeyRevoke, audit.TargetAPIKey, keyID, before, after)
+
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 	})
@@ -526,6 +547,8 @@ func (h *APIKeyHandler) DeleteAPIKey(c *gin.Context) {
 		return
 	}
 
+	h.auditor.Record(c, orgID, audit.ActionAPIKeyDelete, audit.TargetAPIKey, keyID, apiKeyAuditFields(key), nil)
+
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 	})
@@ -0,0 +1,392 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/regrada-ai/regrada-be/internal/archive"
+	"github.com/regrada-ai/regrada-be/internal/retention"
+	"github.com/regrada-ai/regrada-be/internal/storage"
+)
+
+const (
+	defaultRestoreKeepDays = 7
+	maxRestoreKeepDays     = 90
+	maxRestoresListed      = 50
+)
+
+type ArchiveHandler struct {
+	archiveRepo   storage.ArchiveRepository
+	retentionRepo storage.RetentionRepository
+	archiver      *archive.Archiver
+}
+
+func NewArchiveHandler(archiveRepo storage.ArchiveRepository, retentionRepo storage.RetentionRepository, archiver *archive.Archiver) *ArchiveHandler {
+	return &ArchiveHandler{
+		archiveRepo:   archiveRepo,
+		retentionRepo: retentionRepo,
+		archiver:      archiver,
+	}
+}
+
+type archivePolicyRequest struct {
+	// ArchiveAfterDays sets the threshold; null disables archiving
+	ArchiveAfterDays *int `json:"archive_after_days"`
+}
+
+type restoreRequest struct {
+	From     time.Time `json:"from" binding:"required"`
+	To       time.Time `json:"to" binding:"required"`
+	KeepDays int       `json:"keep_days"`
+}
+
+// GetArchivePolicy returns the project's archive threshold
+// @Summary      Get archive policy
+// @Description  Get how many days traces stay in Postgres before being moved to cold archive files
+// @Tags         archives
+// @Produce      json
+// @Param        projectID  path      string  true  "Project ID"
+// @Success      200        {object}  map[string]interface{} "Archive policy"
+// @Failure      401        {object}  map[string]interface{} "Unauthorized"
+// @Failure      404        {object}  map[string]interface{} "Project not found"
+// @Failure      500        {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/archive-policy [get]
+func (h *ArchiveHandler) GetArchivePolicy(c *gin.Context) {
+	project := loadProject(c, h.retentionRepo)
+	if project == nil {
+		return
+	}
+
+	days, err := h.archiveRepo.GetPolicy(c.Request.Context(), project.ProjectID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Failed to fetch archive policy",
+			},
+		})
+		return
+	}
+
+	retentionDays, _ := retention.Effective(project)
+	c.JSON(http.StatusOK, gin.H{
+		"archive_after_days": days,
+		"retention_days":     retentionDays,
+	})
+}
+
+// UpdateArchivePolicy sets or clears the project's archive threshold
+// @Summary      Update archive policy
+// @Description  Set how many days traces stay in Postgres before being archived (less than the retention period), or null to disable archiving
+// @Tags         archives
+// @Accept       json
+// @Produce      json
+// @Param        projectID  path      string                  true  "Project ID"
+// @Param        request    body      map[string]interface{}  true  "Archive threshold in days"
+// @Success      200        {object}  map[string]interface{} "Updated archive policy"
+// @Failure      400        {object}  map[string]interface{} "Invalid request"
+// @Failure      401        {object}  map[string]interface{} "Unauthorized"
+// @Failure      403        {object}  map[string]interface{} "Forbidden"
+// @Failure      404        {object}  map[string]interface{} "Project not found"
+// @Failure      500        {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/archive-policy [put]
+func (h *ArchiveHandler) UpdateArchivePolicy(c *gin.Context) {
+	if c.GetString("role") != string(storage.UserRoleAdmin) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": gin.H{
+				"code":    "FORBIDDEN",
+				"message": "Admin role required to update the archive policy",
+			},
+		})
+		return
+	}
+
+	project := loadProject(c, h.retentionRepo)
+	if project == nil {
+		return
+	}
+
+	var req archivePolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("[UpdateArchivePolicy] binding error: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": "Invalid request parameters",
+			},
+		})
+		return
+	}
+
+	// Traces past the retention period are purged, so archiving them is pointless
+	retentionDays, _ := retention.Effective(project)
+	if req.ArchiveAfterDays != nil && (*req.ArchiveAfterDays < 1 || *req.ArchiveAfterDays >= retentionDays) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": fmt.Sprintf("archive_after_days must be between 1 and %d (less than the %d-day retention period)", retentionDays-1, retentionDays),
+			},
+		})
+		return
+	}
+
+	if err := h.archiveRepo.SetPolicy(c.Request.Context(), project.ProjectID, req.ArchiveAfterDays); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Failed to update archive policy",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"archive_after_days": req.ArchiveAfterDays,
+		"retention_days":     retentionDays,
+	})
+}
+
+// ListArchives returns the manifest of the project's archive files
+// @Summary      List trace archives
+// @Description  List archive files holding traces moved out of Postgres, optionally limited to those overlapping a time range
+// @Tags         archives
+// @Produce      json
+// @Param        projectID  path      string  true   "Project ID"
+// @Param        from       query     string  false  "Start of the time range (RFC 3339)"
+// @Param        to         query     string  false  "End of the time range (RFC 3339)"
+// @Success      200        {object}  map[string]interface{} "Archive manifest"
+// @Failure      400        {object}  map[string]interface{} "Invalid request"
+// @Failure      401        {object}  map[string]interface{} "Unauthorized"
+// @Failure      404        {object}  map[string]interface{} "Project not found"
+// @Failure      500        {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/archives [get]
+func (h *ArchiveHandler) ListArchives(c *gin.Context) {
+	project := loadProject(c, h.retentionRepo)
+	if project == nil {
+		return
+	}
+
+	from, to, ok := parseTimeRange(c)
+	if !ok {
+		return
+	}
+
+	archives, err := h.archiveRepo.ListArchives(c.Request.Context(), project.ProjectID, from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Failed to fetch archives",
+			},
+		})
+		return
+	}
+
+	var traces, size int64
+	for _, entry := range archives {
+		traces += int64(entry.TraceCount)
+		size += entry.SizeBytes
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"archives":     archives,
+		"count":        len(archives),
+		"total_traces": traces,
+		"total_bytes":  size,
+	})
+}
+
+// CreateRestore requests that an archived time range be loaded back into Postgres
+// @Summary      Restore archived traces
+// @Description  Load archived traces in [from, to) back into the queryable store. The restore runs in the background;
+// @Description  restored traces are kept for keep_days (default 7, max 90) before being deleted again; their archives are kept.
+// @Tags         archives
+// @Accept       json
+// @Produce      json
+// @Param        projectID  path      string                  true  "Project ID"
+// @Param        request    body      map[string]interface{}  true  "Time range and keep_days"
+// @Success      202        {object}  storage.ArchiveRestore "Restore queued"
+// @Failure      400        {object}  map[string]interface{} "Invalid request"
+// @Failure      401        {object}  map[string]interface{} "Unauthorized"
+// @Failure      403        {object}  map[string]interface{} "Forbidden"
+// @Failure      404        {object}  map[string]interface{} "Project not found"
+// @Failure      500        {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/archives/restores [post]
+func (h *ArchiveHandler) CreateRestore(c *gin.Context) {
+	if c.GetString("role") == string(storage.UserRoleViewer) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": gin.H{
+				"code":    "FORBIDDEN",
+				"message": "Viewers cannot restore archives",
+			},
+		})
+		return
+	}
+
+	project := loadProject(c, h.retentionRepo)
+	if project == nil {
+		return
+	}
+
+	var req restoreRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("[CreateRestore] binding error: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": "Invalid request parameters",
+			},
+		})
+		return
+	}
+
+	if req.KeepDays == 0 {
+		req.KeepDays = defaultRestoreKeepDays
+	}
+	reason := ""
+	switch {
+	case !req.From.Before(req.To):
+		reason = "from must be before to"
+	case req.KeepDays < 1 || req.KeepDays > maxRestoreKeepDays:
+		reason = fmt.Sprintf("keep_days must be between 1 and %d", maxRestoreKeepDays)
+	}
+	if reason != "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": reason,
+			},
+		})
+		return
+	}
+
+	restore := &storage.ArchiveRestore{
+		ProjectID:   project.ProjectID,
+		RequestedBy: c.GetString("user_id"),
+		RangeStart:  req.From,
+		RangeEnd:    req.To,
+		KeepDays:    req.KeepDays,
+	}
+	if err := h.archiver.RequestRestore(c.Request.Context(), restore); err != nil {
+		log.Printf("Failed to create archive restore for project %s: %v", project.ProjectID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Failed to create restore",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, restore)
+}
+
+// ListRestores returns the project's most recent archive restores
+// @Summary      List archive restores
+// @Description  List the project's most recent archive restores and their progress
+// @Tags         archives
+// @Produce      json
+// @Param        projectID  path      string  true  "Project ID"
+// @Success      200        {object}  map[string]interface{} "Restores"
+// @Failure      401        {object}  map[string]interface{} "Unauthorized"
+// @Failure      404        {object}  map[string]interface{} "Project not found"
+// @Failure      500        {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/archives/restores [get]
+func (h *ArchiveHandler) ListRestores(c *gin.Context) {
+	project := loadProject(c, h.retentionRepo)
+	if project == nil {
+		return
+	}
+
+	restores, err := h.archiveRepo.ListRestores(c.Request.Context(), project.ProjectID, maxRestoresListed)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Failed to fetch restores",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"restores": restores,
+		"count":    len(restores),
+	})
+}
+
+// GetRestore returns the status of an archive restore
+// @Summary      Get archive restore
+// @Description  Get the status and progress of an archive restore
+// @Tags         archives
+// @Produce      json
+// @Param        projectID  path      string  true  "Project ID"
+// @Param        restoreID  path      string  true  "Restore ID"
+// @Success      200        {object}  storage.ArchiveRestore "Restore"
+// @Failure      401        {object}  map[string]interface{} "Unauthorized"
+// @Failure      404        {object}  map[string]interface{} "Restore not found"
+// @Failure      500        {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/archives/restores/{restoreID} [get]
+func (h *ArchiveHandler) GetRestore(c *gin.Context) {
+	project := loadProject(c, h.retentionRepo)
+	if project == nil {
+		return
+	}
+
+	restore, err := h.archiveRepo.GetRestore(c.Request.Context(), project.ProjectID, c.Param("restoreID"))
+	if err != nil {
+		if err == storage.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": gin.H{
+					"code":    "NOT_FOUND",
+					"message": "Restore not found",
+				},
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Failed to fetch restore",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, restore)
+}
+
+// parseTimeRange reads optional RFC 3339 from and to query parameters. It
+// writes an error response and returns false if either is invalid.
+func parseTimeRange(c *gin.Context) (time.Time, time.Time, bool) {
+	var bounds [2]time.Time
+	for i, name := range []string{"from", "to"} {
+		value := c.Query(name)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": gin.H{
+					"code":    "INVALID_REQUEST",
+					"message": fmt.Sprintf("%s must be an RFC 3339 timestamp", name),
+				},
+			})
+			return time.Time{}, time.Time{}, false
+		}
+		bounds[i] = t
+	}
+	return bounds[0], bounds[1], true
+}
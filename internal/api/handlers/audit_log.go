@@ -0,0 +1,288 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/regrada-ai/regrada-be/internal/audit"
+	"github.com/regrada-ai/regrada-be/internal/storage"
+)
+
+const (
+	defaultAuditLogLimit = 50
+	maxAuditLogLimit     = 500
+
+	// maxAuditLogExport caps the entries in an export; narrow the time range
+	// to export more
+	maxAuditLogExport  = 10000
+	auditLogExportPage = 1000
+)
+
+// Auditor records administrative actions in the audit log
+type Auditor struct {
+	auditRepo  storage.AuditLogRepository
+	apiKeyRepo storage.APIKeyRepository
+}
+
+func NewAuditor(auditRepo storage.AuditLogRepository, apiKeyRepo storage.APIKeyRepository) *Auditor {
+	return &Auditor{
+		auditRepo:  auditRepo,
+		apiKeyRepo: apiKeyRepo,
+	}
+}
+
+// Record appends an entry for an action the request took on a target, with
+// the fields that changed between before and after. Failures are logged
+// rather than returned, since the action has already happened, and the entry
+// is written even if the client has disconnected.
+func (a *Auditor) Record(c *gin.Context, orgID, action, targetType, targetID string, before, after audit.Fields) {
+	ctx := context.WithoutCancel(c.Request.Context())
+	changes, err := audit.Diff(before, after)
+	if err != nil {
+		// Record the action without its changes rather than not at all
+		log.Printf("Failed to diff audit log entry %s on %s %s: %v", action, targetType, targetID, err)
+		changes = json.RawMessage("{}")
+	}
+
+	entry := &storage.AuditLogEntry{
+		OrganizationID: orgID,
+		Action:         action,
+		TargetType:     targetType,
+		TargetID:       targetID,
+		Changes:        changes,
+		IPAddress:      c.ClientIP(),
+		UserAgent:      c.Request.UserAgent(),
+	}
+	if userID := c.GetString("user_id"); userID != "" {
+		entry.ActorType = storage.AuditActorUser
+		entry.ActorID = userID
+		entry.ActorName = c.GetString("email")
+	} else {
+		entry.ActorType = storage.AuditActorAPIKey
+		if keyHash := c.GetString("api_key_hash"); keyHash != "" {
+			key, err := a.apiKeyRepo.GetByHash(ctx, keyHash)
+			if err != nil {
+				log.Printf("Failed to fetch API key for audit log entry %s: %v", action, err)
+			} else {
+				entry.ActorID = key.ID
+				entry.ActorName = key.Name
+			}
+		}
+	}
+
+	if err := a.auditRepo.Append(ctx, entry); err != nil {
+		log.Printf("Failed to record audit log entry %s on %s %s: %v", action, targetType, targetID, err)
+	}
+}
+
+type AuditLogHandler struct {
+	auditRepo storage.AuditLogRepository
+}
+
+func NewAuditLogHandler(auditRepo storage.AuditLogRepository) *AuditLogHandler {
+	return &AuditLogHandler{auditRepo: auditRepo}
+}
+
+// ListAuditLog lists an organization's audit log
+// @Summary      List audit log
+// @Description  List the organization's audit log, newest first: role changes, member removals, invites, API keys, organization updates, and project deletions, each with its actor, target, changed fields, IP address, and user agent. Requires the admin role.
+// @Tags         organizations
+// @Produce      json
+// @Param        orgID        path      string  true   "Organization ID"
+// @Param        actor_id     query     string  false  "Filter by acting user or API key ID"
+// @Param        action       query     string  false  "Filter by action, e.g. member.role_update"
+// @Param        target_type  query     string  false  "Filter by target type"
+// @Param        target_id    query     string  false  "Filter by target ID"
+// @Param        from         query     string  false  "Start of time range (RFC 3339)"
+// @Param        to           query     string  false  "End of time range (RFC 3339)"
+// @Param        limit        query     int     false  "Page size (default 50, max 500)"
+// @Param        offset       query     int     false  "Page offset"
+// @Success      200          {object}  map[string]interface{} "Audit log entries"
+// @Failure      400          {object}  map[string]interface{} "Invalid request"
+// @Failure      401          {object}  map[string]interface{} "Unauthorized"
+// @Failure      403          {object}  map[string]interface{} "Admin role required"
+// @Failure      500          {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/organizations/{orgID}/audit-log [get]
+func (h *AuditLogHandler) ListAuditLog(c *gin.Context) {
+	orgID, ok := requireAuditLogAccess(c)
+	if !ok {
+		return
+	}
+	filter, ok := parseAuditLogFilter(c)
+	if !ok {
+		return
+	}
+
+	limit, offset := defaultAuditLogLimit, 0
+	reason := ""
+	if value := c.Query("limit"); value != "" {
+		var err error
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxAuditLogLimit {
+			reason = fmt.Sprintf("limit must be between 1 and %d", maxAuditLogLimit)
+		}
+	}
+	if value := c.Query("offset"); value != "" {
+		var err error
+		offset, err = strconv.Atoi(value)
+		if err != nil || offset < 0 {
+			reason = "offset must be a non-negative integer"
+		}
+	}
+	if reason != "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": reason,
+			},
+		})
+		return
+	}
+
+	entries, err := h.auditRepo.List(c.Request.Context(), orgID, filter, limit, offset)
+	if err != nil {
+		writeAuditLogError(c, err, "Failed to fetch audit log")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"entries": entries,
+		"count":   len(entries),
+	})
+}
+
+// ExportAuditLog downloads an organization's audit log
+// @Summary      Export audit log
+// @Description  Download the organization's audit log entries matching the list filters, newest first, as CSV or JSON Lines. At most 10000 entries are exported; narrow the time range to export more. Requires the admin role.
+// @Tags         organizations
+// @Produce      text/csv
+// @Produce      application/x-ndjson
+// @Param        orgID        path      string  true   "Organization ID"
+// @Param        format       query     string  false  "csv (default) or jsonl"
+// @Param        actor_id     query     string  false  "Filter by acting user or API key ID"
+// @Param        action       query     string  false  "Filter by action"
+// @Param        target_type  query     string  false  "Filter by target type"
+// @Param        target_id    query     string  false  "Filter by target ID"
+// @Param        from         query     string  false  "Start of time range (RFC 3339)"
+// @Param        to           query     string  false  "End of time range (RFC 3339)"
+// @Success      200          {file}    file    "Audit log export"
+// @Failure      400          {object}  map[string]interface{} "Invalid request"
+// @Failure      401          {object}  map[string]interface{} "Unauthorized"
+// @Failure      403          {object}  map[string]interface{} "Admin role required"
+// @Failure      500          {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/organizations/{orgID}/audit-log/export [get]
+func (h *AuditLogHandler) ExportAuditLog(c *gin.Context) {
+	orgID, ok := requireAuditLogAccess(c)
+	if !ok {
+		return
+	}
+	filter, ok := parseAuditLogFilter(c)
+	if !ok {
+		return
+	}
+	format := c.DefaultQuery("format", "csv")
+	if format != "csv" && format != "jsonl" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": "format must be csv or jsonl",
+			},
+		})
+		return
+	}
+
+	var entries []*storage.AuditLogEntry
+	for len(entries) < maxAuditLogExport {
+		page, err := h.auditRepo.List(c.Request.Context(), orgID, filter, auditLogExportPage, len(entries))
+		if err != nil {
+			writeAuditLogError(c, err, "Failed to fetch audit log")
+			return
+		}
+		entries = append(entries, page...)
+		if len(page) < auditLogExportPage {
+			break
+		}
+	}
+
+	var buf bytes.Buffer
+	contentType := "text/csv"
+	if format == "jsonl" {
+		contentType = "application/x-ndjson"
+		encoder := json.NewEncoder(&buf)
+		for _, entry := range entries {
+			if err := encoder.Encode(entry); err != nil {
+				writeAuditLogError(c, err, "Failed to export audit log")
+				return
+			}
+		}
+	} else if err := audit.WriteCSV(&buf, entries); err != nil {
+		writeAuditLogError(c, err, "Failed to export audit log")
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="audit-log.%s"`, format))
+	c.Data(http.StatusOK, contentType, buf.Bytes())
+}
+
+// requireAuditLogAccess returns the organization of the request if the
+// caller is one of its admins, or writes an error response
+func requireAuditLogAccess(c *gin.Context) (string, bool) {
+	orgID := c.Param("orgID")
+	if c.GetString("organization_id") != orgID {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": gin.H{
+				"code":    "FORBIDDEN",
+				"message": "Cannot view the audit log of a different organization",
+			},
+		})
+		return "", false
+	}
+	if c.GetString("role") != string(storage.UserRoleAdmin) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": gin.H{
+				"code":    "FORBIDDEN",
+				"message": "Admin role required to view the audit log",
+			},
+		})
+		return "", false
+	}
+	return orgID, true
+}
+
+// parseAuditLogFilter reads the audit log filters from the query, or writes
+// an error response
+func parseAuditLogFilter(c *gin.Context) (storage.AuditLogFilter, bool) {
+	from, to, ok := parseTimeRange(c)
+	if !ok {
+		return storage.AuditLogFilter{}, false
+	}
+	return storage.AuditLogFilter{
+		ActorID:    c.Query("actor_id"),
+		Action:     c.Query("action"),
+		TargetType: c.Query("target_type"),
+		TargetID:   c.Query("target_id"),
+		From:       from,
+		To:         to,
+	}, true
+}
+
+// writeAuditLogError writes the response for an audit log repository error
+func writeAuditLogError(c *gin.Context, err error, message string) {
+	log.Printf("%s: %v", message, err)
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"error": gin.H{
+			"code":    "INTERNAL_ERROR",
+			"message": message,
+		},
+	})
+}
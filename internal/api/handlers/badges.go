@@ -0,0 +1,379 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"github.com/regrada-ai/regrada-be/internal/badge"
+	"github.com/regrada-ai/regrada-be/internal/storage"
+)
+
+const (
+	// badgeCacheTTL is how long a rendered badge is served from Redis, and
+	// how long clients may cache it
+	badgeCacheTTL = time.Minute
+
+	defaultBadgeLabel = "regrada"
+)
+
+// Badge kinds, as the last segment of a badge URL
+const (
+	badgePassRate    = "pass-rate.svg"
+	badgeStatus      = "status.svg"
+	badgeRegressions = "regressions.svg"
+)
+
+type BadgeHandler struct {
+	badgeRepo      storage.BadgeRepository
+	testRunRepo    storage.TestRunRepository
+	regressionRepo storage.RegressionRepository
+	retentionRepo  storage.RetentionRepository
+	redisClient    *redis.Client
+	baseURL        string // public API URL badge URLs are built on
+}
+
+func NewBadgeHandler(
+	badgeRepo storage.BadgeRepository,
+	testRunRepo storage.TestRunRepository,
+	regressionRepo storage.RegressionRepository,
+	retentionRepo storage.RetentionRepository,
+	redisClient *redis.Client,
+	baseURL string,
+) *BadgeHandler {
+	return &BadgeHandler{
+		badgeRepo:      badgeRepo,
+		testRunRepo:    testRunRepo,
+		regressionRepo: regressionRepo,
+		retentionRepo:  retentionRepo,
+		redisClient:    redisClient,
+		baseURL:        strings.TrimSuffix(baseURL, "/"),
+	}
+}
+
+type createBadgeTokenRequest struct {
+	Name string `json:"name" binding:"max=255"`
+}
+
+// CreateBadgeToken creates a badge token for the project
+// @Summary      Create badge token
+// @Description  Create a token for the project's public status badges. The token is part of the badge URLs, which need no authentication, and is only returned once. Badges show the latest completed run on a branch (?branch=, default main): pass-rate.svg, status.svg, or regressions.svg for the open regression count. ?label= overrides the badge label.
+// @Tags         badges
+// @Accept       json
+// @Produce      json
+// @Param        projectID  path      string                  true   "Project ID"
+// @Param        request    body      map[string]interface{}  false  "name"
+// @Success      201        {object}  map[string]interface{} "Badge token, the token, and the badge URLs"
+// @Failure      400        {object}  map[string]interface{} "Invalid request"
+// @Failure      401        {object}  map[string]interface{} "Unauthorized"
+// @Failure      403        {object}  map[string]interface{} "Viewers cannot manage badges"
+// @Failure      404        {object}  map[string]interface{} "Project not found"
+// @Failure      500        {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/badges [post]
+func (h *BadgeHandler) CreateBadgeToken(c *gin.Context) {
+	if !requireEditor(c, "Viewers cannot manage badges") {
+		return
+	}
+
+	var req createBadgeTokenRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			log.Printf("[CreateBadgeToken] binding error: %v", err)
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": gin.H{
+					"code":    "INVALID_REQUEST",
+					"message": "Invalid request parameters",
+				},
+			})
+			return
+		}
+	}
+
+	project := loadProject(c, h.retentionRepo)
+	if project == nil {
+		return
+	}
+
+	secret, hash, err := generateBadgeToken()
+	if err != nil {
+		writeBadgeError(c, err, "", "Failed to generate badge token")
+		return
+	}
+	token := &storage.BadgeToken{
+		ProjectID:   project.ProjectID,
+		TokenHash:   hash,
+		TokenPrefix: secret[:16],
+		Name:        req.Name,
+		CreatedBy:   c.GetString("user_id"),
+	}
+	if err := h.badgeRepo.Create(c.Request.Context(), token); err != nil {
+		writeBadgeError(c, err, "", "Failed to create badge token")
+		return
+	}
+
+	base := h.baseURL + "/v1/badges/" + secret + "/"
+	c.JSON(http.StatusCreated, gin.H{
+		"badge_token": token,
+		"token":       secret,
+		"urls": gin.H{
+			"pass_rate":   base + badgePassRate,
+			"status":      base + badgeStatus,
+			"regressions": base + badgeRegressions,
+		},
+	})
+}
+
+// ListBadgeTokens lists the project's badge tokens
+// @Summary      List badge tokens
+// @Description  List the project's badge tokens, including revoked ones. Tokens are identified by their prefix.
+// @Tags         badges
+// @Produce      json
+// @Param        projectID  path      string  true  "Project ID"
+// @Success      200        {object}  map[string]interface{} "Badge tokens"
+// @Failure      401        {object}  map[string]interface{} "Unauthorized"
+// @Failure      404        {object}  map[string]interface{} "Project not found"
+// @Failure      500        {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/badges [get]
+func (h *BadgeHandler) ListBadgeTokens(c *gin.Context) {
+	project := loadProject(c, h.retentionRepo)
+	if project == nil {
+		return
+	}
+
+	tokens, err := h.badgeRepo.List(c.Request.Context(), project.ProjectID)
+	if err != nil {
+		writeBadgeError(c, err, "", "Failed to fetch badge tokens")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"badge_tokens": tokens,
+		"count":        len(tokens),
+	})
+}
+
+// RevokeBadgeToken revokes a badge token
+// @Summary      Revoke badge token
+// @Description  Revoke a badge token. Its badge URLs stop working at once.
+// @Tags         badges
+// @Param        projectID  path  string  true  "Project ID"
+// @Param        badgeID    path  string  true  "Badge token ID"
+// @Success      204        "Badge token revoked"
+// @Failure      401        {object}  map[string]interface{} "Unauthorized"
+// @Failure      403        {object}  map[string]interface{} "Viewers cannot manage badges"
+// @Failure      404        {object}  map[string]interface{} "Project or unrevoked badge token not found"
+// @Failure      500        {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/badges/{badgeID} [delete]
+func (h *BadgeHandler) RevokeBadgeToken(c *gin.Context) {
+	if !requireEditor(c, "Viewers cannot manage badges") {
+		return
+	}
+	project := loadProject(c, h.retentionRepo)
+	if project == nil {
+		return
+	}
+
+	ctx := c.Request.Context()
+	token, err := h.badgeRepo.Revoke(ctx, project.ProjectID, c.Param("badgeID"))
+	if err != nil {
+		writeBadgeError(c, err, "Badge token not found", "Failed to revoke badge token")
+		return
+	}
+	h.purgeCache(ctx, token.TokenHash)
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetBadge renders a status badge
+// @Summary      Get status badge
+// @Description  Render an SVG badge for the latest completed test run on a branch: its pass rate, its status (passing, warnings, or failing), or the number of open regressions located on the branch. Needs no authentication; the badge token in the URL grants access. Badges are cached for a minute and carry an ETag for conditional requests.
+// @Tags         badges
+// @Produce      image/svg+xml
+// @Param        token          path      string  true   "Badge token"
+// @Param        badge          path      string  true   "pass-rate.svg, status.svg, or regressions.svg"
+// @Param        branch         query     string  false  "Branch (default main)"
+// @Param        label          query     string  false  "Badge label (default regrada)"
+// @Param        If-None-Match  header    string  false  "ETag of the cached badge"
+// @Success      200            {string}  string  "SVG badge"
+// @Success      304            "Badge not modified"
+// @Failure      400            {object}  map[string]interface{} "Invalid request"
+// @Failure      404            {object}  map[string]interface{} "Badge not found"
+// @Failure      500            {object}  map[string]interface{} "Internal server error"
+// @Router       /v1/badges/{token}/{badge} [get]
+func (h *BadgeHandler) GetBadge(c *gin.Context) {
+	kind := c.Param("badge")
+	branch := c.DefaultQuery("branch", "main")
+	label := c.DefaultQuery("label", defaultBadgeLabel)
+	reason := ""
+	switch {
+	case kind != badgePassRate && kind != badgeStatus && kind != badgeRegressions:
+		writeBadgeError(c, storage.ErrNotFound, "Badge not found", "")
+		return
+	case len(branch) > 255:
+		reason = "branch must be at most 255 characters"
+	case label == "" || len(label) > 64:
+		reason = "label must be 1-64 characters"
+	}
+	if reason != "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": reason,
+			},
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	checksum := sha256.Sum256([]byte(c.Param("token")))
+	tokenHash := hex.EncodeToString(checksum[:])
+	cacheKey := badgeCacheKey(tokenHash) + kind + ":" + branch + ":" + label
+
+	svg, err := h.redisClient.Get(ctx, cacheKey).Bytes()
+	if err != nil {
+		if err != redis.Nil {
+			log.Printf("Failed to read cached badge: %v", err)
+		}
+
+		token, err := h.badgeRepo.GetByHash(ctx, tokenHash)
+		if err != nil {
+			writeBadgeError(c, err, "Badge not found", "Failed to fetch badge token")
+			return
+		}
+		svg, err = h.render(ctx, token.ProjectID, kind, branch, label)
+		if err != nil {
+			writeBadgeError(c, err, "", "Failed to render badge")
+			return
+		}
+		if err := h.redisClient.Set(ctx, cacheKey, svg, badgeCacheTTL).Err(); err != nil {
+			log.Printf("Failed to cache badge: %v", err)
+		}
+	}
+
+	digest := sha256.Sum256(svg)
+	etag := `"` + hex.EncodeToString(digest[:16]) + `"`
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", int(badgeCacheTTL.Seconds())))
+	if etagMatches(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Data(http.StatusOK, "image/svg+xml", svg)
+}
+
+// render renders a badge of the project's latest run on the branch
+func (h *BadgeHandler) render(ctx context.Context, projectID, kind, branch, label string) ([]byte, error) {
+	if kind == badgeRegressions {
+		open, err := h.regressionRepo.CountOpen(ctx, projectID, branch)
+		if err != nil {
+			return nil, err
+		}
+		if open == 0 {
+			return badge.RenderSVG(label, "no regressions", badge.ColorGreen), nil
+		}
+		message := fmt.Sprintf("%d regressions", open)
+		if open == 1 {
+			message = "1 regression"
+		}
+		return badge.RenderSVG(label, message, badge.ColorRed), nil
+	}
+
+	run, err := h.testRunRepo.Latest(ctx, projectID, branch, time.Now(), "")
+	if err == storage.ErrNotFound {
+		return badge.RenderSVG(label, "no runs", badge.ColorGray), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if kind == badgePassRate {
+		if run.TotalCases == 0 {
+			return badge.RenderSVG(label, "no cases", badge.ColorGray), nil
+		}
+		rate := float64(run.PassedCases) / float64(run.TotalCases)
+		message := fmt.Sprintf("%.0f%% passing", rate*100)
+		if rate < 1 && rate >= 0.995 {
+			// Don't round a failing run up to 100%
+			message = "99% passing"
+		}
+		return badge.RenderSVG(label, message, badge.PassRateColor(rate)), nil
+	}
+
+	switch {
+	case run.FailedCases > 0:
+		return badge.RenderSVG(label, "failing", badge.ColorRed), nil
+	case run.WarnedCases > 0:
+		return badge.RenderSVG(label, "warnings", badge.ColorYellow), nil
+	default:
+		return badge.RenderSVG(label, "passing", badge.ColorGreen), nil
+	}
+}
+
+// purgeCache drops the cached badges of a token
+func (h *BadgeHandler) purgeCache(ctx context.Context, tokenHash string) {
+	iter := h.redisClient.Scan(ctx, 0, badgeCacheKey(tokenHash)+"*", 100).Iterator()
+	var keys []string
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	if err := iter.Err(); err != nil {
+		log.Printf("Failed to list cached badges: %v", err)
+		return
+	}
+	if len(keys) > 0 {
+		if err := h.redisClient.Del(ctx, keys...).Err(); err != nil {
+			log.Printf("Failed to purge cached badges: %v", err)
+		}
+	}
+}
+
+func badgeCacheKey(tokenHash string) string {
+	return "badge:" + tokenHash + ":"
+}
+
+// generateBadgeToken returns a new badge token and its hash
+func generateBadgeToken() (secret, hash string, err error) {
+	randomBytes := make([]byte, 24)
+	if _, err = rand.Read(randomBytes); err != nil {
+		return "", "", err
+	}
+
+	secret = "rg_badge_" + base64.RawURLEncoding.EncodeToString(randomBytes)
+	checksum := sha256.Sum256([]byte(secret))
+	return secret, hex.EncodeToString(checksum[:]), nil
+}
+
+// writeBadgeError writes the response for a badge repository error
+func writeBadgeError(c *gin.Context, err error, notFound, message string) {
+	if err == storage.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": gin.H{
+				"code":    "NOT_FOUND",
+				"message": notFound,
+			},
+		})
+		return
+	}
+
+	log.Printf("%s: %v", message, err)
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"error": gin.H{
+			"code":    "INTERNAL_ERROR",
+			"message": message,
+		},
+	})
+}
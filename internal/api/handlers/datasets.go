@@ -0,0 +1,773 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.yaml.in/yaml/v3"
+
+	"github.com/regrada-ai/regrada-be/internal/domain"
+	"github.com/regrada-ai/regrada-be/internal/storage"
+)
+
+const (
+	maxDatasetTraceIDs   = 500
+	defaultDatasetQuery  = 100
+	maxDatasetQueryLimit = 1000
+	datasetCaseIDLength  = 8
+)
+
+type DatasetHandler struct {
+	datasetRepo   storage.DatasetRepository
+	traceRepo     storage.TraceRepository
+	retentionRepo storage.RetentionRepository
+}
+
+func NewDatasetHandler(datasetRepo storage.DatasetRepository, traceRepo storage.TraceRepository, retentionRepo storage.RetentionRepository) *DatasetHandler {
+	return &DatasetHandler{
+		datasetRepo:   datasetRepo,
+		traceRepo:     traceRepo,
+		retentionRepo: retentionRepo,
+	}
+}
+
+type datasetRequest struct {
+	Name        string `json:"name" binding:"required,max=255"`
+	Description string `json:"description"`
+}
+
+type updateDatasetRequest struct {
+	Name        *string `json:"name" binding:"omitempty,min=1,max=255"`
+	Description *string `json:"description"`
+}
+
+type addDatasetItemsRequest struct {
+	// TraceIDs adds specific traces
+	TraceIDs []string `json:"trace_ids"`
+	// Query adds the most recent traces matching trace search filters
+	Query *storage.TraceFilter `json:"query"`
+	Limit int                  `json:"limit"`
+	// IncludeExpectedOutput uses each trace's response as the item's expected output
+	IncludeExpectedOutput bool `json:"include_expected_output"`
+}
+
+// CreateDataset creates an empty dataset
+// @Summary      Create dataset
+// @Description  Create a named dataset of test case inputs for the project
+// @Tags         datasets
+// @Accept       json
+// @Produce      json
+// @Param        projectID  path      string                  true  "Project ID"
+// @Param        request    body      map[string]interface{}  true  "Name and description"
+// @Success      201        {object}  map[string]interface{} "Dataset"
+// @Failure      400        {object}  map[string]interface{} "Invalid request"
+// @Failure      401        {object}  map[string]interface{} "Unauthorized"
+// @Failure      403        {object}  map[string]interface{} "Forbidden"
+// @Failure      404        {object}  map[string]interface{} "Project not found"
+// @Failure      409        {object}  map[string]interface{} "Dataset name already in use"
+// @Failure      500        {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/datasets [post]
+func (h *DatasetHandler) CreateDataset(c *gin.Context) {
+	if !requireEditor(c, "Viewers cannot modify datasets") {
+		return
+	}
+
+	project := loadProject(c, h.retentionRepo)
+	if project == nil {
+		return
+	}
+
+	var req datasetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("[CreateDataset] binding error: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": "Invalid request parameters",
+			},
+		})
+		return
+	}
+
+	dataset := &storage.Dataset{
+		ProjectID:   project.ProjectID,
+		Name:        req.Name,
+		Description: req.Description,
+		CreatedBy:   c.GetString("user_id"),
+	}
+	if err := h.datasetRepo.Create(c.Request.Context(), dataset); err != nil {
+		writeDatasetError(c, err, "Dataset not found", "Failed to create dataset")
+		return
+	}
+
+	c.JSON(http.StatusCreated, dataset)
+}
+
+// ListDatasets returns the project's datasets
+// @Summary      List datasets
+// @Description  List the project's datasets with their current version and item count
+// @Tags         datasets
+// @Produce      json
+// @Param        projectID  path      string  true  "Project ID"
+// @Success      200        {object}  map[string]interface{} "Datasets"
+// @Failure      401        {object}  map[string]interface{} "Unauthorized"
+// @Failure      404        {object}  map[string]interface{} "Project not found"
+// @Failure      500        {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/datasets [get]
+func (h *DatasetHandler) ListDatasets(c *gin.Context) {
+	project := loadProject(c, h.retentionRepo)
+	if project == nil {
+		return
+	}
+
+	datasets, err := h.datasetRepo.List(c.Request.Context(), project.ProjectID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Failed to fetch datasets",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"datasets": datasets,
+		"count":    len(datasets),
+	})
+}
+
+// GetDataset returns a dataset
+// @Summary      Get dataset
+// @Description  Get a dataset with its current version and item count
+// @Tags         datasets
+// @Produce      json
+// @Param        projectID  path      string  true  "Project ID"
+// @Param        datasetID  path      string  true  "Dataset ID"
+// @Success      200        {object}  map[string]interface{} "Dataset"
+// @Failure      401        {object}  map[string]interface{} "Unauthorized"
+// @Failure      404        {object}  map[string]interface{} "Dataset not found"
+// @Failure      500        {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/datasets/{datasetID} [get]
+func (h *DatasetHandler) GetDataset(c *gin.Context) {
+	project := loadProject(c, h.retentionRepo)
+	if project == nil {
+		return
+	}
+
+	dataset, err := h.datasetRepo.Get(c.Request.Context(), project.ProjectID, c.Param("datasetID"))
+	if err != nil {
+		writeDatasetError(c, err, "Dataset not found", "Failed to fetch dataset")
+		return
+	}
+
+	c.JSON(http.StatusOK, dataset)
+}
+
+// UpdateDataset renames a dataset or changes its description
+// @Summary      Update dataset
+// @Description  Rename a dataset or change its description. This does not create a new version.
+// @Tags         datasets
+// @Accept       json
+// @Produce      json
+// @Param        projectID  path      string                  true  "Project ID"
+// @Param        datasetID  path      string                  true  "Dataset ID"
+// @Param        request    body      map[string]interface{}  true  "Name and/or description"
+// @Success      200        {object}  map[string]interface{} "Dataset"
+// @Failure      400        {object}  map[string]interface{} "Invalid request"
+// @Failure      401        {object}  map[string]interface{} "Unauthorized"
+// @Failure      403        {object}  map[string]interface{} "Forbidden"
+// @Failure      404        {object}  map[string]interface{} "Dataset not found"
+// @Failure      409        {object}  map[string]interface{} "Dataset name already in use"
+// @Failure      500        {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/datasets/{datasetID} [patch]
+func (h *DatasetHandler) UpdateDataset(c *gin.Context) {
+	if !requireEditor(c, "Viewers cannot modify datasets") {
+		return
+	}
+
+	project := loadProject(c, h.retentionRepo)
+	if project == nil {
+		return
+	}
+
+	var req updateDatasetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("[UpdateDataset] binding error: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": "Invalid request parameters",
+			},
+		})
+		return
+	}
+
+	dataset, err := h.datasetRepo.Get(c.Request.Context(), project.ProjectID, c.Param("datasetID"))
+	if err != nil {
+		writeDatasetError(c, err, "Dataset not found", "Failed to fetch dataset")
+		return
+	}
+
+	if req.Name != nil {
+		dataset.Name = *req.Name
+	}
+	if req.Description != nil {
+		dataset.Description = *req.Description
+	}
+	if err := h.datasetRepo.Update(c.Request.Context(), dataset); err != nil {
+		writeDatasetError(c, err, "Dataset not found", "Failed to update dataset")
+		return
+	}
+
+	c.JSON(http.StatusOK, dataset)
+}
+
+// DeleteDataset deletes a dataset and all of its versions
+// @Summary      Delete dataset
+// @Description  Delete a dataset and all of its versions
+// @Tags         datasets
+// @Produce      json
+// @Param        projectID  path      string  true  "Project ID"
+// @Param        datasetID  path      string  true  "Dataset ID"
+// @Success      204        "No content"
+// @Failure      401        {object}  map[string]interface{} "Unauthorized"
+// @Failure      403        {object}  map[string]interface{} "Forbidden"
+// @Failure      404        {object}  map[string]interface{} "Dataset not found"
+// @Failure      500        {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/datasets/{datasetID} [delete]
+func (h *DatasetHandler) DeleteDataset(c *gin.Context) {
+	if !requireEditor(c, "Viewers cannot modify datasets") {
+		return
+	}
+
+	project := loadProject(c, h.retentionRepo)
+	if project == nil {
+		return
+	}
+
+	if err := h.datasetRepo.Delete(c.Request.Context(), project.ProjectID, c.Param("datasetID")); err != nil {
+		writeDatasetError(c, err, "Dataset not found", "Failed to delete dataset")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// AddDatasetItems adds traces to a dataset as new items
+// @Summary      Add dataset items
+// @Description  Add traces to a dataset, either by trace ID or as the most recent results of a trace search query. Each item captures the trace's messages and sampling parameters, and optionally its response as the expected output. Traces already in the dataset are skipped. Adding items creates a new version.
+// @Tags         datasets
+// @Accept       json
+// @Produce      json
+// @Param        projectID  path      string                  true  "Project ID"
+// @Param        datasetID  path      string                  true  "Dataset ID"
+// @Param        request    body      map[string]interface{}  true  "trace_ids or query, limit, include_expected_output"
+// @Success      200        {object}  map[string]interface{} "New version and number of items added"
+// @Failure      400        {object}  map[string]interface{} "Invalid request"
+// @Failure      401        {object}  map[string]interface{} "Unauthorized"
+// @Failure      403        {object}  map[string]interface{} "Forbidden"
+// @Failure      404        {object}  map[string]interface{} "Dataset not found"
+// @Failure      500        {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/datasets/{datasetID}/items [post]
+func (h *DatasetHandler) AddDatasetItems(c *gin.Context) {
+	if !requireEditor(c, "Viewers cannot modify datasets") {
+		return
+	}
+
+	project := loadProject(c, h.retentionRepo)
+	if project == nil {
+		return
+	}
+
+	var req addDatasetItemsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("[AddDatasetItems] binding error: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": "Invalid request parameters",
+			},
+		})
+		return
+	}
+
+	if req.Limit == 0 {
+		req.Limit = defaultDatasetQuery
+	}
+	reason := ""
+	switch {
+	case (len(req.TraceIDs) == 0) == (req.Query == nil):
+		reason = "Exactly one of trace_ids and query is required"
+	case len(req.TraceIDs) > maxDatasetTraceIDs:
+		reason = fmt.Sprintf("At most %d trace_ids may be added at once", maxDatasetTraceIDs)
+	case req.Limit < 1 || req.Limit > maxDatasetQueryLimit:
+		reason = fmt.Sprintf("limit must be between 1 and %d", maxDatasetQueryLimit)
+	case req.Query != nil && req.Query.From != nil && req.Query.To != nil && !req.Query.From.Before(*req.Query.To):
+		reason = "from must be before to"
+	}
+	if reason != "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": reason,
+			},
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	datasetID := c.Param("datasetID")
+	if _, err := h.datasetRepo.Get(ctx, project.ProjectID, datasetID); err != nil {
+		writeDatasetError(c, err, "Dataset not found", "Failed to fetch dataset")
+		return
+	}
+
+	var traces []*domain.Trace
+	notFound := []string{}
+	if req.Query != nil {
+		var err error
+		traces, err = h.traceRepo.List(ctx, project.ProjectID, *req.Query, req.Limit, 0)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": gin.H{
+					"code":    "INTERNAL_ERROR",
+					"message": "Failed to search traces",
+				},
+			})
+			return
+		}
+	} else {
+		for _, traceID := range req.TraceIDs {
+			trace, err := h.traceRepo.Get(ctx, project.ProjectID, traceID)
+			if err == storage.ErrNotFound {
+				notFound = append(notFound, traceID)
+				continue
+			}
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{
+					"error": gin.H{
+						"code":    "INTERNAL_ERROR",
+						"message": "Failed to fetch traces",
+					},
+				})
+				return
+			}
+			traces = append(traces, trace)
+		}
+	}
+
+	items := make([]*storage.DatasetItem, len(traces))
+	for i, trace := range traces {
+		items[i] = datasetItemFromTrace(trace, req.IncludeExpectedOutput)
+	}
+
+	version, added, err := h.datasetRepo.AddItems(ctx, project.ProjectID, datasetID, items)
+	if err != nil {
+		writeDatasetError(c, err, "Dataset not found", "Failed to add dataset items")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"version":             version,
+		"added":               added,
+		"skipped":             len(items) - added,
+		"not_found_trace_ids": notFound,
+	})
+}
+
+// ListDatasetItems returns the items in a version of a dataset
+// @Summary      List dataset items
+// @Description  List the items in a dataset, as of the current version or an earlier one
+// @Tags         datasets
+// @Produce      json
+// @Param        projectID  path      string  true   "Project ID"
+// @Param        datasetID  path      string  true   "Dataset ID"
+// @Param        version    query     int     false  "Dataset version (default current)"
+// @Success      200        {object}  map[string]interface{} "Dataset items"
+// @Failure      400        {object}  map[string]interface{} "Invalid version"
+// @Failure      401        {object}  map[string]interface{} "Unauthorized"
+// @Failure      404        {object}  map[string]interface{} "Dataset or version not found"
+// @Failure      500        {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/datasets/{datasetID}/items [get]
+func (h *DatasetHandler) ListDatasetItems(c *gin.Context) {
+	project := loadProject(c, h.retentionRepo)
+	if project == nil {
+		return
+	}
+
+	version, ok := parseDatasetVersion(c)
+	if !ok {
+		return
+	}
+
+	items, err := h.datasetRepo.ListItems(c.Request.Context(), project.ProjectID, c.Param("datasetID"), version)
+	if err != nil {
+		writeDatasetError(c, err, "Dataset or version not found", "Failed to fetch dataset items")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"items": items,
+		"count": len(items),
+	})
+}
+
+// SetDatasetItemExpectedOutput sets the expected output of a dataset item
+// @Summary      Set expected output
+// @Description  Set the output a test case built from a dataset item should produce. This creates a new version; earlier versions keep the previous expected output.
+// @Tags         datasets
+// @Accept       json
+// @Produce      json
+// @Param        projectID  path      string                  true  "Project ID"
+// @Param        datasetID  path      string                  true  "Dataset ID"
+// @Param        itemID     path      string                  true  "Item ID"
+// @Param        request    body      map[string]interface{}  true  "Expected text and/or JSON"
+// @Success      200        {object}  map[string]interface{} "Dataset item"
+// @Failure      400        {object}  map[string]interface{} "Invalid request"
+// @Failure      401        {object}  map[string]interface{} "Unauthorized"
+// @Failure      403        {object}  map[string]interface{} "Forbidden"
+// @Failure      404        {object}  map[string]interface{} "Dataset item not found"
+// @Failure      500        {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/datasets/{datasetID}/items/{itemID}/expected-output [put]
+func (h *DatasetHandler) SetDatasetItemExpectedOutput(c *gin.Context) {
+	if !requireEditor(c, "Viewers cannot modify datasets") {
+		return
+	}
+
+	project := loadProject(c, h.retentionRepo)
+	if project == nil {
+		return
+	}
+
+	var req storage.DatasetExpectedOutput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("[SetDatasetItemExpectedOutput] binding error: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": "Invalid request parameters",
+			},
+		})
+		return
+	}
+	if req.Text == "" && len(req.JSON) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": "text or json is required; use DELETE to clear the expected output",
+			},
+		})
+		return
+	}
+
+	item, err := h.datasetRepo.SetExpectedOutput(c.Request.Context(), project.ProjectID, c.Param("datasetID"), c.Param("itemID"), &req)
+	if err != nil {
+		writeDatasetError(c, err, "Dataset item not found", "Failed to update dataset item")
+		return
+	}
+
+	c.JSON(http.StatusOK, item)
+}
+
+// ClearDatasetItemExpectedOutput removes the expected output of a dataset item
+// @Summary      Clear expected output
+// @Description  Remove the expected output of a dataset item. This creates a new version.
+// @Tags         datasets
+// @Produce      json
+// @Param        projectID  path      string  true  "Project ID"
+// @Param        datasetID  path      string  true  "Dataset ID"
+// @Param        itemID     path      string  true  "Item ID"
+// @Success      200        {object}  map[string]interface{} "Dataset item"
+// @Failure      401        {object}  map[string]interface{} "Unauthorized"
+// @Failure      403        {object}  map[string]interface{} "Forbidden"
+// @Failure      404        {object}  map[string]interface{} "Dataset item not found"
+// @Failure      500        {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/datasets/{datasetID}/items/{itemID}/expected-output [delete]
+func (h *DatasetHandler) ClearDatasetItemExpectedOutput(c *gin.Context) {
+	if !requireEditor(c, "Viewers cannot modify datasets") {
+		return
+	}
+
+	project := loadProject(c, h.retentionRepo)
+	if project == nil {
+		return
+	}
+
+	item, err := h.datasetRepo.SetExpectedOutput(c.Request.Context(), project.ProjectID, c.Param("datasetID"), c.Param("itemID"), nil)
+	if err != nil {
+		writeDatasetError(c, err, "Dataset item not found", "Failed to update dataset item")
+		return
+	}
+
+	c.JSON(http.StatusOK, item)
+}
+
+// RemoveDatasetItem removes an item from a dataset
+// @Summary      Remove dataset item
+// @Description  Remove an item from a dataset. This creates a new version; earlier versions still include the item.
+// @Tags         datasets
+// @Produce      json
+// @Param        projectID  path      string  true  "Project ID"
+// @Param        datasetID  path      string  true  "Dataset ID"
+// @Param        itemID     path      string  true  "Item ID"
+// @Success      200        {object}  map[string]interface{} "New version"
+// @Failure      401        {object}  map[string]interface{} "Unauthorized"
+// @Failure      403        {object}  map[string]interface{} "Forbidden"
+// @Failure      404        {object}  map[string]interface{} "Dataset item not found"
+// @Failure      500        {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/datasets/{datasetID}/items/{itemID} [delete]
+func (h *DatasetHandler) RemoveDatasetItem(c *gin.Context) {
+	if !requireEditor(c, "Viewers cannot modify datasets") {
+		return
+	}
+
+	project := loadProject(c, h.retentionRepo)
+	if project == nil {
+		return
+	}
+
+	version, err := h.datasetRepo.RemoveItem(c.Request.Context(), project.ProjectID, c.Param("datasetID"), c.Param("itemID"))
+	if err != nil {
+		writeDatasetError(c, err, "Dataset item not found", "Failed to remove dataset item")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"version": version})
+}
+
+// datasetCaseFile is a dataset exported as regrada CLI test cases
+type datasetCaseFile struct {
+	Dataset string        `yaml:"dataset"`
+	Version int           `yaml:"version"`
+	Cases   []datasetCase `yaml:"cases"`
+}
+
+type datasetCase struct {
+	ID       string               `yaml:"id"`
+	Tags     []string             `yaml:"tags,omitempty"`
+	Request  datasetCaseRequest   `yaml:"request"`
+	Expected *datasetCaseExpected `yaml:"expected,omitempty"`
+}
+
+type datasetCaseRequest struct {
+	Messages []datasetCaseMessage `yaml:"messages"`
+	Params   *datasetCaseParams   `yaml:"params,omitempty"`
+}
+
+type datasetCaseMessage struct {
+	Role    string `yaml:"role"`
+	Content string `yaml:"content"`
+}
+
+type datasetCaseParams struct {
+	Temperature     *float64 `yaml:"temperature,omitempty"`
+	TopP            *float64 `yaml:"top_p,omitempty"`
+	MaxOutputTokens *int     `yaml:"max_output_tokens,omitempty"`
+	Stop            []string `yaml:"stop,omitempty"`
+}
+
+type datasetCaseExpected struct {
+	OutputText string `yaml:"output_text,omitempty"`
+	JSON       any    `yaml:"json,omitempty"`
+}
+
+// ExportDataset downloads a dataset as test cases for the regrada CLI
+// @Summary      Export dataset
+// @Description  Download a version of a dataset as a YAML file of test cases for the regrada CLI. Each case has the item's request messages and sampling parameters, and its expected output if one is set.
+// @Tags         datasets
+// @Produce      application/x-yaml
+// @Param        projectID  path      string  true   "Project ID"
+// @Param        datasetID  path      string  true   "Dataset ID"
+// @Param        version    query     int     false  "Dataset version (default current)"
+// @Success      200        {string}  string  "Test cases"
+// @Failure      400        {object}  map[string]interface{} "Invalid version"
+// @Failure      401        {object}  map[string]interface{} "Unauthorized"
+// @Failure      404        {object}  map[string]interface{} "Dataset or version not found"
+// @Failure      500        {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/datasets/{datasetID}/export [get]
+func (h *DatasetHandler) ExportDataset(c *gin.Context) {
+	project := loadProject(c, h.retentionRepo)
+	if project == nil {
+		return
+	}
+
+	version, ok := parseDatasetVersion(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	dataset, err := h.datasetRepo.Get(ctx, project.ProjectID, c.Param("datasetID"))
+	if err != nil {
+		writeDatasetError(c, err, "Dataset not found", "Failed to fetch dataset")
+		return
+	}
+	if version == 0 {
+		version = dataset.Version
+	}
+
+	items, err := h.datasetRepo.ListItems(ctx, project.ProjectID, dataset.ID, version)
+	if err != nil {
+		writeDatasetError(c, err, "Dataset or version not found", "Failed to fetch dataset items")
+		return
+	}
+
+	slug, err := sanitizeSlug(dataset.Name)
+	if err != nil {
+		slug = "dataset"
+	}
+
+	file := datasetCaseFile{Dataset: dataset.Name, Version: version, Cases: make([]datasetCase, len(items))}
+	for i, item := range items {
+		file.Cases[i] = toDatasetCase(slug, item)
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(file); err != nil {
+		log.Printf("Failed to encode dataset %s: %v", dataset.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Failed to export dataset",
+			},
+		})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-v%d.yml"`, slug, version))
+	c.Data(http.StatusOK, "application/x-yaml", buf.Bytes())
+}
+
+func datasetItemFromTrace(trace *domain.Trace, includeExpected bool) *storage.DatasetItem {
+	item := &storage.DatasetItem{
+		SourceTraceID: trace.TraceID,
+		Messages:      trace.Request.Messages,
+		Params:        trace.Request.Params,
+		Tags:          trace.Tags,
+	}
+	if includeExpected && trace.Response.AssistantText != "" {
+		item.ExpectedOutput = &storage.DatasetExpectedOutput{Text: trace.Response.AssistantText}
+	}
+	return item
+}
+
+func toDatasetCase(slug string, item *storage.DatasetItem) datasetCase {
+	id := item.ID
+	if len(id) > datasetCaseIDLength {
+		id = id[:datasetCaseIDLength]
+	}
+
+	tc := datasetCase{
+		ID:   slug + "-" + id,
+		Tags: item.Tags,
+		Request: datasetCaseRequest{
+			Messages: make([]datasetCaseMessage, len(item.Messages)),
+		},
+	}
+	for i, msg := range item.Messages {
+		tc.Request.Messages[i] = datasetCaseMessage{Role: msg.Role, Content: msg.Content}
+	}
+	if p := item.Params; p != nil {
+		tc.Request.Params = &datasetCaseParams{
+			Temperature:     p.Temperature,
+			TopP:            p.TopP,
+			MaxOutputTokens: p.MaxOutputTokens,
+			Stop:            p.Stop,
+		}
+	}
+	if e := item.ExpectedOutput; e != nil {
+		tc.Expected = &datasetCaseExpected{OutputText: e.Text}
+		if len(e.JSON) > 0 {
+			// Decode so the JSON is written as YAML rather than a string
+			var v any
+			if err := json.Unmarshal(e.JSON, &v); err == nil {
+				tc.Expected.JSON = v
+			}
+		}
+	}
+	return tc
+}
+
+// parseDatasetVersion reads the optional version query parameter, where 0
+// means the current version. It writes an error response and returns false
+// if it is invalid.
+func parseDatasetVersion(c *gin.Context) (int, bool) {
+	value := c.Query("version")
+	if value == "" {
+		return 0, true
+	}
+	version, err := strconv.Atoi(value)
+	if err != nil || version < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": "version must be a positive integer",
+			},
+		})
+		return 0, false
+	}
+	return version, true
+}
+
+// requireEditor writes a forbidden response and returns false for viewers
+func requireEditor(c *gin.Context, message string) bool {
+	if c.GetString("role") != string(storage.UserRoleViewer) {
+		return true
+	}
+	c.JSON(http.StatusForbidden, gin.H{
+		"error": gin.H{
+			"code":    "FORBIDDEN",
+			"message": message,
+		},
+	})
+	return false
+}
+
+// writeDatasetError writes the response for a dataset repository error
+func writeDatasetError(c *gin.Context, err error, notFound, message string) {
+	switch err {
+	case storage.ErrNotFound:
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": gin.H{
+				"code":    "NOT_FOUND",
+				"message": notFound,
+			},
+		})
+	case storage.ErrAlreadyExists:
+		c.JSON(http.StatusConflict, gin.H{
+			"error": gin.H{
+				"code":    "ALREADY_EXISTS",
+				"message": "A dataset with this name already exists",
+			},
+		})
+	default:
+		log.Printf("%s: %v", message, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": message,
+			},
+		})
+	}
+}
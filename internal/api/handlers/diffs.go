@@ -0,0 +1,265 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/regrada-ai/regrada-be/internal/diff"
+	"github.com/regrada-ai/regrada-be/internal/domain"
+	"github.com/regrada-ai/regrada-be/internal/storage"
+)
+
+type DiffHandler struct {
+	testRunRepo   storage.TestRunRepository
+	traceRepo     storage.TraceRepository
+	retentionRepo storage.RetentionRepository
+}
+
+func NewDiffHandler(testRunRepo storage.TestRunRepository, traceRepo storage.TraceRepository, retentionRepo storage.RetentionRepository) *DiffHandler {
+	return &DiffHandler{
+		testRunRepo:   testRunRepo,
+		traceRepo:     traceRepo,
+		retentionRepo: retentionRepo,
+	}
+}
+
+// caseOutputDiff is the diff of one run of a case between two test runs
+type caseOutputDiff struct {
+	CaseID        string       `json:"case_id"`
+	Provider      string       `json:"provider,omitempty"`
+	Model         string       `json:"model,omitempty"`
+	RunID         string       `json:"run_id"`
+	BaselineRunID string       `json:"baseline_run_id"`
+	Run           int          `json:"run"`
+	Pass          bool         `json:"pass"`
+	BaselinePass  bool         `json:"baseline_pass"`
+	Diff          *diff.Output `json:"diff"`
+}
+
+// traceOutputDiff is the diff of the responses of two traces
+type traceOutputDiff struct {
+	TraceID         string       `json:"trace_id"`
+	BaselineTraceID string       `json:"baseline_trace_id"`
+	Diff            *diff.Output `json:"diff"`
+}
+
+// DiffCaseOutput diffs a case's output between two test runs
+// @Summary      Diff case output
+// @Description  Diff the output of a case in a test run against its output in a baseline run: a word-level diff of output_text and a path-level diff of the JSON output, from the baseline to the run, with an HTML rendering. Pick the provider and model if the case ran against several, and the repeated run to compare by its run number (default the first run of each).
+// @Tags         test-runs
+// @Produce      json
+// @Param        projectID        path      string  true   "Project ID"
+// @Param        runID            path      string  true   "Run ID"
+// @Param        caseID           path      string  true   "Case ID"
+// @Param        baseline_run_id  query     string  true   "Run to compare against"
+// @Param        provider         query     string  false  "Provider of the case result"
+// @Param        model            query     string  false  "Model of the case result"
+// @Param        run              query     int     false  "Run number of the repeated run"
+// @Success      200              {object}  map[string]interface{} "Output diff"
+// @Failure      400              {object}  map[string]interface{} "Invalid request"
+// @Failure      401              {object}  map[string]interface{} "Unauthorized"
+// @Failure      404              {object}  map[string]interface{} "Project, test run, or case not found"
+// @Failure      500              {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/test-runs/{runID}/cases/{caseID}/diff [get]
+func (h *DiffHandler) DiffCaseOutput(c *gin.Context) {
+	baselineRunID := c.Query("baseline_run_id")
+	reason := ""
+	if baselineRunID == "" {
+		reason = "baseline_run_id is required"
+	}
+	run, hasRun := 0, false
+	if value := c.Query("run"); value != "" {
+		var err error
+		run, err = strconv.Atoi(value)
+		if err != nil {
+			reason = "run must be an integer"
+		}
+		hasRun = true
+	}
+	if reason != "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": reason,
+			},
+		})
+		return
+	}
+
+	project := loadProject(c, h.retentionRepo)
+	if project == nil {
+		return
+	}
+
+	ctx := c.Request.Context()
+	testRun, err := h.testRunRepo.Get(ctx, project.ProjectID, c.Param("runID"))
+	if err != nil {
+		writeDiffError(c, err, "Test run not found", "Failed to fetch test run")
+		return
+	}
+	baseline, err := h.testRunRepo.Get(ctx, project.ProjectID, baselineRunID)
+	if err != nil {
+		writeDiffError(c, err, "Baseline run not found", "Failed to fetch baseline run")
+		return
+	}
+
+	caseID := c.Param("caseID")
+	result := findCaseResult(testRun, caseID, c.Query("provider"), c.Query("model"))
+	if result == nil {
+		writeDiffError(c, storage.ErrNotFound, fmt.Sprintf("Case %s not found in test run", caseID), "")
+		return
+	}
+	// Match the baseline on the model the run's result was found on
+	baselineResult := findCaseResult(baseline, caseID, result.Provider, result.Model)
+	if baselineResult == nil {
+		writeDiffError(c, storage.ErrNotFound, fmt.Sprintf("Case %s not found in baseline run", caseID), "")
+		return
+	}
+
+	output := findRunResult(result, run, hasRun)
+	baselineOutput := findRunResult(baselineResult, run, hasRun)
+	if output == nil || baselineOutput == nil {
+		writeDiffError(c, storage.ErrNotFound, "Run of the case not found in both test runs", "")
+		return
+	}
+
+	c.JSON(http.StatusOK, caseOutputDiff{
+		CaseID:        caseID,
+		Provider:      result.Provider,
+		Model:         result.Model,
+		RunID:         testRun.RunID,
+		BaselineRunID: baseline.RunID,
+		Run:           output.RunID,
+		Pass:          output.Pass,
+		BaselinePass:  baselineOutput.Pass,
+		Diff:          diff.Outputs(baselineOutput.OutputText, output.OutputText, baselineOutput.JSON, output.JSON),
+	})
+}
+
+// DiffTraceOutput diffs the responses of two traces
+// @Summary      Diff trace output
+// @Description  Diff a trace's response against a baseline trace's: a word-level diff of the assistant text and a path-level diff of the tool calls and raw response, from the baseline to the trace, with an HTML rendering. Any two traces of the project can be compared.
+// @Tags         traces
+// @Produce      json
+// @Param        projectID          path      string  true  "Project ID"
+// @Param        traceID            path      string  true  "Trace ID"
+// @Param        baseline_trace_id  query     string  true  "Trace to compare against"
+// @Success      200                {object}  map[string]interface{} "Output diff"
+// @Failure      400                {object}  map[string]interface{} "Invalid request"
+// @Failure      401                {object}  map[string]interface{} "Unauthorized"
+// @Failure      404                {object}  map[string]interface{} "Project or trace not found"
+// @Failure      500                {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/traces/{traceID}/diff [get]
+func (h *DiffHandler) DiffTraceOutput(c *gin.Context) {
+	baselineTraceID := c.Query("baseline_trace_id")
+	if baselineTraceID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": "baseline_trace_id is required",
+			},
+		})
+		return
+	}
+
+	project := loadProject(c, h.retentionRepo)
+	if project == nil {
+		return
+	}
+
+	ctx := c.Request.Context()
+	trace, err := h.traceRepo.Get(ctx, project.ProjectID, c.Param("traceID"))
+	if err != nil {
+		writeDiffError(c, err, "Trace not found", "Failed to fetch trace")
+		return
+	}
+	baseline, err := h.traceRepo.Get(ctx, project.ProjectID, baselineTraceID)
+	if err != nil {
+		writeDiffError(c, err, "Baseline trace not found", "Failed to fetch baseline trace")
+		return
+	}
+
+	structured, err := structuredResponse(trace.Response)
+	if err != nil {
+		writeDiffError(c, err, "", "Failed to encode trace response")
+		return
+	}
+	baselineStructured, err := structuredResponse(baseline.Response)
+	if err != nil {
+		writeDiffError(c, err, "", "Failed to encode trace response")
+		return
+	}
+
+	c.JSON(http.StatusOK, traceOutputDiff{
+		TraceID:         trace.TraceID,
+		BaselineTraceID: baseline.TraceID,
+		Diff:            diff.Outputs(baseline.Response.AssistantText, trace.Response.AssistantText, baselineStructured, structured),
+	})
+}
+
+// findCaseResult returns the first result of the case in the run, on the
+// provider and model if set
+func findCaseResult(run *domain.TestRun, caseID, provider, model string) *domain.CaseResult {
+	for i := range run.Results {
+		result := &run.Results[i]
+		if result.CaseID != caseID {
+			continue
+		}
+		if (provider == "" || result.Provider == provider) && (model == "" || result.Model == model) {
+			return result
+		}
+	}
+	return nil
+}
+
+// findRunResult returns the repeated run with the run number, or the first
+// run if no number is given
+func findRunResult(result *domain.CaseResult, run int, hasRun bool) *domain.RunResult {
+	for i := range result.Runs {
+		if !hasRun || result.Runs[i].RunID == run {
+			return &result.Runs[i]
+		}
+	}
+	return nil
+}
+
+// structuredResponse returns the parts of a trace response diffed as JSON,
+// or nil if it has none
+func structuredResponse(response domain.TraceResponse) (json.RawMessage, error) {
+	if len(response.ToolCalls) == 0 && len(response.Raw) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(struct {
+		ToolCalls []domain.ToolCall `json:"tool_calls,omitempty"`
+		Raw       json.RawMessage   `json:"raw,omitempty"`
+	}{response.ToolCalls, response.Raw})
+}
+
+// writeDiffError writes the response for a repository error
+func writeDiffError(c *gin.Context, err error, notFound, message string) {
+	if err == storage.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": gin.H{
+				"code":    "NOT_FOUND",
+				"message": notFound,
+			},
+		})
+		return
+	}
+
+	log.Printf("%s: %v", message, err)
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"error": gin.H{
+			"code":    "INTERNAL_ERROR",
+			"message": message,
+		},
+	})
+}
@@ -0,0 +1,349 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/regrada-ai/regrada-be/internal/evaluation"
+	"github.com/regrada-ai/regrada-be/internal/storage"
+)
+
+type EvaluatorHandler struct {
+	evaluationRepo storage.EvaluationRepository
+	retentionRepo  storage.RetentionRepository
+}
+
+func NewEvaluatorHandler(evaluationRepo storage.EvaluationRepository, retentionRepo storage.RetentionRepository) *EvaluatorHandler {
+	return &EvaluatorHandler{
+		evaluationRepo: evaluationRepo,
+		retentionRepo:  retentionRepo,
+	}
+}
+
+type evaluatorRequest struct {
+	Name    string                `json:"name" binding:"required,max=255"`
+	Type    storage.EvaluatorType `json:"type" binding:"required,oneof=regex_match regex_absent json_schema max_latency max_tokens refusal tool_args_schema"`
+	Config  json.RawMessage       `json:"config"`
+	Enabled *bool                 `json:"enabled"`
+}
+
+type updateEvaluatorRequest struct {
+	Name    *string         `json:"name" binding:"omitempty,min=1,max=255"`
+	Config  json.RawMessage `json:"config"`
+	Enabled *bool           `json:"enabled"`
+}
+
+// CreateEvaluator creates an evaluator
+// @Summary      Create evaluator
+// @Description  Create a check that runs on every trace ingested into the project from now on. Types and their config: regex_match and regex_absent (pattern, case_insensitive), json_schema (schema, applied to the response text), max_latency (max_ms), max_tokens (max_tokens_out and/or max_tokens_total), refusal (phrases, defaulting to common refusals), and tool_args_schema (tool_name, schema).
+// @Tags         evaluators
+// @Accept       json
+// @Produce      json
+// @Param        projectID  path      string                  true  "Project ID"
+// @Param        request    body      map[string]interface{}  true  "name, type, config, enabled"
+// @Success      201        {object}  map[string]interface{} "Evaluator"
+// @Failure      400        {object}  map[string]interface{} "Invalid request or config"
+// @Failure      401        {object}  map[string]interface{} "Unauthorized"
+// @Failure      403        {object}  map[string]interface{} "Forbidden"
+// @Failure      404        {object}  map[string]interface{} "Project not found"
+// @Failure      409        {object}  map[string]interface{} "Evaluator name already in use"
+// @Failure      500        {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/evaluators [post]
+func (h *EvaluatorHandler) CreateEvaluator(c *gin.Context) {
+	if !requireEditor(c, "Viewers cannot modify evaluators") {
+		return
+	}
+
+	project := loadProject(c, h.retentionRepo)
+	if project == nil {
+		return
+	}
+
+	var req evaluatorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("[CreateEvaluator] binding error: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": "Invalid request parameters",
+			},
+		})
+		return
+	}
+
+	evaluator := &storage.Evaluator{
+		ProjectID: project.ProjectID,
+		Name:      req.Name,
+		Type:      req.Type,
+		Config:    req.Config,
+		Enabled:   req.Enabled == nil || *req.Enabled,
+		CreatedBy: c.GetString("user_id"),
+	}
+	if !checkEvaluatorConfig(c, evaluator) {
+		return
+	}
+
+	if err := h.evaluationRepo.CreateEvaluator(c.Request.Context(), evaluator); err != nil {
+		writeEvaluatorError(c, err, "Failed to create evaluator")
+		return
+	}
+
+	c.JSON(http.StatusCreated, evaluator)
+}
+
+// ListEvaluators returns the project's evaluators
+// @Summary      List evaluators
+// @Description  List the project's evaluators
+// @Tags         evaluators
+// @Produce      json
+// @Param        projectID  path      string  true  "Project ID"
+// @Success      200        {object}  map[string]interface{} "Evaluators"
+// @Failure      401        {object}  map[string]interface{} "Unauthorized"
+// @Failure      404        {object}  map[string]interface{} "Project not found"
+// @Failure      500        {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/evaluators [get]
+func (h *EvaluatorHandler) ListEvaluators(c *gin.Context) {
+	project := loadProject(c, h.retentionRepo)
+	if project == nil {
+		return
+	}
+
+	evaluators, err := h.evaluationRepo.ListEvaluators(c.Request.Context(), project.ProjectID)
+	if err != nil {
+		writeEvaluatorError(c, err, "Failed to fetch evaluators")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"evaluators": evaluators,
+		"count":      len(evaluators),
+	})
+}
+
+// GetEvaluator returns an evaluator
+// @Summary      Get evaluator
+// @Description  Get an evaluator's type and config
+// @Tags         evaluators
+// @Produce      json
+// @Param        projectID    path      string  true  "Project ID"
+// @Param        evaluatorID  path      string  true  "Evaluator ID"
+// @Success      200          {object}  map[string]interface{} "Evaluator"
+// @Failure      401          {object}  map[string]interface{} "Unauthorized"
+// @Failure      404          {object}  map[string]interface{} "Evaluator not found"
+// @Failure      500          {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/evaluators/{evaluatorID} [get]
+func (h *EvaluatorHandler) GetEvaluator(c *gin.Context) {
+	project := loadProject(c, h.retentionRepo)
+	if project == nil {
+		return
+	}
+
+	evaluator, err := h.evaluationRepo.GetEvaluator(c.Request.Context(), project.ProjectID, c.Param("evaluatorID"))
+	if err != nil {
+		writeEvaluatorError(c, err, "Failed to fetch evaluator")
+		return
+	}
+
+	c.JSON(http.StatusOK, evaluator)
+}
+
+// UpdateEvaluator updates an evaluator
+// @Summary      Update evaluator
+// @Description  Rename an evaluator, replace its config, or enable or disable it. Changes apply to traces evaluated from now on; the type cannot be changed.
+// @Tags         evaluators
+// @Accept       json
+// @Produce      json
+// @Param        projectID    path      string                  true  "Project ID"
+// @Param        evaluatorID  path      string                  true  "Evaluator ID"
+// @Param        request      body      map[string]interface{}  true  "name, config, enabled"
+// @Success      200          {object}  map[string]interface{} "Evaluator"
+// @Failure      400          {object}  map[string]interface{} "Invalid request or config"
+// @Failure      401          {object}  map[string]interface{} "Unauthorized"
+// @Failure      403          {object}  map[string]interface{} "Forbidden"
+// @Failure      404          {object}  map[string]interface{} "Evaluator not found"
+// @Failure      409          {object}  map[string]interface{} "Evaluator name already in use"
+// @Failure      500          {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/evaluators/{evaluatorID} [patch]
+func (h *EvaluatorHandler) UpdateEvaluator(c *gin.Context) {
+	if !requireEditor(c, "Viewers cannot modify evaluators") {
+		return
+	}
+
+	project := loadProject(c, h.retentionRepo)
+	if project == nil {
+		return
+	}
+
+	var req updateEvaluatorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("[UpdateEvaluator] binding error: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": "Invalid request parameters",
+			},
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	evaluator, err := h.evaluationRepo.GetEvaluator(ctx, project.ProjectID, c.Param("evaluatorID"))
+	if err != nil {
+		writeEvaluatorError(c, err, "Failed to fetch evaluator")
+		return
+	}
+
+	if req.Name != nil {
+		evaluator.Name = *req.Name
+	}
+	if req.Config != nil {
+		evaluator.Config = req.Config
+	}
+	if req.Enabled != nil {
+		evaluator.Enabled = *req.Enabled
+	}
+	if !checkEvaluatorConfig(c, evaluator) {
+		return
+	}
+
+	if err := h.evaluationRepo.UpdateEvaluator(ctx, evaluator); err != nil {
+		writeEvaluatorError(c, err, "Failed to update evaluator")
+		return
+	}
+
+	c.JSON(http.StatusOK, evaluator)
+}
+
+// DeleteEvaluator deletes an evaluator and its results
+// @Summary      Delete evaluator
+// @Description  Delete an evaluator together with its results. Disable it instead to keep its history.
+// @Tags         evaluators
+// @Produce      json
+// @Param        projectID    path      string  true  "Project ID"
+// @Param        evaluatorID  path      string  true  "Evaluator ID"
+// @Success      204          "No content"
+// @Failure      401          {object}  map[string]interface{} "Unauthorized"
+// @Failure      403          {object}  map[string]interface{} "Forbidden"
+// @Failure      404          {object}  map[string]interface{} "Evaluator not found"
+// @Failure      500          {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/evaluators/{evaluatorID} [delete]
+func (h *EvaluatorHandler) DeleteEvaluator(c *gin.Context) {
+	if !requireEditor(c, "Viewers cannot modify evaluators") {
+		return
+	}
+
+	project := loadProject(c, h.retentionRepo)
+	if project == nil {
+		return
+	}
+
+	if err := h.evaluationRepo.DeleteEvaluator(c.Request.Context(), project.ProjectID, c.Param("evaluatorID")); err != nil {
+		writeEvaluatorError(c, err, "Failed to delete evaluator")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetEvaluationAnalytics aggregates evaluator pass rates
+// @Summary      Evaluation analytics
+// @Description  Pass rates of each of the project's evaluators over the results recorded in a time range
+// @Tags         evaluators
+// @Produce      json
+// @Param        projectID  path      string  true   "Project ID"
+// @Param        from       query     string  false  "Earliest result time, inclusive (RFC 3339)"
+// @Param        to         query     string  false  "Latest result time, exclusive (RFC 3339)"
+// @Success      200        {object}  map[string]interface{} "Pass rates"
+// @Failure      400        {object}  map[string]interface{} "Invalid time range"
+// @Failure      401        {object}  map[string]interface{} "Unauthorized"
+// @Failure      404        {object}  map[string]interface{} "Project not found"
+// @Failure      500        {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/analytics/evaluations [get]
+func (h *EvaluatorHandler) GetEvaluationAnalytics(c *gin.Context) {
+	project := loadProject(c, h.retentionRepo)
+	if project == nil {
+		return
+	}
+
+	from, to, ok := parseTimeRange(c)
+	if !ok {
+		return
+	}
+
+	rates, err := h.evaluationRepo.PassRates(c.Request.Context(), project.ProjectID, from, to)
+	if err != nil {
+		writeEvaluatorError(c, err, "Failed to fetch evaluation analytics")
+		return
+	}
+
+	var total, passed int
+	for _, rate := range rates {
+		total += rate.Total
+		passed += rate.Passed
+	}
+	var passRate *float64
+	if total > 0 {
+		rate := float64(passed) / float64(total)
+		passRate = &rate
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"evaluators": rates,
+		"total":      total,
+		"passed":     passed,
+		"pass_rate":  passRate,
+	})
+}
+
+// checkEvaluatorConfig compiles an evaluator's config. It writes an error
+// response and returns false if the config is invalid.
+func checkEvaluatorConfig(c *gin.Context, evaluator *storage.Evaluator) bool {
+	if _, err := evaluation.Compile(evaluator.Type, evaluator.Config); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": err.Error(),
+			},
+		})
+		return false
+	}
+	return true
+}
+
+// writeEvaluatorError writes the response for an evaluation repository error
+func writeEvaluatorError(c *gin.Context, err error, message string) {
+	switch err {
+	case storage.ErrNotFound:
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": gin.H{
+				"code":    "NOT_FOUND",
+				"message": "Evaluator not found",
+			},
+		})
+	case storage.ErrAlreadyExists:
+		c.JSON(http.StatusConflict, gin.H{
+			"error": gin.H{
+				"code":    "ALREADY_EXISTS",
+				"message": "An evaluator with this name already exists",
+			},
+		})
+	default:
+		log.Printf("%s: %v", message, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": message,
+			},
+		})
+	}
+}
@@ -0,0 +1,270 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/regrada-ai/regrada-be/internal/export"
+	"github.com/regrada-ai/regrada-be/internal/storage"
+)
+
+const (
+	exportDownloadURLExpiry = time.Hour
+	maxExportsListed        = 50
+)
+
+type ExportHandler struct {
+	exportRepo    storage.ExportRepository
+	retentionRepo storage.RetentionRepository
+	userRepo      storage.UserRepository
+	files         storage.FileStorageService
+	exporter      *export.Exporter
+}
+
+func NewExportHandler(
+	exportRepo storage.ExportRepository,
+	retentionRepo storage.RetentionRepository,
+	userRepo storage.UserRepository,
+	files storage.FileStorageService,
+	exporter *export.Exporter,
+) *ExportHandler {
+	return &ExportHandler{
+		exportRepo:    exportRepo,
+		retentionRepo: retentionRepo,
+		userRepo:      userRepo,
+		files:         files,
+		exporter:      exporter,
+	}
+}
+
+type exportRequest struct {
+	Resource storage.ExportResource `json:"resource" binding:"omitempty,oneof=traces test_runs"`
+	Format   storage.ExportFormat   `json:"format" binding:"required,oneof=jsonl csv parquet"`
+	// Filters takes the trace search filters for traces, or from, to,
+	// git_sha, git_branch, and status for test runs
+	Filters json.RawMessage `json:"filters"`
+	// NotifyEmail defaults to the requesting user's email
+	NotifyEmail string `json:"notify_email" binding:"omitempty,email"`
+}
+
+type exportResponse struct {
+	*storage.Export
+	DownloadURL string `json:"download_url,omitempty"`
+}
+
+// CreateExport starts an asynchronous export of traces or test runs
+// @Summary      Create export
+// @Description  Export the project's traces or test runs matching the given filters to a JSONL, CSV, or Parquet file. The export runs in the background; poll it for a download link, or wait for the email sent when it finishes.
+// @Tags         exports
+// @Accept       json
+// @Produce      json
+// @Param        projectID  path      string                  true  "Project ID"
+// @Param        request    body      map[string]interface{}  true  "Resource, format, filters, and notify_email"
+// @Success      202        {object}  map[string]interface{} "Export queued"
+// @Failure      400        {object}  map[string]interface{} "Invalid request"
+// @Failure      401        {object}  map[string]interface{} "Unauthorized"
+// @Failure      403        {object}  map[string]interface{} "Forbidden"
+// @Failure      404        {object}  map[string]interface{} "Project not found"
+// @Failure      500        {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/exports [post]
+func (h *ExportHandler) CreateExport(c *gin.Context) {
+	if c.GetString("role") == string(storage.UserRoleViewer) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": gin.H{
+				"code":    "FORBIDDEN",
+				"message": "Viewers cannot create exports",
+			},
+		})
+		return
+	}
+
+	project := loadProject(c, h.retentionRepo)
+	if project == nil {
+		return
+	}
+
+	var req exportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("[CreateExport] binding error: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": "Invalid request parameters",
+			},
+		})
+		return
+	}
+
+	exp := &storage.Export{
+		ProjectID:   project.ProjectID,
+		RequestedBy: c.GetString("user_id"),
+		NotifyEmail: req.NotifyEmail,
+		Resource:    req.Resource,
+		Format:      req.Format,
+	}
+	if exp.Resource == "" {
+		exp.Resource = storage.ExportTraces
+	}
+
+	var from, to *time.Time
+	var err error
+	if exp.Resource == storage.ExportTestRuns {
+		exp.TestRunFilter = &storage.TestRunFilter{}
+		err = decodeFilters(req.Filters, exp.TestRunFilter)
+		from, to = exp.TestRunFilter.From, exp.TestRunFilter.To
+	} else {
+		exp.TraceFilter = &storage.TraceFilter{}
+		err = decodeFilters(req.Filters, exp.TraceFilter)
+		from, to = exp.TraceFilter.From, exp.TraceFilter.To
+	}
+	reason := ""
+	switch {
+	case err != nil:
+		log.Printf("[CreateExport] invalid filters: %v", err)
+		reason = "Invalid filters"
+	case from != nil && to != nil && !from.Before(*to):
+		reason = "from must be before to"
+	}
+	if reason != "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": reason,
+			},
+		})
+		return
+	}
+
+	// API keys have no user to notify unless an address is given
+	if exp.NotifyEmail == "" && exp.RequestedBy != "" {
+		user, err := h.userRepo.GetByID(c.Request.Context(), exp.RequestedBy)
+		if err != nil {
+			log.Printf("Failed to look up email of user %s for export: %v", exp.RequestedBy, err)
+		} else {
+			exp.NotifyEmail = user.Email
+		}
+	}
+
+	if err := h.exporter.Request(c.Request.Context(), exp); err != nil {
+		log.Printf("Failed to create export for project %s: %v", project.ProjectID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Failed to create export",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, exp)
+}
+
+// ListExports returns the project's most recent exports
+// @Summary      List exports
+// @Description  List the project's most recent exports and their status
+// @Tags         exports
+// @Produce      json
+// @Param        projectID  path      string  true  "Project ID"
+// @Success      200        {object}  map[string]interface{} "Exports"
+// @Failure      401        {object}  map[string]interface{} "Unauthorized"
+// @Failure      404        {object}  map[string]interface{} "Project not found"
+// @Failure      500        {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/exports [get]
+func (h *ExportHandler) ListExports(c *gin.Context) {
+	project := loadProject(c, h.retentionRepo)
+	if project == nil {
+		return
+	}
+
+	exports, err := h.exportRepo.List(c.Request.Context(), project.ProjectID, maxExportsListed)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Failed to fetch exports",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"exports": exports,
+		"count":   len(exports),
+	})
+}
+
+// GetExport returns the status of an export, with a download link once it has completed
+// @Summary      Get export
+// @Description  Get the status of an export. Completed exports include a download_url valid for one hour.
+// @Tags         exports
+// @Produce      json
+// @Param        projectID  path      string  true  "Project ID"
+// @Param        exportID   path      string  true  "Export ID"
+// @Success      200        {object}  map[string]interface{} "Export"
+// @Failure      401        {object}  map[string]interface{} "Unauthorized"
+// @Failure      404        {object}  map[string]interface{} "Export not found"
+// @Failure      500        {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/exports/{exportID} [get]
+func (h *ExportHandler) GetExport(c *gin.Context) {
+	project := loadProject(c, h.retentionRepo)
+	if project == nil {
+		return
+	}
+
+	exp, err := h.exportRepo.Get(c.Request.Context(), project.ProjectID, c.Param("exportID"))
+	if err != nil {
+		if err == storage.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": gin.H{
+					"code":    "NOT_FOUND",
+					"message": "Export not found",
+				},
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Failed to fetch export",
+			},
+		})
+		return
+	}
+
+	resp := exportResponse{Export: exp}
+	if exp.Status == storage.ExportCompleted && h.files != nil {
+		resp.DownloadURL, err = h.files.GetPresignedURL(c.Request.Context(), exp.StorageKey, exportDownloadURLExpiry)
+		if err != nil {
+			log.Printf("Failed to create download URL for export %s: %v", exp.ID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": gin.H{
+					"code":    "INTERNAL_ERROR",
+					"message": "Failed to create download URL",
+				},
+			})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// decodeFilters decodes optional JSON filters, rejecting unknown fields so
+// a misspelled filter doesn't silently export everything
+func decodeFilters(data json.RawMessage, filter any) error {
+	if len(data) == 0 || string(data) == "null" {
+		return nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	return dec.Decode(filter)
+}
@@ -0,0 +1,95 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"path"
+
+	"github.com/gin-gonic/gin"
+	"github.com/regrada-ai/regrada-be/internal/storage"
+	"github.com/regrada-ai/regrada-be/internal/storage/local"
+)
+
+// FileHandler serves files from local storage through signed URLs
+type FileHandler struct {
+	localStorage *local.Service
+}
+
+func NewFileHandler(localStorage *local.Service) *FileHandler {
+	return &FileHandler{
+		localStorage: localStorage,
+	}
+}
+
+// ServeFile serves a locally stored file if the URL signature is valid
+// @Summary      Download a file
+// @Description  Download a locally stored file using a signed, expiring URL
+// @Tags         files
+// @Produce      octet-stream
+// @Param        key        path   string  true  "File key"
+// @Param        expires    query  int     true  "Expiry as a unix timestamp"
+// @Param        signature  query  string  true  "URL signature"
+// @Success      200  {file}    binary
+// @Failure      403  {object}  map[string]interface{} "Invalid or expired signature"
+// @Failure      404  {object}  map[string]interface{} "File not found"
+// @Router       /v1/files/{key} [get]
+func (h *FileHandler) ServeFile(c *gin.Context) {
+	key := c.Param("key")
+
+	file, contentType, err := h.localStorage.Open(key, c.Query("expires"), c.Query("signature"))
+	if err != nil {
+		switch {
+		case errors.Is(err, local.ErrInvalidSignature), errors.Is(err, local.ErrInvalidKey):
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": gin.H{
+					"code":    "FORBIDDEN",
+					"message": "Invalid file signature",
+				},
+			})
+		case errors.Is(err, local.ErrURLExpired):
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": gin.H{
+					"code":    "URL_EXPIRED",
+					"message": "File URL has expired",
+				},
+			})
+		case errors.Is(err, storage.ErrNotFound):
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": gin.H{
+					"code":    "NOT_FOUND",
+					"message": "File not found",
+				},
+			})
+		default:
+			log.Printf("Failed to open file %s: %v", key, err)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": gin.H{
+					"code":    "INTERNAL_ERROR",
+					"message": "Failed to read file",
+				},
+			})
+		}
+		return
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Failed to read file",
+			},
+		})
+		return
+	}
+
+	if contentType != "" {
+		c.Header("Content-Type", contentType)
+	}
+	c.Header("Cache-Control", "private, max-age=300")
+	http.ServeContent(c.Writer, c.Request, path.Base(key), info.ModTime(), file)
+}
@@ -0,0 +1,281 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/regrada-ai/regrada-be/internal/flaky"
+	"github.com/regrada-ai/regrada-be/internal/storage"
+)
+
+const (
+	defaultFlakyRuns = 50
+	maxFlakyRuns     = 500
+)
+
+type FlakyCaseHandler struct {
+	testRunRepo    storage.TestRunRepository
+	quarantineRepo storage.QuarantineRepository
+	retentionRepo  storage.RetentionRepository
+}
+
+func NewFlakyCaseHandler(
+	testRunRepo storage.TestRunRepository,
+	quarantineRepo storage.QuarantineRepository,
+	retentionRepo storage.RetentionRepository,
+) *FlakyCaseHandler {
+	return &FlakyCaseHandler{
+		testRunRepo:    testRunRepo,
+		quarantineRepo: quarantineRepo,
+		retentionRepo:  retentionRepo,
+	}
+}
+
+type quarantineRequest struct {
+	Reason string `json:"reason" binding:"max=2000"`
+}
+
+// ListFlakyCases scores the project's cases by flakiness
+// @Summary      List flaky cases
+// @Description  Score each case of the project's latest test runs by how inconsistently it passes: the pass/fail variance between the runs of the case within a test run, and how often its outcome flips between test runs at the same git SHA. Scores range from 0 (stable) to 1. Cases run against several models are scored model by model.
+// @Tags         test-runs
+// @Produce      json
+// @Param        projectID         path      string  true   "Project ID"
+// @Param        runs              query     int     false  "Latest test runs to analyze (default 50, max 500)"
+// @Param        branch            query     string  false  "Only analyze runs on this branch"
+// @Param        min_score         query     number  false  "Lowest score reported (default 0.1)"
+// @Param        min_observations  query     int     false  "Fewest test runs a case must appear in (default 3)"
+// @Success      200               {object}  map[string]interface{} "Flaky cases, most flaky first"
+// @Failure      400               {object}  map[string]interface{} "Invalid request"
+// @Failure      401               {object}  map[string]interface{} "Unauthorized"
+// @Failure      404               {object}  map[string]interface{} "Project not found"
+// @Failure      500               {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/cases/flaky [get]
+func (h *FlakyCaseHandler) ListFlakyCases(c *gin.Context) {
+	runs, minObservations, minScore := defaultFlakyRuns, 3, 0.1
+	reason := ""
+	if value := c.Query("runs"); value != "" {
+		var err error
+		runs, err = strconv.Atoi(value)
+		if err != nil || runs < 1 || runs > maxFlakyRuns {
+			reason = fmt.Sprintf("runs must be between 1 and %d", maxFlakyRuns)
+		}
+	}
+	if value := c.Query("min_observations"); value != "" {
+		var err error
+		minObservations, err = strconv.Atoi(value)
+		if err != nil || minObservations < 1 {
+			reason = "min_observations must be a positive integer"
+		}
+	}
+	if value := c.Query("min_score"); value != "" {
+		var err error
+		minScore, err = strconv.ParseFloat(value, 64)
+		if err != nil || minScore < 0 || minScore > 1 {
+			reason = "min_score must be between 0 and 1"
+		}
+	}
+	if reason != "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": reason,
+			},
+		})
+		return
+	}
+
+	project := loadProject(c, h.retentionRepo)
+	if project == nil {
+		return
+	}
+
+	observations, err := h.testRunRepo.CaseHistory(c.Request.Context(), project.ProjectID, c.Query("branch"), runs)
+	if err != nil {
+		writeFlakyCaseError(c, err, "Failed to fetch case history")
+		return
+	}
+	quarantines, err := h.quarantineRepo.List(c.Request.Context(), project.ProjectID)
+	if err != nil {
+		writeFlakyCaseError(c, err, "Failed to fetch quarantined cases")
+		return
+	}
+	quarantined := make(map[string]bool, len(quarantines))
+	for _, quarantine := range quarantines {
+		quarantined[quarantine.CaseID] = true
+	}
+
+	cases := []*flaky.CaseScore{}
+	for _, score := range flaky.Score(observations) {
+		if score.Score < minScore || score.Observations < minObservations {
+			continue
+		}
+		score.Quarantined = quarantined[score.CaseID]
+		cases = append(cases, score)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"cases": cases,
+		"count": len(cases),
+	})
+}
+
+// ListQuarantinedCases lists the project's quarantined cases
+// @Summary      List quarantined cases
+// @Description  List the cases left out of the project's quality gates
+// @Tags         test-runs
+// @Produce      json
+// @Param        projectID  path      string  true  "Project ID"
+// @Success      200        {object}  map[string]interface{} "Quarantined cases"
+// @Failure      401        {object}  map[string]interface{} "Unauthorized"
+// @Failure      404        {object}  map[string]interface{} "Project not found"
+// @Failure      500        {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/cases/quarantined [get]
+func (h *FlakyCaseHandler) ListQuarantinedCases(c *gin.Context) {
+	project := loadProject(c, h.retentionRepo)
+	if project == nil {
+		return
+	}
+
+	quarantines, err := h.quarantineRepo.List(c.Request.Context(), project.ProjectID)
+	if err != nil {
+		writeFlakyCaseError(c, err, "Failed to fetch quarantined cases")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"cases": quarantines,
+		"count": len(quarantines),
+	})
+}
+
+// QuarantineCase quarantines a case
+// @Summary      Quarantine case
+// @Description  Leave a case out of the project's quality gates, on every provider and model, until it is taken out of quarantine. Quarantining a quarantined case updates its reason.
+// @Tags         test-runs
+// @Accept       json
+// @Produce      json
+// @Param        projectID  path      string                  true   "Project ID"
+// @Param        caseID     path      string                  true   "Case ID"
+// @Param        request    body      map[string]interface{}  false  "reason"
+// @Success      201        {object}  storage.CaseQuarantine "Case quarantined"
+// @Success      200        {object}  storage.CaseQuarantine "Quarantine updated"
+// @Failure      400        {object}  map[string]interface{} "Invalid request"
+// @Failure      401        {object}  map[string]interface{} "Unauthorized"
+// @Failure      403        {object}  map[string]interface{} "Viewers cannot quarantine cases"
+// @Failure      404        {object}  map[string]interface{} "Project not found"
+// @Failure      500        {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/cases/{caseID}/quarantine [put]
+func (h *FlakyCaseHandler) QuarantineCase(c *gin.Context) {
+	if !requireEditor(c, "Viewers cannot quarantine cases") {
+		return
+	}
+
+	caseID := c.Param("caseID")
+	if len(caseID) > 255 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": "case ID must be at most 255 characters",
+			},
+		})
+		return
+	}
+
+	var req quarantineRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			log.Printf("[QuarantineCase] binding error: %v", err)
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": gin.H{
+					"code":    "INVALID_REQUEST",
+					"message": "Invalid request parameters",
+				},
+			})
+			return
+		}
+	}
+
+	project := loadProject(c, h.retentionRepo)
+	if project == nil {
+		return
+	}
+
+	quarantine := &storage.CaseQuarantine{
+		ProjectID:     project.ProjectID,
+		CaseID:        caseID,
+		Reason:        req.Reason,
+		QuarantinedBy: c.GetString("user_id"),
+	}
+	created, err := h.quarantineRepo.Upsert(c.Request.Context(), quarantine)
+	if err != nil {
+		writeFlakyCaseError(c, err, "Failed to quarantine case")
+		return
+	}
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	c.JSON(status, quarantine)
+}
+
+// UnquarantineCase takes a case out of quarantine
+// @Summary      Unquarantine case
+// @Description  Check a quarantined case in the project's quality gates again
+// @Tags         test-runs
+// @Param        projectID  path  string  true  "Project ID"
+// @Param        caseID     path  string  true  "Case ID"
+// @Success      204        "Case taken out of quarantine"
+// @Failure      401        {object}  map[string]interface{} "Unauthorized"
+// @Failure      403        {object}  map[string]interface{} "Viewers cannot quarantine cases"
+// @Failure      404        {object}  map[string]interface{} "Project or quarantined case not found"
+// @Failure      500        {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/cases/{caseID}/quarantine [delete]
+func (h *FlakyCaseHandler) UnquarantineCase(c *gin.Context) {
+	if !requireEditor(c, "Viewers cannot quarantine cases") {
+		return
+	}
+	project := loadProject(c, h.retentionRepo)
+	if project == nil {
+		return
+	}
+
+	if err := h.quarantineRepo.Delete(c.Request.Context(), project.ProjectID, c.Param("caseID")); err != nil {
+		writeFlakyCaseError(c, err, "Failed to unquarantine case")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// writeFlakyCaseError writes the response for a case history or quarantine
+// repository error
+func writeFlakyCaseError(c *gin.Context, err error, message string) {
+	if err == storage.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": gin.H{
+				"code":    "NOT_FOUND",
+				"message": "Case is not quarantined",
+			},
+		})
+		return
+	}
+
+	log.Printf("%s: %v", message, err)
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"error": gin.H{
+			"code":    "INTERNAL_ERROR",
+			"message": message,
+		},
+	})
+}
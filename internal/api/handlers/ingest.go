@@ -0,0 +1,213 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/regrada-ai/regrada-be/internal/api/middleware"
+	"github.com/regrada-ai/regrada-be/internal/domain"
+	"github.com/regrada-ai/regrada-be/internal/ingest"
+	"github.com/regrada-ai/regrada-be/internal/storage"
+)
+
+type IngestHandler struct {
+	queue *ingest.Queue
+}
+
+func NewIngestHandler(queue *ingest.Queue) *IngestHandler {
+	return &IngestHandler{
+		queue: queue,
+	}
+}
+
+// GetQueueStatus reports the asynchronous ingestion backlog
+// @Summary      Get ingestion queue status
+// @Description  Get the backlog, lag, and dead-letter count of the asynchronous trace ingestion queue across all organizations. Operators only: requires the X-Internal-Token header to match INTERNAL_API_TOKEN, and is not served when it is unset.
+// @Tags         traces
+// @Produce      json
+// @Param        X-Internal-Token  header    string  true  "Internal API token"
+// @Success      200               {object}  ingest.Status "Queue status"
+// @Failure      401               {object}  map[string]interface{} "Missing or wrong internal token"
+// @Failure      404               {object}  map[string]interface{} "Internal API disabled"
+// @Failure      500               {object}  map[string]interface{} "Internal server error"
+// @Router       /v1/ingest/status [get]
+func (h *IngestHandler) GetQueueStatus(c *gin.Context) {
+	status, err := h.queue.Status(c.Request.Context())
+	if err != nil {
+		log.Printf("Failed to fetch ingestion queue status: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Failed to fetch queue status",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// parseConflictMode reads the on_conflict query parameter, defaulting to ignore.
+// It writes a 400 response and returns false if the mode is not allowed.
+func parseConflictMode(c *gin.Context, allowed ...storage.ConflictMode) (storage.ConflictMode, bool) {
+	mode := storage.ConflictMode(c.DefaultQuery("on_conflict", string(storage.ConflictIgnore)))
+	for _, m := range allowed {
+		if mode == m {
+			return mode, true
+		}
+	}
+
+	c.JSON(http.StatusBadRequest, gin.H{
+		"error": gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": fmt.Sprintf("on_conflict must be one of %v", allowed),
+		},
+	})
+	return "", false
+}
+
+// parseIngestMode reads the mode query parameter and reports whether traces
+// should be queued for asynchronous storage. It writes a 400 response and
+// returns false if the mode is invalid.
+func parseIngestMode(c *gin.Context) (bool, bool) {
+	switch c.DefaultQuery("mode", "sync") {
+	case "sync":
+		return false, true
+	case "async":
+		return true, true
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": "mode must be sync or async",
+			},
+		})
+		return false, false
+	}
+}
+
+// duplicateReason describes what happened to a duplicate under the given mode
+func duplicateReason(kind string, mode storage.ConflictMode) string {
+	switch mode {
+	case storage.ConflictReplace:
+		return kind + " already exists; replaced"
+	case storage.ConflictMergeTags:
+		return kind + " already exists; tags merged"
+	default:
+		return kind + " already exists; ignored"
+	}
+}
+
+// recordUsage reports how many traces a request ingested so UsageMiddleware
+// meters traces rather than requests
+func recordUsage(c *gin.Context, traces int) {
+	c.Set(middleware.UsageUnitsKey, traces)
+}
+
+// reserveUsage claims monthly quota for traces about to be ingested and
+// returns how many may be. Unmetered requests may ingest them all.
+func reserveUsage(c *gin.Context, traces int) int {
+	value, ok := c.Get(middleware.UsageMeterKey)
+	if !ok {
+		return traces
+	}
+	return value.(*middleware.UsageMeter).Reserve(c.Request.Context(), traces)
+}
+
+// overLimitReason is the reason reported for traces past the usage limit
+const overLimitReason = "monthly usage limit reached"
+
+// decodeTrace parses and validates a single trace. The trace is returned
+// whenever it could be parsed so callers can report its ID; reason is empty
+// when the trace is valid.
+func decodeTrace(raw []byte) (*domain.Trace, string) {
+	var trace domain.Trace
+	if err := json.Unmarshal(raw, &trace); err != nil {
+		return nil, "invalid trace data"
+	}
+	return &trace, validateTrace(&trace)
+}
+
+// validateTrace checks required fields and column limits so a single bad
+// trace is reported instead of failing the whole insert. It returns an empty
+// string when the trace is valid.
+func validateTrace(trace *domain.Trace) string {
+	switch {
+	case trace.TraceID == "":
+		return "trace_id is required"
+	case len(trace.TraceID) > 255:
+		return "trace_id must be at most 255 characters"
+	case trace.Timestamp.IsZero():
+		return "timestamp is required"
+	case trace.Provider == "":
+		return "provider is required"
+	case len(trace.Provider) > 50:
+		return "provider must be at most 50 characters"
+	case trace.Model == "":
+		return "model is required"
+	case len(trace.Model) > 255:
+		return "model must be at most 255 characters"
+	case len(trace.Environment) > 50:
+		return "environment must be at most 50 characters"
+	case len(trace.GitSHA) > 40:
+		return "git_sha must be at most 40 characters"
+	case len(trace.GitBranch) > 255:
+		return "git_branch must be at most 255 characters"
+	case len(trace.PromptName) > 255:
+		return "prompt_name must be at most 255 characters"
+	case trace.PromptVersion < 0:
+		return "prompt_version must not be negative"
+	case trace.PromptVersion > 0 && trace.PromptName == "":
+		return "prompt_version requires prompt_name"
+	}
+	return ""
+}
+
+// validateTestRun checks required fields and column limits of a test run.
+// It returns an empty string when the test run is valid.
+func validateTestRun(testRun *domain.TestRun) string {
+	switch {
+	case testRun.RunID == "":
+		return "run_id is required"
+	case len(testRun.RunID) > 255:
+		return "run_id must be at most 255 characters"
+	case testRun.Timestamp.IsZero():
+		return "timestamp is required"
+	case testRun.GitSHA == "":
+		return "git_sha is required"
+	case len(testRun.GitSHA) > 40:
+		return "git_sha must be at most 40 characters"
+	case len(testRun.GitBranch) > 255:
+		return "git_branch must be at most 255 characters"
+	case len(testRun.CIProvider) > 50:
+		return "ci_provider must be at most 50 characters"
+	}
+
+	for _, result := range testRun.Results {
+		switch {
+		case len(result.PromptName) > 255:
+			return fmt.Sprintf("prompt_name of case %s must be at most 255 characters", result.CaseID)
+		case result.PromptVersion < 0:
+			return fmt.Sprintf("prompt_version of case %s must not be negative", result.CaseID)
+		case result.PromptVersion > 0 && result.PromptName == "":
+			return fmt.Sprintf("prompt_version of case %s requires prompt_name", result.CaseID)
+		}
+		for _, run := range result.Runs {
+			if run.Metrics.CostUSD < 0 {
+				return fmt.Sprintf("cost_usd of case %s must not be negative", result.CaseID)
+			}
+		}
+	}
+
+	switch testRun.Status {
+	case "", "running", "completed", "failed", "cancelled":
+		return ""
+	default:
+		return "status must be running, completed, failed, or cancelled"
+	}
+}
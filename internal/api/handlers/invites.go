@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/regrada-ai/regrada-be/internal/audit"
 	"github.com/regrada-ai/regrada-be/internal/email"
 	"github.com/regrada-ai/regrada-be/internal/storage"
 )
@@ -21,6 +22,7 @@ type InviteHandler struct {
 	memberRepo   storage.OrganizationMemberRepository
 	orgRepo      storage.OrganizationRepository
 	emailService *email.Service
+	auditor      *Auditor
 }
 
 func NewInviteHandler(
@@ -29,6 +31,7 @@ func NewInviteHandler(
 	memberRepo storage.OrganizationMemberRepository,
 	orgRepo storage.OrganizationRepository,
 	emailService *email.Service,
+	auditor *Auditor,
 ) *InviteHandler {
 	return &InviteHandler{
 		inviteRepo:   inviteRepo,
@@ -36,6 +39,7 @@ func NewInviteHandler(
 		memberRepo:   memberRepo,
 		orgRepo:      orgRepo,
 		emailService: emailService,
+		auditor:      auditor,
 	}
 }
 
@@ -158,6 +162,12 @@ func (h *InviteHandler) CreateInvite(c *gin.Context) {
 		return
 	}
 
+	h.auditor.Record(c, orgID, audit.ActionInviteCreate, audit.TargetInvite, invite.ID, nil, audit.Fields{
+		"email":      invite.Email,
+		"role":       invite.Role,
+		"expires_at": invite.ExpiresAt,
+	})
+
 	// Create encoded token with email for the response and email
 	encodedToken := encodeInviteToken(req.Email, token)
 
@@ -514,7 +524,28 @@ func (h *InviteHandler) RevokeInvite(c *gin.Context) {
 		return
 	}
 
-	if err := h.inviteRepo.Revoke(c.Request.Context(), inviteID); err != nil {
+	ctx := c.Request.Context()
+	invite, err := h.inviteRepo.GetByID(ctx, inviteID)
+	if err != nil && err != storage.ErrNotFound {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Failed to fetch invite",
+			},
+		})
+		return
+	}
+	if err == storage.ErrNotFound || invite.OrganizationID != orgID {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": gin.H{
+				"code":    "NOT_FOUND",
+				"message": "Invite not found or already accepted/revoked",
+			},
+		})
+		return
+	}
+
+	if err := h.inviteRepo.Revoke(ctx, inviteID); err != nil {
 		if err == storage.ErrNotFound {
 			c.JSON(http.StatusNotFound, gin.H{
 				"error": gin.H{
@@ -533,6 +564,12 @@ func (h *InviteHandler) RevokeInvite(c *gin.Context) {
 		return
 	}
 
+	h.auditor.Record(c, orgID, audit.ActionInviteRevoke, audit.TargetInvite, inviteID, audit.Fields{
+		"email":      invite.Email,
+		"role":       invite.Role,
+		"expires_at": invite.ExpiresAt,
+	}, nil)
+
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 	})
@@ -0,0 +1,652 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/regrada-ai/regrada-be/internal/judge"
+	"github.com/regrada-ai/regrada-be/internal/storage"
+)
+
+const (
+	maxPromptTemplateLength = 20000
+	defaultJudgeRunItems    = 1000
+	maxJudgeRunItems        = 10000
+	maxJudgeRunsListed      = 50
+	defaultJudgeScores      = 100
+	maxJudgeScoresLimit     = 500
+	defaultJudgeScaleMin    = 1
+	defaultJudgeScaleMax    = 5
+)
+
+type JudgeHandler struct {
+	judgeRepo     storage.JudgeRepository
+	datasetRepo   storage.DatasetRepository
+	retentionRepo storage.RetentionRepository
+	worker        *judge.Worker
+}
+
+func NewJudgeHandler(
+	judgeRepo storage.JudgeRepository,
+	datasetRepo storage.DatasetRepository,
+	retentionRepo storage.RetentionRepository,
+	worker *judge.Worker,
+) *JudgeHandler {
+	return &JudgeHandler{
+		judgeRepo:     judgeRepo,
+		datasetRepo:   datasetRepo,
+		retentionRepo: retentionRepo,
+		worker:        worker,
+	}
+}
+
+type judgeRequest struct {
+	Name           *string  `json:"name" binding:"omitempty,min=1,max=255"`
+	PromptTemplate *string  `json:"prompt_template"`
+	Model          *string  `json:"model" binding:"omitempty,min=1,max=255"`
+	ScaleMin       *int     `json:"scale_min"`
+	ScaleMax       *int     `json:"scale_max"`
+	PassThreshold  *float64 `json:"pass_threshold"`
+}
+
+type judgeRunRequest struct {
+	Target storage.JudgeTarget `json:"target" binding:"omitempty,oneof=traces dataset"`
+	// Filters takes the trace search filters when the target is traces
+	Filters        json.RawMessage `json:"filters"`
+	DatasetID      string          `json:"dataset_id" binding:"omitempty,uuid"`
+	DatasetVersion int             `json:"dataset_version" binding:"min=0"`
+	MaxItems       int             `json:"max_items" binding:"min=0"`
+}
+
+// apply copies the fields set in the request onto a judge
+func (r *judgeRequest) apply(j *storage.Judge) {
+	if r.Name != nil {
+		j.Name = *r.Name
+	}
+	if r.PromptTemplate != nil {
+		j.PromptTemplate = *r.PromptTemplate
+	}
+	if r.Model != nil {
+		j.Model = *r.Model
+	}
+	if r.ScaleMin != nil {
+		j.ScaleMin = *r.ScaleMin
+	}
+	if r.ScaleMax != nil {
+		j.ScaleMax = *r.ScaleMax
+	}
+	if r.PassThreshold != nil {
+		j.PassThreshold = r.PassThreshold
+	}
+}
+
+// validateJudge checks a judge's template and scale. It returns why the judge
+// is invalid, or "" if it is valid.
+func validateJudge(j *storage.Judge) string {
+	if j.Name == "" || j.Model == "" || j.PromptTemplate == "" {
+		return "name, model, and prompt_template are required"
+	}
+	if len(j.PromptTemplate) > maxPromptTemplateLength {
+		return fmt.Sprintf("prompt_template must be at most %d characters", maxPromptTemplateLength)
+	}
+	if _, err := judge.ParseTemplate(j.PromptTemplate); err != nil {
+		return err.Error()
+	}
+	if j.ScaleMin >= j.ScaleMax {
+		return "scale_min must be below scale_max"
+	}
+	if t := j.PassThreshold; t != nil && (math.IsNaN(*t) || *t < float64(j.ScaleMin) || *t > float64(j.ScaleMax)) {
+		return "pass_threshold must be within the scale"
+	}
+	return ""
+}
+
+// CreateJudge creates an LLM judge
+// @Summary      Create judge
+// @Description  Create an LLM-as-judge evaluator. The prompt template is a Go text/template that can use {{.Input}} (the last user message), {{.Conversation}}, {{.Output}} (a trace's response or a dataset item's expected output), {{.ToolCalls}}, and {{.Model}}. The judge model scores each item on the scale (default 1-5); scores at or above pass_threshold pass.
+// @Tags         judges
+// @Accept       json
+// @Produce      json
+// @Param        projectID  path      string                  true  "Project ID"
+// @Param        request    body      map[string]interface{}  true  "name, prompt_template, model, scale_min, scale_max, pass_threshold"
+// @Success      201        {object}  map[string]interface{} "Judge"
+// @Failure      400        {object}  map[string]interface{} "Invalid request or template"
+// @Failure      401        {object}  map[string]interface{} "Unauthorized"
+// @Failure      403        {object}  map[string]interface{} "Forbidden"
+// @Failure      404        {object}  map[string]interface{} "Project not found"
+// @Failure      409        {object}  map[string]interface{} "Judge name already in use"
+// @Failure      500        {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/judges [post]
+func (h *JudgeHandler) CreateJudge(c *gin.Context) {
+	if !requireEditor(c, "Viewers cannot modify judges") {
+		return
+	}
+
+	project := loadProject(c, h.retentionRepo)
+	if project == nil {
+		return
+	}
+
+	req, ok := bindJudgeRequest(c, "CreateJudge")
+	if !ok {
+		return
+	}
+
+	j := &storage.Judge{
+		ProjectID: project.ProjectID,
+		ScaleMin:  defaultJudgeScaleMin,
+		ScaleMax:  defaultJudgeScaleMax,
+		CreatedBy: c.GetString("user_id"),
+	}
+	req.apply(j)
+	if !checkJudge(c, j) {
+		return
+	}
+
+	if err := h.judgeRepo.CreateJudge(c.Request.Context(), j); err != nil {
+		writeJudgeError(c, err, "Judge not found", "Failed to create judge")
+		return
+	}
+
+	c.JSON(http.StatusCreated, j)
+}
+
+// ListJudges returns the project's judges
+// @Summary      List judges
+// @Description  List the project's LLM judges
+// @Tags         judges
+// @Produce      json
+// @Param        projectID  path      string  true  "Project ID"
+// @Success      200        {object}  map[string]interface{} "Judges"
+// @Failure      401        {object}  map[string]interface{} "Unauthorized"
+// @Failure      404        {object}  map[string]interface{} "Project not found"
+// @Failure      500        {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/judges [get]
+func (h *JudgeHandler) ListJudges(c *gin.Context) {
+	project := loadProject(c, h.retentionRepo)
+	if project == nil {
+		return
+	}
+
+	judges, err := h.judgeRepo.ListJudges(c.Request.Context(), project.ProjectID)
+	if err != nil {
+		writeJudgeError(c, err, "Project not found", "Failed to fetch judges")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"judges": judges,
+		"count":  len(judges),
+	})
+}
+
+// GetJudge returns a judge
+// @Summary      Get judge
+// @Description  Get an LLM judge's prompt template, model, and scale
+// @Tags         judges
+// @Produce      json
+// @Param        projectID  path      string  true  "Project ID"
+// @Param        judgeID    path      string  true  "Judge ID"
+// @Success      200        {object}  map[string]interface{} "Judge"
+// @Failure      401        {object}  map[string]interface{} "Unauthorized"
+// @Failure      404        {object}  map[string]interface{} "Judge not found"
+// @Failure      500        {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/judges/{judgeID} [get]
+func (h *JudgeHandler) GetJudge(c *gin.Context) {
+	j := h.loadJudge(c)
+	if j == nil {
+		return
+	}
+
+	c.JSON(http.StatusOK, j)
+}
+
+// UpdateJudge updates a judge
+// @Summary      Update judge
+// @Description  Change any of an LLM judge's name, prompt template, model, scale, and pass threshold. Runs already started keep the old settings only for items they have scored.
+// @Tags         judges
+// @Accept       json
+// @Produce      json
+// @Param        projectID  path      string                  true  "Project ID"
+// @Param        judgeID    path      string                  true  "Judge ID"
+// @Param        request    body      map[string]interface{}  true  "name, prompt_template, model, scale_min, scale_max, pass_threshold"
+// @Success      200        {object}  map[string]interface{} "Judge"
+// @Failure      400        {object}  map[string]interface{} "Invalid request or template"
+// @Failure      401        {object}  map[string]interface{} "Unauthorized"
+// @Failure      403        {object}  map[string]interface{} "Forbidden"
+// @Failure      404        {object}  map[string]interface{} "Judge not found"
+// @Failure      409        {object}  map[string]interface{} "Judge name already in use"
+// @Failure      500        {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/judges/{judgeID} [patch]
+func (h *JudgeHandler) UpdateJudge(c *gin.Context) {
+	if !requireEditor(c, "Viewers cannot modify judges") {
+		return
+	}
+
+	req, ok := bindJudgeRequest(c, "UpdateJudge")
+	if !ok {
+		return
+	}
+
+	j := h.loadJudge(c)
+	if j == nil {
+		return
+	}
+
+	req.apply(j)
+	if !checkJudge(c, j) {
+		return
+	}
+
+	if err := h.judgeRepo.UpdateJudge(c.Request.Context(), j); err != nil {
+		writeJudgeError(c, err, "Judge not found", "Failed to update judge")
+		return
+	}
+
+	c.JSON(http.StatusOK, j)
+}
+
+// DeleteJudge deletes a judge with its runs and scores
+// @Summary      Delete judge
+// @Description  Delete an LLM judge together with its runs and scores
+// @Tags         judges
+// @Produce      json
+// @Param        projectID  path      string  true  "Project ID"
+// @Param        judgeID    path      string  true  "Judge ID"
+// @Success      204        "No content"
+// @Failure      401        {object}  map[string]interface{} "Unauthorized"
+// @Failure      403        {object}  map[string]interface{} "Forbidden"
+// @Failure      404        {object}  map[string]interface{} "Judge not found"
+// @Failure      500        {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/judges/{judgeID} [delete]
+func (h *JudgeHandler) DeleteJudge(c *gin.Context) {
+	if !requireEditor(c, "Viewers cannot modify judges") {
+		return
+	}
+
+	project := loadProject(c, h.retentionRepo)
+	if project == nil {
+		return
+	}
+
+	if err := h.judgeRepo.DeleteJudge(c.Request.Context(), project.ProjectID, c.Param("judgeID")); err != nil {
+		writeJudgeError(c, err, "Judge not found", "Failed to delete judge")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// CreateJudgeRun starts a judge run
+// @Summary      Run judge
+// @Description  Score traces matching the given filters (oldest first), or the items of a dataset version, with an LLM judge in the background. Runs are capped at max_items (default 1000, max 10000). Identical prompts are answered from a cache at no cost; judge tokens and cost are tracked on the run, not as project traces.
+// @Tags         judges
+// @Accept       json
+// @Produce      json
+// @Param        projectID  path      string                  true  "Project ID"
+// @Param        judgeID    path      string                  true  "Judge ID"
+// @Param        request    body      map[string]interface{}  true  "target (traces or dataset), filters, dataset_id, dataset_version, max_items"
+// @Success      202        {object}  map[string]interface{} "Judge run queued"
+// @Failure      400        {object}  map[string]interface{} "Invalid request"
+// @Failure      401        {object}  map[string]interface{} "Unauthorized"
+// @Failure      403        {object}  map[string]interface{} "Forbidden"
+// @Failure      404        {object}  map[string]interface{} "Judge or dataset not found"
+// @Failure      500        {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/judges/{judgeID}/runs [post]
+func (h *JudgeHandler) CreateJudgeRun(c *gin.Context) {
+	if !requireEditor(c, "Viewers cannot run judges") {
+		return
+	}
+
+	var req judgeRunRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("[CreateJudgeRun] binding error: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": "Invalid request parameters",
+			},
+		})
+		return
+	}
+
+	run := &storage.JudgeRun{
+		RequestedBy:    c.GetString("user_id"),
+		Target:         req.Target,
+		DatasetID:      req.DatasetID,
+		DatasetVersion: req.DatasetVersion,
+		MaxItems:       req.MaxItems,
+	}
+	if run.Target == "" {
+		run.Target = storage.JudgeTraces
+	}
+	if run.MaxItems == 0 {
+		run.MaxItems = defaultJudgeRunItems
+	}
+
+	reason := ""
+	switch {
+	case run.MaxItems > maxJudgeRunItems:
+		reason = fmt.Sprintf("max_items must be at most %d", maxJudgeRunItems)
+	case run.Target == storage.JudgeDataset && run.DatasetID == "":
+		reason = "dataset_id is required for dataset runs"
+	case run.Target == storage.JudgeTraces && (run.DatasetID != "" || run.DatasetVersion != 0):
+		reason = "dataset_id and dataset_version apply only to dataset runs"
+	case run.Target == storage.JudgeDataset && len(req.Filters) > 0:
+		reason = "filters apply only to trace runs"
+	}
+	if run.Target == storage.JudgeTraces && reason == "" {
+		run.TraceFilter = &storage.TraceFilter{}
+		if err := decodeFilters(req.Filters, run.TraceFilter); err != nil {
+			log.Printf("[CreateJudgeRun] invalid filters: %v", err)
+			reason = "Invalid filters"
+		} else if from, to := run.TraceFilter.From, run.TraceFilter.To; from != nil && to != nil && !from.Before(*to) {
+			reason = "from must be before to"
+		}
+	}
+	if reason != "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": reason,
+			},
+		})
+		return
+	}
+
+	j := h.loadJudge(c)
+	if j == nil {
+		return
+	}
+	run.ProjectID = j.ProjectID
+	run.JudgeID = j.ID
+
+	ctx := c.Request.Context()
+	if run.Target == storage.JudgeDataset {
+		dataset, err := h.datasetRepo.Get(ctx, j.ProjectID, run.DatasetID)
+		if err != nil {
+			writeJudgeError(c, err, "Dataset not found", "Failed to fetch dataset")
+			return
+		}
+		if run.DatasetVersion > dataset.Version {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": gin.H{
+					"code":    "INVALID_REQUEST",
+					"message": fmt.Sprintf("dataset_version must be at most %d", dataset.Version),
+				},
+			})
+			return
+		}
+	}
+
+	if err := h.worker.Request(ctx, run); err != nil {
+		writeJudgeError(c, err, "Judge not found", "Failed to create judge run")
+		return
+	}
+
+	c.JSON(http.StatusAccepted, run)
+}
+
+// ListJudgeRuns returns a judge's most recent runs
+// @Summary      List judge runs
+// @Description  List a judge's most recent runs with their progress, mean score, pass count, and cost
+// @Tags         judges
+// @Produce      json
+// @Param        projectID  path      string  true  "Project ID"
+// @Param        judgeID    path      string  true  "Judge ID"
+// @Success      200        {object}  map[string]interface{} "Judge runs"
+// @Failure      401        {object}  map[string]interface{} "Unauthorized"
+// @Failure      404        {object}  map[string]interface{} "Judge not found"
+// @Failure      500        {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/judges/{judgeID}/runs [get]
+func (h *JudgeHandler) ListJudgeRuns(c *gin.Context) {
+	j := h.loadJudge(c)
+	if j == nil {
+		return
+	}
+
+	runs, err := h.judgeRepo.ListRuns(c.Request.Context(), j.ProjectID, j.ID, maxJudgeRunsListed)
+	if err != nil {
+		writeJudgeError(c, err, "Judge not found", "Failed to fetch judge runs")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"runs":  runs,
+		"count": len(runs),
+	})
+}
+
+// GetJudgeRun returns a judge run
+// @Summary      Get judge run
+// @Description  Get a judge run's status, progress, mean score, pass count, token usage, and cost
+// @Tags         judges
+// @Produce      json
+// @Param        projectID  path      string  true  "Project ID"
+// @Param        runID      path      string  true  "Judge run ID"
+// @Success      200        {object}  map[string]interface{} "Judge run"
+// @Failure      401        {object}  map[string]interface{} "Unauthorized"
+// @Failure      404        {object}  map[string]interface{} "Judge run not found"
+// @Failure      500        {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/judge-runs/{runID} [get]
+func (h *JudgeHandler) GetJudgeRun(c *gin.Context) {
+	run := h.loadRun(c)
+	if run == nil {
+		return
+	}
+
+	c.JSON(http.StatusOK, run)
+}
+
+// ListJudgeScores returns the scores of a judge run
+// @Summary      List judge scores
+// @Description  List the scores of a judge run, in the order they were given. Items that could not be scored have an error instead.
+// @Tags         judges
+// @Produce      json
+// @Param        projectID  path      string  true   "Project ID"
+// @Param        runID      path      string  true   "Judge run ID"
+// @Param        limit      query     int     false  "Maximum scores (default 100, max 500)"
+// @Param        offset     query     int     false  "Scores to skip"
+// @Success      200        {object}  map[string]interface{} "Judge scores"
+// @Failure      400        {object}  map[string]interface{} "Invalid pagination"
+// @Failure      401        {object}  map[string]interface{} "Unauthorized"
+// @Failure      404        {object}  map[string]interface{} "Judge run not found"
+// @Failure      500        {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/judge-runs/{runID}/scores [get]
+func (h *JudgeHandler) ListJudgeScores(c *gin.Context) {
+	limit, offset := defaultJudgeScores, 0
+	reason := ""
+	if value := c.Query("limit"); value != "" {
+		var err error
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxJudgeScoresLimit {
+			reason = fmt.Sprintf("limit must be between 1 and %d", maxJudgeScoresLimit)
+		}
+	}
+	if value := c.Query("offset"); value != "" {
+		var err error
+		offset, err = strconv.Atoi(value)
+		if err != nil || offset < 0 {
+			reason = "offset must be a non-negative integer"
+		}
+	}
+	if reason != "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": reason,
+			},
+		})
+		return
+	}
+
+	run := h.loadRun(c)
+	if run == nil {
+		return
+	}
+
+	scores, err := h.judgeRepo.ListScores(c.Request.Context(), run.ID, limit, offset)
+	if err != nil {
+		writeJudgeError(c, err, "Judge run not found", "Failed to fetch judge scores")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"scores": scores,
+		"count":  len(scores),
+	})
+}
+
+// GetJudgeCostAnalytics aggregates judge token usage and cost
+// @Summary      Judge cost analytics
+// @Description  Judge calls, cache hits, token usage, and cost per judge over a time range. Judge usage is tracked apart from the project's traces and does not count towards trace usage.
+// @Tags         judges
+// @Produce      json
+// @Param        projectID  path      string  true   "Project ID"
+// @Param        from       query     string  false  "Earliest score time, inclusive (RFC 3339)"
+// @Param        to         query     string  false  "Latest score time, exclusive (RFC 3339)"
+// @Success      200        {object}  map[string]interface{} "Judge costs"
+// @Failure      400        {object}  map[string]interface{} "Invalid time range"
+// @Failure      401        {object}  map[string]interface{} "Unauthorized"
+// @Failure      404        {object}  map[string]interface{} "Project not found"
+// @Failure      500        {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/analytics/judge-costs [get]
+func (h *JudgeHandler) GetJudgeCostAnalytics(c *gin.Context) {
+	project := loadProject(c, h.retentionRepo)
+	if project == nil {
+		return
+	}
+
+	from, to, ok := parseTimeRange(c)
+	if !ok {
+		return
+	}
+
+	costs, err := h.judgeRepo.Costs(c.Request.Context(), project.ProjectID, from, to)
+	if err != nil {
+		writeJudgeError(c, err, "Project not found", "Failed to fetch judge costs")
+		return
+	}
+
+	var tokensIn, tokensOut int64
+	var cost float64
+	for _, judgeCost := range costs {
+		tokensIn += judgeCost.TokensIn
+		tokensOut += judgeCost.TokensOut
+		cost += judgeCost.CostUSD
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"judges":     costs,
+		"tokens_in":  tokensIn,
+		"tokens_out": tokensOut,
+		"cost_usd":   cost,
+	})
+}
+
+// loadJudge loads the judge named in the path, writing an error response
+// and returning nil if it cannot be loaded
+func (h *JudgeHandler) loadJudge(c *gin.Context) *storage.Judge {
+	project := loadProject(c, h.retentionRepo)
+	if project == nil {
+		return nil
+	}
+
+	j, err := h.judgeRepo.GetJudge(c.Request.Context(), project.ProjectID, c.Param("judgeID"))
+	if err != nil {
+		writeJudgeError(c, err, "Judge not found", "Failed to fetch judge")
+		return nil
+	}
+	return j
+}
+
+// loadRun loads the judge run named in the path, writing an error response
+// and returning nil if it cannot be loaded
+func (h *JudgeHandler) loadRun(c *gin.Context) *storage.JudgeRun {
+	project := loadProject(c, h.retentionRepo)
+	if project == nil {
+		return nil
+	}
+
+	run, err := h.judgeRepo.GetRun(c.Request.Context(), project.ProjectID, c.Param("runID"))
+	if err != nil {
+		writeJudgeError(c, err, "Judge run not found", "Failed to fetch judge run")
+		return nil
+	}
+	return run
+}
+
+func bindJudgeRequest(c *gin.Context, handler string) (*judgeRequest, bool) {
+	var req judgeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("[%s] binding error: %v", handler, err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": "Invalid request parameters",
+			},
+		})
+		return nil, false
+	}
+	return &req, true
+}
+
+// checkJudge validates a judge, writing an error response and returning
+// false if it is invalid
+func checkJudge(c *gin.Context, j *storage.Judge) bool {
+	if reason := validateJudge(j); reason != "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": reason,
+			},
+		})
+		return false
+	}
+	return true
+}
+
+// writeJudgeError writes the response for a judge repository error
+func writeJudgeError(c *gin.Context, err error, notFound, message string) {
+	switch err {
+	case storage.ErrNotFound:
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": gin.H{
+				"code":    "NOT_FOUND",
+				"message": notFound,
+			},
+		})
+	case storage.ErrAlreadyExists:
+		c.JSON(http.StatusConflict, gin.H{
+			"error": gin.H{
+				"code":    "ALREADY_EXISTS",
+				"message": "A judge with this name already exists",
+			},
+		})
+	default:
+		log.Printf("%s: %v", message, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": message,
+			},
+		})
+	}
+}
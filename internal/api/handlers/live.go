@@ -0,0 +1,159 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/regrada-ai/regrada-be/internal/domain"
+	"github.com/regrada-ai/regrada-be/internal/live"
+	"github.com/regrada-ai/regrada-be/internal/storage"
+)
+
+// streamHeartbeat is how often an idle stream sends a comment, keeping
+// proxies from closing it
+const streamHeartbeat = 15 * time.Second
+
+type LiveHandler struct {
+	hub           *live.Hub
+	retentionRepo storage.RetentionRepository
+}
+
+func NewLiveHandler(hub *live.Hub, retentionRepo storage.RetentionRepository) *LiveHandler {
+	return &LiveHandler{
+		hub:           hub,
+		retentionRepo: retentionRepo,
+	}
+}
+
+// StreamTraces streams newly ingested traces
+// @Summary      Stream traces
+// @Description  Stream traces as they are ingested, as Server-Sent Events of type trace whose data is the trace. Takes the trace list filters that apply to a single trace: environment, provider, model, git_sha, git_branch, tag, prompt_name, and prompt_version. Traces queued with mode=async are streamed when a worker stores them. A dropped event reports how many events a slow client missed.
+// @Tags         traces
+// @Produce      text/event-stream
+// @Param        projectID       path      string  true   "Project ID"
+// @Param        environment     query     string  false  "Filter by environment"
+// @Param        provider        query     string  false  "Filter by provider"
+// @Param        model           query     string  false  "Filter by model"
+// @Param        git_sha         query     string  false  "Filter by git SHA"
+// @Param        git_branch      query     string  false  "Filter by git branch"
+// @Param        tag             query     string  false  "Filter by tag (repeatable, all must match)"
+// @Param        prompt_name     query     string  false  "Filter by registry prompt"
+// @Param        prompt_version  query     int     false  "Filter by prompt version (requires prompt_name)"
+// @Success      200             {string}  string  "Event stream"
+// @Failure      400             {object}  map[string]interface{} "Invalid filter"
+// @Failure      401             {object}  map[string]interface{} "Unauthorized"
+// @Failure      404             {object}  map[string]interface{} "Project not found"
+// @Failure      503             {object}  map[string]interface{} "Streaming unavailable"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/traces/stream [get]
+func (h *LiveHandler) StreamTraces(c *gin.Context) {
+	filter, ok := parseTraceFilter(c)
+	if !ok {
+		return
+	}
+	if !live.Streamable(filter) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": "time range, annotation, and evaluation filters cannot be streamed",
+			},
+		})
+		return
+	}
+
+	h.stream(c, live.TopicTraces, func(event live.Event) bool {
+		var trace domain.Trace
+		if err := json.Unmarshal(event.Data, &trace); err != nil {
+			return false
+		}
+		return live.MatchTrace(filter, &trace)
+	})
+}
+
+// StreamTestRuns streams uploaded test runs
+// @Summary      Stream test runs
+// @Description  Stream test runs as they are uploaded, as Server-Sent Events of type test_run whose data summarizes the run: its status, case counts, and quality gate verdict if the project has a gate. A dropped event reports how many events a slow client missed.
+// @Tags         test-runs
+// @Produce      text/event-stream
+// @Param        projectID   path      string  true   "Project ID"
+// @Param        git_branch  query     string  false  "Filter by git branch"
+// @Success      200         {string}  string  "Event stream"
+// @Failure      401         {object}  map[string]interface{} "Unauthorized"
+// @Failure      404         {object}  map[string]interface{} "Project not found"
+// @Failure      503         {object}  map[string]interface{} "Streaming unavailable"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/test-runs/stream [get]
+func (h *LiveHandler) StreamTestRuns(c *gin.Context) {
+	branch := c.Query("git_branch")
+	h.stream(c, live.TopicTestRuns, func(event live.Event) bool {
+		if branch == "" {
+			return true
+		}
+		var run live.TestRunEvent
+		if err := json.Unmarshal(event.Data, &run); err != nil {
+			return false
+		}
+		return run.GitBranch == branch
+	})
+}
+
+// stream subscribes to the project's events on the topic and writes those
+// that match as Server-Sent Events until the client disconnects
+func (h *LiveHandler) stream(c *gin.Context, topic live.Topic, match func(live.Event) bool) {
+	project := loadProject(c, h.retentionRepo)
+	if project == nil {
+		return
+	}
+
+	subscription, err := h.hub.Subscribe(c.Request.Context(), topic, project.ProjectID)
+	if err != nil {
+		log.Printf("Failed to subscribe to %s of project %s: %v", topic, project.ProjectID, err)
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": gin.H{
+				"code":    "SERVICE_UNAVAILABLE",
+				"message": "Streaming is temporarily unavailable",
+			},
+		})
+		return
+	}
+	defer subscription.Close()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // keep nginx from buffering the stream
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+	dropped := 0
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case event, ok := <-subscription.Events():
+			if !ok {
+				return false
+			}
+			if match(event) {
+				c.SSEvent(event.Type, event.Data)
+			}
+			return true
+		case <-heartbeat.C:
+			if total := subscription.Dropped(); total > dropped {
+				c.SSEvent("dropped", gin.H{"events": total - dropped})
+				dropped = total
+				return true
+			}
+			_, err := io.WriteString(w, ": ping\n\n")
+			return err == nil
+		}
+	})
+}
@@ -0,0 +1,204 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/regrada-ai/regrada-be/internal/comparison"
+	"github.com/regrada-ai/regrada-be/internal/domain"
+	"github.com/regrada-ai/regrada-be/internal/storage"
+)
+
+const (
+	// maxMatrixRuns caps the test runs a model matrix is built from
+	maxMatrixRuns = 1000
+
+	defaultMatrixRange = 30 * 24 * time.Hour
+)
+
+var errTooManyMatrixRuns = errors.New("too many test runs")
+
+type ModelComparisonHandler struct {
+	testRunRepo   storage.TestRunRepository
+	retentionRepo storage.RetentionRepository
+}
+
+func NewModelComparisonHandler(testRunRepo storage.TestRunRepository, retentionRepo storage.RetentionRepository) *ModelComparisonHandler {
+	return &ModelComparisonHandler{
+		testRunRepo:   testRunRepo,
+		retentionRepo: retentionRepo,
+	}
+}
+
+// GetModelMatrix compares models case by case
+// @Summary      Model comparison matrix
+// @Description  Pivot the results of a test run, or of the test runs in a time range, into a case by model matrix of pass rate, p95 latency, refusal rate, and mean cost per run, with a summary per model. Without run_id or a time range, the last 30 days are used. Cost is reported only where runs report cost_usd.
+// @Tags         test-runs
+// @Produce      json
+// @Param        projectID  path      string  true   "Project ID"
+// @Param        run_id     query     string  false  "Test run to pivot"
+// @Param        from       query     string  false  "Earliest run time, inclusive (RFC 3339)"
+// @Param        to         query     string  false  "Latest run time, exclusive (RFC 3339)"
+// @Param        branch     query     string  false  "Only include runs on this branch"
+// @Success      200        {object}  comparison.Matrix "Model comparison matrix"
+// @Failure      400        {object}  map[string]interface{} "Invalid request"
+// @Failure      401        {object}  map[string]interface{} "Unauthorized"
+// @Failure      404        {object}  map[string]interface{} "Project or test run not found"
+// @Failure      500        {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/analytics/models [get]
+func (h *ModelComparisonHandler) GetModelMatrix(c *gin.Context) {
+	matrix := h.buildMatrix(c)
+	if matrix == nil {
+		return
+	}
+
+	c.JSON(http.StatusOK, matrix)
+}
+
+// ExportModelMatrix downloads the model comparison matrix as CSV
+// @Summary      Export model comparison matrix
+// @Description  Download the model comparison matrix as CSV: one row per case and a final summary row, with pass rate, p95 latency, refusal rate, and cost columns per model. Takes the same parameters as the matrix.
+// @Tags         test-runs
+// @Produce      text/csv
+// @Param        projectID  path      string  true   "Project ID"
+// @Param        run_id     query     string  false  "Test run to pivot"
+// @Param        from       query     string  false  "Earliest run time, inclusive (RFC 3339)"
+// @Param        to         query     string  false  "Latest run time, exclusive (RFC 3339)"
+// @Param        branch     query     string  false  "Only include runs on this branch"
+// @Success      200        {string}  string  "Model comparison matrix"
+// @Failure      400        {object}  map[string]interface{} "Invalid request"
+// @Failure      401        {object}  map[string]interface{} "Unauthorized"
+// @Failure      404        {object}  map[string]interface{} "Project or test run not found"
+// @Failure      500        {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/analytics/models/export [get]
+func (h *ModelComparisonHandler) ExportModelMatrix(c *gin.Context) {
+	matrix := h.buildMatrix(c)
+	if matrix == nil {
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := comparison.WriteCSV(&buf, matrix); err != nil {
+		log.Printf("Failed to encode model matrix: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Failed to export model matrix",
+			},
+		})
+		return
+	}
+
+	filename := "model-matrix.csv"
+	if runID := c.Query("run_id"); runID != "" {
+		if slug, err := sanitizeSlug(runID); err == nil {
+			filename = fmt.Sprintf("model-matrix-%s.csv", slug)
+		}
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Data(http.StatusOK, "text/csv", buf.Bytes())
+}
+
+// buildMatrix builds the matrix the request asks for, or writes an error
+// response and returns nil
+func (h *ModelComparisonHandler) buildMatrix(c *gin.Context) *comparison.Matrix {
+	from, to, ok := parseTimeRange(c)
+	if !ok {
+		return nil
+	}
+	runID := c.Query("run_id")
+	if runID != "" && (!from.IsZero() || !to.IsZero() || c.Query("branch") != "") {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": "run_id cannot be combined with from, to, or branch",
+			},
+		})
+		return nil
+	}
+
+	project := loadProject(c, h.retentionRepo)
+	if project == nil {
+		return nil
+	}
+
+	ctx := c.Request.Context()
+	builder := comparison.NewBuilder()
+	if runID != "" {
+		testRun, err := h.testRunRepo.Get(ctx, project.ProjectID, runID)
+		if err != nil {
+			writeModelComparisonError(c, err, "Failed to fetch test run")
+			return nil
+		}
+		builder.Add(testRun)
+		return builder.Matrix()
+	}
+
+	if from.IsZero() && to.IsZero() {
+		from = time.Now().Add(-defaultMatrixRange)
+	}
+	filter := storage.TestRunFilter{GitBranch: c.Query("branch")}
+	if !from.IsZero() {
+		filter.From = &from
+	}
+	if !to.IsZero() {
+		filter.To = &to
+	}
+
+	runs := 0
+	err := h.testRunRepo.Each(ctx, project.ProjectID, filter, 100, func(batch []*domain.TestRun) error {
+		runs += len(batch)
+		if runs > maxMatrixRuns {
+			return errTooManyMatrixRuns
+		}
+		for _, testRun := range batch {
+			builder.Add(testRun)
+		}
+		return nil
+	})
+	if err == errTooManyMatrixRuns {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": fmt.Sprintf("the time range has more than %d test runs; narrow it", maxMatrixRuns),
+			},
+		})
+		return nil
+	}
+	if err != nil {
+		writeModelComparisonError(c, err, "Failed to fetch test runs")
+		return nil
+	}
+
+	return builder.Matrix()
+}
+
+// writeModelComparisonError writes the response for a test run repository error
+func writeModelComparisonError(c *gin.Context, err error, message string) {
+	if err == storage.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": gin.H{
+				"code":    "NOT_FOUND",
+				"message": "Test run not found",
+			},
+		})
+		return
+	}
+
+	log.Printf("%s: %v", message, err)
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"error": gin.H{
+			"code":    "INTERNAL_ERROR",
+			"message": message,
+		},
+	})
+}
@@ -11,6 +11,7 @@ import (
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/regrada-ai/regrada-be/internal/audit"
 	"github.com/regrada-ai/regrada-be/internal/storage"
 
 	_ "github.com/regrada-ai/regrada-be/internal/api/types" // for swagger
@@ -42,17 +43,35 @@ type OrganizationHandler struct {
 	memberRepo storage.OrganizationMemberRepository
 	userRepo   storage.UserRepository
 	apiKeyRepo storage.APIKeyRepository
+	auditor    *Auditor
 }
 
-func NewOrganizationHandler(orgRepo storage.OrganizationRepository, memberRepo storage.OrganizationMemberRepository, userRepo storage.UserRepository, apiKeyRepo storage.APIKeyRepository) *OrganizationHandler {
+func NewOrganizationHandler(orgRepo storage.OrganizationRepository, memberRepo storage.OrganizationMemberRepository, userRepo storage.UserRepository, apiKeyRepo storage.APIKeyRepository, auditor *Auditor) *OrganizationHandler {
 	return &OrganizationHandler{
 		orgRepo:    orgRepo,
 		memberRepo: memberRepo,
 		userRepo:   userRepo,
 		apiKeyRepo: apiKeyRepo,
+		auditor:    auditor,
 	}
 }
 
+// organizationAuditFields returns the fields of an organization recorded in
+// the audit log
+func organizationAuditFields(org *storage.Organization) audit.Fields {
+	fields := audit.Fields{
+		"name":                  org.Name,
+		"slug":                  org.Slug,
+		"tier":                  org.Tier,
+		"monthly_request_limit": org.MonthlyRequestLimit,
+		"github_org_name":       org.GitHubOrgName,
+	}
+	if org.GitHubOrgID != nil {
+		fields["github_org_id"] = *org.GitHubOrgID
+	}
+	return fields
+}
+
 // getUserOrganizationID looks up the user's organization from the database
 func (h *OrganizationHandler) getUserOrganizationID(c *gin.Context) (string, error) {
 	// First try to get user from database by their IDP subject
@@ -283,6 +302,7 @@ func (h *OrganizationHandler) UpdateOrganization(c *gin.Context) {
 	}
 
 	oldTier := org.Tier
+	before := organizationAuditFields(org)
 
 	if req.Name != nil {
 		org.Name = *req.Name
@@ -322,6 +342,9 @@ func (h *OrganizationHandler) UpdateOrganization(c *gin.Context) {
 		return
 	}
 
+	h.auditor.Record(c, orgID, audit.ActionOrganizationUpdate, audit.TargetOrganization, orgID,
+		before, organizationAuditFields(org))
+
 	// If tier changed, update all API keys for this organization
 	if oldTier != org.Tier {
 		rateLimitRPM := rateLimitForTier(org.Tier)
@@ -392,6 +415,8 @@ func (h *OrganizationHandler) DeleteOrganization(c *gin.Context) {
 		return
 	}
 
+	h.auditor.Record(c, orgID, audit.ActionOrganizationDelete, audit.TargetOrganization, orgID, nil, nil)
+
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 	})
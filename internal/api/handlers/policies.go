@@ -0,0 +1,527 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/regrada-ai/regrada-be/internal/domain"
+	"github.com/regrada-ai/regrada-be/internal/storage"
+)
+
+// policyIDPattern matches the policy IDs the CLI reports in violations
+var policyIDPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._:-]{0,127}$`)
+
+type PolicyHandler struct {
+	policyRepo    storage.PolicyRepository
+	retentionRepo storage.RetentionRepository
+}
+
+func NewPolicyHandler(policyRepo storage.PolicyRepository, retentionRepo storage.RetentionRepository) *PolicyHandler {
+	return &PolicyHandler{
+		policyRepo:    policyRepo,
+		retentionRepo: retentionRepo,
+	}
+}
+
+type policyRequest struct {
+	Description string                 `json:"description" binding:"max=2000"`
+	Severity    storage.PolicySeverity `json:"severity" binding:"required,oneof=info warn error"`
+	Owner       string                 `json:"owner" binding:"max=255"`
+	Enabled     *bool                  `json:"enabled"`
+	Blocking    bool                   `json:"blocking"`
+}
+
+// policyStats is one policy's violations over a time range
+type policyStats struct {
+	PolicyID    string                   `json:"policy_id"`
+	Registered  bool                     `json:"registered"`
+	Description string                   `json:"description,omitempty"`
+	Severity    storage.PolicySeverity   `json:"severity,omitempty"`
+	Enabled     bool                     `json:"enabled"`
+	Blocking    bool                     `json:"blocking"`
+	Violations  int                      `json:"violations"`
+	Runs        int                      `json:"runs"`
+	Series      []storage.ViolationCount `json:"series"`
+}
+
+// ListOrganizationPolicies lists the organization-wide policies
+// @Summary      List organization policies
+// @Description  List the policies registered for every project of the organization
+// @Tags         policies
+// @Produce      json
+// @Param        orgID  path      string  true  "Organization ID"
+// @Success      200    {object}  map[string]interface{} "Policies"
+// @Failure      401    {object}  map[string]interface{} "Unauthorized"
+// @Failure      403    {object}  map[string]interface{} "Forbidden"
+// @Failure      500    {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/organizations/{orgID}/policies [get]
+func (h *PolicyHandler) ListOrganizationPolicies(c *gin.Context) {
+	orgID := c.Param("orgID")
+	if !requireOrganization(c, orgID, "Cannot view a different organization") {
+		return
+	}
+
+	policies, err := h.policyRepo.List(c.Request.Context(), orgID, "")
+	if err != nil {
+		writePolicyError(c, err, "Failed to fetch policies")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"policies": policies,
+		"count":    len(policies),
+	})
+}
+
+// GetOrganizationPolicy returns an organization-wide policy
+// @Summary      Get organization policy
+// @Description  Get an organization-wide policy by its policy ID
+// @Tags         policies
+// @Produce      json
+// @Param        orgID     path      string  true  "Organization ID"
+// @Param        policyID  path      string  true  "Policy ID"
+// @Success      200       {object}  map[string]interface{} "Policy"
+// @Failure      401       {object}  map[string]interface{} "Unauthorized"
+// @Failure      403       {object}  map[string]interface{} "Forbidden"
+// @Failure      404       {object}  map[string]interface{} "Policy not found"
+// @Failure      500       {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/organizations/{orgID}/policies/{policyID} [get]
+func (h *PolicyHandler) GetOrganizationPolicy(c *gin.Context) {
+	orgID := c.Param("orgID")
+	if !requireOrganization(c, orgID, "Cannot view a different organization") {
+		return
+	}
+
+	policy, err := h.policyRepo.Get(c.Request.Context(), orgID, "", c.Param("policyID"))
+	if err != nil {
+		writePolicyError(c, err, "Failed to fetch policy")
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
+
+// PutOrganizationPolicy creates or replaces an organization-wide policy
+// @Summary      Create or replace organization policy
+// @Description  Register a policy for every project of the organization, or replace it. Projects may override it with a policy of the same ID. Requires the admin role.
+// @Tags         policies
+// @Accept       json
+// @Produce      json
+// @Param        orgID     path      string                  true  "Organization ID"
+// @Param        policyID  path      string                  true  "Policy ID"
+// @Param        request   body      map[string]interface{}  true  "description, severity (info, warn, error), owner, enabled, blocking"
+// @Success      201       {object}  map[string]interface{} "Policy created"
+// @Success      200       {object}  map[string]interface{} "Policy replaced"
+// @Failure      400       {object}  map[string]interface{} "Invalid request"
+// @Failure      401       {object}  map[string]interface{} "Unauthorized"
+// @Failure      403       {object}  map[string]interface{} "Forbidden"
+// @Failure      500       {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/organizations/{orgID}/policies/{policyID} [put]
+func (h *PolicyHandler) PutOrganizationPolicy(c *gin.Context) {
+	orgID := c.Param("orgID")
+	if !requireOrganizationAdmin(c, orgID, "Admin role required to manage organization policies") {
+		return
+	}
+
+	h.putPolicy(c, orgID, "")
+}
+
+// DeleteOrganizationPolicy deletes an organization-wide policy
+// @Summary      Delete organization policy
+// @Description  Remove an organization-wide policy. Requires the admin role.
+// @Tags         policies
+// @Param        orgID     path  string  true  "Organization ID"
+// @Param        policyID  path  string  true  "Policy ID"
+// @Success      204       "Policy deleted"
+// @Failure      401       {object}  map[string]interface{} "Unauthorized"
+// @Failure      403       {object}  map[string]interface{} "Forbidden"
+// @Failure      404       {object}  map[string]interface{} "Policy not found"
+// @Failure      500       {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/organizations/{orgID}/policies/{policyID} [delete]
+func (h *PolicyHandler) DeleteOrganizationPolicy(c *gin.Context) {
+	orgID := c.Param("orgID")
+	if !requireOrganizationAdmin(c, orgID, "Admin role required to manage organization policies") {
+		return
+	}
+
+	if err := h.policyRepo.Delete(c.Request.Context(), orgID, "", c.Param("policyID")); err != nil {
+		writePolicyError(c, err, "Failed to delete policy")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListProjectPolicies lists the policies in effect for a project
+// @Summary      List project policies
+// @Description  List the policies test runs of the project are validated against: the project's own policies and the organization-wide policies it does not override. Project policies have a project_id.
+// @Tags         policies
+// @Produce      json
+// @Param        projectID  path      string  true  "Project ID"
+// @Success      200        {object}  map[string]interface{} "Policies"
+// @Failure      401        {object}  map[string]interface{} "Unauthorized"
+// @Failure      404        {object}  map[string]interface{} "Project not found"
+// @Failure      500        {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/policies [get]
+func (h *PolicyHandler) ListProjectPolicies(c *gin.Context) {
+	project := loadProject(c, h.retentionRepo)
+	if project == nil {
+		return
+	}
+
+	policies, err := h.policyRepo.Effective(c.Request.Context(), project.OrganizationID, project.ProjectID)
+	if err != nil {
+		writePolicyError(c, err, "Failed to fetch policies")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"policies": policies,
+		"count":    len(policies),
+	})
+}
+
+// GetProjectPolicy returns a policy in effect for a project
+// @Summary      Get project policy
+// @Description  Get the project's policy with the given ID, or the organization-wide policy if the project does not override it
+// @Tags         policies
+// @Produce      json
+// @Param        projectID  path      string  true  "Project ID"
+// @Param        policyID   path      string  true  "Policy ID"
+// @Success      200        {object}  map[string]interface{} "Policy"
+// @Failure      401        {object}  map[string]interface{} "Unauthorized"
+// @Failure      404        {object}  map[string]interface{} "Policy not found"
+// @Failure      500        {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/policies/{policyID} [get]
+func (h *PolicyHandler) GetProjectPolicy(c *gin.Context) {
+	project := loadProject(c, h.retentionRepo)
+	if project == nil {
+		return
+	}
+
+	ctx := c.Request.Context()
+	policyID := c.Param("policyID")
+	policy, err := h.policyRepo.Get(ctx, project.OrganizationID, project.ProjectID, policyID)
+	if err == storage.ErrNotFound {
+		policy, err = h.policyRepo.Get(ctx, project.OrganizationID, "", policyID)
+	}
+	if err != nil {
+		writePolicyError(c, err, "Failed to fetch policy")
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
+
+// PutProjectPolicy creates or replaces a project policy
+// @Summary      Create or replace project policy
+// @Description  Register a policy for the project, or replace it. A project policy overrides the organization-wide policy with the same ID.
+// @Tags         policies
+// @Accept       json
+// @Produce      json
+// @Param        projectID  path      string                  true  "Project ID"
+// @Param        policyID   path      string                  true  "Policy ID"
+// @Param        request    body      map[string]interface{}  true  "description, severity (info, warn, error), owner, enabled, blocking"
+// @Success      201        {object}  map[string]interface{} "Policy created"
+// @Success      200        {object}  map[string]interface{} "Policy replaced"
+// @Failure      400        {object}  map[string]interface{} "Invalid request"
+// @Failure      401        {object}  map[string]interface{} "Unauthorized"
+// @Failure      403        {object}  map[string]interface{} "Viewers cannot manage policies"
+// @Failure      404        {object}  map[string]interface{} "Project not found"
+// @Failure      500        {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/policies/{policyID} [put]
+func (h *PolicyHandler) PutProjectPolicy(c *gin.Context) {
+	if !requireEditor(c, "Viewers cannot manage policies") {
+		return
+	}
+	project := loadProject(c, h.retentionRepo)
+	if project == nil {
+		return
+	}
+
+	h.putPolicy(c, project.OrganizationID, project.ProjectID)
+}
+
+// DeleteProjectPolicy deletes a project policy
+// @Summary      Delete project policy
+// @Description  Remove the project's own policy. An organization-wide policy with the same ID applies again.
+// @Tags         policies
+// @Param        projectID  path  string  true  "Project ID"
+// @Param        policyID   path  string  true  "Policy ID"
+// @Success      204        "Policy deleted"
+// @Failure      401        {object}  map[string]interface{} "Unauthorized"
+// @Failure      403        {object}  map[string]interface{} "Viewers cannot manage policies"
+// @Failure      404        {object}  map[string]interface{} "Policy not found"
+// @Failure      500        {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/policies/{policyID} [delete]
+func (h *PolicyHandler) DeleteProjectPolicy(c *gin.Context) {
+	if !requireEditor(c, "Viewers cannot manage policies") {
+		return
+	}
+	project := loadProject(c, h.retentionRepo)
+	if project == nil {
+		return
+	}
+
+	if err := h.policyRepo.Delete(c.Request.Context(), project.OrganizationID, project.ProjectID, c.Param("policyID")); err != nil {
+		writePolicyError(c, err, "Failed to delete policy")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetViolationAnalytics reports violations per policy over time
+// @Summary      Violation analytics
+// @Description  Violations reported by the project's test runs per policy and day or week, with each policy's registry entry. Policy IDs no longer registered are reported with registered false.
+// @Tags         policies
+// @Produce      json
+// @Param        projectID  path      string  true   "Project ID"
+// @Param        from       query     string  false  "Earliest run time, inclusive (RFC 3339)"
+// @Param        to         query     string  false  "Latest run time, exclusive (RFC 3339)"
+// @Param        interval   query     string  false  "day (default) or week"
+// @Success      200        {object}  map[string]interface{} "Violations per policy"
+// @Failure      400        {object}  map[string]interface{} "Invalid time range or interval"
+// @Failure      401        {object}  map[string]interface{} "Unauthorized"
+// @Failure      404        {object}  map[string]interface{} "Project not found"
+// @Failure      500        {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/analytics/violations [get]
+func (h *PolicyHandler) GetViolationAnalytics(c *gin.Context) {
+	project := loadProject(c, h.retentionRepo)
+	if project == nil {
+		return
+	}
+
+	from, to, ok := parseTimeRange(c)
+	if !ok {
+		return
+	}
+
+	interval := storage.ViolationInterval(c.DefaultQuery("interval", string(storage.ViolationsByDay)))
+	if interval != storage.ViolationsByDay && interval != storage.ViolationsByWeek {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": "interval must be day or week",
+			},
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	counts, err := h.policyRepo.ViolationCounts(ctx, project.ProjectID, from, to, interval)
+	if err != nil {
+		writePolicyError(c, err, "Failed to fetch violations")
+		return
+	}
+	policies, err := h.policyRepo.Effective(ctx, project.OrganizationID, project.ProjectID)
+	if err != nil {
+		writePolicyError(c, err, "Failed to fetch policies")
+		return
+	}
+
+	stats := make(map[string]*policyStats)
+	var total int
+	for _, count := range counts {
+		s := stats[count.PolicyID]
+		if s == nil {
+			s = &policyStats{PolicyID: count.PolicyID}
+			stats[count.PolicyID] = s
+		}
+		s.Violations += count.Count
+		s.Runs += count.Runs
+		s.Series = append(s.Series, count)
+		total += count.Count
+	}
+	for _, policy := range policies {
+		if s := stats[policy.PolicyID]; s != nil {
+			s.Registered = true
+			s.Description = policy.Description
+			s.Severity = policy.Severity
+			s.Enabled = policy.Enabled
+			s.Blocking = policy.Blocking
+		}
+	}
+
+	result := make([]*policyStats, 0, len(stats))
+	for _, s := range stats {
+		result = append(result, s)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Violations != result[j].Violations {
+			return result[i].Violations > result[j].Violations
+		}
+		return result[i].PolicyID < result[j].PolicyID
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"interval":   interval,
+		"policies":   result,
+		"violations": total,
+	})
+}
+
+// putPolicy binds a policy from the request and stores it in the scope of
+// the organization, or of the project if projectID is set
+func (h *PolicyHandler) putPolicy(c *gin.Context, orgID, projectID string) {
+	policyID := c.Param("policyID")
+	if !policyIDPattern.MatchString(policyID) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": "policy ID must be 1-128 letters, digits, '.', '_', ':', or '-', starting with a letter or digit",
+			},
+		})
+		return
+	}
+
+	var req policyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("[PutPolicy] binding error: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": "Invalid request parameters",
+			},
+		})
+		return
+	}
+
+	policy := &storage.Policy{
+		OrganizationID: orgID,
+		ProjectID:      projectID,
+		PolicyID:       policyID,
+		Description:    req.Description,
+		Severity:       req.Severity,
+		Owner:          req.Owner,
+		Enabled:        req.Enabled == nil || *req.Enabled,
+		Blocking:       req.Blocking,
+		CreatedBy:      c.GetString("user_id"),
+	}
+	created, err := h.policyRepo.Upsert(c.Request.Context(), policy)
+	if err != nil {
+		writePolicyError(c, err, "Failed to save policy")
+		return
+	}
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	c.JSON(status, policy)
+}
+
+// applyPolicies validates a test run's violations against the policies in
+// effect for its project. Each violation takes its policy's severity, and a
+// violation of an enabled blocking policy fails the run. It returns the IDs
+// of the blocking policies violated, or an error message if a violation
+// names an unregistered policy. Projects without any policies accept all
+// violations as reported.
+func applyPolicies(testRun *domain.TestRun, policies []*storage.Policy) ([]string, string) {
+	if len(policies) == 0 {
+		return nil, ""
+	}
+
+	byID := make(map[string]*storage.Policy, len(policies))
+	for _, policy := range policies {
+		byID[policy.PolicyID] = policy
+	}
+
+	var unknown, blocking []string
+	seen := make(map[string]bool)
+	for i := range testRun.Violations {
+		violation := &testRun.Violations[i]
+		policy := byID[violation.PolicyID]
+		if policy == nil {
+			if !seen[violation.PolicyID] {
+				unknown = append(unknown, violation.PolicyID)
+			}
+			seen[violation.PolicyID] = true
+			continue
+		}
+
+		violation.Severity = string(policy.Severity)
+		if policy.Enabled && policy.Blocking && !seen[policy.PolicyID] {
+			blocking = append(blocking, policy.PolicyID)
+		}
+		seen[policy.PolicyID] = true
+	}
+
+	if len(unknown) > 0 {
+		return nil, fmt.Sprintf("violations reference unregistered policies: %s", strings.Join(unknown, ", "))
+	}
+	if len(blocking) > 0 {
+		testRun.Status = "failed"
+	}
+	return blocking, ""
+}
+
+// requireOrganization writes a 403 response and returns false unless the
+// caller belongs to the organization
+func requireOrganization(c *gin.Context, orgID, message string) bool {
+	if c.GetString("organization_id") == orgID {
+		return true
+	}
+	c.JSON(http.StatusForbidden, gin.H{
+		"error": gin.H{
+			"code":    "FORBIDDEN",
+			"message": message,
+		},
+	})
+	return false
+}
+
+// requireOrganizationAdmin writes a 403 response and returns false unless the
+// caller is an admin of the organization
+func requireOrganizationAdmin(c *gin.Context, orgID, message string) bool {
+	if !requireOrganization(c, orgID, "Cannot update a different organization") {
+		return false
+	}
+	if c.GetString("role") == string(storage.UserRoleAdmin) {
+		return true
+	}
+	c.JSON(http.StatusForbidden, gin.H{
+		"error": gin.H{
+			"code":    "FORBIDDEN",
+			"message": message,
+		},
+	})
+	return false
+}
+
+// writePolicyError writes the response for a policy repository error
+func writePolicyError(c *gin.Context, err error, message string) {
+	if err == storage.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": gin.H{
+				"code":    "NOT_FOUND",
+				"message": "Policy not found",
+			},
+		})
+		return
+	}
+
+	log.Printf("%s: %v", message, err)
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"error": gin.H{
+			"code":    "INTERNAL_ERROR",
+			"message": message,
+		},
+	})
+}
@@ -7,16 +7,19 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/regrada-ai/regrada-be/internal/audit"
 	"github.com/regrada-ai/regrada-be/internal/storage"
 )
 
 type ProjectHandler struct {
 	projectRepo storage.ProjectRepository
+	auditor     *Auditor
 }
 
-func NewProjectHandler(projectRepo storage.ProjectRepository) *ProjectHandler {
+func NewProjectHandler(projectRepo storage.ProjectRepository, auditor *Auditor) *ProjectHandler {
 	return &ProjectHandler{
 		projectRepo: projectRepo,
+		auditor:     auditor,
 	}
 }
 
@@ -117,6 +120,92 @@ func (h *ProjectHandler) GetProject(c *gin.Context) {
 	c.JSON(http.StatusOK, project)
 }
 
+// DeleteProject soft deletes a project
+func (h *ProjectHandler) DeleteProject(c *gin.Context) {
+	projectID := c.Param("projectID")
+
+	orgID := c.GetString("organization_id")
+	if orgID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in token",
+			},
+		})
+		return
+	}
+
+	// Require admin role to delete projects
+	role := c.GetString("role")
+	if role != "admin" {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": gin.H{
+				"code":    "FORBIDDEN",
+				"message": "Admin role required to delete projects",
+			},
+		})
+		return
+	}
+
+	project, err := h.projectRepo.Get(c.Request.Context(), projectID)
+	if err != nil {
+		if err == storage.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": gin.H{
+					"code":    "NOT_FOUND",
+					"message": "Project not found",
+				},
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Failed to fetch project",
+			},
+		})
+		return
+	}
+
+	if project.OrganizationID != orgID {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": gin.H{
+				"code":    "FORBIDDEN",
+				"message": "Cannot delete projects from a different organization",
+			},
+		})
+		return
+	}
+
+	if err := h.projectRepo.Delete(c.Request.Context(), projectID); err != nil {
+		if err == storage.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": gin.H{
+					"code":    "NOT_FOUND",
+					"message": "Project not found",
+				},
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Failed to delete project",
+			},
+		})
+		return
+	}
+
+	h.auditor.Record(c, orgID, audit.ActionProjectDelete, audit.TargetProject, projectID, audit.Fields{
+		"name": project.Name,
+		"slug": project.Slug,
+	}, nil)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+	})
+}
+
 // ListProjects lists all projects for an organization
 func (h *ProjectHandler) ListProjects(c *gin.Context) {
 	orgID := c.GetString("organization_id")
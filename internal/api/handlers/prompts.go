@@ -0,0 +1,707 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/regrada-ai/regrada-be/internal/domain"
+	"github.com/regrada-ai/regrada-be/internal/storage"
+)
+
+const (
+	maxPromptVersionTemplate = 100000
+	maxPromptVariables       = 100
+	maxPromptVersionLabels   = 20
+	defaultPromptLabel       = "production"
+)
+
+var (
+	// promptNamePattern keeps prompt names usable as a path segment
+	promptNamePattern  = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,254}$`)
+	promptLabelPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]{0,63}$`)
+	promptVarPattern   = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	// templateVarPattern finds the {{variable}} placeholders of a template
+	templateVarPattern = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+)
+
+type PromptHandler struct {
+	promptRepo    storage.PromptRepository
+	retentionRepo storage.RetentionRepository
+}
+
+func NewPromptHandler(promptRepo storage.PromptRepository, retentionRepo storage.RetentionRepository) *PromptHandler {
+	return &PromptHandler{
+		promptRepo:    promptRepo,
+		retentionRepo: retentionRepo,
+	}
+}
+
+type promptRequest struct {
+	Name        string  `json:"name" binding:"required"`
+	Description *string `json:"description" binding:"omitempty,max=2000"`
+}
+
+type promptVersionRequest struct {
+	Template  string                 `json:"template" binding:"required"`
+	Variables []string               `json:"variables"` // defaults to the template's {{variable}} placeholders
+	Params    *domain.SamplingParams `json:"params"`
+	Model     string                 `json:"model" binding:"max=255"`
+	Labels    []string               `json:"labels"`
+}
+
+type promptLabelRequest struct {
+	Version int `json:"version" binding:"required,min=1"`
+}
+
+// CreatePrompt registers a prompt
+// @Summary      Create prompt
+// @Description  Register a named prompt in the project. Add its template with POST .../prompts/{name}/versions.
+// @Tags         prompts
+// @Accept       json
+// @Produce      json
+// @Param        projectID  path      string                  true  "Project ID"
+// @Param        request    body      map[string]interface{}  true  "name, description"
+// @Success      201        {object}  map[string]interface{} "Prompt"
+// @Failure      400        {object}  map[string]interface{} "Invalid request"
+// @Failure      401        {object}  map[string]interface{} "Unauthorized"
+// @Failure      403        {object}  map[string]interface{} "Forbidden"
+// @Failure      404        {object}  map[string]interface{} "Project not found"
+// @Failure      409        {object}  map[string]interface{} "Prompt name already in use"
+// @Failure      500        {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/prompts [post]
+func (h *PromptHandler) CreatePrompt(c *gin.Context) {
+	if !requireEditor(c, "Viewers cannot modify prompts") {
+		return
+	}
+
+	project := loadProject(c, h.retentionRepo)
+	if project == nil {
+		return
+	}
+
+	var req promptRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("[CreatePrompt] binding error: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": "Invalid request parameters",
+			},
+		})
+		return
+	}
+	if !promptNamePattern.MatchString(req.Name) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": "name must be 1-255 letters, digits, '.', '_', or '-', starting with a letter or digit",
+			},
+		})
+		return
+	}
+
+	prompt := &storage.Prompt{
+		ProjectID: project.ProjectID,
+		Name:      req.Name,
+		CreatedBy: c.GetString("user_id"),
+	}
+	if req.Description != nil {
+		prompt.Description = *req.Description
+	}
+
+	if err := h.promptRepo.CreatePrompt(c.Request.Context(), prompt); err != nil {
+		writePromptError(c, err, "Prompt not found", "Failed to create prompt")
+		return
+	}
+
+	c.JSON(http.StatusCreated, prompt)
+}
+
+// ListPrompts returns the project's prompts
+// @Summary      List prompts
+// @Description  List the project's prompts with their latest version and labels
+// @Tags         prompts
+// @Produce      json
+// @Param        projectID  path      string  true  "Project ID"
+// @Success      200        {object}  map[string]interface{} "Prompts"
+// @Failure      401        {object}  map[string]interface{} "Unauthorized"
+// @Failure      404        {object}  map[string]interface{} "Project not found"
+// @Failure      500        {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/prompts [get]
+func (h *PromptHandler) ListPrompts(c *gin.Context) {
+	project := loadProject(c, h.retentionRepo)
+	if project == nil {
+		return
+	}
+
+	prompts, err := h.promptRepo.ListPrompts(c.Request.Context(), project.ProjectID)
+	if err != nil {
+		writePromptError(c, err, "Project not found", "Failed to fetch prompts")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"prompts": prompts,
+		"count":   len(prompts),
+	})
+}
+
+// GetPrompt returns a prompt
+// @Summary      Get prompt
+// @Description  Get a prompt with its latest version number and the versions its labels point at
+// @Tags         prompts
+// @Produce      json
+// @Param        projectID  path      string  true  "Project ID"
+// @Param        name       path      string  true  "Prompt name"
+// @Success      200        {object}  map[string]interface{} "Prompt"
+// @Failure      401        {object}  map[string]interface{} "Unauthorized"
+// @Failure      404        {object}  map[string]interface{} "Prompt not found"
+// @Failure      500        {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/prompts/{name} [get]
+func (h *PromptHandler) GetPrompt(c *gin.Context) {
+	project := loadProject(c, h.retentionRepo)
+	if project == nil {
+		return
+	}
+
+	prompt, err := h.promptRepo.GetPrompt(c.Request.Context(), project.ProjectID, c.Param("name"))
+	if err != nil {
+		writePromptError(c, err, "Prompt not found", "Failed to fetch prompt")
+		return
+	}
+
+	c.JSON(http.StatusOK, prompt)
+}
+
+// UpdatePrompt updates a prompt's description
+// @Summary      Update prompt
+// @Description  Change a prompt's description. Prompts cannot be renamed, since traces refer to them by name.
+// @Tags         prompts
+// @Accept       json
+// @Produce      json
+// @Param        projectID  path      string                  true  "Project ID"
+// @Param        name       path      string                  true  "Prompt name"
+// @Param        request    body      map[string]interface{}  true  "description"
+// @Success      200        {object}  map[string]interface{} "Prompt"
+// @Failure      400        {object}  map[string]interface{} "Invalid request"
+// @Failure      401        {object}  map[string]interface{} "Unauthorized"
+// @Failure      403        {object}  map[string]interface{} "Forbidden"
+// @Failure      404        {object}  map[string]interface{} "Prompt not found"
+// @Failure      500        {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/prompts/{name} [patch]
+func (h *PromptHandler) UpdatePrompt(c *gin.Context) {
+	if !requireEditor(c, "Viewers cannot modify prompts") {
+		return
+	}
+
+	project := loadProject(c, h.retentionRepo)
+	if project == nil {
+		return
+	}
+
+	var req struct {
+		Description *string `json:"description" binding:"omitempty,max=2000"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("[UpdatePrompt] binding error: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": "Invalid request parameters",
+			},
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	prompt, err := h.promptRepo.GetPrompt(ctx, project.ProjectID, c.Param("name"))
+	if err != nil {
+		writePromptError(c, err, "Prompt not found", "Failed to fetch prompt")
+		return
+	}
+	if req.Description != nil {
+		prompt.Description = *req.Description
+	}
+
+	if err := h.promptRepo.UpdatePrompt(ctx, prompt); err != nil {
+		writePromptError(c, err, "Prompt not found", "Failed to update prompt")
+		return
+	}
+
+	c.JSON(http.StatusOK, prompt)
+}
+
+// DeletePrompt deletes a prompt
+// @Summary      Delete prompt
+// @Description  Delete a prompt with all its versions and labels. Traces keep the prompt name and version they reported.
+// @Tags         prompts
+// @Param        projectID  path  string  true  "Project ID"
+// @Param        name       path  string  true  "Prompt name"
+// @Success      204        "Prompt deleted"
+// @Failure      401        {object}  map[string]interface{} "Unauthorized"
+// @Failure      403        {object}  map[string]interface{} "Forbidden"
+// @Failure      404        {object}  map[string]interface{} "Prompt not found"
+// @Failure      500        {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/prompts/{name} [delete]
+func (h *PromptHandler) DeletePrompt(c *gin.Context) {
+	if !requireEditor(c, "Viewers cannot modify prompts") {
+		return
+	}
+
+	project := loadProject(c, h.retentionRepo)
+	if project == nil {
+		return
+	}
+
+	if err := h.promptRepo.DeletePrompt(c.Request.Context(), project.ProjectID, c.Param("name")); err != nil {
+		writePromptError(c, err, "Prompt not found", "Failed to delete prompt")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// CreatePromptVersion adds a version to a prompt
+// @Summary      Create prompt version
+// @Description  Add the next version of a prompt: its template, variables (by default the template's {{variable}} placeholders), default sampling params, and default model. Versions cannot be changed afterwards. Labels given here are moved to the new version.
+// @Tags         prompts
+// @Accept       json
+// @Produce      json
+// @Param        projectID  path      string                  true  "Project ID"
+// @Param        name       path      string                  true  "Prompt name"
+// @Param        request    body      map[string]interface{}  true  "template, variables, params, model, labels"
+// @Success      201        {object}  map[string]interface{} "Prompt version"
+// @Failure      400        {object}  map[string]interface{} "Invalid request"
+// @Failure      401        {object}  map[string]interface{} "Unauthorized"
+// @Failure      403        {object}  map[string]interface{} "Forbidden"
+// @Failure      404        {object}  map[string]interface{} "Prompt not found"
+// @Failure      500        {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/prompts/{name}/versions [post]
+func (h *PromptHandler) CreatePromptVersion(c *gin.Context) {
+	if !requireEditor(c, "Viewers cannot modify prompts") {
+		return
+	}
+
+	project := loadProject(c, h.retentionRepo)
+	if project == nil {
+		return
+	}
+
+	var req promptVersionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("[CreatePromptVersion] binding error: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": "Invalid request parameters",
+			},
+		})
+		return
+	}
+
+	version := &storage.PromptVersion{
+		Template:  req.Template,
+		Variables: req.Variables,
+		Params:    req.Params,
+		Model:     req.Model,
+		Labels:    dedupe(req.Labels),
+		CreatedBy: c.GetString("user_id"),
+	}
+	if version.Variables == nil {
+		version.Variables = templateVariables(req.Template)
+	}
+	if reason := validatePromptVersion(version); reason != "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": reason,
+			},
+		})
+		return
+	}
+
+	if err := h.promptRepo.CreateVersion(c.Request.Context(), project.ProjectID, c.Param("name"), version); err != nil {
+		writePromptError(c, err, "Prompt not found", "Failed to create prompt version")
+		return
+	}
+
+	c.JSON(http.StatusCreated, version)
+}
+
+// ListPromptVersions returns a prompt's versions
+// @Summary      List prompt versions
+// @Description  List a prompt's versions, newest first, with the labels pointing at each
+// @Tags         prompts
+// @Produce      json
+// @Param        projectID  path      string  true  "Project ID"
+// @Param        name       path      string  true  "Prompt name"
+// @Success      200        {object}  map[string]interface{} "Prompt versions"
+// @Failure      401        {object}  map[string]interface{} "Unauthorized"
+// @Failure      404        {object}  map[string]interface{} "Prompt not found"
+// @Failure      500        {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/prompts/{name}/versions [get]
+func (h *PromptHandler) ListPromptVersions(c *gin.Context) {
+	project := loadProject(c, h.retentionRepo)
+	if project == nil {
+		return
+	}
+
+	versions, err := h.promptRepo.ListVersions(c.Request.Context(), project.ProjectID, c.Param("name"))
+	if err != nil {
+		writePromptError(c, err, "Prompt not found", "Failed to fetch prompt versions")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"versions": versions,
+		"count":    len(versions),
+	})
+}
+
+// GetPromptVersion returns one version of a prompt
+// @Summary      Get prompt version
+// @Description  Get a version of a prompt by number
+// @Tags         prompts
+// @Produce      json
+// @Param        projectID  path      string  true  "Project ID"
+// @Param        name       path      string  true  "Prompt name"
+// @Param        version    path      int     true  "Version"
+// @Success      200        {object}  map[string]interface{} "Prompt version"
+// @Failure      400        {object}  map[string]interface{} "Invalid version"
+// @Failure      401        {object}  map[string]interface{} "Unauthorized"
+// @Failure      404        {object}  map[string]interface{} "Prompt version not found"
+// @Failure      500        {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/prompts/{name}/versions/{version} [get]
+func (h *PromptHandler) GetPromptVersion(c *gin.Context) {
+	project := loadProject(c, h.retentionRepo)
+	if project == nil {
+		return
+	}
+
+	number, err := strconv.Atoi(c.Param("version"))
+	if err != nil || number < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": "version must be a positive integer",
+			},
+		})
+		return
+	}
+
+	version, err := h.promptRepo.GetVersion(c.Request.Context(), project.ProjectID, c.Param("name"), number)
+	if err != nil {
+		writePromptError(c, err, "Prompt version not found", "Failed to fetch prompt version")
+		return
+	}
+
+	c.JSON(http.StatusOK, version)
+}
+
+// SetPromptLabel points a label at a prompt version
+// @Summary      Set prompt label
+// @Description  Point a label such as production or staging at a version of the prompt, moving it if it already exists
+// @Tags         prompts
+// @Accept       json
+// @Produce      json
+// @Param        projectID  path      string                  true  "Project ID"
+// @Param        name       path      string                  true  "Prompt name"
+// @Param        label      path      string                  true  "Label"
+// @Param        request    body      map[string]interface{}  true  "version"
+// @Success      200        {object}  map[string]interface{} "Prompt with its labels"
+// @Failure      400        {object}  map[string]interface{} "Invalid request"
+// @Failure      401        {object}  map[string]interface{} "Unauthorized"
+// @Failure      403        {object}  map[string]interface{} "Forbidden"
+// @Failure      404        {object}  map[string]interface{} "Prompt version not found"
+// @Failure      500        {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/prompts/{name}/labels/{label} [put]
+func (h *PromptHandler) SetPromptLabel(c *gin.Context) {
+	if !requireEditor(c, "Viewers cannot modify prompts") {
+		return
+	}
+
+	project := loadProject(c, h.retentionRepo)
+	if project == nil {
+		return
+	}
+
+	label := c.Param("label")
+	if !promptLabelPattern.MatchString(label) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": "label must be 1-64 lowercase letters, digits, '.', '_', or '-'",
+			},
+		})
+		return
+	}
+
+	var req promptLabelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("[SetPromptLabel] binding error: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": "Invalid request parameters",
+			},
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	name := c.Param("name")
+	if err := h.promptRepo.SetLabel(ctx, project.ProjectID, name, label, req.Version); err != nil {
+		writePromptError(c, err, "Prompt version not found", "Failed to set prompt label")
+		return
+	}
+
+	prompt, err := h.promptRepo.GetPrompt(ctx, project.ProjectID, name)
+	if err != nil {
+		writePromptError(c, err, "Prompt not found", "Failed to fetch prompt")
+		return
+	}
+
+	c.JSON(http.StatusOK, prompt)
+}
+
+// DeletePromptLabel removes a prompt label
+// @Summary      Delete prompt label
+// @Description  Remove a label from a prompt
+// @Tags         prompts
+// @Param        projectID  path  string  true  "Project ID"
+// @Param        name       path  string  true  "Prompt name"
+// @Param        label      path  string  true  "Label"
+// @Success      204        "Label deleted"
+// @Failure      401        {object}  map[string]interface{} "Unauthorized"
+// @Failure      403        {object}  map[string]interface{} "Forbidden"
+// @Failure      404        {object}  map[string]interface{} "Label not found"
+// @Failure      500        {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/prompts/{name}/labels/{label} [delete]
+func (h *PromptHandler) DeletePromptLabel(c *gin.Context) {
+	if !requireEditor(c, "Viewers cannot modify prompts") {
+		return
+	}
+
+	project := loadProject(c, h.retentionRepo)
+	if project == nil {
+		return
+	}
+
+	if err := h.promptRepo.DeleteLabel(c.Request.Context(), project.ProjectID, c.Param("name"), c.Param("label")); err != nil {
+		writePromptError(c, err, "Label not found", "Failed to delete prompt label")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// FetchPrompt resolves a prompt for SDKs
+// @Summary      Fetch prompt
+// @Description  Resolve the prompt version an SDK should use: the given version, or the version the label points at (production by default). The response carries an ETag; send it back in If-None-Match to get 304 Not Modified while the label still points at the same version.
+// @Tags         prompts
+// @Produce      json
+// @Param        projectID      path      string  true   "Project ID"
+// @Param        name           path      string  true   "Prompt name"
+// @Param        label          query     string  false  "Label to resolve (default production)"
+// @Param        version        query     int     false  "Version to fetch instead of a label"
+// @Param        If-None-Match  header    string  false  "ETag of the cached version"
+// @Success      200            {object}  map[string]interface{} "Prompt version"
+// @Success      304            "Not modified"
+// @Failure      400            {object}  map[string]interface{} "Invalid request"
+// @Failure      401            {object}  map[string]interface{} "Unauthorized"
+// @Failure      404            {object}  map[string]interface{} "Prompt version not found"
+// @Failure      500            {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/prompts/{name}/fetch [get]
+func (h *PromptHandler) FetchPrompt(c *gin.Context) {
+	project := loadProject(c, h.retentionRepo)
+	if project == nil {
+		return
+	}
+
+	ctx := c.Request.Context()
+	name := c.Param("name")
+	var version *storage.PromptVersion
+	var err error
+	if value := c.Query("version"); value != "" {
+		number, convErr := strconv.Atoi(value)
+		if convErr != nil || number < 1 || c.Query("label") != "" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": gin.H{
+					"code":    "INVALID_REQUEST",
+					"message": "version must be a positive integer and cannot be combined with label",
+				},
+			})
+			return
+		}
+		version, err = h.promptRepo.GetVersion(ctx, project.ProjectID, name, number)
+	} else {
+		version, err = h.promptRepo.GetLabeled(ctx, project.ProjectID, name, c.DefaultQuery("label", defaultPromptLabel))
+	}
+	if err != nil {
+		writePromptError(c, err, "Prompt version not found", "Failed to fetch prompt")
+		return
+	}
+
+	// Versions never change, so the version's ID identifies its content
+	etag := `"` + version.ID + `"`
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", "no-cache")
+	if etagMatches(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	c.JSON(http.StatusOK, version)
+}
+
+// GetPromptAnalytics aggregates traces and test cases per prompt version
+// @Summary      Prompt analytics
+// @Description  Trace counts, latency, and token usage, and test case pass rates, per prompt version over a time range, from the prompt_name and prompt_version that traces and test case results report
+// @Tags         prompts
+// @Produce      json
+// @Param        projectID    path      string  true   "Project ID"
+// @Param        prompt_name  query     string  false  "Only this prompt"
+// @Param        from         query     string  false  "Earliest timestamp, inclusive (RFC 3339)"
+// @Param        to           query     string  false  "Latest timestamp, exclusive (RFC 3339)"
+// @Success      200          {object}  map[string]interface{} "Stats per prompt version"
+// @Failure      400          {object}  map[string]interface{} "Invalid time range"
+// @Failure      401          {object}  map[string]interface{} "Unauthorized"
+// @Failure      404          {object}  map[string]interface{} "Project not found"
+// @Failure      500          {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/analytics/prompts [get]
+func (h *PromptHandler) GetPromptAnalytics(c *gin.Context) {
+	project := loadProject(c, h.retentionRepo)
+	if project == nil {
+		return
+	}
+
+	from, to, ok := parseTimeRange(c)
+	if !ok {
+		return
+	}
+
+	stats, err := h.promptRepo.Stats(c.Request.Context(), project.ProjectID, c.Query("prompt_name"), from, to)
+	if err != nil {
+		writePromptError(c, err, "Project not found", "Failed to fetch prompt analytics")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"versions": stats,
+	})
+}
+
+// validatePromptVersion checks a version's template, variables, model, and
+// labels. It returns why the version is invalid, or "" if it is valid.
+func validatePromptVersion(v *storage.PromptVersion) string {
+	if len(v.Template) > maxPromptVersionTemplate {
+		return fmt.Sprintf("template must be at most %d characters", maxPromptVersionTemplate)
+	}
+	if len(v.Variables) > maxPromptVariables {
+		return fmt.Sprintf("at most %d variables are allowed", maxPromptVariables)
+	}
+	seen := make(map[string]bool, len(v.Variables))
+	for _, variable := range v.Variables {
+		if !promptVarPattern.MatchString(variable) || len(variable) > 64 {
+			return fmt.Sprintf("invalid variable name %q", variable)
+		}
+		if seen[variable] {
+			return fmt.Sprintf("duplicate variable %q", variable)
+		}
+		seen[variable] = true
+	}
+	if len(v.Labels) > maxPromptVersionLabels {
+		return fmt.Sprintf("at most %d labels are allowed", maxPromptVersionLabels)
+	}
+	for _, label := range v.Labels {
+		if !promptLabelPattern.MatchString(label) {
+			return fmt.Sprintf("invalid label %q: labels are 1-64 lowercase letters, digits, '.', '_', or '-'", label)
+		}
+	}
+	return ""
+}
+
+// templateVariables returns the distinct {{variable}} placeholders of a
+// template, in order of first use
+func templateVariables(template string) []string {
+	variables := []string{}
+	seen := make(map[string]bool)
+	for _, match := range templateVarPattern.FindAllStringSubmatch(template, maxPromptVariables+1) {
+		if !seen[match[1]] {
+			seen[match[1]] = true
+			variables = append(variables, match[1])
+		}
+	}
+	return variables
+}
+
+// dedupe returns values without repeats, keeping first-seen order
+func dedupe(values []string) []string {
+	var result []string
+	seen := make(map[string]bool, len(values))
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+// etagMatches reports whether an If-None-Match header lists the ETag
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// writePromptError writes the response for a prompt repository error
+func writePromptError(c *gin.Context, err error, notFound, message string) {
+	switch err {
+	case storage.ErrNotFound:
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": gin.H{
+				"code":    "NOT_FOUND",
+				"message": notFound,
+			},
+		})
+	case storage.ErrAlreadyExists:
+		c.JSON(http.StatusConflict, gin.H{
+			"error": gin.H{
+				"code":    "ALREADY_EXISTS",
+				"message": "A prompt with this name already exists",
+			},
+		})
+	default:
+		log.Printf("%s: %v", message, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": message,
+			},
+		})
+	}
+}
@@ -0,0 +1,444 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/regrada-ai/regrada-be/internal/comparison"
+	"github.com/regrada-ai/regrada-be/internal/domain"
+	"github.com/regrada-ai/regrada-be/internal/gate"
+	"github.com/regrada-ai/regrada-be/internal/stats"
+	"github.com/regrada-ai/regrada-be/internal/storage"
+)
+
+type QualityGateHandler struct {
+	gateRepo       storage.QualityGateRepository
+	testRunRepo    storage.TestRunRepository
+	quarantineRepo storage.QuarantineRepository
+	retentionRepo  storage.RetentionRepository
+}
+
+func NewQualityGateHandler(
+	gateRepo storage.QualityGateRepository,
+	testRunRepo storage.TestRunRepository,
+	quarantineRepo storage.QuarantineRepository,
+	retentionRepo storage.RetentionRepository,
+) *QualityGateHandler {
+	return &QualityGateHandler{
+		gateRepo:       gateRepo,
+		testRunRepo:    testRunRepo,
+		quarantineRepo: quarantineRepo,
+		retentionRepo:  retentionRepo,
+	}
+}
+
+type qualityGateRequest struct {
+	Enabled        *bool                   `json:"enabled"`
+	BaselineBranch string                  `json:"baseline_branch"`
+	Conditions     []storage.GateCondition `json:"conditions" binding:"required"`
+}
+
+// GetQualityGate returns the project's quality gate
+// @Summary      Get quality gate
+// @Description  Get the conditions the project's uploaded test runs are checked against
+// @Tags         quality-gates
+// @Produce      json
+// @Param        projectID  path      string  true  "Project ID"
+// @Success      200        {object}  storage.QualityGate "Quality gate"
+// @Failure      401        {object}  map[string]interface{} "Unauthorized"
+// @Failure      404        {object}  map[string]interface{} "Project or quality gate not found"
+// @Failure      500        {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/quality-gate [get]
+func (h *QualityGateHandler) GetQualityGate(c *gin.Context) {
+	project := loadProject(c, h.retentionRepo)
+	if project == nil {
+		return
+	}
+
+	qualityGate, err := h.gateRepo.Get(c.Request.Context(), project.ProjectID)
+	if err != nil {
+		writeQualityGateError(c, err, "Quality gate not found", "Failed to fetch quality gate")
+		return
+	}
+
+	c.JSON(http.StatusOK, qualityGate)
+}
+
+// PutQualityGate creates or replaces the project's quality gate
+// @Summary      Set quality gate
+// @Description  Create or replace the project's quality gate. Conditions are min_pass_rate (0-1), max_violations (a count, of severity or above, default error), max_case_pass_rate_drop (0-1, per case against the baseline run) and max_p95_latency_increase (0.2 is 20%, against the baseline run). The baseline run is the latest completed run on baseline_branch (default main) uploaded before the checked run.
+// @Tags         quality-gates
+// @Accept       json
+// @Produce      json
+// @Param        projectID  path      string                  true  "Project ID"
+// @Param        request    body      map[string]interface{}  true  "enabled, baseline_branch, conditions"
+// @Success      200        {object}  storage.QualityGate "Quality gate"
+// @Failure      400        {object}  map[string]interface{} "Invalid request"
+// @Failure      401        {object}  map[string]interface{} "Unauthorized"
+// @Failure      403        {object}  map[string]interface{} "Viewers cannot manage quality gates"
+// @Failure      404        {object}  map[string]interface{} "Project not found"
+// @Failure      500        {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/quality-gate [put]
+func (h *QualityGateHandler) PutQualityGate(c *gin.Context) {
+	if !requireEditor(c, "Viewers cannot manage quality gates") {
+		return
+	}
+	project := loadProject(c, h.retentionRepo)
+	if project == nil {
+		return
+	}
+
+	var req qualityGateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("[PutQualityGate] binding error: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": "Invalid request parameters",
+			},
+		})
+		return
+	}
+
+	qualityGate := &storage.QualityGate{
+		ProjectID:      project.ProjectID,
+		Enabled:        req.Enabled == nil || *req.Enabled,
+		BaselineBranch: req.BaselineBranch,
+		Conditions:     req.Conditions,
+		UpdatedBy:      c.GetString("user_id"),
+	}
+	if qualityGate.BaselineBranch == "" {
+		qualityGate.BaselineBranch = "main"
+	}
+	if err := gate.Validate(qualityGate); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": err.Error(),
+			},
+		})
+		return
+	}
+
+	if err := h.gateRepo.Upsert(c.Request.Context(), qualityGate); err != nil {
+		writeQualityGateError(c, err, "Project not found", "Failed to save quality gate")
+		return
+	}
+
+	c.JSON(http.StatusOK, qualityGate)
+}
+
+// DeleteQualityGate removes the project's quality gate
+// @Summary      Delete quality gate
+// @Description  Remove the project's quality gate. Uploaded test runs are no longer given a verdict.
+// @Tags         quality-gates
+// @Param        projectID  path  string  true  "Project ID"
+// @Success      204        "Quality gate deleted"
+// @Failure      401        {object}  map[string]interface{} "Unauthorized"
+// @Failure      403        {object}  map[string]interface{} "Viewers cannot manage quality gates"
+// @Failure      404        {object}  map[string]interface{} "Project or quality gate not found"
+// @Failure      500        {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/quality-gate [delete]
+func (h *QualityGateHandler) DeleteQualityGate(c *gin.Context) {
+	if !requireEditor(c, "Viewers cannot manage quality gates") {
+		return
+	}
+	project := loadProject(c, h.retentionRepo)
+	if project == nil {
+		return
+	}
+
+	if err := h.gateRepo.Delete(c.Request.Context(), project.ProjectID); err != nil {
+		writeQualityGateError(c, err, "Quality gate not found", "Failed to delete quality gate")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetTestRunVerdict checks a stored test run against the quality gate
+// @Summary      Get test run verdict
+// @Description  Check a stored test run against the project's current quality gate and quarantined cases, the same way it is checked on upload
+// @Tags         quality-gates
+// @Produce      json
+// @Param        projectID        path      string  true   "Project ID"
+// @Param        runID            path      string  true   "Run ID"
+// @Param        baseline_run_id  query     string  false  "Run to compare against instead of the latest run on the baseline branch"
+// @Success      200              {object}  gate.Verdict "Verdict"
+// @Failure      401              {object}  map[string]interface{} "Unauthorized"
+// @Failure      404              {object}  map[string]interface{} "Project, test run, baseline run, or enabled quality gate not found"
+// @Failure      500              {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/test-runs/{runID}/verdict [get]
+func (h *QualityGateHandler) GetTestRunVerdict(c *gin.Context) {
+	project := loadProject(c, h.retentionRepo)
+	if project == nil {
+		return
+	}
+
+	testRun, err := h.testRunRepo.Get(c.Request.Context(), project.ProjectID, c.Param("runID"))
+	if err != nil {
+		writeQualityGateError(c, err, "Test run not found", "Failed to fetch test run")
+		return
+	}
+
+	verdict, err := evaluateQualityGate(c.Request.Context(), h.gateRepo, h.testRunRepo, h.quarantineRepo, project.ProjectID, testRun, c.Query("baseline_run_id"))
+	if err != nil {
+		writeQualityGateError(c, err, "Baseline run not found", "Failed to evaluate quality gate")
+		return
+	}
+	if verdict == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": gin.H{
+				"code":    "NOT_FOUND",
+				"message": "The project has no enabled quality gate",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, verdict)
+}
+
+type significanceRequest struct {
+	// Alpha is the significance level, or null for the default
+	Alpha *float64 `json:"alpha"`
+}
+
+// GetSignificance returns the project's significance level
+// @Summary      Get significance level
+// @Description  Get the significance level (alpha) pass rate and latency changes between test runs are tested at
+// @Tags         quality-gates
+// @Produce      json
+// @Param        projectID  path      string  true  "Project ID"
+// @Success      200        {object}  map[string]interface{} "alpha, and whether it is the default"
+// @Failure      401        {object}  map[string]interface{} "Unauthorized"
+// @Failure      404        {object}  map[string]interface{} "Project not found"
+// @Failure      500        {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/significance [get]
+func (h *QualityGateHandler) GetSignificance(c *gin.Context) {
+	project := loadProject(c, h.retentionRepo)
+	if project == nil {
+		return
+	}
+
+	alpha, err := h.gateRepo.GetAlpha(c.Request.Context(), project.ProjectID)
+	if err != nil {
+		writeQualityGateError(c, err, "Project not found", "Failed to fetch significance level")
+		return
+	}
+
+	c.JSON(http.StatusOK, significanceResponse(alpha))
+}
+
+// PutSignificance sets the project's significance level
+// @Summary      Set significance level
+// @Description  Set the significance level (alpha, between 0 and 1 exclusive) pass rate and latency changes between test runs are tested at. A null alpha restores the default of 0.05.
+// @Tags         quality-gates
+// @Accept       json
+// @Produce      json
+// @Param        projectID  path      string                  true  "Project ID"
+// @Param        request    body      map[string]interface{}  true  "alpha"
+// @Success      200        {object}  map[string]interface{} "alpha, and whether it is the default"
+// @Failure      400        {object}  map[string]interface{} "Invalid request"
+// @Failure      401        {object}  map[string]interface{} "Unauthorized"
+// @Failure      403        {object}  map[string]interface{} "Viewers cannot manage quality gates"
+// @Failure      404        {object}  map[string]interface{} "Project not found"
+// @Failure      500        {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/significance [put]
+func (h *QualityGateHandler) PutSignificance(c *gin.Context) {
+	if !requireEditor(c, "Viewers cannot manage quality gates") {
+		return
+	}
+	project := loadProject(c, h.retentionRepo)
+	if project == nil {
+		return
+	}
+
+	var req significanceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("[PutSignificance] binding error: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": "Invalid request parameters",
+			},
+		})
+		return
+	}
+	if req.Alpha != nil && !(*req.Alpha > 0 && *req.Alpha < 1) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": "alpha must be between 0 and 1 exclusive",
+			},
+		})
+		return
+	}
+
+	if err := h.gateRepo.SetAlpha(c.Request.Context(), project.ProjectID, req.Alpha); err != nil {
+		writeQualityGateError(c, err, "Project not found", "Failed to update significance level")
+		return
+	}
+
+	c.JSON(http.StatusOK, significanceResponse(req.Alpha))
+}
+
+func significanceResponse(alpha *float64) gin.H {
+	if alpha == nil {
+		return gin.H{"alpha": stats.DefaultAlpha, "default": true}
+	}
+	return gin.H{"alpha": *alpha, "default": false}
+}
+
+// CompareTestRuns compares a test run with a baseline run case by case
+// @Summary      Compare test runs
+// @Description  Compare the cases two test runs have in common. Each case has its pass rate with a Wilson confidence interval, the pass rate change with the p-value of Fisher's exact test, and the p95 latency change with the p-value of a Mann-Whitney U test, each labeled significant at the project's significance level. Intervals and p-values are left out for cases either run reports only aggregates for. Cases are ordered by pass rate change, largest drop first.
+// @Tags         test-runs
+// @Produce      json
+// @Param        projectID        path      string  true  "Project ID"
+// @Param        runID            path      string  true  "Run ID"
+// @Param        baseline_run_id  query     string  true  "Run to compare against"
+// @Success      200              {object}  comparison.RunComparison "Run comparison"
+// @Failure      400              {object}  map[string]interface{} "Invalid request"
+// @Failure      401              {object}  map[string]interface{} "Unauthorized"
+// @Failure      404              {object}  map[string]interface{} "Project, test run, or baseline run not found"
+// @Failure      500              {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/test-runs/{runID}/compare [get]
+func (h *QualityGateHandler) CompareTestRuns(c *gin.Context) {
+	baselineRunID := c.Query("baseline_run_id")
+	if baselineRunID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": "baseline_run_id is required",
+			},
+		})
+		return
+	}
+
+	project := loadProject(c, h.retentionRepo)
+	if project == nil {
+		return
+	}
+
+	ctx := c.Request.Context()
+	testRun, err := h.testRunRepo.Get(ctx, project.ProjectID, c.Param("runID"))
+	if err != nil {
+		writeQualityGateError(c, err, "Test run not found", "Failed to fetch test run")
+		return
+	}
+	baseline, err := h.testRunRepo.Get(ctx, project.ProjectID, baselineRunID)
+	if err != nil {
+		writeQualityGateError(c, err, "Baseline run not found", "Failed to fetch baseline run")
+		return
+	}
+
+	alpha, err := h.gateRepo.GetAlpha(ctx, project.ProjectID)
+	if err != nil {
+		writeQualityGateError(c, err, "Project not found", "Failed to fetch significance level")
+		return
+	}
+	level := stats.DefaultAlpha
+	if alpha != nil {
+		level = *alpha
+	}
+
+	c.JSON(http.StatusOK, comparison.CompareRuns(testRun, baseline, level))
+}
+
+// evaluateQualityGate checks a test run against the project's quality gate.
+// The baseline is the run baselineRunID if set, and otherwise the latest
+// completed run on the gate's baseline branch before the checked run.
+// Quarantined cases are left out of both runs, and changes from the baseline
+// are tested at the project's significance level. It returns nil if the
+// project has no enabled gate, and ErrNotFound if baselineRunID does not
+// exist.
+func evaluateQualityGate(
+	ctx context.Context,
+	gateRepo storage.QualityGateRepository,
+	testRunRepo storage.TestRunRepository,
+	quarantineRepo storage.QuarantineRepository,
+	projectID string,
+	testRun *domain.TestRun,
+	baselineRunID string,
+) (*gate.Verdict, error) {
+	qualityGate, err := gateRepo.Get(ctx, projectID)
+	if err == storage.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !qualityGate.Enabled {
+		return nil, nil
+	}
+
+	var baseline *domain.TestRun
+	switch {
+	case baselineRunID != "":
+		baseline, err = testRunRepo.Get(ctx, projectID, baselineRunID)
+		if err != nil {
+			return nil, err
+		}
+	case gate.NeedsBaseline(qualityGate):
+		before := testRun.Timestamp
+		if before.IsZero() {
+			before = time.Now()
+		}
+		baseline, err = testRunRepo.Latest(ctx, projectID, qualityGate.BaselineBranch, before, testRun.RunID)
+		if err != nil && err != storage.ErrNotFound {
+			return nil, err
+		}
+	}
+
+	quarantines, err := quarantineRepo.List(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	opts := gate.Options{Quarantined: make(map[string]bool, len(quarantines))}
+	for _, quarantine := range quarantines {
+		opts.Quarantined[quarantine.CaseID] = true
+	}
+	alpha, err := gateRepo.GetAlpha(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	if alpha != nil {
+		opts.Alpha = *alpha
+	}
+
+	return gate.Evaluate(qualityGate, testRun, baseline, opts), nil
+}
+
+// writeQualityGateError writes the response for a quality gate repository error
+func writeQualityGateError(c *gin.Context, err error, notFound, message string) {
+	if err == storage.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": gin.H{
+				"code":    "NOT_FOUND",
+				"message": notFound,
+			},
+		})
+		return
+	}
+
+	log.Printf("%s: %v", message, err)
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"error": gin.H{
+			"code":    "INTERNAL_ERROR",
+			"message": message,
+		},
+	})
+}
@@ -0,0 +1,214 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/regrada-ai/regrada-be/internal/domain"
+	"github.com/regrada-ai/regrada-be/internal/redaction"
+	"github.com/regrada-ai/regrada-be/internal/storage"
+)
+
+type RedactionHandler struct {
+	redactionRepo storage.RedactionPolicyRepository
+	retentionRepo storage.RetentionRepository
+}
+
+func NewRedactionHandler(redactionRepo storage.RedactionPolicyRepository, retentionRepo storage.RetentionRepository) *RedactionHandler {
+	return &RedactionHandler{
+		redactionRepo: redactionRepo,
+		retentionRepo: retentionRepo,
+	}
+}
+
+// defaultRedactionPolicy is returned for projects that have not configured a policy
+func defaultRedactionPolicy() *domain.RedactionPolicy {
+	return &domain.RedactionPolicy{
+		Enabled:   false,
+		Mode:      domain.RedactionModeMask,
+		Detectors: []string{},
+	}
+}
+
+// GetRedactionPolicy returns the project's redaction policy
+// @Summary      Get redaction policy
+// @Description  Get the server-side PII redaction policy applied to traces at ingestion
+// @Tags         redaction
+// @Produce      json
+// @Param        projectID  path      string  true  "Project ID"
+// @Success      200        {object}  map[string]interface{} "Redaction policy"
+// @Failure      401        {object}  map[string]interface{} "Unauthorized"
+// @Failure      404        {object}  map[string]interface{} "Project not found"
+// @Failure      500        {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/redaction-policy [get]
+func (h *RedactionHandler) GetRedactionPolicy(c *gin.Context) {
+	project := loadProject(c, h.retentionRepo)
+	if project == nil {
+		return
+	}
+
+	policy, err := h.redactionRepo.Get(c.Request.Context(), project.ProjectID)
+	if err != nil {
+		if err != storage.ErrNotFound {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": gin.H{
+					"code":    "INTERNAL_ERROR",
+					"message": "Failed to fetch redaction policy",
+				},
+			})
+			return
+		}
+		policy = defaultRedactionPolicy()
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"policy":              policy,
+		"available_detectors": redaction.BuiltinDetectors(),
+	})
+}
+
+// UpdateRedactionPolicy replaces the project's redaction policy
+// @Summary      Update redaction policy
+// @Description  Replace the server-side PII redaction policy for a project
+// @Tags         redaction
+// @Accept       json
+// @Produce      json
+// @Param        projectID  path      string                  true  "Project ID"
+// @Param        policy     body      domain.RedactionPolicy  true  "Redaction policy"
+// @Success      200        {object}  domain.RedactionPolicy "Updated policy"
+// @Failure      400        {object}  map[string]interface{} "Invalid policy"
+// @Failure      401        {object}  map[string]interface{} "Unauthorized"
+// @Failure      403        {object}  map[string]interface{} "Forbidden"
+// @Failure      404        {object}  map[string]interface{} "Project not found"
+// @Failure      500        {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/redaction-policy [put]
+func (h *RedactionHandler) UpdateRedactionPolicy(c *gin.Context) {
+	if !requireEditor(c, "Viewers cannot update the redaction policy") {
+		return
+	}
+	project := loadProject(c, h.retentionRepo)
+	if project == nil {
+		return
+	}
+
+	var policy domain.RedactionPolicy
+	if err := c.ShouldBindJSON(&policy); err != nil {
+		log.Printf("[UpdateRedactionPolicy] binding error: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": "Invalid request parameters",
+			},
+		})
+		return
+	}
+
+	if policy.Mode == "" {
+		policy.Mode = domain.RedactionModeMask
+	}
+
+	if err := redaction.Validate(&policy); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_POLICY",
+				"message": err.Error(),
+			},
+		})
+		return
+	}
+
+	if err := h.redactionRepo.Upsert(c.Request.Context(), project.ProjectID, &policy); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Failed to update redaction policy",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
+
+// TestRedactionPolicy runs a redaction policy against sample text without storing anything
+// @Summary      Dry-run redaction policy
+// @Description  Apply the stored policy (or one supplied in the request) to sample text and return the redacted result
+// @Tags         redaction
+// @Accept       json
+// @Produce      json
+// @Param        projectID  path      string                  true  "Project ID"
+// @Param        request    body      map[string]interface{}  true  "Sample text and optional policy"
+// @Success      200        {object}  map[string]interface{} "Redacted text and matches"
+// @Failure      400        {object}  map[string]interface{} "Invalid request"
+// @Failure      401        {object}  map[string]interface{} "Unauthorized"
+// @Failure      404        {object}  map[string]interface{} "Project not found"
+// @Failure      500        {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/redaction-policy/test [post]
+func (h *RedactionHandler) TestRedactionPolicy(c *gin.Context) {
+	project := loadProject(c, h.retentionRepo)
+	if project == nil {
+		return
+	}
+
+	var req struct {
+		Text   string                  `json:"text" binding:"required"`
+		Policy *domain.RedactionPolicy `json:"policy"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": "Invalid request parameters",
+			},
+		})
+		return
+	}
+
+	stored, err := h.redactionRepo.Get(c.Request.Context(), project.ProjectID)
+	if err != nil && err != storage.ErrNotFound {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Failed to fetch redaction policy",
+			},
+		})
+		return
+	}
+
+	policy := req.Policy
+	switch {
+	case policy != nil && stored != nil:
+		// Hash with the project's key so tokens match stored traces
+		policy.HashKey = stored.HashKey
+	case policy == nil && stored != nil:
+		policy = stored
+	case policy == nil:
+		policy = defaultRedactionPolicy()
+	}
+
+	redactor, err := redaction.New(policy)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_POLICY",
+				"message": err.Error(),
+			},
+		})
+		return
+	}
+
+	redacted, matches := redactor.RedactString(req.Text)
+
+	c.JSON(http.StatusOK, gin.H{
+		"redacted_text": redacted,
+		"matches":       matches,
+		"enabled":       redactor.Enabled(),
+	})
+}
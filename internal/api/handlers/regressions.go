@@ -0,0 +1,325 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/regrada-ai/regrada-be/internal/bisect"
+	"github.com/regrada-ai/regrada-be/internal/storage"
+)
+
+const (
+	defaultBisectRuns = 200
+	maxBisectRuns     = 1000
+	maxBisectCommits  = 10000
+	maxBisectParallel = 16
+
+	defaultRegressionsLimit = 50
+	maxRegressionsLimit     = 500
+)
+
+type RegressionHandler struct {
+	regressionRepo storage.RegressionRepository
+	testRunRepo    storage.TestRunRepository
+	quarantineRepo storage.QuarantineRepository
+	retentionRepo  storage.RetentionRepository
+}
+
+func NewRegressionHandler(
+	regressionRepo storage.RegressionRepository,
+	testRunRepo storage.TestRunRepository,
+	quarantineRepo storage.QuarantineRepository,
+	retentionRepo storage.RetentionRepository,
+) *RegressionHandler {
+	return &RegressionHandler{
+		regressionRepo: regressionRepo,
+		testRunRepo:    testRunRepo,
+		quarantineRepo: quarantineRepo,
+		retentionRepo:  retentionRepo,
+	}
+}
+
+type bisectRequest struct {
+	Branch      string   `json:"branch" binding:"max=255"`
+	CaseID      string   `json:"case_id" binding:"max=255"`
+	MinPassRate *float64 `json:"min_pass_rate"`
+	Runs        int      `json:"runs"`
+	Commits     []string `json:"commits"`
+	Parallel    int      `json:"parallel"`
+}
+
+// bisectDetails is stored as the details of a recorded regression
+type bisectDetails struct {
+	Branch       string   `json:"branch"`
+	Status       string   `json:"status"`
+	MinPassRate  float64  `json:"min_pass_rate"`
+	LastGoodRate float64  `json:"last_good_pass_rate"`
+	FirstBadRate float64  `json:"first_bad_pass_rate"`
+	Untested     []string `json:"untested,omitempty"`
+}
+
+// BisectRegression locates the commit that introduced a regression
+// @Summary      Bisect regression
+// @Description  Find the first bad and last good git SHAs of a failing case from the history of test runs on a branch (default main), ordered by timestamp. A SHA is bad if the case's mean pass rate there is below min_pass_rate (default 0.5). Without case_id, every case failing at its newest tested SHA is bisected, except quarantined cases. Each located regression of a case that is not quarantined is recorded, or updates the case's open regression on the branch; open regressions on the branch of cases that pass again are resolved. If commits lists the branch's commits oldest first (for example from git rev-list --reverse), the untested commits between the last good and first bad SHAs are reported, with the commits CI should run next: the midpoint, or parallel commits splitting the range.
+// @Tags         test-runs
+// @Accept       json
+// @Produce      json
+// @Param        projectID  path      string                  true  "Project ID"
+// @Param        request    body      map[string]interface{}  true  "branch, case_id, min_pass_rate, runs (default 200, max 1000), commits, parallel (default 1, max 16)"
+// @Success      200        {object}  map[string]interface{} "Bisect results and the commits to run next"
+// @Failure      400        {object}  map[string]interface{} "Invalid request"
+// @Failure      401        {object}  map[string]interface{} "Unauthorized"
+// @Failure      403        {object}  map[string]interface{} "Viewers cannot bisect regressions"
+// @Failure      404        {object}  map[string]interface{} "Project or case not found"
+// @Failure      500        {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/regressions/bisect [post]
+func (h *RegressionHandler) BisectRegression(c *gin.Context) {
+	if !requireEditor(c, "Viewers cannot bisect regressions") {
+		return
+	}
+
+	var req bisectRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("[BisectRegression] binding error: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": "Invalid request parameters",
+			},
+		})
+		return
+	}
+
+	opts := bisect.Options{MinPassRate: 0.5, Commits: req.Commits, Parallel: 1}
+	if req.Branch == "" {
+		req.Branch = "main"
+	}
+	if req.Runs == 0 {
+		req.Runs = defaultBisectRuns
+	}
+	if req.MinPassRate != nil {
+		opts.MinPassRate = *req.MinPassRate
+	}
+	if req.Parallel != 0 {
+		opts.Parallel = req.Parallel
+	}
+	reason := ""
+	switch {
+	case opts.MinPassRate <= 0 || opts.MinPassRate > 1:
+		reason = "min_pass_rate must be greater than 0 and at most 1"
+	case req.Runs < 1 || req.Runs > maxBisectRuns:
+		reason = fmt.Sprintf("runs must be between 1 and %d", maxBisectRuns)
+	case len(req.Commits) > maxBisectCommits:
+		reason = fmt.Sprintf("commits can list at most %d SHAs", maxBisectCommits)
+	case opts.Parallel < 1 || opts.Parallel > maxBisectParallel:
+		reason = fmt.Sprintf("parallel must be between 1 and %d", maxBisectParallel)
+	}
+	if reason != "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": reason,
+			},
+		})
+		return
+	}
+
+	project := loadProject(c, h.retentionRepo)
+	if project == nil {
+		return
+	}
+
+	ctx := c.Request.Context()
+	observations, err := h.testRunRepo.CaseHistory(ctx, project.ProjectID, req.Branch, req.Runs)
+	if err != nil {
+		writeRegressionError(c, err, "Failed to fetch case history")
+		return
+	}
+
+	quarantines, err := h.quarantineRepo.List(ctx, project.ProjectID)
+	if err != nil {
+		writeRegressionError(c, err, "Failed to fetch quarantined cases")
+		return
+	}
+	quarantined := make(map[string]bool, len(quarantines))
+	for _, quarantine := range quarantines {
+		quarantined[quarantine.CaseID] = true
+	}
+
+	caseIDs := []string{req.CaseID}
+	if req.CaseID == "" {
+		caseIDs = caseIDs[:0]
+		for _, caseID := range bisect.Regressed(observations, opts.MinPassRate) {
+			if !quarantined[caseID] {
+				caseIDs = append(caseIDs, caseID)
+			}
+		}
+	}
+
+	results := []*bisect.Result{}
+	for _, caseID := range caseIDs {
+		result := bisect.Case(observations, caseID, opts)
+		if result == nil {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": gin.H{
+					"code":    "NOT_FOUND",
+					"message": fmt.Sprintf("case %s has no test runs on %s", caseID, req.Branch),
+				},
+			})
+			return
+		}
+		// A quarantined case can still be bisected by ID, but its regressions
+		// are not recorded
+		if !quarantined[caseID] {
+			if err := h.record(ctx, project.ProjectID, req.Branch, opts, result); err != nil {
+				writeRegressionError(c, err, "Failed to record regression")
+				return
+			}
+		}
+		results = append(results, result)
+	}
+
+	// Suggest each commit once, across cases
+	next := []string{}
+	suggested := make(map[string]bool)
+	for _, result := range results {
+		for _, sha := range result.Next {
+			if !suggested[sha] {
+				suggested[sha] = true
+				next = append(next, sha)
+			}
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"branch":  req.Branch,
+		"results": results,
+		"next":    next,
+	})
+}
+
+// record stores a regression located on the branch, or resolves the case's
+// open regressions on the branch if it passes again
+func (h *RegressionHandler) record(ctx context.Context, projectID, branch string, opts bisect.Options, result *bisect.Result) error {
+	switch result.Status {
+	case bisect.StatusNotRegressed:
+		return h.regressionRepo.Resolve(ctx, projectID, branch, result.CaseID)
+	case bisect.StatusNoGoodRun:
+		// Nothing to report without a last good SHA
+		return nil
+	}
+
+	details, err := json.Marshal(bisectDetails{
+		Branch:       branch,
+		Status:       result.Status,
+		MinPassRate:  opts.MinPassRate,
+		LastGoodRate: result.LastGoodRate,
+		FirstBadRate: result.FirstBadRate,
+		Untested:     result.Untested,
+	})
+	if err != nil {
+		return err
+	}
+
+	severity := "warning"
+	if result.FirstBadRate == 0 {
+		severity = "error"
+	}
+	return h.regressionRepo.Upsert(ctx, &storage.RegressionDetection{
+		ProjectID:        projectID,
+		CaseID:           result.CaseID,
+		Branch:           branch,
+		RegressionGitSHA: result.FirstBadSHA,
+		LastGoodGitSHA:   result.LastGoodSHA,
+		RegressionType:   storage.RegressionPassRate,
+		Severity:         severity,
+		Details:          details,
+	})
+}
+
+// ListRegressions lists the project's regressions
+// @Summary      List regressions
+// @Description  List the project's located regressions, newest first, with the first bad (regression_git_sha) and last good git SHAs. Only open regressions are listed unless resolved is true.
+// @Tags         test-runs
+// @Produce      json
+// @Param        projectID  path      string  true   "Project ID"
+// @Param        resolved   query     bool    false  "Include resolved regressions"
+// @Param        limit      query     int     false  "Page size (default 50, max 500)"
+// @Param        offset     query     int     false  "Page offset"
+// @Success      200        {object}  map[string]interface{} "Regressions"
+// @Failure      400        {object}  map[string]interface{} "Invalid request"
+// @Failure      401        {object}  map[string]interface{} "Unauthorized"
+// @Failure      404        {object}  map[string]interface{} "Project not found"
+// @Failure      500        {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/regressions [get]
+func (h *RegressionHandler) ListRegressions(c *gin.Context) {
+	limit, offset, includeResolved := defaultRegressionsLimit, 0, false
+	reason := ""
+	if value := c.Query("resolved"); value != "" {
+		var err error
+		includeResolved, err = strconv.ParseBool(value)
+		if err != nil {
+			reason = "resolved must be true or false"
+		}
+	}
+	if value := c.Query("limit"); value != "" {
+		var err error
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxRegressionsLimit {
+			reason = fmt.Sprintf("limit must be between 1 and %d", maxRegressionsLimit)
+		}
+	}
+	if value := c.Query("offset"); value != "" {
+		var err error
+		offset, err = strconv.Atoi(value)
+		if err != nil || offset < 0 {
+			reason = "offset must be a non-negative integer"
+		}
+	}
+	if reason != "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": reason,
+			},
+		})
+		return
+	}
+
+	project := loadProject(c, h.retentionRepo)
+	if project == nil {
+		return
+	}
+
+	regressions, err := h.regressionRepo.List(c.Request.Context(), project.ProjectID, includeResolved, limit, offset)
+	if err != nil {
+		writeRegressionError(c, err, "Failed to fetch regressions")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"regressions": regressions,
+		"count":       len(regressions),
+	})
+}
+
+// writeRegressionError writes the response for a regression or test run
+// repository error
+func writeRegressionError(c *gin.Context, err error, message string) {
+	log.Printf("%s: %v", message, err)
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"error": gin.H{
+			"code":    "INTERNAL_ERROR",
+			"message": message,
+		},
+	})
+}
@@ -0,0 +1,441 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/regrada-ai/regrada-be/internal/retention"
+	"github.com/regrada-ai/regrada-be/internal/storage"
+)
+
+type RetentionHandler struct {
+	retentionRepo storage.RetentionRepository
+	purger        *retention.Purger
+}
+
+func NewRetentionHandler(retentionRepo storage.RetentionRepository, purger *retention.Purger) *RetentionHandler {
+	return &RetentionHandler{
+		retentionRepo: retentionRepo,
+		purger:        purger,
+	}
+}
+
+// projectRetentionReport describes a project's retention period and what the
+// next purge will delete
+type projectRetentionReport struct {
+	ProjectID         string                 `json:"project_id"`
+	ProjectName       string                 `json:"project_name"`
+	RetentionDays     int                    `json:"retention_days"`
+	Source            string                 `json:"source"` // tier, organization, or project
+	ProjectOverride   *int                   `json:"project_override"`
+	ExpiresBefore     time.Time              `json:"expires_before"`
+	SoftDeletedBefore time.Time              `json:"soft_deleted_before"`
+	Purgeable         *storage.PurgeEstimate `json:"purgeable"`
+}
+
+type retentionRequest struct {
+	// RetentionDays sets the override; null clears it
+	RetentionDays *int `json:"retention_days"`
+}
+
+func (h *RetentionHandler) report(ctx context.Context, project *storage.ProjectRetention, now time.Time) (*projectRetentionReport, error) {
+	days, source := retention.Effective(project)
+	expiredBefore, deletedBefore := retention.Cutoffs(now, days)
+
+	estimate, err := h.retentionRepo.EstimatePurge(ctx, project.ProjectID, expiredBefore, deletedBefore)
+	if err != nil {
+		return nil, err
+	}
+
+	return &projectRetentionReport{
+		ProjectID:         project.ProjectID,
+		ProjectName:       project.ProjectName,
+		RetentionDays:     days,
+		Source:            source,
+		ProjectOverride:   project.ProjectDays,
+		ExpiresBefore:     expiredBefore,
+		SoftDeletedBefore: deletedBefore,
+		Purgeable:         estimate,
+	}, nil
+}
+
+// nextPurgeAt returns when the next purge is expected, or nil if unknown
+func (h *RetentionHandler) nextPurgeAt(ctx context.Context) *time.Time {
+	if h.purger == nil {
+		return nil
+	}
+	next, err := h.purger.NextRun(ctx)
+	if err != nil {
+		log.Printf("Failed to read next retention purge time: %v", err)
+		return nil
+	}
+	if next.IsZero() {
+		return nil
+	}
+	return &next
+}
+
+// validateRetentionDays checks an override against the tier maximum
+func validateRetentionDays(days *int, tier string) string {
+	if days == nil {
+		return ""
+	}
+	maxDays := retention.MaxDaysForTier(tier)
+	if *days < 1 || *days > maxDays {
+		return fmt.Sprintf("retention_days must be between 1 and %d for the %s tier", maxDays, tier)
+	}
+	return ""
+}
+
+// loadOrganization fetches an organization's retention settings. It writes an
+// error response and returns nil on failure.
+func (h *RetentionHandler) loadOrganization(c *gin.Context, orgID string) *storage.OrganizationRetention {
+	org, err := h.retentionRepo.GetOrganization(c.Request.Context(), orgID)
+	if err != nil {
+		if err == storage.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": gin.H{
+					"code":    "NOT_FOUND",
+					"message": "Organization not found",
+				},
+			})
+			return nil
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Failed to fetch retention settings",
+			},
+		})
+		return nil
+	}
+	return org
+}
+
+// loadProject fetches a project's retention settings and checks that it
+// belongs to the caller's organization. It writes an error response and
+// returns nil on failure.
+func loadProject(c *gin.Context, retentionRepo storage.RetentionRepository) *storage.ProjectRetention {
+	project, err := retentionRepo.GetProject(c.Request.Context(), c.Param("projectID"))
+	if err != nil {
+		if err == storage.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": gin.H{
+					"code":    "NOT_FOUND",
+					"message": "Project not found",
+				},
+			})
+			return nil
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Failed to fetch project",
+			},
+		})
+		return nil
+	}
+
+	if project.OrganizationID != c.GetString("organization_id") {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": gin.H{
+				"code":    "NOT_FOUND",
+				"message": "Project not found",
+			},
+		})
+		return nil
+	}
+
+	return project
+}
+
+// GetOrganizationRetention returns the organization's retention settings and a
+// per-project report of what the next purge will delete
+// @Summary      Get organization retention report
+// @Description  Get the organization's trace retention settings and, for each project, the effective retention period and the traces the next purge will delete
+// @Tags         retention
+// @Produce      json
+// @Param        orgID  path      string  true  "Organization ID"
+// @Success      200    {object}  map[string]interface{} "Retention report"
+// @Failure      401    {object}  map[string]interface{} "Unauthorized"
+// @Failure      403    {object}  map[string]interface{} "Forbidden"
+// @Failure      500    {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/organizations/{orgID}/retention [get]
+func (h *RetentionHandler) GetOrganizationRetention(c *gin.Context) {
+	orgID := c.Param("orgID")
+	ctx := c.Request.Context()
+
+	if c.GetString("organization_id") != orgID {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": gin.H{
+				"code":    "FORBIDDEN",
+				"message": "Cannot view a different organization",
+			},
+		})
+		return
+	}
+
+	org := h.loadOrganization(c, orgID)
+	if org == nil {
+		return
+	}
+
+	projects, err := h.retentionRepo.ListProjects(ctx, orgID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Failed to fetch retention settings",
+			},
+		})
+		return
+	}
+
+	now := time.Now().UTC()
+	reports := make([]*projectRetentionReport, 0, len(projects))
+	for _, project := range projects {
+		report, err := h.report(ctx, project, now)
+		if err != nil {
+			log.Printf("Failed to estimate purge for project %s: %v", project.ProjectID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": gin.H{
+					"code":    "INTERNAL_ERROR",
+					"message": "Failed to build retention report",
+				},
+			})
+			return
+		}
+		reports = append(reports, report)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"tier":                   org.Tier,
+		"default_days":           retention.DefaultDaysForTier(org.Tier),
+		"max_days":               retention.MaxDaysForTier(org.Tier),
+		"organization_override":  org.Days,
+		"soft_delete_grace_days": int(retention.SoftDeleteGrace.Hours() / 24),
+		"next_purge_at":          h.nextPurgeAt(ctx),
+		"projects":               reports,
+	})
+}
+
+// UpdateOrganizationRetention sets or clears the organization-wide retention override
+// @Summary      Update organization retention
+// @Description  Set the organization-wide trace retention period (up to the tier maximum), or null to use the tier default
+// @Tags         retention
+// @Accept       json
+// @Produce      json
+// @Param        orgID    path      string                  true  "Organization ID"
+// @Param        request  body      map[string]interface{}  true  "Retention days"
+// @Success      200      {object}  map[string]interface{} "Updated retention settings"
+// @Failure      400      {object}  map[string]interface{} "Invalid request"
+// @Failure      401      {object}  map[string]interface{} "Unauthorized"
+// @Failure      403      {object}  map[string]interface{} "Forbidden"
+// @Failure      500      {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/organizations/{orgID}/retention [put]
+func (h *RetentionHandler) UpdateOrganizationRetention(c *gin.Context) {
+	orgID := c.Param("orgID")
+
+	if c.GetString("organization_id") != orgID {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": gin.H{
+				"code":    "FORBIDDEN",
+				"message": "Cannot update a different organization",
+			},
+		})
+		return
+	}
+
+	if c.GetString("role") != string(storage.UserRoleAdmin) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": gin.H{
+				"code":    "FORBIDDEN",
+				"message": "Admin role required to update retention",
+			},
+		})
+		return
+	}
+
+	var req retentionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("[UpdateOrganizationRetention] binding error: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": "Invalid request parameters",
+			},
+		})
+		return
+	}
+
+	org := h.loadOrganization(c, orgID)
+	if org == nil {
+		return
+	}
+
+	if reason := validateRetentionDays(req.RetentionDays, org.Tier); reason != "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": reason,
+			},
+		})
+		return
+	}
+
+	if err := h.retentionRepo.SetOrganizationDays(c.Request.Context(), orgID, req.RetentionDays); err != nil {
+		if err == storage.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": gin.H{
+					"code":    "NOT_FOUND",
+					"message": "Organization not found",
+				},
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Failed to update retention",
+			},
+		})
+		return
+	}
+
+	days := retention.DefaultDaysForTier(org.Tier)
+	if req.RetentionDays != nil {
+		days = *req.RetentionDays
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"tier":                  org.Tier,
+		"organization_override": req.RetentionDays,
+		"retention_days":        days,
+	})
+}
+
+// GetProjectRetention returns a project's retention period and what the next purge will delete
+// @Summary      Get project retention report
+// @Description  Get the project's effective trace retention period and the traces the next purge will delete
+// @Tags         retention
+// @Produce      json
+// @Param        projectID  path      string  true  "Project ID"
+// @Success      200        {object}  map[string]interface{} "Retention report"
+// @Failure      401        {object}  map[string]interface{} "Unauthorized"
+// @Failure      404        {object}  map[string]interface{} "Project not found"
+// @Failure      500        {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/retention [get]
+func (h *RetentionHandler) GetProjectRetention(c *gin.Context) {
+	project := loadProject(c, h.retentionRepo)
+	if project == nil {
+		return
+	}
+
+	report, err := h.report(c.Request.Context(), project, time.Now().UTC())
+	if err != nil {
+		log.Printf("Failed to estimate purge for project %s: %v", project.ProjectID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Failed to build retention report",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"retention":     report,
+		"next_purge_at": h.nextPurgeAt(c.Request.Context()),
+	})
+}
+
+// UpdateProjectRetention sets or clears a project's retention override
+// @Summary      Update project retention
+// @Description  Set the project's trace retention period (up to the tier maximum), or null to inherit the organization setting
+// @Tags         retention
+// @Accept       json
+// @Produce      json
+// @Param        projectID  path      string                  true  "Project ID"
+// @Param        request    body      map[string]interface{}  true  "Retention days"
+// @Success      200        {object}  map[string]interface{} "Updated retention report"
+// @Failure      400        {object}  map[string]interface{} "Invalid request"
+// @Failure      401        {object}  map[string]interface{} "Unauthorized"
+// @Failure      403        {object}  map[string]interface{} "Forbidden"
+// @Failure      404        {object}  map[string]interface{} "Project not found"
+// @Failure      500        {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/retention [put]
+func (h *RetentionHandler) UpdateProjectRetention(c *gin.Context) {
+	if c.GetString("role") != string(storage.UserRoleAdmin) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": gin.H{
+				"code":    "FORBIDDEN",
+				"message": "Admin role required to update retention",
+			},
+		})
+		return
+	}
+
+	project := loadProject(c, h.retentionRepo)
+	if project == nil {
+		return
+	}
+
+	var req retentionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("[UpdateProjectRetention] binding error: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": "Invalid request parameters",
+			},
+		})
+		return
+	}
+
+	if reason := validateRetentionDays(req.RetentionDays, project.Tier); reason != "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": reason,
+			},
+		})
+		return
+	}
+
+	if err := h.retentionRepo.SetProjectDays(c.Request.Context(), project.ProjectID, req.RetentionDays); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Failed to update retention",
+			},
+		})
+		return
+	}
+	project.ProjectDays = req.RetentionDays
+
+	report, err := h.report(c.Request.Context(), project, time.Now().UTC())
+	if err != nil {
+		log.Printf("Failed to estimate purge for project %s: %v", project.ProjectID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Failed to build retention report",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"retention": report,
+	})
+}
@@ -0,0 +1,881 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/regrada-ai/regrada-be/internal/storage"
+)
+
+const (
+	maxReviewTraceIDs   = 500
+	maxReviewTags       = 20
+	maxRubricCriteria   = 20
+	defaultReviewItems  = 50
+	maxReviewItemsLimit = 200
+	defaultRubricMin    = 1
+	defaultRubricMax    = 5
+
+	// reviewClaimTTL is how long a claim holds before others can take the item
+	reviewClaimTTL = 2 * time.Hour
+)
+
+type ReviewHandler struct {
+	reviewRepo    storage.ReviewRepository
+	traceRepo     storage.TraceRepository
+	testRunRepo   storage.TestRunRepository
+	memberRepo    storage.OrganizationMemberRepository
+	retentionRepo storage.RetentionRepository
+}
+
+func NewReviewHandler(
+	reviewRepo storage.ReviewRepository,
+	traceRepo storage.TraceRepository,
+	testRunRepo storage.TestRunRepository,
+	memberRepo storage.OrganizationMemberRepository,
+	retentionRepo storage.RetentionRepository,
+) *ReviewHandler {
+	return &ReviewHandler{
+		reviewRepo:    reviewRepo,
+		traceRepo:     traceRepo,
+		testRunRepo:   testRunRepo,
+		memberRepo:    memberRepo,
+		retentionRepo: retentionRepo,
+	}
+}
+
+type reviewQueueRequest struct {
+	Name        string                    `json:"name" binding:"required,max=255"`
+	Description string                    `json:"description"`
+	Rules       storage.ReviewRules       `json:"rules"`
+	Rubric      []storage.RubricCriterion `json:"rubric"`
+}
+
+type addReviewItemsRequest struct {
+	// TraceIDs adds specific traces
+	TraceIDs []string `json:"trace_ids"`
+	// TestRunID adds the failed cases of a test run
+	TestRunID string `json:"test_run_id"`
+}
+
+type assignReviewItemRequest struct {
+	// AssigneeID is the member to assign, or empty to unassign
+	AssigneeID string `json:"assignee_id" binding:"omitempty,uuid"`
+}
+
+type completeReviewItemRequest struct {
+	Scores  map[string]float64 `json:"scores"`
+	Comment string             `json:"comment" binding:"max=10000"`
+}
+
+// validate checks a queue's rules and fills in default rubric ranges. It
+// returns why the queue is invalid, or "" if it is valid.
+func (r *reviewQueueRequest) validate() string {
+	if r.Rules.SampleRate < 0 || r.Rules.SampleRate > 1 || math.IsNaN(r.Rules.SampleRate) {
+		return "rules.sample_rate must be between 0 and 1"
+	}
+	if len(r.Rules.Tags) > maxReviewTags {
+		return fmt.Sprintf("At most %d rules.tags are allowed", maxReviewTags)
+	}
+	if len(r.Rules.FailedEvaluators) > maxReviewTags {
+		return fmt.Sprintf("At most %d rules.failed_evaluators are allowed", maxReviewTags)
+	}
+	for _, evaluatorID := range r.Rules.FailedEvaluators {
+		if uuid.Validate(evaluatorID) != nil {
+			return "rules.failed_evaluators must be evaluator IDs"
+		}
+	}
+	if len(r.Rubric) > maxRubricCriteria {
+		return fmt.Sprintf("At most %d rubric criteria are allowed", maxRubricCriteria)
+	}
+
+	seen := make(map[string]bool, len(r.Rubric))
+	for i := range r.Rubric {
+		criterion := &r.Rubric[i]
+		if criterion.Name == "" || len(criterion.Name) > maxCriterionLength {
+			return fmt.Sprintf("Rubric criterion names must be 1 to %d characters", maxCriterionLength)
+		}
+		if seen[criterion.Name] {
+			return fmt.Sprintf("Rubric criterion %q is listed twice", criterion.Name)
+		}
+		seen[criterion.Name] = true
+
+		if criterion.Min == 0 && criterion.Max == 0 {
+			criterion.Min, criterion.Max = defaultRubricMin, defaultRubricMax
+		}
+		if !(criterion.Min < criterion.Max) || math.IsInf(criterion.Min, 0) || math.IsInf(criterion.Max, 0) {
+			return fmt.Sprintf("Rubric criterion %q needs a finite min below its max", criterion.Name)
+		}
+	}
+	return ""
+}
+
+// CreateReviewQueue creates a review queue
+// @Summary      Create review queue
+// @Description  Create a queue of traces and test cases for human review. Rules sample a fraction of new traces, pick up new traces with given tags or that fail given evaluators, and add the failed cases of uploaded test runs. The rubric lists the scores reviewers give each item (ranges default to 1-5).
+// @Tags         reviews
+// @Accept       json
+// @Produce      json
+// @Param        projectID  path      string                  true  "Project ID"
+// @Param        request    body      map[string]interface{}  true  "name, description, rules, rubric"
+// @Success      201        {object}  map[string]interface{} "Review queue"
+// @Failure      400        {object}  map[string]interface{} "Invalid request"
+// @Failure      401        {object}  map[string]interface{} "Unauthorized"
+// @Failure      403        {object}  map[string]interface{} "Forbidden"
+// @Failure      404        {object}  map[string]interface{} "Project not found"
+// @Failure      409        {object}  map[string]interface{} "Queue name already in use"
+// @Failure      500        {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/review-queues [post]
+func (h *ReviewHandler) CreateReviewQueue(c *gin.Context) {
+	if !requireEditor(c, "Viewers cannot manage review queues") {
+		return
+	}
+
+	project := loadProject(c, h.retentionRepo)
+	if project == nil {
+		return
+	}
+
+	req, ok := bindReviewQueueRequest(c, "CreateReviewQueue")
+	if !ok {
+		return
+	}
+
+	queue := &storage.ReviewQueue{
+		ProjectID:   project.ProjectID,
+		Name:        req.Name,
+		Description: req.Description,
+		Rules:       req.Rules,
+		Rubric:      req.Rubric,
+		CreatedBy:   c.GetString("user_id"),
+	}
+	if err := h.reviewRepo.CreateQueue(c.Request.Context(), queue); err != nil {
+		writeReviewError(c, err, "Review queue not found", "Failed to create review queue")
+		return
+	}
+
+	c.JSON(http.StatusCreated, queue)
+}
+
+// ListReviewQueues returns the project's review queues
+// @Summary      List review queues
+// @Description  List the project's review queues
+// @Tags         reviews
+// @Produce      json
+// @Param        projectID  path      string  true  "Project ID"
+// @Success      200        {object}  map[string]interface{} "Review queues"
+// @Failure      401        {object}  map[string]interface{} "Unauthorized"
+// @Failure      404        {object}  map[string]interface{} "Project not found"
+// @Failure      500        {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/review-queues [get]
+func (h *ReviewHandler) ListReviewQueues(c *gin.Context) {
+	project := loadProject(c, h.retentionRepo)
+	if project == nil {
+		return
+	}
+
+	queues, err := h.reviewRepo.ListQueues(c.Request.Context(), project.ProjectID)
+	if err != nil {
+		writeReviewError(c, err, "Project not found", "Failed to fetch review queues")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"queues": queues,
+		"count":  len(queues),
+	})
+}
+
+// GetReviewQueue returns a review queue
+// @Summary      Get review queue
+// @Description  Get a review queue's rules and rubric
+// @Tags         reviews
+// @Produce      json
+// @Param        projectID  path      string  true  "Project ID"
+// @Param        queueID    path      string  true  "Review queue ID"
+// @Success      200        {object}  map[string]interface{} "Review queue"
+// @Failure      401        {object}  map[string]interface{} "Unauthorized"
+// @Failure      404        {object}  map[string]interface{} "Review queue not found"
+// @Failure      500        {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/review-queues/{queueID} [get]
+func (h *ReviewHandler) GetReviewQueue(c *gin.Context) {
+	queue := h.loadQueue(c)
+	if queue == nil {
+		return
+	}
+
+	c.JSON(http.StatusOK, queue)
+}
+
+// UpdateReviewQueue replaces a review queue's settings
+// @Summary      Update review queue
+// @Description  Replace a review queue's name, description, rules, and rubric. New rules apply to traces and test runs stored from now on.
+// @Tags         reviews
+// @Accept       json
+// @Produce      json
+// @Param        projectID  path      string                  true  "Project ID"
+// @Param        queueID    path      string                  true  "Review queue ID"
+// @Param        request    body      map[string]interface{}  true  "name, description, rules, rubric"
+// @Success      200        {object}  map[string]interface{} "Review queue"
+// @Failure      400        {object}  map[string]interface{} "Invalid request"
+// @Failure      401        {object}  map[string]interface{} "Unauthorized"
+// @Failure      403        {object}  map[string]interface{} "Forbidden"
+// @Failure      404        {object}  map[string]interface{} "Review queue not found"
+// @Failure      409        {object}  map[string]interface{} "Queue name already in use"
+// @Failure      500        {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/review-queues/{queueID} [put]
+func (h *ReviewHandler) UpdateReviewQueue(c *gin.Context) {
+	if !requireEditor(c, "Viewers cannot manage review queues") {
+		return
+	}
+
+	queue := h.loadQueue(c)
+	if queue == nil {
+		return
+	}
+
+	req, ok := bindReviewQueueRequest(c, "UpdateReviewQueue")
+	if !ok {
+		return
+	}
+
+	queue.Name = req.Name
+	queue.Description = req.Description
+	queue.Rules = req.Rules
+	queue.Rubric = req.Rubric
+	if err := h.reviewRepo.UpdateQueue(c.Request.Context(), queue); err != nil {
+		writeReviewError(c, err, "Review queue not found", "Failed to update review queue")
+		return
+	}
+
+	c.JSON(http.StatusOK, queue)
+}
+
+// DeleteReviewQueue deletes a review queue and its items
+// @Summary      Delete review queue
+// @Description  Delete a review queue together with its items and reviews
+// @Tags         reviews
+// @Produce      json
+// @Param        projectID  path      string  true  "Project ID"
+// @Param        queueID    path      string  true  "Review queue ID"
+// @Success      204        "No content"
+// @Failure      401        {object}  map[string]interface{} "Unauthorized"
+// @Failure      403        {object}  map[string]interface{} "Forbidden"
+// @Failure      404        {object}  map[string]interface{} "Review queue not found"
+// @Failure      500        {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/review-queues/{queueID} [delete]
+func (h *ReviewHandler) DeleteReviewQueue(c *gin.Context) {
+	if !requireEditor(c, "Viewers cannot manage review queues") {
+		return
+	}
+
+	project := loadProject(c, h.retentionRepo)
+	if project == nil {
+		return
+	}
+
+	if err := h.reviewRepo.DeleteQueue(c.Request.Context(), project.ProjectID, c.Param("queueID")); err != nil {
+		writeReviewError(c, err, "Review queue not found", "Failed to delete review queue")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// AddReviewItems adds traces or failed test cases to a review queue
+// @Summary      Add review items
+// @Description  Add specific traces, or the failed cases of a test run, to a review queue. Items already in the queue are skipped.
+// @Tags         reviews
+// @Accept       json
+// @Produce      json
+// @Param        projectID  path      string                  true  "Project ID"
+// @Param        queueID    path      string                  true  "Review queue ID"
+// @Param        request    body      map[string]interface{}  true  "trace_ids or test_run_id"
+// @Success      200        {object}  map[string]interface{} "Number of items added"
+// @Failure      400        {object}  map[string]interface{} "Invalid request"
+// @Failure      401        {object}  map[string]interface{} "Unauthorized"
+// @Failure      403        {object}  map[string]interface{} "Forbidden"
+// @Failure      404        {object}  map[string]interface{} "Review queue or test run not found"
+// @Failure      500        {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/review-queues/{queueID}/items [post]
+func (h *ReviewHandler) AddReviewItems(c *gin.Context) {
+	if !requireEditor(c, "Viewers cannot manage review queues") {
+		return
+	}
+
+	var req addReviewItemsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("[AddReviewItems] binding error: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": "Invalid request parameters",
+			},
+		})
+		return
+	}
+
+	reason := ""
+	switch {
+	case (len(req.TraceIDs) == 0) == (req.TestRunID == ""):
+		reason = "Exactly one of trace_ids and test_run_id is required"
+	case len(req.TraceIDs) > maxReviewTraceIDs:
+		reason = fmt.Sprintf("At most %d trace_ids may be added at once", maxReviewTraceIDs)
+	}
+	if reason != "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": reason,
+			},
+		})
+		return
+	}
+
+	queue := h.loadQueue(c)
+	if queue == nil {
+		return
+	}
+
+	ctx := c.Request.Context()
+	var items []*storage.ReviewItem
+	notFound := []string{}
+	if req.TestRunID != "" {
+		testRun, err := h.testRunRepo.Get(ctx, queue.ProjectID, req.TestRunID)
+		if err != nil {
+			writeReviewError(c, err, "Test run not found", "Failed to fetch test run")
+			return
+		}
+		for _, result := range testRun.Results {
+			for _, run := range result.Runs {
+				if run.Pass {
+					continue
+				}
+				items = append(items, &storage.ReviewItem{
+					Kind:   storage.ReviewItemTestCase,
+					RunID:  testRun.RunID,
+					CaseID: result.CaseID,
+				})
+				break
+			}
+		}
+	} else {
+		for _, traceID := range req.TraceIDs {
+			_, err := h.traceRepo.Get(ctx, queue.ProjectID, traceID)
+			if err == storage.ErrNotFound {
+				notFound = append(notFound, traceID)
+				continue
+			}
+			if err != nil {
+				writeReviewError(c, err, "Trace not found", "Failed to fetch traces")
+				return
+			}
+			items = append(items, &storage.ReviewItem{
+				Kind:    storage.ReviewItemTrace,
+				TraceID: traceID,
+			})
+		}
+	}
+
+	for _, item := range items {
+		item.QueueID = queue.ID
+		item.ProjectID = queue.ProjectID
+		item.Reason = storage.ReviewReasonManual
+	}
+	added, err := h.reviewRepo.AddItems(ctx, items)
+	if err != nil {
+		writeReviewError(c, err, "Review queue not found", "Failed to add review items")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"added":     added,
+		"not_found": notFound,
+	})
+}
+
+// ListReviewItems returns the items in a review queue
+// @Summary      List review items
+// @Description  List the items in a review queue, oldest first
+// @Tags         reviews
+// @Produce      json
+// @Param        projectID    path      string  true   "Project ID"
+// @Param        queueID      path      string  true   "Review queue ID"
+// @Param        status       query     string  false  "pending, claimed, or completed"
+// @Param        assignee_id  query     string  false  "Assigned member"
+// @Param        reviewer_id  query     string  false  "Member who claimed or completed the item"
+// @Param        limit        query     int     false  "Maximum items (default 50, max 200)"
+// @Param        offset       query     int     false  "Items to skip"
+// @Success      200          {object}  map[string]interface{} "Review items"
+// @Failure      400          {object}  map[string]interface{} "Invalid request"
+// @Failure      401          {object}  map[string]interface{} "Unauthorized"
+// @Failure      404          {object}  map[string]interface{} "Review queue not found"
+// @Failure      500          {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/review-queues/{queueID}/items [get]
+func (h *ReviewHandler) ListReviewItems(c *gin.Context) {
+	filter := storage.ReviewItemFilter{
+		Status:     storage.ReviewStatus(c.Query("status")),
+		AssigneeID: c.Query("assignee_id"),
+		ReviewerID: c.Query("reviewer_id"),
+		Limit:      defaultReviewItems,
+	}
+
+	reason := ""
+	switch filter.Status {
+	case "", storage.ReviewPending, storage.ReviewClaimed, storage.ReviewCompleted:
+	default:
+		reason = "status must be pending, claimed, or completed"
+	}
+	for name, value := range map[string]string{"assignee_id": filter.AssigneeID, "reviewer_id": filter.ReviewerID} {
+		if value != "" && uuid.Validate(value) != nil {
+			reason = fmt.Sprintf("%s must be a user ID", name)
+		}
+	}
+	if value := c.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxReviewItemsLimit {
+			reason = fmt.Sprintf("limit must be between 1 and %d", maxReviewItemsLimit)
+		}
+		filter.Limit = limit
+	}
+	if value := c.Query("offset"); value != "" {
+		offset, err := strconv.Atoi(value)
+		if err != nil || offset < 0 {
+			reason = "offset must be a non-negative integer"
+		}
+		filter.Offset = offset
+	}
+	if reason != "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": reason,
+			},
+		})
+		return
+	}
+
+	queue := h.loadQueue(c)
+	if queue == nil {
+		return
+	}
+
+	items, err := h.reviewRepo.ListItems(c.Request.Context(), queue.ID, filter)
+	if err != nil {
+		writeReviewError(c, err, "Review queue not found", "Failed to fetch review items")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"items": items,
+		"count": len(items),
+	})
+}
+
+// AssignReviewItem assigns a review item to an org member
+// @Summary      Assign review item
+// @Description  Assign a review item to an org member who can review, or unassign it with an empty assignee_id. Only the assignee can claim an assigned item.
+// @Tags         reviews
+// @Accept       json
+// @Produce      json
+// @Param        projectID  path      string                  true  "Project ID"
+// @Param        queueID    path      string                  true  "Review queue ID"
+// @Param        itemID     path      string                  true  "Review item ID"
+// @Param        request    body      map[string]interface{}  true  "assignee_id"
+// @Success      200        {object}  map[string]interface{} "Review item"
+// @Failure      400        {object}  map[string]interface{} "Assignee cannot review"
+// @Failure      401        {object}  map[string]interface{} "Unauthorized"
+// @Failure      403        {object}  map[string]interface{} "Forbidden"
+// @Failure      404        {object}  map[string]interface{} "Review item not found"
+// @Failure      500        {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/review-queues/{queueID}/items/{itemID}/assignee [put]
+func (h *ReviewHandler) AssignReviewItem(c *gin.Context) {
+	if !requireEditor(c, "Viewers cannot manage review queues") {
+		return
+	}
+
+	var req assignReviewItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("[AssignReviewItem] binding error: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": "Invalid request parameters",
+			},
+		})
+		return
+	}
+
+	queue := h.loadQueue(c)
+	if queue == nil {
+		return
+	}
+
+	ctx := c.Request.Context()
+	if req.AssigneeID != "" {
+		member, err := h.memberRepo.GetByUserAndOrg(ctx, req.AssigneeID, c.GetString("organization_id"))
+		if err != nil && err != storage.ErrNotFound {
+			writeReviewError(c, err, "Member not found", "Failed to fetch assignee")
+			return
+		}
+		if err == storage.ErrNotFound || member.Role == storage.UserRoleViewer {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": gin.H{
+					"code":    "INVALID_REQUEST",
+					"message": "The assignee must be an org member who can review",
+				},
+			})
+			return
+		}
+	}
+
+	item, err := h.reviewRepo.AssignItem(ctx, queue.ID, c.Param("itemID"), req.AssigneeID)
+	if err != nil {
+		writeReviewError(c, err, "Review item not found", "Failed to assign review item")
+		return
+	}
+
+	c.JSON(http.StatusOK, item)
+}
+
+// ClaimNextReviewItem claims the next item to review
+// @Summary      Claim next review item
+// @Description  Claim the oldest pending item assigned to you, or failing that the oldest unassigned one. Claims older than two hours can be taken over. Returns 204 when there is nothing to review.
+// @Tags         reviews
+// @Produce      json
+// @Param        projectID  path      string  true  "Project ID"
+// @Param        queueID    path      string  true  "Review queue ID"
+// @Success      200        {object}  map[string]interface{} "Claimed review item"
+// @Success      204        "Nothing to review"
+// @Failure      401        {object}  map[string]interface{} "Unauthorized"
+// @Failure      403        {object}  map[string]interface{} "Forbidden"
+// @Failure      404        {object}  map[string]interface{} "Review queue not found"
+// @Failure      500        {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/review-queues/{queueID}/claim [post]
+func (h *ReviewHandler) ClaimNextReviewItem(c *gin.Context) {
+	if !requireReviewer(c) {
+		return
+	}
+
+	queue := h.loadQueue(c)
+	if queue == nil {
+		return
+	}
+
+	item, err := h.reviewRepo.ClaimNext(c.Request.Context(), queue.ID, c.GetString("user_id"), reviewClaimTTL)
+	if err != nil {
+		writeReviewError(c, err, "Review item not found", "Failed to claim review item")
+		return
+	}
+	if item == nil {
+		c.Status(http.StatusNoContent)
+		return
+	}
+
+	c.JSON(http.StatusOK, item)
+}
+
+// ClaimReviewItem claims a specific review item
+// @Summary      Claim review item
+// @Description  Claim a pending review item that is unassigned or assigned to you
+// @Tags         reviews
+// @Produce      json
+// @Param        projectID  path      string  true  "Project ID"
+// @Param        queueID    path      string  true  "Review queue ID"
+// @Param        itemID     path      string  true  "Review item ID"
+// @Success      200        {object}  map[string]interface{} "Claimed review item"
+// @Failure      401        {object}  map[string]interface{} "Unauthorized"
+// @Failure      403        {object}  map[string]interface{} "Forbidden"
+// @Failure      404        {object}  map[string]interface{} "Review item not found"
+// @Failure      409        {object}  map[string]interface{} "Item already claimed, completed, or assigned to someone else"
+// @Failure      500        {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/review-queues/{queueID}/items/{itemID}/claim [post]
+func (h *ReviewHandler) ClaimReviewItem(c *gin.Context) {
+	if !requireReviewer(c) {
+		return
+	}
+
+	h.transitionItem(c, "Item is already claimed, completed, or assigned to someone else", func(queueID, itemID, userID string) (*storage.ReviewItem, error) {
+		return h.reviewRepo.ClaimItem(c.Request.Context(), queueID, itemID, userID, reviewClaimTTL)
+	})
+}
+
+// ReleaseReviewItem gives up a claim on a review item
+// @Summary      Release review item
+// @Description  Return an item you have claimed to the queue without reviewing it
+// @Tags         reviews
+// @Produce      json
+// @Param        projectID  path      string  true  "Project ID"
+// @Param        queueID    path      string  true  "Review queue ID"
+// @Param        itemID     path      string  true  "Review item ID"
+// @Success      200        {object}  map[string]interface{} "Review item"
+// @Failure      401        {object}  map[string]interface{} "Unauthorized"
+// @Failure      403        {object}  map[string]interface{} "Forbidden"
+// @Failure      404        {object}  map[string]interface{} "Review item not found"
+// @Failure      409        {object}  map[string]interface{} "Item not claimed by you"
+// @Failure      500        {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/review-queues/{queueID}/items/{itemID}/release [post]
+func (h *ReviewHandler) ReleaseReviewItem(c *gin.Context) {
+	if !requireReviewer(c) {
+		return
+	}
+
+	h.transitionItem(c, "Item is not claimed by you", func(queueID, itemID, userID string) (*storage.ReviewItem, error) {
+		return h.reviewRepo.ReleaseItem(c.Request.Context(), queueID, itemID, userID)
+	})
+}
+
+// CompleteReviewItem submits the review of a claimed item
+// @Summary      Complete review item
+// @Description  Submit rubric scores and a comment for an item you have claimed. Scores must name rubric criteria, fall within their ranges, and include every required criterion.
+// @Tags         reviews
+// @Accept       json
+// @Produce      json
+// @Param        projectID  path      string                  true  "Project ID"
+// @Param        queueID    path      string                  true  "Review queue ID"
+// @Param        itemID     path      string                  true  "Review item ID"
+// @Param        request    body      map[string]interface{}  true  "scores and comment"
+// @Success      200        {object}  map[string]interface{} "Completed review item"
+// @Failure      400        {object}  map[string]interface{} "Invalid scores"
+// @Failure      401        {object}  map[string]interface{} "Unauthorized"
+// @Failure      403        {object}  map[string]interface{} "Forbidden"
+// @Failure      404        {object}  map[string]interface{} "Review item not found"
+// @Failure      409        {object}  map[string]interface{} "Item not claimed by you"
+// @Failure      500        {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/review-queues/{queueID}/items/{itemID}/complete [post]
+func (h *ReviewHandler) CompleteReviewItem(c *gin.Context) {
+	if !requireReviewer(c) {
+		return
+	}
+
+	var req completeReviewItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("[CompleteReviewItem] binding error: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": "Invalid request parameters",
+			},
+		})
+		return
+	}
+
+	queue := h.loadQueue(c)
+	if queue == nil {
+		return
+	}
+
+	if reason := checkRubricScores(queue.Rubric, req.Scores); reason != "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": reason,
+			},
+		})
+		return
+	}
+
+	h.transitionItem(c, "Item is not claimed by you", func(queueID, itemID, userID string) (*storage.ReviewItem, error) {
+		return h.reviewRepo.CompleteItem(c.Request.Context(), queueID, itemID, userID, req.Scores, req.Comment)
+	})
+}
+
+// GetReviewProgress reports how far along a review queue is
+// @Summary      Review queue progress
+// @Description  Count a review queue's items by status, break down assigned, claimed, and completed items per member, and aggregate the rubric scores of completed reviews
+// @Tags         reviews
+// @Produce      json
+// @Param        projectID  path      string  true  "Project ID"
+// @Param        queueID    path      string  true  "Review queue ID"
+// @Success      200        {object}  map[string]interface{} "Progress"
+// @Failure      401        {object}  map[string]interface{} "Unauthorized"
+// @Failure      404        {object}  map[string]interface{} "Review queue not found"
+// @Failure      500        {object}  map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/review-queues/{queueID}/progress [get]
+func (h *ReviewHandler) GetReviewProgress(c *gin.Context) {
+	queue := h.loadQueue(c)
+	if queue == nil {
+		return
+	}
+
+	progress, err := h.reviewRepo.Progress(c.Request.Context(), queue.ID)
+	if err != nil {
+		writeReviewError(c, err, "Review queue not found", "Failed to fetch review progress")
+		return
+	}
+
+	var completion float64
+	if progress.Total > 0 {
+		completion = float64(progress.Completed) / float64(progress.Total)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"progress":   progress,
+		"completion": completion,
+	})
+}
+
+// loadQueue fetches the review queue named in the path, checking that the
+// project belongs to the caller's organization. It writes an error response
+// and returns nil if it cannot.
+func (h *ReviewHandler) loadQueue(c *gin.Context) *storage.ReviewQueue {
+	project := loadProject(c, h.retentionRepo)
+	if project == nil {
+		return nil
+	}
+
+	queue, err := h.reviewRepo.GetQueue(c.Request.Context(), project.ProjectID, c.Param("queueID"))
+	if err != nil {
+		writeReviewError(c, err, "Review queue not found", "Failed to fetch review queue")
+		return nil
+	}
+	return queue
+}
+
+// transitionItem applies a claim, release, or completion to the item named in
+// the path. The repository returns ErrNotFound both for missing items and for
+// items not in the right state, so a miss is told apart by looking the item up.
+func (h *ReviewHandler) transitionItem(c *gin.Context, conflict string, apply func(queueID, itemID, userID string) (*storage.ReviewItem, error)) {
+	queue := h.loadQueue(c)
+	if queue == nil {
+		return
+	}
+
+	itemID := c.Param("itemID")
+	item, err := apply(queue.ID, itemID, c.GetString("user_id"))
+	if err == storage.ErrNotFound {
+		if _, err = h.reviewRepo.GetItem(c.Request.Context(), queue.ID, itemID); err == nil {
+			c.JSON(http.StatusConflict, gin.H{
+				"error": gin.H{
+					"code":    "CONFLICT",
+					"message": conflict,
+				},
+			})
+			return
+		}
+	}
+	if err != nil {
+		writeReviewError(c, err, "Review item not found", "Failed to update review item")
+		return
+	}
+
+	c.JSON(http.StatusOK, item)
+}
+
+// checkRubricScores returns why scores do not fit a rubric, or "" if they do
+func checkRubricScores(rubric []storage.RubricCriterion, scores map[string]float64) string {
+	criteria := make(map[string]storage.RubricCriterion, len(rubric))
+	for _, criterion := range rubric {
+		criteria[criterion.Name] = criterion
+		if _, ok := scores[criterion.Name]; criterion.Required && !ok {
+			return fmt.Sprintf("A score for %q is required", criterion.Name)
+		}
+	}
+	for name, score := range scores {
+		criterion, ok := criteria[name]
+		if !ok {
+			return fmt.Sprintf("%q is not a rubric criterion", name)
+		}
+		if math.IsNaN(score) || score < criterion.Min || score > criterion.Max {
+			return fmt.Sprintf("Score for %q must be between %g and %g", name, criterion.Min, criterion.Max)
+		}
+	}
+	return ""
+}
+
+// bindReviewQueueRequest binds and validates a queue body. It writes an error
+// response and returns false if the body is invalid.
+func bindReviewQueueRequest(c *gin.Context, handler string) (*reviewQueueRequest, bool) {
+	var req reviewQueueRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("[%s] binding error: %v", handler, err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": "Invalid request parameters",
+			},
+		})
+		return nil, false
+	}
+	if reason := req.validate(); reason != "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": reason,
+			},
+		})
+		return nil, false
+	}
+	return &req, true
+}
+
+// requireReviewer writes a forbidden response and returns false unless the
+// caller is a signed-in member allowed to submit reviews. Viewers can follow
+// a queue's progress but not review, and API keys act for no member.
+func requireReviewer(c *gin.Context) bool {
+	message := ""
+	switch {
+	case c.GetString("user_id") == "":
+		message = "Reviews must be submitted by a signed-in member"
+	case c.GetString("role") == string(storage.UserRoleViewer):
+		message = "Viewers cannot submit reviews"
+	default:
+		return true
+	}
+	c.JSON(http.StatusForbidden, gin.H{
+		"error": gin.H{
+			"code":    "FORBIDDEN",
+			"message": message,
+		},
+	})
+	return false
+}
+
+// writeReviewError writes the response for a review repository error
+func writeReviewError(c *gin.Context, err error, notFound, message string) {
+	switch err {
+	case storage.ErrNotFound:
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": gin.H{
+				"code":    "NOT_FOUND",
+				"message": notFound,
+			},
+		})
+	case storage.ErrAlreadyExists:
+		c.JSON(http.StatusConflict, gin.H{
+			"error": gin.H{
+				"code":    "ALREADY_EXISTS",
+				"message": "A review queue with this name already exists",
+			},
+		})
+	default:
+		log.Printf("%s: %v", message, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": message,
+			},
+		})
+	}
+}
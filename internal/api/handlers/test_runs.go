@@ -3,41 +3,74 @@
 package handlers
 
 import (
+	"log"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/regrada-ai/regrada-be/internal/domain"
+	"github.com/regrada-ai/regrada-be/internal/live"
 	"github.com/regrada-ai/regrada-be/internal/storage"
 )
 
 type TestRunHandler struct {
-	testRunRepo storage.TestRunRepository
-	projectRepo storage.ProjectRepository
+	testRunRepo    storage.TestRunRepository
+	projectRepo    storage.ProjectRepository
+	policyRepo     storage.PolicyRepository
+	retentionRepo  storage.RetentionRepository
+	gateRepo       storage.QualityGateRepository
+	quarantineRepo storage.QuarantineRepository
+	hub            *live.Hub
 }
 
-func NewTestRunHandler(testRunRepo storage.TestRunRepository, projectRepo storage.ProjectRepository) *TestRunHandler {
+func NewTestRunHandler(
+	testRunRepo storage.TestRunRepository,
+	projectRepo storage.ProjectRepository,
+	policyRepo storage.PolicyRepository,
+	retentionRepo storage.RetentionRepository,
+	gateRepo storage.QualityGateRepository,
+	quarantineRepo storage.QuarantineRepository,
+	hub *live.Hub,
+) *TestRunHandler {
 	return &TestRunHandler{
-		testRunRepo: testRunRepo,
-		projectRepo: projectRepo,
+		testRunRepo:    testRunRepo,
+		projectRepo:    projectRepo,
+		policyRepo:     policyRepo,
+		retentionRepo:  retentionRepo,
+		gateRepo:       gateRepo,
+		quarantineRepo: quarantineRepo,
+		hub:            hub,
 	}
 }
 
 // UploadTestRun handles test run upload
 // @Summary      Upload a test run
-// @Description  Upload a test run with test results for a project
+// @Description  Upload a test run with test results for a project. Re-uploading an existing run_id is handled according to on_conflict. If the project has registered policies, violations must name one of them and take its severity, and violating an enabled blocking policy marks the run failed. If the project has an enabled quality gate, the response includes its verdict, leaving out quarantined cases; the run fails the gate if verdict.passed is false.
 // @Tags         test-runs
 // @Accept       json
 // @Produce      json
-// @Param        projectID  path      string           true  "Project ID"
-// @Param        testRun    body      domain.TestRun  true  "Test run data"
-// @Success      201        {object}  map[string]interface{} "Test run created successfully"
-// @Failure      400        {object}  map[string]interface{} "Invalid request"
-// @Failure      401        {object}  map[string]interface{} "Unauthorized"
-// @Failure      500        {object}  map[string]interface{} "Internal server error"
+// @Param        projectID        path      string           true   "Project ID"
+// @Param        on_conflict      query     string           false  "ignore (default) or replace"
+// @Param        baseline_run_id  query     string           false  "Run the quality gate compares against instead of the latest run on its baseline branch"
+// @Param        testRun          body      domain.TestRun  true   "Test run data"
+// @Success      201              {object}  map[string]interface{} "Test run created successfully"
+// @Success      200              {object}  map[string]interface{} "Test run already existed"
+// @Failure      400              {object}  map[string]interface{} "Invalid request"
+// @Failure      401              {object}  map[string]interface{} "Unauthorized"
+// @Failure      404              {object}  map[string]interface{} "Project or baseline run not found"
+// @Failure      500              {object}  map[string]interface{} "Internal server error"
 // @Security     BearerAuth
 // @Router       /v1/projects/{projectID}/test-runs [post]
 func (h *TestRunHandler) UploadTestRun(c *gin.Context) {
-	projectID := c.Param("projectID")
+	project := loadProject(c, h.retentionRepo)
+	if project == nil {
+		return
+	}
+	projectID := project.ProjectID
+
+	onConflict, ok := parseConflictMode(c, storage.ConflictIgnore, storage.ConflictReplace)
+	if !ok {
+		return
+	}
 
 	var testRun domain.TestRun
 	if err := c.ShouldBindJSON(&testRun); err != nil {
@@ -50,8 +83,44 @@ func (h *TestRunHandler) UploadTestRun(c *gin.Context) {
 		return
 	}
 
+	if reason := validateTestRun(&testRun); reason != "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": reason,
+			},
+		})
+		return
+	}
+
+	policies, err := h.policyRepo.Effective(c.Request.Context(), project.OrganizationID, projectID)
+	if err != nil {
+		writePolicyError(c, err, "Failed to fetch policies")
+		return
+	}
+	blocking, reason := applyPolicies(&testRun, policies)
+	if reason != "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": reason,
+			},
+		})
+		return
+	}
+
+	// Check the run against the quality gate before storing it, so a
+	// missing baseline run is reported without storing anything
+	verdict, err := evaluateQualityGate(c.Request.Context(), h.gateRepo, h.testRunRepo, h.quarantineRepo, projectID, &testRun, c.Query("baseline_run_id"))
+	if err != nil {
+		writeQualityGateError(c, err, "Baseline run not found", "Failed to evaluate quality gate")
+		return
+	}
+
 	// Store test run
-	if err := h.testRunRepo.Create(c.Request.Context(), projectID, &testRun); err != nil {
+	status, err := h.testRunRepo.Create(c.Request.Context(), projectID, &testRun, onConflict)
+	if err != nil {
+		log.Printf("Failed to store test run %s for project %s: %v", testRun.RunID, projectID, err)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": gin.H{
 				"code":    "INTERNAL_ERROR",
@@ -61,10 +130,37 @@ func (h *TestRunHandler) UploadTestRun(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{
-		"status": "created",
-		"run_id": testRun.RunID,
-	})
+	if status == storage.IngestDuplicate {
+		response := gin.H{
+			"status": status,
+			"run_id": testRun.RunID,
+			"reason": duplicateReason("run_id", onConflict),
+		}
+		if verdict != nil {
+			response["verdict"] = verdict
+		}
+		c.JSON(http.StatusOK, response)
+		return
+	}
+
+	var gatePassed *bool
+	if verdict != nil {
+		gatePassed = &verdict.Passed
+	}
+	h.hub.Publish(c.Request.Context(), live.TopicTestRuns, projectID, live.NewTestRunEvent(&testRun, gatePassed))
+
+	response := gin.H{
+		"status":     status,
+		"run_id":     testRun.RunID,
+		"run_status": testRun.Status,
+	}
+	if len(blocking) > 0 {
+		response["blocking_policies"] = blocking
+	}
+	if verdict != nil {
+		response["verdict"] = verdict
+	}
+	c.JSON(http.StatusCreated, response)
 }
 
 // ListTestRuns returns paginated list of test runs
@@ -3,42 +3,102 @@
 package handlers
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/regrada-ai/regrada-be/internal/domain"
+	"github.com/regrada-ai/regrada-be/internal/ingest"
+	"github.com/regrada-ai/regrada-be/internal/live"
+	"github.com/regrada-ai/regrada-be/internal/redaction"
 	"github.com/regrada-ai/regrada-be/internal/storage"
 )
 
+const (
+	maxBatchTraces      = 100
+	maxAsyncBatchTraces = 1000
+)
+
 type TraceHandler struct {
-	traceRepo   storage.TraceRepository
-	projectRepo storage.ProjectRepository
+	traceRepo      storage.TraceRepository
+	projectRepo    storage.ProjectRepository
+	redactionRepo  storage.RedactionPolicyRepository
+	evaluationRepo storage.EvaluationRepository
+	queue          *ingest.Queue
+	hub            *live.Hub
 }
 
-func NewTraceHandler(traceRepo storage.TraceRepository, projectRepo storage.ProjectRepository) *TraceHandler {
+func NewTraceHandler(traceRepo storage.TraceRepository, projectRepo storage.ProjectRepository, redactionRepo storage.RedactionPolicyRepository, evaluationRepo storage.EvaluationRepository, queue *ingest.Queue, hub *live.Hub) *TraceHandler {
 	return &TraceHandler{
-		traceRepo:   traceRepo,
-		projectRepo: projectRepo,
+		traceRepo:      traceRepo,
+		projectRepo:    projectRepo,
+		redactionRepo:  redactionRepo,
+		evaluationRepo: evaluationRepo,
+		queue:          queue,
+		hub:            hub,
+	}
+}
+
+// traceDetail is a trace with the results of the project's evaluators
+type traceDetail struct {
+	*domain.Trace
+	Evaluations []*storage.EvaluationResult `json:"evaluations"`
+}
+
+// redactorForProject loads and compiles the project's redaction policy.
+// Projects without a policy get a no-op redactor.
+func (h *TraceHandler) redactorForProject(ctx context.Context, projectID string) (*redaction.Redactor, error) {
+	policy, err := h.redactionRepo.Get(ctx, projectID)
+	if err != nil {
+		if err == storage.ErrNotFound {
+			return redaction.New(nil)
+		}
+		return nil, err
 	}
+	return redaction.New(policy)
+}
+
+// respondRedactionError fails ingestion when the policy cannot be applied,
+// rather than storing data that may contain PII.
+func respondRedactionError(c *gin.Context, projectID string, err error) {
+	log.Printf("Failed to load redaction policy for project %s: %v", projectID, err)
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"error": gin.H{
+			"code":    "INTERNAL_ERROR",
+			"message": "Failed to apply redaction policy",
+		},
+	})
 }
 
 // UploadTrace handles single trace upload
 // @Summary      Upload a trace
-// @Description  Upload a single LLM trace for a project
+// @Description  Upload a single LLM trace for a project. Re-uploading an existing trace_id is handled according to on_conflict.
 // @Tags         traces
 // @Accept       json
 // @Produce      json
-// @Param        projectID  path      string         true  "Project ID"
-// @Param        trace      body      domain.Trace  true  "Trace data"
-// @Success      201        {object}  map[string]interface{} "Trace created successfully"
-// @Failure      400        {object}  map[string]interface{} "Invalid request"
-// @Failure      401        {object}  map[string]interface{} "Unauthorized"
-// @Failure      500        {object}  map[string]interface{} "Internal server error"
+// @Param        projectID    path      string         true   "Project ID"
+// @Param        on_conflict  query     string         false  "ignore (default), replace, or merge_tags"
+// @Param        trace        body      domain.Trace  true   "Trace data"
+// @Success      201          {object}  map[string]interface{} "Trace created successfully"
+// @Success      200          {object}  map[string]interface{} "Trace already existed"
+// @Failure      400          {object}  map[string]interface{} "Invalid request"
+// @Failure      401          {object}  map[string]interface{} "Unauthorized"
+// @Failure      500          {object}  map[string]interface{} "Internal server error"
 // @Security     BearerAuth
 // @Router       /v1/projects/{projectID}/traces [post]
 func (h *TraceHandler) UploadTrace(c *gin.Context) {
 	projectID := c.Param("projectID")
 
+	onConflict, ok := parseConflictMode(c, storage.ConflictIgnore, storage.ConflictReplace, storage.ConflictMergeTags)
+	if !ok {
+		return
+	}
+
 	var trace domain.Trace
 	if err := c.ShouldBindJSON(&trace); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -50,8 +110,27 @@ func (h *TraceHandler) UploadTrace(c *gin.Context) {
 		return
 	}
 
+	if reason := validateTrace(&trace); reason != "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": reason,
+			},
+		})
+		return
+	}
+
+	redactor, err := h.redactorForProject(c.Request.Context(), projectID)
+	if err != nil {
+		respondRedactionError(c, projectID, err)
+		return
+	}
+	redactor.RedactTrace(&trace)
+
 	// Store trace
-	if err := h.traceRepo.Create(c.Request.Context(), projectID, &trace); err != nil {
+	status, err := h.traceRepo.Create(c.Request.Context(), projectID, &trace, onConflict)
+	if err != nil {
+		log.Printf("Failed to store trace %s for project %s: %v", trace.TraceID, projectID, err)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": gin.H{
 				"code":    "INTERNAL_ERROR",
@@ -61,31 +140,64 @@ func (h *TraceHandler) UploadTrace(c *gin.Context) {
 		return
 	}
 
+	if status == storage.IngestDuplicate {
+		c.JSON(http.StatusOK, gin.H{
+			"status":   status,
+			"trace_id": trace.TraceID,
+			"reason":   duplicateReason("trace_id", onConflict),
+		})
+		return
+	}
+
+	h.hub.Publish(c.Request.Context(), live.TopicTraces, projectID, live.TraceEvents([]domain.Trace{trace})...)
+
 	c.JSON(http.StatusCreated, gin.H{
-		"status":   "created",
+		"status":   status,
 		"trace_id": trace.TraceID,
 	})
 }
 
 // UploadTracesBatch handles batch trace upload
 // @Summary      Upload traces in batch
-// @Description  Upload multiple LLM traces at once (max 100 per request)
+// @Description  Upload multiple LLM traces at once (max 100 per request). Each trace is reported as created, duplicate, or invalid.
+// @Description  With mode=async, up to 1000 valid traces are queued for background storage and acknowledged with 202.
 // @Tags         traces
 // @Accept       json
 // @Produce      json
-// @Param        projectID  path      string                  true  "Project ID"
-// @Param        traces     body      map[string]interface{}  true  "Batch of traces"
-// @Success      201        {object}  map[string]interface{}  "Traces created successfully"
-// @Failure      400        {object}  map[string]interface{}  "Invalid request"
-// @Failure      401        {object}  map[string]interface{}  "Unauthorized"
-// @Failure      500        {object}  map[string]interface{}  "Internal server error"
+// @Param        projectID    path      string                  true   "Project ID"
+// @Param        on_conflict  query     string                  false  "ignore (default), replace, or merge_tags"
+// @Param        mode         query     string                  false  "sync (default) or async"
+// @Param        traces       body      map[string]interface{}  true   "Batch of traces"
+// @Success      201          {object}  map[string]interface{}  "At least one trace created"
+// @Success      200          {object}  map[string]interface{}  "No new traces created"
+// @Success      202          {object}  map[string]interface{}  "Traces queued (async mode)"
+// @Failure      400          {object}  map[string]interface{}  "Invalid request"
+// @Failure      401          {object}  map[string]interface{}  "Unauthorized"
+// @Failure      500          {object}  map[string]interface{}  "Internal server error"
+// @Failure      503          {object}  map[string]interface{}  "Ingestion queue full (async mode)"
 // @Security     BearerAuth
 // @Router       /v1/projects/{projectID}/traces/batch [post]
 func (h *TraceHandler) UploadTracesBatch(c *gin.Context) {
 	projectID := c.Param("projectID")
 
+	onConflict, ok := parseConflictMode(c, storage.ConflictIgnore, storage.ConflictReplace, storage.ConflictMergeTags)
+	if !ok {
+		return
+	}
+
+	async, ok := parseIngestMode(c)
+	if !ok {
+		return
+	}
+
+	maxTraces := maxBatchTraces
+	if async {
+		maxTraces = maxAsyncBatchTraces
+	}
+
+	// Decode items individually so one malformed trace doesn't reject the batch
 	var req struct {
-		Traces []domain.Trace `json:"traces" binding:"required"`
+		Traces []json.RawMessage `json:"traces" binding:"required"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -108,18 +220,84 @@ func (h *TraceHandler) UploadTracesBatch(c *gin.Context) {
 		return
 	}
 
-	if len(req.Traces) > 100 {
+	if len(req.Traces) > maxTraces {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": fmt.Sprintf("Maximum %d traces per batch", maxTraces),
+			},
+		})
+		return
+	}
+
+	redactor, err := h.redactorForProject(c.Request.Context(), projectID)
+	if err != nil {
+		respondRedactionError(c, projectID, err)
+		return
+	}
+
+	results := make([]storage.IngestResult, len(req.Traces))
+	valid := make([]domain.Trace, 0, len(req.Traces))
+	validIndexes := make([]int, 0, len(req.Traces))
+	seen := make(map[string]bool, len(req.Traces))
+
+	for i, raw := range req.Traces {
+		results[i].Index = i
+
+		trace, reason := decodeTrace(raw)
+		if trace != nil {
+			results[i].ID = trace.TraceID
+		}
+		if reason != "" {
+			results[i].Status = storage.IngestInvalid
+			results[i].Reason = reason
+			continue
+		}
+
+		if seen[trace.TraceID] {
+			results[i].Status = storage.IngestDuplicate
+			results[i].Reason = "trace_id repeated within batch; ignored"
+			continue
+		}
+		seen[trace.TraceID] = true
+
+		redactor.RedactTrace(trace)
+		valid = append(valid, *trace)
+		validIndexes = append(validIndexes, i)
+	}
+
+	if len(valid) == 0 {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": gin.H{
 				"code":    "INVALID_REQUEST",
-				"message": "Maximum 100 traces per batch",
+				"message": "No valid traces provided",
+				"details": results,
 			},
 		})
 		return
 	}
 
-	// Store all traces
-	if err := h.traceRepo.CreateBatch(c.Request.Context(), projectID, req.Traces); err != nil {
+	// Traces past the monthly usage limit are rejected
+	granted := reserveUsage(c, len(valid))
+	for _, i := range validIndexes[granted:] {
+		results[i].Status = storage.IngestOverLimit
+		results[i].Reason = overLimitReason
+	}
+	valid, validIndexes = valid[:granted], validIndexes[:granted]
+	if len(valid) == 0 {
+		c.JSON(http.StatusOK, batchResponse(results))
+		return
+	}
+
+	if async {
+		h.enqueueTraces(c, projectID, valid, validIndexes, results, onConflict)
+		return
+	}
+
+	// Store all valid traces
+	statuses, err := h.traceRepo.CreateBatch(c.Request.Context(), projectID, valid, onConflict)
+	if err != nil {
+		log.Printf("Failed to store trace batch for project %s: %v", projectID, err)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": gin.H{
 				"code":    "INTERNAL_ERROR",
@@ -129,29 +307,162 @@ func (h *TraceHandler) UploadTracesBatch(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{
-		"status": "created",
-		"count":  len(req.Traces),
+	created := make([]domain.Trace, 0, len(valid))
+	for i, status := range statuses {
+		result := &results[validIndexes[i]]
+		result.Status = status
+		if status == storage.IngestDuplicate {
+			result.Reason = duplicateReason("trace_id", onConflict)
+		} else {
+			created = append(created, valid[i])
+		}
+	}
+	recordUsage(c, len(valid))
+	h.hub.Publish(c.Request.Context(), live.TopicTraces, projectID, live.TraceEvents(created)...)
+
+	c.JSON(batchStatusCode(results), batchResponse(results))
+}
+
+// enqueueTraces writes validated, redacted traces to the ingestion queue and
+// acknowledges them with 202
+func (h *TraceHandler) enqueueTraces(c *gin.Context, projectID string, traces []domain.Trace, indexes []int, results []storage.IngestResult, onConflict storage.ConflictMode) {
+	if _, err := h.queue.Enqueue(c.Request.Context(), projectID, traces, onConflict); err != nil {
+		if err == ingest.ErrQueueFull {
+			c.Header("Retry-After", "30")
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"error": gin.H{
+					"code":    "QUEUE_FULL",
+					"message": "Ingestion queue is full, retry later",
+				},
+			})
+			return
+		}
+		log.Printf("Failed to enqueue traces for project %s: %v", projectID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Failed to queue traces",
+			},
+		})
+		return
+	}
+
+	for _, i := range indexes {
+		results[i].Status = storage.IngestQueued
+	}
+	recordUsage(c, len(traces))
+
+	var queued, duplicates, invalid, overLimit int
+	for _, result := range results {
+		switch result.Status {
+		case storage.IngestQueued:
+			queued++
+		case storage.IngestDuplicate:
+			duplicates++
+		case storage.IngestInvalid:
+			invalid++
+		case storage.IngestOverLimit:
+			overLimit++
+		}
+	}
+
+	status := "queued"
+	if duplicates > 0 || invalid > 0 || overLimit > 0 {
+		status = "partial"
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"status":     status,
+		"queued":     queued,
+		"duplicates": duplicates,
+		"invalid":    invalid,
+		"over_limit": overLimit,
+		"results":    results,
 	})
 }
 
+// batchResponse summarizes per-item ingestion results
+func batchResponse(results []storage.IngestResult) gin.H {
+	var created, duplicates, invalid, overLimit int
+	for _, result := range results {
+		switch result.Status {
+		case storage.IngestCreated:
+			created++
+		case storage.IngestDuplicate:
+			duplicates++
+		case storage.IngestInvalid:
+			invalid++
+		case storage.IngestOverLimit:
+			overLimit++
+		}
+	}
+
+	status := "created"
+	if duplicates > 0 || invalid > 0 || overLimit > 0 {
+		status = "partial"
+	}
+
+	return gin.H{
+		"status":     status,
+		"count":      created,
+		"created":    created,
+		"duplicates": duplicates,
+		"invalid":    invalid,
+		"over_limit": overLimit,
+		"results":    results,
+	}
+}
+
+// batchStatusCode returns 201 if anything was created, 200 otherwise
+func batchStatusCode(results []storage.IngestResult) int {
+	for _, result := range results {
+		if result.Status == storage.IngestCreated {
+			return http.StatusCreated
+		}
+	}
+	return http.StatusOK
+}
+
 // ListTraces returns paginated list of traces
 // @Summary      List traces
 // @Description  Get a paginated list of traces for a project
 // @Tags         traces
 // @Accept       json
 // @Produce      json
-// @Param        projectID  path      string  true  "Project ID"
-// @Success      200        {object}  map[string]interface{} "List of traces"
-// @Failure      401        {object}  map[string]interface{} "Unauthorized"
-// @Failure      500        {object}  map[string]interface{} "Internal server error"
+// @Param        projectID         path      string  true   "Project ID"
+// @Param        from              query     string  false  "Earliest timestamp, inclusive (RFC 3339)"
+// @Param        to                query     string  false  "Latest timestamp, exclusive (RFC 3339)"
+// @Param        environment       query     string  false  "Environment"
+// @Param        provider          query     string  false  "Provider"
+// @Param        model             query     string  false  "Model"
+// @Param        git_sha           query     string  false  "Git commit SHA"
+// @Param        git_branch        query     string  false  "Git branch"
+// @Param        prompt_name       query     string  false  "Registry prompt name"
+// @Param        prompt_version    query     int     false  "Prompt version (requires prompt_name)"
+// @Param        tag               query     string  false  "Tag the trace must have (repeatable)"
+// @Param        rating            query     string  false  "Annotation rating (up or down)"
+// @Param        annotation_label  query     string  false  "Annotation label"
+// @Param        score_criterion   query     string  false  "Annotation score criterion"
+// @Param        min_score         query     number  false  "Minimum score on score_criterion"
+// @Param        max_score         query     number  false  "Maximum score on score_criterion"
+// @Param        evaluator_id      query     string  false  "Evaluator the evaluation filter applies to (any evaluator if omitted)"
+// @Param        evaluation        query     string  false  "Evaluation outcome (pass or fail)"
+// @Success      200               {object}  map[string]interface{} "List of traces"
+// @Failure      400               {object}  map[string]interface{} "Invalid filter"
+// @Failure      401               {object}  map[string]interface{} "Unauthorized"
+// @Failure      500               {object}  map[string]interface{} "Internal server error"
 // @Security     BearerAuth
 // @Router       /v1/projects/{projectID}/traces [get]
 func (h *TraceHandler) ListTraces(c *gin.Context) {
 	projectID := c.Param("projectID")
 
-	// TODO: Add pagination and filtering
-	traces, err := h.traceRepo.List(c.Request.Context(), projectID, 50, 0)
+	filter, ok := parseTraceFilter(c)
+	if !ok {
+		return
+	}
+
+	// TODO: Add pagination
+	traces, err := h.traceRepo.List(c.Request.Context(), projectID, filter, 50, 0)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": gin.H{
@@ -168,9 +479,81 @@ func (h *TraceHandler) ListTraces(c *gin.Context) {
 	})
 }
 
+// parseTraceFilter reads trace filters from the query string. It writes an
+// error response and returns false if a filter is invalid.
+func parseTraceFilter(c *gin.Context) (storage.TraceFilter, bool) {
+	from, to, ok := parseTimeRange(c)
+	if !ok {
+		return storage.TraceFilter{}, false
+	}
+
+	filter := storage.TraceFilter{
+		Environment: c.Query("environment"),
+		Provider:    c.Query("provider"),
+		Model:       c.Query("model"),
+		GitSHA:      c.Query("git_sha"),
+		GitBranch:   c.Query("git_branch"),
+		Tags:        c.QueryArray("tag"),
+	}
+	if !from.IsZero() {
+		filter.From = &from
+	}
+	if !to.IsZero() {
+		filter.To = &to
+	}
+
+	filter.Rating = storage.Rating(c.Query("rating"))
+	filter.AnnotationLabel = c.Query("annotation_label")
+	filter.ScoreCriterion = c.Query("score_criterion")
+	reason := ""
+	if filter.Rating != "" && filter.Rating != storage.RatingUp && filter.Rating != storage.RatingDown {
+		reason = "rating must be up or down"
+	}
+	for name, bound := range map[string]**float64{"min_score": &filter.MinScore, "max_score": &filter.MaxScore} {
+		value := c.Query(name)
+		if value == "" {
+			continue
+		}
+		score, err := strconv.ParseFloat(value, 64)
+		if err != nil || filter.ScoreCriterion == "" {
+			reason = fmt.Sprintf("%s must be a number and requires score_criterion", name)
+			continue
+		}
+		*bound = &score
+	}
+
+	filter.PromptName = c.Query("prompt_name")
+	if value := c.Query("prompt_version"); value != "" {
+		version, err := strconv.Atoi(value)
+		if err != nil || version < 1 || filter.PromptName == "" {
+			reason = "prompt_version must be a positive integer and requires prompt_name"
+		}
+		filter.PromptVersion = version
+	}
+
+	filter.EvaluatorID = c.Query("evaluator_id")
+	filter.Evaluation = c.Query("evaluation")
+	if filter.EvaluatorID != "" && uuid.Validate(filter.EvaluatorID) != nil {
+		reason = "evaluator_id must be a UUID"
+	}
+	if filter.Evaluation != "" && filter.Evaluation != "pass" && filter.Evaluation != "fail" {
+		reason = "evaluation must be pass or fail"
+	}
+	if reason != "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": reason,
+			},
+		})
+		return storage.TraceFilter{}, false
+	}
+	return filter, true
+}
+
 // GetTrace returns a single trace
 // @Summary      Get a trace
-// @Description  Get a specific trace by ID
+// @Description  Get a specific trace by ID, with the results of the project's evaluators
 // @Tags         traces
 // @Accept       json
 // @Produce      json
@@ -206,5 +589,17 @@ func (h *TraceHandler) GetTrace(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, trace)
+	evaluations, err := h.evaluationRepo.ListResults(c.Request.Context(), projectID, traceID)
+	if err != nil {
+		log.Printf("Failed to fetch evaluations of trace %s: %v", traceID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Failed to fetch trace",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, traceDetail{Trace: trace, Evaluations: evaluations})
 }
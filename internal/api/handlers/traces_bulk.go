@@ -0,0 +1,381 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/klauspost/compress/zstd"
+	"github.com/regrada-ai/regrada-be/internal/domain"
+	"github.com/regrada-ai/regrada-be/internal/ingest"
+	"github.com/regrada-ai/regrada-be/internal/live"
+	"github.com/regrada-ai/regrada-be/internal/redaction"
+	"github.com/regrada-ai/regrada-be/internal/storage"
+)
+
+const (
+	maxBulkLineBytes   = 1 << 20   // largest single trace
+	maxBulkBodyBytes   = 512 << 20 // decompressed upload size
+	maxBulkLines       = 100_000
+	maxBulkLineErrors  = 1000
+	bulkChunkSize      = 500
+	maxZstdDecoderSize = 64 << 20
+)
+
+var errLineTooLong = errors.New("line too long")
+
+// bulkLineError reports why a line of a bulk upload was not ingested
+type bulkLineError struct {
+	Line   int    `json:"line"`
+	ID     string `json:"id,omitempty"`
+	Reason string `json:"reason"`
+}
+
+// bulkUpload accumulates traces from a streamed upload and stores them in chunks
+type bulkUpload struct {
+	h          *TraceHandler
+	c          *gin.Context
+	projectID  string
+	onConflict storage.ConflictMode
+	async      bool
+	redactor   *redaction.Redactor
+
+	chunk []domain.Trace
+	seen  map[string]bool
+
+	lines           int
+	created         int
+	duplicates      int
+	queued          int
+	invalid         int
+	overLimit       int
+	errors          []bulkLineError
+	errorsTruncated bool
+}
+
+// UploadTracesBulk handles streaming NDJSON trace upload
+// @Summary      Bulk upload traces (NDJSON)
+// @Description  Stream newline-delimited JSON traces, optionally compressed with gzip or zstd (Content-Encoding).
+// @Description  Lines are parsed one at a time and stored in chunks; invalid lines are reported by line number without rejecting the upload.
+// @Description  Up to 100,000 lines per request. With mode=async, traces are queued and acknowledged with 202.
+// @Tags         traces
+// @Accept       application/x-ndjson
+// @Produce      json
+// @Param        projectID         path      string  true   "Project ID"
+// @Param        on_conflict       query     string  false  "ignore (default), replace, or merge_tags"
+// @Param        mode              query     string  false  "sync (default) or async"
+// @Param        Content-Encoding  header    string  false  "gzip or zstd"
+// @Success      201               {object}  map[string]interface{}  "At least one trace created"
+// @Success      202               {object}  map[string]interface{}  "Traces queued (async mode)"
+// @Success      200               {object}  map[string]interface{}  "No new traces created"
+// @Failure      400               {object}  map[string]interface{}  "Invalid request"
+// @Failure      401               {object}  map[string]interface{}  "Unauthorized"
+// @Failure      413               {object}  map[string]interface{}  "Upload too large"
+// @Failure      415               {object}  map[string]interface{}  "Unsupported content type or encoding"
+// @Failure      500               {object}  map[string]interface{}  "Internal server error"
+// @Failure      503               {object}  map[string]interface{}  "Ingestion queue full (async mode)"
+// @Security     BearerAuth
+// @Router       /v1/projects/{projectID}/traces/bulk [post]
+func (h *TraceHandler) UploadTracesBulk(c *gin.Context) {
+	projectID := c.Param("projectID")
+
+	onConflict, ok := parseConflictMode(c, storage.ConflictIgnore, storage.ConflictReplace, storage.ConflictMergeTags)
+	if !ok {
+		return
+	}
+
+	async, ok := parseIngestMode(c)
+	if !ok {
+		return
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(c.GetHeader("Content-Type"))
+	if mediaType != "application/x-ndjson" && mediaType != "application/jsonl" {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{
+			"error": gin.H{
+				"code":    "UNSUPPORTED_MEDIA_TYPE",
+				"message": "Content-Type must be application/x-ndjson",
+			},
+		})
+		return
+	}
+
+	body, err := decompressBody(c.Request)
+	if err != nil {
+		status, code := http.StatusBadRequest, "INVALID_REQUEST"
+		if errors.Is(err, errUnsupportedEncoding) {
+			status, code = http.StatusUnsupportedMediaType, "UNSUPPORTED_MEDIA_TYPE"
+		}
+		c.JSON(status, gin.H{
+			"error": gin.H{
+				"code":    code,
+				"message": err.Error(),
+			},
+		})
+		return
+	}
+	defer body.Close()
+
+	redactor, err := h.redactorForProject(c.Request.Context(), projectID)
+	if err != nil {
+		respondRedactionError(c, projectID, err)
+		return
+	}
+
+	u := &bulkUpload{
+		h:          h,
+		c:          c,
+		projectID:  projectID,
+		onConflict: onConflict,
+		async:      async,
+		redactor:   redactor,
+		chunk:      make([]domain.Trace, 0, bulkChunkSize),
+		seen:       make(map[string]bool, bulkChunkSize),
+	}
+
+	reader := bufio.NewReaderSize(http.MaxBytesReader(c.Writer, body, maxBulkBodyBytes), 64<<10)
+	buf := make([]byte, 0, 64<<10)
+	for {
+		line, err := readLine(reader, buf, maxBulkLineBytes)
+		if err == errLineTooLong {
+			u.lines++
+			u.fail(u.lines, "", fmt.Sprintf("line exceeds %d bytes", maxBulkLineBytes))
+			continue
+		}
+		if err != nil && err != io.EOF {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				u.abort(http.StatusRequestEntityTooLarge, "REQUEST_TOO_LARGE", fmt.Sprintf("Upload exceeds %d bytes after decompression", maxBulkBodyBytes))
+				return
+			}
+			u.abort(http.StatusBadRequest, "INVALID_REQUEST", "Failed to read request body: "+err.Error())
+			return
+		}
+
+		if len(line) > 0 || err == nil {
+			u.lines++
+			if u.lines > maxBulkLines {
+				u.abort(http.StatusRequestEntityTooLarge, "REQUEST_TOO_LARGE", fmt.Sprintf("Maximum %d lines per upload", maxBulkLines))
+				return
+			}
+			if trimmed := bytes.TrimSpace(line); len(trimmed) > 0 {
+				if !u.add(u.lines, trimmed) {
+					return
+				}
+			}
+		}
+
+		if err == io.EOF {
+			break
+		}
+		buf = line[:0]
+	}
+
+	if !u.flush() {
+		return
+	}
+
+	u.respond()
+}
+
+// add decodes, validates, and redacts one line, flushing the chunk when full.
+// It returns false if storing a chunk failed and a response was written.
+func (u *bulkUpload) add(line int, raw []byte) bool {
+	trace, reason := decodeTrace(raw)
+	if reason != "" {
+		id := ""
+		if trace != nil {
+			id = trace.TraceID
+		}
+		u.fail(line, id, reason)
+		return true
+	}
+
+	// A repeated trace ID must land in a later chunk so ON CONFLICT handles it
+	if u.seen[trace.TraceID] || len(u.chunk) == bulkChunkSize {
+		if !u.flush() {
+			return false
+		}
+	}
+
+	u.redactor.RedactTrace(trace)
+	u.chunk = append(u.chunk, *trace)
+	u.seen[trace.TraceID] = true
+	return true
+}
+
+// flush stores the current chunk. It returns false if a response was written.
+func (u *bulkUpload) flush() bool {
+	if len(u.chunk) == 0 {
+		return true
+	}
+
+	// Traces past the monthly usage limit are dropped
+	granted := reserveUsage(u.c, len(u.chunk))
+	u.overLimit += len(u.chunk) - granted
+	u.chunk = u.chunk[:granted]
+	if len(u.chunk) == 0 {
+		clear(u.seen)
+		return true
+	}
+
+	ctx := u.c.Request.Context()
+	if u.async {
+		if _, err := u.h.queue.Enqueue(ctx, u.projectID, u.chunk, u.onConflict); err != nil {
+			if err == ingest.ErrQueueFull {
+				u.c.Header("Retry-After", "30")
+				u.abort(http.StatusServiceUnavailable, "QUEUE_FULL", "Ingestion queue is full, retry later")
+				return false
+			}
+			log.Printf("Failed to enqueue bulk traces for project %s: %v", u.projectID, err)
+			u.abort(http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to queue traces")
+			return false
+		}
+		u.queued += len(u.chunk)
+	} else {
+		statuses, err := u.h.traceRepo.CreateBatch(ctx, u.projectID, u.chunk, u.onConflict)
+		if err != nil {
+			log.Printf("Failed to store bulk traces for project %s: %v", u.projectID, err)
+			u.abort(http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to store traces")
+			return false
+		}
+		created := make([]domain.Trace, 0, len(u.chunk))
+		for i, status := range statuses {
+			if status == storage.IngestCreated {
+				u.created++
+				created = append(created, u.chunk[i])
+			} else {
+				u.duplicates++
+			}
+		}
+		u.h.hub.Publish(ctx, live.TopicTraces, u.projectID, live.TraceEvents(created)...)
+	}
+
+	u.chunk = u.chunk[:0]
+	clear(u.seen)
+	return true
+}
+
+func (u *bulkUpload) fail(line int, id, reason string) {
+	u.invalid++
+	if len(u.errors) >= maxBulkLineErrors {
+		u.errorsTruncated = true
+		return
+	}
+	u.errors = append(u.errors, bulkLineError{Line: line, ID: id, Reason: reason})
+}
+
+func (u *bulkUpload) summary() gin.H {
+	status := "created"
+	if u.async {
+		status = "queued"
+	}
+	if u.duplicates > 0 || u.invalid > 0 || u.overLimit > 0 {
+		status = "partial"
+	}
+
+	return gin.H{
+		"status":           status,
+		"lines":            u.lines,
+		"created":          u.created,
+		"duplicates":       u.duplicates,
+		"queued":           u.queued,
+		"invalid":          u.invalid,
+		"over_limit":       u.overLimit,
+		"errors":           u.errors,
+		"errors_truncated": u.errorsTruncated,
+	}
+}
+
+// abort reports an error after part of the upload may already have been
+// stored; the summary tells the client what was ingested
+func (u *bulkUpload) abort(status int, code, message string) {
+	recordUsage(u.c, u.created+u.duplicates+u.queued)
+	u.c.JSON(status, gin.H{
+		"error": gin.H{
+			"code":    code,
+			"message": message,
+			"details": u.summary(),
+		},
+	})
+}
+
+func (u *bulkUpload) respond() {
+	ingested := u.created + u.duplicates + u.queued
+	if ingested == 0 && u.overLimit == 0 {
+		u.abort(http.StatusBadRequest, "INVALID_REQUEST", "No valid traces provided")
+		return
+	}
+
+	recordUsage(u.c, ingested)
+
+	status := http.StatusOK
+	switch {
+	case u.queued > 0:
+		status = http.StatusAccepted
+	case u.created > 0:
+		status = http.StatusCreated
+	}
+	u.c.JSON(status, u.summary())
+}
+
+var errUnsupportedEncoding = errors.New("unsupported Content-Encoding: use gzip or zstd")
+
+// decompressBody wraps the request body according to its Content-Encoding
+func decompressBody(r *http.Request) (io.ReadCloser, error) {
+	switch strings.ToLower(strings.TrimSpace(r.Header.Get("Content-Encoding"))) {
+	case "", "identity":
+		return r.Body, nil
+	case "gzip", "x-gzip":
+		gz, err := gzip.NewReader(r.Body)
+		if err != nil {
+			return nil, fmt.Errorf("invalid gzip body: %w", err)
+		}
+		return gz, nil
+	case "zstd":
+		dec, err := zstd.NewReader(r.Body, zstd.WithDecoderConcurrency(1), zstd.WithDecoderMaxMemory(maxZstdDecoderSize))
+		if err != nil {
+			return nil, fmt.Errorf("invalid zstd body: %w", err)
+		}
+		return dec.IOReadCloser(), nil
+	default:
+		return nil, errUnsupportedEncoding
+	}
+}
+
+// readLine reads the next line into buf, including any trailing newline.
+// Lines longer than max bytes are skipped and reported as errLineTooLong so
+// memory stays bounded regardless of input.
+func readLine(r *bufio.Reader, buf []byte, max int) ([]byte, error) {
+	buf = buf[:0]
+	tooLong := false
+	for {
+		chunk, err := r.ReadSlice('\n')
+		if !tooLong {
+			if len(buf)+len(chunk) > max+1 {
+				tooLong = true
+				buf = buf[:0]
+			} else {
+				buf = append(buf, chunk...)
+			}
+		}
+
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if tooLong && (err == nil || err == io.EOF) {
+			return nil, errLineTooLong
+		}
+		return buf, err
+	}
+}
@@ -7,21 +7,23 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/regrada-ai/regrada-be/internal/audit"
 	"github.com/regrada-ai/regrada-be/internal/storage"
-	"github.com/regrada-ai/regrada-be/internal/storage/s3"
 )
 
 type UserHandler struct {
 	userRepo       storage.UserRepository
 	memberRepo     storage.OrganizationMemberRepository
 	storageService storage.FileStorageService
+	auditor        *Auditor
 }
 
-func NewUserHandler(userRepo storage.UserRepository, memberRepo storage.OrganizationMemberRepository, storageService storage.FileStorageService) *UserHandler {
+func NewUserHandler(userRepo storage.UserRepository, memberRepo storage.OrganizationMemberRepository, storageService storage.FileStorageService, auditor *Auditor) *UserHandler {
 	return &UserHandler{
 		userRepo:       userRepo,
 		memberRepo:     memberRepo,
 		storageService: storageService,
+		auditor:        auditor,
 	}
 }
 
@@ -264,7 +266,7 @@ func (h *UserHandler) UploadProfilePicture(c *gin.Context) {
 	defer file.Close()
 
 	// Validate image file
-	if err := s3.ValidateImageFile(header); err != nil {
+	if err := storage.ValidateImageFile(header); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": gin.H{
 				"code":    "INVALID_FILE",
@@ -275,7 +277,7 @@ func (h *UserHandler) UploadProfilePicture(c *gin.Context) {
 	}
 
 	// Generate S3 key
-	ext := s3.GetFileExtension(header.Filename)
+	ext := storage.GetFileExtension(header.Filename)
 	s3Key := fmt.Sprintf("users/%s/profile%s", userID, ext)
 
 	// Delete old profile picture if exists
@@ -613,6 +615,9 @@ func (h *UserHandler) UpdateOrganizationMemberRole(c *gin.Context) {
 		return
 	}
 
+	h.auditor.Record(c, orgID, audit.ActionMemberRoleUpdate, audit.TargetMember, userID,
+		audit.Fields{"role": member.Role}, audit.Fields{"role": req.Role})
+
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 	})
@@ -676,6 +681,9 @@ func (h *UserHandler) RemoveOrganizationMember(c *gin.Context) {
 		return
 	}
 
+	h.auditor.Record(c, orgID, audit.ActionMemberRemove, audit.TargetMember, userID,
+		audit.Fields{"role": member.Role}, nil)
+
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 	})
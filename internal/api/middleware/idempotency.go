@@ -0,0 +1,252 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"hash"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	idempotencyHeader       = "Idempotency-Key"
+	idempotencyMaxKeyLength = 255
+	// Responses larger than this are not stored, so retries re-execute
+	idempotencyMaxBodySize = 1 << 20
+)
+
+// idempotencyRecord is stored in Redis for each Idempotency-Key
+type idempotencyRecord struct {
+	State       string `json:"state"` // "processing" or "completed"
+	Fingerprint string `json:"fingerprint,omitempty"`
+	StatusCode  int    `json:"status_code,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	Body        []byte `json:"body,omitempty"`
+}
+
+type IdempotencyMiddleware struct {
+	redisClient *redis.Client
+	ttl         time.Duration
+	lockTTL     time.Duration
+}
+
+func NewIdempotencyMiddleware(redisClient *redis.Client) *IdempotencyMiddleware {
+	return &IdempotencyMiddleware{
+		redisClient: redisClient,
+		ttl:         24 * time.Hour,
+		lockTTL:     5 * time.Minute,
+	}
+}
+
+// Handle makes POST requests carrying an Idempotency-Key header safe to retry.
+// The first request with a key is executed and its response stored for 24h;
+// later requests with the same key and body replay the stored response, and
+// requests with the same key but a different body are rejected.
+// It must run after authentication so keys are scoped per caller.
+func (m *IdempotencyMiddleware) Handle() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(idempotencyHeader)
+		if c.Request.Method != http.MethodPost || key == "" {
+			c.Next()
+			return
+		}
+
+		if len(key) > idempotencyMaxKeyLength {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": gin.H{
+					"code":    "INVALID_REQUEST",
+					"message": "Idempotency-Key must be at most 255 characters",
+				},
+			})
+			c.Abort()
+			return
+		}
+
+		ctx := c.Request.Context()
+		redisKey := m.redisKey(c, key)
+
+		processing, _ := json.Marshal(idempotencyRecord{State: "processing"})
+		acquired, err := m.redisClient.SetNX(ctx, redisKey, processing, m.lockTTL).Result()
+		if err != nil {
+			// Fail open: idempotency is best effort when Redis is unavailable
+			log.Printf("Idempotency Redis error: %v", err)
+			c.Next()
+			return
+		}
+
+		if !acquired {
+			m.replay(c, redisKey)
+			return
+		}
+
+		// The response is stored even if the client has gone away, since
+		// that is when it retries. Unless it is stored, the lock is released,
+		// including when the handler panics.
+		storeCtx := context.WithoutCancel(ctx)
+		stored := false
+		defer func() {
+			if !stored {
+				m.redisClient.Del(storeCtx, redisKey)
+			}
+		}()
+
+		// Hash the body as the handler reads it, without buffering it
+		hasher := sha256.New()
+		if c.Request.Body != nil {
+			c.Request.Body = &hashingReadCloser{ReadCloser: c.Request.Body, hash: hasher}
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+
+		c.Next()
+
+		// Drain anything the handler didn't read so the fingerprint covers the full body
+		if c.Request.Body != nil {
+			io.Copy(io.Discard, c.Request.Body)
+		}
+
+		status := recorder.Status()
+		if status >= http.StatusInternalServerError || status == http.StatusTooManyRequests || recorder.overflow {
+			// Let the client retry failed, throttled, or unstorable requests
+			return
+		}
+
+		record := idempotencyRecord{
+			State:       "completed",
+			Fingerprint: hex.EncodeToString(hasher.Sum(nil)),
+			StatusCode:  status,
+			ContentType: recorder.Header().Get("Content-Type"),
+			Body:        recorder.body.Bytes(),
+		}
+		data, err := json.Marshal(record)
+		if err != nil {
+			return
+		}
+		if err := m.redisClient.Set(storeCtx, redisKey, data, m.ttl).Err(); err != nil {
+			log.Printf("Failed to store idempotent response: %v", err)
+			return
+		}
+		stored = true
+	}
+}
+
+// replay returns the stored response for a key that was already used
+func (m *IdempotencyMiddleware) replay(c *gin.Context, redisKey string) {
+	data, err := m.redisClient.Get(c.Request.Context(), redisKey).Bytes()
+	if err != nil {
+		if err != redis.Nil {
+			log.Printf("Idempotency Redis error: %v", err)
+		}
+		// The original request finished and was cleared between SETNX and GET
+		m.conflict(c)
+		return
+	}
+
+	var record idempotencyRecord
+	if err := json.Unmarshal(data, &record); err != nil || record.State != "completed" {
+		m.conflict(c)
+		return
+	}
+
+	hasher := sha256.New()
+	if c.Request.Body != nil {
+		io.Copy(hasher, c.Request.Body)
+	}
+	if hex.EncodeToString(hasher.Sum(nil)) != record.Fingerprint {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error": gin.H{
+				"code":    "IDEMPOTENCY_KEY_REUSED",
+				"message": "Idempotency-Key was already used with a different request body",
+			},
+		})
+		c.Abort()
+		return
+	}
+
+	c.Header("Idempotent-Replayed", "true")
+	contentType := record.ContentType
+	if contentType == "" {
+		contentType = "application/json; charset=utf-8"
+	}
+	c.Data(record.StatusCode, contentType, record.Body)
+	c.Abort()
+}
+
+func (m *IdempotencyMiddleware) conflict(c *gin.Context) {
+	c.JSON(http.StatusConflict, gin.H{
+		"error": gin.H{
+			"code":    "IDEMPOTENCY_IN_PROGRESS",
+			"message": "A request with this Idempotency-Key is still being processed",
+		},
+	})
+	c.Abort()
+}
+
+// redisKey scopes the key to the caller and route so keys cannot collide
+// across organizations or endpoints
+func (m *IdempotencyMiddleware) redisKey(c *gin.Context, key string) string {
+	scope := c.GetString("organization_id")
+	if scope == "" {
+		scope = c.GetString("user_id")
+	}
+	if scope == "" {
+		scope = c.GetString("api_key_hash")
+	}
+
+	sum := sha256.Sum256([]byte(scope + "\n" + c.Request.URL.Path + "\n" + key))
+	return "idempotency:" + hex.EncodeToString(sum[:])
+}
+
+// hashingReadCloser feeds everything read from the body into a hash
+type hashingReadCloser struct {
+	io.ReadCloser
+	hash hash.Hash
+}
+
+func (r *hashingReadCloser) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if n > 0 {
+		r.hash.Write(p[:n])
+	}
+	return n, err
+}
+
+// responseRecorder captures the response body while writing it through
+type responseRecorder struct {
+	gin.ResponseWriter
+	body     bytes.Buffer
+	overflow bool
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.capture(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.capture([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *responseRecorder) capture(b []byte) {
+	if w.overflow {
+		return
+	}
+	if w.body.Len()+len(b) > idempotencyMaxBodySize {
+		w.overflow = true
+		w.body.Reset()
+		return
+	}
+	w.body.Write(b)
+}
@@ -0,0 +1,40 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InternalTokenHeader carries the token of operator-only endpoints
+const InternalTokenHeader = "X-Internal-Token"
+
+// NewInternalAuthMiddleware admits only requests that send the internal
+// token, for endpoints that report on the whole deployment rather than one
+// organization. With no token configured the endpoints are not served.
+func NewInternalAuthMiddleware(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token == "" {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{
+				"error": gin.H{
+					"code":    "NOT_FOUND",
+					"message": "Not found",
+				},
+			})
+			return
+		}
+		if subtle.ConstantTimeCompare([]byte(c.GetHeader(InternalTokenHeader)), []byte(token)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error": gin.H{
+					"code":    "UNAUTHORIZED",
+					"message": "Internal token required",
+				},
+			})
+			return
+		}
+		c.Next()
+	}
+}
@@ -3,6 +3,8 @@
 package middleware
 
 import (
+	"context"
+	"log"
 	"net/http"
 	"strconv"
 	"time"
@@ -21,9 +23,52 @@ func NewUsageMiddleware(orgRepo storage.OrganizationRepository) *UsageMiddleware
 	}
 }
 
+// UsageUnitsKey is the context key handlers set to the number of units (e.g.
+// traces) a request consumed. Requests that don't set it count as one unit
+// when they succeed.
+const UsageUnitsKey = "usage_units"
+
+// UsageMeterKey is the context key of the request's *UsageMeter
+const UsageMeterKey = "usage_meter"
+
+// UsageMeter reserves an organization's remaining monthly quota for a
+// request. Handlers that consume more than one unit reserve it before storing
+// anything, so concurrent requests cannot take usage past the limit.
+type UsageMeter struct {
+	orgRepo storage.OrganizationRepository
+	orgID   string
+	// ceiling is the count requests are stopped at, or -1 for no limit
+	ceiling int64
+	// prepaid is reserved before the handler ran and not yet claimed by it
+	prepaid int64
+	// charged is what has been added to the organization's count
+	charged int64
+}
+
+// Reserve claims up to n units and returns how many were granted. When the
+// reservation cannot be made the request is allowed (fail open) and charged
+// afterwards.
+func (m *UsageMeter) Reserve(ctx context.Context, n int) int {
+	want := int64(n)
+	granted := min(want, m.prepaid)
+	m.prepaid -= granted
+	want -= granted
+	if want == 0 || m.ceiling < 0 {
+		return n
+	}
+
+	reserved, err := m.orgRepo.ReserveUsage(ctx, m.orgID, want, m.ceiling)
+	if err != nil {
+		log.Printf("Failed to reserve usage for organization %s: %v", m.orgID, err)
+		return n
+	}
+	m.charged += reserved
+	return int(granted + reserved)
+}
+
 // TrackUsage tracks and enforces monthly usage limits for metered requests.
 // This should be applied to routes that count against the monthly limit:
-// - Trace ingestion
+// - Trace ingestion (counted per trace)
 // - Test run uploads
 // - Regression evaluation runs
 func (m *UsageMiddleware) TrackUsage() gin.HandlerFunc {
@@ -36,10 +81,9 @@ func (m *UsageMiddleware) TrackUsage() gin.HandlerFunc {
 
 		ctx := c.Request.Context()
 
-		// Get current org state and increment counter atomically
-		org, err := m.orgRepo.IncrementRequestCount(ctx, orgID)
+		org, err := m.orgRepo.Get(ctx, orgID)
 		if err != nil {
-			// On error, allow the request (fail open) but log it
+			// On error, allow the request (fail open)
 			c.Next()
 			return
 		}
@@ -48,65 +92,125 @@ func (m *UsageMiddleware) TrackUsage() gin.HandlerFunc {
 		if time.Now().UTC().After(org.UsageResetAt) {
 			if err := m.orgRepo.ResetMonthlyUsage(ctx, orgID); err == nil {
 				// Refetch after reset
-				org, _ = m.orgRepo.Get(ctx, orgID)
+				if refreshed, err := m.orgRepo.Get(ctx, orgID); err == nil {
+					org = refreshed
+				}
 			}
 		}
 
-		// Calculate usage percentage
-		usagePercent := float64(org.MonthlyRequestCount) / float64(org.MonthlyRequestLimit) * 100
-
-		// Set usage headers for client visibility
+		// Set usage headers for client visibility (usage before this request)
 		c.Header("X-Monthly-Limit", formatInt64(org.MonthlyRequestLimit))
 		c.Header("X-Monthly-Used", formatInt64(org.MonthlyRequestCount))
 		c.Header("X-Monthly-Reset", org.UsageResetAt.Format(time.RFC3339))
 
-		// Enforce limits based on tier
 		tier := c.GetString("tier")
 		if tier == "" {
 			tier = org.Tier
 		}
 
-		// Starter tier: hard stop at 100%
-		if tier == "starter" && usagePercent >= 100 {
-			c.JSON(http.StatusPaymentRequired, gin.H{
-				"error": gin.H{
-					"code":    "USAGE_LIMIT_EXCEEDED",
-					"message": "Monthly request limit exceeded. Upgrade your plan to continue.",
-					"details": gin.H{
-						"limit":    org.MonthlyRequestLimit,
-						"used":     org.MonthlyRequestCount,
-						"tier":     tier,
-						"reset_at": org.UsageResetAt,
-					},
-				},
-			})
-			c.Abort()
-			return
+		meter := &UsageMeter{
+			orgRepo: m.orgRepo,
+			orgID:   orgID,
+			ceiling: usageCeiling(tier, org.MonthlyRequestLimit),
 		}
 
-		// Team/Scale tiers: throttle at 120% (reduce RPM by 50%)
-		if (tier == "team" || tier == "scale") && usagePercent >= 120 {
-			c.JSON(http.StatusTooManyRequests, gin.H{
-				"error": gin.H{
-					"code":    "USAGE_LIMIT_THROTTLED",
-					"message": "Monthly request limit exceeded by 20%. Requests are being throttled.",
-					"details": gin.H{
-						"limit":    org.MonthlyRequestLimit,
-						"used":     org.MonthlyRequestCount,
-						"tier":     tier,
-						"reset_at": org.UsageResetAt,
-						"overage":  org.MonthlyRequestCount - org.MonthlyRequestLimit,
-					},
-				},
-			})
-			c.Abort()
+		// Reserve the request's first unit up front, atomically with the limit
+		// check
+		if meter.ceiling >= 0 {
+			reserved, err := m.orgRepo.ReserveUsage(ctx, orgID, 1, meter.ceiling)
+			switch {
+			case err != nil:
+				// Allow the request (fail open) and charge it afterwards
+				log.Printf("Failed to reserve usage for organization %s: %v", orgID, err)
+			case reserved == 0:
+				rejectOverLimit(c, org, tier)
+				return
+			default:
+				meter.prepaid = reserved
+				meter.charged = reserved
+			}
+		}
+		c.Set(UsageMeterKey, meter)
+
+		c.Next()
+
+		// Settle what the request actually consumed against what it reserved,
+		// even if the client has gone away
+		delta := usageUnits(c) - meter.charged
+		if delta == 0 {
 			return
 		}
+		if _, err := m.orgRepo.IncrementRequestCount(context.WithoutCancel(ctx), orgID, delta); err != nil {
+			log.Printf("Failed to record usage for organization %s: %v", orgID, err)
+		}
+	}
+}
 
+// usageCeiling returns the monthly count a tier's requests are stopped at,
+// or -1 for no limit
+func usageCeiling(tier string, limit int64) int64 {
+	switch tier {
+	case "starter":
+		// Starter tier: hard stop at 100%
+		return limit
+	case "team", "scale":
+		// Team/Scale tiers: throttle at 120%
+		return limit * 120 / 100
+	default:
 		// Enterprise: no hard limit enforcement (custom/negotiated)
+		return -1
+	}
+}
 
-		c.Next()
+// rejectOverLimit writes the response for a request over the tier's limit
+func rejectOverLimit(c *gin.Context, org *storage.Organization, tier string) {
+	if tier == "starter" {
+		c.JSON(http.StatusPaymentRequired, gin.H{
+			"error": gin.H{
+				"code":    "USAGE_LIMIT_EXCEEDED",
+				"message": "Monthly request limit exceeded. Upgrade your plan to continue.",
+				"details": gin.H{
+					"limit":    org.MonthlyRequestLimit,
+					"used":     org.MonthlyRequestCount,
+					"tier":     tier,
+					"reset_at": org.UsageResetAt,
+				},
+			},
+		})
+		c.Abort()
+		return
+	}
+
+	c.JSON(http.StatusTooManyRequests, gin.H{
+		"error": gin.H{
+			"code":    "USAGE_LIMIT_THROTTLED",
+			"message": "Monthly request limit exceeded by 20%. Requests are being throttled.",
+			"details": gin.H{
+				"limit":    org.MonthlyRequestLimit,
+				"used":     org.MonthlyRequestCount,
+				"tier":     tier,
+				"reset_at": org.UsageResetAt,
+				"overage":  org.MonthlyRequestCount - org.MonthlyRequestLimit,
+			},
+		},
+	})
+	c.Abort()
+}
+
+// usageUnits returns the units consumed by a completed request
+func usageUnits(c *gin.Context) int64 {
+	if value, ok := c.Get(UsageUnitsKey); ok {
+		switch n := value.(type) {
+		case int:
+			return int64(n)
+		case int64:
+			return n
+		}
+	}
+	if c.Writer.Status() >= http.StatusBadRequest {
+		return 0
 	}
+	return 1
 }
 
 func formatInt64(n int64) string {
@@ -0,0 +1,366 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+// Package archive moves old traces out of Postgres into compressed files in
+// file storage and restores them on demand.
+package archive
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/regrada-ai/regrada-be/internal/retention"
+	"github.com/regrada-ai/regrada-be/internal/storage"
+)
+
+const (
+	archiveLockKey = "archive:lock"
+	archiveLockTTL = 30 * time.Minute
+
+	// restoreStaleAfter is how long a running restore may go without progress
+	// before another instance takes it over
+	restoreStaleAfter = 15 * time.Minute
+)
+
+// Config configures the archive job
+type Config struct {
+	Interval         time.Duration // time between archive runs
+	TracesPerFile    int           // maximum traces in one archive file
+	PageSize         int           // traces read from Postgres per query
+	RestoreBatchSize int           // traces written per COPY when restoring
+	RestorePoll      time.Duration // how often to look for restores requested on other instances
+}
+
+// DefaultConfig returns the default archive settings
+func DefaultConfig() Config {
+	return Config{
+		Interval:         6 * time.Hour,
+		TracesPerFile:    50_000,
+		PageSize:         1000,
+		RestoreBatchSize: 500,
+		RestorePoll:      30 * time.Second,
+	}
+}
+
+// Archiver periodically archives traces older than each project's threshold
+// and deletes archives once they pass the project's retention period. It also
+// processes restore requests. A Redis lock ensures only one instance archives
+// at a time; restores are claimed in the database.
+type Archiver struct {
+	archiveRepo   storage.ArchiveRepository
+	retentionRepo storage.RetentionRepository
+	traceRepo     storage.TraceRepository
+	files         storage.FileStorageService
+	redisClient   *redis.Client
+	cfg           Config
+
+	wake   chan struct{}
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewArchiver(
+	archiveRepo storage.ArchiveRepository,
+	retentionRepo storage.RetentionRepository,
+	traceRepo storage.TraceRepository,
+	files storage.FileStorageService,
+	redisClient *redis.Client,
+	cfg Config,
+) *Archiver {
+	return &Archiver{
+		archiveRepo:   archiveRepo,
+		retentionRepo: retentionRepo,
+		traceRepo:     traceRepo,
+		files:         files,
+		redisClient:   redisClient,
+		cfg:           cfg,
+		wake:          make(chan struct{}, 1),
+	}
+}
+
+// Start runs the archive job every Interval and processes restores until Stop is called
+func (a *Archiver) Start(ctx context.Context) {
+	ctx, a.cancel = context.WithCancel(ctx)
+
+	a.wg.Add(2)
+	go func() {
+		defer a.wg.Done()
+
+		ticker := time.NewTicker(a.cfg.Interval)
+		defer ticker.Stop()
+
+		for {
+			if err := a.RunOnce(ctx); err != nil && ctx.Err() == nil {
+				log.Printf("Trace archive failed: %v", err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	go func() {
+		defer a.wg.Done()
+		a.processRestores(ctx)
+	}()
+}
+
+// Stop cancels the archive job and waits for it to exit. An interrupted
+// restore is resumed by another instance once it goes stale.
+func (a *Archiver) Stop() {
+	if a.cancel == nil {
+		return
+	}
+	a.cancel()
+	a.wg.Wait()
+}
+
+// RequestRestore records a restore and wakes the restore loop
+func (a *Archiver) RequestRestore(ctx context.Context, restore *storage.ArchiveRestore) error {
+	if err := a.archiveRepo.CreateRestore(ctx, restore); err != nil {
+		return err
+	}
+
+	select {
+	case a.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// RunOnce removes expired restores, archives every project with archiving
+// enabled, and removes expired archives, unless another instance is already
+// doing so
+func (a *Archiver) RunOnce(ctx context.Context) error {
+	acquired, err := a.redisClient.SetNX(ctx, archiveLockKey, time.Now().UTC().Format(time.RFC3339), archiveLockTTL).Result()
+	if err != nil {
+		return err
+	}
+	if !acquired {
+		return nil
+	}
+	defer a.redisClient.Del(context.WithoutCancel(ctx), archiveLockKey)
+
+	start := time.Now()
+	if err := a.expireRestores(ctx, start); err != nil {
+		return err
+	}
+
+	policies, err := a.archiveRepo.ListPolicies(ctx)
+	if err != nil {
+		return err
+	}
+
+	var files, traces int
+	for _, policy := range policies {
+		before := start.AddDate(0, 0, -policy.AfterDays)
+
+		n, count, err := a.archiveProject(ctx, policy.ProjectID, before)
+		files += n
+		traces += count
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			log.Printf("Failed to archive traces for project %s: %v", policy.ProjectID, err)
+			continue
+		}
+		if count > 0 {
+			log.Printf("Archived %d traces for project %s in %d files", count, policy.ProjectID, n)
+		}
+	}
+
+	if err := a.expireArchives(ctx, start); err != nil {
+		return err
+	}
+
+	log.Printf("✓ Trace archive finished: %d traces in %d files across %d projects in %s", traces, files, len(policies), time.Since(start).Round(time.Millisecond))
+	return nil
+}
+
+// archiveProject archives a project's traces older than before, one file at a
+// time, and returns the number of files and traces archived
+func (a *Archiver) archiveProject(ctx context.Context, projectID string, before time.Time) (int, int, error) {
+	var files, traces int
+	for {
+		archive, rowIDs, err := a.archiveNext(ctx, projectID, before)
+		if err != nil {
+			return files, traces, err
+		}
+		if archive == nil {
+			return files, traces, nil
+		}
+		files++
+		traces += archive.TraceCount
+
+		if len(rowIDs) < a.cfg.TracesPerFile {
+			return files, traces, nil
+		}
+
+		// Keep the lock while a large backlog is still being archived
+		a.redisClient.Expire(ctx, archiveLockKey, archiveLockTTL)
+	}
+}
+
+// archiveNext writes the oldest archivable traces to a file, uploads it, and
+// then records the manifest and deletes the traces. It returns a nil archive
+// if there was nothing to archive.
+func (a *Archiver) archiveNext(ctx context.Context, projectID string, before time.Time) (*storage.TraceArchive, []string, error) {
+	w, err := newFileWriter()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer w.discard()
+
+	var cursor *storage.ArchiveCursor
+	var rowIDs []string
+	var periodStart, periodEnd time.Time
+	for len(rowIDs) < a.cfg.TracesPerFile {
+		limit := min(a.cfg.PageSize, a.cfg.TracesPerFile-len(rowIDs))
+		page, err := a.archiveRepo.ListArchivable(ctx, projectID, before, cursor, limit)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for i := range page {
+			if err := w.write(&page[i].Trace); err != nil {
+				return nil, nil, err
+			}
+			rowIDs = append(rowIDs, page[i].RowID)
+		}
+
+		if len(page) > 0 {
+			if periodStart.IsZero() {
+				periodStart = page[0].Trace.Timestamp
+			}
+			last := page[len(page)-1]
+			periodEnd = last.Trace.Timestamp
+			cursor = &storage.ArchiveCursor{Timestamp: last.Trace.Timestamp, RowID: last.RowID}
+		}
+		if len(page) < limit {
+			break
+		}
+	}
+
+	if len(rowIDs) == 0 {
+		return nil, nil, nil
+	}
+
+	size, checksum, err := w.close()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	archive := &storage.TraceArchive{
+		ProjectID:   projectID,
+		StorageKey:  storageKey(projectID, periodStart),
+		Format:      storage.ArchiveFormatJSONLGzip,
+		PeriodStart: periodStart,
+		PeriodEnd:   periodEnd,
+		TraceCount:  len(rowIDs),
+		SizeBytes:   size,
+		SHA256:      checksum,
+	}
+
+	if err := a.files.UploadFile(ctx, archive.StorageKey, w.file, "application/gzip"); err != nil {
+		return nil, nil, err
+	}
+
+	if err := a.archiveRepo.CreateArchive(ctx, archive, rowIDs); err != nil {
+		// Without a manifest the file would never be found again
+		if delErr := a.files.DeleteFile(context.WithoutCancel(ctx), archive.StorageKey); delErr != nil {
+			log.Printf("Failed to delete orphaned archive %s: %v", archive.StorageKey, delErr)
+		}
+		return nil, nil, err
+	}
+
+	return archive, rowIDs, nil
+}
+
+// expireArchives deletes archives whose traces are all past the project's
+// retention period
+func (a *Archiver) expireArchives(ctx context.Context, now time.Time) error {
+	projects, err := a.retentionRepo.ListProjects(ctx, "")
+	if err != nil {
+		return err
+	}
+
+	for _, project := range projects {
+		days, _ := retention.Effective(project)
+		expiredBefore, _ := retention.Cutoffs(now, days)
+
+		archives, err := a.archiveRepo.ListArchives(ctx, project.ProjectID, time.Time{}, expiredBefore)
+		if err != nil {
+			return err
+		}
+
+		for _, archive := range archives {
+			if !archive.PeriodEnd.Before(expiredBefore) {
+				continue
+			}
+			if err := a.files.DeleteFile(ctx, archive.StorageKey); err != nil {
+				log.Printf("Failed to delete expired archive %s: %v", archive.StorageKey, err)
+				continue
+			}
+			if err := a.archiveRepo.DeleteArchive(ctx, archive.ID); err != nil && err != storage.ErrNotFound {
+				return err
+			}
+			log.Printf("Deleted expired archive %s (%d traces, retention %d days)", archive.StorageKey, archive.TraceCount, days)
+		}
+	}
+	return nil
+}
+
+// expireRestores deletes the traces of restores past their expiry, which are
+// still in their archives, and marks the restores expired. Traces left behind
+// by failed restores are deleted the same way.
+func (a *Archiver) expireRestores(ctx context.Context, now time.Time) error {
+	restores, err := a.archiveRepo.ListReleasableRestores(ctx, now)
+	if err != nil {
+		return err
+	}
+
+	for _, restore := range restores {
+		var deleted int64
+		for {
+			n, err := a.archiveRepo.DeleteRestoredTraces(ctx, restore, a.cfg.PageSize)
+			if err != nil {
+				return err
+			}
+			deleted += n
+			if n < int64(a.cfg.PageSize) {
+				break
+			}
+		}
+
+		if restore.Status == storage.RestoreFailed {
+			log.Printf("Cleaned up failed archive restore %s: deleted %d restored traces", restore.ID, deleted)
+			continue
+		}
+		restore.Status = storage.RestoreExpired
+		if err := a.archiveRepo.UpdateRestore(ctx, restore); err != nil {
+			return err
+		}
+		log.Printf("Expired archive restore %s: deleted %d restored traces", restore.ID, deleted)
+	}
+	return nil
+}
+
+// storageKey returns where an archive file is stored, grouped by project and month
+func storageKey(projectID string, periodStart time.Time) string {
+	periodStart = periodStart.UTC()
+	return fmt.Sprintf("archives/traces/%s/%s/%s-%d.%s",
+		projectID,
+		periodStart.Format("2006/01"),
+		periodStart.Format("20060102T150405Z"),
+		time.Now().UnixNano(),
+		storage.ArchiveFormatJSONLGzip,
+	)
+}
@@ -0,0 +1,125 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package archive
+
+import (
+	"bufio"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+
+	"github.com/regrada-ai/regrada-be/internal/domain"
+)
+
+// maxArchiveLineBytes bounds a single trace when reading an archive back
+const maxArchiveLineBytes = 16 << 20
+
+// fileWriter writes traces as gzip-compressed JSONL to a temporary file,
+// so archives of any size are built without holding them in memory
+type fileWriter struct {
+	file *os.File
+	hash hash.Hash
+	gz   *gzip.Writer
+	buf  *bufio.Writer
+}
+
+func newFileWriter() (*fileWriter, error) {
+	file, err := os.CreateTemp("", "trace-archive-*.jsonl.gz")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create archive file: %w", err)
+	}
+
+	h := sha256.New()
+	gz := gzip.NewWriter(io.MultiWriter(file, h))
+	return &fileWriter{
+		file: file,
+		hash: h,
+		gz:   gz,
+		buf:  bufio.NewWriterSize(gz, 64<<10),
+	}, nil
+}
+
+func (w *fileWriter) write(trace *domain.Trace) error {
+	line, err := json.Marshal(trace)
+	if err != nil {
+		return err
+	}
+	if _, err := w.buf.Write(line); err != nil {
+		return err
+	}
+	return w.buf.WriteByte('\n')
+}
+
+// close finishes the file and rewinds it for upload, returning its size and
+// SHA-256 checksum
+func (w *fileWriter) close() (int64, string, error) {
+	if err := w.buf.Flush(); err != nil {
+		return 0, "", err
+	}
+	if err := w.gz.Close(); err != nil {
+		return 0, "", err
+	}
+
+	size, err := w.file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, "", err
+	}
+	if _, err := w.file.Seek(0, io.SeekStart); err != nil {
+		return 0, "", err
+	}
+
+	return size, hex.EncodeToString(w.hash.Sum(nil)), nil
+}
+
+// discard closes and removes the temporary file
+func (w *fileWriter) discard() {
+	w.file.Close()
+	os.Remove(w.file.Name())
+}
+
+var errChecksumMismatch = errors.New("archive checksum mismatch")
+
+// readArchive decodes every trace in a gzip-compressed JSONL archive, calling
+// fn for each one. The file's checksum is verified once it has been read.
+func readArchive(r io.Reader, checksum string, fn func(trace *domain.Trace) error) error {
+	h := sha256.New()
+	tee := io.TeeReader(r, h)
+	gz, err := gzip.NewReader(tee)
+	if err != nil {
+		return fmt.Errorf("invalid archive: %w", err)
+	}
+	defer gz.Close()
+
+	scanner := bufio.NewScanner(gz)
+	scanner.Buffer(make([]byte, 0, 64<<10), maxArchiveLineBytes)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var trace domain.Trace
+		if err := json.Unmarshal(scanner.Bytes(), &trace); err != nil {
+			return fmt.Errorf("invalid archive line %d: %w", line, err)
+		}
+		if err := fn(&trace); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("invalid archive: %w", err)
+	}
+
+	// Drain anything after the gzip stream so the checksum covers the whole file
+	if _, err := io.Copy(io.Discard, tee); err != nil {
+		return err
+	}
+	if hex.EncodeToString(h.Sum(nil)) != checksum {
+		return errChecksumMismatch
+	}
+	return nil
+}
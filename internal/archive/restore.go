@@ -0,0 +1,135 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package archive
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/regrada-ai/regrada-be/internal/domain"
+	"github.com/regrada-ai/regrada-be/internal/storage"
+)
+
+// processRestores claims and runs restores until ctx is cancelled
+func (a *Archiver) processRestores(ctx context.Context) {
+	ticker := time.NewTicker(a.cfg.RestorePoll)
+	defer ticker.Stop()
+
+	for {
+		restore, err := a.archiveRepo.ClaimRestore(ctx, restoreStaleAfter)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("Failed to claim archive restore: %v", err)
+		}
+
+		if restore != nil {
+			a.runRestore(ctx, restore)
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-a.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// runRestore loads every archive overlapping the restore's range back into
+// traces. Progress is saved after each archive so an interrupted restore
+// resumes where it stopped.
+func (a *Archiver) runRestore(ctx context.Context, restore *storage.ArchiveRestore) {
+	archives, err := a.archiveRepo.ListArchives(ctx, restore.ProjectID, restore.RangeStart, restore.RangeEnd)
+	if err != nil {
+		a.finishRestore(ctx, restore, err)
+		return
+	}
+	restore.ArchivesTotal = len(archives)
+
+	for i := restore.ArchivesRestored; i < len(archives); i++ {
+		n, err := a.restoreArchive(ctx, restore, archives[i])
+		restore.TracesRestored += n
+		if err != nil {
+			a.finishRestore(ctx, restore, fmt.Errorf("archive %s: %w", archives[i].ID, err))
+			return
+		}
+
+		restore.ArchivesRestored = i + 1
+		if err := a.archiveRepo.UpdateRestore(ctx, restore); err != nil && ctx.Err() == nil {
+			log.Printf("Failed to save progress of archive restore %s: %v", restore.ID, err)
+		}
+	}
+
+	a.finishRestore(ctx, restore, nil)
+}
+
+// restoreArchive copies the traces of one archive that fall in the restore's
+// range into Postgres, skipping traces that already exist
+func (a *Archiver) restoreArchive(ctx context.Context, restore *storage.ArchiveRestore, archive *storage.TraceArchive) (int64, error) {
+	if archive.Format != storage.ArchiveFormatJSONLGzip {
+		return 0, fmt.Errorf("unsupported archive format %q", archive.Format)
+	}
+
+	body, err := a.files.DownloadFile(ctx, archive.StorageKey)
+	if err != nil {
+		return 0, err
+	}
+	defer body.Close()
+
+	var restored int64
+	batch := make([]storage.ProjectTrace, 0, a.cfg.RestoreBatchSize)
+	flush := func() error {
+		n, err := a.traceRepo.CopyBatch(ctx, batch, storage.ConflictIgnore)
+		restored += n
+		batch = batch[:0]
+		return err
+	}
+
+	err = readArchive(body, archive.SHA256, func(trace *domain.Trace) error {
+		if trace.Timestamp.Before(restore.RangeStart) || !trace.Timestamp.Before(restore.RangeEnd) {
+			return nil
+		}
+		batch = append(batch, storage.ProjectTrace{ProjectID: restore.ProjectID, Trace: *trace, RestoreID: restore.ID})
+		if len(batch) == a.cfg.RestoreBatchSize {
+			return flush()
+		}
+		return nil
+	})
+	if err != nil {
+		return restored, err
+	}
+
+	if len(batch) > 0 {
+		if err := flush(); err != nil {
+			return restored, err
+		}
+	}
+	return restored, nil
+}
+
+// finishRestore records a restore's outcome. A restore interrupted by
+// shutdown is left running so another instance resumes it.
+func (a *Archiver) finishRestore(ctx context.Context, restore *storage.ArchiveRestore, err error) {
+	if ctx.Err() != nil {
+		return
+	}
+
+	now := time.Now()
+	restore.CompletedAt = &now
+	if err != nil {
+		log.Printf("Archive restore %s failed: %v", restore.ID, err)
+		restore.Status = storage.RestoreFailed
+		restore.Error = err.Error()
+	} else {
+		expiresAt := now.AddDate(0, 0, restore.KeepDays)
+		restore.Status = storage.RestoreCompleted
+		restore.ExpiresAt = &expiresAt
+		log.Printf("✓ Archive restore %s finished: %d traces from %d archives", restore.ID, restore.TracesRestored, restore.ArchivesTotal)
+	}
+
+	if err := a.archiveRepo.UpdateRestore(ctx, restore); err != nil {
+		log.Printf("Failed to save archive restore %s: %v", restore.ID, err)
+	}
+}
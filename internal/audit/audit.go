@@ -0,0 +1,114 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+// Package audit describes the administrative actions recorded in an
+// organization's audit log and the field changes each entry carries.
+package audit
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/regrada-ai/regrada-be/internal/csvsafe"
+	"github.com/regrada-ai/regrada-be/internal/storage"
+)
+
+// Actions
+const (
+	ActionOrganizationUpdate = "organization.update"
+	ActionOrganizationDelete = "organization.delete"
+	ActionMemberRoleUpdate   = "member.role_update"
+	ActionMemberRemove       = "member.remove"
+	ActionInviteCreate       = "invite.create"
+	ActionInviteRevoke       = "invite.revoke"
+	ActionAPIKeyCreate       = "api_key.create"
+	ActionAPIKeyRevoke       = "api_key.revoke"
+	ActionAPIKeyDelete       = "api_key.delete"
+	ActionProjectDelete      = "project.delete"
+)
+
+// Target types
+const (
+	TargetOrganization = "organization"
+	TargetMember       = "member"
+	TargetInvite       = "invite"
+	TargetAPIKey       = "api_key"
+	TargetProject      = "project"
+)
+
+// Fields are the audited fields of a target, by name. Values must encode
+// as JSON.
+type Fields map[string]any
+
+// Change is a field's value before and after an action. Before is absent
+// for created fields and After for removed ones.
+type Change struct {
+	Before any `json:"before,omitempty"`
+	After  any `json:"after,omitempty"`
+}
+
+// Diff returns the fields whose values differ between before and after as
+// a JSON object of Changes. Either side may be nil, for a created or
+// deleted target.
+func Diff(before, after Fields) (json.RawMessage, error) {
+	changes := make(map[string]Change)
+	for name, value := range before {
+		changes[name] = Change{Before: value}
+	}
+	for name, value := range after {
+		change := changes[name]
+		change.After = value
+		changes[name] = change
+	}
+
+	for name, change := range changes {
+		beforeJSON, err := json.Marshal(change.Before)
+		if err != nil {
+			return nil, err
+		}
+		afterJSON, err := json.Marshal(change.After)
+		if err != nil {
+			return nil, err
+		}
+		if bytes.Equal(beforeJSON, afterJSON) {
+			delete(changes, name)
+		}
+	}
+	return json.Marshal(changes)
+}
+
+// WriteCSV writes entries with one row each. Changes are written as their
+// JSON object. Cells that start like a spreadsheet formula are escaped.
+func WriteCSV(w io.Writer, entries []*storage.AuditLogEntry) error {
+	cw := csv.NewWriter(w)
+	header := []string{
+		"created_at", "actor_type", "actor_id", "actor_name", "action",
+		"target_type", "target_id", "changes", "ip_address", "user_agent",
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		record := []string{
+			entry.CreatedAt.UTC().Format(time.RFC3339),
+			entry.ActorType,
+			entry.ActorID,
+			entry.ActorName,
+			entry.Action,
+			entry.TargetType,
+			entry.TargetID,
+			string(entry.Changes),
+			entry.IPAddress,
+			entry.UserAgent,
+		}
+		if err := cw.Write(csvsafe.Escape(record)); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
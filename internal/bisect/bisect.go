@@ -0,0 +1,210 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+// Package bisect finds the commit that introduced a regression from the test
+// run history of a branch.
+package bisect
+
+import (
+	"sort"
+
+	"github.com/regrada-ai/regrada-be/internal/storage"
+)
+
+// Bisect states
+const (
+	// StatusFound means the first bad SHA directly follows the last good one
+	StatusFound = "found"
+	// StatusNarrowing means untested commits lie between the last good and
+	// first bad SHAs
+	StatusNarrowing = "narrowing"
+	// StatusNoGoodRun means the case failed in every run of the history
+	StatusNoGoodRun = "no_good_run"
+	// StatusNotRegressed means the case passes at the newest tested SHA
+	StatusNotRegressed = "not_regressed"
+)
+
+// Result locates the regression of one case
+type Result struct {
+	CaseID        string  `json:"case_id"`
+	Status        string  `json:"status"`
+	LastGoodSHA   string  `json:"last_good_sha,omitempty"`
+	LastGoodRate  float64 `json:"last_good_pass_rate"`
+	FirstBadSHA   string  `json:"first_bad_sha,omitempty"`
+	FirstBadRate  float64 `json:"first_bad_pass_rate"`
+	TestedCommits int     `json:"tested_commits"`
+	// Untested lists the commits between the last good and first bad SHAs
+	// with no test run, oldest first. It is only known if the caller passes
+	// the branch's commits.
+	Untested []string `json:"untested,omitempty"`
+	// Next lists the untested commits CI should run next, which split the
+	// untested range into equal parts
+	Next []string `json:"next,omitempty"`
+}
+
+// Options tune a bisect
+type Options struct {
+	// MinPassRate is the pass rate at or above which a SHA is good
+	MinPassRate float64
+	// Commits are the branch's commits, oldest first, used to find the
+	// untested commits in the regression range
+	Commits []string
+	// Parallel is the number of commits suggested to run next
+	Parallel int
+}
+
+// tested is a case's mean pass rate at one git SHA
+type tested struct {
+	sha      string
+	passRate float64
+}
+
+// Case bisects one case over the observations, which must be oldest first.
+// A SHA's pass rate is the mean over the case's results at that SHA, across
+// models and repeated runs. It returns nil if the case was never observed.
+func Case(observations []storage.CaseObservation, caseID string, opts Options) *Result {
+	history := shaHistory(observations, caseID)
+	if len(history) == 0 {
+		return nil
+	}
+
+	result := &Result{CaseID: caseID, TestedCommits: len(history)}
+	newest := history[len(history)-1]
+	if newest.passRate >= opts.MinPassRate {
+		result.Status = StatusNotRegressed
+		return result
+	}
+
+	firstBad := len(history) - 1
+	for firstBad > 0 && history[firstBad-1].passRate < opts.MinPassRate {
+		firstBad--
+	}
+	result.FirstBadSHA = history[firstBad].sha
+	result.FirstBadRate = history[firstBad].passRate
+	if firstBad == 0 {
+		result.Status = StatusNoGoodRun
+		return result
+	}
+	result.LastGoodSHA = history[firstBad-1].sha
+	result.LastGoodRate = history[firstBad-1].passRate
+
+	result.Status = StatusFound
+	if len(opts.Commits) == 0 {
+		return result
+	}
+
+	testedSHAs := make(map[string]bool, len(history))
+	for _, t := range history {
+		testedSHAs[t.sha] = true
+	}
+	result.Untested = untestedBetween(opts.Commits, result.LastGoodSHA, result.FirstBadSHA, testedSHAs)
+	if len(result.Untested) > 0 {
+		result.Status = StatusNarrowing
+		result.Next = splitPoints(result.Untested, max(opts.Parallel, 1))
+	}
+	return result
+}
+
+// Regressed returns the IDs of the cases failing at the newest SHA they were
+// tested at, in order of first observation
+func Regressed(observations []storage.CaseObservation, minPassRate float64) []string {
+	var caseIDs []string
+	seen := make(map[string]bool)
+	for _, observation := range observations {
+		if !seen[observation.CaseID] {
+			seen[observation.CaseID] = true
+			caseIDs = append(caseIDs, observation.CaseID)
+		}
+	}
+
+	var regressed []string
+	for _, caseID := range caseIDs {
+		history := shaHistory(observations, caseID)
+		// Cases only run without a git SHA have nothing to bisect
+		if len(history) == 0 {
+			continue
+		}
+		if history[len(history)-1].passRate < minPassRate {
+			regressed = append(regressed, caseID)
+		}
+	}
+	return regressed
+}
+
+// shaHistory returns the case's mean pass rate per git SHA, ordered by the
+// SHA's first test run
+func shaHistory(observations []storage.CaseObservation, caseID string) []tested {
+	type sum struct {
+		total float64
+		count int
+	}
+
+	sums := make(map[string]*sum)
+	var order []string
+	for _, observation := range observations {
+		if observation.CaseID != caseID || observation.GitSHA == "" {
+			continue
+		}
+		s := sums[observation.GitSHA]
+		if s == nil {
+			s = &sum{}
+			sums[observation.GitSHA] = s
+			order = append(order, observation.GitSHA)
+		}
+		s.total += observation.PassRate
+		s.count++
+	}
+
+	history := make([]tested, len(order))
+	for i, sha := range order {
+		history[i] = tested{sha: sha, passRate: sums[sha].total / float64(sums[sha].count)}
+	}
+	return history
+}
+
+// untestedBetween returns the commits strictly between lastGood and
+// firstBad that were not tested. If lastGood is not among the commits, the
+// range starts at the first commit; if firstBad is not, nothing is returned.
+func untestedBetween(commits []string, lastGood, firstBad string, testedSHAs map[string]bool) []string {
+	start, end := -1, -1
+	for i, sha := range commits {
+		switch sha {
+		case lastGood:
+			start = i
+		case firstBad:
+			end = i
+		}
+	}
+	if end < 0 || start >= end {
+		return nil
+	}
+
+	var untested []string
+	for _, sha := range commits[start+1 : end] {
+		if !testedSHAs[sha] {
+			untested = append(untested, sha)
+		}
+	}
+	return untested
+}
+
+// splitPoints returns up to n commits splitting the range into n+1 parts of
+// equal size, so a single commit is the midpoint of a binary search
+func splitPoints(commits []string, n int) []string {
+	n = min(n, len(commits))
+	indices := make(map[int]bool, n)
+	for i := 1; i <= n; i++ {
+		indices[i*len(commits)/(n+1)] = true
+	}
+
+	sorted := make([]int, 0, len(indices))
+	for index := range indices {
+		sorted = append(sorted, index)
+	}
+	sort.Ints(sorted)
+
+	points := make([]string, len(sorted))
+	for i, index := range sorted {
+		points[i] = commits[index]
+	}
+	return points
+}
@@ -0,0 +1,285 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+// Package comparison pivots test run results into a case by model matrix.
+package comparison
+
+import (
+	"encoding/csv"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/regrada-ai/regrada-be/internal/csvsafe"
+	"github.com/regrada-ai/regrada-be/internal/domain"
+)
+
+// summaryCaseID labels the per-model summary row of the CSV matrix
+const summaryCaseID = "(all cases)"
+
+// Model is a column of the matrix
+type Model struct {
+	Provider string `json:"provider"`
+	Model    string `json:"model"`
+}
+
+// Cell is a case's results on one model
+type Cell struct {
+	Observations int     `json:"observations"` // test runs the case ran on the model in
+	Runs         int     `json:"runs"`         // individual runs of the case
+	PassRate     float64 `json:"pass_rate"`
+	LatencyP95MS int     `json:"latency_p95_ms"`
+	RefusalRate  float64 `json:"refusal_rate"`
+	// CostUSD is the mean cost per run, if the runs reported their cost
+	CostUSD *float64 `json:"cost_usd,omitempty"`
+}
+
+// Row is a case's cells, one per model of the matrix and null where the
+// case did not run on the model
+type Row struct {
+	CaseID string  `json:"case_id"`
+	Cells  []*Cell `json:"cells"`
+}
+
+// Summary is a model's results over all its cases. Pass and refusal rates
+// are means over the model's cases, so every case weighs the same.
+type Summary struct {
+	Model
+	Cases        int      `json:"cases"`
+	Runs         int      `json:"runs"`
+	PassRate     float64  `json:"pass_rate"`
+	LatencyP95MS int      `json:"latency_p95_ms"`
+	RefusalRate  float64  `json:"refusal_rate"`
+	CostUSD      *float64 `json:"cost_usd,omitempty"` // mean cost per run
+	TotalCostUSD *float64 `json:"total_cost_usd,omitempty"`
+}
+
+// Matrix is the case by model matrix of one or more test runs
+type Matrix struct {
+	TestRuns int       `json:"test_runs"`
+	Models   []Model   `json:"models"`
+	Cases    []Row     `json:"cases"`
+	Summary  []Summary `json:"summary"` // one per model
+}
+
+// accumulator collects the results a cell or summary is computed from
+type accumulator struct {
+	observations int
+	runs         int
+	passRateSum  float64
+	refusalSum   float64
+	latencies    []int
+	costSum      float64
+	costed       bool
+}
+
+func (a *accumulator) add(result domain.CaseResult) {
+	a.observations++
+	a.runs += len(result.Runs)
+	a.passRateSum += result.Aggregates.PassRate
+	a.refusalSum += result.Aggregates.RefusalRate
+
+	for _, run := range result.Runs {
+		a.latencies = append(a.latencies, run.Metrics.LatencyMS)
+		if run.Metrics.CostUSD > 0 {
+			a.costSum += run.Metrics.CostUSD
+			a.costed = true
+		}
+	}
+	if len(result.Runs) == 0 {
+		a.latencies = append(a.latencies, result.Aggregates.LatencyP95MS)
+	}
+}
+
+func (a *accumulator) cell() *Cell {
+	cell := &Cell{
+		Observations: a.observations,
+		Runs:         a.runs,
+		PassRate:     a.passRateSum / float64(a.observations),
+		LatencyP95MS: p95(a.latencies),
+		RefusalRate:  a.refusalSum / float64(a.observations),
+	}
+	if a.costed && a.runs > 0 {
+		cost := a.costSum / float64(a.runs)
+		cell.CostUSD = &cost
+	}
+	return cell
+}
+
+type cellKey struct {
+	caseID string
+	model  Model
+}
+
+// Builder builds a matrix from test runs added one at a time
+type Builder struct {
+	testRuns int
+	cells    map[cellKey]*accumulator
+	models   map[Model]*accumulator // every result of the model
+	cases    map[string]bool
+}
+
+func NewBuilder() *Builder {
+	return &Builder{
+		cells:  make(map[cellKey]*accumulator),
+		models: make(map[Model]*accumulator),
+		cases:  make(map[string]bool),
+	}
+}
+
+// Add adds a test run's results to the matrix
+func (b *Builder) Add(run *domain.TestRun) {
+	b.testRuns++
+	for _, result := range run.Results {
+		model := Model{Provider: result.Provider, Model: result.Model}
+		key := cellKey{caseID: result.CaseID, model: model}
+		if b.cells[key] == nil {
+			b.cells[key] = &accumulator{}
+		}
+		if b.models[model] == nil {
+			b.models[model] = &accumulator{}
+		}
+		b.cells[key].add(result)
+		b.models[model].add(result)
+		b.cases[result.CaseID] = true
+	}
+}
+
+// Matrix returns the matrix of the test runs added so far, with cases and
+// models in alphabetical order
+func (b *Builder) Matrix() *Matrix {
+	matrix := &Matrix{
+		TestRuns: b.testRuns,
+		Models:   make([]Model, 0, len(b.models)),
+		Cases:    make([]Row, 0, len(b.cases)),
+		Summary:  make([]Summary, 0, len(b.models)),
+	}
+	for model := range b.models {
+		matrix.Models = append(matrix.Models, model)
+	}
+	sort.Slice(matrix.Models, func(i, j int) bool {
+		if matrix.Models[i].Provider != matrix.Models[j].Provider {
+			return matrix.Models[i].Provider < matrix.Models[j].Provider
+		}
+		return matrix.Models[i].Model < matrix.Models[j].Model
+	})
+
+	caseIDs := make([]string, 0, len(b.cases))
+	for caseID := range b.cases {
+		caseIDs = append(caseIDs, caseID)
+	}
+	sort.Strings(caseIDs)
+
+	summaries := make([]Summary, len(matrix.Models))
+	passRateSums := make([]float64, len(matrix.Models))
+	refusalSums := make([]float64, len(matrix.Models))
+	for _, caseID := range caseIDs {
+		row := Row{CaseID: caseID, Cells: make([]*Cell, len(matrix.Models))}
+		for i, model := range matrix.Models {
+			acc := b.cells[cellKey{caseID: caseID, model: model}]
+			if acc == nil {
+				continue
+			}
+			row.Cells[i] = acc.cell()
+			summaries[i].Cases++
+			passRateSums[i] += row.Cells[i].PassRate
+			refusalSums[i] += row.Cells[i].RefusalRate
+		}
+		matrix.Cases = append(matrix.Cases, row)
+	}
+
+	for i, model := range matrix.Models {
+		acc := b.models[model]
+		summary := summaries[i]
+		summary.Model = model
+		summary.Runs = acc.runs
+		summary.PassRate = passRateSums[i] / float64(summary.Cases)
+		summary.RefusalRate = refusalSums[i] / float64(summary.Cases)
+		summary.LatencyP95MS = p95(acc.latencies)
+		if acc.costed && acc.runs > 0 {
+			cost, total := acc.costSum/float64(acc.runs), acc.costSum
+			summary.CostUSD, summary.TotalCostUSD = &cost, &total
+		}
+		matrix.Summary = append(matrix.Summary, summary)
+	}
+	return matrix
+}
+
+// WriteCSV writes the matrix with one row per case and a final summary row.
+// Each model has a pass rate, p95 latency, refusal rate, and cost column.
+// Cells where the case did not run on the model are empty, and case IDs and
+// model names that start like a spreadsheet formula are escaped.
+func WriteCSV(w io.Writer, matrix *Matrix) error {
+	cw := csv.NewWriter(w)
+
+	header := []string{"case_id"}
+	for _, model := range matrix.Models {
+		name := model.Provider + "/" + model.Model
+		header = append(header,
+			name+" pass_rate",
+			name+" latency_p95_ms",
+			name+" refusal_rate",
+			name+" cost_usd",
+		)
+	}
+	if err := cw.Write(csvsafe.Escape(header)); err != nil {
+		return err
+	}
+
+	for _, row := range matrix.Cases {
+		record := []string{row.CaseID}
+		for _, cell := range row.Cells {
+			if cell == nil {
+				record = append(record, "", "", "", "")
+				continue
+			}
+			record = append(record,
+				formatFloat(cell.PassRate),
+				strconv.Itoa(cell.LatencyP95MS),
+				formatFloat(cell.RefusalRate),
+				formatCost(cell.CostUSD),
+			)
+		}
+		if err := cw.Write(csvsafe.Escape(record)); err != nil {
+			return err
+		}
+	}
+
+	record := []string{summaryCaseID}
+	for _, summary := range matrix.Summary {
+		record = append(record,
+			formatFloat(summary.PassRate),
+			strconv.Itoa(summary.LatencyP95MS),
+			formatFloat(summary.RefusalRate),
+			formatCost(summary.CostUSD),
+		)
+	}
+	if err := cw.Write(record); err != nil {
+		return err
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', 4, 64)
+}
+
+func formatCost(cost *float64) string {
+	if cost == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*cost, 'f', 6, 64)
+}
+
+// p95 returns the nearest-rank 95th percentile of the latencies
+func p95(latencies []int) int {
+	if len(latencies) == 0 {
+		return 0
+	}
+	sorted := append([]int(nil), latencies...)
+	sort.Ints(sorted)
+	rank := int(math.Ceil(0.95*float64(len(sorted)))) - 1
+	return sorted[max(rank, 0)]
+}
@@ -0,0 +1,137 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package comparison
+
+import (
+	"sort"
+
+	"github.com/regrada-ai/regrada-be/internal/domain"
+	"github.com/regrada-ai/regrada-be/internal/stats"
+)
+
+// CaseDelta compares a case's results on one model between a test run and
+// a baseline run. Intervals and p-values need the individual runs of the
+// case and are left out where either run reports only aggregates.
+type CaseDelta struct {
+	CaseID   string `json:"case_id"`
+	Provider string `json:"provider,omitempty"`
+	Model    string `json:"model,omitempty"`
+
+	PassRate            float64         `json:"pass_rate"`
+	PassRateCI          *stats.Interval `json:"pass_rate_ci,omitempty"`
+	BaselinePassRate    float64         `json:"baseline_pass_rate"`
+	BaselinePassRateCI  *stats.Interval `json:"baseline_pass_rate_ci,omitempty"`
+	PassRateDelta       float64         `json:"pass_rate_delta"`
+	PassRatePValue      *float64        `json:"pass_rate_p_value,omitempty"` // Fisher's exact test
+	PassRateSignificant bool            `json:"pass_rate_significant"`
+
+	LatencyP95MS         int      `json:"latency_p95_ms"`
+	BaselineLatencyP95MS int      `json:"baseline_latency_p95_ms"`
+	LatencyPValue        *float64 `json:"latency_p_value,omitempty"` // Mann-Whitney U test
+	LatencySignificant   bool     `json:"latency_significant"`
+}
+
+// RunComparison compares the cases two test runs have in common
+type RunComparison struct {
+	RunID         string      `json:"run_id"`
+	BaselineRunID string      `json:"baseline_run_id"`
+	Alpha         float64     `json:"alpha"`
+	Cases         []CaseDelta `json:"cases"`
+	// Significant counts the cases with a significant pass rate or latency change
+	Significant int `json:"significant"`
+}
+
+// CompareRuns compares the cases of run against the same cases of baseline,
+// testing each change at significance level alpha
+func CompareRuns(run, baseline *domain.TestRun, alpha float64) *RunComparison {
+	baselineResults := make(map[cellKey]domain.CaseResult, len(baseline.Results))
+	for _, result := range baseline.Results {
+		baselineResults[resultKey(result)] = result
+	}
+
+	comparison := &RunComparison{
+		RunID:         run.RunID,
+		BaselineRunID: baseline.RunID,
+		Alpha:         alpha,
+		Cases:         []CaseDelta{},
+	}
+	for _, result := range run.Results {
+		before, ok := baselineResults[resultKey(result)]
+		if !ok {
+			continue
+		}
+
+		delta := CompareCase(result, before, alpha)
+		if delta.PassRateSignificant || delta.LatencySignificant {
+			comparison.Significant++
+		}
+		comparison.Cases = append(comparison.Cases, delta)
+	}
+
+	sort.SliceStable(comparison.Cases, func(i, j int) bool {
+		return comparison.Cases[i].PassRateDelta < comparison.Cases[j].PassRateDelta
+	})
+	return comparison
+}
+
+// CompareCase compares a case result against its baseline result
+func CompareCase(result, baseline domain.CaseResult, alpha float64) CaseDelta {
+	delta := CaseDelta{
+		CaseID:               result.CaseID,
+		Provider:             result.Provider,
+		Model:                result.Model,
+		PassRate:             result.Aggregates.PassRate,
+		BaselinePassRate:     baseline.Aggregates.PassRate,
+		LatencyP95MS:         result.Aggregates.LatencyP95MS,
+		BaselineLatencyP95MS: baseline.Aggregates.LatencyP95MS,
+	}
+
+	if len(result.Runs) > 0 && len(baseline.Runs) > 0 {
+		passes, fails, latencies := runSample(result.Runs)
+		baselinePasses, baselineFails, baselineLatencies := runSample(baseline.Runs)
+
+		delta.PassRate = float64(passes) / float64(len(result.Runs))
+		delta.BaselinePassRate = float64(baselinePasses) / float64(len(baseline.Runs))
+		ci := stats.Wilson(passes, len(result.Runs), alpha)
+		baselineCI := stats.Wilson(baselinePasses, len(baseline.Runs), alpha)
+		delta.PassRateCI, delta.BaselinePassRateCI = &ci, &baselineCI
+
+		passP := stats.FisherExact(passes, fails, baselinePasses, baselineFails)
+		delta.PassRatePValue = &passP
+		delta.PassRateSignificant = passP < alpha
+
+		delta.LatencyP95MS = p95(intLatencies(latencies))
+		delta.BaselineLatencyP95MS = p95(intLatencies(baselineLatencies))
+		latencyP := stats.MannWhitney(latencies, baselineLatencies)
+		delta.LatencyPValue = &latencyP
+		delta.LatencySignificant = latencyP < alpha
+	}
+
+	delta.PassRateDelta = delta.PassRate - delta.BaselinePassRate
+	return delta
+}
+
+// runSample returns the pass and fail counts and latencies of a case's runs
+func runSample(runs []domain.RunResult) (int, int, []float64) {
+	passes := 0
+	latencies := make([]float64, len(runs))
+	for i, run := range runs {
+		if run.Pass {
+			passes++
+		}
+		latencies[i] = float64(run.Metrics.LatencyMS)
+	}
+	return passes, len(runs) - passes, latencies
+}
+
+func intLatencies(latencies []float64) []int {
+	ints := make([]int, len(latencies))
+	for i, latency := range latencies {
+		ints[i] = int(latency)
+	}
+	return ints
+}
+
+func resultKey(result domain.CaseResult) cellKey {
+	return cellKey{caseID: result.CaseID, model: Model{Provider: result.Provider, Model: result.Model}}
+}
@@ -0,0 +1,23 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+// Package csvsafe neutralizes CSV cells that spreadsheets would evaluate as
+// formulas.
+package csvsafe
+
+import "strings"
+
+// formulaPrefixes are the leading characters that make Excel, Sheets, and
+// LibreOffice treat a cell as a formula
+const formulaPrefixes = "=+-@\t\r"
+
+// Escape prefixes each cell of record that starts like a formula with a
+// single quote, so it is shown as text. The record is modified in place and
+// returned.
+func Escape(record []string) []string {
+	for i, cell := range record {
+		if cell != "" && strings.IndexByte(formulaPrefixes, cell[0]) >= 0 {
+			record[i] = "'" + cell
+		}
+	}
+	return record
+}
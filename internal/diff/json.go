@@ -0,0 +1,170 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package diff
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// maxJSONChanges caps the changes reported by a JSON diff
+const maxJSONChanges = 1000
+
+// JSON change kinds
+const (
+	ChangeAdded   = "added"
+	ChangeRemoved = "removed"
+	ChangeChanged = "changed"
+)
+
+// identifierPattern matches object keys written as .key in a path
+var identifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// JSONChange is a value added, removed, or changed at a path such as
+// $.items[0].name
+type JSONChange struct {
+	Path   string `json:"path"`
+	Kind   string `json:"kind"`
+	Before any    `json:"before,omitempty"`
+	After  any    `json:"after,omitempty"`
+}
+
+// JSONDiff is a structural diff of two JSON documents
+type JSONDiff struct {
+	Identical bool         `json:"identical"`
+	Changes   []JSONChange `json:"changes"`
+	// Truncated is set if there were more changes than reported
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// JSON diffs two JSON documents. Objects are compared key by key and arrays
+// index by index; a value whose type changes is reported as one change. An
+// empty document counts as absent.
+func JSON(before, after json.RawMessage) (*JSONDiff, error) {
+	a, err := decode(before)
+	if err != nil {
+		return nil, fmt.Errorf("before: %w", err)
+	}
+	b, err := decode(after)
+	if err != nil {
+		return nil, fmt.Errorf("after: %w", err)
+	}
+
+	diff := &JSONDiff{Changes: []JSONChange{}}
+	diff.compare("$", a, b)
+	diff.Identical = len(diff.Changes) == 0 && !diff.Truncated
+	return diff, nil
+}
+
+// absent stands for a missing document
+type absent struct{}
+
+func decode(raw json.RawMessage) (any, error) {
+	if len(bytes.TrimSpace(raw)) == 0 {
+		return absent{}, nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+func (d *JSONDiff) compare(path string, a, b any) {
+	switch a := a.(type) {
+	case map[string]any:
+		if b, ok := b.(map[string]any); ok {
+			d.compareObjects(path, a, b)
+			return
+		}
+	case []any:
+		if b, ok := b.([]any); ok {
+			for i := 0; i < max(len(a), len(b)); i++ {
+				elementPath := path + "[" + strconv.Itoa(i) + "]"
+				switch {
+				case i >= len(a):
+					d.record(JSONChange{Path: elementPath, Kind: ChangeAdded, After: b[i]})
+				case i >= len(b):
+					d.record(JSONChange{Path: elementPath, Kind: ChangeRemoved, Before: a[i]})
+				default:
+					d.compare(elementPath, a[i], b[i])
+				}
+			}
+			return
+		}
+	case json.Number:
+		// Compare numbers by value, so 1.0 equals 1
+		if b, ok := b.(json.Number); ok {
+			fa, errA := a.Float64()
+			fb, errB := b.Float64()
+			if a == b || (errA == nil && errB == nil && fa == fb) {
+				return
+			}
+		}
+	case absent:
+		if _, ok := b.(absent); !ok {
+			d.record(JSONChange{Path: path, Kind: ChangeAdded, After: b})
+		}
+		return
+	default:
+		if a == b {
+			return
+		}
+	}
+
+	if _, ok := b.(absent); ok {
+		d.record(JSONChange{Path: path, Kind: ChangeRemoved, Before: a})
+		return
+	}
+	d.record(JSONChange{Path: path, Kind: ChangeChanged, Before: a, After: b})
+}
+
+func (d *JSONDiff) compareObjects(path string, a, b map[string]any) {
+	keys := make([]string, 0, len(a)+len(b))
+	for key := range a {
+		keys = append(keys, key)
+	}
+	for key := range b {
+		if _, ok := a[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		keyPath := objectPath(path, key)
+		before, inA := a[key]
+		after, inB := b[key]
+		switch {
+		case !inA:
+			d.record(JSONChange{Path: keyPath, Kind: ChangeAdded, After: after})
+		case !inB:
+			d.record(JSONChange{Path: keyPath, Kind: ChangeRemoved, Before: before})
+		default:
+			d.compare(keyPath, before, after)
+		}
+	}
+}
+
+func (d *JSONDiff) record(change JSONChange) {
+	if len(d.Changes) >= maxJSONChanges {
+		d.Truncated = true
+		return
+	}
+	d.Changes = append(d.Changes, change)
+}
+
+func objectPath(path, key string) string {
+	if identifierPattern.MatchString(key) {
+		return path + "." + key
+	}
+	quoted, _ := json.Marshal(key)
+	return path + "[" + string(quoted) + "]"
+}
@@ -0,0 +1,87 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package diff
+
+import (
+	"encoding/json"
+	"html"
+	"strings"
+)
+
+// Output is the diff of two model outputs
+type Output struct {
+	Text *TextDiff `json:"text,omitempty"`
+	JSON *JSONDiff `json:"json,omitempty"`
+	// JSONError explains why the JSON outputs could not be compared
+	JSONError string `json:"json_error,omitempty"`
+	HTML      string `json:"html"`
+}
+
+// Outputs diffs the text and JSON of two outputs and renders the result as
+// an HTML fragment. The JSON is left out if neither output has any.
+func Outputs(beforeText, afterText string, beforeJSON, afterJSON json.RawMessage) *Output {
+	output := &Output{Text: Text(beforeText, afterText)}
+	if len(beforeJSON) > 0 || len(afterJSON) > 0 {
+		jsonDiff, err := JSON(beforeJSON, afterJSON)
+		if err != nil {
+			output.JSONError = err.Error()
+		} else {
+			output.JSON = jsonDiff
+		}
+	}
+	output.HTML = RenderHTML(output)
+	return output
+}
+
+// RenderHTML renders a diff as an HTML fragment: the text with <del> and
+// <ins> marks, and a table of JSON changes. All output text is escaped.
+func RenderHTML(output *Output) string {
+	var b strings.Builder
+	b.WriteString(`<div class="regrada-diff">`)
+
+	if output.Text != nil {
+		b.WriteString(`<pre class="regrada-diff-text">`)
+		for _, op := range output.Text.Ops {
+			text := html.EscapeString(op.Text)
+			switch op.Op {
+			case OpInsert:
+				b.WriteString("<ins>" + text + "</ins>")
+			case OpDelete:
+				b.WriteString("<del>" + text + "</del>")
+			default:
+				b.WriteString(text)
+			}
+		}
+		b.WriteString(`</pre>`)
+	}
+
+	if output.JSON != nil && len(output.JSON.Changes) > 0 {
+		b.WriteString(`<table class="regrada-diff-json"><thead><tr><th>Path</th><th>Change</th><th>Before</th><th>After</th></tr></thead><tbody>`)
+		for _, change := range output.JSON.Changes {
+			b.WriteString(`<tr class="` + change.Kind + `"><td><code>` + html.EscapeString(change.Path) + `</code></td><td>` + change.Kind + `</td><td>`)
+			if change.Kind != ChangeAdded {
+				b.WriteString(`<code>` + html.EscapeString(renderValue(change.Before)) + `</code>`)
+			}
+			b.WriteString(`</td><td>`)
+			if change.Kind != ChangeRemoved {
+				b.WriteString(`<code>` + html.EscapeString(renderValue(change.After)) + `</code>`)
+			}
+			b.WriteString(`</td></tr>`)
+		}
+		b.WriteString(`</tbody></table>`)
+	}
+	if output.JSONError != "" {
+		b.WriteString(`<p class="regrada-diff-error">` + html.EscapeString(output.JSONError) + `</p>`)
+	}
+
+	b.WriteString(`</div>`)
+	return b.String()
+}
+
+func renderValue(value any) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
@@ -0,0 +1,150 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+// Package diff compares model outputs: text word by word and JSON path by
+// path.
+package diff
+
+import (
+	"strings"
+	"unicode"
+)
+
+// maxLCSCells caps the table the word diff fills; larger changed regions are
+// reported as one deletion and one insertion
+const maxLCSCells = 4_000_000
+
+// Text diff operations
+const (
+	OpEqual  = "equal"
+	OpInsert = "insert"
+	OpDelete = "delete"
+)
+
+// TextOp is a run of text kept, inserted, or deleted
+type TextOp struct {
+	Op   string `json:"op"`
+	Text string `json:"text"`
+}
+
+// TextDiff is a word-level diff of two texts
+type TextDiff struct {
+	Identical bool     `json:"identical"`
+	Ops       []TextOp `json:"ops"`
+	// Insertions and Deletions count the words added and removed
+	Insertions int `json:"insertions"`
+	Deletions  int `json:"deletions"`
+}
+
+// Text diffs before and after word by word. Whitespace runs are tokens of
+// their own, so the ops concatenate back to either text.
+func Text(before, after string) *TextDiff {
+	a, b := tokenize(before), tokenize(after)
+	diff := &TextDiff{Identical: before == after, Ops: []TextOp{}}
+
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	diff.add(OpEqual, a[:prefix])
+	middleA, middleB := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	if len(middleA)*len(middleB) > maxLCSCells {
+		diff.add(OpDelete, middleA)
+		diff.add(OpInsert, middleB)
+	} else {
+		lcs(diff, middleA, middleB)
+	}
+	diff.add(OpEqual, a[len(a)-suffix:])
+	return diff
+}
+
+// lcs adds the ops turning a into b along a longest common subsequence
+func lcs(diff *TextDiff, a, b []string) {
+	n, m := len(a), len(b)
+	// lengths[i][j] is the LCS length of a[i:] and b[j:]
+	lengths := make([][]int32, n+1)
+	for i := range lengths {
+		lengths[i] = make([]int32, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			} else {
+				lengths[i][j] = max(lengths[i+1][j], lengths[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case a[i] == b[j]:
+			diff.add(OpEqual, a[i:i+1])
+			i++
+			j++
+		case lengths[i+1][j] >= lengths[i][j+1]:
+			diff.add(OpDelete, a[i:i+1])
+			i++
+		default:
+			diff.add(OpInsert, b[j:j+1])
+			j++
+		}
+	}
+	diff.add(OpDelete, a[i:])
+	diff.add(OpInsert, b[j:])
+}
+
+// add appends tokens as an op, merging it into the last op if they match
+func (d *TextDiff) add(op string, tokens []string) {
+	if len(tokens) == 0 {
+		return
+	}
+
+	words := 0
+	for _, token := range tokens {
+		if !isSpace(token) {
+			words++
+		}
+	}
+	switch op {
+	case OpInsert:
+		d.Insertions += words
+	case OpDelete:
+		d.Deletions += words
+	}
+
+	text := strings.Join(tokens, "")
+	if last := len(d.Ops) - 1; last >= 0 && d.Ops[last].Op == op {
+		d.Ops[last].Text += text
+		return
+	}
+	d.Ops = append(d.Ops, TextOp{Op: op, Text: text})
+}
+
+// tokenize splits text into alternating words and whitespace runs
+func tokenize(text string) []string {
+	var tokens []string
+	start := 0
+	for i, r := range text {
+		if i > start && unicode.IsSpace(r) != isSpace(text[start:i]) {
+			tokens = append(tokens, text[start:i])
+			start = i
+		}
+	}
+	if start < len(text) {
+		tokens = append(tokens, text[start:])
+	}
+	return tokens
+}
+
+func isSpace(token string) bool {
+	for _, r := range token {
+		return unicode.IsSpace(r)
+	}
+	return false
+}
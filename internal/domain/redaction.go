@@ -0,0 +1,41 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package domain
+
+import "time"
+
+// Redaction modes
+const (
+	RedactionModeMask = "mask" // replace matches with a [REDACTED_<RULE>] placeholder
+	RedactionModeHash = "hash" // replace matches with a truncated HMAC-SHA256 of the value, keyed per project
+	RedactionModeDrop = "drop" // remove matches entirely
+)
+
+// Built-in redaction detectors
+const (
+	RedactionDetectorEmail      = "email"
+	RedactionDetectorPhone      = "phone"
+	RedactionDetectorCreditCard = "credit_card"
+	RedactionDetectorSSN        = "ssn"
+	RedactionDetectorIP         = "ip"
+	RedactionDetectorSecret     = "secret"
+)
+
+// RedactionPolicy is a project's server-side PII redaction configuration
+type RedactionPolicy struct {
+	Enabled     bool                  `json:"enabled"`
+	Mode        string                `json:"mode"`
+	Detectors   []string              `json:"detectors"`
+	CustomRules []CustomRedactionRule `json:"custom_rules,omitempty"`
+	UpdatedAt   time.Time             `json:"updated_at,omitempty"`
+	// HashKey is the project's secret key for hash mode. It is generated
+	// when the policy is first stored and never returned by the API.
+	HashKey []byte `json:"-"`
+}
+
+// CustomRedactionRule is a user-defined regular expression to redact
+type CustomRedactionRule struct {
+	Name    string `json:"name"`
+	Pattern string `json:"pattern"`
+	Mode    string `json:"mode,omitempty"` // defaults to the policy mode
+}
@@ -30,11 +30,13 @@ type TestRun struct {
 
 // CaseResult represents the result of running a single test case
 type CaseResult struct {
-	CaseID     string      `json:"case_id"`
-	Provider   string      `json:"provider"`
-	Model      string      `json:"model"`
-	Runs       []RunResult `json:"runs"`
-	Aggregates Aggregates  `json:"aggregates"`
+	CaseID        string      `json:"case_id"`
+	Provider      string      `json:"provider"`
+	Model         string      `json:"model"`
+	PromptName    string      `json:"prompt_name,omitempty"`    // registry prompt the case ran
+	PromptVersion int         `json:"prompt_version,omitempty"` // version of PromptName
+	Runs          []RunResult `json:"runs"`
+	Aggregates    Aggregates  `json:"aggregates"`
 }
 
 // RunResult represents a single run of a test case
@@ -49,9 +51,10 @@ type RunResult struct {
 
 // RunMetrics contains metrics for a single test run
 type RunMetrics struct {
-	LatencyMS int  `json:"latency_ms"`
-	Refused   bool `json:"refused"`
-	JSONValid bool `json:"json_valid"`
+	LatencyMS int     `json:"latency_ms"`
+	Refused   bool    `json:"refused"`
+	JSONValid bool    `json:"json_valid"`
+	CostUSD   float64 `json:"cost_usd,omitempty"` // cost of the model call, if the CLI knows it
 }
 
 // Aggregates contains aggregated metrics across multiple runs
@@ -16,6 +16,8 @@ type Trace struct {
 	Environment      string        `json:"environment,omitempty"`
 	GitSHA           string        `json:"git_sha,omitempty"`
 	GitBranch        string        `json:"git_branch,omitempty"`
+	PromptName       string        `json:"prompt_name,omitempty"`    // registry prompt the request was built from
+	PromptVersion    int           `json:"prompt_version,omitempty"` // version of PromptName
 	Request          TraceRequest  `json:"request"`
 	Response         TraceResponse `json:"response"`
 	Metrics          TraceMetrics  `json:"metrics"`
@@ -3,6 +3,7 @@ package email
 import (
 	"context"
 	"fmt"
+	"html"
 	"log"
 	"time"
 
@@ -338,3 +339,86 @@ CI for AI behavior — catch regressions before they ship.
 	_, err := s.sesv2Client.SendEmail(ctx, input)
 	return err
 }
+
+// SendExportReadyEmail tells the requester that a data export can be downloaded
+func (s *Service) SendExportReadyEmail(ctx context.Context, toEmail, projectName, resource string, rowCount int64, downloadURL string, linkExpiresAt time.Time) error {
+	expires := linkExpiresAt.UTC().Format("January 2, 2006 15:04 MST")
+
+	htmlBody := fmt.Sprintf(`
+<html>
+  <body style="font-family: ui-monospace, SFMono-Regular, Menlo, Monaco, Consolas, 'Liberation Mono', 'Courier New', monospace; background-color: #1D1F21; color: #C5C8C6; padding: 20px; margin: 0;">
+    <h2 style="color: #81A2BE;">Your %s export is ready</h2>
+    <p>The export of <strong>%d</strong> %s from <strong>%s</strong> has finished.</p>
+    <p style="margin: 30px 0;">
+      <a href="%s" style="background-color: #81A2BE; color: #1D1F21; padding: 12px 24px; text-decoration: none; font-weight: bold;">Download Export</a>
+    </p>
+    <p style="color: #969896;">This link expires on %s. A new link can be requested from the exports API.</p>
+    <p style="color: #969896;">- The Regrada Team</p>
+  </body>
+</html>`, resource, rowCount, resource, projectName, downloadURL, expires)
+
+	textBody := fmt.Sprintf(`Your %s export is ready
+
+The export of %d %s from %s has finished.
+
+Download it here: %s
+
+This link expires on %s. A new link can be requested from the exports API.
+
+- The Regrada Team`, resource, rowCount, resource, projectName, downloadURL, expires)
+
+	return s.sendNotification(ctx, toEmail, fmt.Sprintf("Your %s export from %s is ready", resource, projectName), textBody, htmlBody)
+}
+
+// SendExportFailedEmail tells the requester that a data export could not be completed
+func (s *Service) SendExportFailedEmail(ctx context.Context, toEmail, projectName, resource, reason string) error {
+	htmlBody := fmt.Sprintf(`
+<html>
+  <body style="font-family: ui-monospace, SFMono-Regular, Menlo, Monaco, Consolas, 'Liberation Mono', 'Courier New', monospace; background-color: #1D1F21; color: #C5C8C6; padding: 20px; margin: 0;">
+    <h2 style="color: #CC6666;">Your %s export failed</h2>
+    <p>The export of %s from <strong>%s</strong> could not be completed:</p>
+    <p style="color: #969896;">%s</p>
+    <p style="color: #969896; margin-top: 30px;">- The Regrada Team</p>
+  </body>
+</html>`, resource, resource, projectName, html.EscapeString(reason))
+
+	textBody := fmt.Sprintf(`Your %s export failed
+
+The export of %s from %s could not be completed:
+
+%s
+
+- The Regrada Team`, resource, resource, projectName, reason)
+
+	return s.sendNotification(ctx, toEmail, fmt.Sprintf("Your %s export from %s failed", resource, projectName), textBody, htmlBody)
+}
+
+func (s *Service) sendNotification(ctx context.Context, toEmail, subject, textBody, htmlBody string) error {
+	input := &sesv2.SendEmailInput{
+		FromEmailAddress: aws.String(fmt.Sprintf("%s <%s>", s.fromName, s.fromEmail)),
+		Destination: &sesv2types.Destination{
+			ToAddresses: []string{toEmail},
+		},
+		Content: &sesv2types.EmailContent{
+			Simple: &sesv2types.Message{
+				Subject: &sesv2types.Content{
+					Data:    aws.String(subject),
+					Charset: aws.String("UTF-8"),
+				},
+				Body: &sesv2types.Body{
+					Text: &sesv2types.Content{
+						Data:    aws.String(textBody),
+						Charset: aws.String("UTF-8"),
+					},
+					Html: &sesv2types.Content{
+						Data:    aws.String(htmlBody),
+						Charset: aws.String("UTF-8"),
+					},
+				},
+			},
+		},
+	}
+
+	_, err := s.sesv2Client.SendEmail(ctx, input)
+	return err
+}
@@ -0,0 +1,290 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+// Package evaluation runs project evaluators on ingested traces.
+package evaluation
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+
+	"github.com/regrada-ai/regrada-be/internal/domain"
+	"github.com/regrada-ai/regrada-be/internal/storage"
+)
+
+const maxPatternLength = 1000
+
+// defaultRefusalPhrases are matched case-insensitively when a refusal
+// evaluator lists no phrases of its own
+var defaultRefusalPhrases = []string{
+	"i'm sorry, but i can't",
+	"i'm sorry, but i cannot",
+	"i can't help with",
+	"i cannot help with",
+	"i can't assist with",
+	"i cannot assist with",
+	"i'm unable to help",
+	"i am unable to help",
+	"i'm not able to help",
+	"as an ai language model",
+}
+
+// Check evaluates a trace. It returns whether the trace passed, and if not,
+// why.
+type Check func(trace *domain.Trace) (bool, string)
+
+type regexConfig struct {
+	Pattern         string `json:"pattern"`
+	CaseInsensitive bool   `json:"case_insensitive"`
+}
+
+type jsonSchemaConfig struct {
+	Schema json.RawMessage `json:"schema"`
+}
+
+type maxLatencyConfig struct {
+	MaxMS int `json:"max_ms"`
+}
+
+type maxTokensConfig struct {
+	MaxTokensOut   int `json:"max_tokens_out"`
+	MaxTokensTotal int `json:"max_tokens_total"`
+}
+
+type refusalConfig struct {
+	Phrases []string `json:"phrases"`
+}
+
+type toolArgsSchemaConfig struct {
+	ToolName string          `json:"tool_name"`
+	Schema   json.RawMessage `json:"schema"`
+}
+
+// Compile validates an evaluator's config and returns its check
+func Compile(evaluatorType storage.EvaluatorType, config json.RawMessage) (Check, error) {
+	switch evaluatorType {
+	case storage.EvaluatorRegexMatch, storage.EvaluatorRegexAbsent:
+		var cfg regexConfig
+		if err := decodeConfig(config, &cfg); err != nil {
+			return nil, err
+		}
+		re, err := compilePattern(cfg)
+		if err != nil {
+			return nil, err
+		}
+		if evaluatorType == storage.EvaluatorRegexMatch {
+			return func(trace *domain.Trace) (bool, string) {
+				if re.MatchString(trace.Response.AssistantText) {
+					return true, ""
+				}
+				return false, "Response does not match the pattern"
+			}, nil
+		}
+		return func(trace *domain.Trace) (bool, string) {
+			if match := re.FindString(trace.Response.AssistantText); match != "" {
+				return false, fmt.Sprintf("Response contains %q", truncate(match))
+			}
+			return true, ""
+		}, nil
+
+	case storage.EvaluatorJSONSchema:
+		var cfg jsonSchemaConfig
+		if err := decodeConfig(config, &cfg); err != nil {
+			return nil, err
+		}
+		schema, err := compileSchema(cfg.Schema)
+		if err != nil {
+			return nil, err
+		}
+		return func(trace *domain.Trace) (bool, string) {
+			value, err := jsonschema.UnmarshalJSON(strings.NewReader(trace.Response.AssistantText))
+			if err != nil {
+				return false, "Response is not valid JSON"
+			}
+			if err := schema.Validate(value); err != nil {
+				return false, fmt.Sprintf("Response does not match the schema: %s", schemaError(err))
+			}
+			return true, ""
+		}, nil
+
+	case storage.EvaluatorMaxLatency:
+		var cfg maxLatencyConfig
+		if err := decodeConfig(config, &cfg); err != nil {
+			return nil, err
+		}
+		if cfg.MaxMS <= 0 {
+			return nil, errors.New("max_ms must be positive")
+		}
+		return func(trace *domain.Trace) (bool, string) {
+			if trace.Metrics.LatencyMS > cfg.MaxMS {
+				return false, fmt.Sprintf("Latency %dms exceeds %dms", trace.Metrics.LatencyMS, cfg.MaxMS)
+			}
+			return true, ""
+		}, nil
+
+	case storage.EvaluatorMaxTokens:
+		var cfg maxTokensConfig
+		if err := decodeConfig(config, &cfg); err != nil {
+			return nil, err
+		}
+		if cfg.MaxTokensOut < 0 || cfg.MaxTokensTotal < 0 || cfg.MaxTokensOut+cfg.MaxTokensTotal == 0 {
+			return nil, errors.New("max_tokens_out or max_tokens_total must be positive")
+		}
+		return func(trace *domain.Trace) (bool, string) {
+			out := trace.Metrics.TokensOut
+			total := trace.Metrics.TokensIn + out
+			if cfg.MaxTokensOut > 0 && out > cfg.MaxTokensOut {
+				return false, fmt.Sprintf("%d output tokens exceed %d", out, cfg.MaxTokensOut)
+			}
+			if cfg.MaxTokensTotal > 0 && total > cfg.MaxTokensTotal {
+				return false, fmt.Sprintf("%d total tokens exceed %d", total, cfg.MaxTokensTotal)
+			}
+			return true, ""
+		}, nil
+
+	case storage.EvaluatorRefusal:
+		var cfg refusalConfig
+		if err := decodeConfig(config, &cfg); err != nil {
+			return nil, err
+		}
+		phrases := defaultRefusalPhrases
+		if len(cfg.Phrases) > 0 {
+			phrases = make([]string, 0, len(cfg.Phrases))
+			for _, phrase := range cfg.Phrases {
+				if phrase = strings.TrimSpace(phrase); phrase == "" {
+					return nil, errors.New("phrases must not be empty")
+				}
+				phrases = append(phrases, strings.ToLower(phrase))
+			}
+		}
+		return func(trace *domain.Trace) (bool, string) {
+			text := strings.ToLower(trace.Response.AssistantText)
+			for _, phrase := range phrases {
+				if strings.Contains(text, phrase) {
+					return false, fmt.Sprintf("Response contains the refusal phrase %q", phrase)
+				}
+			}
+			return true, ""
+		}, nil
+
+	case storage.EvaluatorToolArgsSchema:
+		var cfg toolArgsSchemaConfig
+		if err := decodeConfig(config, &cfg); err != nil {
+			return nil, err
+		}
+		if cfg.ToolName == "" {
+			return nil, errors.New("tool_name is required")
+		}
+		schema, err := compileSchema(cfg.Schema)
+		if err != nil {
+			return nil, err
+		}
+		return func(trace *domain.Trace) (bool, string) {
+			for _, call := range trace.Response.ToolCalls {
+				if call.Name != cfg.ToolName {
+					continue
+				}
+				value, err := toolArguments(call.Arguments)
+				if err != nil {
+					return false, fmt.Sprintf("Arguments of %s call %s are not valid JSON", cfg.ToolName, call.ID)
+				}
+				if err := schema.Validate(value); err != nil {
+					return false, fmt.Sprintf("Arguments of %s call %s do not match the schema: %s", cfg.ToolName, call.ID, schemaError(err))
+				}
+			}
+			return true, ""
+		}, nil
+	}
+
+	return nil, fmt.Errorf("unknown evaluator type %q", evaluatorType)
+}
+
+// decodeConfig decodes an evaluator config, rejecting unknown fields so a
+// misspelled setting is not silently ignored
+func decodeConfig(config json.RawMessage, v any) error {
+	if len(config) == 0 {
+		config = []byte("{}")
+	}
+	dec := json.NewDecoder(bytes.NewReader(config))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+	return nil
+}
+
+func compilePattern(cfg regexConfig) (*regexp.Regexp, error) {
+	if cfg.Pattern == "" {
+		return nil, errors.New("pattern is required")
+	}
+	if len(cfg.Pattern) > maxPatternLength {
+		return nil, fmt.Errorf("pattern must be at most %d characters", maxPatternLength)
+	}
+	pattern := cfg.Pattern
+	if cfg.CaseInsensitive {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern: %w", err)
+	}
+	return re, nil
+}
+
+// compileSchema compiles a JSON schema. Schemas cannot reference external
+// documents, so evaluators never read files or make requests.
+func compileSchema(raw json.RawMessage) (*jsonschema.Schema, error) {
+	if len(raw) == 0 {
+		return nil, errors.New("schema is required")
+	}
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+
+	compiler := jsonschema.NewCompiler()
+	compiler.UseLoader(jsonschema.SchemeURLLoader{})
+	if err := compiler.AddResource("evaluator.json", doc); err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	schema, err := compiler.Compile("evaluator.json")
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	return schema, nil
+}
+
+// toolArguments decodes tool call arguments, which providers send either as
+// a JSON object or as a string holding one
+func toolArguments(raw json.RawMessage) (any, error) {
+	var encoded string
+	if err := json.Unmarshal(raw, &encoded); err == nil {
+		raw = json.RawMessage(encoded)
+	}
+	return jsonschema.UnmarshalJSON(bytes.NewReader(raw))
+}
+
+// schemaError reduces a validation error to its first cause, e.g.
+// "at '/a': got string, want integer"
+func schemaError(err error) string {
+	lines := strings.Split(err.Error(), "\n")
+	msg := lines[0]
+	if len(lines) > 1 {
+		msg = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(lines[1]), "-"))
+	}
+	return truncate(msg)
+}
+
+func truncate(s string) string {
+	const max = 200
+	if len(s) <= max {
+		return s
+	}
+	// Cutting may split a character, and Postgres rejects invalid UTF-8
+	return strings.ToValidUTF8(s[:max], "") + "..."
+}
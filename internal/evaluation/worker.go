@@ -0,0 +1,156 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package evaluation
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/regrada-ai/regrada-be/internal/domain"
+	"github.com/regrada-ai/regrada-be/internal/storage"
+)
+
+// WorkerConfig configures the evaluation worker
+type WorkerConfig struct {
+	Interval  time.Duration // time between passes over projects with evaluators
+	BatchSize int           // traces evaluated per transaction
+}
+
+// DefaultWorkerConfig returns the default evaluation settings
+func DefaultWorkerConfig() WorkerConfig {
+	return WorkerConfig{
+		Interval:  10 * time.Second,
+		BatchSize: 500,
+	}
+}
+
+// compiledEvaluator caches an evaluator's check until the evaluator changes
+type compiledEvaluator struct {
+	updatedAt time.Time
+	check     Check
+}
+
+// Worker evaluates newly ingested and replaced traces with their project's
+// enabled evaluators, oldest first, and stores a result per trace and
+// evaluator. Each project is locked while it is evaluated, so every instance
+// may run a worker.
+type Worker struct {
+	evaluationRepo storage.EvaluationRepository
+	cfg            WorkerConfig
+
+	compiled map[string]compiledEvaluator
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewWorker(evaluationRepo storage.EvaluationRepository, cfg WorkerConfig) *Worker {
+	return &Worker{
+		evaluationRepo: evaluationRepo,
+		cfg:            cfg,
+		compiled:       make(map[string]compiledEvaluator),
+	}
+}
+
+// Start runs the worker every Interval until Stop is called
+func (w *Worker) Start(ctx context.Context) {
+	ctx, w.cancel = context.WithCancel(ctx)
+
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+
+		ticker := time.NewTicker(w.cfg.Interval)
+		defer ticker.Stop()
+
+		for {
+			if err := w.RunOnce(ctx); err != nil && ctx.Err() == nil {
+				log.Printf("Trace evaluation failed: %v", err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop cancels the worker and waits for it to exit
+func (w *Worker) Stop() {
+	if w.cancel == nil {
+		return
+	}
+	w.cancel()
+	w.wg.Wait()
+}
+
+// RunOnce evaluates every project's unevaluated traces
+func (w *Worker) RunOnce(ctx context.Context) error {
+	projectIDs, err := w.evaluationRepo.ListEvaluatingProjects(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, projectID := range projectIDs {
+		var total int
+		for {
+			n, err := w.evaluationRepo.EvaluateNext(ctx, projectID, w.cfg.BatchSize, w.evaluate)
+			total += n
+			if err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				log.Printf("Failed to evaluate traces of project %s: %v", projectID, err)
+				break
+			}
+			if n < w.cfg.BatchSize {
+				break
+			}
+		}
+		if total > 0 {
+			log.Printf("Evaluated %d traces of project %s", total, projectID)
+		}
+	}
+	return nil
+}
+
+// evaluate runs each evaluator on each trace. Evaluators whose config no
+// longer compiles are skipped.
+func (w *Worker) evaluate(evaluators []*storage.Evaluator, traces []*domain.Trace) []*storage.EvaluationResult {
+	results := make([]*storage.EvaluationResult, 0, len(evaluators)*len(traces))
+	for _, evaluator := range evaluators {
+		check := w.check(evaluator)
+		if check == nil {
+			continue
+		}
+		for _, trace := range traces {
+			passed, message := check(trace)
+			results = append(results, &storage.EvaluationResult{
+				TraceID:     trace.TraceID,
+				EvaluatorID: evaluator.ID,
+				Passed:      passed,
+				Message:     message,
+			})
+		}
+	}
+	return results
+}
+
+// check returns an evaluator's compiled check, compiling it if it is new or
+// has changed since it was last compiled
+func (w *Worker) check(evaluator *storage.Evaluator) Check {
+	if cached, ok := w.compiled[evaluator.ID]; ok && cached.updatedAt.Equal(evaluator.UpdatedAt) {
+		return cached.check
+	}
+
+	check, err := Compile(evaluator.Type, evaluator.Config)
+	if err != nil {
+		log.Printf("Skipping evaluator %s: %v", evaluator.ID, err)
+	}
+	w.compiled[evaluator.ID] = compiledEvaluator{updatedAt: evaluator.UpdatedAt, check: check}
+	return check
+}
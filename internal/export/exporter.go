@@ -0,0 +1,341 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+// Package export writes a project's traces or test runs to a downloadable
+// file in file storage in the background.
+package export
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/regrada-ai/regrada-be/internal/domain"
+	"github.com/regrada-ai/regrada-be/internal/email"
+	"github.com/regrada-ai/regrada-be/internal/storage"
+)
+
+const (
+	// staleAfter is how long a running export may go without progress before
+	// another instance takes it over
+	staleAfter = 10 * time.Minute
+
+	// heartbeatInterval is how often a running export saves its progress
+	heartbeatInterval = time.Minute
+
+	expiredBatchSize = 100
+)
+
+// Config configures the export worker
+type Config struct {
+	Poll      time.Duration // how often to look for exports requested on other instances
+	BatchSize int           // records read from Postgres per query
+	TTL       time.Duration // how long a finished export file is kept
+	URLExpiry time.Duration // lifetime of the download link sent by email
+}
+
+// DefaultConfig returns the default export settings
+func DefaultConfig() Config {
+	return Config{
+		Poll:      30 * time.Second,
+		BatchSize: 1000,
+		TTL:       7 * 24 * time.Hour,
+		URLExpiry: 24 * time.Hour,
+	}
+}
+
+// Exporter claims export jobs from the database, writes their files to file
+// storage, and emails the requester when they finish. Expired files are
+// deleted as the worker polls.
+type Exporter struct {
+	exportRepo   storage.ExportRepository
+	traceRepo    storage.TraceRepository
+	testRunRepo  storage.TestRunRepository
+	projectRepo  storage.ProjectRepository
+	files        storage.FileStorageService
+	emailService *email.Service
+	cfg          Config
+
+	wake   chan struct{}
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewExporter creates an export worker. emailService may be nil, in which
+// case no notifications are sent.
+func NewExporter(
+	exportRepo storage.ExportRepository,
+	traceRepo storage.TraceRepository,
+	testRunRepo storage.TestRunRepository,
+	projectRepo storage.ProjectRepository,
+	files storage.FileStorageService,
+	emailService *email.Service,
+	cfg Config,
+) *Exporter {
+	return &Exporter{
+		exportRepo:   exportRepo,
+		traceRepo:    traceRepo,
+		testRunRepo:  testRunRepo,
+		projectRepo:  projectRepo,
+		files:        files,
+		emailService: emailService,
+		cfg:          cfg,
+		wake:         make(chan struct{}, 1),
+	}
+}
+
+// Start processes exports until Stop is called
+func (e *Exporter) Start(ctx context.Context) {
+	ctx, e.cancel = context.WithCancel(ctx)
+
+	e.wg.Add(1)
+	go func() {
+		defer e.wg.Done()
+
+		ticker := time.NewTicker(e.cfg.Poll)
+		defer ticker.Stop()
+
+		for {
+			export, err := e.exportRepo.Claim(ctx, staleAfter)
+			if err != nil && ctx.Err() == nil {
+				log.Printf("Failed to claim export: %v", err)
+			}
+
+			if export != nil {
+				e.run(ctx, export)
+				continue
+			}
+
+			if err := e.deleteExpired(ctx); err != nil && ctx.Err() == nil {
+				log.Printf("Failed to delete expired exports: %v", err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-e.wake:
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop cancels the export worker and waits for it to exit. An interrupted
+// export is restarted by another instance once it goes stale.
+func (e *Exporter) Stop() {
+	if e.cancel == nil {
+		return
+	}
+	e.cancel()
+	e.wg.Wait()
+}
+
+// Request records an export and wakes the worker
+func (e *Exporter) Request(ctx context.Context, export *storage.Export) error {
+	if err := e.exportRepo.Create(ctx, export); err != nil {
+		return err
+	}
+
+	select {
+	case e.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// run writes an export to a temporary file, uploads it, and records the result
+func (e *Exporter) run(ctx context.Context, export *storage.Export) {
+	start := time.Now()
+	export.RowCount = 0
+
+	file, err := os.CreateTemp("", "export-*."+string(export.Format))
+	if err != nil {
+		e.finish(ctx, export, fmt.Errorf("failed to create export file: %w", err))
+		return
+	}
+	defer func() {
+		file.Close()
+		os.Remove(file.Name())
+	}()
+
+	if err := e.write(ctx, export, file); err != nil {
+		e.finish(ctx, export, err)
+		return
+	}
+
+	size, err := file.Seek(0, io.SeekCurrent)
+	if err == nil {
+		_, err = file.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		e.finish(ctx, export, err)
+		return
+	}
+
+	key := fmt.Sprintf("exports/%s/%s.%s", export.ProjectID, export.ID, export.Format)
+	if err := e.files.UploadFile(ctx, key, file, contentTypes[export.Format]); err != nil {
+		e.finish(ctx, export, fmt.Errorf("failed to upload export: %w", err))
+		return
+	}
+
+	export.StorageKey = key
+	export.SizeBytes = size
+	e.finish(ctx, export, nil)
+	log.Printf("✓ Export %s finished: %d %s (%d bytes) in %s", export.ID, export.RowCount, export.Resource, size, time.Since(start).Round(time.Millisecond))
+}
+
+// write streams the export's records into w, saving progress periodically so
+// the job is not taken over while it is still running
+func (e *Exporter) write(ctx context.Context, export *storage.Export, w io.Writer) error {
+	buf := bufio.NewWriterSize(w, 64<<10)
+	lastSaved := time.Now()
+	progress := func(n int) {
+		export.RowCount += int64(n)
+		if time.Since(lastSaved) < heartbeatInterval {
+			return
+		}
+		lastSaved = time.Now()
+		if err := e.exportRepo.Update(ctx, export); err != nil && ctx.Err() == nil {
+			log.Printf("Failed to save progress of export %s: %v", export.ID, err)
+		}
+	}
+
+	var err error
+	switch export.Resource {
+	case storage.ExportTraces:
+		err = e.writeTraces(ctx, export, buf, progress)
+	case storage.ExportTestRuns:
+		err = e.writeTestRuns(ctx, export, buf, progress)
+	default:
+		err = fmt.Errorf("unsupported export resource %q", export.Resource)
+	}
+	if err != nil {
+		return err
+	}
+	return buf.Flush()
+}
+
+func (e *Exporter) writeTraces(ctx context.Context, export *storage.Export, w io.Writer, progress func(int)) error {
+	enc, err := newTraceEncoder(w, export.Format)
+	if err != nil {
+		return err
+	}
+
+	var filter storage.TraceFilter
+	if export.TraceFilter != nil {
+		filter = *export.TraceFilter
+	}
+	err = e.traceRepo.Each(ctx, export.ProjectID, filter, e.cfg.BatchSize, func(batch []*domain.Trace) error {
+		if err := enc.write(batch); err != nil {
+			return err
+		}
+		progress(len(batch))
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return enc.close()
+}
+
+func (e *Exporter) writeTestRuns(ctx context.Context, export *storage.Export, w io.Writer, progress func(int)) error {
+	enc, err := newTestRunEncoder(w, export.Format)
+	if err != nil {
+		return err
+	}
+
+	var filter storage.TestRunFilter
+	if export.TestRunFilter != nil {
+		filter = *export.TestRunFilter
+	}
+	err = e.testRunRepo.Each(ctx, export.ProjectID, filter, e.cfg.BatchSize, func(batch []*domain.TestRun) error {
+		if err := enc.write(batch); err != nil {
+			return err
+		}
+		progress(len(batch))
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return enc.close()
+}
+
+// finish records an export's outcome and notifies the requester. An export
+// interrupted by shutdown is left running so another instance restarts it.
+func (e *Exporter) finish(ctx context.Context, export *storage.Export, err error) {
+	if ctx.Err() != nil {
+		return
+	}
+
+	now := time.Now()
+	export.CompletedAt = &now
+	if err != nil {
+		log.Printf("Export %s failed: %v", export.ID, err)
+		export.Status = storage.ExportFailed
+		export.Error = err.Error()
+	} else {
+		expiresAt := now.Add(e.cfg.TTL)
+		export.Status = storage.ExportCompleted
+		export.ExpiresAt = &expiresAt
+	}
+
+	if err := e.exportRepo.Update(ctx, export); err != nil {
+		log.Printf("Failed to save export %s: %v", export.ID, err)
+		return
+	}
+
+	if err := e.notify(ctx, export); err != nil {
+		log.Printf("⚠ Failed to send notification for export %s: %v", export.ID, err)
+	}
+}
+
+// notify emails the requester a download link, or the reason the export failed
+func (e *Exporter) notify(ctx context.Context, export *storage.Export) error {
+	if e.emailService == nil || export.NotifyEmail == "" {
+		return nil
+	}
+
+	projectName := export.ProjectID
+	if project, err := e.projectRepo.Get(ctx, export.ProjectID); err == nil {
+		projectName = project.Name
+	}
+	resource := string(export.Resource)
+	if export.Resource == storage.ExportTestRuns {
+		resource = "test runs"
+	}
+
+	if export.Status == storage.ExportFailed {
+		return e.emailService.SendExportFailedEmail(ctx, export.NotifyEmail, projectName, resource, export.Error)
+	}
+
+	url, err := e.files.GetPresignedURL(ctx, export.StorageKey, e.cfg.URLExpiry)
+	if err != nil {
+		return err
+	}
+	return e.emailService.SendExportReadyEmail(ctx, export.NotifyEmail, projectName, resource, export.RowCount, url, time.Now().Add(e.cfg.URLExpiry))
+}
+
+// deleteExpired removes the files of exports past their TTL
+func (e *Exporter) deleteExpired(ctx context.Context) error {
+	exports, err := e.exportRepo.ListExpired(ctx, time.Now(), expiredBatchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, export := range exports {
+		if err := e.files.DeleteFile(ctx, export.StorageKey); err != nil {
+			log.Printf("Failed to delete expired export %s: %v", export.StorageKey, err)
+			continue
+		}
+		export.Status = storage.ExportExpired
+		if err := e.exportRepo.Update(ctx, export); err != nil {
+			return err
+		}
+	}
+	return nil
+}
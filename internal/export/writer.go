@@ -0,0 +1,315 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+
+	"github.com/regrada-ai/regrada-be/internal/domain"
+	"github.com/regrada-ai/regrada-be/internal/storage"
+)
+
+// encoder writes batches of records to an export file in one format
+type encoder[T any] interface {
+	write(batch []T) error
+	close() error
+}
+
+// contentTypes maps each export format to the content type of its file
+var contentTypes = map[storage.ExportFormat]string{
+	storage.ExportJSONL:   "application/x-ndjson",
+	storage.ExportCSV:     "text/csv",
+	storage.ExportParquet: "application/vnd.apache.parquet",
+}
+
+func newTraceEncoder(w io.Writer, format storage.ExportFormat) (encoder[*domain.Trace], error) {
+	switch format {
+	case storage.ExportJSONL:
+		return &jsonlEncoder[*domain.Trace]{enc: json.NewEncoder(w)}, nil
+	case storage.ExportCSV:
+		return newCSVEncoder(w, traceColumns, func(t *domain.Trace) []string { return toTraceRow(t).record() }), nil
+	case storage.ExportParquet:
+		return newParquetEncoder(w, toTraceRow), nil
+	}
+	return nil, fmt.Errorf("unsupported export format %q", format)
+}
+
+func newTestRunEncoder(w io.Writer, format storage.ExportFormat) (encoder[*domain.TestRun], error) {
+	switch format {
+	case storage.ExportJSONL:
+		return &jsonlEncoder[*domain.TestRun]{enc: json.NewEncoder(w)}, nil
+	case storage.ExportCSV:
+		return newCSVEncoder(w, testRunColumns, func(r *domain.TestRun) []string { return toTestRunRow(r).record() }), nil
+	case storage.ExportParquet:
+		return newParquetEncoder(w, toTestRunRow), nil
+	}
+	return nil, fmt.Errorf("unsupported export format %q", format)
+}
+
+// jsonlEncoder writes each record as one line of JSON in the same shape the
+// API returns
+type jsonlEncoder[T any] struct {
+	enc *json.Encoder
+}
+
+func (e *jsonlEncoder[T]) write(batch []T) error {
+	for _, record := range batch {
+		if err := e.enc.Encode(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *jsonlEncoder[T]) close() error {
+	return nil
+}
+
+// csvEncoder writes a header row followed by one flattened row per record.
+// Nested values are written as JSON.
+type csvEncoder[T any] struct {
+	w        *csv.Writer
+	header   []string
+	toRecord func(T) []string
+}
+
+func newCSVEncoder[T any](w io.Writer, header []string, toRecord func(T) []string) *csvEncoder[T] {
+	return &csvEncoder[T]{w: csv.NewWriter(w), header: header, toRecord: toRecord}
+}
+
+func (e *csvEncoder[T]) write(batch []T) error {
+	if e.header != nil {
+		if err := e.w.Write(e.header); err != nil {
+			return err
+		}
+		e.header = nil
+	}
+	for _, record := range batch {
+		if err := e.w.Write(e.toRecord(record)); err != nil {
+			return err
+		}
+	}
+	e.w.Flush()
+	return e.w.Error()
+}
+
+func (e *csvEncoder[T]) close() error {
+	if e.header != nil {
+		// An empty export still gets its header
+		if err := e.w.Write(e.header); err != nil {
+			return err
+		}
+	}
+	e.w.Flush()
+	return e.w.Error()
+}
+
+// parquetEncoder converts records to flat rows and writes them as Parquet
+type parquetEncoder[T, R any] struct {
+	w     *parquet.GenericWriter[R]
+	toRow func(T) R
+	rows  []R
+}
+
+func newParquetEncoder[T, R any](w io.Writer, toRow func(T) R) *parquetEncoder[T, R] {
+	return &parquetEncoder[T, R]{w: parquet.NewGenericWriter[R](w), toRow: toRow}
+}
+
+func (e *parquetEncoder[T, R]) write(batch []T) error {
+	e.rows = e.rows[:0]
+	for _, record := range batch {
+		e.rows = append(e.rows, e.toRow(record))
+	}
+	_, err := e.w.Write(e.rows)
+	return err
+}
+
+func (e *parquetEncoder[T, R]) close() error {
+	return e.w.Close()
+}
+
+var traceColumns = []string{
+	"trace_id", "timestamp", "provider", "model", "environment", "git_sha", "git_branch",
+	"prompt_name", "prompt_version", "messages", "params", "assistant_text", "tool_calls", "raw_response",
+	"latency_ms", "tokens_in", "tokens_out", "redaction_applied", "tags",
+}
+
+// traceRow is a trace flattened for tabular formats
+type traceRow struct {
+	TraceID          string    `parquet:"trace_id"`
+	Timestamp        time.Time `parquet:"timestamp,timestamp(microsecond)"`
+	Provider         string    `parquet:"provider"`
+	Model            string    `parquet:"model"`
+	Environment      string    `parquet:"environment,optional"`
+	GitSHA           string    `parquet:"git_sha,optional"`
+	GitBranch        string    `parquet:"git_branch,optional"`
+	PromptName       string    `parquet:"prompt_name,optional"`
+	PromptVersion    int64     `parquet:"prompt_version,optional"`
+	Messages         string    `parquet:"messages,json"`
+	Params           string    `parquet:"params,json,optional"`
+	AssistantText    string    `parquet:"assistant_text,optional"`
+	ToolCalls        string    `parquet:"tool_calls,json,optional"`
+	RawResponse      string    `parquet:"raw_response,json,optional"`
+	LatencyMS        int64     `parquet:"latency_ms"`
+	TokensIn         int64     `parquet:"tokens_in"`
+	TokensOut        int64     `parquet:"tokens_out"`
+	RedactionApplied []string  `parquet:"redaction_applied,list"`
+	Tags             []string  `parquet:"tags,list"`
+}
+
+func toTraceRow(t *domain.Trace) traceRow {
+	row := traceRow{
+		TraceID:          t.TraceID,
+		Timestamp:        t.Timestamp.UTC(),
+		Provider:         t.Provider,
+		Model:            t.Model,
+		Environment:      t.Environment,
+		GitSHA:           t.GitSHA,
+		GitBranch:        t.GitBranch,
+		PromptName:       t.PromptName,
+		PromptVersion:    int64(t.PromptVersion),
+		Messages:         toJSON(t.Request.Messages),
+		AssistantText:    t.Response.AssistantText,
+		RawResponse:      string(t.Response.Raw),
+		LatencyMS:        int64(t.Metrics.LatencyMS),
+		TokensIn:         int64(t.Metrics.TokensIn),
+		TokensOut:        int64(t.Metrics.TokensOut),
+		RedactionApplied: t.RedactionApplied,
+		Tags:             t.Tags,
+	}
+	if t.Request.Params != nil {
+		row.Params = toJSON(t.Request.Params)
+	}
+	if len(t.Response.ToolCalls) > 0 {
+		row.ToolCalls = toJSON(t.Response.ToolCalls)
+	}
+	return row
+}
+
+func (r traceRow) record() []string {
+	promptVersion := ""
+	if r.PromptVersion != 0 {
+		promptVersion = strconv.FormatInt(r.PromptVersion, 10)
+	}
+	return []string{
+		r.TraceID,
+		r.Timestamp.Format(time.RFC3339Nano),
+		r.Provider,
+		r.Model,
+		r.Environment,
+		r.GitSHA,
+		r.GitBranch,
+		r.PromptName,
+		promptVersion,
+		r.Messages,
+		r.Params,
+		r.AssistantText,
+		r.ToolCalls,
+		r.RawResponse,
+		strconv.FormatInt(r.LatencyMS, 10),
+		strconv.FormatInt(r.TokensIn, 10),
+		strconv.FormatInt(r.TokensOut, 10),
+		toJSON(nonNil(r.RedactionApplied)),
+		toJSON(nonNil(r.Tags)),
+	}
+}
+
+var testRunColumns = []string{
+	"run_id", "timestamp", "git_sha", "git_branch", "git_commit_message",
+	"ci_provider", "ci_build_id", "ci_build_url", "ci_pr_number", "status",
+	"total_cases", "passed_cases", "warned_cases", "failed_cases",
+	"results", "violations", "config",
+}
+
+// testRunRow is a test run flattened for tabular formats
+type testRunRow struct {
+	RunID            string    `parquet:"run_id"`
+	Timestamp        time.Time `parquet:"timestamp,timestamp(microsecond)"`
+	GitSHA           string    `parquet:"git_sha"`
+	GitBranch        string    `parquet:"git_branch,optional"`
+	GitCommitMessage string    `parquet:"git_commit_message,optional"`
+	CIProvider       string    `parquet:"ci_provider,optional"`
+	CIBuildID        string    `parquet:"ci_build_id,optional"`
+	CIBuildURL       string    `parquet:"ci_build_url,optional"`
+	CIPRNumber       int64     `parquet:"ci_pr_number,optional"`
+	Status           string    `parquet:"status,optional"`
+	TotalCases       int64     `parquet:"total_cases"`
+	PassedCases      int64     `parquet:"passed_cases"`
+	WarnedCases      int64     `parquet:"warned_cases"`
+	FailedCases      int64     `parquet:"failed_cases"`
+	Results          string    `parquet:"results,json"`
+	Violations       string    `parquet:"violations,json"`
+	Config           string    `parquet:"config,json,optional"`
+}
+
+func toTestRunRow(r *domain.TestRun) testRunRow {
+	row := testRunRow{
+		RunID:            r.RunID,
+		Timestamp:        r.Timestamp.UTC(),
+		GitSHA:           r.GitSHA,
+		GitBranch:        r.GitBranch,
+		GitCommitMessage: r.GitCommitMessage,
+		CIProvider:       r.CIProvider,
+		CIBuildID:        r.CIBuildID,
+		CIBuildURL:       r.CIBuildURL,
+		CIPRNumber:       int64(r.CIPRNumber),
+		Status:           r.Status,
+		TotalCases:       int64(r.TotalCases),
+		PassedCases:      int64(r.PassedCases),
+		WarnedCases:      int64(r.WarnedCases),
+		FailedCases:      int64(r.FailedCases),
+		Results:          toJSON(nonNil(r.Results)),
+		Violations:       toJSON(nonNil(r.Violations)),
+	}
+	if r.Config != nil {
+		row.Config = toJSON(r.Config)
+	}
+	return row
+}
+
+func (r testRunRow) record() []string {
+	prNumber := ""
+	if r.CIPRNumber != 0 {
+		prNumber = strconv.FormatInt(r.CIPRNumber, 10)
+	}
+	return []string{
+		r.RunID,
+		r.Timestamp.Format(time.RFC3339Nano),
+		r.GitSHA,
+		r.GitBranch,
+		r.GitCommitMessage,
+		r.CIProvider,
+		r.CIBuildID,
+		r.CIBuildURL,
+		prNumber,
+		r.Status,
+		strconv.FormatInt(r.TotalCases, 10),
+		strconv.FormatInt(r.PassedCases, 10),
+		strconv.FormatInt(r.WarnedCases, 10),
+		strconv.FormatInt(r.FailedCases, 10),
+		r.Results,
+		r.Violations,
+		r.Config,
+	}
+}
+
+// toJSON encodes a value already decoded from JSON, so it cannot fail
+func toJSON(v any) string {
+	data, _ := json.Marshal(v)
+	return string(data)
+}
+
+// nonNil returns an empty slice for nil so it encodes as [] rather than null
+func nonNil[T any](s []T) []T {
+	if s == nil {
+		return []T{}
+	}
+	return s
+}
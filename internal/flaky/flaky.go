@@ -0,0 +1,116 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+// Package flaky scores test cases by how inconsistently they pass.
+package flaky
+
+import (
+	"sort"
+	"time"
+
+	"github.com/regrada-ai/regrada-be/internal/storage"
+)
+
+// CaseScore is a case's flakiness over its recent history. Cases run against
+// several models are scored model by model.
+type CaseScore struct {
+	CaseID   string `json:"case_id"`
+	Provider string `json:"provider,omitempty"`
+	Model    string `json:"model,omitempty"`
+	// Score is the larger of RunVariance and FlipRate, from 0 (stable) to 1
+	Score float64 `json:"score"`
+	// RunVariance is the mean pass/fail variance between the runs of a case
+	// within one test run, scaled to 0-1. Results of a single run are left out.
+	RunVariance float64 `json:"run_variance"`
+	// Flips counts outcome changes between consecutive test runs at the same
+	// git SHA, out of Comparisons such pairs. Runs without a git SHA are not
+	// compared.
+	Flips       int     `json:"flips"`
+	Comparisons int     `json:"comparisons"`
+	FlipRate    float64 `json:"flip_rate"`
+	// Observations counts the test runs the case appeared in
+	Observations int       `json:"observations"`
+	PassRate     float64   `json:"pass_rate"`
+	LastSeen     time.Time `json:"last_seen"`
+	LastGitSHA   string    `json:"last_git_sha"`
+	Quarantined  bool      `json:"quarantined"`
+}
+
+type caseKey struct {
+	caseID, provider, model string
+}
+
+// Score scores every case in the observations, which must be oldest first.
+// The result is ordered by descending score.
+func Score(observations []storage.CaseObservation) []*CaseScore {
+	type history struct {
+		score         *CaseScore
+		varianceSum   float64
+		varianceCount int
+		passRateSum   float64
+		lastOutcome   map[string]bool // by git SHA
+	}
+
+	histories := make(map[caseKey]*history)
+	var order []caseKey
+	for _, observation := range observations {
+		key := caseKey{observation.CaseID, observation.Provider, observation.Model}
+		h := histories[key]
+		if h == nil {
+			h = &history{
+				score: &CaseScore{
+					CaseID:   observation.CaseID,
+					Provider: observation.Provider,
+					Model:    observation.Model,
+				},
+				lastOutcome: make(map[string]bool),
+			}
+			histories[key] = h
+			order = append(order, key)
+		}
+
+		h.score.Observations++
+		h.score.LastSeen = observation.Timestamp
+		h.score.LastGitSHA = observation.GitSHA
+		h.passRateSum += observation.PassRate
+
+		if observation.Runs > 1 {
+			// p(1-p) peaks at 0.25 when half the runs pass
+			h.varianceSum += 4 * observation.PassRate * (1 - observation.PassRate)
+			h.varianceCount++
+		}
+
+		// Runs without a git SHA can't be matched to a commit, so they only
+		// count toward run variance
+		if observation.GitSHA == "" {
+			continue
+		}
+		passed := observation.PassRate >= 0.5
+		if previous, ok := h.lastOutcome[observation.GitSHA]; ok {
+			h.score.Comparisons++
+			if previous != passed {
+				h.score.Flips++
+			}
+		}
+		h.lastOutcome[observation.GitSHA] = passed
+	}
+
+	scores := make([]*CaseScore, len(order))
+	for i, key := range order {
+		h := histories[key]
+		score := h.score
+		score.PassRate = h.passRateSum / float64(score.Observations)
+		if h.varianceCount > 0 {
+			score.RunVariance = h.varianceSum / float64(h.varianceCount)
+		}
+		if score.Comparisons > 0 {
+			score.FlipRate = float64(score.Flips) / float64(score.Comparisons)
+		}
+		score.Score = max(score.RunVariance, score.FlipRate)
+		scores[i] = score
+	}
+
+	sort.SliceStable(scores, func(i, j int) bool {
+		return scores[i].Score > scores[j].Score
+	})
+	return scores
+}
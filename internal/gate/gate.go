@@ -0,0 +1,415 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+// Package gate checks uploaded test runs against a project's quality gate.
+package gate
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/regrada-ai/regrada-be/internal/comparison"
+	"github.com/regrada-ai/regrada-be/internal/domain"
+	"github.com/regrada-ai/regrada-be/internal/stats"
+	"github.com/regrada-ai/regrada-be/internal/storage"
+)
+
+const (
+	maxConditions = 20
+
+	// maxCasesReported caps the regressed cases listed in a failed condition
+	maxCasesReported = 50
+)
+
+// Condition outcomes
+const (
+	StatusPassed  = "passed"
+	StatusFailed  = "failed"
+	StatusSkipped = "skipped" // the condition could not be checked
+)
+
+// severityRanks orders violation severities. Severities not listed rank as
+// critical, so an unknown severity never slips through a gate.
+var severityRanks = map[string]int{
+	"info":     0,
+	"warn":     1,
+	"warning":  1,
+	"error":    2,
+	"critical": 3,
+}
+
+// Verdict is the outcome of checking a test run against a quality gate
+type Verdict struct {
+	Passed        bool    `json:"passed"`
+	BaselineRunID string  `json:"baseline_run_id,omitempty"`
+	Alpha         float64 `json:"alpha"` // significance level of baseline comparisons
+	// QuarantinedCases lists the quarantined cases of the run, which the
+	// conditions leave out
+	QuarantinedCases []string          `json:"quarantined_cases,omitempty"`
+	Conditions       []ConditionResult `json:"conditions"`
+	Failures         []ConditionResult `json:"failures"` // the failed conditions
+}
+
+// ConditionResult is the outcome of one gate condition
+type ConditionResult struct {
+	Type      storage.GateConditionType `json:"type"`
+	Status    string                    `json:"status"`
+	Threshold float64                   `json:"threshold"`
+	Actual    *float64                  `json:"actual,omitempty"`
+	Baseline  *float64                  `json:"baseline,omitempty"`
+	// PValue is the significance of a latency change, if both runs report
+	// individual runs
+	PValue  *float64         `json:"p_value,omitempty"`
+	Message string           `json:"message"`
+	Cases   []CaseRegression `json:"cases,omitempty"`
+}
+
+// CaseRegression is a case whose pass rate fell by more than allowed. A drop
+// without a p-value, because a run reports only aggregates, counts as
+// significant.
+type CaseRegression struct {
+	CaseID           string          `json:"case_id"`
+	Provider         string          `json:"provider,omitempty"`
+	Model            string          `json:"model,omitempty"`
+	PassRate         float64         `json:"pass_rate"`
+	PassRateCI       *stats.Interval `json:"pass_rate_ci,omitempty"`
+	BaselinePassRate float64         `json:"baseline_pass_rate"`
+	PValue           *float64        `json:"p_value,omitempty"`
+	Significant      bool            `json:"significant"`
+}
+
+// Options tune how a run is checked
+type Options struct {
+	// Quarantined holds the IDs of cases left out of both runs
+	Quarantined map[string]bool
+	// Alpha is the significance level of baseline comparisons, 0 for
+	// stats.DefaultAlpha
+	Alpha float64
+}
+
+// Validate checks a gate's conditions
+func Validate(gate *storage.QualityGate) error {
+	if gate.BaselineBranch == "" || len(gate.BaselineBranch) > 255 {
+		return errors.New("baseline_branch must be 1-255 characters")
+	}
+	if len(gate.Conditions) == 0 {
+		return errors.New("a gate needs at least one condition")
+	}
+	if len(gate.Conditions) > maxConditions {
+		return fmt.Errorf("a gate can have at most %d conditions", maxConditions)
+	}
+
+	for i, condition := range gate.Conditions {
+		if math.IsNaN(condition.Threshold) || math.IsInf(condition.Threshold, 0) || condition.Threshold < 0 {
+			return fmt.Errorf("condition %d: threshold must be a non-negative number", i)
+		}
+		if condition.Severity != "" && condition.Type != storage.GateMaxViolations {
+			return fmt.Errorf("condition %d: severity only applies to max_violations", i)
+		}
+		if condition.RequireSignificance && condition.Type != storage.GateMaxCasePassRateDrop && condition.Type != storage.GateMaxP95LatencyIncrease {
+			return fmt.Errorf("condition %d: require_significance only applies to baseline comparisons", i)
+		}
+
+		switch condition.Type {
+		case storage.GateMinPassRate, storage.GateMaxCasePassRateDrop:
+			if condition.Threshold > 1 {
+				return fmt.Errorf("condition %d: %s threshold must be between 0 and 1", i, condition.Type)
+			}
+		case storage.GateMaxViolations:
+			if condition.Threshold != math.Trunc(condition.Threshold) {
+				return fmt.Errorf("condition %d: max_violations threshold must be a whole number", i)
+			}
+			if _, ok := severityRanks[condition.Severity]; condition.Severity != "" && !ok {
+				return fmt.Errorf("condition %d: severity must be info, warn, error, or critical", i)
+			}
+		case storage.GateMaxP95LatencyIncrease:
+		default:
+			return fmt.Errorf("condition %d: unknown type %q", i, condition.Type)
+		}
+	}
+	return nil
+}
+
+// NeedsBaseline reports whether any of the gate's conditions compares
+// against a baseline run
+func NeedsBaseline(gate *storage.QualityGate) bool {
+	for _, condition := range gate.Conditions {
+		if condition.Type == storage.GateMaxCasePassRateDrop || condition.Type == storage.GateMaxP95LatencyIncrease {
+			return true
+		}
+	}
+	return false
+}
+
+// Evaluate checks a run against the gate's conditions. baseline may be nil,
+// in which case conditions that need one are skipped. Changes from the
+// baseline fail a condition by exceeding its threshold, or only if they are
+// also significant when the condition requires significance.
+func Evaluate(gate *storage.QualityGate, run, baseline *domain.TestRun, opts Options) *Verdict {
+	alpha := opts.Alpha
+	if alpha <= 0 {
+		alpha = stats.DefaultAlpha
+	}
+	verdict := &Verdict{
+		Passed:     true,
+		Alpha:      alpha,
+		Conditions: make([]ConditionResult, 0, len(gate.Conditions)),
+		Failures:   []ConditionResult{},
+	}
+	if len(opts.Quarantined) > 0 {
+		run, verdict.QuarantinedCases = withoutCases(run, opts.Quarantined)
+		if baseline != nil {
+			baseline, _ = withoutCases(baseline, opts.Quarantined)
+		}
+	}
+	if baseline != nil {
+		verdict.BaselineRunID = baseline.RunID
+	}
+
+	for _, condition := range gate.Conditions {
+		var result ConditionResult
+		switch condition.Type {
+		case storage.GateMinPassRate:
+			result = checkPassRate(condition, run)
+		case storage.GateMaxViolations:
+			result = checkViolations(condition, run)
+		case storage.GateMaxCasePassRateDrop:
+			result = checkCasePassRates(condition, run, baseline, alpha)
+		case storage.GateMaxP95LatencyIncrease:
+			result = checkLatency(condition, run, baseline, alpha)
+		default:
+			continue
+		}
+		result.Type = condition.Type
+		result.Threshold = condition.Threshold
+
+		verdict.Conditions = append(verdict.Conditions, result)
+		if result.Status == StatusFailed {
+			verdict.Passed = false
+			verdict.Failures = append(verdict.Failures, result)
+		}
+	}
+	return verdict
+}
+
+func checkPassRate(condition storage.GateCondition, run *domain.TestRun) ConditionResult {
+	if run.TotalCases == 0 {
+		return ConditionResult{Status: StatusSkipped, Message: "the run has no cases"}
+	}
+
+	rate := float64(run.PassedCases) / float64(run.TotalCases)
+	result := ConditionResult{Actual: &rate}
+	if rate < condition.Threshold {
+		result.Status = StatusFailed
+		result.Message = fmt.Sprintf("pass rate %.1f%% is below the required %.1f%%", rate*100, condition.Threshold*100)
+	} else {
+		result.Status = StatusPassed
+		result.Message = fmt.Sprintf("pass rate %.1f%% meets the required %.1f%%", rate*100, condition.Threshold*100)
+	}
+	return result
+}
+
+func checkViolations(condition storage.GateCondition, run *domain.TestRun) ConditionResult {
+	severity := condition.Severity
+	if severity == "" {
+		severity = "error"
+	}
+	minRank := severityRanks[severity]
+
+	count := 0
+	for _, violation := range run.Violations {
+		rank, ok := severityRanks[violation.Severity]
+		if !ok {
+			rank = severityRanks["critical"]
+		}
+		if rank >= minRank {
+			count++
+		}
+	}
+
+	actual := float64(count)
+	result := ConditionResult{Actual: &actual}
+	if actual > condition.Threshold {
+		result.Status = StatusFailed
+		result.Message = fmt.Sprintf("%d violations of severity %s or above, at most %d allowed", count, severity, int(condition.Threshold))
+	} else {
+		result.Status = StatusPassed
+		result.Message = fmt.Sprintf("%d violations of severity %s or above", count, severity)
+	}
+	return result
+}
+
+func checkCasePassRates(condition storage.GateCondition, run, baseline *domain.TestRun, alpha float64) ConditionResult {
+	if baseline == nil {
+		return ConditionResult{Status: StatusSkipped, Message: "no baseline run to compare with"}
+	}
+
+	baselineResults := make(map[string]domain.CaseResult, len(baseline.Results))
+	for _, result := range baseline.Results {
+		baselineResults[caseKey(result)] = result
+	}
+
+	var regressions []CaseRegression
+	significant := 0
+	worst := 0.0
+	for _, result := range run.Results {
+		before, ok := baselineResults[caseKey(result)]
+		if !ok {
+			continue
+		}
+		delta := comparison.CompareCase(result, before, alpha)
+		drop := -delta.PassRateDelta
+		worst = max(worst, drop)
+		if drop <= condition.Threshold {
+			continue
+		}
+
+		regression := CaseRegression{
+			CaseID:           result.CaseID,
+			Provider:         result.Provider,
+			Model:            result.Model,
+			PassRate:         delta.PassRate,
+			PassRateCI:       delta.PassRateCI,
+			BaselinePassRate: delta.BaselinePassRate,
+			PValue:           delta.PassRatePValue,
+			Significant:      delta.PassRatePValue == nil || delta.PassRateSignificant,
+		}
+		if regression.Significant {
+			significant++
+		}
+		regressions = append(regressions, regression)
+	}
+
+	result := ConditionResult{Actual: &worst}
+	if len(regressions) == 0 {
+		result.Status = StatusPassed
+		result.Message = fmt.Sprintf("no case pass rate dropped by more than %.1f points", condition.Threshold*100)
+		return result
+	}
+
+	sort.SliceStable(regressions, func(i, j int) bool {
+		if regressions[i].Significant != regressions[j].Significant {
+			return regressions[i].Significant
+		}
+		return regressions[i].BaselinePassRate-regressions[i].PassRate > regressions[j].BaselinePassRate-regressions[j].PassRate
+	})
+	result.Cases = regressions[:min(len(regressions), maxCasesReported)]
+	switch {
+	case !condition.RequireSignificance:
+		result.Status = StatusFailed
+		result.Message = fmt.Sprintf("%d cases dropped by more than %.1f points in pass rate, %d significantly at alpha %g", len(regressions), condition.Threshold*100, significant, alpha)
+	case significant == 0:
+		result.Status = StatusPassed
+		result.Message = fmt.Sprintf("%d cases dropped by more than %.1f points in pass rate, none significantly at alpha %g", len(regressions), condition.Threshold*100, alpha)
+	default:
+		result.Status = StatusFailed
+		result.Message = fmt.Sprintf("%d cases dropped significantly, by more than %.1f points in pass rate", significant, condition.Threshold*100)
+	}
+	return result
+}
+
+func checkLatency(condition storage.GateCondition, run, baseline *domain.TestRun, alpha float64) ConditionResult {
+	if baseline == nil {
+		return ConditionResult{Status: StatusSkipped, Message: "no baseline run to compare with"}
+	}
+
+	current, before := p95Latency(run), p95Latency(baseline)
+	if current == 0 || before == 0 {
+		return ConditionResult{Status: StatusSkipped, Message: "the run or its baseline reports no latencies"}
+	}
+
+	increase := current/before - 1
+	result := ConditionResult{Actual: &current, Baseline: &before}
+	if samples, baselineSamples := runLatencies(run), runLatencies(baseline); len(samples) > 0 && len(baselineSamples) > 0 {
+		p := stats.MannWhitney(samples, baselineSamples)
+		result.PValue = &p
+	}
+
+	switch {
+	case increase <= condition.Threshold:
+		result.Status = StatusPassed
+		result.Message = fmt.Sprintf("p95 latency changed %+.1f%% (%.0fms to %.0fms)", increase*100, before, current)
+	case condition.RequireSignificance && result.PValue != nil && *result.PValue >= alpha:
+		result.Status = StatusPassed
+		result.Message = fmt.Sprintf("p95 latency rose %.1f%% (%.0fms to %.0fms), but not significantly at alpha %g", increase*100, before, current, alpha)
+	default:
+		result.Status = StatusFailed
+		result.Message = fmt.Sprintf("p95 latency rose %.1f%% (%.0fms to %.0fms), at most %.1f%% allowed", increase*100, before, current, condition.Threshold*100)
+		if result.PValue != nil {
+			result.Message += fmt.Sprintf(" (p = %.3g)", *result.PValue)
+		}
+	}
+	return result
+}
+
+// runLatencies returns the latencies of a run's individual runs
+func runLatencies(run *domain.TestRun) []float64 {
+	var latencies []float64
+	for _, result := range run.Results {
+		for _, r := range result.Runs {
+			latencies = append(latencies, float64(r.Metrics.LatencyMS))
+		}
+	}
+	return latencies
+}
+
+// p95Latency returns the 95th percentile latency of a run's individual runs,
+// or of its cases' p95 latencies if it reports no individual runs
+func p95Latency(run *domain.TestRun) float64 {
+	var latencies []int
+	for _, result := range run.Results {
+		for _, r := range result.Runs {
+			latencies = append(latencies, r.Metrics.LatencyMS)
+		}
+	}
+	if len(latencies) == 0 {
+		for _, result := range run.Results {
+			latencies = append(latencies, result.Aggregates.LatencyP95MS)
+		}
+	}
+	if len(latencies) == 0 {
+		return 0
+	}
+
+	sort.Ints(latencies)
+	rank := int(math.Ceil(0.95*float64(len(latencies)))) - 1
+	return float64(latencies[max(rank, 0)])
+}
+
+// withoutCases returns a copy of the run without the results of the given
+// cases, and the IDs of the cases it left out. A result left out counts as a
+// passed case if all its runs passed.
+func withoutCases(run *domain.TestRun, cases map[string]bool) (*domain.TestRun, []string) {
+	filtered := *run
+	filtered.Results = make([]domain.CaseResult, 0, len(run.Results))
+
+	var removed []string
+	seen := make(map[string]bool)
+	for _, result := range run.Results {
+		if !cases[result.CaseID] {
+			filtered.Results = append(filtered.Results, result)
+			continue
+		}
+
+		filtered.TotalCases--
+		if result.Aggregates.PassRate >= 1 {
+			filtered.PassedCases--
+		}
+		if !seen[result.CaseID] {
+			removed = append(removed, result.CaseID)
+			seen[result.CaseID] = true
+		}
+	}
+	filtered.TotalCases = max(filtered.TotalCases, 0)
+	filtered.PassedCases = min(max(filtered.PassedCases, 0), filtered.TotalCases)
+
+	sort.Strings(removed)
+	return &filtered, removed
+}
+
+// caseKey identifies a case across runs. A case run against several models
+// is compared model by model.
+func caseKey(result domain.CaseResult) string {
+	return result.CaseID + "\x00" + result.Provider + "\x00" + result.Model
+}
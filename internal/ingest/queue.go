@@ -0,0 +1,296 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+// Package ingest implements asynchronous trace ingestion: traces are written
+// to a durable Redis Stream by the API and bulk loaded into Postgres by a pool
+// of background workers.
+package ingest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/regrada-ai/regrada-be/internal/domain"
+	"github.com/regrada-ai/regrada-be/internal/storage"
+)
+
+const (
+	streamKey     = "ingest:traces"
+	deadLetterKey = "ingest:traces:dead"
+	consumerGroup = "ingest-workers"
+
+	// deadLetterMaxLen caps the dead-letter stream (approximately)
+	deadLetterMaxLen = 100000
+)
+
+// ErrQueueFull is returned when the backlog exceeds the configured limit
+var ErrQueueFull = errors.New("ingestion queue is full")
+
+// Message is one queued trace
+type Message struct {
+	ID         string
+	ProjectID  string
+	OnConflict storage.ConflictMode
+	Trace      domain.Trace
+}
+
+// Status describes the state of the ingestion queue
+type Status struct {
+	Backlog          int64   `json:"backlog"`            // traces waiting to be stored
+	Pending          int64   `json:"pending"`            // traces claimed by a worker but not yet stored
+	Lag              int64   `json:"lag"`                // traces not yet claimed by any worker
+	OldestAgeSeconds float64 `json:"oldest_age_seconds"` // age of the oldest trace in the backlog
+	DeadLetters      int64   `json:"dead_letters"`       // traces that could not be stored
+	Consumers        int64   `json:"consumers"`          // worker connections registered with the stream
+	MaxBacklog       int64   `json:"max_backlog"`        // backlog at which new traces are rejected
+}
+
+// Queue is a Redis Stream of traces awaiting storage
+type Queue struct {
+	redisClient *redis.Client
+	maxBacklog  int64
+}
+
+// NewQueue creates a queue. Enqueue fails with ErrQueueFull once the backlog
+// reaches maxBacklog; zero disables the limit.
+func NewQueue(redisClient *redis.Client, maxBacklog int64) *Queue {
+	return &Queue{
+		redisClient: redisClient,
+		maxBacklog:  maxBacklog,
+	}
+}
+
+// EnsureGroup creates the stream and its consumer group if they don't exist
+func (q *Queue) EnsureGroup(ctx context.Context) error {
+	err := q.redisClient.XGroupCreateMkStream(ctx, streamKey, consumerGroup, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return err
+	}
+	return nil
+}
+
+// Enqueue appends traces for a project to the stream atomically and returns
+// their message IDs, in order
+func (q *Queue) Enqueue(ctx context.Context, projectID string, traces []domain.Trace, onConflict storage.ConflictMode) ([]string, error) {
+	if len(traces) == 0 {
+		return nil, nil
+	}
+
+	if q.maxBacklog > 0 {
+		backlog, err := q.redisClient.XLen(ctx, streamKey).Result()
+		if err != nil {
+			return nil, err
+		}
+		if backlog+int64(len(traces)) > q.maxBacklog {
+			return nil, ErrQueueFull
+		}
+	}
+
+	cmds := make([]*redis.StringCmd, len(traces))
+	_, err := q.redisClient.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i := range traces {
+			data, err := json.Marshal(traces[i])
+			if err != nil {
+				return err
+			}
+			cmds[i] = pipe.XAdd(ctx, &redis.XAddArgs{
+				Stream: streamKey,
+				Values: map[string]any{
+					"project_id":  projectID,
+					"on_conflict": string(onConflict),
+					"trace":       data,
+				},
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, len(cmds))
+	for i, cmd := range cmds {
+		ids[i] = cmd.Val()
+	}
+	return ids, nil
+}
+
+// Status reports the backlog, lag, and dead-letter count
+func (q *Queue) Status(ctx context.Context) (*Status, error) {
+	status := &Status{MaxBacklog: q.maxBacklog}
+
+	// Stored traces are deleted from the stream, so its length is the backlog
+	backlog, err := q.redisClient.XLen(ctx, streamKey).Result()
+	if err != nil {
+		return nil, err
+	}
+	status.Backlog = backlog
+
+	groups, err := q.redisClient.XInfoGroups(ctx, streamKey).Result()
+	if err != nil && !isNoSuchKey(err) {
+		return nil, err
+	}
+	for _, group := range groups {
+		if group.Name == consumerGroup {
+			status.Pending = group.Pending
+			status.Consumers = group.Consumers
+		}
+	}
+	status.Lag = max(status.Backlog-status.Pending, 0)
+
+	oldest, err := q.redisClient.XRangeN(ctx, streamKey, "-", "+", 1).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(oldest) > 0 {
+		if enqueuedAt, ok := messageTime(oldest[0].ID); ok {
+			status.OldestAgeSeconds = time.Since(enqueuedAt).Seconds()
+		}
+	}
+
+	deadLetters, err := q.redisClient.XLen(ctx, deadLetterKey).Result()
+	if err != nil {
+		return nil, err
+	}
+	status.DeadLetters = deadLetters
+
+	return status, nil
+}
+
+// read returns new messages for a consumer, blocking up to block
+func (q *Queue) read(ctx context.Context, consumer string, count int64, block time.Duration) ([]redis.XMessage, error) {
+	streams, err := q.redisClient.XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group:    consumerGroup,
+		Consumer: consumer,
+		Streams:  []string{streamKey, ">"},
+		Count:    count,
+		Block:    block,
+	}).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var messages []redis.XMessage
+	for _, stream := range streams {
+		messages = append(messages, stream.Messages...)
+	}
+	return messages, nil
+}
+
+// claimStale takes over messages another consumer has held for longer than
+// minIdle, e.g. because its worker crashed or failed to store them
+func (q *Queue) claimStale(ctx context.Context, consumer string, count int64, minIdle time.Duration) ([]redis.XMessage, error) {
+	messages, _, err := q.redisClient.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+		Stream:   streamKey,
+		Group:    consumerGroup,
+		Consumer: consumer,
+		MinIdle:  minIdle,
+		Start:    "0-0",
+		Count:    count,
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+// deliveries returns how many times a message has been delivered to a worker
+func (q *Queue) deliveries(ctx context.Context, id string) (int64, error) {
+	pending, err := q.redisClient.XPendingExt(ctx, &redis.XPendingExtArgs{
+		Stream: streamKey,
+		Group:  consumerGroup,
+		Start:  id,
+		End:    id,
+		Count:  1,
+	}).Result()
+	if err != nil {
+		return 0, err
+	}
+	if len(pending) == 0 {
+		return 0, nil
+	}
+	return pending[0].RetryCount, nil
+}
+
+// ack marks messages as stored and removes them from the stream
+func (q *Queue) ack(ctx context.Context, ids ...string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	_, err := q.redisClient.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.XAck(ctx, streamKey, consumerGroup, ids...)
+		pipe.XDel(ctx, streamKey, ids...)
+		return nil
+	})
+	return err
+}
+
+// deadLetter moves a message that cannot be stored to the dead-letter stream
+func (q *Queue) deadLetter(ctx context.Context, msg redis.XMessage, cause error, attempts int64) error {
+	values := make(map[string]any, len(msg.Values)+4)
+	for k, v := range msg.Values {
+		values[k] = v
+	}
+	values["message_id"] = msg.ID
+	values["error"] = cause.Error()
+	values["attempts"] = attempts
+	values["failed_at"] = time.Now().UTC().Format(time.RFC3339)
+
+	if err := q.redisClient.XAdd(ctx, &redis.XAddArgs{
+		Stream: deadLetterKey,
+		MaxLen: deadLetterMaxLen,
+		Approx: true,
+		Values: values,
+	}).Err(); err != nil {
+		return err
+	}
+	return q.ack(ctx, msg.ID)
+}
+
+// decode parses a stream entry into a Message
+func decode(msg redis.XMessage) (*Message, error) {
+	projectID, _ := msg.Values["project_id"].(string)
+	onConflict, _ := msg.Values["on_conflict"].(string)
+	data, _ := msg.Values["trace"].(string)
+	if projectID == "" || data == "" {
+		return nil, errors.New("malformed queue message")
+	}
+
+	m := &Message{
+		ID:         msg.ID,
+		ProjectID:  projectID,
+		OnConflict: storage.ConflictMode(onConflict),
+	}
+	if m.OnConflict == "" {
+		m.OnConflict = storage.ConflictIgnore
+	}
+	if err := json.Unmarshal([]byte(data), &m.Trace); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// messageTime extracts the enqueue time from a stream entry ID ("<ms>-<seq>")
+func messageTime(id string) (time.Time, bool) {
+	ms, _, ok := strings.Cut(id, "-")
+	if !ok {
+		return time.Time{}, false
+	}
+	n, err := strconv.ParseInt(ms, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.UnixMilli(n), true
+}
+
+func isNoSuchKey(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "no such key")
+}
@@ -0,0 +1,264 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package ingest
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/regrada-ai/regrada-be/internal/domain"
+	"github.com/regrada-ai/regrada-be/internal/live"
+	"github.com/regrada-ai/regrada-be/internal/storage"
+)
+
+// PoolConfig configures the ingestion workers
+type PoolConfig struct {
+	Workers       int           // number of concurrent workers
+	BatchSize     int64         // maximum traces stored per COPY
+	Block         time.Duration // how long a worker waits for new traces
+	ClaimIdle     time.Duration // how long a trace may sit unacknowledged before another worker retries it
+	MaxDeliveries int64         // deliveries after which a failing trace is dead-lettered
+	MaxAttempts   int           // immediate retries of a failed batch before isolating traces
+}
+
+// DefaultPoolConfig returns the default worker settings
+func DefaultPoolConfig() PoolConfig {
+	return PoolConfig{
+		Workers:       4,
+		BatchSize:     500,
+		Block:         2 * time.Second,
+		ClaimIdle:     time.Minute,
+		MaxDeliveries: 5,
+		MaxAttempts:   3,
+	}
+}
+
+// Pool drains the ingestion queue into Postgres
+type Pool struct {
+	queue     *Queue
+	traceRepo storage.TraceRepository
+	hub       *live.Hub
+	cfg       PoolConfig
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewPool(queue *Queue, traceRepo storage.TraceRepository, hub *live.Hub, cfg PoolConfig) *Pool {
+	return &Pool{
+		queue:     queue,
+		traceRepo: traceRepo,
+		hub:       hub,
+		cfg:       cfg,
+	}
+}
+
+// Start launches the workers. They run until Stop is called.
+func (p *Pool) Start(ctx context.Context) {
+	ctx, p.cancel = context.WithCancel(ctx)
+
+	hostname, _ := os.Hostname()
+	for i := 0; i < p.cfg.Workers; i++ {
+		consumer := fmt.Sprintf("%s-%d-%d", hostname, os.Getpid(), i)
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			p.run(ctx, consumer)
+		}()
+	}
+}
+
+// Stop signals the workers to exit and waits for in-flight batches to finish
+func (p *Pool) Stop() {
+	if p.cancel == nil {
+		return
+	}
+	p.cancel()
+	p.wg.Wait()
+}
+
+func (p *Pool) run(ctx context.Context, consumer string) {
+	for ctx.Err() == nil {
+		// Retry traces abandoned by other workers before taking new ones
+		messages, err := p.queue.claimStale(ctx, consumer, p.cfg.BatchSize, p.cfg.ClaimIdle)
+		if err == nil && len(messages) == 0 {
+			messages, err = p.queue.read(ctx, consumer, p.cfg.BatchSize, p.cfg.Block)
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("Ingestion worker %s failed to read queue: %v", consumer, err)
+			sleep(ctx, time.Second)
+			continue
+		}
+		if len(messages) == 0 {
+			continue
+		}
+
+		// Finish the batch even if shutdown starts meanwhile
+		batchCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), time.Minute)
+		p.process(batchCtx, messages)
+		cancel()
+	}
+}
+
+// process stores a batch of messages, grouped by conflict mode
+func (p *Pool) process(ctx context.Context, messages []redis.XMessage) {
+	groups := make(map[storage.ConflictMode][]*Message)
+	raw := make(map[string]redis.XMessage, len(messages))
+	for _, msg := range messages {
+		raw[msg.ID] = msg
+		if msg.Values == nil {
+			// Entry was deleted while pending (already stored); just clear it
+			p.queue.ack(ctx, msg.ID)
+			continue
+		}
+		m, err := decode(msg)
+		if err != nil {
+			p.fail(ctx, msg, err, true)
+			continue
+		}
+		groups[m.OnConflict] = append(groups[m.OnConflict], m)
+	}
+
+	for onConflict, group := range groups {
+		err := p.store(ctx, group, onConflict)
+		if err == nil {
+			p.ack(ctx, group...)
+			continue
+		}
+
+		log.Printf("Failed to store %d queued traces: %v", len(group), err)
+		if len(group) == 1 {
+			p.fail(ctx, raw[group[0].ID], err, false)
+			continue
+		}
+
+		// Store traces one at a time so a single bad trace doesn't block the rest
+		for _, m := range group {
+			if err := p.store(ctx, []*Message{m}, onConflict); err != nil {
+				p.fail(ctx, raw[m.ID], err, false)
+				continue
+			}
+			p.ack(ctx, m)
+		}
+	}
+}
+
+// store bulk loads messages, retrying with backoff
+func (p *Pool) store(ctx context.Context, messages []*Message, onConflict storage.ConflictMode) error {
+	traces := dedupe(messages, onConflict)
+
+	var err error
+	backoff := 200 * time.Millisecond
+	for attempt := 1; attempt <= p.cfg.MaxAttempts; attempt++ {
+		if _, err = p.traceRepo.CopyBatch(ctx, traces, onConflict); err == nil {
+			p.publish(ctx, traces)
+			return nil
+		}
+		if attempt < p.cfg.MaxAttempts {
+			sleep(ctx, backoff)
+			backoff *= 2
+		}
+	}
+	return err
+}
+
+// publish streams stored traces to live subscribers. CopyBatch does not
+// report which traces were duplicates, so ignored duplicates are streamed
+// too.
+func (p *Pool) publish(ctx context.Context, traces []storage.ProjectTrace) {
+	byProject := make(map[string][]domain.Trace)
+	for _, trace := range traces {
+		byProject[trace.ProjectID] = append(byProject[trace.ProjectID], trace.Trace)
+	}
+	for projectID, projectTraces := range byProject {
+		p.hub.Publish(ctx, live.TopicTraces, projectID, live.TraceEvents(projectTraces)...)
+	}
+}
+
+// fail leaves a message pending so it is retried after ClaimIdle, or moves it
+// to the dead-letter stream once it has been delivered MaxDeliveries times.
+// Permanent failures are dead-lettered immediately.
+func (p *Pool) fail(ctx context.Context, msg redis.XMessage, cause error, permanent bool) {
+	attempts := int64(1)
+	if !permanent {
+		deliveries, err := p.queue.deliveries(ctx, msg.ID)
+		if err != nil {
+			log.Printf("Failed to read delivery count for queued trace %s: %v", msg.ID, err)
+			return
+		}
+		if deliveries < p.cfg.MaxDeliveries {
+			return
+		}
+		attempts = deliveries
+	}
+
+	log.Printf("Dead-lettering queued trace %s after %d attempts: %v", msg.ID, attempts, cause)
+	if err := p.queue.deadLetter(ctx, msg, cause, attempts); err != nil {
+		log.Printf("Failed to dead-letter queued trace %s: %v", msg.ID, err)
+	}
+}
+
+func (p *Pool) ack(ctx context.Context, messages ...*Message) {
+	ids := make([]string, len(messages))
+	for i, m := range messages {
+		ids[i] = m.ID
+	}
+	if err := p.queue.ack(ctx, ids...); err != nil {
+		// The traces are stored; they will be redelivered and deduplicated by trace ID
+		log.Printf("Failed to acknowledge %d queued traces: %v", len(ids), err)
+	}
+}
+
+// dedupe drops repeated (project, trace ID) pairs, which a single INSERT ...
+// ON CONFLICT cannot handle. The first occurrence wins when ignoring
+// conflicts, tags are combined when merging, and otherwise the latest wins.
+func dedupe(messages []*Message, onConflict storage.ConflictMode) []storage.ProjectTrace {
+	type key struct{ projectID, traceID string }
+
+	index := make(map[key]int, len(messages))
+	traces := make([]storage.ProjectTrace, 0, len(messages))
+	for _, m := range messages {
+		k := key{m.ProjectID, m.Trace.TraceID}
+		if i, ok := index[k]; ok {
+			switch onConflict {
+			case storage.ConflictMergeTags:
+				traces[i].Trace.Tags = mergeTags(traces[i].Trace.Tags, m.Trace.Tags)
+			case storage.ConflictReplace:
+				traces[i].Trace = m.Trace
+			}
+			continue
+		}
+		index[k] = len(traces)
+		traces = append(traces, storage.ProjectTrace{ProjectID: m.ProjectID, Trace: m.Trace})
+	}
+	return traces
+}
+
+// mergeTags appends new tags to existing ones, keeping first-seen order
+func mergeTags(existing, added []string) []string {
+	seen := make(map[string]bool, len(existing)+len(added))
+	merged := make([]string, 0, len(existing)+len(added))
+	for _, tag := range append(existing, added...) {
+		if !seen[tag] {
+			seen[tag] = true
+			merged = append(merged, tag)
+		}
+	}
+	return merged
+}
+
+func sleep(ctx context.Context, d time.Duration) {
+	select {
+	case <-ctx.Done():
+	case <-time.After(d):
+	}
+}
@@ -0,0 +1,140 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+// Package judge scores traces and dataset items with LLM judges in the
+// background.
+package judge
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/regrada-ai/regrada-be/internal/domain"
+)
+
+// DefaultBaseURL is the OpenAI API, used when no base URL is configured
+const DefaultBaseURL = "https://api.openai.com/v1"
+
+// maxErrorBody caps how much of a failed response is kept in the error
+const maxErrorBody = 512
+
+// CompletionRequest is a chat completion request to a judge model
+type CompletionRequest struct {
+	Model     string
+	Messages  []domain.Message
+	MaxTokens int
+}
+
+// Completion is a judge model's reply and the tokens it used
+type Completion struct {
+	Text      string
+	TokensIn  int
+	TokensOut int
+}
+
+// Client sends chat completion requests to a judge model provider
+type Client interface {
+	Complete(ctx context.Context, req CompletionRequest) (*Completion, error)
+}
+
+// Ensure OpenAIClient implements Client interface at compile time
+var _ Client = (*OpenAIClient)(nil)
+
+// OpenAIClient calls the chat completions endpoint of the OpenAI API or any
+// server compatible with it, such as a gateway or a local stub
+type OpenAIClient struct {
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
+}
+
+// NewOpenAIClient creates a client for the API at baseURL, or the OpenAI API
+// if baseURL is empty. No Authorization header is sent if apiKey is empty.
+func NewOpenAIClient(baseURL, apiKey string, timeout time.Duration) *OpenAIClient {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	return &OpenAIClient{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		apiKey:     apiKey,
+		httpClient: &http.Client{Timeout: timeout},
+	}
+}
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type chatRequest struct {
+	Model       string        `json:"model"`
+	Messages    []chatMessage `json:"messages"`
+	Temperature float64       `json:"temperature"`
+	MaxTokens   int           `json:"max_tokens,omitempty"`
+}
+
+type chatResponse struct {
+	Choices []struct {
+		Message chatMessage `json:"message"`
+	} `json:"choices"`
+	Usage struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage"`
+}
+
+// Complete sends a request at temperature 0, so repeated judgements of the
+// same input agree as far as the provider allows
+func (c *OpenAIClient) Complete(ctx context.Context, req CompletionRequest) (*Completion, error) {
+	body := chatRequest{
+		Model:     req.Model,
+		Messages:  make([]chatMessage, len(req.Messages)),
+		MaxTokens: req.MaxTokens,
+	}
+	for i, msg := range req.Messages {
+		body.Messages[i] = chatMessage{Role: msg.Role, Content: msg.Content}
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/chat/completions", bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create judge request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if c.apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("judge request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return nil, fmt.Errorf("judge request failed with status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	var chatResp chatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&chatResp); err != nil {
+		return nil, fmt.Errorf("failed to decode judge response: %w", err)
+	}
+	if len(chatResp.Choices) == 0 {
+		return nil, fmt.Errorf("judge response has no choices")
+	}
+
+	return &Completion{
+		Text:      chatResp.Choices[0].Message.Content,
+		TokensIn:  chatResp.Usage.PromptTokens,
+		TokensOut: chatResp.Usage.CompletionTokens,
+	}, nil
+}
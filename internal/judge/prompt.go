@@ -0,0 +1,188 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package judge
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/regrada-ai/regrada-be/internal/domain"
+	"github.com/regrada-ai/regrada-be/internal/storage"
+)
+
+const maxReasoningLength = 2000
+
+// Input is what a judge's prompt template can refer to, e.g.
+// {{.Input}} or {{.Output}}
+type Input struct {
+	Input        string // the last user message
+	Conversation string // every request message, one "role: content" block each
+	Output       string // a trace's response, or a dataset item's expected output
+	ToolCalls    string // a trace's tool calls as JSON, if it made any
+	Model        string // the model that produced a trace
+}
+
+// TraceInput returns the template input for a trace
+func TraceInput(trace *domain.Trace) Input {
+	input := messagesInput(trace.Request.Messages)
+	input.Output = trace.Response.AssistantText
+	input.Model = trace.Model
+	if len(trace.Response.ToolCalls) > 0 {
+		if data, err := json.Marshal(trace.Response.ToolCalls); err == nil {
+			input.ToolCalls = string(data)
+		}
+	}
+	return input
+}
+
+// DatasetItemInput returns the template input for a dataset item
+func DatasetItemInput(item *storage.DatasetItem) Input {
+	input := messagesInput(item.Messages)
+	if expected := item.ExpectedOutput; expected != nil {
+		input.Output = expected.Text
+		if input.Output == "" && len(expected.JSON) > 0 {
+			input.Output = string(expected.JSON)
+		}
+	}
+	return input
+}
+
+func messagesInput(messages []domain.Message) Input {
+	var input Input
+	blocks := make([]string, len(messages))
+	for i, msg := range messages {
+		blocks[i] = msg.Role + ": " + msg.Content
+		if msg.Role == "user" {
+			input.Input = msg.Content
+		}
+	}
+	input.Conversation = strings.Join(blocks, "\n\n")
+	return input
+}
+
+// ParseTemplate parses a judge's prompt template, and checks that it only
+// refers to fields of Input
+func ParseTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("judge").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid prompt template: %w", err)
+	}
+	if err := tmpl.Execute(&strings.Builder{}, Input{}); err != nil {
+		return nil, fmt.Errorf("invalid prompt template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// Prompt builds the messages sent to a judge model: instructions to score on
+// the judge's scale, followed by the rendered template
+func Prompt(judge *storage.Judge, tmpl *template.Template, input Input) ([]domain.Message, error) {
+	var user strings.Builder
+	if err := tmpl.Execute(&user, input); err != nil {
+		return nil, fmt.Errorf("failed to render prompt: %w", err)
+	}
+
+	system := fmt.Sprintf("You are an impartial evaluator. Score the response described by the user on a scale from %d (worst) to %d (best). "+
+		`Reply with only a JSON object of the form {"score": <number>, "reasoning": "<one or two sentences>"}.`,
+		judge.ScaleMin, judge.ScaleMax)
+
+	return []domain.Message{
+		{Role: "system", Content: system},
+		{Role: "user", Content: user.String()},
+	}, nil
+}
+
+// InputHash identifies a judge model and prompt, so identical requests can
+// be answered from the cache
+func InputHash(model string, messages []domain.Message) string {
+	h := sha256.New()
+	h.Write([]byte(model))
+	for _, msg := range messages {
+		h.Write([]byte{0})
+		h.Write([]byte(msg.Role))
+		h.Write([]byte{0})
+		h.Write([]byte(msg.Content))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// ParseVerdict reads the JSON verdict in a judge model's reply, which may be
+// wrapped in prose or a code fence
+func ParseVerdict(text string, scaleMin, scaleMax int) (*storage.JudgeVerdict, error) {
+	start, end := strings.Index(text, "{"), strings.LastIndex(text, "}")
+	if start < 0 || end < start {
+		return nil, errors.New("judge reply has no JSON verdict")
+	}
+
+	var reply struct {
+		Score     json.Number `json:"score"`
+		Reasoning string      `json:"reasoning"`
+	}
+	if err := json.Unmarshal([]byte(text[start:end+1]), &reply); err != nil {
+		return nil, fmt.Errorf("judge reply has no valid JSON verdict: %w", err)
+	}
+	score, err := strconv.ParseFloat(string(reply.Score), 64)
+	if err != nil || math.IsNaN(score) {
+		return nil, errors.New("judge verdict has no numeric score")
+	}
+	if score < float64(scaleMin) || score > float64(scaleMax) {
+		return nil, fmt.Errorf("judge score %g is outside the scale %d to %d", score, scaleMin, scaleMax)
+	}
+
+	reasoning := reply.Reasoning
+	if len(reasoning) > maxReasoningLength {
+		reasoning = strings.ToValidUTF8(reasoning[:maxReasoningLength], "") + "..."
+	}
+	return &storage.JudgeVerdict{Score: score, Reasoning: reasoning}, nil
+}
+
+// Price is what a judge model costs per million tokens, in USD
+type Price struct {
+	Input  float64
+	Output float64
+}
+
+// Prices maps judge model names to their prices
+type Prices map[string]Price
+
+// ParsePrices parses a comma-separated list of model=input:output prices per
+// million tokens, e.g. "gpt-4o-mini=0.15:0.60,gpt-4o=2.50:10"
+func ParsePrices(s string) (Prices, error) {
+	prices := Prices{}
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		model, rates, ok := strings.Cut(entry, "=")
+		input, output, ok2 := strings.Cut(rates, ":")
+		if !ok || !ok2 || model == "" {
+			return nil, fmt.Errorf("invalid judge price %q, want model=input:output", entry)
+		}
+		var price Price
+		var err error
+		if price.Input, err = strconv.ParseFloat(input, 64); err != nil || price.Input < 0 {
+			return nil, fmt.Errorf("invalid input price in %q", entry)
+		}
+		if price.Output, err = strconv.ParseFloat(output, 64); err != nil || price.Output < 0 {
+			return nil, fmt.Errorf("invalid output price in %q", entry)
+		}
+		prices[strings.TrimSpace(model)] = price
+	}
+	return prices, nil
+}
+
+// Cost returns the cost of a judge call in USD, or 0 if the model has no price
+func (p Prices) Cost(model string, tokensIn, tokensOut int) float64 {
+	price, ok := p[model]
+	if !ok {
+		return 0
+	}
+	return (float64(tokensIn)*price.Input + float64(tokensOut)*price.Output) / 1e6
+}
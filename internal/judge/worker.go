@@ -0,0 +1,370 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package judge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/regrada-ai/regrada-be/internal/domain"
+	"github.com/regrada-ai/regrada-be/internal/storage"
+)
+
+const (
+	// staleAfter is how long a running judge run may go without progress
+	// before another instance takes it over
+	staleAfter = 10 * time.Minute
+
+	// heartbeatInterval is how often a running judge run saves its progress
+	heartbeatInterval = time.Minute
+)
+
+// errEnoughItems stops reading traces once a run has its maximum
+var errEnoughItems = errors.New("run has enough items")
+
+// Config configures the judge worker
+type Config struct {
+	Poll        time.Duration // how often to look for runs requested on other instances
+	Concurrency int           // judge calls in flight at once
+	BatchSize   int           // traces read from Postgres per query
+	MaxTokens   int           // cap on the tokens of each judge reply
+	Prices      Prices        // judge model prices, for cost tracking
+}
+
+// DefaultConfig returns the default judge settings
+func DefaultConfig() Config {
+	return Config{
+		Poll:        30 * time.Second,
+		Concurrency: 4,
+		BatchSize:   100,
+		MaxTokens:   512,
+		Prices:      Prices{},
+	}
+}
+
+// item is a trace or dataset item to be scored
+type item struct {
+	id    string
+	input Input
+}
+
+// Worker claims judge runs from the database and scores their traces or
+// dataset items with the judge model. Verdicts are cached by input hash, so
+// an input judged before is not paid for again.
+type Worker struct {
+	judgeRepo   storage.JudgeRepository
+	traceRepo   storage.TraceRepository
+	datasetRepo storage.DatasetRepository
+	client      Client
+	cfg         Config
+
+	wake   chan struct{}
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewWorker(
+	judgeRepo storage.JudgeRepository,
+	traceRepo storage.TraceRepository,
+	datasetRepo storage.DatasetRepository,
+	client Client,
+	cfg Config,
+) *Worker {
+	return &Worker{
+		judgeRepo:   judgeRepo,
+		traceRepo:   traceRepo,
+		datasetRepo: datasetRepo,
+		client:      client,
+		cfg:         cfg,
+		wake:        make(chan struct{}, 1),
+	}
+}
+
+// Start processes judge runs until Stop is called
+func (w *Worker) Start(ctx context.Context) {
+	ctx, w.cancel = context.WithCancel(ctx)
+
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+
+		ticker := time.NewTicker(w.cfg.Poll)
+		defer ticker.Stop()
+
+		for {
+			run, err := w.judgeRepo.ClaimRun(ctx, staleAfter)
+			if err != nil && ctx.Err() == nil {
+				log.Printf("Failed to claim judge run: %v", err)
+			}
+
+			if run != nil {
+				w.run(ctx, run)
+				continue
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-w.wake:
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop cancels the judge worker and waits for it to exit. An interrupted run
+// is resumed by another instance once it goes stale.
+func (w *Worker) Stop() {
+	if w.cancel == nil {
+		return
+	}
+	w.cancel()
+	w.wg.Wait()
+}
+
+// Request records a judge run and wakes the worker
+func (w *Worker) Request(ctx context.Context, run *storage.JudgeRun) error {
+	if err := w.judgeRepo.CreateRun(ctx, run); err != nil {
+		return err
+	}
+
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// run scores the run's items that have no score yet and records the outcome
+func (w *Worker) run(ctx context.Context, run *storage.JudgeRun) {
+	start := time.Now()
+
+	judge, err := w.judgeRepo.GetJudge(ctx, run.ProjectID, run.JudgeID)
+	if err != nil {
+		w.finish(ctx, run, fmt.Errorf("failed to load judge: %w", err))
+		return
+	}
+	tmpl, err := ParseTemplate(judge.PromptTemplate)
+	if err != nil {
+		w.finish(ctx, run, err)
+		return
+	}
+	scored, err := w.judgeRepo.ScoredTargets(ctx, run.ID)
+	if err != nil {
+		w.finish(ctx, run, err)
+		return
+	}
+
+	lastSaved := time.Now()
+	process := func(items []item) {
+		w.scoreAll(ctx, run, judge, tmpl, items, scored)
+		if time.Since(lastSaved) < heartbeatInterval {
+			return
+		}
+		lastSaved = time.Now()
+		if err := w.judgeRepo.UpdateRun(ctx, run); err != nil && ctx.Err() == nil {
+			log.Printf("Failed to save progress of judge run %s: %v", run.ID, err)
+		}
+	}
+
+	switch run.Target {
+	case storage.JudgeTraces:
+		err = w.judgeTraces(ctx, run, process)
+	case storage.JudgeDataset:
+		err = w.judgeDataset(ctx, run, process)
+	default:
+		err = fmt.Errorf("unsupported judge target %q", run.Target)
+	}
+	if err == nil {
+		err = ctx.Err()
+	}
+
+	w.finish(ctx, run, err)
+	if err == nil {
+		log.Printf("✓ Judge run %s finished: %d scored, %d errors, $%.4f in %s", run.ID, run.Scored, run.Errored, run.CostUSD, time.Since(start).Round(time.Millisecond))
+	}
+}
+
+// judgeTraces passes the traces matching the run's filter, oldest first, to
+// process in batches until the run has MaxItems
+func (w *Worker) judgeTraces(ctx context.Context, run *storage.JudgeRun, process func([]item)) error {
+	var filter storage.TraceFilter
+	if run.TraceFilter != nil {
+		filter = *run.TraceFilter
+	}
+
+	remaining := run.MaxItems
+	err := w.traceRepo.Each(ctx, run.ProjectID, filter, w.cfg.BatchSize, func(batch []*domain.Trace) error {
+		if len(batch) > remaining {
+			batch = batch[:remaining]
+		}
+		items := make([]item, len(batch))
+		for i, trace := range batch {
+			items[i] = item{id: trace.TraceID, input: TraceInput(trace)}
+		}
+		process(items)
+
+		remaining -= len(batch)
+		if remaining == 0 {
+			return errEnoughItems
+		}
+		return ctx.Err()
+	})
+	if errors.Is(err, errEnoughItems) {
+		return nil
+	}
+	return err
+}
+
+// judgeDataset passes the items of the run's dataset version to process. A
+// run without a version is pinned to the current one, so a resumed run
+// scores the same items.
+func (w *Worker) judgeDataset(ctx context.Context, run *storage.JudgeRun, process func([]item)) error {
+	if run.DatasetVersion == 0 {
+		dataset, err := w.datasetRepo.Get(ctx, run.ProjectID, run.DatasetID)
+		if err != nil {
+			return fmt.Errorf("failed to load dataset: %w", err)
+		}
+		run.DatasetVersion = dataset.Version
+		if err := w.judgeRepo.UpdateRun(ctx, run); err != nil {
+			return err
+		}
+	}
+
+	datasetItems, err := w.datasetRepo.ListItems(ctx, run.ProjectID, run.DatasetID, run.DatasetVersion)
+	if err != nil {
+		return fmt.Errorf("failed to load dataset items: %w", err)
+	}
+	if len(datasetItems) > run.MaxItems {
+		datasetItems = datasetItems[:run.MaxItems]
+	}
+
+	for start := 0; start < len(datasetItems) && ctx.Err() == nil; start += w.cfg.BatchSize {
+		end := min(start+w.cfg.BatchSize, len(datasetItems))
+		items := make([]item, 0, end-start)
+		for _, datasetItem := range datasetItems[start:end] {
+			items = append(items, item{id: datasetItem.ID, input: DatasetItemInput(datasetItem)})
+		}
+		process(items)
+	}
+	return nil
+}
+
+// scoreAll scores the items not yet scored, Concurrency at a time, and saves
+// their scores
+func (w *Worker) scoreAll(ctx context.Context, run *storage.JudgeRun, judge *storage.Judge, tmpl *template.Template, items []item, scored map[string]bool) {
+	sem := make(chan struct{}, max(w.cfg.Concurrency, 1))
+	var wg sync.WaitGroup
+	for _, it := range items {
+		if scored[it.id] {
+			continue
+		}
+		scored[it.id] = true
+
+		select {
+		case <-ctx.Done():
+		case sem <- struct{}{}:
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func(it item) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			score := w.score(ctx, judge, tmpl, it)
+			// An item interrupted by shutdown is left unscored for the resumed run
+			if ctx.Err() != nil {
+				return
+			}
+			score.RunID = run.ID
+			if err := w.judgeRepo.SaveScore(ctx, score); err != nil && ctx.Err() == nil {
+				log.Printf("Failed to save judge score of %s in run %s: %v", it.id, run.ID, err)
+			}
+		}(it)
+	}
+	wg.Wait()
+}
+
+// score judges one item, from the cache if the same prompt was judged before
+func (w *Worker) score(ctx context.Context, judge *storage.Judge, tmpl *template.Template, it item) *storage.JudgeScore {
+	score := &storage.JudgeScore{
+		ProjectID: judge.ProjectID,
+		JudgeID:   judge.ID,
+		TargetID:  it.id,
+	}
+
+	messages, err := Prompt(judge, tmpl, it.input)
+	if err != nil {
+		score.Error = err.Error()
+		return score
+	}
+	hash := InputHash(judge.Model, messages)
+
+	verdict, err := w.judgeRepo.GetCached(ctx, judge.ProjectID, hash)
+	if err != nil {
+		log.Printf("Failed to read judge cache: %v", err)
+	}
+	if verdict != nil {
+		score.Cached = true
+	} else {
+		completion, err := w.client.Complete(ctx, CompletionRequest{
+			Model:     judge.Model,
+			Messages:  messages,
+			MaxTokens: w.cfg.MaxTokens,
+		})
+		if err != nil {
+			score.Error = err.Error()
+			return score
+		}
+		score.TokensIn = completion.TokensIn
+		score.TokensOut = completion.TokensOut
+		score.CostUSD = w.cfg.Prices.Cost(judge.Model, completion.TokensIn, completion.TokensOut)
+
+		verdict, err = ParseVerdict(completion.Text, judge.ScaleMin, judge.ScaleMax)
+		if err != nil {
+			score.Error = err.Error()
+			return score
+		}
+		if err := w.judgeRepo.PutCached(ctx, judge.ProjectID, hash, verdict); err != nil && ctx.Err() == nil {
+			log.Printf("Failed to write judge cache: %v", err)
+		}
+	}
+
+	score.Score = &verdict.Score
+	score.Reasoning = verdict.Reasoning
+	if judge.PassThreshold != nil {
+		passed := verdict.Score >= *judge.PassThreshold
+		score.Passed = &passed
+	}
+	return score
+}
+
+// finish records a run's outcome. A run interrupted by shutdown is left
+// running so another instance resumes it.
+func (w *Worker) finish(ctx context.Context, run *storage.JudgeRun, err error) {
+	if ctx.Err() != nil {
+		return
+	}
+
+	now := time.Now()
+	run.CompletedAt = &now
+	if err != nil {
+		log.Printf("Judge run %s failed: %v", run.ID, err)
+		run.Status = storage.JudgeRunFailed
+		run.Error = err.Error()
+	} else {
+		run.Status = storage.JudgeRunCompleted
+	}
+
+	if err := w.judgeRepo.UpdateRun(ctx, run); err != nil {
+		log.Printf("Failed to save judge run %s: %v", run.ID, err)
+	}
+}
@@ -0,0 +1,86 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package live
+
+import (
+	"encoding/json"
+	"slices"
+	"time"
+
+	"github.com/regrada-ai/regrada-be/internal/domain"
+	"github.com/regrada-ai/regrada-be/internal/storage"
+)
+
+// TestRunEvent reports a stored or replaced test run
+type TestRunEvent struct {
+	RunID       string    `json:"run_id"`
+	RunStatus   string    `json:"run_status"`
+	Timestamp   time.Time `json:"timestamp"`
+	GitSHA      string    `json:"git_sha"`
+	GitBranch   string    `json:"git_branch,omitempty"`
+	TotalCases  int       `json:"total_cases"`
+	PassedCases int       `json:"passed_cases"`
+	WarnedCases int       `json:"warned_cases"`
+	FailedCases int       `json:"failed_cases"`
+	// GatePassed is the quality gate verdict, if the project has a gate
+	GatePassed *bool `json:"gate_passed,omitempty"`
+}
+
+// TraceEvents returns an event per trace
+func TraceEvents(traces []domain.Trace) []Event {
+	events := make([]Event, 0, len(traces))
+	for i := range traces {
+		data, err := json.Marshal(&traces[i])
+		if err != nil {
+			continue
+		}
+		events = append(events, Event{Type: EventTrace, Data: data})
+	}
+	return events
+}
+
+// NewTestRunEvent returns the event for a stored test run
+func NewTestRunEvent(run *domain.TestRun, gatePassed *bool) Event {
+	data, _ := json.Marshal(TestRunEvent{
+		RunID:       run.RunID,
+		RunStatus:   run.Status,
+		Timestamp:   run.Timestamp,
+		GitSHA:      run.GitSHA,
+		GitBranch:   run.GitBranch,
+		TotalCases:  run.TotalCases,
+		PassedCases: run.PassedCases,
+		WarnedCases: run.WarnedCases,
+		FailedCases: run.FailedCases,
+		GatePassed:  gatePassed,
+	})
+	return Event{Type: EventTestRun, Data: data}
+}
+
+// Streamable reports whether a trace filter can be checked against a trace
+// as it is ingested. Time ranges, annotations, and evaluations cannot.
+func Streamable(filter storage.TraceFilter) bool {
+	return filter.From == nil && filter.To == nil &&
+		filter.Rating == "" && filter.AnnotationLabel == "" && filter.ScoreCriterion == "" &&
+		filter.EvaluatorID == "" && filter.Evaluation == ""
+}
+
+// MatchTrace reports whether a trace matches the streamable fields of a
+// filter
+func MatchTrace(filter storage.TraceFilter, trace *domain.Trace) bool {
+	switch {
+	case filter.Environment != "" && trace.Environment != filter.Environment,
+		filter.Provider != "" && trace.Provider != filter.Provider,
+		filter.Model != "" && trace.Model != filter.Model,
+		filter.GitSHA != "" && trace.GitSHA != filter.GitSHA,
+		filter.GitBranch != "" && trace.GitBranch != filter.GitBranch,
+		filter.PromptName != "" && trace.PromptName != filter.PromptName,
+		filter.PromptVersion != 0 && trace.PromptVersion != filter.PromptVersion:
+		return false
+	}
+	for _, tag := range filter.Tags {
+		if !slices.Contains(trace.Tags, tag) {
+			return false
+		}
+	}
+	return true
+}
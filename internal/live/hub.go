@@ -0,0 +1,231 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+// Package live fans out newly ingested traces and test runs to streaming
+// clients on every server instance through Redis pub/sub.
+package live
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// subscriberBuffer is the events a slow client may fall behind by
+	// before further events are dropped for it
+	subscriberBuffer = 256
+
+	publishTimeout = 2 * time.Second
+)
+
+// ErrClosed is returned when subscribing to a stopped hub
+var ErrClosed = errors.New("live hub closed")
+
+// Topic is a kind of event stream
+type Topic string
+
+const (
+	TopicTraces   Topic = "traces"
+	TopicTestRuns Topic = "test-runs"
+)
+
+// Event types
+const (
+	EventTrace   = "trace"
+	EventTestRun = "test_run"
+)
+
+// Event is a message on a project's stream
+type Event struct {
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+// Hub publishes events to Redis and delivers the events of subscribed
+// projects to local subscribers. One Redis connection per instance carries
+// the channels local clients listen on.
+type Hub struct {
+	redisClient *redis.Client
+
+	mu          sync.Mutex
+	pubsub      *redis.PubSub
+	subscribers map[string]map[*Subscription]struct{} // by channel
+	closed      bool
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewHub(redisClient *redis.Client) *Hub {
+	return &Hub{
+		redisClient: redisClient,
+		subscribers: make(map[string]map[*Subscription]struct{}),
+	}
+}
+
+// Start opens the Redis subscription and delivers events until Stop is
+// called
+func (h *Hub) Start(ctx context.Context) {
+	ctx, h.cancel = context.WithCancel(ctx)
+	h.pubsub = h.redisClient.Subscribe(ctx)
+
+	h.wg.Add(1)
+	go func() {
+		defer h.wg.Done()
+		for msg := range h.pubsub.Channel() {
+			var event Event
+			if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+				log.Printf("Failed to decode live event on %s: %v", msg.Channel, err)
+				continue
+			}
+			h.deliver(msg.Channel, event)
+		}
+	}()
+}
+
+// Stop closes the Redis subscription and every subscription, ending the
+// streams of connected clients
+func (h *Hub) Stop() {
+	h.mu.Lock()
+	if h.closed || h.cancel == nil {
+		h.mu.Unlock()
+		return
+	}
+	h.closed = true
+	for _, subscriptions := range h.subscribers {
+		for subscription := range subscriptions {
+			close(subscription.events)
+		}
+	}
+	clear(h.subscribers)
+	h.mu.Unlock()
+
+	h.cancel()
+	if err := h.pubsub.Close(); err != nil {
+		log.Printf("Failed to close live subscription: %v", err)
+	}
+	h.wg.Wait()
+}
+
+// Publish sends an event to the project's subscribers on every instance.
+// Failures are logged rather than returned, so streaming never fails
+// ingestion.
+func (h *Hub) Publish(ctx context.Context, topic Topic, projectID string, events ...Event) {
+	if len(events) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), publishTimeout)
+	defer cancel()
+
+	channel := channelName(topic, projectID)
+	pipe := h.redisClient.Pipeline()
+	for _, event := range events {
+		payload, err := json.Marshal(event)
+		if err != nil {
+			log.Printf("Failed to encode live event: %v", err)
+			continue
+		}
+		pipe.Publish(ctx, channel, payload)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Printf("Failed to publish live events to %s: %v", channel, err)
+	}
+}
+
+// Subscribe starts receiving the project's events on the topic. The
+// subscription must be closed.
+func (h *Hub) Subscribe(ctx context.Context, topic Topic, projectID string) (*Subscription, error) {
+	channel := channelName(topic, projectID)
+	subscription := &Subscription{
+		hub:     h,
+		channel: channel,
+		events:  make(chan Event, subscriberBuffer),
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed || h.pubsub == nil {
+		return nil, ErrClosed
+	}
+
+	subscriptions := h.subscribers[channel]
+	if subscriptions == nil {
+		if err := h.pubsub.Subscribe(ctx, channel); err != nil {
+			return nil, err
+		}
+		subscriptions = make(map[*Subscription]struct{})
+		h.subscribers[channel] = subscriptions
+	}
+	subscriptions[subscription] = struct{}{}
+	return subscription, nil
+}
+
+func (h *Hub) deliver(channel string, event Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for subscription := range h.subscribers[channel] {
+		select {
+		case subscription.events <- event:
+		default:
+			subscription.dropped++
+		}
+	}
+}
+
+func (h *Hub) unsubscribe(subscription *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	subscriptions, ok := h.subscribers[subscription.channel]
+	if !ok {
+		return
+	}
+	if _, ok := subscriptions[subscription]; !ok {
+		return
+	}
+
+	delete(subscriptions, subscription)
+	close(subscription.events)
+	if len(subscriptions) == 0 {
+		delete(h.subscribers, subscription.channel)
+		if err := h.pubsub.Unsubscribe(context.Background(), subscription.channel); err != nil {
+			log.Printf("Failed to unsubscribe from %s: %v", subscription.channel, err)
+		}
+	}
+}
+
+// Subscription receives the events of one project and topic
+type Subscription struct {
+	hub     *Hub
+	channel string
+	events  chan Event
+	dropped int // guarded by hub.mu
+}
+
+// Events returns the channel events arrive on. It is closed when the
+// subscription or the hub is closed.
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Dropped returns the number of events dropped because the subscriber fell
+// behind
+func (s *Subscription) Dropped() int {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	return s.dropped
+}
+
+// Close stops the subscription
+func (s *Subscription) Close() {
+	s.hub.unsubscribe(s)
+}
+
+func channelName(topic Topic, projectID string) string {
+	return "live:" + string(topic) + ":" + projectID
+}
@@ -0,0 +1,4 @@
+-- Remove redaction policies
+
+DROP TRIGGER IF EXISTS update_redaction_policies_updated_at ON redaction_policies;
+DROP TABLE IF EXISTS redaction_policies;
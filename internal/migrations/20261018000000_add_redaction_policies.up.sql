@@ -0,0 +1,15 @@
+-- Per-project server-side redaction policies applied at trace ingestion
+
+CREATE TABLE IF NOT EXISTS redaction_policies (
+    project_id UUID PRIMARY KEY REFERENCES projects(id) ON DELETE CASCADE,
+    enabled BOOLEAN NOT NULL DEFAULT false,
+    mode VARCHAR(20) NOT NULL DEFAULT 'mask',
+    detectors TEXT[] NOT NULL DEFAULT '{}',
+    custom_rules JSONB NOT NULL DEFAULT '[]',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (mode IN ('mask', 'hash', 'drop'))
+);
+
+CREATE TRIGGER update_redaction_policies_updated_at BEFORE UPDATE ON redaction_policies
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
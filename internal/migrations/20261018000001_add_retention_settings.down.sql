@@ -0,0 +1,7 @@
+-- Remove trace retention overrides
+
+DROP INDEX IF EXISTS idx_traces_soft_deleted;
+DROP INDEX IF EXISTS idx_traces_project_timestamp;
+
+ALTER TABLE projects DROP COLUMN IF EXISTS retention_days;
+ALTER TABLE organizations DROP COLUMN IF EXISTS retention_days;
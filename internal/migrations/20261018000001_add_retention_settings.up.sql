@@ -0,0 +1,8 @@
+-- Trace retention overrides; NULL falls back to the organization's tier default
+
+ALTER TABLE organizations ADD COLUMN IF NOT EXISTS retention_days INTEGER CHECK (retention_days > 0);
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS retention_days INTEGER CHECK (retention_days > 0);
+
+-- Support the purge job's per-project expiry and soft-delete scans
+CREATE INDEX IF NOT EXISTS idx_traces_project_timestamp ON traces(project_id, timestamp);
+CREATE INDEX IF NOT EXISTS idx_traces_soft_deleted ON traces(project_id, deleted_at) WHERE deleted_at IS NOT NULL;
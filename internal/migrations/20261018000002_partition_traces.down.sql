@@ -0,0 +1,56 @@
+-- Convert traces back to a single table. Where a trace ID was stored with
+-- several timestamps, only the latest is kept.
+
+CREATE TABLE traces_unpartitioned (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    trace_id VARCHAR(255) NOT NULL,
+    timestamp TIMESTAMPTZ NOT NULL,
+    provider VARCHAR(50) NOT NULL,
+    model VARCHAR(255) NOT NULL,
+    environment VARCHAR(50),
+    git_sha VARCHAR(40),
+    git_branch VARCHAR(255),
+    request_data JSONB NOT NULL,
+    response_data JSONB NOT NULL,
+    latency_ms INTEGER,
+    tokens_in INTEGER,
+    tokens_out INTEGER,
+    redaction_applied TEXT[],
+    tags TEXT[],
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMPTZ,
+    UNIQUE(project_id, trace_id)
+);
+
+INSERT INTO traces_unpartitioned (
+    id, project_id, trace_id, timestamp, provider, model, environment, git_sha, git_branch,
+    request_data, response_data, latency_ms, tokens_in, tokens_out, redaction_applied, tags,
+    created_at, deleted_at
+)
+SELECT DISTINCT ON (project_id, trace_id)
+    id, project_id, trace_id, timestamp, provider, model, environment, git_sha, git_branch,
+    request_data, response_data, latency_ms, tokens_in, tokens_out, redaction_applied, tags,
+    created_at, deleted_at
+FROM traces
+ORDER BY project_id, trace_id, timestamp DESC;
+
+DROP TABLE traces;
+DROP FUNCTION IF EXISTS ensure_traces_partition(TIMESTAMPTZ);
+
+ALTER TABLE traces_unpartitioned RENAME TO traces;
+ALTER TABLE traces RENAME CONSTRAINT traces_unpartitioned_pkey TO traces_pkey;
+ALTER TABLE traces RENAME CONSTRAINT traces_unpartitioned_project_id_trace_id_key TO traces_project_id_trace_id_key;
+ALTER TABLE traces RENAME CONSTRAINT traces_unpartitioned_project_id_fkey TO traces_project_id_fkey;
+
+CREATE INDEX idx_traces_project_id ON traces(project_id);
+CREATE INDEX idx_traces_timestamp ON traces(timestamp DESC);
+CREATE INDEX idx_traces_git_sha ON traces(git_sha) WHERE git_sha IS NOT NULL;
+CREATE INDEX idx_traces_provider_model ON traces(provider, model);
+CREATE INDEX idx_traces_environment ON traces(environment) WHERE environment IS NOT NULL;
+CREATE INDEX idx_traces_tags ON traces USING GIN(tags);
+CREATE INDEX idx_traces_request_data ON traces USING GIN(request_data);
+CREATE INDEX idx_traces_response_data ON traces USING GIN(response_data);
+CREATE INDEX idx_traces_deleted_at ON traces(deleted_at) WHERE deleted_at IS NULL;
+CREATE INDEX idx_traces_project_timestamp ON traces(project_id, timestamp);
+CREATE INDEX idx_traces_soft_deleted ON traces(project_id, deleted_at) WHERE deleted_at IS NOT NULL;
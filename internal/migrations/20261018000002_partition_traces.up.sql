@@ -0,0 +1,116 @@
+-- Convert traces to native range partitioning by month on timestamp.
+-- Unique constraints on a partitioned table must include the partition key,
+-- so the primary key becomes (id, timestamp) and trace IDs are unique per
+-- (project_id, trace_id, timestamp).
+
+-- Move the existing table aside and free its constraint and index names
+ALTER TABLE traces RENAME TO traces_unpartitioned;
+ALTER TABLE traces_unpartitioned RENAME CONSTRAINT traces_pkey TO traces_unpartitioned_pkey;
+ALTER TABLE traces_unpartitioned RENAME CONSTRAINT traces_project_id_trace_id_key TO traces_unpartitioned_project_id_trace_id_key;
+ALTER TABLE traces_unpartitioned RENAME CONSTRAINT traces_project_id_fkey TO traces_unpartitioned_project_id_fkey;
+
+DROP INDEX IF EXISTS idx_traces_project_id;
+DROP INDEX IF EXISTS idx_traces_timestamp;
+DROP INDEX IF EXISTS idx_traces_git_sha;
+DROP INDEX IF EXISTS idx_traces_provider_model;
+DROP INDEX IF EXISTS idx_traces_environment;
+DROP INDEX IF EXISTS idx_traces_tags;
+DROP INDEX IF EXISTS idx_traces_request_data;
+DROP INDEX IF EXISTS idx_traces_response_data;
+DROP INDEX IF EXISTS idx_traces_deleted_at;
+DROP INDEX IF EXISTS idx_traces_project_timestamp;
+DROP INDEX IF EXISTS idx_traces_soft_deleted;
+
+CREATE TABLE traces (
+    id UUID NOT NULL DEFAULT gen_random_uuid(),
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    trace_id VARCHAR(255) NOT NULL,
+    timestamp TIMESTAMPTZ NOT NULL,
+    provider VARCHAR(50) NOT NULL,
+    model VARCHAR(255) NOT NULL,
+    environment VARCHAR(50),
+    git_sha VARCHAR(40),
+    git_branch VARCHAR(255),
+    request_data JSONB NOT NULL,
+    response_data JSONB NOT NULL,
+    latency_ms INTEGER,
+    tokens_in INTEGER,
+    tokens_out INTEGER,
+    redaction_applied TEXT[],
+    tags TEXT[],
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMPTZ,
+    PRIMARY KEY (id, timestamp),
+    UNIQUE (project_id, trace_id, timestamp)
+) PARTITION BY RANGE (timestamp);
+
+-- Catches traces for months that have no partition yet
+CREATE TABLE traces_default PARTITION OF traces DEFAULT;
+
+-- ensure_traces_partition creates the partition (traces_pYYYYMM) for the UTC
+-- month containing month_start, moving any matching rows out of the default
+-- partition first. It returns false if the partition already exists.
+CREATE OR REPLACE FUNCTION ensure_traces_partition(month_start TIMESTAMPTZ) RETURNS BOOLEAN AS $$
+DECLARE
+    range_start TIMESTAMPTZ := date_trunc('month', month_start AT TIME ZONE 'UTC') AT TIME ZONE 'UTC';
+    range_end TIMESTAMPTZ := (date_trunc('month', month_start AT TIME ZONE 'UTC') + INTERVAL '1 month') AT TIME ZONE 'UTC';
+    partition_name TEXT := 'traces_p' || to_char(month_start AT TIME ZONE 'UTC', 'YYYYMM');
+BEGIN
+    -- Serialize concurrent callers (e.g. several API instances)
+    PERFORM pg_advisory_xact_lock(hashtext('ensure_traces_partition'));
+
+    IF to_regclass(partition_name) IS NOT NULL THEN
+        RETURN FALSE;
+    END IF;
+
+    EXECUTE format('CREATE TABLE %I (LIKE traces INCLUDING DEFAULTS INCLUDING CONSTRAINTS)', partition_name);
+    EXECUTE format(
+        'WITH moved AS (DELETE FROM traces_default WHERE timestamp >= %L AND timestamp < %L RETURNING *) INSERT INTO %I SELECT * FROM moved',
+        range_start, range_end, partition_name
+    );
+    EXECUTE format('ALTER TABLE traces ATTACH PARTITION %I FOR VALUES FROM (%L) TO (%L)', partition_name, range_start, range_end);
+
+    RETURN TRUE;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Partitions for every month with existing data, plus the next three months
+DO $$
+DECLARE
+    month TIMESTAMPTZ;
+BEGIN
+    FOR month IN
+        SELECT DISTINCT date_trunc('month', timestamp AT TIME ZONE 'UTC') AT TIME ZONE 'UTC' FROM traces_unpartitioned
+        UNION
+        SELECT (date_trunc('month', NOW() AT TIME ZONE 'UTC') + make_interval(months => n)) AT TIME ZONE 'UTC' FROM generate_series(0, 3) AS n
+    LOOP
+        PERFORM ensure_traces_partition(month);
+    END LOOP;
+END $$;
+
+INSERT INTO traces (
+    id, project_id, trace_id, timestamp, provider, model, environment, git_sha, git_branch,
+    request_data, response_data, latency_ms, tokens_in, tokens_out, redaction_applied, tags,
+    created_at, deleted_at
+)
+SELECT
+    id, project_id, trace_id, timestamp, provider, model, environment, git_sha, git_branch,
+    request_data, response_data, latency_ms, tokens_in, tokens_out, redaction_applied, tags,
+    created_at, deleted_at
+FROM traces_unpartitioned;
+
+DROP TABLE traces_unpartitioned;
+
+-- Indexes are created on the parent and cascade to every partition
+CREATE INDEX idx_traces_project_id ON traces(project_id);
+CREATE INDEX idx_traces_timestamp ON traces(timestamp DESC);
+CREATE INDEX idx_traces_git_sha ON traces(git_sha) WHERE git_sha IS NOT NULL;
+CREATE INDEX idx_traces_provider_model ON traces(provider, model);
+CREATE INDEX idx_traces_environment ON traces(environment) WHERE environment IS NOT NULL;
+CREATE INDEX idx_traces_tags ON traces USING GIN(tags);
+CREATE INDEX idx_traces_request_data ON traces USING GIN(request_data);
+CREATE INDEX idx_traces_response_data ON traces USING GIN(response_data);
+CREATE INDEX idx_traces_deleted_at ON traces(deleted_at) WHERE deleted_at IS NULL;
+CREATE INDEX idx_traces_project_timestamp ON traces(project_id, timestamp);
+CREATE INDEX idx_traces_soft_deleted ON traces(project_id, deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX idx_traces_project_trace_id ON traces(project_id, trace_id);
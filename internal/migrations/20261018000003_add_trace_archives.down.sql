@@ -0,0 +1,6 @@
+-- Remove cold trace archives. Archive files in object storage are not deleted.
+
+DROP TABLE IF EXISTS trace_archive_restores;
+DROP TABLE IF EXISTS trace_archives;
+
+ALTER TABLE projects DROP COLUMN IF EXISTS archive_after_days;
@@ -0,0 +1,49 @@
+-- Cold archive of old traces to object storage
+
+-- Traces older than this many days are moved to archive files; NULL disables archiving
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS archive_after_days INTEGER CHECK (archive_after_days > 0);
+
+-- Manifest of archive files. A file holds traces with timestamps in
+-- [period_start, period_end].
+CREATE TABLE IF NOT EXISTS trace_archives (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    storage_key TEXT NOT NULL UNIQUE,
+    format VARCHAR(20) NOT NULL,
+    period_start TIMESTAMPTZ NOT NULL,
+    period_end TIMESTAMPTZ NOT NULL,
+    trace_count INTEGER NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    sha256 VARCHAR(64) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (period_start <= period_end)
+);
+
+CREATE INDEX IF NOT EXISTS idx_trace_archives_project_period ON trace_archives(project_id, period_start, period_end);
+
+-- Requests to load an archived time range back into traces
+CREATE TABLE IF NOT EXISTS trace_archive_restores (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    requested_by VARCHAR(255),
+    range_start TIMESTAMPTZ NOT NULL,
+    range_end TIMESTAMPTZ NOT NULL,
+    keep_days INTEGER NOT NULL CHECK (keep_days > 0),
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    archives_total INTEGER NOT NULL DEFAULT 0,
+    archives_restored INTEGER NOT NULL DEFAULT 0,
+    traces_restored BIGINT NOT NULL DEFAULT 0,
+    error TEXT,
+    expires_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    completed_at TIMESTAMPTZ,
+    CHECK (range_start < range_end),
+    CHECK (status IN ('pending', 'running', 'completed', 'failed'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_trace_archive_restores_project ON trace_archive_restores(project_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_trace_archive_restores_open ON trace_archive_restores(status) WHERE status IN ('pending', 'running');
+
+CREATE TRIGGER update_trace_archive_restores_updated_at BEFORE UPDATE ON trace_archive_restores
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
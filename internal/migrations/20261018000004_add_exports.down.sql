@@ -0,0 +1,3 @@
+-- Remove export jobs. Export files in object storage are not deleted.
+
+DROP TABLE IF EXISTS exports;
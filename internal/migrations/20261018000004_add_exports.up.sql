@@ -0,0 +1,30 @@
+-- Asynchronous exports of traces and test runs to file storage
+
+CREATE TABLE IF NOT EXISTS exports (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    requested_by VARCHAR(255),
+    notify_email VARCHAR(255),
+    resource VARCHAR(20) NOT NULL,
+    format VARCHAR(20) NOT NULL,
+    filters JSONB NOT NULL DEFAULT '{}',
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    row_count BIGINT NOT NULL DEFAULT 0,
+    size_bytes BIGINT NOT NULL DEFAULT 0,
+    storage_key TEXT,
+    error TEXT,
+    expires_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    completed_at TIMESTAMPTZ,
+    CHECK (resource IN ('traces', 'test_runs')),
+    CHECK (format IN ('jsonl', 'csv', 'parquet')),
+    CHECK (status IN ('pending', 'running', 'completed', 'failed', 'expired'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_exports_project ON exports(project_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_exports_open ON exports(status) WHERE status IN ('pending', 'running');
+CREATE INDEX IF NOT EXISTS idx_exports_expires_at ON exports(expires_at) WHERE status = 'completed';
+
+CREATE TRIGGER update_exports_updated_at BEFORE UPDATE ON exports
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
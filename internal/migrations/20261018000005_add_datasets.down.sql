@@ -0,0 +1,4 @@
+-- Remove datasets and all of their versions
+
+DROP TABLE IF EXISTS dataset_items;
+DROP TABLE IF EXISTS datasets;
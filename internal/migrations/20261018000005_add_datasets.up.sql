@@ -0,0 +1,40 @@
+-- Datasets of test case inputs built from production traces. Item rows are
+-- never updated in place: each change to a dataset bumps its version, closes
+-- the affected rows (removed_version), and inserts new ones (added_version),
+-- so any earlier version can still be read.
+
+CREATE TABLE IF NOT EXISTS datasets (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    version INTEGER NOT NULL DEFAULT 1,
+    created_by VARCHAR(255),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (project_id, name)
+);
+
+CREATE TRIGGER update_datasets_updated_at BEFORE UPDATE ON datasets
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TABLE IF NOT EXISTS dataset_items (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    dataset_id UUID NOT NULL REFERENCES datasets(id) ON DELETE CASCADE,
+    item_id UUID NOT NULL DEFAULT gen_random_uuid(),
+    source_trace_id VARCHAR(255),
+    messages JSONB NOT NULL DEFAULT '[]',
+    params JSONB,
+    expected_output JSONB,
+    tags TEXT[],
+    added_version INTEGER NOT NULL,
+    removed_version INTEGER,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (removed_version IS NULL OR removed_version > added_version)
+);
+
+CREATE INDEX IF NOT EXISTS idx_dataset_items_dataset ON dataset_items(dataset_id, added_version);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_dataset_items_current ON dataset_items(dataset_id, item_id)
+    WHERE removed_version IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_dataset_items_current_trace ON dataset_items(dataset_id, source_trace_id)
+    WHERE removed_version IS NULL AND source_trace_id IS NOT NULL;
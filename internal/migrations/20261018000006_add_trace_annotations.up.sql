@@ -0,0 +1,28 @@
+-- Human feedback on traces: ratings, scores on named criteria, comments, and
+-- labels, from org members or from end users through API keys. Traces are
+-- referenced by their trace_id, since the partitioned traces table cannot be
+-- the target of a foreign key on that column alone.
+
+CREATE TABLE IF NOT EXISTS trace_annotations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    trace_id VARCHAR(255) NOT NULL,
+    source VARCHAR(20) NOT NULL,
+    author_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    end_user_id VARCHAR(255),
+    rating VARCHAR(4),
+    scores JSONB NOT NULL DEFAULT '{}',
+    comment TEXT,
+    labels TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (source IN ('member', 'end_user')),
+    CHECK (rating IN ('up', 'down'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_trace_annotations_trace ON trace_annotations(project_id, trace_id);
+CREATE INDEX IF NOT EXISTS idx_trace_annotations_created_at ON trace_annotations(project_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_trace_annotations_labels ON trace_annotations USING GIN(labels);
+
+CREATE TRIGGER update_trace_annotations_updated_at BEFORE UPDATE ON trace_annotations
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
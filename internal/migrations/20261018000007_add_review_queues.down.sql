@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_test_runs_project_created_at;
+DROP INDEX IF EXISTS idx_traces_project_created_at;
+DROP TABLE IF EXISTS review_items;
+DROP TABLE IF EXISTS review_queues;
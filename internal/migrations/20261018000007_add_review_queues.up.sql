@@ -0,0 +1,56 @@
+-- Human review queues. Rules on each queue sample traces and pick up failed
+-- test cases; org members claim items and score them against the queue's
+-- rubric. populated_through is how far the sampler has scanned.
+
+CREATE TABLE IF NOT EXISTS review_queues (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    rules JSONB NOT NULL DEFAULT '{}',
+    rubric JSONB NOT NULL DEFAULT '[]',
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    populated_through TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (project_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS review_items (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    queue_id UUID NOT NULL REFERENCES review_queues(id) ON DELETE CASCADE,
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    kind VARCHAR(20) NOT NULL,
+    trace_id VARCHAR(255),
+    run_id VARCHAR(255),
+    case_id VARCHAR(255),
+    reason VARCHAR(20) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    assignee_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    reviewer_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    claimed_at TIMESTAMPTZ,
+    scores JSONB NOT NULL DEFAULT '{}',
+    comment TEXT,
+    completed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (kind IN ('trace', 'test_case')),
+    CHECK (status IN ('pending', 'claimed', 'completed'))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_review_items_trace ON review_items(queue_id, trace_id)
+    WHERE kind = 'trace';
+CREATE UNIQUE INDEX IF NOT EXISTS idx_review_items_test_case ON review_items(queue_id, run_id, case_id)
+    WHERE kind = 'test_case';
+CREATE INDEX IF NOT EXISTS idx_review_items_queue_status ON review_items(queue_id, status, created_at);
+CREATE INDEX IF NOT EXISTS idx_review_items_assignee ON review_items(assignee_id) WHERE assignee_id IS NOT NULL;
+
+-- The sampler scans traces and test runs by insertion time
+CREATE INDEX IF NOT EXISTS idx_traces_project_created_at ON traces(project_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_test_runs_project_created_at ON test_runs(project_id, created_at);
+
+CREATE TRIGGER update_review_queues_updated_at BEFORE UPDATE ON review_queues
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TRIGGER update_review_items_updated_at BEFORE UPDATE ON review_items
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
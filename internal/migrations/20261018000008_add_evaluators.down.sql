@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS evaluation_cursors;
+DROP TABLE IF EXISTS evaluation_results;
+DROP TABLE IF EXISTS evaluators;
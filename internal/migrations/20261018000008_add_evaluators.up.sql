@@ -0,0 +1,43 @@
+-- Server-side evaluators: per-project checks run on traces after ingestion.
+-- The evaluation worker walks each project's traces in insertion order from
+-- its cursor and stores one result per trace and evaluator.
+
+CREATE TABLE IF NOT EXISTS evaluators (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    type VARCHAR(50) NOT NULL,
+    config JSONB NOT NULL DEFAULT '{}',
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (project_id, name)
+);
+
+CREATE INDEX IF NOT EXISTS idx_evaluators_enabled ON evaluators(project_id) WHERE enabled;
+
+CREATE TABLE IF NOT EXISTS evaluation_results (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    trace_id VARCHAR(255) NOT NULL,
+    evaluator_id UUID NOT NULL REFERENCES evaluators(id) ON DELETE CASCADE,
+    passed BOOLEAN NOT NULL,
+    message TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (evaluator_id, trace_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_evaluation_results_trace ON evaluation_results(project_id, trace_id);
+CREATE INDEX IF NOT EXISTS idx_evaluation_results_created_at ON evaluation_results(project_id, created_at);
+
+-- Where the evaluation worker has got to in each project's traces, by
+-- (created_at, id) of the last trace evaluated
+CREATE TABLE IF NOT EXISTS evaluation_cursors (
+    project_id UUID PRIMARY KEY REFERENCES projects(id) ON DELETE CASCADE,
+    evaluated_through TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_trace_row UUID
+);
+
+CREATE TRIGGER update_evaluators_updated_at BEFORE UPDATE ON evaluators
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
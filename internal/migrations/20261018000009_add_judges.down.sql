@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS judge_cache;
+DROP TABLE IF EXISTS judge_scores;
+DROP TABLE IF EXISTS judge_runs;
+DROP TABLE IF EXISTS judges;
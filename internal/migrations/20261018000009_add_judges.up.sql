@@ -0,0 +1,88 @@
+-- LLM-as-judge evaluators: a prompt template sent to a judge model, which
+-- scores each trace or dataset item of a run on the judge's scale. Judge
+-- token usage and cost are recorded here, apart from the customer's traces.
+
+CREATE TABLE IF NOT EXISTS judges (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    prompt_template TEXT NOT NULL,
+    model VARCHAR(255) NOT NULL,
+    scale_min INTEGER NOT NULL DEFAULT 1,
+    scale_max INTEGER NOT NULL DEFAULT 5,
+    pass_threshold DOUBLE PRECISION,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (project_id, name),
+    CHECK (scale_min < scale_max)
+);
+
+CREATE TRIGGER update_judges_updated_at BEFORE UPDATE ON judges
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TABLE IF NOT EXISTS judge_runs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    judge_id UUID NOT NULL REFERENCES judges(id) ON DELETE CASCADE,
+    requested_by VARCHAR(255),
+    target VARCHAR(20) NOT NULL,
+    filters JSONB NOT NULL DEFAULT '{}',
+    dataset_id UUID REFERENCES datasets(id) ON DELETE CASCADE,
+    dataset_version INTEGER,
+    max_items INTEGER NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    scored INTEGER NOT NULL DEFAULT 0,
+    errored INTEGER NOT NULL DEFAULT 0,
+    cached INTEGER NOT NULL DEFAULT 0,
+    passed INTEGER NOT NULL DEFAULT 0,
+    mean_score DOUBLE PRECISION,
+    tokens_in BIGINT NOT NULL DEFAULT 0,
+    tokens_out BIGINT NOT NULL DEFAULT 0,
+    cost_usd DOUBLE PRECISION NOT NULL DEFAULT 0,
+    error TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    completed_at TIMESTAMPTZ,
+    CHECK (target IN ('traces', 'dataset')),
+    CHECK (status IN ('pending', 'running', 'completed', 'failed'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_judge_runs_judge ON judge_runs(judge_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_judge_runs_open ON judge_runs(status) WHERE status IN ('pending', 'running');
+
+CREATE TRIGGER update_judge_runs_updated_at BEFORE UPDATE ON judge_runs
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- One score per item of a run. Items already scored are skipped when an
+-- interrupted run is resumed.
+CREATE TABLE IF NOT EXISTS judge_scores (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    run_id UUID NOT NULL REFERENCES judge_runs(id) ON DELETE CASCADE,
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    judge_id UUID NOT NULL REFERENCES judges(id) ON DELETE CASCADE,
+    target_id VARCHAR(255) NOT NULL,
+    score DOUBLE PRECISION,
+    passed BOOLEAN,
+    reasoning TEXT,
+    error TEXT,
+    cached BOOLEAN NOT NULL DEFAULT FALSE,
+    tokens_in INTEGER NOT NULL DEFAULT 0,
+    tokens_out INTEGER NOT NULL DEFAULT 0,
+    cost_usd DOUBLE PRECISION NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (run_id, target_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_judge_scores_project ON judge_scores(project_id, created_at);
+
+-- Judge responses by a hash of the model and prompt, so identical inputs are
+-- not paid for twice
+CREATE TABLE IF NOT EXISTS judge_cache (
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    input_hash VARCHAR(64) NOT NULL,
+    score DOUBLE PRECISION NOT NULL,
+    reasoning TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (project_id, input_hash)
+);
@@ -0,0 +1,30 @@
+-- Server-managed registry of the policies test run violations refer to.
+-- Organization-wide policies have no project; a project policy with the same
+-- policy_id takes precedence within its project.
+
+CREATE TABLE IF NOT EXISTS policies (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    project_id UUID REFERENCES projects(id) ON DELETE CASCADE,
+    policy_id VARCHAR(128) NOT NULL,
+    description TEXT,
+    severity VARCHAR(20) NOT NULL,
+    owner VARCHAR(255),
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    blocking BOOLEAN NOT NULL DEFAULT FALSE,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (severity IN ('info', 'warn', 'error'))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_policies_organization ON policies(organization_id, policy_id)
+    WHERE project_id IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_policies_project ON policies(project_id, policy_id)
+    WHERE project_id IS NOT NULL;
+
+CREATE TRIGGER update_policies_updated_at BEFORE UPDATE ON policies
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Violation analytics scan a project's test runs by timestamp
+CREATE INDEX IF NOT EXISTS idx_test_runs_project_timestamp ON test_runs(project_id, timestamp);
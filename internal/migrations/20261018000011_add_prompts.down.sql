@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_traces_prompt;
+ALTER TABLE traces DROP COLUMN IF EXISTS prompt_version;
+ALTER TABLE traces DROP COLUMN IF EXISTS prompt_name;
+
+DROP TABLE IF EXISTS prompt_labels;
+DROP TABLE IF EXISTS prompt_versions;
+DROP TABLE IF EXISTS prompts;
@@ -0,0 +1,46 @@
+-- Prompt registry: named prompts with immutable, numbered versions and
+-- labels such as production or staging that point at one version.
+
+CREATE TABLE IF NOT EXISTS prompts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    latest_version INTEGER NOT NULL DEFAULT 0,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (project_id, name)
+);
+
+CREATE TRIGGER update_prompts_updated_at BEFORE UPDATE ON prompts
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TABLE IF NOT EXISTS prompt_versions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    prompt_id UUID NOT NULL REFERENCES prompts(id) ON DELETE CASCADE,
+    version INTEGER NOT NULL,
+    template TEXT NOT NULL,
+    variables TEXT[],
+    params JSONB,
+    model VARCHAR(255),
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (prompt_id, version)
+);
+
+CREATE TABLE IF NOT EXISTS prompt_labels (
+    prompt_id UUID NOT NULL REFERENCES prompts(id) ON DELETE CASCADE,
+    label VARCHAR(64) NOT NULL,
+    version INTEGER NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (prompt_id, label),
+    FOREIGN KEY (prompt_id, version) REFERENCES prompt_versions(prompt_id, version) ON DELETE CASCADE
+);
+
+-- Traces record the prompt version that produced them
+ALTER TABLE traces ADD COLUMN IF NOT EXISTS prompt_name VARCHAR(255);
+ALTER TABLE traces ADD COLUMN IF NOT EXISTS prompt_version INTEGER;
+
+CREATE INDEX IF NOT EXISTS idx_traces_prompt ON traces(project_id, prompt_name, prompt_version, timestamp)
+    WHERE prompt_name IS NOT NULL;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_test_runs_project_branch_timestamp;
+DROP TABLE IF EXISTS quality_gates;
@@ -0,0 +1,19 @@
+-- Quality gates: conditions every uploaded test run of a project is checked
+-- against, some of them relative to the latest run on a baseline branch
+
+CREATE TABLE IF NOT EXISTS quality_gates (
+    project_id UUID PRIMARY KEY REFERENCES projects(id) ON DELETE CASCADE,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    baseline_branch VARCHAR(255) NOT NULL DEFAULT 'main',
+    conditions JSONB NOT NULL DEFAULT '[]',
+    updated_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TRIGGER update_quality_gates_updated_at BEFORE UPDATE ON quality_gates
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Baseline lookups find the newest completed run on a branch
+CREATE INDEX IF NOT EXISTS idx_test_runs_project_branch_timestamp ON test_runs(project_id, git_branch, timestamp DESC)
+    WHERE status = 'completed' AND deleted_at IS NULL;
@@ -0,0 +1,15 @@
+-- Quarantined test cases: flaky cases excluded from quality gates until
+-- they are taken out of quarantine
+
+CREATE TABLE IF NOT EXISTS quarantined_cases (
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    case_id VARCHAR(255) NOT NULL,
+    reason TEXT,
+    quarantined_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (project_id, case_id)
+);
+
+CREATE TRIGGER update_quarantined_cases_updated_at BEFORE UPDATE ON quarantined_cases
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,3 @@
+-- Significance level regressions are tested at; NULL uses the default of 0.05
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS significance_alpha DOUBLE PRECISION
+    CHECK (significance_alpha > 0 AND significance_alpha < 1);
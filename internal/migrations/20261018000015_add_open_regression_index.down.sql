@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_regression_detections_open;
@@ -0,0 +1,6 @@
+-- At most one open regression per project, case, and regression type, so a
+-- bisect updates the open regression instead of recording a new one
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_regression_detections_open
+    ON regression_detections(project_id, case_id, regression_type)
+    WHERE resolved_at IS NULL;
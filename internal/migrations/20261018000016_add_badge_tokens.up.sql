@@ -0,0 +1,15 @@
+-- Badge tokens: unguessable, revocable tokens in the URLs of a project's
+-- public status badges. Only a hash of each token is stored.
+
+CREATE TABLE IF NOT EXISTS badge_tokens (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    token_prefix VARCHAR(32) NOT NULL,
+    name VARCHAR(255),
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    revoked_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_badge_tokens_project_id ON badge_tokens(project_id);
@@ -0,0 +1,34 @@
+-- Audit log: an append-only record of administrative actions in an
+-- organization. Entries have no foreign keys so they outlive the users, keys,
+-- and projects they mention.
+
+CREATE TABLE IF NOT EXISTS audit_log (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    organization_id UUID NOT NULL,
+    actor_type VARCHAR(16) NOT NULL,
+    actor_id VARCHAR(255),
+    actor_name VARCHAR(255),
+    action VARCHAR(64) NOT NULL,
+    target_type VARCHAR(32) NOT NULL,
+    target_id VARCHAR(255),
+    changes JSONB NOT NULL DEFAULT '{}',
+    ip_address VARCHAR(45),
+    user_agent TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_org_created ON audit_log(organization_id, created_at DESC);
+
+-- Reject changes to recorded entries
+CREATE OR REPLACE FUNCTION reject_audit_log_change()
+RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ language 'plpgsql';
+
+CREATE TRIGGER audit_log_append_only BEFORE UPDATE OR DELETE ON audit_log
+    FOR EACH ROW EXECUTE FUNCTION reject_audit_log_change();
+
+CREATE TRIGGER audit_log_no_truncate BEFORE TRUNCATE ON audit_log
+    FOR EACH STATEMENT EXECUTE FUNCTION reject_audit_log_change();
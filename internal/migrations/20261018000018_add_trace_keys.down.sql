@@ -0,0 +1 @@
+DROP TABLE IF EXISTS trace_keys;
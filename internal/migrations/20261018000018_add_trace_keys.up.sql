@@ -0,0 +1,19 @@
+-- traces is partitioned by timestamp, so its unique key has to include the
+-- timestamp. trace_keys keeps trace IDs unique per project: ingestion claims
+-- the key first and stores a resent trace ID under the timestamp it was
+-- first seen with.
+CREATE TABLE trace_keys (
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    trace_id VARCHAR(255) NOT NULL,
+    timestamp TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (project_id, trace_id)
+);
+
+CREATE INDEX idx_trace_keys_timestamp ON trace_keys(timestamp);
+
+-- IDs already stored under several timestamps keep the latest, the one
+-- returned by the trace detail endpoint
+INSERT INTO trace_keys (project_id, trace_id, timestamp)
+SELECT DISTINCT ON (project_id, trace_id) project_id, trace_id, timestamp
+FROM traces
+ORDER BY project_id, trace_id, timestamp DESC;
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_trace_archive_restores_completed;
+
+UPDATE trace_archive_restores SET status = 'completed' WHERE status = 'expired';
+ALTER TABLE trace_archive_restores DROP CONSTRAINT IF EXISTS trace_archive_restores_status_check;
+ALTER TABLE trace_archive_restores ADD CONSTRAINT trace_archive_restores_status_check
+    CHECK (status IN ('pending', 'running', 'completed', 'failed'));
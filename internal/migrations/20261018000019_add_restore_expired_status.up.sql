@@ -0,0 +1,6 @@
+-- Completed restores become expired once their restored traces are deleted
+ALTER TABLE trace_archive_restores DROP CONSTRAINT IF EXISTS trace_archive_restores_status_check;
+ALTER TABLE trace_archive_restores ADD CONSTRAINT trace_archive_restores_status_check
+    CHECK (status IN ('pending', 'running', 'completed', 'failed', 'expired'));
+
+CREATE INDEX IF NOT EXISTS idx_trace_archive_restores_completed ON trace_archive_restores(expires_at) WHERE status = 'completed';
@@ -0,0 +1 @@
+ALTER TABLE redaction_policies DROP COLUMN IF EXISTS hash_key;
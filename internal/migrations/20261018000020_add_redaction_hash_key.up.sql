@@ -0,0 +1,4 @@
+-- Per-project secret key for hash mode redaction. gen_random_uuid() is
+-- backed by a cryptographic random source; two UUIDs give 244 random bits.
+ALTER TABLE redaction_policies ADD COLUMN IF NOT EXISTS hash_key BYTEA NOT NULL
+    DEFAULT decode(replace(gen_random_uuid()::text || gen_random_uuid()::text, '-', ''), 'hex');
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_traces_restore_id;
+ALTER TABLE traces DROP COLUMN IF EXISTS restore_id;
@@ -0,0 +1,6 @@
+-- Tag traces loaded by an archive restore, so they are never archived again
+-- and only they are deleted when the restore expires or fails. Traces
+-- restored before this migration are untagged and treated as live.
+ALTER TABLE traces ADD COLUMN IF NOT EXISTS restore_id UUID;
+
+CREATE INDEX IF NOT EXISTS idx_traces_restore_id ON traces(restore_id) WHERE restore_id IS NOT NULL;
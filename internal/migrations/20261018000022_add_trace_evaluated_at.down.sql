@@ -0,0 +1,17 @@
+DROP INDEX IF EXISTS idx_traces_unevaluated;
+DROP INDEX IF EXISTS idx_traces_project_created_at;
+
+ALTER TABLE evaluation_cursors ADD COLUMN IF NOT EXISTS last_trace_row UUID;
+ALTER TABLE evaluation_cursors RENAME COLUMN evaluating_since TO evaluated_through;
+
+-- Resume the cursor at each project's newest evaluated trace
+UPDATE evaluation_cursors AS ec SET evaluated_through = t.created_at, last_trace_row = t.id
+FROM (
+    SELECT DISTINCT ON (project_id) project_id, created_at, id
+    FROM traces
+    WHERE evaluated_at IS NOT NULL
+    ORDER BY project_id, created_at DESC, id DESC
+) AS t
+WHERE t.project_id = ec.project_id AND t.created_at > ec.evaluated_through;
+
+ALTER TABLE traces DROP COLUMN IF EXISTS evaluated_at;
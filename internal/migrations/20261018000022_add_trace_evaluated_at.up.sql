@@ -0,0 +1,20 @@
+-- Track evaluation per trace rather than by a (created_at, id) cursor, which
+-- missed traces committed late and never revisited replaced traces. Traces
+-- stored before this migration count as evaluated, except those past their
+-- project's cursor. The cursor row now only records when evaluation started
+-- and serializes workers per project.
+ALTER TABLE traces ADD COLUMN IF NOT EXISTS evaluated_at TIMESTAMPTZ DEFAULT NOW();
+ALTER TABLE traces ALTER COLUMN evaluated_at DROP DEFAULT;
+
+UPDATE traces AS t SET evaluated_at = NULL
+FROM evaluation_cursors AS ec
+WHERE t.project_id = ec.project_id
+  AND (t.created_at > ec.evaluated_through
+    OR (t.created_at = ec.evaluated_through AND t.id > ec.last_trace_row));
+
+ALTER TABLE evaluation_cursors RENAME COLUMN evaluated_through TO evaluating_since;
+ALTER TABLE evaluation_cursors DROP COLUMN IF EXISTS last_trace_row;
+
+CREATE INDEX IF NOT EXISTS idx_traces_project_created_at ON traces(project_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_traces_unevaluated ON traces(project_id, created_at, id)
+    WHERE evaluated_at IS NULL AND deleted_at IS NULL;
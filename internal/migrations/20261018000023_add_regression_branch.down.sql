@@ -0,0 +1,18 @@
+-- Keep only the newest open regression of each case and type across branches
+UPDATE regression_detections AS rd SET resolved_at = NOW()
+WHERE rd.resolved_at IS NULL
+  AND EXISTS (
+    SELECT 1 FROM regression_detections AS newer
+    WHERE newer.project_id = rd.project_id
+      AND newer.case_id = rd.case_id
+      AND newer.regression_type = rd.regression_type
+      AND newer.resolved_at IS NULL
+      AND (newer.detected_at, newer.id) > (rd.detected_at, rd.id)
+  );
+
+DROP INDEX IF EXISTS idx_regression_detections_open;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_regression_detections_open
+    ON regression_detections(project_id, case_id, regression_type)
+    WHERE resolved_at IS NULL;
+
+ALTER TABLE regression_detections DROP COLUMN IF EXISTS branch;
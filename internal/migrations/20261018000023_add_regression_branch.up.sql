@@ -0,0 +1,11 @@
+-- Record the branch a regression was located on as a column, so open
+-- regressions are kept per branch: a bisect on one branch neither overwrites
+-- nor resolves another branch's regression of the same case
+ALTER TABLE regression_detections ADD COLUMN IF NOT EXISTS branch VARCHAR(255) NOT NULL DEFAULT '';
+
+UPDATE regression_detections SET branch = details->>'branch' WHERE details ? 'branch';
+
+DROP INDEX IF EXISTS idx_regression_detections_open;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_regression_detections_open
+    ON regression_detections(project_id, branch, case_id, regression_type)
+    WHERE resolved_at IS NULL;
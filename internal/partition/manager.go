@@ -0,0 +1,109 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+// Package partition keeps the monthly partitions of the traces table ahead
+// of incoming data.
+package partition
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/regrada-ai/regrada-be/internal/storage"
+)
+
+// ManagerConfig configures partition maintenance
+type ManagerConfig struct {
+	Interval    time.Duration // time between maintenance runs
+	MonthsAhead int           // future months to create partitions for
+}
+
+// DefaultManagerConfig returns the default maintenance settings
+func DefaultManagerConfig() ManagerConfig {
+	return ManagerConfig{
+		Interval:    6 * time.Hour,
+		MonthsAhead: 3,
+	}
+}
+
+// Manager periodically creates partitions for the current and upcoming months,
+// and for any month whose traces landed in the default partition (e.g. traces
+// with timestamps far in the past or future). Partition creation is
+// serialized in the database, so every instance may run a manager.
+type Manager struct {
+	partitionRepo storage.TracePartitionRepository
+	cfg           ManagerConfig
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewManager(partitionRepo storage.TracePartitionRepository, cfg ManagerConfig) *Manager {
+	return &Manager{
+		partitionRepo: partitionRepo,
+		cfg:           cfg,
+	}
+}
+
+// Start runs maintenance immediately and then every Interval until Stop is called
+func (m *Manager) Start(ctx context.Context) {
+	ctx, m.cancel = context.WithCancel(ctx)
+
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+
+		ticker := time.NewTicker(m.cfg.Interval)
+		defer ticker.Stop()
+
+		for {
+			if err := m.RunOnce(ctx); err != nil && ctx.Err() == nil {
+				log.Printf("Trace partition maintenance failed: %v", err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop cancels maintenance and waits for it to exit
+func (m *Manager) Stop() {
+	if m.cancel == nil {
+		return
+	}
+	m.cancel()
+	m.wg.Wait()
+}
+
+// RunOnce creates any missing partitions
+func (m *Manager) RunOnce(ctx context.Context) error {
+	now := time.Now().UTC()
+	current := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	months := make([]time.Time, 0, m.cfg.MonthsAhead+1)
+	for i := 0; i <= m.cfg.MonthsAhead; i++ {
+		months = append(months, current.AddDate(0, i, 0))
+	}
+
+	stray, err := m.partitionRepo.DefaultMonths(ctx)
+	if err != nil {
+		return err
+	}
+	months = append(months, stray...)
+
+	for _, month := range months {
+		created, err := m.partitionRepo.EnsureMonth(ctx, month)
+		if err != nil {
+			return err
+		}
+		if created {
+			log.Printf("✓ Created trace partition for %s", month.Format("2006-01"))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,368 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+// Package redaction removes PII and secrets from traces before they are stored.
+package redaction
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/regrada-ai/regrada-be/internal/domain"
+)
+
+const (
+	maxCustomRules   = 50
+	maxPatternLength = 1000
+)
+
+var customRuleNameRegex = regexp.MustCompile(`^[a-z0-9_-]{1,64}$`)
+
+// detector matches one kind of sensitive value. validate, when set, filters
+// out regex matches that are not real hits (e.g. card numbers failing Luhn).
+type detector struct {
+	pattern  *regexp.Regexp
+	validate func(match string) bool
+}
+
+// builtinDetectors are applied in builtinOrder so that more specific
+// detectors (cards, SSNs) claim digits before the looser phone pattern.
+var builtinDetectors = map[string]detector{
+	domain.RedactionDetectorEmail: {
+		pattern: regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`),
+	},
+	domain.RedactionDetectorCreditCard: {
+		pattern:  regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`),
+		validate: luhnValid,
+	},
+	domain.RedactionDetectorSSN: {
+		pattern: regexp.MustCompile(`\b\d{3}-\d{2}-\d{4}\b`),
+	},
+	domain.RedactionDetectorSecret: {
+		pattern: regexp.MustCompile(`(?i:bearer\s+[A-Za-z0-9._~+/-]{20,}=*)|\b(?:sk-[A-Za-z0-9_-]{20,}|sk_(?:live|test)_[A-Za-z0-9]{16,}|rg_live_[A-Za-z0-9_-]{20,}|AKIA[0-9A-Z]{16}|gh[pousr]_[A-Za-z0-9]{36,}|xox[abpr]-[A-Za-z0-9-]{10,}|AIza[0-9A-Za-z_-]{35})`),
+	},
+	domain.RedactionDetectorIP: {
+		pattern:  regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}\b|(?:[0-9A-Fa-f]{0,4}:){2,7}[0-9A-Fa-f]{0,4}`),
+		validate: func(match string) bool { return net.ParseIP(match) != nil },
+	},
+	domain.RedactionDetectorPhone: {
+		pattern: regexp.MustCompile(`(?:\+\d{1,3}[ .-]?)?(?:\(\d{3}\)|\b\d{3})[ .-]?\d{3}[ .-]?\d{4}\b`),
+	},
+}
+
+var builtinOrder = []string{
+	domain.RedactionDetectorSecret,
+	domain.RedactionDetectorEmail,
+	domain.RedactionDetectorCreditCard,
+	domain.RedactionDetectorSSN,
+	domain.RedactionDetectorIP,
+	domain.RedactionDetectorPhone,
+}
+
+// BuiltinDetectors returns the names of all built-in detectors
+func BuiltinDetectors() []string {
+	names := make([]string, len(builtinOrder))
+	copy(names, builtinOrder)
+	return names
+}
+
+// rule is a compiled detector bound to a mode
+type rule struct {
+	name     string
+	mode     string
+	detector detector
+}
+
+// Redactor applies a compiled redaction policy
+type Redactor struct {
+	rules   []rule
+	hashKey []byte
+}
+
+// Validate checks that a policy is well formed
+func Validate(policy *domain.RedactionPolicy) error {
+	_, err := New(policy)
+	return err
+}
+
+// New compiles a policy into a Redactor. A nil or disabled policy yields a
+// Redactor that changes nothing.
+func New(policy *domain.RedactionPolicy) (*Redactor, error) {
+	r := &Redactor{}
+	if policy == nil {
+		return r, nil
+	}
+
+	// Policies that were never stored, such as dry runs, hash with a random
+	// key, so their tokens don't match stored ones
+	r.hashKey = policy.HashKey
+	if len(r.hashKey) == 0 {
+		r.hashKey = make([]byte, 32)
+		if _, err := rand.Read(r.hashKey); err != nil {
+			return nil, err
+		}
+	}
+
+	mode := policy.Mode
+	if mode == "" {
+		mode = domain.RedactionModeMask
+	}
+	if !validMode(mode) {
+		return nil, fmt.Errorf("invalid mode %q: must be mask, hash, or drop", mode)
+	}
+
+	enabled := make(map[string]bool, len(policy.Detectors))
+	for _, name := range policy.Detectors {
+		if _, ok := builtinDetectors[name]; !ok {
+			return nil, fmt.Errorf("unknown detector %q", name)
+		}
+		enabled[name] = true
+	}
+
+	if len(policy.CustomRules) > maxCustomRules {
+		return nil, fmt.Errorf("at most %d custom rules are allowed", maxCustomRules)
+	}
+
+	seen := make(map[string]bool, len(policy.CustomRules))
+	customRules := make([]rule, 0, len(policy.CustomRules))
+	for _, custom := range policy.CustomRules {
+		if !customRuleNameRegex.MatchString(custom.Name) {
+			return nil, fmt.Errorf("invalid custom rule name %q: use lowercase letters, digits, '-' or '_'", custom.Name)
+		}
+		if seen[custom.Name] {
+			return nil, fmt.Errorf("duplicate custom rule name %q", custom.Name)
+		}
+		seen[custom.Name] = true
+
+		if custom.Pattern == "" || len(custom.Pattern) > maxPatternLength {
+			return nil, fmt.Errorf("custom rule %q: pattern must be 1-%d characters", custom.Name, maxPatternLength)
+		}
+		pattern, err := regexp.Compile(custom.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("custom rule %q: %w", custom.Name, err)
+		}
+
+		ruleMode := custom.Mode
+		if ruleMode == "" {
+			ruleMode = mode
+		}
+		if !validMode(ruleMode) {
+			return nil, fmt.Errorf("custom rule %q: invalid mode %q", custom.Name, ruleMode)
+		}
+
+		customRules = append(customRules, rule{
+			name:     "custom:" + custom.Name,
+			mode:     ruleMode,
+			detector: detector{pattern: pattern},
+		})
+	}
+
+	if !policy.Enabled {
+		return r, nil
+	}
+
+	// Custom rules run first so they can target values the built-ins would
+	// otherwise partially consume.
+	r.rules = append(r.rules, customRules...)
+	for _, name := range builtinOrder {
+		if enabled[name] {
+			r.rules = append(r.rules, rule{name: name, mode: mode, detector: builtinDetectors[name]})
+		}
+	}
+
+	return r, nil
+}
+
+// Enabled reports whether the redactor has any rules to apply
+func (r *Redactor) Enabled() bool {
+	return len(r.rules) > 0
+}
+
+// RedactString applies every rule to s and returns the result along with
+// the number of matches per rule name.
+func (r *Redactor) RedactString(s string) (string, map[string]int) {
+	counts := make(map[string]int)
+	return r.redactString(s, counts), counts
+}
+
+func (r *Redactor) redactString(s string, counts map[string]int) string {
+	if s == "" {
+		return s
+	}
+	for _, rl := range r.rules {
+		s = rl.detector.pattern.ReplaceAllStringFunc(s, func(match string) string {
+			if rl.detector.validate != nil && !rl.detector.validate(match) {
+				return match
+			}
+			counts[rl.name]++
+			return r.replacement(rl, match)
+		})
+	}
+	return s
+}
+
+// RedactTrace redacts message contents, assistant text, tool call payloads,
+// and the raw provider response in place. The names of rules that matched are
+// appended to trace.RedactionApplied.
+func (r *Redactor) RedactTrace(trace *domain.Trace) {
+	if !r.Enabled() {
+		return
+	}
+
+	counts := make(map[string]int)
+
+	for i := range trace.Request.Messages {
+		trace.Request.Messages[i].Content = r.redactString(trace.Request.Messages[i].Content, counts)
+	}
+
+	trace.Response.AssistantText = r.redactString(trace.Response.AssistantText, counts)
+
+	for i := range trace.Response.ToolCalls {
+		trace.Response.ToolCalls[i].Arguments = r.redactJSON(trace.Response.ToolCalls[i].Arguments, counts)
+		trace.Response.ToolCalls[i].Response = r.redactJSON(trace.Response.ToolCalls[i].Response, counts)
+	}
+
+	trace.Response.Raw = r.redactJSON(trace.Response.Raw, counts)
+
+	trace.RedactionApplied = appendApplied(trace.RedactionApplied, counts)
+}
+
+// redactJSON redacts every string value (and object key) in a JSON document
+// so the result stays valid JSON.
+func (r *Redactor) redactJSON(raw json.RawMessage, counts map[string]int) json.RawMessage {
+	if len(raw) == 0 {
+		return raw
+	}
+
+	var value any
+	if err := json.Unmarshal(raw, &value); err != nil {
+		// Not valid JSON; treat it as opaque text
+		redacted, err := json.Marshal(r.redactString(string(raw), counts))
+		if err != nil {
+			return nil
+		}
+		return redacted
+	}
+
+	matched := make(map[string]int)
+	value = r.redactValue(value, matched)
+	if len(matched) == 0 {
+		// Nothing matched; keep the original bytes untouched
+		return raw
+	}
+	for name, n := range matched {
+		counts[name] += n
+	}
+
+	redacted, err := json.Marshal(value)
+	if err != nil {
+		return nil
+	}
+	return redacted
+}
+
+func (r *Redactor) redactValue(value any, counts map[string]int) any {
+	switch v := value.(type) {
+	case string:
+		return r.redactString(v, counts)
+	case []any:
+		for i := range v {
+			v[i] = r.redactValue(v[i], counts)
+		}
+		return v
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for key, item := range v {
+			out[r.redactString(key, counts)] = r.redactValue(item, counts)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// replacement returns what a match is replaced with. Hash mode uses an HMAC
+// keyed per project, so equal values stay linkable within the project but
+// small value spaces (SSNs, phone numbers) can't be enumerated offline.
+func (r *Redactor) replacement(rl rule, match string) string {
+	switch rl.mode {
+	case domain.RedactionModeHash:
+		mac := hmac.New(sha256.New, r.hashKey)
+		mac.Write([]byte(match))
+		return fmt.Sprintf("[%s:%s]", placeholderName(rl.name), hex.EncodeToString(mac.Sum(nil))[:16])
+	case domain.RedactionModeDrop:
+		return ""
+	default:
+		return fmt.Sprintf("[REDACTED_%s]", placeholderName(rl.name))
+	}
+}
+
+func placeholderName(name string) string {
+	name = strings.TrimPrefix(name, "custom:")
+	name = strings.ReplaceAll(name, "-", "_")
+	return strings.ToUpper(name)
+}
+
+func appendApplied(applied []string, counts map[string]int) []string {
+	if len(counts) == 0 {
+		return applied
+	}
+
+	existing := make(map[string]bool, len(applied))
+	for _, name := range applied {
+		existing[name] = true
+	}
+
+	names := make([]string, 0, len(counts))
+	for name := range counts {
+		if !existing[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	return append(applied, names...)
+}
+
+func validMode(mode string) bool {
+	switch mode {
+	case domain.RedactionModeMask, domain.RedactionModeHash, domain.RedactionModeDrop:
+		return true
+	default:
+		return false
+	}
+}
+
+// luhnValid reports whether the digits in s pass the Luhn checksum
+func luhnValid(s string) bool {
+	sum := 0
+	digits := 0
+	double := false
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c == ' ' || c == '-' {
+			continue
+		}
+		if c < '0' || c > '9' {
+			return false
+		}
+		d := int(c - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		digits++
+		double = !double
+	}
+	return digits >= 13 && digits <= 19 && sum%10 == 0
+}
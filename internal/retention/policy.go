@@ -0,0 +1,67 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+// Package retention decides how long traces are kept and purges them once
+// they expire.
+package retention
+
+import (
+	"time"
+
+	"github.com/regrada-ai/regrada-be/internal/storage"
+)
+
+// SoftDeleteGrace is how long soft-deleted traces are kept before being purged
+const SoftDeleteGrace = 7 * 24 * time.Hour
+
+// Where an effective retention period comes from
+const (
+	SourceTier         = "tier"
+	SourceOrganization = "organization"
+	SourceProject      = "project"
+)
+
+// DefaultDaysForTier returns the retention period used when no override is set
+func DefaultDaysForTier(tier string) int {
+	switch tier {
+	case "starter":
+		return 14
+	case "team":
+		return 90
+	case "scale":
+		return 365
+	case "enterprise":
+		return 365
+	default:
+		return 14 // Default to starter tier retention
+	}
+}
+
+// MaxDaysForTier returns the longest retention period a tier may configure
+func MaxDaysForTier(tier string) int {
+	switch tier {
+	case "enterprise":
+		return 3650
+	default:
+		return DefaultDaysForTier(tier)
+	}
+}
+
+// Effective returns a project's retention period in days and where it comes
+// from. Overrides are capped at the tier maximum so a downgrade takes effect
+// without rewriting stored settings.
+func Effective(p *storage.ProjectRetention) (int, string) {
+	days, source := DefaultDaysForTier(p.Tier), SourceTier
+	if p.OrganizationDays != nil {
+		days, source = *p.OrganizationDays, SourceOrganization
+	}
+	if p.ProjectDays != nil {
+		days, source = *p.ProjectDays, SourceProject
+	}
+	return min(days, MaxDaysForTier(p.Tier)), source
+}
+
+// Cutoffs returns the times before which traces expire and soft-deleted
+// traces are purged
+func Cutoffs(now time.Time, days int) (expiredBefore, deletedBefore time.Time) {
+	return now.AddDate(0, 0, -days), now.Add(-SoftDeleteGrace)
+}
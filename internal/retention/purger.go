@@ -0,0 +1,225 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package retention
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/regrada-ai/regrada-be/internal/storage"
+)
+
+const (
+	purgeLockKey    = "retention:purge:lock"
+	purgeLastRunKey = "retention:purge:last_run"
+	purgeLockTTL    = 30 * time.Minute
+)
+
+// PurgerConfig configures the purge job
+type PurgerConfig struct {
+	Interval   time.Duration // time between purge runs
+	BatchSize  int           // traces deleted per statement
+	BatchPause time.Duration // pause between batches to limit database load
+}
+
+// DefaultPurgerConfig returns the default purge settings
+func DefaultPurgerConfig() PurgerConfig {
+	return PurgerConfig{
+		Interval:   time.Hour,
+		BatchSize:  1000,
+		BatchPause: 100 * time.Millisecond,
+	}
+}
+
+// Purger periodically hard-deletes expired and soft-deleted traces. Monthly
+// partitions that have expired for every project are dropped whole; remaining
+// traces are deleted row by row. A Redis lock ensures only one instance
+// purges at a time.
+type Purger struct {
+	retentionRepo storage.RetentionRepository
+	partitionRepo storage.TracePartitionRepository
+	redisClient   *redis.Client
+	cfg           PurgerConfig
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewPurger(retentionRepo storage.RetentionRepository, partitionRepo storage.TracePartitionRepository, redisClient *redis.Client, cfg PurgerConfig) *Purger {
+	return &Purger{
+		retentionRepo: retentionRepo,
+		partitionRepo: partitionRepo,
+		redisClient:   redisClient,
+		cfg:           cfg,
+	}
+}
+
+// Start runs the purge job every Interval until Stop is called
+func (p *Purger) Start(ctx context.Context) {
+	ctx, p.cancel = context.WithCancel(ctx)
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+
+		ticker := time.NewTicker(p.cfg.Interval)
+		defer ticker.Stop()
+
+		for {
+			if err := p.RunOnce(ctx); err != nil && ctx.Err() == nil {
+				log.Printf("Retention purge failed: %v", err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop cancels the purge job and waits for it to exit
+func (p *Purger) Stop() {
+	if p.cancel == nil {
+		return
+	}
+	p.cancel()
+	p.wg.Wait()
+}
+
+// NextRun estimates when the next purge will start, based on the last run by
+// any instance. It returns the zero time if no purge has run yet.
+func (p *Purger) NextRun(ctx context.Context) (time.Time, error) {
+	lastRun, err := p.redisClient.Get(ctx, purgeLastRunKey).Time()
+	if err != nil {
+		if err == redis.Nil {
+			return time.Time{}, nil
+		}
+		return time.Time{}, err
+	}
+	return lastRun.Add(p.cfg.Interval), nil
+}
+
+// RunOnce purges every project, unless another instance is already purging
+func (p *Purger) RunOnce(ctx context.Context) error {
+	acquired, err := p.redisClient.SetNX(ctx, purgeLockKey, time.Now().UTC().Format(time.RFC3339), purgeLockTTL).Result()
+	if err != nil {
+		return err
+	}
+	if !acquired {
+		return nil
+	}
+	defer p.redisClient.Del(context.WithoutCancel(ctx), purgeLockKey)
+
+	// Skip if another instance purged recently
+	lastRun, err := p.redisClient.Get(ctx, purgeLastRunKey).Time()
+	if err != nil && err != redis.Nil {
+		return err
+	}
+	if err == nil && time.Since(lastRun) < p.cfg.Interval/2 {
+		return nil
+	}
+
+	projects, err := p.retentionRepo.ListProjects(ctx, "")
+	if err != nil {
+		return err
+	}
+
+	start := time.Now()
+	if err := p.dropPartitions(ctx, start, projects); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		log.Printf("Failed to drop expired trace partitions: %v", err)
+	}
+
+	var total int64
+	for _, project := range projects {
+		days, _ := Effective(project)
+		expiredBefore, deletedBefore := Cutoffs(start, days)
+
+		deleted, err := p.purgeProject(ctx, project.ProjectID, expiredBefore, deletedBefore)
+		total += deleted
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			log.Printf("Failed to purge traces for project %s: %v", project.ProjectID, err)
+			continue
+		}
+		if deleted > 0 {
+			log.Printf("Purged %d traces for project %s (retention %d days)", deleted, project.ProjectID, days)
+		}
+	}
+
+	if err := p.redisClient.Set(ctx, purgeLastRunKey, start, 0).Err(); err != nil {
+		return err
+	}
+
+	log.Printf("✓ Retention purge finished: %d traces across %d projects in %s", total, len(projects), time.Since(start).Round(time.Millisecond))
+	return nil
+}
+
+// dropPartitions drops monthly partitions whose traces have all expired under
+// the longest retention period of any project, except those holding restored
+// traces
+func (p *Purger) dropPartitions(ctx context.Context, now time.Time, projects []*storage.ProjectRetention) error {
+	if len(projects) == 0 {
+		return nil
+	}
+
+	maxDays := 0
+	for _, project := range projects {
+		days, _ := Effective(project)
+		maxDays = max(maxDays, days)
+	}
+	expiredBefore, _ := Cutoffs(now, maxDays)
+
+	partitions, err := p.partitionRepo.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, partition := range partitions {
+		if partition.To.After(expiredBefore) {
+			break // ordered by start time
+		}
+		if partition.Restored {
+			continue // kept until the restore expires
+		}
+		if err := p.partitionRepo.Drop(ctx, partition.Name); err != nil {
+			return err
+		}
+		log.Printf("Dropped expired trace partition %s", partition.Name)
+	}
+	return nil
+}
+
+// purgeProject deletes a project's purgeable traces in bounded batches
+func (p *Purger) purgeProject(ctx context.Context, projectID string, expiredBefore, deletedBefore time.Time) (int64, error) {
+	var total int64
+	for {
+		deleted, err := p.retentionRepo.PurgeTraces(ctx, projectID, expiredBefore, deletedBefore, p.cfg.BatchSize)
+		total += deleted
+		if err != nil {
+			return total, err
+		}
+		if deleted < int64(p.cfg.BatchSize) {
+			return total, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return total, ctx.Err()
+		case <-time.After(p.cfg.BatchPause):
+		}
+
+		// Keep the lock while a large purge is still running
+		p.redisClient.Expire(ctx, purgeLockKey, purgeLockTTL)
+	}
+}
@@ -0,0 +1,106 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+// Package review fills human review queues from incoming traces and test
+// runs.
+package review
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/regrada-ai/regrada-be/internal/storage"
+)
+
+// SamplerConfig configures the review queue sampler
+type SamplerConfig struct {
+	Interval time.Duration // time between sampler runs
+	// Lag keeps the sampler behind the present, so traces in transactions
+	// still committing when a window is scanned land in the next window
+	Lag time.Duration
+}
+
+// DefaultSamplerConfig returns the default sampler settings
+func DefaultSamplerConfig() SamplerConfig {
+	return SamplerConfig{
+		Interval: 5 * time.Minute,
+		Lag:      time.Minute,
+	}
+}
+
+// Sampler periodically adds new traces and failed test cases to review
+// queues according to each queue's rules. Each queue is locked while it is
+// populated, so every instance may run a sampler.
+type Sampler struct {
+	reviewRepo storage.ReviewRepository
+	cfg        SamplerConfig
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewSampler(reviewRepo storage.ReviewRepository, cfg SamplerConfig) *Sampler {
+	return &Sampler{
+		reviewRepo: reviewRepo,
+		cfg:        cfg,
+	}
+}
+
+// Start runs the sampler every Interval until Stop is called
+func (s *Sampler) Start(ctx context.Context) {
+	ctx, s.cancel = context.WithCancel(ctx)
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		ticker := time.NewTicker(s.cfg.Interval)
+		defer ticker.Stop()
+
+		for {
+			if err := s.RunOnce(ctx); err != nil && ctx.Err() == nil {
+				log.Printf("Review queue sampling failed: %v", err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop cancels the sampler and waits for it to exit
+func (s *Sampler) Stop() {
+	if s.cancel == nil {
+		return
+	}
+	s.cancel()
+	s.wg.Wait()
+}
+
+// RunOnce populates every review queue up to the present, less Lag
+func (s *Sampler) RunOnce(ctx context.Context) error {
+	queues, err := s.reviewRepo.ListAllQueues(ctx)
+	if err != nil {
+		return err
+	}
+
+	until := time.Now().Add(-s.cfg.Lag)
+	for _, queue := range queues {
+		added, err := s.reviewRepo.Populate(ctx, queue.ID, until)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			log.Printf("Failed to populate review queue %s: %v", queue.ID, err)
+			continue
+		}
+		if added > 0 {
+			log.Printf("Added %d items to review queue %s", added, queue.ID)
+		}
+	}
+	return nil
+}
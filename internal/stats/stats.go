@@ -0,0 +1,133 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+// Package stats provides the confidence intervals and significance tests
+// used to tell regressions from noise.
+package stats
+
+import (
+	"math"
+	"sort"
+)
+
+// DefaultAlpha is the significance level used when a project sets none
+const DefaultAlpha = 0.05
+
+// Interval is a confidence interval
+type Interval struct {
+	Low  float64 `json:"low"`
+	High float64 `json:"high"`
+}
+
+// Wilson returns the Wilson score interval of a pass rate at confidence
+// 1-alpha. With no trials the interval is [0, 1].
+func Wilson(successes, trials int, alpha float64) Interval {
+	if trials <= 0 {
+		return Interval{Low: 0, High: 1}
+	}
+
+	z := zScore(alpha)
+	n := float64(trials)
+	p := float64(successes) / n
+	denominator := 1 + z*z/n
+	center := (p + z*z/(2*n)) / denominator
+	margin := z * math.Sqrt(p*(1-p)/n+z*z/(4*n*n)) / denominator
+	return Interval{
+		Low:  math.Max(0, center-margin),
+		High: math.Min(1, center+margin),
+	}
+}
+
+// zScore returns the two-sided standard normal critical value for alpha
+func zScore(alpha float64) float64 {
+	return math.Sqrt2 * math.Erfinv(1-alpha)
+}
+
+// FisherExact returns the two-sided p-value of Fisher's exact test for the
+// 2x2 table of pass and fail counts of two samples
+func FisherExact(passA, failA, passB, failB int) float64 {
+	rowA, rowB := passA+failA, passB+failB
+	passes := passA + passB
+	total := rowA + rowB
+	if rowA == 0 || rowB == 0 || passes == 0 || passes == total {
+		return 1
+	}
+
+	// logProbability is the hypergeometric probability of a table with x
+	// passes in sample A and the same margins
+	logProbability := func(x int) float64 {
+		return logChoose(rowA, x) + logChoose(rowB, passes-x) - logChoose(total, passes)
+	}
+
+	observed := logProbability(passA)
+	p := 0.0
+	for x := max(0, passes-rowB); x <= min(rowA, passes); x++ {
+		// Tables as or less likely than the observed one, allowing for
+		// rounding error
+		if lp := logProbability(x); lp <= observed+1e-7 {
+			p += math.Exp(lp)
+		}
+	}
+	return math.Min(1, p)
+}
+
+func logChoose(n, k int) float64 {
+	a, _ := math.Lgamma(float64(n + 1))
+	b, _ := math.Lgamma(float64(k + 1))
+	c, _ := math.Lgamma(float64(n - k + 1))
+	return a - b - c
+}
+
+// MannWhitney returns the two-sided p-value of the Mann-Whitney U test that
+// the two samples come from the same distribution, using the normal
+// approximation with tie and continuity corrections
+func MannWhitney(a, b []float64) float64 {
+	n1, n2 := len(a), len(b)
+	if n1 == 0 || n2 == 0 {
+		return 1
+	}
+
+	type value struct {
+		v       float64
+		inFirst bool
+	}
+	values := make([]value, 0, n1+n2)
+	for _, v := range a {
+		values = append(values, value{v, true})
+	}
+	for _, v := range b {
+		values = append(values, value{v, false})
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i].v < values[j].v })
+
+	// Rank the pooled sample, giving ties their mean rank
+	n := float64(n1 + n2)
+	rankSum, tieTerm := 0.0, 0.0
+	for i := 0; i < len(values); {
+		j := i
+		for j < len(values) && values[j].v == values[i].v {
+			j++
+		}
+		rank := float64(i+j+1) / 2
+		for k := i; k < j; k++ {
+			if values[k].inFirst {
+				rankSum += rank
+			}
+		}
+		ties := float64(j - i)
+		tieTerm += ties*ties*ties - ties
+		i = j
+	}
+
+	u := rankSum - float64(n1)*float64(n1+1)/2
+	mean := float64(n1) * float64(n2) / 2
+	variance := float64(n1) * float64(n2) / 12 * ((n + 1) - tieTerm/(n*(n-1)))
+	if variance <= 0 {
+		return 1
+	}
+
+	z := (math.Abs(u-mean) - 0.5) / math.Sqrt(variance)
+	if z < 0 {
+		return 1
+	}
+	return math.Min(1, math.Erfc(z/math.Sqrt2))
+}
@@ -2,7 +2,11 @@ package storage
 
 import (
 	"context"
+	"fmt"
+	"io"
 	"mime/multipart"
+	"path/filepath"
+	"strings"
 	"time"
 )
 
@@ -10,6 +14,40 @@ import (
 type FileStorageService interface {
 	UploadFile(ctx context.Context, key string, file multipart.File, contentType string) error
 	DeleteFile(ctx context.Context, key string) error
+	// DownloadFile opens a stored file for reading; the caller must close it.
+	// It returns ErrNotFound if the key does not exist.
+	DownloadFile(ctx context.Context, key string) (io.ReadCloser, error)
 	GetPresignedURL(ctx context.Context, key string, expiresIn time.Duration) (string, error)
 	GetCloudFrontURL(key string) string
 }
+
+// ValidateImageFile validates that the uploaded file is an image
+func ValidateImageFile(header *multipart.FileHeader) error {
+	// Check file size (max 5MB)
+	const maxSize = 5 * 1024 * 1024
+	if header.Size > maxSize {
+		return fmt.Errorf("file size exceeds 5MB limit")
+	}
+
+	// Check content type
+	contentType := header.Header.Get("Content-Type")
+	allowedTypes := map[string]bool{
+		"image/jpeg": true,
+		"image/jpg":  true,
+		"image/png":  true,
+		"image/gif":  true,
+		"image/webp": true,
+	}
+
+	if !allowedTypes[contentType] {
+		return fmt.Errorf("invalid file type: %s. Allowed types: jpeg, jpg, png, gif, webp", contentType)
+	}
+
+	return nil
+}
+
+// GetFileExtension returns the file extension from the filename
+func GetFileExtension(filename string) string {
+	ext := filepath.Ext(filename)
+	return strings.ToLower(ext)
+}
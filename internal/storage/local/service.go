@@ -0,0 +1,241 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package local
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/regrada-ai/regrada-be/internal/storage"
+)
+
+// Ensure Service implements storage.FileStorageService interface at compile time
+var _ storage.FileStorageService = (*Service)(nil)
+
+var (
+	ErrInvalidKey       = errors.New("invalid file key")
+	ErrInvalidSignature = errors.New("invalid signature")
+	ErrURLExpired       = errors.New("url expired")
+)
+
+// contentTypeSuffix is appended to a file's path to store its content type
+const contentTypeSuffix = ".content-type"
+
+// Service stores files on the local filesystem and serves them through signed,
+// expiring URLs handled by the API itself (see handlers.FileHandler).
+type Service struct {
+	baseDir    string
+	baseURL    string
+	signingKey []byte
+	publicTTL  time.Duration
+}
+
+// NewService creates a new local file storage service.
+// baseURL is the externally reachable URL of the files route, e.g. "http://localhost:8080/v1/files".
+// publicTTL is the lifetime of URLs returned by GetCloudFrontURL.
+func NewService(baseDir, baseURL string, signingKey []byte, publicTTL time.Duration) (*Service, error) {
+	if len(signingKey) == 0 {
+		return nil, fmt.Errorf("signing key is required")
+	}
+
+	absDir, err := filepath.Abs(baseDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve storage directory: %w", err)
+	}
+
+	if err := os.MkdirAll(absDir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create storage directory: %w", err)
+	}
+
+	return &Service{
+		baseDir:    absDir,
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		signingKey: signingKey,
+		publicTTL:  publicTTL,
+	}, nil
+}
+
+// UploadFile writes a file to disk under the given key
+func (s *Service) UploadFile(ctx context.Context, key string, file multipart.File, contentType string) error {
+	path, err := s.resolve(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	// Write to a temp file first so readers never see a partial file
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, file); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to store file: %w", err)
+	}
+
+	if contentType != "" {
+		if err := os.WriteFile(path+contentTypeSuffix, []byte(contentType), 0o644); err != nil {
+			return fmt.Errorf("failed to store content type: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// DeleteFile removes a file from disk
+func (s *Service) DeleteFile(ctx context.Context, key string) error {
+	path, err := s.resolve(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete file: %w", err)
+	}
+	os.Remove(path + contentTypeSuffix)
+
+	return nil
+}
+
+// DownloadFile opens a file on disk for reading
+func (s *Service) DownloadFile(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, err := s.resolve(key)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, storage.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+
+	return file, nil
+}
+
+// GetPresignedURL returns a URL to the files route signed for the given duration
+func (s *Service) GetPresignedURL(ctx context.Context, key string, expiresIn time.Duration) (string, error) {
+	if key == "" {
+		return "", nil
+	}
+
+	if _, err := s.resolve(key); err != nil {
+		return "", err
+	}
+
+	key = strings.TrimPrefix(key, "/")
+	expires := time.Now().Add(expiresIn).Unix()
+
+	query := url.Values{}
+	query.Set("expires", strconv.FormatInt(expires, 10))
+	query.Set("signature", s.sign(key, expires))
+
+	return fmt.Sprintf("%s/%s?%s", s.baseURL, escapeKey(key), query.Encode()), nil
+}
+
+// GetCloudFrontURL returns a signed URL valid for the configured public TTL.
+// There is no CDN in front of local storage, so "public" URLs are signed too.
+func (s *Service) GetCloudFrontURL(key string) string {
+	url, err := s.GetPresignedURL(context.Background(), key, s.publicTTL)
+	if err != nil {
+		return ""
+	}
+	return url
+}
+
+// Open verifies a signed request and opens the referenced file.
+// The caller must close the returned file.
+func (s *Service) Open(key, expires, signature string) (*os.File, string, error) {
+	key = strings.TrimPrefix(key, "/")
+
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return nil, "", ErrInvalidSignature
+	}
+
+	expected := s.sign(key, expiresAt)
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return nil, "", ErrInvalidSignature
+	}
+
+	if time.Now().Unix() > expiresAt {
+		return nil, "", ErrURLExpired
+	}
+
+	path, err := s.resolve(key)
+	if err != nil {
+		return nil, "", err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, "", storage.ErrNotFound
+		}
+		return nil, "", err
+	}
+
+	contentType := ""
+	if data, err := os.ReadFile(path + contentTypeSuffix); err == nil {
+		contentType = string(data)
+	}
+
+	return file, contentType, nil
+}
+
+// resolve maps a key to a path inside the base directory, rejecting traversal
+func (s *Service) resolve(key string) (string, error) {
+	key = strings.TrimPrefix(key, "/")
+	if key == "" || strings.HasSuffix(key, contentTypeSuffix) {
+		return "", ErrInvalidKey
+	}
+
+	path := filepath.Join(s.baseDir, filepath.FromSlash(key))
+	if !strings.HasPrefix(path, s.baseDir+string(filepath.Separator)) {
+		return "", ErrInvalidKey
+	}
+
+	return path, nil
+}
+
+func (s *Service) sign(key string, expires int64) string {
+	mac := hmac.New(sha256.New, s.signingKey)
+	mac.Write([]byte(key))
+	mac.Write([]byte("\n"))
+	mac.Write([]byte(strconv.FormatInt(expires, 10)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func escapeKey(key string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
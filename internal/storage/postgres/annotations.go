@@ -0,0 +1,223 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/regrada-ai/regrada-be/internal/storage"
+	"github.com/uptrace/bun"
+)
+
+type AnnotationRepository struct {
+	db *bun.DB
+}
+
+func NewAnnotationRepository(db *bun.DB) *AnnotationRepository {
+	return &AnnotationRepository{db: db}
+}
+
+func (r *AnnotationRepository) Create(ctx context.Context, annotation *storage.Annotation) error {
+	dbAnnotation := toDBAnnotation(annotation)
+	if _, err := r.db.NewInsert().Model(dbAnnotation).Returning("id, created_at, updated_at").Exec(ctx); err != nil {
+		return err
+	}
+
+	annotation.ID = dbAnnotation.ID
+	annotation.CreatedAt = dbAnnotation.CreatedAt
+	annotation.UpdatedAt = dbAnnotation.UpdatedAt
+	return nil
+}
+
+func (r *AnnotationRepository) Get(ctx context.Context, projectID, id string) (*storage.Annotation, error) {
+	var dbAnnotation DBAnnotation
+	err := r.db.NewSelect().
+		Model(&dbAnnotation).
+		Where("id = ?", id).
+		Where("project_id = ?", projectID).
+		Scan(ctx)
+
+	if err == sql.ErrNoRows {
+		return nil, storage.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return toAnnotation(&dbAnnotation), nil
+}
+
+func (r *AnnotationRepository) ListByTrace(ctx context.Context, projectID, traceID string) ([]*storage.Annotation, error) {
+	var dbAnnotations []DBAnnotation
+	err := r.db.NewSelect().
+		Model(&dbAnnotations).
+		Where("project_id = ?", projectID).
+		Where("trace_id = ?", traceID).
+		Order("created_at").
+		Scan(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	annotations := make([]*storage.Annotation, len(dbAnnotations))
+	for i := range dbAnnotations {
+		annotations[i] = toAnnotation(&dbAnnotations[i])
+	}
+	return annotations, nil
+}
+
+func (r *AnnotationRepository) Update(ctx context.Context, annotation *storage.Annotation) error {
+	res, err := r.db.NewUpdate().
+		Model(toDBAnnotation(annotation)).
+		Column("rating", "scores", "comment", "labels").
+		Where("id = ?", annotation.ID).
+		Where("project_id = ?", annotation.ProjectID).
+		Exec(ctx)
+
+	return checkRowsAffected(res, err)
+}
+
+func (r *AnnotationRepository) Delete(ctx context.Context, projectID, id string) error {
+	res, err := r.db.NewDelete().
+		Model((*DBAnnotation)(nil)).
+		Where("id = ?", id).
+		Where("project_id = ?", projectID).
+		Exec(ctx)
+
+	return checkRowsAffected(res, err)
+}
+
+func (r *AnnotationRepository) Summarize(ctx context.Context, projectID string, from, to time.Time, topLabels int) (*storage.AnnotationSummary, error) {
+	inRange := func(q *bun.SelectQuery) *bun.SelectQuery {
+		q = q.Where("an.project_id = ?", projectID)
+		if !from.IsZero() {
+			q = q.Where("an.created_at >= ?", from)
+		}
+		if !to.IsZero() {
+			q = q.Where("an.created_at < ?", to)
+		}
+		return q
+	}
+
+	summary := &storage.AnnotationSummary{
+		Scores: []storage.CriterionScore{},
+		Labels: []storage.LabelCount{},
+	}
+	err := r.db.NewSelect().
+		Model((*DBAnnotation)(nil)).
+		ColumnExpr("count(*) AS annotations").
+		ColumnExpr("count(DISTINCT an.trace_id) AS annotated_traces").
+		ColumnExpr("count(*) FILTER (WHERE an.rating = ?) AS up", storage.RatingUp).
+		ColumnExpr("count(*) FILTER (WHERE an.rating = ?) AS down", storage.RatingDown).
+		Apply(inRange).
+		Scan(ctx, &summary.Annotations, &summary.AnnotatedTraces, &summary.Up, &summary.Down)
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.db.NewSelect().
+		Model((*DBAnnotation)(nil)).
+		TableExpr("jsonb_each_text(an.scores) AS s").
+		ColumnExpr("s.key AS criterion").
+		ColumnExpr("count(*) AS count").
+		ColumnExpr("avg(s.value::float8) AS mean").
+		ColumnExpr("min(s.value::float8) AS min").
+		ColumnExpr("max(s.value::float8) AS max").
+		Apply(inRange).
+		Group("s.key").
+		Order("s.key").
+		Scan(ctx, &summary.Scores)
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.db.NewSelect().
+		Model((*DBAnnotation)(nil)).
+		TableExpr("unnest(an.labels) AS l(label)").
+		ColumnExpr("l.label AS label").
+		ColumnExpr("count(*) AS count").
+		Apply(inRange).
+		Group("l.label").
+		OrderExpr("count(*) DESC, l.label").
+		Limit(topLabels).
+		Scan(ctx, &summary.Labels)
+	if err != nil {
+		return nil, err
+	}
+
+	return summary, nil
+}
+
+// annotationFilter restricts a trace query to traces with at least one
+// annotation matching the filter's annotation fields
+func annotationFilter(q *bun.SelectQuery, filter storage.TraceFilter) *bun.SelectQuery {
+	if filter.Rating == "" && filter.AnnotationLabel == "" && filter.ScoreCriterion == "" {
+		return q
+	}
+
+	sub := q.NewSelect().
+		Model((*DBAnnotation)(nil)).
+		ColumnExpr("1").
+		Where("an.project_id = t.project_id").
+		Where("an.trace_id = t.trace_id")
+	if filter.Rating != "" {
+		sub = sub.Where("an.rating = ?", filter.Rating)
+	}
+	if filter.AnnotationLabel != "" {
+		sub = sub.Where("? = ANY(an.labels)", filter.AnnotationLabel)
+	}
+	if filter.ScoreCriterion != "" {
+		sub = sub.Where("an.scores ->> ? IS NOT NULL", filter.ScoreCriterion)
+		if filter.MinScore != nil {
+			sub = sub.Where("(an.scores ->> ?)::float8 >= ?", filter.ScoreCriterion, *filter.MinScore)
+		}
+		if filter.MaxScore != nil {
+			sub = sub.Where("(an.scores ->> ?)::float8 <= ?", filter.ScoreCriterion, *filter.MaxScore)
+		}
+	}
+	return q.Where("EXISTS (?)", sub)
+}
+
+func toDBAnnotation(annotation *storage.Annotation) *DBAnnotation {
+	scores := annotation.Scores
+	if scores == nil {
+		scores = map[string]float64{}
+	}
+	labels := annotation.Labels
+	if labels == nil {
+		labels = []string{}
+	}
+
+	return &DBAnnotation{
+		ID:        annotation.ID,
+		ProjectID: annotation.ProjectID,
+		TraceID:   annotation.TraceID,
+		Source:    string(annotation.Source),
+		AuthorID:  annotation.AuthorID,
+		EndUserID: annotation.EndUserID,
+		Rating:    string(annotation.Rating),
+		Scores:    scores,
+		Comment:   annotation.Comment,
+		Labels:    labels,
+	}
+}
+
+func toAnnotation(dbAnnotation *DBAnnotation) *storage.Annotation {
+	return &storage.Annotation{
+		ID:        dbAnnotation.ID,
+		ProjectID: dbAnnotation.ProjectID,
+		TraceID:   dbAnnotation.TraceID,
+		Source:    storage.AnnotationSource(dbAnnotation.Source),
+		AuthorID:  dbAnnotation.AuthorID,
+		EndUserID: dbAnnotation.EndUserID,
+		Rating:    storage.Rating(dbAnnotation.Rating),
+		Scores:    dbAnnotation.Scores,
+		Comment:   dbAnnotation.Comment,
+		Labels:    dbAnnotation.Labels,
+		CreatedAt: dbAnnotation.CreatedAt,
+		UpdatedAt: dbAnnotation.UpdatedAt,
+	}
+}
@@ -0,0 +1,435 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/regrada-ai/regrada-be/internal/storage"
+	"github.com/uptrace/bun"
+)
+
+// archiveDeleteBatch bounds the number of row IDs in a single DELETE
+const archiveDeleteBatch = 1000
+
+// heldRestoreStatuses are the statuses of restores whose range is kept in
+// Postgres: retention neither purges its traces nor drops its partitions
+var heldRestoreStatuses = []string{
+	string(storage.RestorePending),
+	string(storage.RestoreRunning),
+	string(storage.RestoreCompleted),
+}
+
+type ArchiveRepository struct {
+	db *bun.DB
+}
+
+func NewArchiveRepository(db *bun.DB) *ArchiveRepository {
+	return &ArchiveRepository{db: db}
+}
+
+func (r *ArchiveRepository) GetPolicy(ctx context.Context, projectID string) (*int, error) {
+	var dbProject DBProject
+	err := r.db.NewSelect().
+		Model(&dbProject).
+		Column("archive_after_days").
+		Where("id = ?", projectID).
+		Scan(ctx)
+
+	if err == sql.ErrNoRows {
+		return nil, storage.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return dbProject.ArchiveAfter, nil
+}
+
+func (r *ArchiveRepository) SetPolicy(ctx context.Context, projectID string, days *int) error {
+	res, err := r.db.NewUpdate().
+		Model((*DBProject)(nil)).
+		Set("archive_after_days = ?", days).
+		Set("updated_at = ?", time.Now()).
+		Where("id = ?", projectID).
+		Where("deleted_at IS NULL").
+		Exec(ctx)
+
+	return checkRowsAffected(res, err)
+}
+
+func (r *ArchiveRepository) ListPolicies(ctx context.Context) ([]storage.ArchivePolicy, error) {
+	var dbProjects []DBProject
+	err := r.db.NewSelect().
+		Model(&dbProjects).
+		Column("id", "archive_after_days").
+		Where("archive_after_days IS NOT NULL").
+		Order("created_at").
+		Scan(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	policies := make([]storage.ArchivePolicy, len(dbProjects))
+	for i, dbProject := range dbProjects {
+		policies[i] = storage.ArchivePolicy{
+			ProjectID: dbProject.ID,
+			AfterDays: *dbProject.ArchiveAfter,
+		}
+	}
+	return policies, nil
+}
+
+func (r *ArchiveRepository) ListArchivable(ctx context.Context, projectID string, before time.Time, after *storage.ArchiveCursor, limit int) ([]storage.ArchivableTrace, error) {
+	var dbTraces []DBTrace
+	query := r.db.NewSelect().
+		Model(&dbTraces).
+		Where("t.project_id = ?", projectID).
+		Where("t.timestamp < ?", before).
+		Where("t.deleted_at IS NULL").
+		// Restored traces are already archived; the archiver deletes them
+		// when their restore expires or fails
+		Where("t.restore_id IS NULL").
+		// Leave ranges being restored alone until the restore finishes
+		Where(`NOT EXISTS (
+			SELECT 1 FROM trace_archive_restores AS r
+			WHERE r.project_id = t.project_id
+				AND t.timestamp >= r.range_start AND t.timestamp < r.range_end
+				AND r.status IN ('pending', 'running')
+		)`).
+		Order("t.timestamp", "t.id").
+		Limit(limit)
+
+	if after != nil {
+		query = query.Where("(t.timestamp, t.id) > (?, ?)", after.Timestamp, after.RowID)
+	}
+
+	if err := query.Scan(ctx); err != nil {
+		return nil, err
+	}
+
+	traces := make([]storage.ArchivableTrace, len(dbTraces))
+	for i := range dbTraces {
+		trace, err := toDomainTrace(&dbTraces[i])
+		if err != nil {
+			return nil, err
+		}
+		traces[i] = storage.ArchivableTrace{RowID: dbTraces[i].ID, Trace: *trace}
+	}
+	return traces, nil
+}
+
+func (r *ArchiveRepository) CreateArchive(ctx context.Context, archive *storage.TraceArchive, rowIDs []string) error {
+	dbArchive := &DBTraceArchive{
+		ProjectID:   archive.ProjectID,
+		StorageKey:  archive.StorageKey,
+		Format:      archive.Format,
+		PeriodStart: archive.PeriodStart,
+		PeriodEnd:   archive.PeriodEnd,
+		TraceCount:  archive.TraceCount,
+		SizeBytes:   archive.SizeBytes,
+		SHA256:      archive.SHA256,
+	}
+
+	return r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if _, err := tx.NewInsert().Model(dbArchive).Returning("id, created_at").Exec(ctx); err != nil {
+			return err
+		}
+
+		// Trace IDs stay claimed in trace_keys while their traces are archived
+		for start := 0; start < len(rowIDs); start += archiveDeleteBatch {
+			end := min(start+archiveDeleteBatch, len(rowIDs))
+			_, err := tx.NewDelete().
+				TableExpr("traces").
+				Where("project_id = ?", archive.ProjectID).
+				// Bound the timestamp so only partitions in the archive's period are scanned
+				Where("timestamp BETWEEN ? AND ?", archive.PeriodStart, archive.PeriodEnd).
+				Where("id IN (?)", bun.In(rowIDs[start:end])).
+				Exec(ctx)
+			if err != nil {
+				return err
+			}
+		}
+
+		archive.ID = dbArchive.ID
+		archive.CreatedAt = dbArchive.CreatedAt
+		return nil
+	})
+}
+
+func (r *ArchiveRepository) ListArchives(ctx context.Context, projectID string, from, to time.Time) ([]*storage.TraceArchive, error) {
+	query := r.db.NewSelect().
+		Model((*DBTraceArchive)(nil)).
+		Where("project_id = ?", projectID).
+		Order("period_start", "created_at")
+
+	if !from.IsZero() {
+		query = query.Where("period_end >= ?", from)
+	}
+	if !to.IsZero() {
+		query = query.Where("period_start <= ?", to)
+	}
+
+	var dbArchives []DBTraceArchive
+	if err := query.Scan(ctx, &dbArchives); err != nil {
+		return nil, err
+	}
+
+	archives := make([]*storage.TraceArchive, len(dbArchives))
+	for i, dbArchive := range dbArchives {
+		archives[i] = &storage.TraceArchive{
+			ID:          dbArchive.ID,
+			ProjectID:   dbArchive.ProjectID,
+			StorageKey:  dbArchive.StorageKey,
+			Format:      dbArchive.Format,
+			PeriodStart: dbArchive.PeriodStart,
+			PeriodEnd:   dbArchive.PeriodEnd,
+			TraceCount:  dbArchive.TraceCount,
+			SizeBytes:   dbArchive.SizeBytes,
+			SHA256:      dbArchive.SHA256,
+			CreatedAt:   dbArchive.CreatedAt,
+		}
+	}
+	return archives, nil
+}
+
+func (r *ArchiveRepository) DeleteArchive(ctx context.Context, id string) error {
+	return r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		var dbArchive DBTraceArchive
+		_, err := tx.NewDelete().
+			Model(&dbArchive).
+			Where("id = ?", id).
+			Returning("project_id, period_start, period_end").
+			Exec(ctx)
+		if err == sql.ErrNoRows {
+			return storage.ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if dbArchive.ProjectID == "" {
+			return storage.ErrNotFound
+		}
+
+		// Release the trace IDs that now exist nowhere: not in Postgres and
+		// not in another archive
+		_, err = tx.NewDelete().
+			Model((*DBTraceKey)(nil)).
+			Where("k.project_id = ?", dbArchive.ProjectID).
+			Where("k.timestamp BETWEEN ? AND ?", dbArchive.PeriodStart, dbArchive.PeriodEnd).
+			Where(`NOT EXISTS (
+				SELECT 1 FROM traces AS t
+				WHERE t.project_id = k.project_id AND t.trace_id = k.trace_id AND t.timestamp = k.timestamp
+			)`).
+			Where(`NOT EXISTS (
+				SELECT 1 FROM trace_archives AS a
+				WHERE a.project_id = k.project_id AND k.timestamp BETWEEN a.period_start AND a.period_end
+			)`).
+			Exec(ctx)
+		return err
+	})
+}
+
+func (r *ArchiveRepository) CreateRestore(ctx context.Context, restore *storage.ArchiveRestore) error {
+	dbRestore := toDBArchiveRestore(restore)
+	dbRestore.Status = string(storage.RestorePending)
+
+	_, err := r.db.NewInsert().
+		Model(dbRestore).
+		Returning("*").
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+
+	*restore = *toArchiveRestore(dbRestore)
+	return nil
+}
+
+func (r *ArchiveRepository) GetRestore(ctx context.Context, projectID, id string) (*storage.ArchiveRestore, error) {
+	var dbRestore DBArchiveRestore
+	err := r.db.NewSelect().
+		Model(&dbRestore).
+		Where("id = ?", id).
+		Where("project_id = ?", projectID).
+		Scan(ctx)
+
+	if err == sql.ErrNoRows {
+		return nil, storage.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return toArchiveRestore(&dbRestore), nil
+}
+
+func (r *ArchiveRepository) ListRestores(ctx context.Context, projectID string, limit int) ([]*storage.ArchiveRestore, error) {
+	var dbRestores []DBArchiveRestore
+	err := r.db.NewSelect().
+		Model(&dbRestores).
+		Where("project_id = ?", projectID).
+		Order("created_at DESC").
+		Limit(limit).
+		Scan(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	restores := make([]*storage.ArchiveRestore, len(dbRestores))
+	for i := range dbRestores {
+		restores[i] = toArchiveRestore(&dbRestores[i])
+	}
+	return restores, nil
+}
+
+func (r *ArchiveRepository) ClaimRestore(ctx context.Context, staleAfter time.Duration) (*storage.ArchiveRestore, error) {
+	next := r.db.NewSelect().
+		Model((*DBArchiveRestore)(nil)).
+		Column("id").
+		WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.Where("status = ?", storage.RestorePending).
+				WhereOr("status = ? AND updated_at < ?", storage.RestoreRunning, time.Now().Add(-staleAfter))
+		}).
+		Order("created_at").
+		Limit(1).
+		For("UPDATE SKIP LOCKED")
+
+	var dbRestore DBArchiveRestore
+	_, err := r.db.NewUpdate().
+		Model(&dbRestore).
+		Set("status = ?", storage.RestoreRunning).
+		Where("id = (?)", next).
+		Returning("*").
+		Exec(ctx)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if dbRestore.ID == "" {
+		return nil, nil
+	}
+
+	return toArchiveRestore(&dbRestore), nil
+}
+
+func (r *ArchiveRepository) UpdateRestore(ctx context.Context, restore *storage.ArchiveRestore) error {
+	res, err := r.db.NewUpdate().
+		Model(toDBArchiveRestore(restore)).
+		Column("status", "archives_total", "archives_restored", "traces_restored", "error", "expires_at", "completed_at").
+		WherePK().
+		Exec(ctx)
+
+	return checkRowsAffected(res, err)
+}
+
+func (r *ArchiveRepository) ListReleasableRestores(ctx context.Context, before time.Time) ([]*storage.ArchiveRestore, error) {
+	var dbRestores []DBArchiveRestore
+	err := r.db.NewSelect().
+		Model(&dbRestores).
+		WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.Where("status = ? AND expires_at < ?", storage.RestoreCompleted, before).
+				WhereOr("status = ? AND EXISTS (SELECT 1 FROM traces AS t WHERE t.restore_id = tar.id)", storage.RestoreFailed)
+		}).
+		Order("created_at").
+		Scan(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	restores := make([]*storage.ArchiveRestore, len(dbRestores))
+	for i := range dbRestores {
+		restores[i] = toArchiveRestore(&dbRestores[i])
+	}
+	return restores, nil
+}
+
+func (r *ArchiveRepository) DeleteRestoredTraces(ctx context.Context, restore *storage.ArchiveRestore, limit int) (int64, error) {
+	// Traces also in the range of another restore that is still held were
+	// skipped by it as already present, so they are handed over to it
+	_, err := r.db.NewRaw(`
+		UPDATE traces AS t SET restore_id = (
+			SELECT r.id FROM trace_archive_restores AS r
+			WHERE r.project_id = t.project_id AND r.id <> t.restore_id
+				AND t.timestamp >= r.range_start AND t.timestamp < r.range_end
+				AND r.status IN (?)
+			ORDER BY r.expires_at DESC NULLS FIRST
+			LIMIT 1
+		)
+		WHERE t.restore_id = ? AND EXISTS (
+			SELECT 1 FROM trace_archive_restores AS r
+			WHERE r.project_id = t.project_id AND r.id <> t.restore_id
+				AND t.timestamp >= r.range_start AND t.timestamp < r.range_end
+				AND r.status IN (?)
+		)`, bun.In(heldRestoreStatuses), restore.ID, bun.In(heldRestoreStatuses)).
+		Exec(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	// Their trace IDs stay claimed, since the traces are still archived
+	batch := r.db.NewSelect().
+		TableExpr("traces").
+		Column("id").
+		Where("restore_id = ?", restore.ID).
+		Limit(limit)
+
+	res, err := r.db.NewDelete().
+		TableExpr("traces").
+		Where("project_id = ?", restore.ProjectID).
+		Where("timestamp >= ? AND timestamp < ?", restore.RangeStart, restore.RangeEnd).
+		Where("id IN (?)", batch).
+		Exec(ctx)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+func toDBArchiveRestore(restore *storage.ArchiveRestore) *DBArchiveRestore {
+	return &DBArchiveRestore{
+		ID:               restore.ID,
+		ProjectID:        restore.ProjectID,
+		RequestedBy:      restore.RequestedBy,
+		RangeStart:       restore.RangeStart,
+		RangeEnd:         restore.RangeEnd,
+		KeepDays:         restore.KeepDays,
+		Status:           string(restore.Status),
+		ArchivesTotal:    restore.ArchivesTotal,
+		ArchivesRestored: restore.ArchivesRestored,
+		TracesRestored:   restore.TracesRestored,
+		Error:            restore.Error,
+		ExpiresAt:        restore.ExpiresAt,
+		CompletedAt:      restore.CompletedAt,
+	}
+}
+
+func toArchiveRestore(dbRestore *DBArchiveRestore) *storage.ArchiveRestore {
+	return &storage.ArchiveRestore{
+		ID:               dbRestore.ID,
+		ProjectID:        dbRestore.ProjectID,
+		RequestedBy:      dbRestore.RequestedBy,
+		RangeStart:       dbRestore.RangeStart,
+		RangeEnd:         dbRestore.RangeEnd,
+		KeepDays:         dbRestore.KeepDays,
+		Status:           storage.RestoreStatus(dbRestore.Status),
+		ArchivesTotal:    dbRestore.ArchivesTotal,
+		ArchivesRestored: dbRestore.ArchivesRestored,
+		TracesRestored:   dbRestore.TracesRestored,
+		Error:            dbRestore.Error,
+		ExpiresAt:        dbRestore.ExpiresAt,
+		CreatedAt:        dbRestore.CreatedAt,
+		UpdatedAt:        dbRestore.UpdatedAt,
+		CompletedAt:      dbRestore.CompletedAt,
+	}
+}
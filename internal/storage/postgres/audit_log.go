@@ -0,0 +1,100 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package postgres
+
+import (
+	"context"
+
+	"github.com/regrada-ai/regrada-be/internal/storage"
+	"github.com/uptrace/bun"
+)
+
+type AuditLogRepository struct {
+	db *bun.DB
+}
+
+func NewAuditLogRepository(db *bun.DB) *AuditLogRepository {
+	return &AuditLogRepository{db: db}
+}
+
+func (r *AuditLogRepository) Append(ctx context.Context, entry *storage.AuditLogEntry) error {
+	dbEntry := &DBAuditLogEntry{
+		OrganizationID: entry.OrganizationID,
+		ActorType:      entry.ActorType,
+		ActorID:        entry.ActorID,
+		ActorName:      entry.ActorName,
+		Action:         entry.Action,
+		TargetType:     entry.TargetType,
+		TargetID:       entry.TargetID,
+		Changes:        entry.Changes,
+		IPAddress:      entry.IPAddress,
+		UserAgent:      entry.UserAgent,
+	}
+	if dbEntry.Changes == nil {
+		dbEntry.Changes = []byte("{}")
+	}
+	_, err := r.db.NewInsert().
+		Model(dbEntry).
+		Returning("*").
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+
+	*entry = *toAuditLogEntry(dbEntry)
+	return nil
+}
+
+func (r *AuditLogRepository) List(ctx context.Context, orgID string, filter storage.AuditLogFilter, limit, offset int) ([]*storage.AuditLogEntry, error) {
+	var dbEntries []DBAuditLogEntry
+	query := r.db.NewSelect().
+		Model(&dbEntries).
+		Where("organization_id = ?", orgID).
+		Order("created_at DESC", "id DESC").
+		Limit(limit).
+		Offset(offset)
+	if filter.ActorID != "" {
+		query = query.Where("actor_id = ?", filter.ActorID)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.TargetType != "" {
+		query = query.Where("target_type = ?", filter.TargetType)
+	}
+	if filter.TargetID != "" {
+		query = query.Where("target_id = ?", filter.TargetID)
+	}
+	if !filter.From.IsZero() {
+		query = query.Where("created_at >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("created_at < ?", filter.To)
+	}
+	if err := query.Scan(ctx); err != nil {
+		return nil, err
+	}
+
+	entries := make([]*storage.AuditLogEntry, len(dbEntries))
+	for i := range dbEntries {
+		entries[i] = toAuditLogEntry(&dbEntries[i])
+	}
+	return entries, nil
+}
+
+func toAuditLogEntry(dbEntry *DBAuditLogEntry) *storage.AuditLogEntry {
+	return &storage.AuditLogEntry{
+		ID:             dbEntry.ID,
+		OrganizationID: dbEntry.OrganizationID,
+		ActorType:      dbEntry.ActorType,
+		ActorID:        dbEntry.ActorID,
+		ActorName:      dbEntry.ActorName,
+		Action:         dbEntry.Action,
+		TargetType:     dbEntry.TargetType,
+		TargetID:       dbEntry.TargetID,
+		Changes:        dbEntry.Changes,
+		IPAddress:      dbEntry.IPAddress,
+		UserAgent:      dbEntry.UserAgent,
+		CreatedAt:      dbEntry.CreatedAt,
+	}
+}
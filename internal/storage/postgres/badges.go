@@ -0,0 +1,113 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/regrada-ai/regrada-be/internal/storage"
+	"github.com/uptrace/bun"
+)
+
+type BadgeRepository struct {
+	db *bun.DB
+}
+
+func NewBadgeRepository(db *bun.DB) *BadgeRepository {
+	return &BadgeRepository{db: db}
+}
+
+func (r *BadgeRepository) Create(ctx context.Context, token *storage.BadgeToken) error {
+	dbToken := &DBBadgeToken{
+		ProjectID:   token.ProjectID,
+		TokenHash:   token.TokenHash,
+		TokenPrefix: token.TokenPrefix,
+		Name:        token.Name,
+		CreatedBy:   token.CreatedBy,
+	}
+	_, err := r.db.NewInsert().
+		Model(dbToken).
+		Returning("*").
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+
+	*token = *toBadgeToken(dbToken)
+	return nil
+}
+
+func (r *BadgeRepository) List(ctx context.Context, projectID string) ([]*storage.BadgeToken, error) {
+	var dbTokens []DBBadgeToken
+	err := r.db.NewSelect().
+		Model(&dbTokens).
+		Where("project_id = ?", projectID).
+		Order("created_at DESC").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens := make([]*storage.BadgeToken, len(dbTokens))
+	for i := range dbTokens {
+		tokens[i] = toBadgeToken(&dbTokens[i])
+	}
+	return tokens, nil
+}
+
+func (r *BadgeRepository) GetByHash(ctx context.Context, tokenHash string) (*storage.BadgeToken, error) {
+	var dbToken DBBadgeToken
+	err := r.db.NewSelect().
+		Model(&dbToken).
+		Where("token_hash = ?", tokenHash).
+		Where("revoked_at IS NULL").
+		Scan(ctx)
+
+	if err == sql.ErrNoRows {
+		return nil, storage.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return toBadgeToken(&dbToken), nil
+}
+
+func (r *BadgeRepository) Revoke(ctx context.Context, projectID, id string) (*storage.BadgeToken, error) {
+	var dbToken DBBadgeToken
+	_, err := r.db.NewUpdate().
+		Model(&dbToken).
+		Set("revoked_at = ?", time.Now()).
+		Where("id = ?", id).
+		Where("project_id = ?", projectID).
+		Where("revoked_at IS NULL").
+		Returning("*").
+		Exec(ctx)
+
+	if err == sql.ErrNoRows {
+		return nil, storage.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if dbToken.ID == "" {
+		return nil, storage.ErrNotFound
+	}
+
+	return toBadgeToken(&dbToken), nil
+}
+
+func toBadgeToken(dbToken *DBBadgeToken) *storage.BadgeToken {
+	return &storage.BadgeToken{
+		ID:          dbToken.ID,
+		ProjectID:   dbToken.ProjectID,
+		TokenHash:   dbToken.TokenHash,
+		TokenPrefix: dbToken.TokenPrefix,
+		Name:        dbToken.Name,
+		CreatedBy:   dbToken.CreatedBy,
+		CreatedAt:   dbToken.CreatedAt,
+		RevokedAt:   dbToken.RevokedAt,
+	}
+}
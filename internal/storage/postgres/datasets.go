@@ -0,0 +1,371 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/regrada-ai/regrada-be/internal/domain"
+	"github.com/regrada-ai/regrada-be/internal/storage"
+	"github.com/uptrace/bun"
+)
+
+const (
+	datasetItemCountExpr = "(SELECT count(*) FROM dataset_items AS dsi WHERE dsi.dataset_id = ds.id AND dsi.removed_version IS NULL) AS item_count"
+
+	datasetNameConflict = "ERROR: duplicate key value violates unique constraint \"datasets_project_id_name_key\" (SQLSTATE=23505)"
+)
+
+type DatasetRepository struct {
+	db *bun.DB
+}
+
+func NewDatasetRepository(db *bun.DB) *DatasetRepository {
+	return &DatasetRepository{db: db}
+}
+
+func (r *DatasetRepository) Create(ctx context.Context, dataset *storage.Dataset) error {
+	dbDataset := &DBDataset{
+		ProjectID:   dataset.ProjectID,
+		Name:        dataset.Name,
+		Description: dataset.Description,
+		CreatedBy:   dataset.CreatedBy,
+	}
+
+	_, err := r.db.NewInsert().Model(dbDataset).Returning("id, version, created_at, updated_at").Exec(ctx)
+	if err != nil {
+		if err.Error() == datasetNameConflict {
+			return storage.ErrAlreadyExists
+		}
+		return err
+	}
+
+	*dataset = *toDataset(dbDataset)
+	return nil
+}
+
+func (r *DatasetRepository) Get(ctx context.Context, projectID, id string) (*storage.Dataset, error) {
+	var dbDataset DBDataset
+	err := r.db.NewSelect().
+		Model(&dbDataset).
+		ColumnExpr("ds.*").
+		ColumnExpr(datasetItemCountExpr).
+		Where("ds.id = ?", id).
+		Where("ds.project_id = ?", projectID).
+		Scan(ctx)
+
+	if err == sql.ErrNoRows {
+		return nil, storage.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return toDataset(&dbDataset), nil
+}
+
+func (r *DatasetRepository) List(ctx context.Context, projectID string) ([]*storage.Dataset, error) {
+	var dbDatasets []DBDataset
+	err := r.db.NewSelect().
+		Model(&dbDatasets).
+		ColumnExpr("ds.*").
+		ColumnExpr(datasetItemCountExpr).
+		Where("ds.project_id = ?", projectID).
+		Order("ds.name").
+		Scan(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	datasets := make([]*storage.Dataset, len(dbDatasets))
+	for i := range dbDatasets {
+		datasets[i] = toDataset(&dbDatasets[i])
+	}
+	return datasets, nil
+}
+
+func (r *DatasetRepository) Update(ctx context.Context, dataset *storage.Dataset) error {
+	dbDataset := &DBDataset{
+		ID:          dataset.ID,
+		Name:        dataset.Name,
+		Description: dataset.Description,
+	}
+
+	res, err := r.db.NewUpdate().
+		Model(dbDataset).
+		Column("name", "description").
+		Where("id = ?", dataset.ID).
+		Where("project_id = ?", dataset.ProjectID).
+		Exec(ctx)
+
+	if err != nil && err.Error() == datasetNameConflict {
+		return storage.ErrAlreadyExists
+	}
+	return checkRowsAffected(res, err)
+}
+
+func (r *DatasetRepository) Delete(ctx context.Context, projectID, id string) error {
+	res, err := r.db.NewDelete().
+		Model((*DBDataset)(nil)).
+		Where("id = ?", id).
+		Where("project_id = ?", projectID).
+		Exec(ctx)
+
+	return checkRowsAffected(res, err)
+}
+
+func (r *DatasetRepository) AddItems(ctx context.Context, projectID, datasetID string, items []*storage.DatasetItem) (int, int, error) {
+	var version, added int
+	err := r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		current, err := lockDataset(ctx, tx, projectID, datasetID)
+		if err != nil {
+			return err
+		}
+		version = current
+		if len(items) == 0 {
+			return nil
+		}
+
+		dbItems := make([]*DBDatasetItem, len(items))
+		for i, item := range items {
+			dbItem, err := toDBDatasetItem(datasetID, item)
+			if err != nil {
+				return err
+			}
+			dbItem.AddedVersion = current + 1
+			dbItems[i] = dbItem
+		}
+
+		res, err := tx.NewInsert().
+			Model(&dbItems).
+			ExcludeColumn("id", "item_id", "created_at").
+			On("CONFLICT (dataset_id, source_trace_id) WHERE removed_version IS NULL AND source_trace_id IS NOT NULL DO NOTHING").
+			Returning("NULL").
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		added = int(n)
+
+		if added == 0 {
+			return nil
+		}
+		version = current + 1
+		return setDatasetVersion(ctx, tx, datasetID, version)
+	})
+	return version, added, err
+}
+
+func (r *DatasetRepository) ListItems(ctx context.Context, projectID, datasetID string, version int) ([]*storage.DatasetItem, error) {
+	dataset, err := r.Get(ctx, projectID, datasetID)
+	if err != nil {
+		return nil, err
+	}
+	if version == 0 {
+		version = dataset.Version
+	}
+	if version < 1 || version > dataset.Version {
+		return nil, storage.ErrNotFound
+	}
+
+	var dbItems []DBDatasetItem
+	err = r.db.NewSelect().
+		Model(&dbItems).
+		Where("dataset_id = ?", datasetID).
+		Where("added_version <= ?", version).
+		Where("(removed_version IS NULL OR removed_version > ?)", version).
+		Order("created_at", "item_id").
+		Scan(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]*storage.DatasetItem, len(dbItems))
+	for i := range dbItems {
+		item, err := toDatasetItem(&dbItems[i])
+		if err != nil {
+			return nil, err
+		}
+		items[i] = item
+	}
+	return items, nil
+}
+
+func (r *DatasetRepository) SetExpectedOutput(ctx context.Context, projectID, datasetID, itemID string, expected *storage.DatasetExpectedOutput) (*storage.DatasetItem, error) {
+	var item *storage.DatasetItem
+	err := r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		current, err := lockDataset(ctx, tx, projectID, datasetID)
+		if err != nil {
+			return err
+		}
+		version := current + 1
+
+		// Close the current row and insert a copy, so earlier versions keep
+		// the old expected output
+		var dbItem DBDatasetItem
+		_, err = tx.NewUpdate().
+			Model(&dbItem).
+			Set("removed_version = ?", version).
+			Where("dataset_id = ?", datasetID).
+			Where("item_id = ?", itemID).
+			Where("removed_version IS NULL").
+			Returning("*").
+			Exec(ctx)
+		if err == sql.ErrNoRows || (err == nil && dbItem.ID == "") {
+			return storage.ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		dbItem.ID = ""
+		dbItem.ExpectedOutput = nil
+		if expected != nil {
+			if dbItem.ExpectedOutput, err = json.Marshal(expected); err != nil {
+				return err
+			}
+		}
+		dbItem.AddedVersion = version
+		dbItem.RemovedVersion = nil
+		if _, err := tx.NewInsert().Model(&dbItem).ExcludeColumn("id").Returning("NULL").Exec(ctx); err != nil {
+			return err
+		}
+
+		if item, err = toDatasetItem(&dbItem); err != nil {
+			return err
+		}
+		return setDatasetVersion(ctx, tx, datasetID, version)
+	})
+	return item, err
+}
+
+func (r *DatasetRepository) RemoveItem(ctx context.Context, projectID, datasetID, itemID string) (int, error) {
+	var version int
+	err := r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		current, err := lockDataset(ctx, tx, projectID, datasetID)
+		if err != nil {
+			return err
+		}
+		version = current + 1
+
+		res, err := tx.NewUpdate().
+			Model((*DBDatasetItem)(nil)).
+			Set("removed_version = ?", version).
+			Where("dataset_id = ?", datasetID).
+			Where("item_id = ?", itemID).
+			Where("removed_version IS NULL").
+			Exec(ctx)
+		if err := checkRowsAffected(res, err); err != nil {
+			return err
+		}
+
+		return setDatasetVersion(ctx, tx, datasetID, version)
+	})
+	return version, err
+}
+
+// lockDataset locks a dataset row for the rest of the transaction, so changes
+// to its items are applied one version at a time, and returns its version
+func lockDataset(ctx context.Context, tx bun.Tx, projectID, datasetID string) (int, error) {
+	var version int
+	err := tx.NewSelect().
+		Model((*DBDataset)(nil)).
+		Column("version").
+		Where("id = ?", datasetID).
+		Where("project_id = ?", projectID).
+		For("UPDATE").
+		Scan(ctx, &version)
+
+	if err == sql.ErrNoRows {
+		return 0, storage.ErrNotFound
+	}
+	return version, err
+}
+
+func setDatasetVersion(ctx context.Context, tx bun.Tx, datasetID string, version int) error {
+	_, err := tx.NewUpdate().
+		Model((*DBDataset)(nil)).
+		Set("version = ?", version).
+		Where("id = ?", datasetID).
+		Exec(ctx)
+	return err
+}
+
+func toDataset(dbDataset *DBDataset) *storage.Dataset {
+	return &storage.Dataset{
+		ID:          dbDataset.ID,
+		ProjectID:   dbDataset.ProjectID,
+		Name:        dbDataset.Name,
+		Description: dbDataset.Description,
+		Version:     dbDataset.Version,
+		ItemCount:   dbDataset.ItemCount,
+		CreatedBy:   dbDataset.CreatedBy,
+		CreatedAt:   dbDataset.CreatedAt,
+		UpdatedAt:   dbDataset.UpdatedAt,
+	}
+}
+
+func toDBDatasetItem(datasetID string, item *storage.DatasetItem) (*DBDatasetItem, error) {
+	messages := item.Messages
+	if messages == nil {
+		messages = []domain.Message{}
+	}
+	messagesData, err := json.Marshal(messages)
+	if err != nil {
+		return nil, err
+	}
+
+	dbItem := &DBDatasetItem{
+		DatasetID:     datasetID,
+		SourceTraceID: item.SourceTraceID,
+		Messages:      messagesData,
+		Tags:          item.Tags,
+	}
+	if item.Params != nil {
+		if dbItem.Params, err = json.Marshal(item.Params); err != nil {
+			return nil, err
+		}
+	}
+	if item.ExpectedOutput != nil {
+		if dbItem.ExpectedOutput, err = json.Marshal(item.ExpectedOutput); err != nil {
+			return nil, err
+		}
+	}
+	return dbItem, nil
+}
+
+func toDatasetItem(dbItem *DBDatasetItem) (*storage.DatasetItem, error) {
+	item := &storage.DatasetItem{
+		ID:            dbItem.ItemID,
+		DatasetID:     dbItem.DatasetID,
+		SourceTraceID: dbItem.SourceTraceID,
+		Tags:          dbItem.Tags,
+		Version:       dbItem.AddedVersion,
+		CreatedAt:     dbItem.CreatedAt,
+	}
+
+	if err := decodeJSONField(dbItem.Messages, &item.Messages); err != nil {
+		return nil, err
+	}
+	if len(dbItem.Params) > 0 {
+		item.Params = &domain.SamplingParams{}
+		if err := decodeJSONField(dbItem.Params, item.Params); err != nil {
+			return nil, err
+		}
+	}
+	if len(dbItem.ExpectedOutput) > 0 {
+		item.ExpectedOutput = &storage.DatasetExpectedOutput{}
+		if err := decodeJSONField(dbItem.ExpectedOutput, item.ExpectedOutput); err != nil {
+			return nil, err
+		}
+	}
+	return item, nil
+}
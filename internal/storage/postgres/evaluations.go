@@ -0,0 +1,360 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/regrada-ai/regrada-be/internal/domain"
+	"github.com/regrada-ai/regrada-be/internal/storage"
+	"github.com/uptrace/bun"
+)
+
+const evaluatorNameConflict = "ERROR: duplicate key value violates unique constraint \"evaluators_project_id_name_key\" (SQLSTATE=23505)"
+
+type EvaluationRepository struct {
+	db *bun.DB
+}
+
+func NewEvaluationRepository(db *bun.DB) *EvaluationRepository {
+	return &EvaluationRepository{db: db}
+}
+
+func (r *EvaluationRepository) CreateEvaluator(ctx context.Context, evaluator *storage.Evaluator) error {
+	dbEvaluator := toDBEvaluator(evaluator)
+	return r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		_, err := tx.NewInsert().Model(dbEvaluator).Returning("id, created_at, updated_at").Exec(ctx)
+		if err != nil {
+			if err.Error() == evaluatorNameConflict {
+				return storage.ErrAlreadyExists
+			}
+			return err
+		}
+
+		// Evaluation covers the traces stored from the project's first
+		// evaluator onwards
+		cursor := &DBEvaluationCursor{ProjectID: evaluator.ProjectID}
+		_, err = tx.NewInsert().Model(cursor).On("CONFLICT (project_id) DO NOTHING").Returning("NULL").Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		evaluator.ID = dbEvaluator.ID
+		evaluator.CreatedAt = dbEvaluator.CreatedAt
+		evaluator.UpdatedAt = dbEvaluator.UpdatedAt
+		return nil
+	})
+}
+
+func (r *EvaluationRepository) GetEvaluator(ctx context.Context, projectID, id string) (*storage.Evaluator, error) {
+	var dbEvaluator DBEvaluator
+	err := r.db.NewSelect().
+		Model(&dbEvaluator).
+		Where("id = ?", id).
+		Where("project_id = ?", projectID).
+		Scan(ctx)
+
+	if err == sql.ErrNoRows {
+		return nil, storage.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return toEvaluator(&dbEvaluator), nil
+}
+
+func (r *EvaluationRepository) ListEvaluators(ctx context.Context, projectID string) ([]*storage.Evaluator, error) {
+	var dbEvaluators []DBEvaluator
+	err := r.db.NewSelect().
+		Model(&dbEvaluators).
+		Where("project_id = ?", projectID).
+		Order("name").
+		Scan(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	evaluators := make([]*storage.Evaluator, len(dbEvaluators))
+	for i := range dbEvaluators {
+		evaluators[i] = toEvaluator(&dbEvaluators[i])
+	}
+	return evaluators, nil
+}
+
+func (r *EvaluationRepository) UpdateEvaluator(ctx context.Context, evaluator *storage.Evaluator) error {
+	res, err := r.db.NewUpdate().
+		Model(toDBEvaluator(evaluator)).
+		Column("name", "config", "enabled").
+		Where("id = ?", evaluator.ID).
+		Where("project_id = ?", evaluator.ProjectID).
+		Exec(ctx)
+
+	if err != nil && err.Error() == evaluatorNameConflict {
+		return storage.ErrAlreadyExists
+	}
+	return checkRowsAffected(res, err)
+}
+
+func (r *EvaluationRepository) DeleteEvaluator(ctx context.Context, projectID, id string) error {
+	res, err := r.db.NewDelete().
+		Model((*DBEvaluator)(nil)).
+		Where("id = ?", id).
+		Where("project_id = ?", projectID).
+		Exec(ctx)
+
+	return checkRowsAffected(res, err)
+}
+
+func (r *EvaluationRepository) ListEvaluatingProjects(ctx context.Context) ([]string, error) {
+	var projectIDs []string
+	err := r.db.NewSelect().
+		Model((*DBEvaluator)(nil)).
+		ColumnExpr("DISTINCT project_id").
+		Where("enabled").
+		Scan(ctx, &projectIDs)
+
+	return projectIDs, err
+}
+
+func (r *EvaluationRepository) EvaluateNext(ctx context.Context, projectID string, limit int, evaluate storage.EvaluateFunc) (int, error) {
+	var evaluated int
+	err := r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		var cursor DBEvaluationCursor
+		err := tx.NewSelect().
+			Model(&cursor).
+			Where("project_id = ?", projectID).
+			For("UPDATE SKIP LOCKED").
+			Scan(ctx)
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var dbEvaluators []DBEvaluator
+		err = tx.NewSelect().
+			Model(&dbEvaluators).
+			Where("project_id = ?", projectID).
+			Where("enabled").
+			Scan(ctx)
+		if err != nil || len(dbEvaluators) == 0 {
+			return err
+		}
+		evaluators := make([]*storage.Evaluator, len(dbEvaluators))
+		for i := range dbEvaluators {
+			evaluators[i] = toEvaluator(&dbEvaluators[i])
+		}
+
+		// Locking the traces makes a concurrent replace wait until they are
+		// marked, so its reset of evaluated_at is not overwritten. Traces in
+		// other transactions are skipped and picked up by a later batch.
+		var dbTraces []DBTrace
+		err = tx.NewSelect().
+			Model(&dbTraces).
+			Where("t.project_id = ?", projectID).
+			Where("t.evaluated_at IS NULL").
+			Where("t.created_at >= ?", cursor.EvaluatingSince).
+			OrderExpr("t.created_at, t.id").
+			Limit(limit).
+			For("UPDATE OF t SKIP LOCKED").
+			Scan(ctx)
+		if err != nil || len(dbTraces) == 0 {
+			return err
+		}
+
+		ids := make([]string, len(dbTraces))
+		traces := make([]*domain.Trace, 0, len(dbTraces))
+		for i := range dbTraces {
+			ids[i] = dbTraces[i].ID
+			trace, err := toDomainTrace(&dbTraces[i])
+			if err != nil {
+				// Mark rather than retry forever; the trace has no results
+				log.Printf("Failed to decode trace %s for evaluation: %v", dbTraces[i].TraceID, err)
+				continue
+			}
+			traces = append(traces, trace)
+		}
+
+		if err := saveEvaluationResults(ctx, tx, projectID, evaluate(evaluators, traces)); err != nil {
+			return err
+		}
+
+		_, err = tx.NewUpdate().
+			Model((*DBTrace)(nil)).
+			Set("evaluated_at = now()").
+			Where("project_id = ?", projectID).
+			Where("id IN (?)", bun.In(ids)).
+			Exec(ctx)
+		evaluated = len(dbTraces)
+		return err
+	})
+	return evaluated, err
+}
+
+// saveEvaluationResults upserts results, keeping the last result for each
+// trace ID and evaluator, since a batch can hold traces that share an ID
+func saveEvaluationResults(ctx context.Context, tx bun.Tx, projectID string, results []*storage.EvaluationResult) error {
+	type key struct{ evaluatorID, traceID string }
+	index := make(map[key]int, len(results))
+	dbResults := make([]*DBEvaluationResult, 0, len(results))
+	for _, result := range results {
+		dbResult := &DBEvaluationResult{
+			ProjectID:   projectID,
+			TraceID:     result.TraceID,
+			EvaluatorID: result.EvaluatorID,
+			Passed:      result.Passed,
+			Message:     result.Message,
+		}
+		k := key{result.EvaluatorID, result.TraceID}
+		if i, ok := index[k]; ok {
+			dbResults[i] = dbResult
+			continue
+		}
+		index[k] = len(dbResults)
+		dbResults = append(dbResults, dbResult)
+	}
+	if len(dbResults) == 0 {
+		return nil
+	}
+
+	_, err := tx.NewInsert().
+		Model(&dbResults).
+		ExcludeColumn("id", "created_at").
+		On("CONFLICT (evaluator_id, trace_id) DO UPDATE").
+		Set("passed = EXCLUDED.passed").
+		Set("message = EXCLUDED.message").
+		Set("created_at = now()").
+		Returning("NULL").
+		Exec(ctx)
+	return err
+}
+
+func (r *EvaluationRepository) ListResults(ctx context.Context, projectID, traceID string) ([]*storage.EvaluationResult, error) {
+	var dbResults []DBEvaluationResult
+	err := r.db.NewSelect().
+		Model(&dbResults).
+		ColumnExpr("er.*").
+		ColumnExpr("ev.name AS evaluator_name, ev.type AS evaluator_type").
+		Join("JOIN evaluators AS ev ON ev.id = er.evaluator_id").
+		Where("er.project_id = ?", projectID).
+		Where("er.trace_id = ?", traceID).
+		Order("ev.name").
+		Scan(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*storage.EvaluationResult, len(dbResults))
+	for i := range dbResults {
+		results[i] = toEvaluationResult(&dbResults[i])
+	}
+	return results, nil
+}
+
+func (r *EvaluationRepository) PassRates(ctx context.Context, projectID string, from, to time.Time) ([]storage.EvaluatorPassRate, error) {
+	join := "LEFT JOIN evaluation_results AS er ON er.evaluator_id = ev.id"
+	var args []any
+	if !from.IsZero() {
+		join += " AND er.created_at >= ?"
+		args = append(args, from)
+	}
+	if !to.IsZero() {
+		join += " AND er.created_at < ?"
+		args = append(args, to)
+	}
+
+	rates := []storage.EvaluatorPassRate{}
+	err := r.db.NewSelect().
+		Model((*DBEvaluator)(nil)).
+		ColumnExpr("ev.id AS evaluator_id, ev.name AS evaluator_name, ev.type AS evaluator_type").
+		ColumnExpr("count(er.id) AS total").
+		ColumnExpr("count(er.id) FILTER (WHERE er.passed) AS passed").
+		Join(join, args...).
+		Where("ev.project_id = ?", projectID).
+		Group("ev.id").
+		Order("ev.name").
+		Scan(ctx, &rates)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range rates {
+		if rates[i].Total > 0 {
+			rates[i].PassRate = float64(rates[i].Passed) / float64(rates[i].Total)
+		}
+	}
+	return rates, nil
+}
+
+// evaluationFilter restricts a trace query to traces with an evaluation
+// result matching the filter's evaluation fields
+func evaluationFilter(q *bun.SelectQuery, filter storage.TraceFilter) *bun.SelectQuery {
+	if filter.EvaluatorID == "" && filter.Evaluation == "" {
+		return q
+	}
+
+	sub := q.NewSelect().
+		Model((*DBEvaluationResult)(nil)).
+		ColumnExpr("1").
+		Where("er.project_id = t.project_id").
+		Where("er.trace_id = t.trace_id")
+	if filter.EvaluatorID != "" {
+		sub = sub.Where("er.evaluator_id = ?", filter.EvaluatorID)
+	}
+	if filter.Evaluation != "" {
+		sub = sub.Where("er.passed = ?", filter.Evaluation == "pass")
+	}
+	return q.Where("EXISTS (?)", sub)
+}
+
+func toDBEvaluator(evaluator *storage.Evaluator) *DBEvaluator {
+	config := evaluator.Config
+	if len(config) == 0 {
+		config = []byte("{}")
+	}
+
+	return &DBEvaluator{
+		ID:        evaluator.ID,
+		ProjectID: evaluator.ProjectID,
+		Name:      evaluator.Name,
+		Type:      string(evaluator.Type),
+		Config:    config,
+		Enabled:   evaluator.Enabled,
+		CreatedBy: evaluator.CreatedBy,
+	}
+}
+
+func toEvaluator(dbEvaluator *DBEvaluator) *storage.Evaluator {
+	return &storage.Evaluator{
+		ID:        dbEvaluator.ID,
+		ProjectID: dbEvaluator.ProjectID,
+		Name:      dbEvaluator.Name,
+		Type:      storage.EvaluatorType(dbEvaluator.Type),
+		Config:    dbEvaluator.Config,
+		Enabled:   dbEvaluator.Enabled,
+		CreatedBy: dbEvaluator.CreatedBy,
+		CreatedAt: dbEvaluator.CreatedAt,
+		UpdatedAt: dbEvaluator.UpdatedAt,
+	}
+}
+
+func toEvaluationResult(dbResult *DBEvaluationResult) *storage.EvaluationResult {
+	return &storage.EvaluationResult{
+		ProjectID:     dbResult.ProjectID,
+		TraceID:       dbResult.TraceID,
+		EvaluatorID:   dbResult.EvaluatorID,
+		EvaluatorName: dbResult.EvaluatorName,
+		EvaluatorType: storage.EvaluatorType(dbResult.EvaluatorType),
+		Passed:        dbResult.Passed,
+		Message:       dbResult.Message,
+		CreatedAt:     dbResult.CreatedAt,
+	}
+}
@@ -0,0 +1,219 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/regrada-ai/regrada-be/internal/storage"
+	"github.com/uptrace/bun"
+)
+
+type ExportRepository struct {
+	db *bun.DB
+}
+
+func NewExportRepository(db *bun.DB) *ExportRepository {
+	return &ExportRepository{db: db}
+}
+
+func (r *ExportRepository) Create(ctx context.Context, export *storage.Export) error {
+	dbExport, err := toDBExport(export)
+	if err != nil {
+		return err
+	}
+	dbExport.Status = string(storage.ExportPending)
+
+	if _, err := r.db.NewInsert().Model(dbExport).Returning("*").Exec(ctx); err != nil {
+		return err
+	}
+
+	created, err := toExport(dbExport)
+	if err != nil {
+		return err
+	}
+	*export = *created
+	return nil
+}
+
+func (r *ExportRepository) Get(ctx context.Context, projectID, id string) (*storage.Export, error) {
+	var dbExport DBExport
+	err := r.db.NewSelect().
+		Model(&dbExport).
+		Where("id = ?", id).
+		Where("project_id = ?", projectID).
+		Scan(ctx)
+
+	if err == sql.ErrNoRows {
+		return nil, storage.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return toExport(&dbExport)
+}
+
+func (r *ExportRepository) List(ctx context.Context, projectID string, limit int) ([]*storage.Export, error) {
+	var dbExports []DBExport
+	err := r.db.NewSelect().
+		Model(&dbExports).
+		Where("project_id = ?", projectID).
+		Order("created_at DESC").
+		Limit(limit).
+		Scan(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return toExports(dbExports)
+}
+
+func (r *ExportRepository) Claim(ctx context.Context, staleAfter time.Duration) (*storage.Export, error) {
+	next := r.db.NewSelect().
+		Model((*DBExport)(nil)).
+		Column("id").
+		WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.Where("status = ?", storage.ExportPending).
+				WhereOr("status = ? AND updated_at < ?", storage.ExportRunning, time.Now().Add(-staleAfter))
+		}).
+		Order("created_at").
+		Limit(1).
+		For("UPDATE SKIP LOCKED")
+
+	var dbExport DBExport
+	_, err := r.db.NewUpdate().
+		Model(&dbExport).
+		Set("status = ?", storage.ExportRunning).
+		Where("id = (?)", next).
+		Returning("*").
+		Exec(ctx)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if dbExport.ID == "" {
+		return nil, nil
+	}
+
+	return toExport(&dbExport)
+}
+
+func (r *ExportRepository) Update(ctx context.Context, export *storage.Export) error {
+	dbExport, err := toDBExport(export)
+	if err != nil {
+		return err
+	}
+
+	res, err := r.db.NewUpdate().
+		Model(dbExport).
+		Column("status", "row_count", "size_bytes", "storage_key", "error", "expires_at", "completed_at").
+		WherePK().
+		Exec(ctx)
+
+	return checkRowsAffected(res, err)
+}
+
+func (r *ExportRepository) ListExpired(ctx context.Context, before time.Time, limit int) ([]*storage.Export, error) {
+	var dbExports []DBExport
+	err := r.db.NewSelect().
+		Model(&dbExports).
+		Where("status = ?", storage.ExportCompleted).
+		Where("expires_at < ?", before).
+		Order("expires_at").
+		Limit(limit).
+		Scan(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return toExports(dbExports)
+}
+
+func toDBExport(export *storage.Export) (*DBExport, error) {
+	var filters any = storage.TraceFilter{}
+	switch {
+	case export.Resource == storage.ExportTestRuns && export.TestRunFilter != nil:
+		filters = export.TestRunFilter
+	case export.Resource == storage.ExportTestRuns:
+		filters = storage.TestRunFilter{}
+	case export.TraceFilter != nil:
+		filters = export.TraceFilter
+	}
+
+	filtersData, err := json.Marshal(filters)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DBExport{
+		ID:          export.ID,
+		ProjectID:   export.ProjectID,
+		RequestedBy: export.RequestedBy,
+		NotifyEmail: export.NotifyEmail,
+		Resource:    string(export.Resource),
+		Format:      string(export.Format),
+		Filters:     filtersData,
+		Status:      string(export.Status),
+		RowCount:    export.RowCount,
+		SizeBytes:   export.SizeBytes,
+		StorageKey:  export.StorageKey,
+		Error:       export.Error,
+		ExpiresAt:   export.ExpiresAt,
+		CompletedAt: export.CompletedAt,
+	}, nil
+}
+
+func toExport(dbExport *DBExport) (*storage.Export, error) {
+	export := &storage.Export{
+		ID:          dbExport.ID,
+		ProjectID:   dbExport.ProjectID,
+		RequestedBy: dbExport.RequestedBy,
+		NotifyEmail: dbExport.NotifyEmail,
+		Resource:    storage.ExportResource(dbExport.Resource),
+		Format:      storage.ExportFormat(dbExport.Format),
+		Status:      storage.ExportStatus(dbExport.Status),
+		RowCount:    dbExport.RowCount,
+		SizeBytes:   dbExport.SizeBytes,
+		StorageKey:  dbExport.StorageKey,
+		Error:       dbExport.Error,
+		ExpiresAt:   dbExport.ExpiresAt,
+		CreatedAt:   dbExport.CreatedAt,
+		UpdatedAt:   dbExport.UpdatedAt,
+		CompletedAt: dbExport.CompletedAt,
+	}
+
+	if export.Resource == storage.ExportTestRuns {
+		export.TestRunFilter = &storage.TestRunFilter{}
+		if err := decodeJSONField(dbExport.Filters, export.TestRunFilter); err != nil {
+			return nil, err
+		}
+	} else {
+		export.TraceFilter = &storage.TraceFilter{}
+		if err := decodeJSONField(dbExport.Filters, export.TraceFilter); err != nil {
+			return nil, err
+		}
+	}
+
+	return export, nil
+}
+
+func toExports(dbExports []DBExport) ([]*storage.Export, error) {
+	exports := make([]*storage.Export, len(dbExports))
+	for i := range dbExports {
+		export, err := toExport(&dbExports[i])
+		if err != nil {
+			return nil, err
+		}
+		exports[i] = export
+	}
+	return exports, nil
+}
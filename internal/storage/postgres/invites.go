@@ -41,6 +41,36 @@ func (r *inviteRepository) Create(ctx context.Context, invite *storage.Invite) e
 	return nil
 }
 
+func (r *inviteRepository) GetByID(ctx context.Context, id string) (*storage.Invite, error) {
+	dbInvite := new(DBInvite)
+	err := r.db.NewSelect().
+		Model(dbInvite).
+		Where("id = ?", id).
+		Scan(ctx)
+
+	if err == sql.ErrNoRows {
+		return nil, storage.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &storage.Invite{
+		ID:             dbInvite.ID,
+		OrganizationID: dbInvite.OrganizationID,
+		Email:          dbInvite.Email,
+		Role:           storage.UserRole(dbInvite.Role),
+		Token:          dbInvite.Token,
+		InvitedBy:      dbInvite.InvitedBy,
+		AcceptedAt:     dbInvite.AcceptedAt,
+		AcceptedBy:     dbInvite.AcceptedBy,
+		RevokedAt:      dbInvite.RevokedAt,
+		ExpiresAt:      dbInvite.ExpiresAt,
+		CreatedAt:      dbInvite.CreatedAt,
+		UpdatedAt:      dbInvite.UpdatedAt,
+	}, nil
+}
+
 func (r *inviteRepository) GetByToken(ctx context.Context, token string) (*storage.Invite, error) {
 	dbInvite := new(DBInvite)
 	err := r.db.NewSelect().
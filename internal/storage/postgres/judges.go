@@ -0,0 +1,464 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/regrada-ai/regrada-be/internal/storage"
+	"github.com/uptrace/bun"
+)
+
+const judgeNameConflict = "ERROR: duplicate key value violates unique constraint \"judges_project_id_name_key\" (SQLSTATE=23505)"
+
+type JudgeRepository struct {
+	db *bun.DB
+}
+
+func NewJudgeRepository(db *bun.DB) *JudgeRepository {
+	return &JudgeRepository{db: db}
+}
+
+func (r *JudgeRepository) CreateJudge(ctx context.Context, judge *storage.Judge) error {
+	dbJudge := toDBJudge(judge)
+	_, err := r.db.NewInsert().Model(dbJudge).Returning("id, created_at, updated_at").Exec(ctx)
+	if err != nil {
+		if err.Error() == judgeNameConflict {
+			return storage.ErrAlreadyExists
+		}
+		return err
+	}
+
+	judge.ID = dbJudge.ID
+	judge.CreatedAt = dbJudge.CreatedAt
+	judge.UpdatedAt = dbJudge.UpdatedAt
+	return nil
+}
+
+func (r *JudgeRepository) GetJudge(ctx context.Context, projectID, id string) (*storage.Judge, error) {
+	var dbJudge DBJudge
+	err := r.db.NewSelect().
+		Model(&dbJudge).
+		Where("id = ?", id).
+		Where("project_id = ?", projectID).
+		Scan(ctx)
+
+	if err == sql.ErrNoRows {
+		return nil, storage.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return toJudge(&dbJudge), nil
+}
+
+func (r *JudgeRepository) ListJudges(ctx context.Context, projectID string) ([]*storage.Judge, error) {
+	var dbJudges []DBJudge
+	err := r.db.NewSelect().
+		Model(&dbJudges).
+		Where("project_id = ?", projectID).
+		Order("name").
+		Scan(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	judges := make([]*storage.Judge, len(dbJudges))
+	for i := range dbJudges {
+		judges[i] = toJudge(&dbJudges[i])
+	}
+	return judges, nil
+}
+
+func (r *JudgeRepository) UpdateJudge(ctx context.Context, judge *storage.Judge) error {
+	dbJudge := toDBJudge(judge)
+	res, err := r.db.NewUpdate().
+		Model(dbJudge).
+		Column("name", "prompt_template", "model", "scale_min", "scale_max", "pass_threshold").
+		Where("id = ?", judge.ID).
+		Where("project_id = ?", judge.ProjectID).
+		Returning("updated_at").
+		Exec(ctx)
+
+	if err != nil && err.Error() == judgeNameConflict {
+		return storage.ErrAlreadyExists
+	}
+	if err := checkRowsAffected(res, err); err != nil {
+		return err
+	}
+	judge.UpdatedAt = dbJudge.UpdatedAt
+	return nil
+}
+
+func (r *JudgeRepository) DeleteJudge(ctx context.Context, projectID, id string) error {
+	res, err := r.db.NewDelete().
+		Model((*DBJudge)(nil)).
+		Where("id = ?", id).
+		Where("project_id = ?", projectID).
+		Exec(ctx)
+
+	return checkRowsAffected(res, err)
+}
+
+func (r *JudgeRepository) CreateRun(ctx context.Context, run *storage.JudgeRun) error {
+	dbRun, err := toDBJudgeRun(run)
+	if err != nil {
+		return err
+	}
+	dbRun.Status = string(storage.JudgeRunPending)
+
+	if _, err := r.db.NewInsert().Model(dbRun).Returning("*").Exec(ctx); err != nil {
+		return err
+	}
+
+	created, err := toJudgeRun(dbRun)
+	if err != nil {
+		return err
+	}
+	*run = *created
+	return nil
+}
+
+func (r *JudgeRepository) GetRun(ctx context.Context, projectID, id string) (*storage.JudgeRun, error) {
+	var dbRun DBJudgeRun
+	err := r.db.NewSelect().
+		Model(&dbRun).
+		Where("id = ?", id).
+		Where("project_id = ?", projectID).
+		Scan(ctx)
+
+	if err == sql.ErrNoRows {
+		return nil, storage.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return toJudgeRun(&dbRun)
+}
+
+func (r *JudgeRepository) ListRuns(ctx context.Context, projectID, judgeID string, limit int) ([]*storage.JudgeRun, error) {
+	var dbRuns []DBJudgeRun
+	err := r.db.NewSelect().
+		Model(&dbRuns).
+		Where("project_id = ?", projectID).
+		Where("judge_id = ?", judgeID).
+		Order("created_at DESC").
+		Limit(limit).
+		Scan(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	runs := make([]*storage.JudgeRun, len(dbRuns))
+	for i := range dbRuns {
+		run, err := toJudgeRun(&dbRuns[i])
+		if err != nil {
+			return nil, err
+		}
+		runs[i] = run
+	}
+	return runs, nil
+}
+
+func (r *JudgeRepository) ClaimRun(ctx context.Context, staleAfter time.Duration) (*storage.JudgeRun, error) {
+	next := r.db.NewSelect().
+		Model((*DBJudgeRun)(nil)).
+		Column("id").
+		WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.Where("status = ?", storage.JudgeRunPending).
+				WhereOr("status = ? AND updated_at < ?", storage.JudgeRunRunning, time.Now().Add(-staleAfter))
+		}).
+		Order("created_at").
+		Limit(1).
+		For("UPDATE SKIP LOCKED")
+
+	var dbRun DBJudgeRun
+	_, err := r.db.NewUpdate().
+		Model(&dbRun).
+		Set("status = ?", storage.JudgeRunRunning).
+		Where("id = (?)", next).
+		Returning("*").
+		Exec(ctx)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if dbRun.ID == "" {
+		return nil, nil
+	}
+
+	return toJudgeRun(&dbRun)
+}
+
+func (r *JudgeRepository) UpdateRun(ctx context.Context, run *storage.JudgeRun) error {
+	totals := r.db.NewSelect().
+		Model((*DBJudgeScore)(nil)).
+		ColumnExpr("count(js.score), count(js.error)").
+		ColumnExpr("count(*) FILTER (WHERE js.cached), count(*) FILTER (WHERE js.passed)").
+		ColumnExpr("avg(js.score)").
+		ColumnExpr("coalesce(sum(js.tokens_in), 0), coalesce(sum(js.tokens_out), 0), coalesce(sum(js.cost_usd), 0)").
+		Where("js.run_id = jr.id")
+
+	var dbRun DBJudgeRun
+	res, err := r.db.NewUpdate().
+		Model(&dbRun).
+		Set("status = ?", run.Status).
+		Set("dataset_version = ?", bun.NullZero(run.DatasetVersion)).
+		Set("error = ?", bun.NullZero(run.Error)).
+		Set("completed_at = ?", run.CompletedAt).
+		Set("(scored, errored, cached, passed, mean_score, tokens_in, tokens_out, cost_usd) = (?)", totals).
+		Where("jr.id = ?", run.ID).
+		Returning("*").
+		Exec(ctx)
+
+	if err := checkRowsAffected(res, err); err != nil {
+		return err
+	}
+
+	updated, err := toJudgeRun(&dbRun)
+	if err != nil {
+		return err
+	}
+	*run = *updated
+	return nil
+}
+
+func (r *JudgeRepository) SaveScore(ctx context.Context, score *storage.JudgeScore) error {
+	_, err := r.db.NewInsert().
+		Model(&DBJudgeScore{
+			RunID:     score.RunID,
+			ProjectID: score.ProjectID,
+			JudgeID:   score.JudgeID,
+			TargetID:  score.TargetID,
+			Score:     score.Score,
+			Passed:    score.Passed,
+			Reasoning: score.Reasoning,
+			Error:     score.Error,
+			Cached:    score.Cached,
+			TokensIn:  score.TokensIn,
+			TokensOut: score.TokensOut,
+			CostUSD:   score.CostUSD,
+		}).
+		On("CONFLICT (run_id, target_id) DO NOTHING").
+		Returning("NULL").
+		Exec(ctx)
+	return err
+}
+
+func (r *JudgeRepository) ScoredTargets(ctx context.Context, runID string) (map[string]bool, error) {
+	var targetIDs []string
+	err := r.db.NewSelect().
+		Model((*DBJudgeScore)(nil)).
+		Column("target_id").
+		Where("run_id = ?", runID).
+		Scan(ctx, &targetIDs)
+
+	if err != nil {
+		return nil, err
+	}
+
+	scored := make(map[string]bool, len(targetIDs))
+	for _, id := range targetIDs {
+		scored[id] = true
+	}
+	return scored, nil
+}
+
+func (r *JudgeRepository) ListScores(ctx context.Context, runID string, limit, offset int) ([]*storage.JudgeScore, error) {
+	var dbScores []DBJudgeScore
+	err := r.db.NewSelect().
+		Model(&dbScores).
+		Where("run_id = ?", runID).
+		OrderExpr("created_at, id").
+		Limit(limit).
+		Offset(offset).
+		Scan(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	scores := make([]*storage.JudgeScore, len(dbScores))
+	for i := range dbScores {
+		scores[i] = toJudgeScore(&dbScores[i])
+	}
+	return scores, nil
+}
+
+func (r *JudgeRepository) GetCached(ctx context.Context, projectID, inputHash string) (*storage.JudgeVerdict, error) {
+	var dbCache DBJudgeCache
+	err := r.db.NewSelect().
+		Model(&dbCache).
+		Where("project_id = ?", projectID).
+		Where("input_hash = ?", inputHash).
+		Scan(ctx)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &storage.JudgeVerdict{Score: dbCache.Score, Reasoning: dbCache.Reasoning}, nil
+}
+
+func (r *JudgeRepository) PutCached(ctx context.Context, projectID, inputHash string, verdict *storage.JudgeVerdict) error {
+	_, err := r.db.NewInsert().
+		Model(&DBJudgeCache{
+			ProjectID: projectID,
+			InputHash: inputHash,
+			Score:     verdict.Score,
+			Reasoning: verdict.Reasoning,
+		}).
+		On("CONFLICT (project_id, input_hash) DO UPDATE").
+		Set("score = EXCLUDED.score").
+		Set("reasoning = EXCLUDED.reasoning").
+		Set("created_at = now()").
+		Returning("NULL").
+		Exec(ctx)
+	return err
+}
+
+func (r *JudgeRepository) Costs(ctx context.Context, projectID string, from, to time.Time) ([]storage.JudgeCost, error) {
+	query := r.db.NewSelect().
+		Model((*DBJudgeScore)(nil)).
+		ColumnExpr("jg.id AS judge_id, jg.name AS judge_name, jg.model").
+		ColumnExpr("count(*) AS scores").
+		ColumnExpr("count(*) FILTER (WHERE js.cached) AS cached").
+		ColumnExpr("sum(js.tokens_in) AS tokens_in, sum(js.tokens_out) AS tokens_out").
+		ColumnExpr("sum(js.cost_usd) AS cost_usd").
+		Join("JOIN judges AS jg ON jg.id = js.judge_id").
+		Where("js.project_id = ?", projectID).
+		Group("jg.id").
+		Order("jg.name")
+	if !from.IsZero() {
+		query = query.Where("js.created_at >= ?", from)
+	}
+	if !to.IsZero() {
+		query = query.Where("js.created_at < ?", to)
+	}
+
+	costs := []storage.JudgeCost{}
+	if err := query.Scan(ctx, &costs); err != nil {
+		return nil, err
+	}
+	return costs, nil
+}
+
+func toDBJudge(judge *storage.Judge) *DBJudge {
+	return &DBJudge{
+		ID:             judge.ID,
+		ProjectID:      judge.ProjectID,
+		Name:           judge.Name,
+		PromptTemplate: judge.PromptTemplate,
+		Model:          judge.Model,
+		ScaleMin:       judge.ScaleMin,
+		ScaleMax:       judge.ScaleMax,
+		PassThreshold:  judge.PassThreshold,
+		CreatedBy:      judge.CreatedBy,
+	}
+}
+
+func toJudge(dbJudge *DBJudge) *storage.Judge {
+	return &storage.Judge{
+		ID:             dbJudge.ID,
+		ProjectID:      dbJudge.ProjectID,
+		Name:           dbJudge.Name,
+		PromptTemplate: dbJudge.PromptTemplate,
+		Model:          dbJudge.Model,
+		ScaleMin:       dbJudge.ScaleMin,
+		ScaleMax:       dbJudge.ScaleMax,
+		PassThreshold:  dbJudge.PassThreshold,
+		CreatedBy:      dbJudge.CreatedBy,
+		CreatedAt:      dbJudge.CreatedAt,
+		UpdatedAt:      dbJudge.UpdatedAt,
+	}
+}
+
+func toDBJudgeRun(run *storage.JudgeRun) (*DBJudgeRun, error) {
+	filters := []byte("{}")
+	if run.TraceFilter != nil {
+		var err error
+		if filters, err = json.Marshal(run.TraceFilter); err != nil {
+			return nil, err
+		}
+	}
+
+	return &DBJudgeRun{
+		ID:             run.ID,
+		ProjectID:      run.ProjectID,
+		JudgeID:        run.JudgeID,
+		RequestedBy:    run.RequestedBy,
+		Target:         string(run.Target),
+		Filters:        filters,
+		DatasetID:      run.DatasetID,
+		DatasetVersion: run.DatasetVersion,
+		MaxItems:       run.MaxItems,
+		Status:         string(run.Status),
+		Error:          run.Error,
+		CompletedAt:    run.CompletedAt,
+	}, nil
+}
+
+func toJudgeRun(dbRun *DBJudgeRun) (*storage.JudgeRun, error) {
+	run := &storage.JudgeRun{
+		ID:             dbRun.ID,
+		ProjectID:      dbRun.ProjectID,
+		JudgeID:        dbRun.JudgeID,
+		RequestedBy:    dbRun.RequestedBy,
+		Target:         storage.JudgeTarget(dbRun.Target),
+		DatasetID:      dbRun.DatasetID,
+		DatasetVersion: dbRun.DatasetVersion,
+		MaxItems:       dbRun.MaxItems,
+		Status:         storage.JudgeRunStatus(dbRun.Status),
+		Scored:         dbRun.Scored,
+		Errored:        dbRun.Errored,
+		Cached:         dbRun.Cached,
+		Passed:         dbRun.Passed,
+		MeanScore:      dbRun.MeanScore,
+		TokensIn:       dbRun.TokensIn,
+		TokensOut:      dbRun.TokensOut,
+		CostUSD:        dbRun.CostUSD,
+		Error:          dbRun.Error,
+		CreatedAt:      dbRun.CreatedAt,
+		UpdatedAt:      dbRun.UpdatedAt,
+		CompletedAt:    dbRun.CompletedAt,
+	}
+
+	if run.Target == storage.JudgeTraces {
+		run.TraceFilter = &storage.TraceFilter{}
+		if err := decodeJSONField(dbRun.Filters, run.TraceFilter); err != nil {
+			return nil, err
+		}
+	}
+	return run, nil
+}
+
+func toJudgeScore(dbScore *DBJudgeScore) *storage.JudgeScore {
+	return &storage.JudgeScore{
+		RunID:     dbScore.RunID,
+		ProjectID: dbScore.ProjectID,
+		JudgeID:   dbScore.JudgeID,
+		TargetID:  dbScore.TargetID,
+		Score:     dbScore.Score,
+		Passed:    dbScore.Passed,
+		Reasoning: dbScore.Reasoning,
+		Error:     dbScore.Error,
+		Cached:    dbScore.Cached,
+		TokensIn:  dbScore.TokensIn,
+		TokensOut: dbScore.TokensOut,
+		CostUSD:   dbScore.CostUSD,
+		CreatedAt: dbScore.CreatedAt,
+	}
+}
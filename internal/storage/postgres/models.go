@@ -3,6 +3,7 @@
 package postgres
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/uptrace/bun"
@@ -39,6 +40,9 @@ type DBProject struct {
 	GitHubOwner    string     `bun:"github_owner"`
 	GitHubRepo     string     `bun:"github_repo"`
 	DefaultBranch  string     `bun:"default_branch"`
+	RetentionDays  *int       `bun:"retention_days"`
+	ArchiveAfter   *int       `bun:"archive_after_days"`
+	Alpha          *float64   `bun:"significance_alpha"`
 	CreatedAt      time.Time  `bun:"created_at,notnull,default:now()"`
 	UpdatedAt      time.Time  `bun:"updated_at,notnull,default:now()"`
 	DeletedAt      *time.Time `bun:"deleted_at,soft_delete"`
@@ -57,6 +61,8 @@ type DBTrace struct {
 	Environment      string     `bun:"environment"`
 	GitSHA           string     `bun:"git_sha"`
 	GitBranch        string     `bun:"git_branch"`
+	PromptName       string     `bun:"prompt_name,nullzero"`
+	PromptVersion    int        `bun:"prompt_version,nullzero"`
 	RequestData      []byte     `bun:"request_data,type:jsonb,notnull"`
 	ResponseData     []byte     `bun:"response_data,type:jsonb,notnull"`
 	LatencyMS        int        `bun:"latency_ms"`
@@ -68,6 +74,15 @@ type DBTrace struct {
 	DeletedAt        *time.Time `bun:"deleted_at,soft_delete"`
 }
 
+// DBTraceKey records the timestamp a project's trace ID is stored under
+type DBTraceKey struct {
+	bun.BaseModel `bun:"table:trace_keys,alias:k"`
+
+	ProjectID string    `bun:"project_id,pk,type:uuid"`
+	TraceID   string    `bun:"trace_id,pk"`
+	Timestamp time.Time `bun:"timestamp,notnull"`
+}
+
 // DBTestRun represents a test run in the database
 type DBTestRun struct {
 	bun.BaseModel `bun:"table:test_runs,alias:tr"`
@@ -106,6 +121,7 @@ type DBOrganization struct {
 	MonthlyRequestLimit int64      `bun:"monthly_request_limit,notnull,default:50000"`
 	MonthlyRequestCount int64      `bun:"monthly_request_count,notnull,default:0"`
 	UsageResetAt        time.Time  `bun:"usage_reset_at,notnull"`
+	RetentionDays       *int       `bun:"retention_days"`
 	CreatedAt           time.Time  `bun:"created_at,notnull,default:now()"`
 	UpdatedAt           time.Time  `bun:"updated_at,notnull,default:now()"`
 	DeletedAt           *time.Time `bun:"deleted_at,soft_delete"`
@@ -164,3 +180,424 @@ type DBInvite struct {
 	CreatedAt      time.Time  `bun:"created_at,notnull,default:now()"`
 	UpdatedAt      time.Time  `bun:"updated_at,notnull,default:now()"`
 }
+
+// DBRedactionPolicy represents a project's redaction policy in the database
+type DBRedactionPolicy struct {
+	bun.BaseModel `bun:"table:redaction_policies,alias:rp"`
+
+	ProjectID   string    `bun:"project_id,pk,type:uuid"`
+	Enabled     bool      `bun:"enabled,notnull"`
+	Mode        string    `bun:"mode,notnull"`
+	Detectors   []string  `bun:"detectors,array"`
+	CustomRules []byte    `bun:"custom_rules,type:jsonb,notnull"`
+	HashKey     []byte    `bun:"hash_key,type:bytea"`
+	CreatedAt   time.Time `bun:"created_at,notnull,default:now()"`
+	UpdatedAt   time.Time `bun:"updated_at,notnull,default:now()"`
+}
+
+// DBTraceArchive represents an archive file manifest entry in the database
+type DBTraceArchive struct {
+	bun.BaseModel `bun:"table:trace_archives,alias:ta"`
+
+	ID          string    `bun:"id,pk,type:uuid,default:gen_random_uuid()"`
+	ProjectID   string    `bun:"project_id,type:uuid,notnull"`
+	StorageKey  string    `bun:"storage_key,notnull,unique"`
+	Format      string    `bun:"format,notnull"`
+	PeriodStart time.Time `bun:"period_start,notnull"`
+	PeriodEnd   time.Time `bun:"period_end,notnull"`
+	TraceCount  int       `bun:"trace_count,notnull"`
+	SizeBytes   int64     `bun:"size_bytes,notnull"`
+	SHA256      string    `bun:"sha256,notnull"`
+	CreatedAt   time.Time `bun:"created_at,notnull,default:now()"`
+}
+
+// DBArchiveRestore represents an archive restore request in the database
+type DBArchiveRestore struct {
+	bun.BaseModel `bun:"table:trace_archive_restores,alias:tar"`
+
+	ID               string     `bun:"id,pk,type:uuid,default:gen_random_uuid()"`
+	ProjectID        string     `bun:"project_id,type:uuid,notnull"`
+	RequestedBy      string     `bun:"requested_by,nullzero"`
+	RangeStart       time.Time  `bun:"range_start,notnull"`
+	RangeEnd         time.Time  `bun:"range_end,notnull"`
+	KeepDays         int        `bun:"keep_days,notnull"`
+	Status           string     `bun:"status,notnull,default:'pending'"`
+	ArchivesTotal    int        `bun:"archives_total,notnull"`
+	ArchivesRestored int        `bun:"archives_restored,notnull"`
+	TracesRestored   int64      `bun:"traces_restored,notnull"`
+	Error            string     `bun:"error,nullzero"`
+	ExpiresAt        *time.Time `bun:"expires_at"`
+	CreatedAt        time.Time  `bun:"created_at,notnull,default:now()"`
+	UpdatedAt        time.Time  `bun:"updated_at,notnull,default:now()"`
+	CompletedAt      *time.Time `bun:"completed_at"`
+}
+
+// DBExport represents an export job in the database
+type DBExport struct {
+	bun.BaseModel `bun:"table:exports,alias:ex"`
+
+	ID          string     `bun:"id,pk,type:uuid,default:gen_random_uuid()"`
+	ProjectID   string     `bun:"project_id,type:uuid,notnull"`
+	RequestedBy string     `bun:"requested_by,nullzero"`
+	NotifyEmail string     `bun:"notify_email,nullzero"`
+	Resource    string     `bun:"resource,notnull"`
+	Format      string     `bun:"format,notnull"`
+	Filters     []byte     `bun:"filters,type:jsonb,notnull"`
+	Status      string     `bun:"status,notnull,default:'pending'"`
+	RowCount    int64      `bun:"row_count,notnull"`
+	SizeBytes   int64      `bun:"size_bytes,notnull"`
+	StorageKey  string     `bun:"storage_key,nullzero"`
+	Error       string     `bun:"error,nullzero"`
+	ExpiresAt   *time.Time `bun:"expires_at"`
+	CreatedAt   time.Time  `bun:"created_at,notnull,default:now()"`
+	UpdatedAt   time.Time  `bun:"updated_at,notnull,default:now()"`
+	CompletedAt *time.Time `bun:"completed_at"`
+}
+
+// DBDataset represents a dataset in the database
+type DBDataset struct {
+	bun.BaseModel `bun:"table:datasets,alias:ds"`
+
+	ID          string    `bun:"id,pk,type:uuid,default:gen_random_uuid()"`
+	ProjectID   string    `bun:"project_id,type:uuid,notnull"`
+	Name        string    `bun:"name,notnull"`
+	Description string    `bun:"description,nullzero"`
+	Version     int       `bun:"version,notnull,default:1"`
+	CreatedBy   string    `bun:"created_by,nullzero"`
+	CreatedAt   time.Time `bun:"created_at,notnull,default:now()"`
+	UpdatedAt   time.Time `bun:"updated_at,notnull,default:now()"`
+
+	ItemCount int `bun:"item_count,scanonly"`
+}
+
+// DBDatasetItem represents one version of a dataset item in the database
+type DBDatasetItem struct {
+	bun.BaseModel `bun:"table:dataset_items,alias:dsi"`
+
+	ID             string          `bun:"id,pk,type:uuid,default:gen_random_uuid()"`
+	DatasetID      string          `bun:"dataset_id,type:uuid,notnull"`
+	ItemID         string          `bun:"item_id,type:uuid,default:gen_random_uuid()"`
+	SourceTraceID  string          `bun:"source_trace_id,nullzero"`
+	Messages       json.RawMessage `bun:"messages,type:jsonb,notnull"`
+	Params         json.RawMessage `bun:"params,type:jsonb,nullzero"`
+	ExpectedOutput json.RawMessage `bun:"expected_output,type:jsonb,nullzero"`
+	Tags           []string        `bun:"tags,array"`
+	AddedVersion   int             `bun:"added_version,notnull"`
+	RemovedVersion *int            `bun:"removed_version"`
+	CreatedAt      time.Time       `bun:"created_at,notnull,default:now()"`
+}
+
+// DBAnnotation represents a trace annotation in the database
+type DBAnnotation struct {
+	bun.BaseModel `bun:"table:trace_annotations,alias:an"`
+
+	ID        string             `bun:"id,pk,type:uuid,default:gen_random_uuid()"`
+	ProjectID string             `bun:"project_id,type:uuid,notnull"`
+	TraceID   string             `bun:"trace_id,notnull"`
+	Source    string             `bun:"source,notnull"`
+	AuthorID  string             `bun:"author_id,type:uuid,nullzero"`
+	EndUserID string             `bun:"end_user_id,nullzero"`
+	Rating    string             `bun:"rating,nullzero"`
+	Scores    map[string]float64 `bun:"scores,type:jsonb,notnull"`
+	Comment   string             `bun:"comment,nullzero"`
+	Labels    []string           `bun:"labels,array,notnull"`
+	CreatedAt time.Time          `bun:"created_at,notnull,default:now()"`
+	UpdatedAt time.Time          `bun:"updated_at,notnull,default:now()"`
+}
+
+// DBReviewQueue represents a review queue in the database
+type DBReviewQueue struct {
+	bun.BaseModel `bun:"table:review_queues,alias:rq"`
+
+	ID               string          `bun:"id,pk,type:uuid,default:gen_random_uuid()"`
+	ProjectID        string          `bun:"project_id,type:uuid,notnull"`
+	Name             string          `bun:"name,notnull"`
+	Description      string          `bun:"description,nullzero"`
+	Rules            json.RawMessage `bun:"rules,type:jsonb,notnull"`
+	Rubric           json.RawMessage `bun:"rubric,type:jsonb,notnull"`
+	CreatedBy        string          `bun:"created_by,type:uuid,nullzero"`
+	PopulatedThrough time.Time       `bun:"populated_through,notnull,default:now()"`
+	CreatedAt        time.Time       `bun:"created_at,notnull,default:now()"`
+	UpdatedAt        time.Time       `bun:"updated_at,notnull,default:now()"`
+}
+
+// DBReviewItem represents an item in a review queue in the database
+type DBReviewItem struct {
+	bun.BaseModel `bun:"table:review_items,alias:ri"`
+
+	ID          string             `bun:"id,pk,type:uuid,default:gen_random_uuid()"`
+	QueueID     string             `bun:"queue_id,type:uuid,notnull"`
+	ProjectID   string             `bun:"project_id,type:uuid,notnull"`
+	Kind        string             `bun:"kind,notnull"`
+	TraceID     string             `bun:"trace_id,nullzero"`
+	RunID       string             `bun:"run_id,nullzero"`
+	CaseID      string             `bun:"case_id,nullzero"`
+	Reason      string             `bun:"reason,notnull"`
+	Status      string             `bun:"status,notnull,default:'pending'"`
+	AssigneeID  string             `bun:"assignee_id,type:uuid,nullzero"`
+	ReviewerID  string             `bun:"reviewer_id,type:uuid,nullzero"`
+	ClaimedAt   *time.Time         `bun:"claimed_at"`
+	Scores      map[string]float64 `bun:"scores,type:jsonb,notnull"`
+	Comment     string             `bun:"comment,nullzero"`
+	CompletedAt *time.Time         `bun:"completed_at"`
+	CreatedAt   time.Time          `bun:"created_at,notnull,default:now()"`
+	UpdatedAt   time.Time          `bun:"updated_at,notnull,default:now()"`
+}
+
+// DBEvaluator represents an evaluator in the database
+type DBEvaluator struct {
+	bun.BaseModel `bun:"table:evaluators,alias:ev"`
+
+	ID        string          `bun:"id,pk,type:uuid,default:gen_random_uuid()"`
+	ProjectID string          `bun:"project_id,type:uuid,notnull"`
+	Name      string          `bun:"name,notnull"`
+	Type      string          `bun:"type,notnull"`
+	Config    json.RawMessage `bun:"config,type:jsonb,notnull"`
+	Enabled   bool            `bun:"enabled,notnull"`
+	CreatedBy string          `bun:"created_by,type:uuid,nullzero"`
+	CreatedAt time.Time       `bun:"created_at,notnull,default:now()"`
+	UpdatedAt time.Time       `bun:"updated_at,notnull,default:now()"`
+}
+
+// DBEvaluationResult represents an evaluator's result on a trace in the database
+type DBEvaluationResult struct {
+	bun.BaseModel `bun:"table:evaluation_results,alias:er"`
+
+	ID          string    `bun:"id,pk,type:uuid,default:gen_random_uuid()"`
+	ProjectID   string    `bun:"project_id,type:uuid,notnull"`
+	TraceID     string    `bun:"trace_id,notnull"`
+	EvaluatorID string    `bun:"evaluator_id,type:uuid,notnull"`
+	Passed      bool      `bun:"passed,notnull"`
+	Message     string    `bun:"message,nullzero"`
+	CreatedAt   time.Time `bun:"created_at,notnull,default:now()"`
+
+	EvaluatorName string `bun:"evaluator_name,scanonly"`
+	EvaluatorType string `bun:"evaluator_type,scanonly"`
+}
+
+// DBEvaluationCursor records when evaluation started in a project. Its row
+// is locked while the project's traces are evaluated.
+type DBEvaluationCursor struct {
+	bun.BaseModel `bun:"table:evaluation_cursors,alias:ec"`
+
+	ProjectID       string    `bun:"project_id,pk,type:uuid"`
+	EvaluatingSince time.Time `bun:"evaluating_since,notnull,default:now()"`
+}
+
+// DBJudge represents an LLM judge in the database
+type DBJudge struct {
+	bun.BaseModel `bun:"table:judges,alias:jg"`
+
+	ID             string    `bun:"id,pk,type:uuid,default:gen_random_uuid()"`
+	ProjectID      string    `bun:"project_id,type:uuid,notnull"`
+	Name           string    `bun:"name,notnull"`
+	PromptTemplate string    `bun:"prompt_template,notnull"`
+	Model          string    `bun:"model,notnull"`
+	ScaleMin       int       `bun:"scale_min,notnull"`
+	ScaleMax       int       `bun:"scale_max,notnull"`
+	PassThreshold  *float64  `bun:"pass_threshold"`
+	CreatedBy      string    `bun:"created_by,type:uuid,nullzero"`
+	CreatedAt      time.Time `bun:"created_at,notnull,default:now()"`
+	UpdatedAt      time.Time `bun:"updated_at,notnull,default:now()"`
+}
+
+// DBJudgeRun represents a judge run in the database
+type DBJudgeRun struct {
+	bun.BaseModel `bun:"table:judge_runs,alias:jr"`
+
+	ID             string     `bun:"id,pk,type:uuid,default:gen_random_uuid()"`
+	ProjectID      string     `bun:"project_id,type:uuid,notnull"`
+	JudgeID        string     `bun:"judge_id,type:uuid,notnull"`
+	RequestedBy    string     `bun:"requested_by,nullzero"`
+	Target         string     `bun:"target,notnull"`
+	Filters        []byte     `bun:"filters,type:jsonb,notnull"`
+	DatasetID      string     `bun:"dataset_id,type:uuid,nullzero"`
+	DatasetVersion int        `bun:"dataset_version,nullzero"`
+	MaxItems       int        `bun:"max_items,notnull"`
+	Status         string     `bun:"status,notnull,default:'pending'"`
+	Scored         int        `bun:"scored,notnull"`
+	Errored        int        `bun:"errored,notnull"`
+	Cached         int        `bun:"cached,notnull"`
+	Passed         int        `bun:"passed,notnull"`
+	MeanScore      *float64   `bun:"mean_score"`
+	TokensIn       int64      `bun:"tokens_in,notnull"`
+	TokensOut      int64      `bun:"tokens_out,notnull"`
+	CostUSD        float64    `bun:"cost_usd,notnull"`
+	Error          string     `bun:"error,nullzero"`
+	CreatedAt      time.Time  `bun:"created_at,notnull,default:now()"`
+	UpdatedAt      time.Time  `bun:"updated_at,notnull,default:now()"`
+	CompletedAt    *time.Time `bun:"completed_at"`
+}
+
+// DBJudgeScore represents a judge's score for one item of a run in the database
+type DBJudgeScore struct {
+	bun.BaseModel `bun:"table:judge_scores,alias:js"`
+
+	ID        string    `bun:"id,pk,type:uuid,default:gen_random_uuid()"`
+	RunID     string    `bun:"run_id,type:uuid,notnull"`
+	ProjectID string    `bun:"project_id,type:uuid,notnull"`
+	JudgeID   string    `bun:"judge_id,type:uuid,notnull"`
+	TargetID  string    `bun:"target_id,notnull"`
+	Score     *float64  `bun:"score"`
+	Passed    *bool     `bun:"passed"`
+	Reasoning string    `bun:"reasoning,nullzero"`
+	Error     string    `bun:"error,nullzero"`
+	Cached    bool      `bun:"cached,notnull"`
+	TokensIn  int       `bun:"tokens_in,notnull"`
+	TokensOut int       `bun:"tokens_out,notnull"`
+	CostUSD   float64   `bun:"cost_usd,notnull"`
+	CreatedAt time.Time `bun:"created_at,notnull,default:now()"`
+}
+
+// DBJudgeCache represents a cached judge response in the database
+type DBJudgeCache struct {
+	bun.BaseModel `bun:"table:judge_cache,alias:jc"`
+
+	ProjectID string    `bun:"project_id,pk,type:uuid"`
+	InputHash string    `bun:"input_hash,pk"`
+	Score     float64   `bun:"score,notnull"`
+	Reasoning string    `bun:"reasoning,nullzero"`
+	CreatedAt time.Time `bun:"created_at,notnull,default:now()"`
+}
+
+// DBPolicy represents a registered policy in the database
+type DBPolicy struct {
+	bun.BaseModel `bun:"table:policies,alias:pol"`
+
+	ID             string    `bun:"id,pk,type:uuid,default:gen_random_uuid()"`
+	OrganizationID string    `bun:"organization_id,type:uuid,notnull"`
+	ProjectID      string    `bun:"project_id,type:uuid,nullzero"`
+	PolicyID       string    `bun:"policy_id,notnull"`
+	Description    string    `bun:"description,nullzero"`
+	Severity       string    `bun:"severity,notnull"`
+	Owner          string    `bun:"owner,nullzero"`
+	Enabled        bool      `bun:"enabled,notnull"`
+	Blocking       bool      `bun:"blocking,notnull"`
+	CreatedBy      string    `bun:"created_by,type:uuid,nullzero"`
+	CreatedAt      time.Time `bun:"created_at,notnull,default:now()"`
+	UpdatedAt      time.Time `bun:"updated_at,notnull,default:now()"`
+
+	Inserted bool `bun:"inserted,scanonly"` // set by Upsert
+}
+
+// DBPrompt represents a registered prompt in the database
+type DBPrompt struct {
+	bun.BaseModel `bun:"table:prompts,alias:pr"`
+
+	ID            string    `bun:"id,pk,type:uuid,default:gen_random_uuid()"`
+	ProjectID     string    `bun:"project_id,type:uuid,notnull"`
+	Name          string    `bun:"name,notnull"`
+	Description   string    `bun:"description,nullzero"`
+	LatestVersion int       `bun:"latest_version,notnull"`
+	CreatedBy     string    `bun:"created_by,type:uuid,nullzero"`
+	CreatedAt     time.Time `bun:"created_at,notnull,default:now()"`
+	UpdatedAt     time.Time `bun:"updated_at,notnull,default:now()"`
+
+	Labels []byte `bun:"labels,scanonly"` // JSON object of label to version
+}
+
+// DBPromptVersion represents a prompt version in the database
+type DBPromptVersion struct {
+	bun.BaseModel `bun:"table:prompt_versions,alias:pv"`
+
+	ID        string          `bun:"id,pk,type:uuid,default:gen_random_uuid()"`
+	PromptID  string          `bun:"prompt_id,type:uuid,notnull"`
+	Version   int             `bun:"version,notnull"`
+	Template  string          `bun:"template,notnull"`
+	Variables []string        `bun:"variables,array"`
+	Params    json.RawMessage `bun:"params,type:jsonb,nullzero"`
+	Model     string          `bun:"model,nullzero"`
+	CreatedBy string          `bun:"created_by,type:uuid,nullzero"`
+	CreatedAt time.Time       `bun:"created_at,notnull,default:now()"`
+
+	Name   string   `bun:"name,scanonly"`
+	Labels []string `bun:"labels,array,scanonly"`
+}
+
+// DBPromptLabel represents a label pointing at a prompt version in the database
+type DBPromptLabel struct {
+	bun.BaseModel `bun:"table:prompt_labels,alias:pl"`
+
+	PromptID  string    `bun:"prompt_id,pk,type:uuid"`
+	Label     string    `bun:"label,pk"`
+	Version   int       `bun:"version,notnull"`
+	UpdatedAt time.Time `bun:"updated_at,notnull,default:now()"`
+}
+
+// DBQualityGate represents a project's quality gate in the database
+type DBQualityGate struct {
+	bun.BaseModel `bun:"table:quality_gates,alias:qg"`
+
+	ProjectID      string          `bun:"project_id,pk,type:uuid"`
+	Enabled        bool            `bun:"enabled,notnull"`
+	BaselineBranch string          `bun:"baseline_branch,notnull"`
+	Conditions     json.RawMessage `bun:"conditions,type:jsonb,notnull"`
+	UpdatedBy      string          `bun:"updated_by,type:uuid,nullzero"`
+	CreatedAt      time.Time       `bun:"created_at,notnull,default:now()"`
+	UpdatedAt      time.Time       `bun:"updated_at,notnull,default:now()"`
+}
+
+// DBCaseQuarantine represents a quarantined test case in the database
+type DBCaseQuarantine struct {
+	bun.BaseModel `bun:"table:quarantined_cases,alias:qc"`
+
+	ProjectID     string    `bun:"project_id,pk,type:uuid"`
+	CaseID        string    `bun:"case_id,pk"`
+	Reason        string    `bun:"reason,nullzero"`
+	QuarantinedBy string    `bun:"quarantined_by,type:uuid,nullzero"`
+	CreatedAt     time.Time `bun:"created_at,notnull,default:now()"`
+	UpdatedAt     time.Time `bun:"updated_at,notnull,default:now()"`
+
+	Inserted bool `bun:"inserted,scanonly"` // set by Upsert
+}
+
+// DBRegressionDetection represents a detected test case regression in the
+// database
+type DBRegressionDetection struct {
+	bun.BaseModel `bun:"table:regression_detections,alias:rd"`
+
+	ID               string          `bun:"id,pk,type:uuid,default:gen_random_uuid()"`
+	ProjectID        string          `bun:"project_id,notnull,type:uuid"`
+	CaseID           string          `bun:"case_id,notnull"`
+	Branch           string          `bun:"branch,notnull"`
+	DetectedAt       time.Time       `bun:"detected_at,notnull,default:now()"`
+	RegressionGitSHA string          `bun:"regression_git_sha,notnull"`
+	LastGoodGitSHA   string          `bun:"last_good_git_sha,nullzero"`
+	RegressionType   string          `bun:"regression_type,notnull"`
+	Severity         string          `bun:"severity,notnull"`
+	Details          json.RawMessage `bun:"details,type:jsonb,notnull"`
+	ResolvedAt       *time.Time      `bun:"resolved_at"`
+	CreatedAt        time.Time       `bun:"created_at,notnull,default:now()"`
+}
+
+// DBBadgeToken represents a project's status badge token in the database
+type DBBadgeToken struct {
+	bun.BaseModel `bun:"table:badge_tokens,alias:bt"`
+
+	ID          string     `bun:"id,pk,type:uuid,default:gen_random_uuid()"`
+	ProjectID   string     `bun:"project_id,notnull,type:uuid"`
+	TokenHash   string     `bun:"token_hash,notnull,unique"`
+	TokenPrefix string     `bun:"token_prefix,notnull"`
+	Name        string     `bun:"name,nullzero"`
+	CreatedBy   string     `bun:"created_by,type:uuid,nullzero"`
+	CreatedAt   time.Time  `bun:"created_at,notnull,default:now()"`
+	RevokedAt   *time.Time `bun:"revoked_at"`
+}
+
+// DBAuditLogEntry represents an audit log entry in the database
+type DBAuditLogEntry struct {
+	bun.BaseModel `bun:"table:audit_log,alias:al"`
+
+	ID             string          `bun:"id,pk,type:uuid,default:gen_random_uuid()"`
+	OrganizationID string          `bun:"organization_id,notnull,type:uuid"`
+	ActorType      string          `bun:"actor_type,notnull"`
+	ActorID        string          `bun:"actor_id,nullzero"`
+	ActorName      string          `bun:"actor_name,nullzero"`
+	Action         string          `bun:"action,notnull"`
+	TargetType     string          `bun:"target_type,notnull"`
+	TargetID       string          `bun:"target_id,nullzero"`
+	Changes        json.RawMessage `bun:"changes,type:jsonb,notnull"`
+	IPAddress      string          `bun:"ip_address,nullzero"`
+	UserAgent      string          `bun:"user_agent,nullzero"`
+	CreatedAt      time.Time       `bun:"created_at,notnull,default:now()"`
+}
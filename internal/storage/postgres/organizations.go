@@ -4,6 +4,7 @@ package postgres
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/regrada-ai/regrada-be/internal/storage"
@@ -173,11 +174,11 @@ func (r *OrganizationRepository) Delete(ctx context.Context, id string) error {
 	return nil
 }
 
-func (r *OrganizationRepository) IncrementRequestCount(ctx context.Context, id string) (*storage.Organization, error) {
+func (r *OrganizationRepository) IncrementRequestCount(ctx context.Context, id string, n int64) (*storage.Organization, error) {
 	var dbOrg DBOrganization
 	_, err := r.db.NewUpdate().
 		Model(&dbOrg).
-		Set("monthly_request_count = monthly_request_count + 1").
+		Set("monthly_request_count = monthly_request_count + ?", n).
 		Where("id = ?", id).
 		Where("deleted_at IS NULL").
 		Returning("*").
@@ -202,6 +203,33 @@ func (r *OrganizationRepository) IncrementRequestCount(ctx context.Context, id s
 	}, nil
 }
 
+func (r *OrganizationRepository) ReserveUsage(ctx context.Context, id string, n, ceiling int64) (int64, error) {
+	// The locking subquery serializes concurrent reservations, so the count
+	// never passes the ceiling
+	var granted int64
+	err := r.db.NewRaw(`
+		UPDATE organizations AS o
+		SET monthly_request_count = o.monthly_request_count + g.granted
+		FROM (
+			SELECT id, LEAST(?, GREATEST(? - monthly_request_count, 0)) AS granted
+			FROM organizations
+			WHERE id = ? AND deleted_at IS NULL
+			FOR UPDATE
+		) AS g
+		WHERE o.id = g.id
+		RETURNING g.granted`, n, ceiling, id).
+		Scan(ctx, &granted)
+
+	if err == sql.ErrNoRows {
+		return 0, storage.ErrNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return granted, nil
+}
+
 func (r *OrganizationRepository) ResetMonthlyUsage(ctx context.Context, id string) error {
 	_, err := r.db.NewUpdate().
 		Model((*DBOrganization)(nil)).
@@ -0,0 +1,126 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/regrada-ai/regrada-be/internal/storage"
+	"github.com/uptrace/bun"
+)
+
+// tracePartitionName matches monthly partitions created by ensure_traces_partition
+var tracePartitionName = regexp.MustCompile(`^traces_p(\d{6})$`)
+
+type TracePartitionRepository struct {
+	db *bun.DB
+}
+
+func NewTracePartitionRepository(db *bun.DB) *TracePartitionRepository {
+	return &TracePartitionRepository{db: db}
+}
+
+func (r *TracePartitionRepository) EnsureMonth(ctx context.Context, t time.Time) (bool, error) {
+	var created bool
+	err := r.db.QueryRowContext(ctx, "SELECT ensure_traces_partition(?)", t.UTC()).Scan(&created)
+	return created, err
+}
+
+func (r *TracePartitionRepository) List(ctx context.Context) ([]storage.TracePartition, error) {
+	var names []string
+	err := r.db.NewSelect().
+		TableExpr("pg_inherits AS i").
+		Join("JOIN pg_class AS c ON c.oid = i.inhrelid").
+		ColumnExpr("c.relname").
+		Where("i.inhparent = 'traces'::regclass").
+		OrderExpr("c.relname").
+		Scan(ctx, &names)
+	if err != nil {
+		return nil, err
+	}
+
+	var restores []struct {
+		RangeStart time.Time `bun:"range_start"`
+		RangeEnd   time.Time `bun:"range_end"`
+	}
+	err = r.db.NewSelect().
+		Model((*DBArchiveRestore)(nil)).
+		Column("range_start", "range_end").
+		Where("status IN (?)", bun.In(heldRestoreStatuses)).
+		Scan(ctx, &restores)
+	if err != nil {
+		return nil, err
+	}
+
+	partitions := make([]storage.TracePartition, 0, len(names))
+	for _, name := range names {
+		match := tracePartitionName.FindStringSubmatch(name)
+		if match == nil {
+			continue // the default partition
+		}
+		from, err := time.Parse("200601", match[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid partition name %q: %w", name, err)
+		}
+		partition := storage.TracePartition{
+			Name: name,
+			From: from,
+			To:   from.AddDate(0, 1, 0),
+		}
+		for _, restore := range restores {
+			if restore.RangeStart.Before(partition.To) && restore.RangeEnd.After(partition.From) {
+				partition.Restored = true
+			}
+		}
+		partitions = append(partitions, partition)
+	}
+	return partitions, nil
+}
+
+func (r *TracePartitionRepository) DefaultMonths(ctx context.Context) ([]time.Time, error) {
+	var months []time.Time
+	err := r.db.NewSelect().
+		TableExpr("traces_default").
+		ColumnExpr("DISTINCT date_trunc('month', timestamp AT TIME ZONE 'UTC') AS month").
+		OrderExpr("month").
+		Scan(ctx, &months)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range months {
+		// timestamp without time zone: the wall clock is already UTC
+		months[i] = time.Date(months[i].Year(), months[i].Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+	return months, nil
+}
+
+func (r *TracePartitionRepository) Drop(ctx context.Context, name string) error {
+	match := tracePartitionName.FindStringSubmatch(name)
+	if match == nil {
+		return fmt.Errorf("not a trace partition: %q", name)
+	}
+	monthStart, err := time.Parse("200601", match[1])
+	if err != nil {
+		return fmt.Errorf("not a trace partition: %q", name)
+	}
+
+	return r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if _, err := tx.ExecContext(ctx, "ALTER TABLE traces DETACH PARTITION ?", bun.Ident(name)); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, "DROP TABLE ?", bun.Ident(name)); err != nil {
+			return err
+		}
+		// Release the trace IDs of the dropped month
+		_, err := tx.NewDelete().
+			Model((*DBTraceKey)(nil)).
+			Where("timestamp >= ?", monthStart).
+			Where("timestamp < ?", monthStart.AddDate(0, 1, 0)).
+			Exec(ctx)
+		return err
+	})
+}
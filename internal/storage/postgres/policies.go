@@ -0,0 +1,180 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/regrada-ai/regrada-be/internal/storage"
+	"github.com/uptrace/bun"
+)
+
+type PolicyRepository struct {
+	db *bun.DB
+}
+
+func NewPolicyRepository(db *bun.DB) *PolicyRepository {
+	return &PolicyRepository{db: db}
+}
+
+func (r *PolicyRepository) Upsert(ctx context.Context, policy *storage.Policy) (bool, error) {
+	conflict := "CONFLICT (organization_id, policy_id) WHERE project_id IS NULL DO UPDATE"
+	if policy.ProjectID != "" {
+		conflict = "CONFLICT (project_id, policy_id) WHERE project_id IS NOT NULL DO UPDATE"
+	}
+
+	dbPolicy := toDBPolicy(policy)
+	_, err := r.db.NewInsert().
+		Model(dbPolicy).
+		On(conflict).
+		Set("description = EXCLUDED.description").
+		Set("severity = EXCLUDED.severity").
+		Set("owner = EXCLUDED.owner").
+		Set("enabled = EXCLUDED.enabled").
+		Set("blocking = EXCLUDED.blocking").
+		Returning("*, (xmax = 0) AS inserted").
+		Exec(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	*policy = *toPolicy(dbPolicy)
+	return dbPolicy.Inserted, nil
+}
+
+func (r *PolicyRepository) Get(ctx context.Context, orgID, projectID, policyID string) (*storage.Policy, error) {
+	var dbPolicy DBPolicy
+	err := scopePolicies(r.db.NewSelect().Model(&dbPolicy), orgID, projectID).
+		Where("pol.policy_id = ?", policyID).
+		Scan(ctx)
+
+	if err == sql.ErrNoRows {
+		return nil, storage.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return toPolicy(&dbPolicy), nil
+}
+
+func (r *PolicyRepository) List(ctx context.Context, orgID, projectID string) ([]*storage.Policy, error) {
+	var dbPolicies []DBPolicy
+	err := scopePolicies(r.db.NewSelect().Model(&dbPolicies), orgID, projectID).
+		Order("pol.policy_id").
+		Scan(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return toPolicies(dbPolicies), nil
+}
+
+func (r *PolicyRepository) Effective(ctx context.Context, orgID, projectID string) ([]*storage.Policy, error) {
+	var dbPolicies []DBPolicy
+	err := r.db.NewSelect().
+		Model(&dbPolicies).
+		DistinctOn("pol.policy_id").
+		Where("pol.organization_id = ?", orgID).
+		Where("pol.project_id IS NULL OR pol.project_id = ?", projectID).
+		OrderExpr("pol.policy_id, pol.project_id NULLS LAST").
+		Scan(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return toPolicies(dbPolicies), nil
+}
+
+func (r *PolicyRepository) Delete(ctx context.Context, orgID, projectID, policyID string) error {
+	query := r.db.NewDelete().
+		Model((*DBPolicy)(nil)).
+		Where("organization_id = ?", orgID).
+		Where("policy_id = ?", policyID)
+	if projectID == "" {
+		query = query.Where("project_id IS NULL")
+	} else {
+		query = query.Where("project_id = ?", projectID)
+	}
+
+	res, err := query.Exec(ctx)
+	return checkRowsAffected(res, err)
+}
+
+func (r *PolicyRepository) ViolationCounts(ctx context.Context, projectID string, from, to time.Time, interval storage.ViolationInterval) ([]storage.ViolationCount, error) {
+	// Runs uploaded without violations store JSON null rather than an array
+	query := r.db.NewSelect().
+		Model((*DBTestRun)(nil)).
+		ColumnExpr("v.value->>'policy_id' AS policy_id").
+		ColumnExpr("date_trunc(?, tr.timestamp AT TIME ZONE 'UTC') AT TIME ZONE 'UTC' AS bucket", string(interval)).
+		ColumnExpr("count(*) AS count, count(DISTINCT tr.id) AS runs").
+		Join("CROSS JOIN LATERAL jsonb_array_elements(CASE WHEN jsonb_typeof(tr.violations) = 'array' THEN tr.violations ELSE '[]' END) AS v").
+		Where("tr.project_id = ?", projectID).
+		Where("tr.deleted_at IS NULL").
+		GroupExpr("1, 2").
+		OrderExpr("2, 1")
+	if !from.IsZero() {
+		query = query.Where("tr.timestamp >= ?", from)
+	}
+	if !to.IsZero() {
+		query = query.Where("tr.timestamp < ?", to)
+	}
+
+	counts := []storage.ViolationCount{}
+	if err := query.Scan(ctx, &counts); err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+// scopePolicies restricts a policy query to the organization's own policies,
+// or to the project's if projectID is set
+func scopePolicies(q *bun.SelectQuery, orgID, projectID string) *bun.SelectQuery {
+	q = q.Where("pol.organization_id = ?", orgID)
+	if projectID == "" {
+		return q.Where("pol.project_id IS NULL")
+	}
+	return q.Where("pol.project_id = ?", projectID)
+}
+
+func toDBPolicy(policy *storage.Policy) *DBPolicy {
+	return &DBPolicy{
+		OrganizationID: policy.OrganizationID,
+		ProjectID:      policy.ProjectID,
+		PolicyID:       policy.PolicyID,
+		Description:    policy.Description,
+		Severity:       string(policy.Severity),
+		Owner:          policy.Owner,
+		Enabled:        policy.Enabled,
+		Blocking:       policy.Blocking,
+		CreatedBy:      policy.CreatedBy,
+	}
+}
+
+func toPolicy(dbPolicy *DBPolicy) *storage.Policy {
+	return &storage.Policy{
+		OrganizationID: dbPolicy.OrganizationID,
+		ProjectID:      dbPolicy.ProjectID,
+		PolicyID:       dbPolicy.PolicyID,
+		Description:    dbPolicy.Description,
+		Severity:       storage.PolicySeverity(dbPolicy.Severity),
+		Owner:          dbPolicy.Owner,
+		Enabled:        dbPolicy.Enabled,
+		Blocking:       dbPolicy.Blocking,
+		CreatedBy:      dbPolicy.CreatedBy,
+		CreatedAt:      dbPolicy.CreatedAt,
+		UpdatedAt:      dbPolicy.UpdatedAt,
+	}
+}
+
+func toPolicies(dbPolicies []DBPolicy) []*storage.Policy {
+	policies := make([]*storage.Policy, len(dbPolicies))
+	for i := range dbPolicies {
+		policies[i] = toPolicy(&dbPolicies[i])
+	}
+	return policies
+}
@@ -0,0 +1,423 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/regrada-ai/regrada-be/internal/domain"
+	"github.com/regrada-ai/regrada-be/internal/storage"
+	"github.com/uptrace/bun"
+)
+
+const (
+	promptLabelsExpr  = "(SELECT jsonb_object_agg(pl.label, pl.version) FROM prompt_labels AS pl WHERE pl.prompt_id = pr.id) AS labels"
+	versionLabelsExpr = "ARRAY(SELECT pl.label FROM prompt_labels AS pl WHERE pl.prompt_id = pv.prompt_id AND pl.version = pv.version ORDER BY pl.label) AS labels"
+
+	promptNameConflict = "ERROR: duplicate key value violates unique constraint \"prompts_project_id_name_key\" (SQLSTATE=23505)"
+
+	setLabelQuery = "INSERT INTO prompt_labels (prompt_id, label, version) ? ON CONFLICT (prompt_id, label) DO UPDATE SET version = EXCLUDED.version, updated_at = NOW()"
+)
+
+type PromptRepository struct {
+	db *bun.DB
+}
+
+func NewPromptRepository(db *bun.DB) *PromptRepository {
+	return &PromptRepository{db: db}
+}
+
+func (r *PromptRepository) CreatePrompt(ctx context.Context, prompt *storage.Prompt) error {
+	dbPrompt := &DBPrompt{
+		ProjectID:   prompt.ProjectID,
+		Name:        prompt.Name,
+		Description: prompt.Description,
+		CreatedBy:   prompt.CreatedBy,
+	}
+
+	_, err := r.db.NewInsert().Model(dbPrompt).Returning("id, latest_version, created_at, updated_at").Exec(ctx)
+	if err != nil {
+		if err.Error() == promptNameConflict {
+			return storage.ErrAlreadyExists
+		}
+		return err
+	}
+
+	created, err := toPrompt(dbPrompt)
+	if err != nil {
+		return err
+	}
+	*prompt = *created
+	return nil
+}
+
+func (r *PromptRepository) GetPrompt(ctx context.Context, projectID, name string) (*storage.Prompt, error) {
+	var dbPrompt DBPrompt
+	err := r.db.NewSelect().
+		Model(&dbPrompt).
+		ColumnExpr("pr.*").
+		ColumnExpr(promptLabelsExpr).
+		Where("pr.project_id = ?", projectID).
+		Where("pr.name = ?", name).
+		Scan(ctx)
+
+	if err == sql.ErrNoRows {
+		return nil, storage.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return toPrompt(&dbPrompt)
+}
+
+func (r *PromptRepository) ListPrompts(ctx context.Context, projectID string) ([]*storage.Prompt, error) {
+	var dbPrompts []DBPrompt
+	err := r.db.NewSelect().
+		Model(&dbPrompts).
+		ColumnExpr("pr.*").
+		ColumnExpr(promptLabelsExpr).
+		Where("pr.project_id = ?", projectID).
+		Order("pr.name").
+		Scan(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	prompts := make([]*storage.Prompt, len(dbPrompts))
+	for i := range dbPrompts {
+		if prompts[i], err = toPrompt(&dbPrompts[i]); err != nil {
+			return nil, err
+		}
+	}
+	return prompts, nil
+}
+
+func (r *PromptRepository) UpdatePrompt(ctx context.Context, prompt *storage.Prompt) error {
+	res, err := r.db.NewUpdate().
+		Model((*DBPrompt)(nil)).
+		Set("description = ?", bun.NullZero(prompt.Description)).
+		Where("project_id = ?", prompt.ProjectID).
+		Where("name = ?", prompt.Name).
+		Exec(ctx)
+
+	return checkRowsAffected(res, err)
+}
+
+func (r *PromptRepository) DeletePrompt(ctx context.Context, projectID, name string) error {
+	res, err := r.db.NewDelete().
+		Model((*DBPrompt)(nil)).
+		Where("project_id = ?", projectID).
+		Where("name = ?", name).
+		Exec(ctx)
+
+	return checkRowsAffected(res, err)
+}
+
+func (r *PromptRepository) CreateVersion(ctx context.Context, projectID, name string, version *storage.PromptVersion) error {
+	params, err := json.Marshal(version.Params)
+	if err != nil {
+		return err
+	}
+	if version.Params == nil {
+		params = nil
+	}
+
+	return r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		// Bumping the counter locks the prompt, so concurrent versions are
+		// numbered one after another
+		var prompt DBPrompt
+		err := tx.NewUpdate().
+			Model(&prompt).
+			Set("latest_version = latest_version + 1").
+			Where("project_id = ?", projectID).
+			Where("name = ?", name).
+			Returning("id, latest_version").
+			Scan(ctx)
+		if err == sql.ErrNoRows {
+			return storage.ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		dbVersion := &DBPromptVersion{
+			PromptID:  prompt.ID,
+			Version:   prompt.LatestVersion,
+			Template:  version.Template,
+			Variables: version.Variables,
+			Params:    params,
+			Model:     version.Model,
+			CreatedBy: version.CreatedBy,
+		}
+		if _, err := tx.NewInsert().Model(dbVersion).Returning("id, created_at").Exec(ctx); err != nil {
+			return err
+		}
+
+		if len(version.Labels) > 0 {
+			labels := make([]DBPromptLabel, len(version.Labels))
+			for i, label := range version.Labels {
+				labels[i] = DBPromptLabel{PromptID: prompt.ID, Label: label, Version: dbVersion.Version}
+			}
+			_, err := tx.NewInsert().
+				Model(&labels).
+				On("CONFLICT (prompt_id, label) DO UPDATE").
+				Set("version = EXCLUDED.version").
+				Set("updated_at = NOW()").
+				Returning("NULL").
+				Exec(ctx)
+			if err != nil {
+				return err
+			}
+		}
+
+		dbVersion.Name = name
+		dbVersion.Labels = version.Labels
+		created, err := toPromptVersion(dbVersion)
+		if err != nil {
+			return err
+		}
+		*version = *created
+		return nil
+	})
+}
+
+func (r *PromptRepository) GetVersion(ctx context.Context, projectID, name string, version int) (*storage.PromptVersion, error) {
+	query := r.selectVersions(projectID, name)
+	if version == 0 {
+		query = query.Where("pv.version = pr.latest_version")
+	} else {
+		query = query.Where("pv.version = ?", version)
+	}
+	return scanPromptVersion(ctx, query)
+}
+
+func (r *PromptRepository) GetLabeled(ctx context.Context, projectID, name, label string) (*storage.PromptVersion, error) {
+	query := r.selectVersions(projectID, name).
+		Join("JOIN prompt_labels AS lbl ON lbl.prompt_id = pv.prompt_id AND lbl.version = pv.version").
+		Where("lbl.label = ?", label)
+	return scanPromptVersion(ctx, query)
+}
+
+func (r *PromptRepository) ListVersions(ctx context.Context, projectID, name string) ([]*storage.PromptVersion, error) {
+	if _, err := r.GetPrompt(ctx, projectID, name); err != nil {
+		return nil, err
+	}
+
+	var dbVersions []DBPromptVersion
+	err := r.selectVersions(projectID, name).Order("pv.version DESC").Scan(ctx, &dbVersions)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make([]*storage.PromptVersion, len(dbVersions))
+	for i := range dbVersions {
+		if versions[i], err = toPromptVersion(&dbVersions[i]); err != nil {
+			return nil, err
+		}
+	}
+	return versions, nil
+}
+
+func (r *PromptRepository) SetLabel(ctx context.Context, projectID, name, label string, version int) error {
+	// Selecting the version makes a missing prompt or version insert nothing
+	source := r.db.NewSelect().
+		Model((*DBPromptVersion)(nil)).
+		ColumnExpr("pv.prompt_id, ?, pv.version", label).
+		Join("JOIN prompts AS pr ON pr.id = pv.prompt_id").
+		Where("pr.project_id = ?", projectID).
+		Where("pr.name = ?", name).
+		Where("pv.version = ?", version)
+
+	res, err := r.db.ExecContext(ctx, setLabelQuery, source)
+	return checkRowsAffected(res, err)
+}
+
+func (r *PromptRepository) DeleteLabel(ctx context.Context, projectID, name, label string) error {
+	res, err := r.db.NewDelete().
+		Model((*DBPromptLabel)(nil)).
+		Where("label = ?", label).
+		Where("prompt_id = (SELECT id FROM prompts WHERE project_id = ? AND name = ?)", projectID, name).
+		Exec(ctx)
+
+	return checkRowsAffected(res, err)
+}
+
+func (r *PromptRepository) Stats(ctx context.Context, projectID, name string, from, to time.Time) ([]storage.PromptVersionStats, error) {
+	var traceStats []struct {
+		PromptName    string  `bun:"prompt_name"`
+		PromptVersion int     `bun:"prompt_version"`
+		Traces        int     `bun:"traces"`
+		AvgLatencyMS  float64 `bun:"avg_latency_ms"`
+		P95LatencyMS  float64 `bun:"p95_latency_ms"`
+		TokensIn      int64   `bun:"tokens_in"`
+		TokensOut     int64   `bun:"tokens_out"`
+	}
+	traces := r.db.NewSelect().
+		Model((*DBTrace)(nil)).
+		ColumnExpr("t.prompt_name, COALESCE(t.prompt_version, 0) AS prompt_version").
+		ColumnExpr("count(*) AS traces").
+		ColumnExpr("avg(t.latency_ms) AS avg_latency_ms").
+		ColumnExpr("percentile_cont(0.95) WITHIN GROUP (ORDER BY t.latency_ms) AS p95_latency_ms").
+		ColumnExpr("COALESCE(sum(t.tokens_in), 0) AS tokens_in, COALESCE(sum(t.tokens_out), 0) AS tokens_out").
+		Where("t.project_id = ?", projectID).
+		Where("t.prompt_name IS NOT NULL").
+		GroupExpr("1, 2")
+	if name != "" {
+		traces = traces.Where("t.prompt_name = ?", name)
+	}
+	if !from.IsZero() {
+		traces = traces.Where("t.timestamp >= ?", from)
+	}
+	if !to.IsZero() {
+		traces = traces.Where("t.timestamp < ?", to)
+	}
+	if err := traces.Scan(ctx, &traceStats); err != nil {
+		return nil, err
+	}
+
+	// Case results are stored in the run's results array
+	var caseStats []struct {
+		PromptName    string  `bun:"prompt_name"`
+		PromptVersion int     `bun:"prompt_version"`
+		Cases         int     `bun:"cases"`
+		CasePassRate  float64 `bun:"case_pass_rate"`
+	}
+	cases := r.db.NewSelect().
+		Model((*DBTestRun)(nil)).
+		ColumnExpr("c.value->>'prompt_name' AS prompt_name").
+		ColumnExpr("COALESCE((c.value->>'prompt_version')::int, 0) AS prompt_version").
+		ColumnExpr("count(*) AS cases").
+		ColumnExpr("COALESCE(avg((c.value->'aggregates'->>'pass_rate')::float8), 0) AS case_pass_rate").
+		Join("CROSS JOIN LATERAL jsonb_array_elements(CASE WHEN jsonb_typeof(tr.results) = 'array' THEN tr.results ELSE '[]' END) AS c").
+		Where("tr.project_id = ?", projectID).
+		Where("c.value->>'prompt_name' IS NOT NULL").
+		GroupExpr("1, 2")
+	if name != "" {
+		cases = cases.Where("c.value->>'prompt_name' = ?", name)
+	}
+	if !from.IsZero() {
+		cases = cases.Where("tr.timestamp >= ?", from)
+	}
+	if !to.IsZero() {
+		cases = cases.Where("tr.timestamp < ?", to)
+	}
+	if err := cases.Scan(ctx, &caseStats); err != nil {
+		return nil, err
+	}
+
+	type key struct {
+		name    string
+		version int
+	}
+	byVersion := make(map[key]*storage.PromptVersionStats)
+	get := func(name string, version int) *storage.PromptVersionStats {
+		k := key{name, version}
+		if byVersion[k] == nil {
+			byVersion[k] = &storage.PromptVersionStats{PromptName: name, PromptVersion: version}
+		}
+		return byVersion[k]
+	}
+	for _, s := range traceStats {
+		stats := get(s.PromptName, s.PromptVersion)
+		stats.Traces = s.Traces
+		stats.AvgLatencyMS = s.AvgLatencyMS
+		stats.P95LatencyMS = s.P95LatencyMS
+		stats.TokensIn = s.TokensIn
+		stats.TokensOut = s.TokensOut
+	}
+	for _, s := range caseStats {
+		stats := get(s.PromptName, s.PromptVersion)
+		stats.Cases = s.Cases
+		stats.CasePassRate = s.CasePassRate
+	}
+
+	result := make([]storage.PromptVersionStats, 0, len(byVersion))
+	for _, stats := range byVersion {
+		result = append(result, *stats)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].PromptName != result[j].PromptName {
+			return result[i].PromptName < result[j].PromptName
+		}
+		return result[i].PromptVersion > result[j].PromptVersion
+	})
+	return result, nil
+}
+
+// selectVersions selects the versions of a prompt with the prompt's name
+// and the labels pointing at each version
+func (r *PromptRepository) selectVersions(projectID, name string) *bun.SelectQuery {
+	return r.db.NewSelect().
+		Model((*DBPromptVersion)(nil)).
+		ColumnExpr("pv.*, pr.name AS name").
+		ColumnExpr(versionLabelsExpr).
+		Join("JOIN prompts AS pr ON pr.id = pv.prompt_id").
+		Where("pr.project_id = ?", projectID).
+		Where("pr.name = ?", name)
+}
+
+func scanPromptVersion(ctx context.Context, query *bun.SelectQuery) (*storage.PromptVersion, error) {
+	var dbVersion DBPromptVersion
+	err := query.Limit(1).Scan(ctx, &dbVersion)
+	if err == sql.ErrNoRows {
+		return nil, storage.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return toPromptVersion(&dbVersion)
+}
+
+func toPrompt(dbPrompt *DBPrompt) (*storage.Prompt, error) {
+	prompt := &storage.Prompt{
+		ID:            dbPrompt.ID,
+		ProjectID:     dbPrompt.ProjectID,
+		Name:          dbPrompt.Name,
+		Description:   dbPrompt.Description,
+		LatestVersion: dbPrompt.LatestVersion,
+		Labels:        map[string]int{},
+		CreatedBy:     dbPrompt.CreatedBy,
+		CreatedAt:     dbPrompt.CreatedAt,
+		UpdatedAt:     dbPrompt.UpdatedAt,
+	}
+	if err := decodeJSONField(dbPrompt.Labels, &prompt.Labels); err != nil {
+		return nil, err
+	}
+	return prompt, nil
+}
+
+func toPromptVersion(dbVersion *DBPromptVersion) (*storage.PromptVersion, error) {
+	version := &storage.PromptVersion{
+		ID:        dbVersion.ID,
+		PromptID:  dbVersion.PromptID,
+		Name:      dbVersion.Name,
+		Version:   dbVersion.Version,
+		Template:  dbVersion.Template,
+		Variables: nonNilStrings(dbVersion.Variables),
+		Model:     dbVersion.Model,
+		Labels:    nonNilStrings(dbVersion.Labels),
+		CreatedBy: dbVersion.CreatedBy,
+		CreatedAt: dbVersion.CreatedAt,
+	}
+	if len(dbVersion.Params) > 0 {
+		version.Params = &domain.SamplingParams{}
+		if err := decodeJSONField(dbVersion.Params, version.Params); err != nil {
+			return nil, err
+		}
+	}
+	return version, nil
+}
+
+// nonNilStrings returns an empty slice for nil, so it encodes as []
+func nonNilStrings(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
@@ -0,0 +1,131 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/regrada-ai/regrada-be/internal/storage"
+	"github.com/uptrace/bun"
+)
+
+type QualityGateRepository struct {
+	db *bun.DB
+}
+
+func NewQualityGateRepository(db *bun.DB) *QualityGateRepository {
+	return &QualityGateRepository{db: db}
+}
+
+func (r *QualityGateRepository) Get(ctx context.Context, projectID string) (*storage.QualityGate, error) {
+	var dbGate DBQualityGate
+	err := r.db.NewSelect().
+		Model(&dbGate).
+		Where("project_id = ?", projectID).
+		Scan(ctx)
+
+	if err == sql.ErrNoRows {
+		return nil, storage.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return toQualityGate(&dbGate)
+}
+
+func (r *QualityGateRepository) Upsert(ctx context.Context, gate *storage.QualityGate) error {
+	conditions := gate.Conditions
+	if conditions == nil {
+		conditions = []storage.GateCondition{}
+	}
+	conditionsData, err := json.Marshal(conditions)
+	if err != nil {
+		return err
+	}
+
+	dbGate := &DBQualityGate{
+		ProjectID:      gate.ProjectID,
+		Enabled:        gate.Enabled,
+		BaselineBranch: gate.BaselineBranch,
+		Conditions:     conditionsData,
+		UpdatedBy:      gate.UpdatedBy,
+	}
+	_, err = r.db.NewInsert().
+		Model(dbGate).
+		On("CONFLICT (project_id) DO UPDATE").
+		Set("enabled = EXCLUDED.enabled").
+		Set("baseline_branch = EXCLUDED.baseline_branch").
+		Set("conditions = EXCLUDED.conditions").
+		Set("updated_by = EXCLUDED.updated_by").
+		Returning("*").
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+
+	saved, err := toQualityGate(dbGate)
+	if err != nil {
+		return err
+	}
+	*gate = *saved
+	return nil
+}
+
+func (r *QualityGateRepository) Delete(ctx context.Context, projectID string) error {
+	res, err := r.db.NewDelete().
+		Model((*DBQualityGate)(nil)).
+		Where("project_id = ?", projectID).
+		Exec(ctx)
+
+	return checkRowsAffected(res, err)
+}
+
+func (r *QualityGateRepository) GetAlpha(ctx context.Context, projectID string) (*float64, error) {
+	var dbProject DBProject
+	err := r.db.NewSelect().
+		Model(&dbProject).
+		Column("significance_alpha").
+		Where("id = ?", projectID).
+		Scan(ctx)
+
+	if err == sql.ErrNoRows {
+		return nil, storage.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return dbProject.Alpha, nil
+}
+
+func (r *QualityGateRepository) SetAlpha(ctx context.Context, projectID string, alpha *float64) error {
+	res, err := r.db.NewUpdate().
+		Model((*DBProject)(nil)).
+		Set("significance_alpha = ?", alpha).
+		Set("updated_at = ?", time.Now()).
+		Where("id = ?", projectID).
+		Where("deleted_at IS NULL").
+		Exec(ctx)
+
+	return checkRowsAffected(res, err)
+}
+
+func toQualityGate(dbGate *DBQualityGate) (*storage.QualityGate, error) {
+	gate := &storage.QualityGate{
+		ProjectID:      dbGate.ProjectID,
+		Enabled:        dbGate.Enabled,
+		BaselineBranch: dbGate.BaselineBranch,
+		Conditions:     []storage.GateCondition{},
+		UpdatedBy:      dbGate.UpdatedBy,
+		CreatedAt:      dbGate.CreatedAt,
+		UpdatedAt:      dbGate.UpdatedAt,
+	}
+	if err := decodeJSONField(dbGate.Conditions, &gate.Conditions); err != nil {
+		return nil, err
+	}
+	return gate, nil
+}
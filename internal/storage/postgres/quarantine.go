@@ -0,0 +1,79 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package postgres
+
+import (
+	"context"
+
+	"github.com/regrada-ai/regrada-be/internal/storage"
+	"github.com/uptrace/bun"
+)
+
+type QuarantineRepository struct {
+	db *bun.DB
+}
+
+func NewQuarantineRepository(db *bun.DB) *QuarantineRepository {
+	return &QuarantineRepository{db: db}
+}
+
+func (r *QuarantineRepository) List(ctx context.Context, projectID string) ([]*storage.CaseQuarantine, error) {
+	var dbQuarantines []DBCaseQuarantine
+	err := r.db.NewSelect().
+		Model(&dbQuarantines).
+		Where("project_id = ?", projectID).
+		Order("case_id").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	quarantines := make([]*storage.CaseQuarantine, len(dbQuarantines))
+	for i := range dbQuarantines {
+		quarantines[i] = toCaseQuarantine(&dbQuarantines[i])
+	}
+	return quarantines, nil
+}
+
+func (r *QuarantineRepository) Upsert(ctx context.Context, quarantine *storage.CaseQuarantine) (bool, error) {
+	dbQuarantine := &DBCaseQuarantine{
+		ProjectID:     quarantine.ProjectID,
+		CaseID:        quarantine.CaseID,
+		Reason:        quarantine.Reason,
+		QuarantinedBy: quarantine.QuarantinedBy,
+	}
+	_, err := r.db.NewInsert().
+		Model(dbQuarantine).
+		On("CONFLICT (project_id, case_id) DO UPDATE").
+		Set("reason = EXCLUDED.reason").
+		Set("quarantined_by = EXCLUDED.quarantined_by").
+		Returning("*, (xmax = 0) AS inserted").
+		Exec(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	*quarantine = *toCaseQuarantine(dbQuarantine)
+	return dbQuarantine.Inserted, nil
+}
+
+func (r *QuarantineRepository) Delete(ctx context.Context, projectID, caseID string) error {
+	res, err := r.db.NewDelete().
+		Model((*DBCaseQuarantine)(nil)).
+		Where("project_id = ?", projectID).
+		Where("case_id = ?", caseID).
+		Exec(ctx)
+
+	return checkRowsAffected(res, err)
+}
+
+func toCaseQuarantine(dbQuarantine *DBCaseQuarantine) *storage.CaseQuarantine {
+	return &storage.CaseQuarantine{
+		ProjectID:     dbQuarantine.ProjectID,
+		CaseID:        dbQuarantine.CaseID,
+		Reason:        dbQuarantine.Reason,
+		QuarantinedBy: dbQuarantine.QuarantinedBy,
+		CreatedAt:     dbQuarantine.CreatedAt,
+		UpdatedAt:     dbQuarantine.UpdatedAt,
+	}
+}
@@ -0,0 +1,97 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/regrada-ai/regrada-be/internal/domain"
+	"github.com/regrada-ai/regrada-be/internal/storage"
+	"github.com/uptrace/bun"
+)
+
+type RedactionPolicyRepository struct {
+	db *bun.DB
+}
+
+func NewRedactionPolicyRepository(db *bun.DB) *RedactionPolicyRepository {
+	return &RedactionPolicyRepository{db: db}
+}
+
+func (r *RedactionPolicyRepository) Get(ctx context.Context, projectID string) (*domain.RedactionPolicy, error) {
+	var dbPolicy DBRedactionPolicy
+	err := r.db.NewSelect().
+		Model(&dbPolicy).
+		Where("project_id = ?", projectID).
+		Scan(ctx)
+
+	if err == sql.ErrNoRows {
+		return nil, storage.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	policy := &domain.RedactionPolicy{
+		Enabled:   dbPolicy.Enabled,
+		Mode:      dbPolicy.Mode,
+		Detectors: dbPolicy.Detectors,
+		UpdatedAt: dbPolicy.UpdatedAt,
+		HashKey:   dbPolicy.HashKey,
+	}
+
+	if err := decodeJSONField(dbPolicy.CustomRules, &policy.CustomRules); err != nil {
+		return nil, err
+	}
+
+	return policy, nil
+}
+
+func (r *RedactionPolicyRepository) Upsert(ctx context.Context, projectID string, policy *domain.RedactionPolicy) error {
+	customRules := policy.CustomRules
+	if customRules == nil {
+		customRules = []domain.CustomRedactionRule{}
+	}
+
+	customRulesData, err := json.Marshal(customRules)
+	if err != nil {
+		return err
+	}
+
+	detectors := policy.Detectors
+	if detectors == nil {
+		detectors = []string{}
+	}
+
+	dbPolicy := &DBRedactionPolicy{
+		ProjectID:   projectID,
+		Enabled:     policy.Enabled,
+		Mode:        policy.Mode,
+		Detectors:   detectors,
+		CustomRules: customRulesData,
+		UpdatedAt:   time.Now(),
+	}
+
+	// The hash key is generated by the column default and kept on update
+	_, err = r.db.NewInsert().
+		Model(dbPolicy).
+		ExcludeColumn("hash_key").
+		On("CONFLICT (project_id) DO UPDATE").
+		Set("enabled = EXCLUDED.enabled").
+		Set("mode = EXCLUDED.mode").
+		Set("detectors = EXCLUDED.detectors").
+		Set("custom_rules = EXCLUDED.custom_rules").
+		Set("updated_at = EXCLUDED.updated_at").
+		Returning("updated_at, hash_key").
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+
+	policy.UpdatedAt = dbPolicy.UpdatedAt
+	policy.HashKey = dbPolicy.HashKey
+	return nil
+}
@@ -0,0 +1,109 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/regrada-ai/regrada-be/internal/storage"
+	"github.com/uptrace/bun"
+)
+
+type RegressionRepository struct {
+	db *bun.DB
+}
+
+func NewRegressionRepository(db *bun.DB) *RegressionRepository {
+	return &RegressionRepository{db: db}
+}
+
+func (r *RegressionRepository) List(ctx context.Context, projectID string, includeResolved bool, limit, offset int) ([]*storage.RegressionDetection, error) {
+	var dbDetections []DBRegressionDetection
+	query := r.db.NewSelect().
+		Model(&dbDetections).
+		Where("project_id = ?", projectID).
+		Order("detected_at DESC").
+		Limit(limit).
+		Offset(offset)
+	if !includeResolved {
+		query = query.Where("resolved_at IS NULL")
+	}
+	if err := query.Scan(ctx); err != nil {
+		return nil, err
+	}
+
+	detections := make([]*storage.RegressionDetection, len(dbDetections))
+	for i := range dbDetections {
+		detections[i] = toRegressionDetection(&dbDetections[i])
+	}
+	return detections, nil
+}
+
+func (r *RegressionRepository) Upsert(ctx context.Context, detection *storage.RegressionDetection) error {
+	dbDetection := &DBRegressionDetection{
+		ProjectID:        detection.ProjectID,
+		CaseID:           detection.CaseID,
+		Branch:           detection.Branch,
+		RegressionGitSHA: detection.RegressionGitSHA,
+		LastGoodGitSHA:   detection.LastGoodGitSHA,
+		RegressionType:   detection.RegressionType,
+		Severity:         detection.Severity,
+		Details:          detection.Details,
+	}
+	if dbDetection.Details == nil {
+		dbDetection.Details = []byte("{}")
+	}
+	_, err := r.db.NewInsert().
+		Model(dbDetection).
+		On("CONFLICT (project_id, branch, case_id, regression_type) WHERE resolved_at IS NULL DO UPDATE").
+		Set("regression_git_sha = EXCLUDED.regression_git_sha").
+		Set("last_good_git_sha = EXCLUDED.last_good_git_sha").
+		Set("severity = EXCLUDED.severity").
+		Set("details = EXCLUDED.details").
+		Returning("*").
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+
+	*detection = *toRegressionDetection(dbDetection)
+	return nil
+}
+
+func (r *RegressionRepository) Resolve(ctx context.Context, projectID, branch, caseID string) error {
+	_, err := r.db.NewUpdate().
+		Model((*DBRegressionDetection)(nil)).
+		Set("resolved_at = ?", time.Now()).
+		Where("project_id = ?", projectID).
+		Where("branch = ?", branch).
+		Where("case_id = ?", caseID).
+		Where("resolved_at IS NULL").
+		Exec(ctx)
+	return err
+}
+
+func (r *RegressionRepository) CountOpen(ctx context.Context, projectID, branch string) (int, error) {
+	return r.db.NewSelect().
+		Model((*DBRegressionDetection)(nil)).
+		Where("project_id = ?", projectID).
+		Where("branch = ?", branch).
+		Where("resolved_at IS NULL").
+		Count(ctx)
+}
+
+func toRegressionDetection(dbDetection *DBRegressionDetection) *storage.RegressionDetection {
+	return &storage.RegressionDetection{
+		ID:               dbDetection.ID,
+		ProjectID:        dbDetection.ProjectID,
+		CaseID:           dbDetection.CaseID,
+		Branch:           dbDetection.Branch,
+		RegressionGitSHA: dbDetection.RegressionGitSHA,
+		LastGoodGitSHA:   dbDetection.LastGoodGitSHA,
+		RegressionType:   dbDetection.RegressionType,
+		Severity:         dbDetection.Severity,
+		Details:          dbDetection.Details,
+		DetectedAt:       dbDetection.DetectedAt,
+		ResolvedAt:       dbDetection.ResolvedAt,
+	}
+}
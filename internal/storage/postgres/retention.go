@@ -0,0 +1,212 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/regrada-ai/regrada-be/internal/storage"
+	"github.com/uptrace/bun"
+)
+
+type RetentionRepository struct {
+	db *bun.DB
+}
+
+func NewRetentionRepository(db *bun.DB) *RetentionRepository {
+	return &RetentionRepository{db: db}
+}
+
+// dbProjectRetention is the result of joining a project with its organization
+type dbProjectRetention struct {
+	ProjectID        string `bun:"project_id"`
+	ProjectName      string `bun:"project_name"`
+	OrganizationID   string `bun:"organization_id"`
+	Tier             string `bun:"tier"`
+	OrganizationDays *int   `bun:"organization_days"`
+	ProjectDays      *int   `bun:"project_days"`
+}
+
+func (r *RetentionRepository) selectProjects() *bun.SelectQuery {
+	return r.db.NewSelect().
+		TableExpr("projects AS p").
+		Join("JOIN organizations AS o ON o.id = p.organization_id").
+		ColumnExpr("p.id AS project_id").
+		ColumnExpr("p.name AS project_name").
+		ColumnExpr("o.id AS organization_id").
+		ColumnExpr("o.tier AS tier").
+		ColumnExpr("o.retention_days AS organization_days").
+		ColumnExpr("p.retention_days AS project_days").
+		Where("p.deleted_at IS NULL").
+		Where("o.deleted_at IS NULL")
+}
+
+func (r *RetentionRepository) GetOrganization(ctx context.Context, orgID string) (*storage.OrganizationRetention, error) {
+	var dbOrg DBOrganization
+	err := r.db.NewSelect().
+		Model(&dbOrg).
+		Column("id", "tier", "retention_days").
+		Where("id = ?", orgID).
+		Where("deleted_at IS NULL").
+		Scan(ctx)
+
+	if err == sql.ErrNoRows {
+		return nil, storage.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &storage.OrganizationRetention{
+		OrganizationID: dbOrg.ID,
+		Tier:           dbOrg.Tier,
+		Days:           dbOrg.RetentionDays,
+	}, nil
+}
+
+func (r *RetentionRepository) GetProject(ctx context.Context, projectID string) (*storage.ProjectRetention, error) {
+	var row dbProjectRetention
+	err := r.selectProjects().
+		Where("p.id = ?", projectID).
+		Scan(ctx, &row)
+
+	if err == sql.ErrNoRows {
+		return nil, storage.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return toProjectRetention(&row), nil
+}
+
+func (r *RetentionRepository) ListProjects(ctx context.Context, orgID string) ([]*storage.ProjectRetention, error) {
+	query := r.selectProjects().Order("p.created_at")
+	if orgID != "" {
+		query = query.Where("p.organization_id = ?", orgID)
+	}
+
+	var rows []dbProjectRetention
+	if err := query.Scan(ctx, &rows); err != nil {
+		return nil, err
+	}
+
+	projects := make([]*storage.ProjectRetention, len(rows))
+	for i := range rows {
+		projects[i] = toProjectRetention(&rows[i])
+	}
+	return projects, nil
+}
+
+func toProjectRetention(row *dbProjectRetention) *storage.ProjectRetention {
+	return &storage.ProjectRetention{
+		ProjectID:        row.ProjectID,
+		ProjectName:      row.ProjectName,
+		OrganizationID:   row.OrganizationID,
+		Tier:             row.Tier,
+		OrganizationDays: row.OrganizationDays,
+		ProjectDays:      row.ProjectDays,
+	}
+}
+
+func (r *RetentionRepository) SetOrganizationDays(ctx context.Context, orgID string, days *int) error {
+	res, err := r.db.NewUpdate().
+		Model((*DBOrganization)(nil)).
+		Set("retention_days = ?", days).
+		Set("updated_at = ?", time.Now()).
+		Where("id = ?", orgID).
+		Where("deleted_at IS NULL").
+		Exec(ctx)
+
+	return checkRowsAffected(res, err)
+}
+
+func (r *RetentionRepository) SetProjectDays(ctx context.Context, projectID string, days *int) error {
+	res, err := r.db.NewUpdate().
+		Model((*DBProject)(nil)).
+		Set("retention_days = ?", days).
+		Set("updated_at = ?", time.Now()).
+		Where("id = ?", projectID).
+		Where("deleted_at IS NULL").
+		Exec(ctx)
+
+	return checkRowsAffected(res, err)
+}
+
+func checkRowsAffected(res sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return storage.ErrNotFound
+	}
+
+	return nil
+}
+
+// notRestored filters out traces in the range of a restore that has not
+// expired, which stay in Postgres until it does
+const notRestored = `NOT EXISTS (
+	SELECT 1 FROM trace_archive_restores AS r
+	WHERE r.project_id = traces.project_id
+		AND traces.timestamp >= r.range_start AND traces.timestamp < r.range_end
+		AND r.status IN (?)
+)`
+
+func (r *RetentionRepository) EstimatePurge(ctx context.Context, projectID string, expiredBefore, deletedBefore time.Time) (*storage.PurgeEstimate, error) {
+	var estimate storage.PurgeEstimate
+	err := r.db.NewSelect().
+		TableExpr("traces").
+		ColumnExpr("COUNT(*) FILTER (WHERE timestamp < ?) AS expired", expiredBefore).
+		ColumnExpr("COUNT(*) FILTER (WHERE timestamp >= ? AND deleted_at < ?) AS soft_deleted", expiredBefore, deletedBefore).
+		ColumnExpr("MIN(timestamp) AS oldest_trace").
+		Where("project_id = ?", projectID).
+		Where(notRestored, bun.In(heldRestoreStatuses)).
+		Scan(ctx, &estimate.Expired, &estimate.SoftDeleted, &estimate.OldestTrace)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &estimate, nil
+}
+
+func (r *RetentionRepository) PurgeTraces(ctx context.Context, projectID string, expiredBefore, deletedBefore time.Time, limit int) (int64, error) {
+	batch := r.db.NewSelect().
+		TableExpr("traces").
+		Column("id").
+		Where("project_id = ?", projectID).
+		WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.Where("timestamp < ?", expiredBefore).
+				WhereOr("deleted_at < ?", deletedBefore)
+		}).
+		Where(notRestored, bun.In(heldRestoreStatuses)).
+		Limit(limit)
+
+	// Release the purged trace IDs along with their traces
+	var purged int64
+	err := r.db.NewRaw(`
+		WITH purged AS (
+			DELETE FROM traces WHERE id IN (?)
+			RETURNING project_id, trace_id, timestamp
+		), released AS (
+			DELETE FROM trace_keys AS k USING purged AS p
+			WHERE k.project_id = p.project_id AND k.trace_id = p.trace_id AND k.timestamp = p.timestamp
+		)
+		SELECT COUNT(*) FROM purged`, batch).
+		Scan(ctx, &purged)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return purged, nil
+}
@@ -0,0 +1,609 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/regrada-ai/regrada-be/internal/domain"
+	"github.com/regrada-ai/regrada-be/internal/storage"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/pgdialect"
+)
+
+const (
+	reviewQueueNameConflict = "ERROR: duplicate key value violates unique constraint \"review_queues_project_id_name_key\" (SQLSTATE=23505)"
+
+	// sampleBuckets is the resolution of trace sampling. A trace is sampled
+	// if a hash of its ID falls in the first SampleRate of the buckets, so
+	// sampling is stable if a window is scanned twice.
+	sampleBuckets = 10000
+)
+
+type ReviewRepository struct {
+	db *bun.DB
+}
+
+func NewReviewRepository(db *bun.DB) *ReviewRepository {
+	return &ReviewRepository{db: db}
+}
+
+func (r *ReviewRepository) CreateQueue(ctx context.Context, queue *storage.ReviewQueue) error {
+	dbQueue, err := toDBReviewQueue(queue)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.NewInsert().Model(dbQueue).Returning("id, populated_through, created_at, updated_at").Exec(ctx)
+	if err != nil {
+		if err.Error() == reviewQueueNameConflict {
+			return storage.ErrAlreadyExists
+		}
+		return err
+	}
+
+	queue.ID = dbQueue.ID
+	queue.PopulatedThrough = dbQueue.PopulatedThrough
+	queue.CreatedAt = dbQueue.CreatedAt
+	queue.UpdatedAt = dbQueue.UpdatedAt
+	return nil
+}
+
+func (r *ReviewRepository) GetQueue(ctx context.Context, projectID, id string) (*storage.ReviewQueue, error) {
+	var dbQueue DBReviewQueue
+	err := r.db.NewSelect().
+		Model(&dbQueue).
+		Where("id = ?", id).
+		Where("project_id = ?", projectID).
+		Scan(ctx)
+
+	if err == sql.ErrNoRows {
+		return nil, storage.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return toReviewQueue(&dbQueue)
+}
+
+func (r *ReviewRepository) ListQueues(ctx context.Context, projectID string) ([]*storage.ReviewQueue, error) {
+	return r.listQueues(ctx, func(q *bun.SelectQuery) *bun.SelectQuery {
+		return q.Where("project_id = ?", projectID).Order("name")
+	})
+}
+
+func (r *ReviewRepository) ListAllQueues(ctx context.Context) ([]*storage.ReviewQueue, error) {
+	return r.listQueues(ctx, func(q *bun.SelectQuery) *bun.SelectQuery {
+		return q.Order("populated_through")
+	})
+}
+
+func (r *ReviewRepository) listQueues(ctx context.Context, apply func(*bun.SelectQuery) *bun.SelectQuery) ([]*storage.ReviewQueue, error) {
+	var dbQueues []DBReviewQueue
+	if err := r.db.NewSelect().Model(&dbQueues).Apply(apply).Scan(ctx); err != nil {
+		return nil, err
+	}
+
+	queues := make([]*storage.ReviewQueue, len(dbQueues))
+	for i := range dbQueues {
+		queue, err := toReviewQueue(&dbQueues[i])
+		if err != nil {
+			return nil, err
+		}
+		queues[i] = queue
+	}
+	return queues, nil
+}
+
+func (r *ReviewRepository) UpdateQueue(ctx context.Context, queue *storage.ReviewQueue) error {
+	dbQueue, err := toDBReviewQueue(queue)
+	if err != nil {
+		return err
+	}
+
+	res, err := r.db.NewUpdate().
+		Model(dbQueue).
+		Column("name", "description", "rules", "rubric").
+		Where("id = ?", queue.ID).
+		Where("project_id = ?", queue.ProjectID).
+		Exec(ctx)
+
+	if err != nil && err.Error() == reviewQueueNameConflict {
+		return storage.ErrAlreadyExists
+	}
+	return checkRowsAffected(res, err)
+}
+
+func (r *ReviewRepository) DeleteQueue(ctx context.Context, projectID, id string) error {
+	res, err := r.db.NewDelete().
+		Model((*DBReviewQueue)(nil)).
+		Where("id = ?", id).
+		Where("project_id = ?", projectID).
+		Exec(ctx)
+
+	return checkRowsAffected(res, err)
+}
+
+func (r *ReviewRepository) Populate(ctx context.Context, queueID string, until time.Time) (int, error) {
+	var added int
+	err := r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		var dbQueue DBReviewQueue
+		err := tx.NewSelect().
+			Model(&dbQueue).
+			Where("id = ?", queueID).
+			For("UPDATE SKIP LOCKED").
+			Scan(ctx)
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !until.After(dbQueue.PopulatedThrough) {
+			return nil
+		}
+
+		queue, err := toReviewQueue(&dbQueue)
+		if err != nil {
+			return err
+		}
+
+		n, err := populateTraces(ctx, tx, queue, until)
+		if err != nil {
+			return err
+		}
+		added += n
+
+		if len(queue.Rules.FailedEvaluators) > 0 {
+			n, err := populateFailedEvaluations(ctx, tx, queue, until)
+			if err != nil {
+				return err
+			}
+			added += n
+		}
+
+		if queue.Rules.FailedTestCases {
+			n, err := populateFailedTestCases(ctx, tx, queue, until)
+			if err != nil {
+				return err
+			}
+			added += n
+		}
+
+		_, err = tx.NewUpdate().
+			Model((*DBReviewQueue)(nil)).
+			Set("populated_through = ?", until).
+			Where("id = ?", queueID).
+			Exec(ctx)
+		return err
+	})
+	return added, err
+}
+
+// populateTraces adds the sampled and tagged traces stored in the queue's
+// population window
+func populateTraces(ctx context.Context, tx bun.Tx, queue *storage.ReviewQueue, until time.Time) (int, error) {
+	threshold := int(queue.Rules.SampleRate * sampleBuckets)
+	if threshold <= 0 && len(queue.Rules.Tags) == 0 {
+		return 0, nil
+	}
+
+	tags := queue.Rules.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	matches := tx.NewSelect().
+		Model((*DBTrace)(nil)).
+		ColumnExpr("?, t.project_id, ?, t.trace_id", queue.ID, storage.ReviewItemTrace).
+		ColumnExpr("CASE WHEN t.tags && ? THEN ? ELSE ? END", pgdialect.Array(tags), storage.ReviewReasonTag, storage.ReviewReasonSample).
+		Where("t.project_id = ?", queue.ProjectID).
+		Where("t.created_at > ?", queue.PopulatedThrough).
+		Where("t.created_at <= ?", until).
+		WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.
+				Where("(hashtext(t.trace_id) & 2147483647) % ? < ?", sampleBuckets, threshold).
+				WhereOr("t.tags && ?", pgdialect.Array(tags))
+		})
+
+	res, err := tx.ExecContext(ctx, "INSERT INTO review_items (queue_id, project_id, kind, trace_id, reason) ? ON CONFLICT DO NOTHING", matches)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+// populateFailedEvaluations adds the traces that failed one of the queue's
+// evaluators in its population window
+func populateFailedEvaluations(ctx context.Context, tx bun.Tx, queue *storage.ReviewQueue, until time.Time) (int, error) {
+	failures := tx.NewSelect().
+		Model((*DBEvaluationResult)(nil)).
+		ColumnExpr("DISTINCT ?, er.project_id, ?, er.trace_id, ?", queue.ID, storage.ReviewItemTrace, storage.ReviewReasonFailedEvaluator).
+		Where("er.project_id = ?", queue.ProjectID).
+		Where("er.evaluator_id IN (?)", bun.In(queue.Rules.FailedEvaluators)).
+		Where("NOT er.passed").
+		Where("er.created_at > ?", queue.PopulatedThrough).
+		Where("er.created_at <= ?", until)
+
+	res, err := tx.ExecContext(ctx, "INSERT INTO review_items (queue_id, project_id, kind, trace_id, reason) ? ON CONFLICT DO NOTHING", failures)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+// populateFailedTestCases adds the failed cases of test runs stored in the
+// queue's population window
+func populateFailedTestCases(ctx context.Context, tx bun.Tx, queue *storage.ReviewQueue, until time.Time) (int, error) {
+	var dbTestRuns []DBTestRun
+	query := tx.NewSelect().
+		Model(&dbTestRuns).
+		Where("project_id = ?", queue.ProjectID).
+		Where("created_at > ?", queue.PopulatedThrough).
+		Where("created_at <= ?", until).
+		Where("deleted_at IS NULL")
+	if queue.Rules.TestRunBranch != "" {
+		query = query.Where("git_branch = ?", queue.Rules.TestRunBranch)
+	}
+	if err := query.Scan(ctx); err != nil {
+		return 0, err
+	}
+
+	var items []*storage.ReviewItem
+	for i := range dbTestRuns {
+		testRun, err := toDomainTestRun(&dbTestRuns[i])
+		if err != nil {
+			return 0, err
+		}
+		for _, result := range testRun.Results {
+			if !caseFailed(result.Runs) {
+				continue
+			}
+			items = append(items, &storage.ReviewItem{
+				QueueID:   queue.ID,
+				ProjectID: queue.ProjectID,
+				Kind:      storage.ReviewItemTestCase,
+				RunID:     testRun.RunID,
+				CaseID:    result.CaseID,
+				Reason:    storage.ReviewReasonFailedTestCase,
+			})
+		}
+	}
+	return addReviewItems(ctx, tx, items)
+}
+
+func (r *ReviewRepository) AddItems(ctx context.Context, items []*storage.ReviewItem) (int, error) {
+	return addReviewItems(ctx, r.db, items)
+}
+
+func addReviewItems(ctx context.Context, db bun.IDB, items []*storage.ReviewItem) (int, error) {
+	if len(items) == 0 {
+		return 0, nil
+	}
+
+	dbItems := make([]*DBReviewItem, len(items))
+	for i, item := range items {
+		dbItems[i] = &DBReviewItem{
+			QueueID:   item.QueueID,
+			ProjectID: item.ProjectID,
+			Kind:      string(item.Kind),
+			TraceID:   item.TraceID,
+			RunID:     item.RunID,
+			CaseID:    item.CaseID,
+			Reason:    string(item.Reason),
+			Status:    string(storage.ReviewPending),
+			Scores:    map[string]float64{},
+		}
+	}
+
+	res, err := db.NewInsert().
+		Model(&dbItems).
+		ExcludeColumn("id", "created_at", "updated_at").
+		On("CONFLICT DO NOTHING").
+		Returning("NULL").
+		Exec(ctx)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+func (r *ReviewRepository) GetItem(ctx context.Context, queueID, id string) (*storage.ReviewItem, error) {
+	var dbItem DBReviewItem
+	err := r.db.NewSelect().
+		Model(&dbItem).
+		Where("id = ?", id).
+		Where("queue_id = ?", queueID).
+		Scan(ctx)
+
+	if err == sql.ErrNoRows {
+		return nil, storage.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return toReviewItem(&dbItem), nil
+}
+
+func (r *ReviewRepository) ListItems(ctx context.Context, queueID string, filter storage.ReviewItemFilter) ([]*storage.ReviewItem, error) {
+	var dbItems []DBReviewItem
+	query := r.db.NewSelect().
+		Model(&dbItems).
+		Where("queue_id = ?", queueID).
+		Order("created_at", "id")
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.AssigneeID != "" {
+		query = query.Where("assignee_id = ?", filter.AssigneeID)
+	}
+	if filter.ReviewerID != "" {
+		query = query.Where("reviewer_id = ?", filter.ReviewerID)
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+	if filter.Offset > 0 {
+		query = query.Offset(filter.Offset)
+	}
+
+	if err := query.Scan(ctx); err != nil {
+		return nil, err
+	}
+
+	items := make([]*storage.ReviewItem, len(dbItems))
+	for i := range dbItems {
+		items[i] = toReviewItem(&dbItems[i])
+	}
+	return items, nil
+}
+
+func (r *ReviewRepository) AssignItem(ctx context.Context, queueID, id, assigneeID string) (*storage.ReviewItem, error) {
+	query := r.db.NewUpdate().Model((*DBReviewItem)(nil))
+	if assigneeID == "" {
+		query = query.Set("assignee_id = NULL")
+	} else {
+		query = query.Set("assignee_id = ?", assigneeID)
+	}
+	return r.updateItem(ctx, query.
+		Where("id = ?", id).
+		Where("queue_id = ?", queueID))
+}
+
+func (r *ReviewRepository) ClaimItem(ctx context.Context, queueID, id, reviewerID string, staleAfter time.Duration) (*storage.ReviewItem, error) {
+	return r.updateItem(ctx, r.db.NewUpdate().
+		Model((*DBReviewItem)(nil)).
+		Apply(claimFor(reviewerID)).
+		Where("id = ?", id).
+		Where("queue_id = ?", queueID).
+		Where(claimableCondition, claimableArgs(reviewerID, staleAfter)...))
+}
+
+func (r *ReviewRepository) ClaimNext(ctx context.Context, queueID, reviewerID string, staleAfter time.Duration) (*storage.ReviewItem, error) {
+	next := r.db.NewSelect().
+		Model((*DBReviewItem)(nil)).
+		Column("id").
+		Where("queue_id = ?", queueID).
+		Where(claimableCondition, claimableArgs(reviewerID, staleAfter)...).
+		OrderExpr("assignee_id IS NULL, created_at").
+		Limit(1).
+		For("UPDATE SKIP LOCKED")
+
+	item, err := r.updateItem(ctx, r.db.NewUpdate().
+		Model((*DBReviewItem)(nil)).
+		Apply(claimFor(reviewerID)).
+		Where("id = (?)", next))
+	if err == storage.ErrNotFound {
+		return nil, nil
+	}
+	return item, err
+}
+
+// claimFor sets the columns that mark an item claimed by a reviewer
+func claimFor(reviewerID string) func(*bun.UpdateQuery) *bun.UpdateQuery {
+	return func(q *bun.UpdateQuery) *bun.UpdateQuery {
+		return q.
+			Set("status = ?", storage.ReviewClaimed).
+			Set("reviewer_id = ?", reviewerID).
+			Set("claimed_at = now()")
+	}
+}
+
+// claimableCondition matches items a reviewer can claim: pending items, or
+// items whose claim has gone stale, that are unassigned or assigned to the
+// reviewer. Its arguments come from claimableArgs.
+const claimableCondition = "(status = ? OR (status = ? AND claimed_at < ?)) AND (assignee_id IS NULL OR assignee_id = ?)"
+
+func claimableArgs(reviewerID string, staleAfter time.Duration) []any {
+	return []any{storage.ReviewPending, storage.ReviewClaimed, time.Now().Add(-staleAfter), reviewerID}
+}
+
+func (r *ReviewRepository) ReleaseItem(ctx context.Context, queueID, id, reviewerID string) (*storage.ReviewItem, error) {
+	return r.updateItem(ctx, r.db.NewUpdate().
+		Model((*DBReviewItem)(nil)).
+		Set("status = ?", storage.ReviewPending).
+		Set("reviewer_id = NULL").
+		Set("claimed_at = NULL").
+		Where("id = ?", id).
+		Where("queue_id = ?", queueID).
+		Where("status = ?", storage.ReviewClaimed).
+		Where("reviewer_id = ?", reviewerID))
+}
+
+func (r *ReviewRepository) CompleteItem(ctx context.Context, queueID, id, reviewerID string, scores map[string]float64, comment string) (*storage.ReviewItem, error) {
+	if scores == nil {
+		scores = map[string]float64{}
+	}
+
+	return r.updateItem(ctx, r.db.NewUpdate().
+		Model((*DBReviewItem)(nil)).
+		Set("status = ?", storage.ReviewCompleted).
+		Set("scores = ?", scores).
+		Set("comment = NULLIF(?, '')", comment).
+		Set("completed_at = now()").
+		Where("id = ?", id).
+		Where("queue_id = ?", queueID).
+		Where("status = ?", storage.ReviewClaimed).
+		Where("reviewer_id = ?", reviewerID))
+}
+
+// updateItem runs an update on a single item and returns the updated item,
+// or ErrNotFound if no item matched
+func (r *ReviewRepository) updateItem(ctx context.Context, query *bun.UpdateQuery) (*storage.ReviewItem, error) {
+	var dbItems []DBReviewItem
+	if _, err := query.Returning("*").Exec(ctx, &dbItems); err != nil {
+		return nil, err
+	}
+	if len(dbItems) == 0 {
+		return nil, storage.ErrNotFound
+	}
+	return toReviewItem(&dbItems[0]), nil
+}
+
+func (r *ReviewRepository) Progress(ctx context.Context, queueID string) (*storage.ReviewProgress, error) {
+	progress := &storage.ReviewProgress{
+		Reviewers: []storage.ReviewerProgress{},
+		Scores:    []storage.CriterionScore{},
+	}
+	err := r.db.NewSelect().
+		Model((*DBReviewItem)(nil)).
+		ColumnExpr("count(*) AS total").
+		ColumnExpr("count(*) FILTER (WHERE ri.status = ?) AS pending", storage.ReviewPending).
+		ColumnExpr("count(*) FILTER (WHERE ri.status = ?) AS claimed", storage.ReviewClaimed).
+		ColumnExpr("count(*) FILTER (WHERE ri.status = ?) AS completed", storage.ReviewCompleted).
+		Where("ri.queue_id = ?", queueID).
+		Scan(ctx, &progress.Total, &progress.Pending, &progress.Claimed, &progress.Completed)
+	if err != nil {
+		return nil, err
+	}
+
+	assigned := r.db.NewSelect().
+		Model((*DBReviewItem)(nil)).
+		ColumnExpr("ri.assignee_id AS user_id, 1 AS assigned, 0 AS claimed, 0 AS completed").
+		Where("ri.queue_id = ?", queueID).
+		Where("ri.assignee_id IS NOT NULL").
+		Where("ri.status <> ?", storage.ReviewCompleted)
+	reviewed := r.db.NewSelect().
+		Model((*DBReviewItem)(nil)).
+		ColumnExpr("ri.reviewer_id AS user_id, 0 AS assigned").
+		ColumnExpr("(ri.status = ?)::int AS claimed", storage.ReviewClaimed).
+		ColumnExpr("(ri.status = ?)::int AS completed", storage.ReviewCompleted).
+		Where("ri.queue_id = ?", queueID).
+		Where("ri.reviewer_id IS NOT NULL")
+	err = r.db.NewSelect().
+		TableExpr("(?) AS work", assigned.UnionAll(reviewed)).
+		ColumnExpr("work.user_id").
+		ColumnExpr("sum(work.assigned) AS assigned").
+		ColumnExpr("sum(work.claimed) AS claimed").
+		ColumnExpr("sum(work.completed) AS completed").
+		Group("work.user_id").
+		Order("work.user_id").
+		Scan(ctx, &progress.Reviewers)
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.db.NewSelect().
+		Model((*DBReviewItem)(nil)).
+		TableExpr("jsonb_each_text(ri.scores) AS s").
+		ColumnExpr("s.key AS criterion").
+		ColumnExpr("count(*) AS count").
+		ColumnExpr("avg(s.value::float8) AS mean").
+		ColumnExpr("min(s.value::float8) AS min").
+		ColumnExpr("max(s.value::float8) AS max").
+		Where("ri.queue_id = ?", queueID).
+		Where("ri.status = ?", storage.ReviewCompleted).
+		Group("s.key").
+		Order("s.key").
+		Scan(ctx, &progress.Scores)
+	if err != nil {
+		return nil, err
+	}
+
+	return progress, nil
+}
+
+// caseFailed reports whether any run of a test case failed
+func caseFailed(runs []domain.RunResult) bool {
+	for _, run := range runs {
+		if !run.Pass {
+			return true
+		}
+	}
+	return false
+}
+
+func toDBReviewQueue(queue *storage.ReviewQueue) (*DBReviewQueue, error) {
+	rules, err := json.Marshal(queue.Rules)
+	if err != nil {
+		return nil, err
+	}
+	rubric := queue.Rubric
+	if rubric == nil {
+		rubric = []storage.RubricCriterion{}
+	}
+	rubricData, err := json.Marshal(rubric)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DBReviewQueue{
+		ID:          queue.ID,
+		ProjectID:   queue.ProjectID,
+		Name:        queue.Name,
+		Description: queue.Description,
+		Rules:       rules,
+		Rubric:      rubricData,
+		CreatedBy:   queue.CreatedBy,
+	}, nil
+}
+
+func toReviewQueue(dbQueue *DBReviewQueue) (*storage.ReviewQueue, error) {
+	queue := &storage.ReviewQueue{
+		ID:               dbQueue.ID,
+		ProjectID:        dbQueue.ProjectID,
+		Name:             dbQueue.Name,
+		Description:      dbQueue.Description,
+		CreatedBy:        dbQueue.CreatedBy,
+		PopulatedThrough: dbQueue.PopulatedThrough,
+		CreatedAt:        dbQueue.CreatedAt,
+		UpdatedAt:        dbQueue.UpdatedAt,
+	}
+
+	if err := decodeJSONField(dbQueue.Rules, &queue.Rules); err != nil {
+		return nil, err
+	}
+	if err := decodeJSONField(dbQueue.Rubric, &queue.Rubric); err != nil {
+		return nil, err
+	}
+	return queue, nil
+}
+
+func toReviewItem(dbItem *DBReviewItem) *storage.ReviewItem {
+	return &storage.ReviewItem{
+		ID:          dbItem.ID,
+		QueueID:     dbItem.QueueID,
+		ProjectID:   dbItem.ProjectID,
+		Kind:        storage.ReviewItemKind(dbItem.Kind),
+		TraceID:     dbItem.TraceID,
+		RunID:       dbItem.RunID,
+		CaseID:      dbItem.CaseID,
+		Reason:      storage.ReviewReason(dbItem.Reason),
+		Status:      storage.ReviewStatus(dbItem.Status),
+		AssigneeID:  dbItem.AssigneeID,
+		ReviewerID:  dbItem.ReviewerID,
+		ClaimedAt:   dbItem.ClaimedAt,
+		Scores:      dbItem.Scores,
+		Comment:     dbItem.Comment,
+		CompletedAt: dbItem.CompletedAt,
+		CreatedAt:   dbItem.CreatedAt,
+		UpdatedAt:   dbItem.UpdatedAt,
+	}
+}
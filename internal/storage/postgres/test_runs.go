@@ -4,6 +4,7 @@ package postgres
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
 	"time"
 
@@ -20,15 +21,15 @@ func NewTestRunRepository(db *bun.DB) *TestRunRepository {
 	return &TestRunRepository{db: db}
 }
 
-func (r *TestRunRepository) Create(ctx context.Context, projectID string, testRun *domain.TestRun) error {
+func (r *TestRunRepository) Create(ctx context.Context, projectID string, testRun *domain.TestRun, onConflict storage.ConflictMode) (storage.IngestStatus, error) {
 	resultsData, err := json.Marshal(testRun.Results)
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	violationsData, err := json.Marshal(testRun.Violations)
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	dbTestRun := &DBTestRun{
@@ -53,26 +54,40 @@ func (r *TestRunRepository) Create(ctx context.Context, projectID string, testRu
 		dbTestRun.Status = "completed"
 	}
 
-	_, err = r.db.NewInsert().Model(dbTestRun).Exec(ctx)
-	return err
-}
+	query := r.db.NewInsert().Model(dbTestRun)
+	if onConflict == storage.ConflictReplace {
+		query = query.On("CONFLICT (project_id, run_id) DO UPDATE").
+			Set("timestamp = EXCLUDED.timestamp").
+			Set("git_sha = EXCLUDED.git_sha").
+			Set("git_branch = EXCLUDED.git_branch").
+			Set("git_commit_message = EXCLUDED.git_commit_message").
+			Set("ci_provider = EXCLUDED.ci_provider").
+			Set("ci_pr_number = EXCLUDED.ci_pr_number").
+			Set("total_cases = EXCLUDED.total_cases").
+			Set("passed_cases = EXCLUDED.passed_cases").
+			Set("warned_cases = EXCLUDED.warned_cases").
+			Set("failed_cases = EXCLUDED.failed_cases").
+			Set("results = EXCLUDED.results").
+			Set("violations = EXCLUDED.violations").
+			Set("status = EXCLUDED.status").
+			Set("deleted_at = NULL")
+	} else {
+		query = query.On("CONFLICT (project_id, run_id) DO NOTHING")
+	}
 
-func (r *TestRunRepository) Get(ctx context.Context, projectID, runID string) (*domain.TestRun, error) {
-	var dbTestRun DBTestRun
-	err := r.db.NewSelect().
-		Model(&dbTestRun).
-		Where("project_id = ?", projectID).
-		Where("run_id = ?", runID).
-		Where("deleted_at IS NULL").
-		Scan(ctx)
+	var inserted []bool
+	if err := query.Returning("(xmax = 0) AS inserted").Scan(ctx, &inserted); err != nil {
+		return "", err
+	}
 
-	if err != nil {
-		if err.Error() == "sql: no rows in result set" {
-			return nil, storage.ErrNotFound
-		}
-		return nil, err
+	if len(inserted) == 1 && inserted[0] {
+		return storage.IngestCreated, nil
 	}
+	return storage.IngestDuplicate, nil
+}
 
+// toDomainTestRun converts a stored test run back to its domain form
+func toDomainTestRun(dbTestRun *DBTestRun) (*domain.TestRun, error) {
 	testRun := &domain.TestRun{
 		RunID:            dbTestRun.RunID,
 		Timestamp:        dbTestRun.Timestamp,
@@ -103,6 +118,25 @@ func (r *TestRunRepository) Get(ctx context.Context, projectID, runID string) (*
 	return testRun, nil
 }
 
+func (r *TestRunRepository) Get(ctx context.Context, projectID, runID string) (*domain.TestRun, error) {
+	var dbTestRun DBTestRun
+	err := r.db.NewSelect().
+		Model(&dbTestRun).
+		Where("project_id = ?", projectID).
+		Where("run_id = ?", runID).
+		Where("deleted_at IS NULL").
+		Scan(ctx)
+
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			return nil, storage.ErrNotFound
+		}
+		return nil, err
+	}
+
+	return toDomainTestRun(&dbTestRun)
+}
+
 func (r *TestRunRepository) List(ctx context.Context, projectID string, limit, offset int) ([]*domain.TestRun, error) {
 	var dbTestRuns []DBTestRun
 	err := r.db.NewSelect().
@@ -119,38 +153,140 @@ func (r *TestRunRepository) List(ctx context.Context, projectID string, limit, o
 	}
 
 	testRuns := make([]*domain.TestRun, len(dbTestRuns))
-	for i, dbTestRun := range dbTestRuns {
-		testRun := &domain.TestRun{
-			RunID:            dbTestRun.RunID,
-			Timestamp:        dbTestRun.Timestamp,
-			GitSHA:           dbTestRun.GitSHA,
-			GitBranch:        dbTestRun.GitBranch,
-			GitCommitMessage: dbTestRun.GitCommitMessage,
-			CIProvider:       dbTestRun.CIProvider,
-			CIPRNumber:       dbTestRun.CIPRNumber,
-			TotalCases:       dbTestRun.TotalCases,
-			PassedCases:      dbTestRun.PassedCases,
-			WarnedCases:      dbTestRun.WarnedCases,
-			FailedCases:      dbTestRun.FailedCases,
-			Status:           dbTestRun.Status,
+	for i := range dbTestRuns {
+		testRun, err := toDomainTestRun(&dbTestRuns[i])
+		if err != nil {
+			return nil, err
 		}
+		testRuns[i] = testRun
+	}
 
-		if len(dbTestRun.Results) == 0 {
-			testRun.Results = []domain.CaseResult{}
-		} else if err := decodeJSONField(dbTestRun.Results, &testRun.Results); err != nil {
-			return nil, err
+	return testRuns, nil
+}
+
+func (r *TestRunRepository) Each(ctx context.Context, projectID string, filter storage.TestRunFilter, batchSize int, fn func([]*domain.TestRun) error) error {
+	var last *DBTestRun
+	for {
+		var dbTestRuns []DBTestRun
+		query := r.db.NewSelect().
+			Model(&dbTestRuns).
+			Where("project_id = ?", projectID).
+			Where("deleted_at IS NULL").
+			Order("timestamp", "id").
+			Limit(batchSize)
+		if filter.From != nil {
+			query = query.Where("timestamp >= ?", *filter.From)
+		}
+		if filter.To != nil {
+			query = query.Where("timestamp < ?", *filter.To)
+		}
+		if filter.GitSHA != "" {
+			query = query.Where("git_sha = ?", filter.GitSHA)
+		}
+		if filter.GitBranch != "" {
+			query = query.Where("git_branch = ?", filter.GitBranch)
+		}
+		if filter.Status != "" {
+			query = query.Where("status = ?", filter.Status)
+		}
+		if last != nil {
+			query = query.Where("(timestamp, id) > (?, ?)", last.Timestamp, last.ID)
 		}
 
-		if len(dbTestRun.Violations) == 0 {
-			testRun.Violations = []domain.Violation{}
-		} else if err := decodeJSONField(dbTestRun.Violations, &testRun.Violations); err != nil {
-			return nil, err
+		if err := query.Scan(ctx); err != nil {
+			return err
+		}
+		if len(dbTestRuns) == 0 {
+			return nil
 		}
 
-		testRuns[i] = testRun
+		testRuns := make([]*domain.TestRun, len(dbTestRuns))
+		for i := range dbTestRuns {
+			testRun, err := toDomainTestRun(&dbTestRuns[i])
+			if err != nil {
+				return err
+			}
+			testRuns[i] = testRun
+		}
+		if err := fn(testRuns); err != nil {
+			return err
+		}
+
+		if len(dbTestRuns) < batchSize {
+			return nil
+		}
+		last = &dbTestRuns[len(dbTestRuns)-1]
 	}
+}
 
-	return testRuns, nil
+func (r *TestRunRepository) Latest(ctx context.Context, projectID, branch string, before time.Time, excludeRunID string) (*domain.TestRun, error) {
+	var dbTestRun DBTestRun
+	err := r.db.NewSelect().
+		Model(&dbTestRun).
+		Where("project_id = ?", projectID).
+		Where("git_branch = ?", branch).
+		Where("status = 'completed'").
+		Where("timestamp < ?", before).
+		Where("run_id <> ?", excludeRunID).
+		Where("deleted_at IS NULL").
+		Order("timestamp DESC").
+		Limit(1).
+		Scan(ctx)
+
+	if err == sql.ErrNoRows {
+		return nil, storage.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return toDomainTestRun(&dbTestRun)
+}
+
+func (r *TestRunRepository) CaseHistory(ctx context.Context, projectID, branch string, runs int) ([]storage.CaseObservation, error) {
+	recent := r.db.NewSelect().
+		Model((*DBTestRun)(nil)).
+		Column("run_id", "git_sha", "timestamp", "results").
+		Where("project_id = ?", projectID).
+		Where("status IN ('completed', 'failed')").
+		Where("deleted_at IS NULL").
+		Order("timestamp DESC").
+		Limit(runs)
+	if branch != "" {
+		recent = recent.Where("git_branch = ?", branch)
+	}
+
+	var rows []struct {
+		RunID     string    `bun:"run_id"`
+		GitSHA    string    `bun:"git_sha"`
+		Timestamp time.Time `bun:"timestamp"`
+		CaseID    string    `bun:"case_id"`
+		Provider  string    `bun:"provider"`
+		Model     string    `bun:"model"`
+		Runs      int       `bun:"runs"`
+		PassRate  float64   `bun:"pass_rate"`
+	}
+	err := r.db.NewSelect().
+		TableExpr("(?) AS tr", recent).
+		ColumnExpr("tr.run_id, tr.git_sha, tr.timestamp").
+		ColumnExpr("c.value->>'case_id' AS case_id").
+		ColumnExpr("COALESCE(c.value->>'provider', '') AS provider").
+		ColumnExpr("COALESCE(c.value->>'model', '') AS model").
+		ColumnExpr("CASE WHEN jsonb_typeof(c.value->'runs') = 'array' THEN jsonb_array_length(c.value->'runs') ELSE 0 END AS runs").
+		ColumnExpr("COALESCE((c.value->'aggregates'->>'pass_rate')::float8, 0) AS pass_rate").
+		Join("CROSS JOIN LATERAL jsonb_array_elements(CASE WHEN jsonb_typeof(tr.results) = 'array' THEN tr.results ELSE '[]' END) AS c").
+		Where("c.value->>'case_id' IS NOT NULL").
+		OrderExpr("tr.timestamp, tr.run_id").
+		Scan(ctx, &rows)
+	if err != nil {
+		return nil, err
+	}
+
+	observations := make([]storage.CaseObservation, len(rows))
+	for i, row := range rows {
+		observations[i] = storage.CaseObservation(row)
+	}
+	return observations, nil
 }
 
 func (r *TestRunRepository) Delete(ctx context.Context, projectID, runID string) error {
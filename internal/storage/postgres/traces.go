@@ -3,13 +3,21 @@
 package postgres
 
 import (
+	"bytes"
 	"context"
+	"encoding/csv"
 	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/regrada-ai/regrada-be/internal/domain"
 	"github.com/regrada-ai/regrada-be/internal/storage"
 	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/pgdialect"
+	"github.com/uptrace/bun/driver/pgdriver"
 )
 
 type TraceRepository struct {
@@ -20,95 +28,318 @@ func NewTraceRepository(db *bun.DB) *TraceRepository {
 	return &TraceRepository{db: db}
 }
 
-func (r *TraceRepository) Create(ctx context.Context, projectID string, trace *domain.Trace) error {
-	requestData, err := json.Marshal(trace.Request)
+func (r *TraceRepository) Create(ctx context.Context, projectID string, trace *domain.Trace, onConflict storage.ConflictMode) (storage.IngestStatus, error) {
+	statuses, err := r.CreateBatch(ctx, projectID, []domain.Trace{*trace}, onConflict)
 	if err != nil {
-		return err
+		return "", err
 	}
+	return statuses[0], nil
+}
 
-	responseData, err := json.Marshal(trace.Response)
+func (r *TraceRepository) CreateBatch(ctx context.Context, projectID string, traces []domain.Trace, onConflict storage.ConflictMode) ([]storage.IngestStatus, error) {
+	if len(traces) == 0 {
+		return nil, nil
+	}
+
+	dbTraces := make([]*DBTrace, len(traces))
+	for i := range traces {
+		dbTrace, err := toDBTrace(projectID, &traces[i])
+		if err != nil {
+			return nil, err
+		}
+		dbTraces[i] = dbTrace
+	}
+
+	var inserted []struct {
+		TraceID  string `bun:"trace_id"`
+		Inserted bool   `bun:"inserted"`
+	}
+	err := r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if err := claimTraceKeys(ctx, tx, projectID, dbTraces); err != nil {
+			return err
+		}
+		return tx.NewInsert().
+			Model(&dbTraces).
+			On(traceConflictClause(onConflict)).
+			Returning("trace_id, (xmax = 0) AS inserted").
+			Scan(ctx, &inserted)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	created := make(map[string]bool, len(inserted))
+	for _, row := range inserted {
+		created[row.TraceID] = row.Inserted
+	}
+
+	statuses := make([]storage.IngestStatus, len(traces))
+	for i, trace := range traces {
+		if created[trace.TraceID] {
+			statuses[i] = storage.IngestCreated
+		} else {
+			statuses[i] = storage.IngestDuplicate
+		}
+	}
+
+	return statuses, nil
+}
+
+// claimTraceKeys records the trace IDs of a project in trace_keys and moves
+// traces whose ID is already there to the timestamp it was stored under, so
+// the conflict clause matches the stored trace. Keys are claimed in trace ID
+// order to keep concurrent batches from deadlocking.
+func claimTraceKeys(ctx context.Context, tx bun.Tx, projectID string, dbTraces []*DBTrace) error {
+	keys := make([]DBTraceKey, len(dbTraces))
+	for i, dbTrace := range dbTraces {
+		keys[i] = DBTraceKey{ProjectID: projectID, TraceID: dbTrace.TraceID, Timestamp: dbTrace.Timestamp}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].TraceID < keys[j].TraceID })
+
+	// The no-op update locks existing keys and returns their timestamp
+	var claimed []struct {
+		TraceID   string    `bun:"trace_id"`
+		Timestamp time.Time `bun:"timestamp"`
+	}
+	err := tx.NewInsert().
+		Model(&keys).
+		On("CONFLICT (project_id, trace_id) DO UPDATE").
+		Set("timestamp = k.timestamp").
+		Returning("trace_id, timestamp").
+		Scan(ctx, &claimed)
 	if err != nil {
 		return err
 	}
 
-	dbTrace := &DBTrace{
-		ProjectID:        projectID,
-		TraceID:          trace.TraceID,
-		Timestamp:        trace.Timestamp,
-		Provider:         trace.Provider,
-		Model:            trace.Model,
-		Environment:      trace.Environment,
-		GitSHA:           trace.GitSHA,
-		GitBranch:        trace.GitBranch,
-		RequestData:      requestData,
-		ResponseData:     responseData,
-		LatencyMS:        trace.Metrics.LatencyMS,
-		TokensIn:         trace.Metrics.TokensIn,
-		TokensOut:        trace.Metrics.TokensOut,
-		RedactionApplied: trace.RedactionApplied,
-		Tags:             trace.Tags,
+	timestamps := make(map[string]time.Time, len(claimed))
+	for _, key := range claimed {
+		timestamps[key.TraceID] = key.Timestamp
+	}
+	for _, dbTrace := range dbTraces {
+		if timestamp, ok := timestamps[dbTrace.TraceID]; ok {
+			dbTrace.Timestamp = timestamp
+		}
 	}
+	return nil
+}
 
-	_, err = r.db.NewInsert().Model(dbTrace).Exec(ctx)
-	return err
+// traceColumns are the columns written on ingestion, in COPY order
+var traceColumns = []string{
+	"project_id",
+	"trace_id",
+	"timestamp",
+	"provider",
+	"model",
+	"environment",
+	"git_sha",
+	"git_branch",
+	"request_data",
+	"response_data",
+	"latency_ms",
+	"tokens_in",
+	"tokens_out",
+	"redaction_applied",
+	"tags",
+	"prompt_name",
+	"prompt_version",
 }
 
-func (r *TraceRepository) CreateBatch(ctx context.Context, projectID string, traces []domain.Trace) error {
+// traceConflictTarget is the unique key ingestion deduplicates on. traces is
+// partitioned by timestamp, so the key must include it; trace IDs are kept
+// unique by claiming them in trace_keys first, which moves a resent trace to
+// the timestamp it was stored under.
+const traceConflictTarget = "(project_id, trace_id, timestamp)"
+
+// traceConflictClause returns the ON CONFLICT clause for an ingestion mode
+func traceConflictClause(onConflict storage.ConflictMode) string {
+	switch onConflict {
+	case storage.ConflictReplace:
+		sets := make([]string, 0, len(traceColumns))
+		for _, column := range traceColumns[3:] {
+			sets = append(sets, fmt.Sprintf("%s = EXCLUDED.%s", column, column))
+		}
+		sets = append(sets, "deleted_at = NULL", "evaluated_at = NULL")
+		return "CONFLICT " + traceConflictTarget + " DO UPDATE SET " + strings.Join(sets, ", ")
+	case storage.ConflictMergeTags:
+		// Append new tags after existing ones, keeping first-seen order
+		return "CONFLICT " + traceConflictTarget + " DO UPDATE SET tags = ARRAY(SELECT tag FROM unnest(COALESCE(t.tags, '{}') || COALESCE(EXCLUDED.tags, '{}')) WITH ORDINALITY AS u(tag, n) GROUP BY tag ORDER BY min(n))"
+	default:
+		return "CONFLICT " + traceConflictTarget + " DO NOTHING"
+	}
+}
+
+// CopyBatch streams traces into a temporary table with COPY and then moves
+// them into traces in a single INSERT, so conflicts are handled the same way
+// as CreateBatch while avoiding per-row round trips.
+func (r *TraceRepository) CopyBatch(ctx context.Context, traces []storage.ProjectTrace, onConflict storage.ConflictMode) (int64, error) {
 	if len(traces) == 0 {
-		return nil
+		return 0, nil
 	}
 
-	dbTraces := make([]*DBTrace, len(traces))
-	for i, trace := range traces {
-		requestData, err := json.Marshal(trace.Request)
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	for i := range traces {
+		dbTrace, err := toDBTrace(traces[i].ProjectID, &traces[i].Trace)
 		if err != nil {
-			return err
+			return 0, err
 		}
-
-		responseData, err := json.Marshal(trace.Response)
-		if err != nil {
-			return err
+		if err := w.Write(append(copyRecord(dbTrace), traces[i].RestoreID)); err != nil {
+			return 0, err
 		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return 0, err
+	}
 
-		dbTraces[i] = &DBTrace{
-			ProjectID:        projectID,
-			TraceID:          trace.TraceID,
-			Timestamp:        trace.Timestamp,
-			Provider:         trace.Provider,
-			Model:            trace.Model,
-			Environment:      trace.Environment,
-			GitSHA:           trace.GitSHA,
-			GitBranch:        trace.GitBranch,
-			RequestData:      requestData,
-			ResponseData:     responseData,
-			LatencyMS:        trace.Metrics.LatencyMS,
-			TokensIn:         trace.Metrics.TokensIn,
-			TokensOut:        trace.Metrics.TokensOut,
-			RedactionApplied: trace.RedactionApplied,
-			Tags:             trace.Tags,
-		}
+	// COPY needs the raw driver connection, so the transaction is started on it
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return 0, err
 	}
+	defer conn.Close()
 
-	_, err := r.db.NewInsert().Model(&dbTraces).Exec(ctx)
-	return err
-}
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
 
-func (r *TraceRepository) Get(ctx context.Context, projectID, traceID string) (*domain.Trace, error) {
-	var dbTrace DBTrace
-	err := r.db.NewSelect().
-		Model(&dbTrace).
-		Where("project_id = ?", projectID).
-		Where("trace_id = ?", traceID).
-		Where("deleted_at IS NULL").
-		Scan(ctx)
+	if _, err := tx.ExecContext(ctx, "CREATE TEMP TABLE ingest_traces (LIKE traces INCLUDING DEFAULTS) ON COMMIT DROP"); err != nil {
+		return 0, err
+	}
+
+	// restore_id is written on insert only, so replacing a trace keeps its tag
+	columns := strings.Join(traceColumns, ", ") + ", restore_id"
+	if _, err := pgdriver.CopyFrom(ctx, conn, &buf, "COPY ingest_traces ("+columns+") FROM STDIN WITH (FORMAT csv)"); err != nil {
+		return 0, err
+	}
 
+	// Claim trace keys as claimTraceKeys does. Stored keys never change
+	// timestamp, so they are read back rather than locked.
+	_, err = tx.ExecContext(ctx, `INSERT INTO trace_keys (project_id, trace_id, timestamp)
+		SELECT project_id, trace_id, timestamp FROM ingest_traces
+		ORDER BY project_id, trace_id
+		ON CONFLICT (project_id, trace_id) DO NOTHING`)
 	if err != nil {
-		if err.Error() == "sql: no rows in result set" {
-			return nil, storage.ErrNotFound
+		return 0, err
+	}
+	_, err = tx.ExecContext(ctx, `UPDATE ingest_traces AS i SET timestamp = k.timestamp
+		FROM trace_keys AS k
+		WHERE k.project_id = i.project_id AND k.trace_id = i.trace_id AND k.timestamp <> i.timestamp`)
+	if err != nil {
+		return 0, err
+	}
+
+	// Restored traces were evaluated before they were archived
+	res, err := tx.ExecContext(ctx, "INSERT INTO traces AS t ("+columns+", evaluated_at) SELECT "+columns+", CASE WHEN restore_id IS NULL THEN NULL ELSE now() END FROM ingest_traces ON "+traceConflictClause(onConflict))
+	if err != nil {
+		return 0, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return affected, nil
+}
+
+// copyRecord renders a trace as a CSV record matching traceColumns. Empty
+// unquoted fields are read by COPY as NULL.
+func copyRecord(dbTrace *DBTrace) []string {
+	return []string{
+		dbTrace.ProjectID,
+		dbTrace.TraceID,
+		dbTrace.Timestamp.UTC().Format(time.RFC3339Nano),
+		dbTrace.Provider,
+		dbTrace.Model,
+		dbTrace.Environment,
+		dbTrace.GitSHA,
+		dbTrace.GitBranch,
+		string(dbTrace.RequestData),
+		string(dbTrace.ResponseData),
+		strconv.Itoa(dbTrace.LatencyMS),
+		strconv.Itoa(dbTrace.TokensIn),
+		strconv.Itoa(dbTrace.TokensOut),
+		pgTextArray(dbTrace.RedactionApplied),
+		pgTextArray(dbTrace.Tags),
+		dbTrace.PromptName,
+		optionalInt(dbTrace.PromptVersion),
+	}
+}
+
+// optionalInt formats an integer column stored as NULL when zero
+func optionalInt(n int) string {
+	if n == 0 {
+		return ""
+	}
+	return strconv.Itoa(n)
+}
+
+// pgTextArray formats a string slice as a Postgres array literal
+func pgTextArray(values []string) string {
+	if values == nil {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, v := range values {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteByte('"')
+		for _, c := range v {
+			if c == '"' || c == '\\' {
+				b.WriteByte('\\')
+			}
+			b.WriteRune(c)
 		}
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+func toDBTrace(projectID string, trace *domain.Trace) (*DBTrace, error) {
+	requestData, err := json.Marshal(trace.Request)
+	if err != nil {
 		return nil, err
 	}
 
+	responseData, err := json.Marshal(trace.Response)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DBTrace{
+		ProjectID:        projectID,
+		TraceID:          trace.TraceID,
+		Timestamp:        trace.Timestamp,
+		Provider:         trace.Provider,
+		Model:            trace.Model,
+		Environment:      trace.Environment,
+		GitSHA:           trace.GitSHA,
+		GitBranch:        trace.GitBranch,
+		PromptName:       trace.PromptName,
+		PromptVersion:    trace.PromptVersion,
+		RequestData:      requestData,
+		ResponseData:     responseData,
+		LatencyMS:        trace.Metrics.LatencyMS,
+		TokensIn:         trace.Metrics.TokensIn,
+		TokensOut:        trace.Metrics.TokensOut,
+		RedactionApplied: trace.RedactionApplied,
+		Tags:             trace.Tags,
+	}, nil
+}
+
+// toDomainTrace converts a stored trace back to its domain form
+func toDomainTrace(dbTrace *DBTrace) (*domain.Trace, error) {
 	trace := &domain.Trace{
 		TraceID:          dbTrace.TraceID,
 		Timestamp:        dbTrace.Timestamp,
@@ -117,6 +348,8 @@ func (r *TraceRepository) Get(ctx context.Context, projectID, traceID string) (*
 		Environment:      dbTrace.Environment,
 		GitSHA:           dbTrace.GitSHA,
 		GitBranch:        dbTrace.GitBranch,
+		PromptName:       dbTrace.PromptName,
+		PromptVersion:    dbTrace.PromptVersion,
 		RedactionApplied: dbTrace.RedactionApplied,
 		Tags:             dbTrace.Tags,
 		Metrics: domain.TraceMetrics{
@@ -137,12 +370,34 @@ func (r *TraceRepository) Get(ctx context.Context, projectID, traceID string) (*
 	return trace, nil
 }
 
-func (r *TraceRepository) List(ctx context.Context, projectID string, limit, offset int) ([]*domain.Trace, error) {
+func (r *TraceRepository) Get(ctx context.Context, projectID, traceID string) (*domain.Trace, error) {
+	var dbTrace DBTrace
+	err := r.db.NewSelect().
+		Model(&dbTrace).
+		Where("project_id = ?", projectID).
+		Where("trace_id = ?", traceID).
+		Where("deleted_at IS NULL").
+		Order("timestamp DESC"). // the latest if the ID was stored with several timestamps before trace_keys
+		Limit(1).
+		Scan(ctx)
+
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			return nil, storage.ErrNotFound
+		}
+		return nil, err
+	}
+
+	return toDomainTrace(&dbTrace)
+}
+
+func (r *TraceRepository) List(ctx context.Context, projectID string, filter storage.TraceFilter, limit, offset int) ([]*domain.Trace, error) {
 	var dbTraces []DBTrace
 	err := r.db.NewSelect().
 		Model(&dbTraces).
 		Where("project_id = ?", projectID).
 		Where("deleted_at IS NULL").
+		Apply(traceFilter(filter)).
 		Order("timestamp DESC").
 		Limit(limit).
 		Offset(offset).
@@ -153,36 +408,94 @@ func (r *TraceRepository) List(ctx context.Context, projectID string, limit, off
 	}
 
 	traces := make([]*domain.Trace, len(dbTraces))
-	for i, dbTrace := range dbTraces {
-		trace := &domain.Trace{
-			TraceID:          dbTrace.TraceID,
-			Timestamp:        dbTrace.Timestamp,
-			Provider:         dbTrace.Provider,
-			Model:            dbTrace.Model,
-			Environment:      dbTrace.Environment,
-			GitSHA:           dbTrace.GitSHA,
-			GitBranch:        dbTrace.GitBranch,
-			RedactionApplied: dbTrace.RedactionApplied,
-			Tags:             dbTrace.Tags,
-			Metrics: domain.TraceMetrics{
-				LatencyMS: dbTrace.LatencyMS,
-				TokensIn:  dbTrace.TokensIn,
-				TokensOut: dbTrace.TokensOut,
-			},
-		}
-
-		if err := decodeJSONField(dbTrace.RequestData, &trace.Request); err != nil {
+	for i := range dbTraces {
+		trace, err := toDomainTrace(&dbTraces[i])
+		if err != nil {
 			return nil, err
 		}
+		traces[i] = trace
+	}
 
-		if err := decodeJSONField(dbTrace.ResponseData, &trace.Response); err != nil {
-			return nil, err
+	return traces, nil
+}
+
+func (r *TraceRepository) Each(ctx context.Context, projectID string, filter storage.TraceFilter, batchSize int, fn func([]*domain.Trace) error) error {
+	var last *DBTrace
+	for {
+		var dbTraces []DBTrace
+		query := r.db.NewSelect().
+			Model(&dbTraces).
+			Where("project_id = ?", projectID).
+			Where("deleted_at IS NULL").
+			Apply(traceFilter(filter)).
+			Order("timestamp", "id").
+			Limit(batchSize)
+		if last != nil {
+			query = query.Where("(timestamp, id) > (?, ?)", last.Timestamp, last.ID)
 		}
 
-		traces[i] = trace
+		if err := query.Scan(ctx); err != nil {
+			return err
+		}
+		if len(dbTraces) == 0 {
+			return nil
+		}
+
+		traces := make([]*domain.Trace, len(dbTraces))
+		for i := range dbTraces {
+			trace, err := toDomainTrace(&dbTraces[i])
+			if err != nil {
+				return err
+			}
+			traces[i] = trace
+		}
+		if err := fn(traces); err != nil {
+			return err
+		}
+
+		if len(dbTraces) < batchSize {
+			return nil
+		}
+		last = &dbTraces[len(dbTraces)-1]
 	}
+}
 
-	return traces, nil
+// traceFilter applies a TraceFilter to a query on traces
+func traceFilter(filter storage.TraceFilter) func(*bun.SelectQuery) *bun.SelectQuery {
+	return func(q *bun.SelectQuery) *bun.SelectQuery {
+		if filter.From != nil {
+			q = q.Where("timestamp >= ?", *filter.From)
+		}
+		if filter.To != nil {
+			q = q.Where("timestamp < ?", *filter.To)
+		}
+		if filter.Environment != "" {
+			q = q.Where("environment = ?", filter.Environment)
+		}
+		if filter.Provider != "" {
+			q = q.Where("provider = ?", filter.Provider)
+		}
+		if filter.Model != "" {
+			q = q.Where("model = ?", filter.Model)
+		}
+		if filter.GitSHA != "" {
+			q = q.Where("git_sha = ?", filter.GitSHA)
+		}
+		if filter.GitBranch != "" {
+			q = q.Where("git_branch = ?", filter.GitBranch)
+		}
+		if filter.PromptName != "" {
+			q = q.Where("prompt_name = ?", filter.PromptName)
+		}
+		if filter.PromptVersion != 0 {
+			q = q.Where("prompt_version = ?", filter.PromptVersion)
+		}
+		if len(filter.Tags) > 0 {
+			q = q.Where("tags @> ?", pgdialect.Array(filter.Tags))
+		}
+		q = annotationFilter(q, filter)
+		return evaluationFilter(q, filter)
+	}
 }
 
 func (r *TraceRepository) Delete(ctx context.Context, projectID, traceID string) error {
@@ -4,6 +4,7 @@ package storage
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"time"
 
@@ -61,23 +62,1026 @@ type Project struct {
 	Slug           string `json:"slug"`
 }
 
+// ConflictMode controls how ingestion handles an ID that already exists
+type ConflictMode string
+
+const (
+	ConflictIgnore    ConflictMode = "ignore"     // keep the existing record
+	ConflictReplace   ConflictMode = "replace"    // overwrite the existing record
+	ConflictMergeTags ConflictMode = "merge_tags" // keep the existing record and add any new tags
+)
+
+// IngestStatus is the outcome of ingesting a single trace or test run
+type IngestStatus string
+
+const (
+	IngestCreated   IngestStatus = "created"
+	IngestDuplicate IngestStatus = "duplicate"
+	IngestInvalid   IngestStatus = "invalid"
+	IngestQueued    IngestStatus = "queued"     // accepted for asynchronous ingestion
+	IngestOverLimit IngestStatus = "over_limit" // rejected because the monthly usage limit was reached
+)
+
+// IngestResult reports the outcome for one item of a batch
+type IngestResult struct {
+	Index  int          `json:"index"`
+	ID     string       `json:"id,omitempty"`
+	Status IngestStatus `json:"status"`
+	Reason string       `json:"reason,omitempty"`
+}
+
+// ProjectTrace is a trace bound for a specific project, used for bulk ingestion
+// across projects
+type ProjectTrace struct {
+	ProjectID string
+	Trace     domain.Trace
+	// RestoreID is set on traces loaded by an archive restore
+	RestoreID string
+}
+
+// TraceFilter narrows trace listing and export. Zero values match everything.
+type TraceFilter struct {
+	From        *time.Time `json:"from,omitempty"` // inclusive
+	To          *time.Time `json:"to,omitempty"`   // exclusive
+	Environment string     `json:"environment,omitempty"`
+	Provider    string     `json:"provider,omitempty"`
+	Model       string     `json:"model,omitempty"`
+	GitSHA      string     `json:"git_sha,omitempty"`
+	GitBranch   string     `json:"git_branch,omitempty"`
+	Tags        []string   `json:"tags,omitempty"` // traces must have all of these tags
+
+	PromptName    string `json:"prompt_name,omitempty"`
+	PromptVersion int    `json:"prompt_version,omitempty"` // requires PromptName
+
+	// Annotation filters match traces with at least one annotation that
+	// satisfies them
+	Rating          Rating   `json:"rating,omitempty"`
+	AnnotationLabel string   `json:"annotation_label,omitempty"`
+	ScoreCriterion  string   `json:"score_criterion,omitempty"`
+	MinScore        *float64 `json:"min_score,omitempty"` // requires ScoreCriterion
+	MaxScore        *float64 `json:"max_score,omitempty"` // requires ScoreCriterion
+
+	// Evaluation filters match traces with a result from the evaluator, or
+	// from any evaluator if EvaluatorID is empty, that has the given outcome
+	EvaluatorID string `json:"evaluator_id,omitempty"`
+	Evaluation  string `json:"evaluation,omitempty"` // "pass" or "fail"
+}
+
 // TraceRepository handles trace storage operations
 type TraceRepository interface {
-	Create(ctx context.Context, projectID string, trace *domain.Trace) error
-	CreateBatch(ctx context.Context, projectID string, traces []domain.Trace) error
+	Create(ctx context.Context, projectID string, trace *domain.Trace, onConflict ConflictMode) (IngestStatus, error)
+	// CreateBatch inserts traces with unique trace IDs and returns a status per trace, in order
+	CreateBatch(ctx context.Context, projectID string, traces []domain.Trace, onConflict ConflictMode) ([]IngestStatus, error)
+	// CopyBatch bulk loads traces with unique (project, trace ID) pairs using COPY
+	// and returns the number of rows inserted or updated
+	CopyBatch(ctx context.Context, traces []ProjectTrace, onConflict ConflictMode) (int64, error)
 	Get(ctx context.Context, projectID, traceID string) (*domain.Trace, error)
-	List(ctx context.Context, projectID string, limit, offset int) ([]*domain.Trace, error)
+	List(ctx context.Context, projectID string, filter TraceFilter, limit, offset int) ([]*domain.Trace, error)
+	// Each calls fn with successive batches of matching traces, oldest first,
+	// so large result sets can be streamed without offset pagination
+	Each(ctx context.Context, projectID string, filter TraceFilter, batchSize int, fn func([]*domain.Trace) error) error
 	Delete(ctx context.Context, projectID, traceID string) error
 }
 
+// RedactionPolicyRepository handles per-project redaction policy operations
+type RedactionPolicyRepository interface {
+	Get(ctx context.Context, projectID string) (*domain.RedactionPolicy, error)
+	Upsert(ctx context.Context, projectID string, policy *domain.RedactionPolicy) error
+}
+
+// ProjectRetention holds a project's retention overrides along with its
+// organization's tier and override
+type ProjectRetention struct {
+	ProjectID        string
+	ProjectName      string
+	OrganizationID   string
+	Tier             string
+	OrganizationDays *int // organization-wide override, nil for the tier default
+	ProjectDays      *int // project override, nil to inherit
+}
+
+// OrganizationRetention holds an organization's tier and retention override
+type OrganizationRetention struct {
+	OrganizationID string
+	Tier           string
+	Days           *int // nil for the tier default
+}
+
+// PurgeEstimate counts traces that the next purge will delete
+type PurgeEstimate struct {
+	Expired     int64      `json:"expired"`      // older than the retention period
+	SoftDeleted int64      `json:"soft_deleted"` // deleted longer ago than the grace period
+	OldestTrace *time.Time `json:"oldest_trace,omitempty"`
+}
+
+// RetentionRepository handles trace retention settings and purges
+type RetentionRepository interface {
+	GetOrganization(ctx context.Context, orgID string) (*OrganizationRetention, error)
+	GetProject(ctx context.Context, projectID string) (*ProjectRetention, error)
+	// ListProjects returns retention settings for an organization's projects, or all projects if orgID is empty
+	ListProjects(ctx context.Context, orgID string) ([]*ProjectRetention, error)
+	SetOrganizationDays(ctx context.Context, orgID string, days *int) error
+	SetProjectDays(ctx context.Context, projectID string, days *int) error
+	EstimatePurge(ctx context.Context, projectID string, expiredBefore, deletedBefore time.Time) (*PurgeEstimate, error)
+	// PurgeTraces hard-deletes up to limit traces older than expiredBefore or
+	// soft-deleted before deletedBefore, returning how many were deleted
+	PurgeTraces(ctx context.Context, projectID string, expiredBefore, deletedBefore time.Time, limit int) (int64, error)
+}
+
+// TracePartition is one monthly partition of the traces table, covering
+// [From, To) in UTC
+type TracePartition struct {
+	Name string    `json:"name"`
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+	// Restored is set if an archive restore that has not expired overlaps
+	// the partition's month
+	Restored bool `json:"restored"`
+}
+
+// TracePartitionRepository manages the monthly partitions of the traces table
+type TracePartitionRepository interface {
+	// EnsureMonth creates the partition for the month containing t, moving any
+	// of its traces out of the default partition. It reports whether the
+	// partition was created.
+	EnsureMonth(ctx context.Context, t time.Time) (bool, error)
+	// List returns the monthly partitions ordered by start time
+	List(ctx context.Context) ([]TracePartition, error)
+	// DefaultMonths returns the months with traces in the default partition
+	DefaultMonths(ctx context.Context) ([]time.Time, error)
+	// Drop detaches and drops a partition along with all of its traces
+	Drop(ctx context.Context, name string) error
+}
+
+// ArchiveFormatJSONLGzip is gzip-compressed newline-delimited JSON with one
+// trace per line, in the same shape as the traces API
+const ArchiveFormatJSONLGzip = "jsonl.gz"
+
+// TraceArchive is the manifest entry for an archive file of traces that were
+// moved out of Postgres into file storage
+type TraceArchive struct {
+	ID          string    `json:"id"`
+	ProjectID   string    `json:"project_id"`
+	StorageKey  string    `json:"storage_key"`
+	Format      string    `json:"format"`
+	PeriodStart time.Time `json:"period_start"` // earliest trace timestamp in the file
+	PeriodEnd   time.Time `json:"period_end"`   // latest trace timestamp in the file
+	TraceCount  int       `json:"trace_count"`
+	SizeBytes   int64     `json:"size_bytes"`
+	SHA256      string    `json:"sha256"` // checksum of the stored file
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// ArchivePolicy is a project's archive threshold
+type ArchivePolicy struct {
+	ProjectID string
+	AfterDays int
+}
+
+// ArchiveCursor is the position of the last trace read when paging through
+// archivable traces
+type ArchiveCursor struct {
+	Timestamp time.Time
+	RowID     string
+}
+
+// ArchivableTrace is a stored trace together with its row ID, used to delete
+// it once archived
+type ArchivableTrace struct {
+	RowID string
+	Trace domain.Trace
+}
+
+// RestoreStatus is the state of an archive restore
+type RestoreStatus string
+
+const (
+	RestorePending   RestoreStatus = "pending"
+	RestoreRunning   RestoreStatus = "running"
+	RestoreCompleted RestoreStatus = "completed"
+	RestoreFailed    RestoreStatus = "failed"
+	RestoreExpired   RestoreStatus = "expired" // the restored traces have been deleted again
+)
+
+// ArchiveRestore is a request to load archived traces in [RangeStart, RangeEnd)
+// back into Postgres. Restored traces are deleted from Postgres again after
+// ExpiresAt; they are never archived twice.
+type ArchiveRestore struct {
+	ID               string        `json:"id"`
+	ProjectID        string        `json:"project_id"`
+	RequestedBy      string        `json:"requested_by,omitempty"`
+	RangeStart       time.Time     `json:"from"`
+	RangeEnd         time.Time     `json:"to"`
+	KeepDays         int           `json:"keep_days"`
+	Status           RestoreStatus `json:"status"`
+	ArchivesTotal    int           `json:"archives_total"`
+	ArchivesRestored int           `json:"archives_restored"`
+	TracesRestored   int64         `json:"traces_restored"`
+	Error            string        `json:"error,omitempty"`
+	ExpiresAt        *time.Time    `json:"expires_at,omitempty"`
+	CreatedAt        time.Time     `json:"created_at"`
+	UpdatedAt        time.Time     `json:"updated_at"`
+	CompletedAt      *time.Time    `json:"completed_at,omitempty"`
+}
+
+// ArchiveRepository handles archive policies, manifests, and restores
+type ArchiveRepository interface {
+	// GetPolicy returns a project's archive threshold in days, or nil if archiving is disabled
+	GetPolicy(ctx context.Context, projectID string) (*int, error)
+	SetPolicy(ctx context.Context, projectID string, days *int) error
+	// ListPolicies returns every project with archiving enabled
+	ListPolicies(ctx context.Context) ([]ArchivePolicy, error)
+	// ListArchivable returns up to limit live traces older than before, ordered
+	// by timestamp after the cursor. Restored traces, and traces in the range
+	// of a restore that has not finished, are skipped.
+	ListArchivable(ctx context.Context, projectID string, before time.Time, after *ArchiveCursor, limit int) ([]ArchivableTrace, error)
+	// CreateArchive records an archive and deletes its traces in one transaction
+	CreateArchive(ctx context.Context, archive *TraceArchive, rowIDs []string) error
+	// ListArchives returns a project's archives overlapping [from, to]; zero times are unbounded
+	ListArchives(ctx context.Context, projectID string, from, to time.Time) ([]*TraceArchive, error)
+	// DeleteArchive deletes an archive's manifest and releases the trace IDs
+	// of its traces that are not stored anywhere else
+	DeleteArchive(ctx context.Context, id string) error
+
+	CreateRestore(ctx context.Context, restore *ArchiveRestore) error
+	GetRestore(ctx context.Context, projectID, id string) (*ArchiveRestore, error)
+	ListRestores(ctx context.Context, projectID string, limit int) ([]*ArchiveRestore, error)
+	// ClaimRestore marks the oldest pending restore, or a running restore not
+	// updated within staleAfter, as running and returns it. It returns nil if
+	// there is nothing to do.
+	ClaimRestore(ctx context.Context, staleAfter time.Duration) (*ArchiveRestore, error)
+	// UpdateRestore saves a restore's status and progress
+	UpdateRestore(ctx context.Context, restore *ArchiveRestore) error
+	// ListReleasableRestores returns completed restores whose ExpiresAt is
+	// before t, and failed restores that left restored traces behind
+	ListReleasableRestores(ctx context.Context, before time.Time) ([]*ArchiveRestore, error)
+	// DeleteRestoredTraces deletes up to limit of the traces a restore loaded,
+	// which are still in its archives. Traces another held restore also
+	// covers are handed over to it instead.
+	DeleteRestoredTraces(ctx context.Context, restore *ArchiveRestore, limit int) (int64, error)
+}
+
+// TestRunFilter narrows test run export. Zero values match everything.
+type TestRunFilter struct {
+	From      *time.Time `json:"from,omitempty"` // inclusive
+	To        *time.Time `json:"to,omitempty"`   // exclusive
+	GitSHA    string     `json:"git_sha,omitempty"`
+	GitBranch string     `json:"git_branch,omitempty"`
+	Status    string     `json:"status,omitempty"`
+}
+
 // TestRunRepository handles test run storage operations
 type TestRunRepository interface {
-	Create(ctx context.Context, projectID string, testRun *domain.TestRun) error
+	Create(ctx context.Context, projectID string, testRun *domain.TestRun, onConflict ConflictMode) (IngestStatus, error)
 	Get(ctx context.Context, projectID, runID string) (*domain.TestRun, error)
 	List(ctx context.Context, projectID string, limit, offset int) ([]*domain.TestRun, error)
+	// Each calls fn with successive batches of matching test runs, oldest first
+	Each(ctx context.Context, projectID string, filter TestRunFilter, batchSize int, fn func([]*domain.TestRun) error) error
+	// Latest returns the newest completed run on the branch with a timestamp
+	// before the given time, other than excludeRunID
+	Latest(ctx context.Context, projectID, branch string, before time.Time, excludeRunID string) (*domain.TestRun, error)
+	// CaseHistory returns the case results of the project's latest runs, on
+	// the branch if set, oldest first
+	CaseHistory(ctx context.Context, projectID, branch string, runs int) ([]CaseObservation, error)
 	Delete(ctx context.Context, projectID, runID string) error
 }
 
+// ExportResource is the kind of record an export contains
+type ExportResource string
+
+const (
+	ExportTraces   ExportResource = "traces"
+	ExportTestRuns ExportResource = "test_runs"
+)
+
+// ExportFormat is the file format of an export
+type ExportFormat string
+
+const (
+	ExportJSONL   ExportFormat = "jsonl"
+	ExportCSV     ExportFormat = "csv"
+	ExportParquet ExportFormat = "parquet"
+)
+
+// ExportStatus is the state of an export job
+type ExportStatus string
+
+const (
+	ExportPending   ExportStatus = "pending"
+	ExportRunning   ExportStatus = "running"
+	ExportCompleted ExportStatus = "completed"
+	ExportFailed    ExportStatus = "failed"
+	ExportExpired   ExportStatus = "expired" // the file has been deleted
+)
+
+// Export is an asynchronous export of a project's traces or test runs to a
+// file in file storage. Exactly one of TraceFilter and TestRunFilter is set,
+// matching Resource.
+type Export struct {
+	ID            string         `json:"id"`
+	ProjectID     string         `json:"project_id"`
+	RequestedBy   string         `json:"requested_by,omitempty"`
+	NotifyEmail   string         `json:"notify_email,omitempty"`
+	Resource      ExportResource `json:"resource"`
+	Format        ExportFormat   `json:"format"`
+	TraceFilter   *TraceFilter   `json:"trace_filter,omitempty"`
+	TestRunFilter *TestRunFilter `json:"test_run_filter,omitempty"`
+	Status        ExportStatus   `json:"status"`
+	RowCount      int64          `json:"row_count"`
+	SizeBytes     int64          `json:"size_bytes"`
+	StorageKey    string         `json:"-"`
+	Error         string         `json:"error,omitempty"`
+	ExpiresAt     *time.Time     `json:"expires_at,omitempty"` // when the file is deleted
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	CompletedAt   *time.Time     `json:"completed_at,omitempty"`
+}
+
+// ExportRepository handles export jobs
+type ExportRepository interface {
+	Create(ctx context.Context, export *Export) error
+	Get(ctx context.Context, projectID, id string) (*Export, error)
+	List(ctx context.Context, projectID string, limit int) ([]*Export, error)
+	// Claim marks the oldest pending export, or a running export not updated
+	// within staleAfter, as running and returns it. It returns nil if there is
+	// nothing to do.
+	Claim(ctx context.Context, staleAfter time.Duration) (*Export, error)
+	// Update saves an export's status, progress, and result
+	Update(ctx context.Context, export *Export) error
+	// ListExpired returns completed exports whose files expired before t
+	ListExpired(ctx context.Context, before time.Time, limit int) ([]*Export, error)
+}
+
+// Dataset is a named collection of test case inputs, usually taken from
+// production traces. Every change to its items creates a new version, and
+// earlier versions stay readable.
+type Dataset struct {
+	ID          string    `json:"id"`
+	ProjectID   string    `json:"project_id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	Version     int       `json:"version"`
+	ItemCount   int       `json:"item_count"` // items in the current version
+	CreatedBy   string    `json:"created_by,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// DatasetExpectedOutput is the output a test case built from a dataset item
+// should produce
+type DatasetExpectedOutput struct {
+	Text string          `json:"text,omitempty"`
+	JSON json.RawMessage `json:"json,omitempty"`
+}
+
+// DatasetItem is one test case input in a dataset. Its ID stays the same
+// across versions.
+type DatasetItem struct {
+	ID             string                 `json:"id"`
+	DatasetID      string                 `json:"dataset_id"`
+	SourceTraceID  string                 `json:"source_trace_id,omitempty"`
+	Messages       []domain.Message       `json:"messages"`
+	Params         *domain.SamplingParams `json:"params,omitempty"`
+	ExpectedOutput *DatasetExpectedOutput `json:"expected_output,omitempty"`
+	Tags           []string               `json:"tags,omitempty"`
+	Version        int                    `json:"version"` // the dataset version that last changed the item
+	CreatedAt      time.Time              `json:"created_at"`
+}
+
+// DatasetRepository handles datasets and their items. Methods that change
+// items return the dataset's new version.
+type DatasetRepository interface {
+	Create(ctx context.Context, dataset *Dataset) error
+	Get(ctx context.Context, projectID, id string) (*Dataset, error)
+	List(ctx context.Context, projectID string) ([]*Dataset, error)
+	Update(ctx context.Context, dataset *Dataset) error
+	Delete(ctx context.Context, projectID, id string) error
+	// AddItems adds items to a dataset, skipping items whose source trace is
+	// already in it. The version is unchanged if nothing was added.
+	AddItems(ctx context.Context, projectID, datasetID string, items []*DatasetItem) (version, added int, err error)
+	// ListItems returns the items in a version of a dataset, or in the
+	// current version if version is 0
+	ListItems(ctx context.Context, projectID, datasetID string, version int) ([]*DatasetItem, error)
+	SetExpectedOutput(ctx context.Context, projectID, datasetID, itemID string, expected *DatasetExpectedOutput) (*DatasetItem, error)
+	RemoveItem(ctx context.Context, projectID, datasetID, itemID string) (int, error)
+}
+
+// AnnotationSource is who submitted an annotation
+type AnnotationSource string
+
+const (
+	AnnotationSourceMember  AnnotationSource = "member"   // an org member signed in to the dashboard
+	AnnotationSourceEndUser AnnotationSource = "end_user" // end-user feedback relayed with an API key
+)
+
+// Rating is a thumbs up or down on a trace
+type Rating string
+
+const (
+	RatingUp   Rating = "up"
+	RatingDown Rating = "down"
+)
+
+// Annotation is human feedback on a trace. It may combine a rating, scores
+// on named criteria, a comment, and labels.
+type Annotation struct {
+	ID        string             `json:"id"`
+	ProjectID string             `json:"project_id"`
+	TraceID   string             `json:"trace_id"`
+	Source    AnnotationSource   `json:"source"`
+	AuthorID  string             `json:"author_id,omitempty"`   // set for member annotations
+	EndUserID string             `json:"end_user_id,omitempty"` // optionally set for end-user feedback
+	Rating    Rating             `json:"rating,omitempty"`
+	Scores    map[string]float64 `json:"scores,omitempty"`
+	Comment   string             `json:"comment,omitempty"`
+	Labels    []string           `json:"labels,omitempty"`
+	CreatedAt time.Time          `json:"created_at"`
+	UpdatedAt time.Time          `json:"updated_at"`
+}
+
+// CriterionScore aggregates the scores given on one criterion
+type CriterionScore struct {
+	Criterion string  `json:"criterion"`
+	Count     int     `json:"count"`
+	Mean      float64 `json:"mean"`
+	Min       float64 `json:"min"`
+	Max       float64 `json:"max"`
+}
+
+// LabelCount is how many annotations carry a label
+type LabelCount struct {
+	Label string `json:"label"`
+	Count int    `json:"count"`
+}
+
+// AnnotationSummary aggregates a project's annotations
+type AnnotationSummary struct {
+	Annotations     int              `json:"annotations"`
+	AnnotatedTraces int              `json:"annotated_traces"`
+	Up              int              `json:"up"`
+	Down            int              `json:"down"`
+	Scores          []CriterionScore `json:"scores"`
+	Labels          []LabelCount     `json:"labels"`
+}
+
+// AnnotationRepository handles trace annotations
+type AnnotationRepository interface {
+	Create(ctx context.Context, annotation *Annotation) error
+	Get(ctx context.Context, projectID, id string) (*Annotation, error)
+	ListByTrace(ctx context.Context, projectID, traceID string) ([]*Annotation, error)
+	// Update saves an annotation's rating, scores, comment, and labels
+	Update(ctx context.Context, annotation *Annotation) error
+	Delete(ctx context.Context, projectID, id string) error
+	// Summarize aggregates the annotations created in [from, to). Zero times
+	// leave the range open.
+	Summarize(ctx context.Context, projectID string, from, to time.Time, topLabels int) (*AnnotationSummary, error)
+}
+
+// ReviewRules decide which new traces and test cases are added to a review
+// queue. A trace is added if it is sampled, carries one of the tags, or
+// fails one of the evaluators.
+type ReviewRules struct {
+	SampleRate      float64  `json:"sample_rate,omitempty"` // fraction of traces to sample, 0 to 1
+	Tags            []string `json:"tags,omitempty"`
+	FailedTestCases bool     `json:"failed_test_cases,omitempty"` // add failed cases from uploaded test runs
+	TestRunBranch   string   `json:"test_run_branch,omitempty"`   // only from test runs on this branch
+	// FailedEvaluators adds traces that fail any of these evaluators
+	FailedEvaluators []string `json:"failed_evaluators,omitempty"`
+}
+
+// RubricCriterion is a score reviewers give each item in a queue
+type RubricCriterion struct {
+	Name        string  `json:"name"`
+	Description string  `json:"description,omitempty"`
+	Min         float64 `json:"min"`
+	Max         float64 `json:"max"`
+	Required    bool    `json:"required,omitempty"`
+}
+
+// ReviewQueue is a project's queue of traces and test cases for human review
+type ReviewQueue struct {
+	ID               string            `json:"id"`
+	ProjectID        string            `json:"project_id"`
+	Name             string            `json:"name"`
+	Description      string            `json:"description,omitempty"`
+	Rules            ReviewRules       `json:"rules"`
+	Rubric           []RubricCriterion `json:"rubric"`
+	CreatedBy        string            `json:"created_by,omitempty"`
+	PopulatedThrough time.Time         `json:"populated_through"`
+	CreatedAt        time.Time         `json:"created_at"`
+	UpdatedAt        time.Time         `json:"updated_at"`
+}
+
+// ReviewItemKind is what a review item points to
+type ReviewItemKind string
+
+const (
+	ReviewItemTrace    ReviewItemKind = "trace"
+	ReviewItemTestCase ReviewItemKind = "test_case"
+)
+
+// ReviewReason is why an item was added to a queue
+type ReviewReason string
+
+const (
+	ReviewReasonSample          ReviewReason = "sample"
+	ReviewReasonTag             ReviewReason = "tag"
+	ReviewReasonFailedTestCase  ReviewReason = "failed_test_case"
+	ReviewReasonFailedEvaluator ReviewReason = "failed_evaluator"
+	ReviewReasonManual          ReviewReason = "manual"
+)
+
+// ReviewStatus is where an item is in the review workflow
+type ReviewStatus string
+
+const (
+	ReviewPending   ReviewStatus = "pending"
+	ReviewClaimed   ReviewStatus = "claimed"
+	ReviewCompleted ReviewStatus = "completed"
+)
+
+// ReviewItem is a trace or test case in a review queue. Test case items
+// point to a case in a test run; trace items point to a trace.
+type ReviewItem struct {
+	ID          string             `json:"id"`
+	QueueID     string             `json:"queue_id"`
+	ProjectID   string             `json:"project_id"`
+	Kind        ReviewItemKind     `json:"kind"`
+	TraceID     string             `json:"trace_id,omitempty"`
+	RunID       string             `json:"run_id,omitempty"`
+	CaseID      string             `json:"case_id,omitempty"`
+	Reason      ReviewReason       `json:"reason"`
+	Status      ReviewStatus       `json:"status"`
+	AssigneeID  string             `json:"assignee_id,omitempty"`
+	ReviewerID  string             `json:"reviewer_id,omitempty"`
+	ClaimedAt   *time.Time         `json:"claimed_at,omitempty"`
+	Scores      map[string]float64 `json:"scores,omitempty"`
+	Comment     string             `json:"comment,omitempty"`
+	CompletedAt *time.Time         `json:"completed_at,omitempty"`
+	CreatedAt   time.Time          `json:"created_at"`
+	UpdatedAt   time.Time          `json:"updated_at"`
+}
+
+// ReviewItemFilter narrows a review item listing. Zero values match everything.
+type ReviewItemFilter struct {
+	Status     ReviewStatus
+	AssigneeID string
+	ReviewerID string
+	Limit      int
+	Offset     int
+}
+
+// ReviewerProgress is one member's share of a queue's work
+type ReviewerProgress struct {
+	UserID    string `json:"user_id"`
+	Assigned  int    `json:"assigned"` // assigned and not yet completed
+	Claimed   int    `json:"claimed"`
+	Completed int    `json:"completed"`
+}
+
+// ReviewProgress summarizes the state of a review queue
+type ReviewProgress struct {
+	Total     int                `json:"total"`
+	Pending   int                `json:"pending"`
+	Claimed   int                `json:"claimed"`
+	Completed int                `json:"completed"`
+	Reviewers []ReviewerProgress `json:"reviewers"`
+	Scores    []CriterionScore   `json:"scores"`
+}
+
+// ReviewRepository handles review queues and their items
+type ReviewRepository interface {
+	CreateQueue(ctx context.Context, queue *ReviewQueue) error
+	GetQueue(ctx context.Context, projectID, id string) (*ReviewQueue, error)
+	ListQueues(ctx context.Context, projectID string) ([]*ReviewQueue, error)
+	// ListAllQueues returns every project's queues, for the sampler
+	ListAllQueues(ctx context.Context) ([]*ReviewQueue, error)
+	// UpdateQueue saves a queue's name, description, rules, and rubric
+	UpdateQueue(ctx context.Context, queue *ReviewQueue) error
+	DeleteQueue(ctx context.Context, projectID, id string) error
+	// Populate adds the traces and failed test cases stored after the queue's
+	// populated_through and up to until that match its rules, then advances
+	// populated_through. It returns 0 without waiting if another instance is
+	// populating the queue.
+	Populate(ctx context.Context, queueID string, until time.Time) (int, error)
+	// AddItems adds items to a queue, skipping any already in it, and returns
+	// how many were added
+	AddItems(ctx context.Context, items []*ReviewItem) (int, error)
+	GetItem(ctx context.Context, queueID, id string) (*ReviewItem, error)
+	ListItems(ctx context.Context, queueID string, filter ReviewItemFilter) ([]*ReviewItem, error)
+	// AssignItem sets or, with an empty assigneeID, clears an item's assignee
+	AssignItem(ctx context.Context, queueID, id, assigneeID string) (*ReviewItem, error)
+	// ClaimItem claims an item for a reviewer if it is pending, or if its claim
+	// is older than staleAfter. It returns ErrNotFound if the item cannot be
+	// claimed.
+	ClaimItem(ctx context.Context, queueID, id, reviewerID string, staleAfter time.Duration) (*ReviewItem, error)
+	// ClaimNext claims the oldest claimable item assigned to the reviewer, or
+	// failing that the oldest unassigned one. It returns nil if there is none.
+	ClaimNext(ctx context.Context, queueID, reviewerID string, staleAfter time.Duration) (*ReviewItem, error)
+	// ReleaseItem returns an item claimed by the reviewer to pending
+	ReleaseItem(ctx context.Context, queueID, id, reviewerID string) (*ReviewItem, error)
+	// CompleteItem records the review of an item claimed by the reviewer
+	CompleteItem(ctx context.Context, queueID, id, reviewerID string, scores map[string]float64, comment string) (*ReviewItem, error)
+	Progress(ctx context.Context, queueID string) (*ReviewProgress, error)
+}
+
+// EvaluatorType is the kind of check an evaluator runs on a trace
+type EvaluatorType string
+
+const (
+	EvaluatorRegexMatch     EvaluatorType = "regex_match"      // the response must match a pattern
+	EvaluatorRegexAbsent    EvaluatorType = "regex_absent"     // the response must not match a pattern
+	EvaluatorJSONSchema     EvaluatorType = "json_schema"      // the response must be JSON valid against a schema
+	EvaluatorMaxLatency     EvaluatorType = "max_latency"      // latency must not exceed a limit
+	EvaluatorMaxTokens      EvaluatorType = "max_tokens"       // token usage must not exceed a limit
+	EvaluatorRefusal        EvaluatorType = "refusal"          // the response must not contain a refusal phrase
+	EvaluatorToolArgsSchema EvaluatorType = "tool_args_schema" // a tool's call arguments must be valid against a schema
+)
+
+// Evaluator is a check run on every trace ingested into a project. Its
+// config depends on its type.
+type Evaluator struct {
+	ID        string          `json:"id"`
+	ProjectID string          `json:"project_id"`
+	Name      string          `json:"name"`
+	Type      EvaluatorType   `json:"type"`
+	Config    json.RawMessage `json:"config"`
+	Enabled   bool            `json:"enabled"`
+	CreatedBy string          `json:"created_by,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+// EvaluationResult is the outcome of one evaluator on one trace
+type EvaluationResult struct {
+	ProjectID     string        `json:"-"`
+	TraceID       string        `json:"trace_id"`
+	EvaluatorID   string        `json:"evaluator_id"`
+	EvaluatorName string        `json:"evaluator_name,omitempty"`
+	EvaluatorType EvaluatorType `json:"evaluator_type,omitempty"`
+	Passed        bool          `json:"passed"`
+	Message       string        `json:"message,omitempty"` // why the trace failed
+	CreatedAt     time.Time     `json:"created_at"`
+}
+
+// EvaluatorPassRate aggregates an evaluator's results
+type EvaluatorPassRate struct {
+	EvaluatorID   string        `json:"evaluator_id"`
+	EvaluatorName string        `json:"evaluator_name"`
+	EvaluatorType EvaluatorType `json:"evaluator_type"`
+	Total         int           `json:"total"`
+	Passed        int           `json:"passed"`
+	PassRate      float64       `json:"pass_rate"`
+}
+
+// EvaluateFunc evaluates a batch of traces with a project's enabled
+// evaluators
+type EvaluateFunc func(evaluators []*Evaluator, traces []*domain.Trace) []*EvaluationResult
+
+// EvaluationRepository handles evaluators and their results
+type EvaluationRepository interface {
+	// CreateEvaluator creates an evaluator. The first evaluator in a project
+	// starts evaluation of the traces ingested from then on.
+	CreateEvaluator(ctx context.Context, evaluator *Evaluator) error
+	GetEvaluator(ctx context.Context, projectID, id string) (*Evaluator, error)
+	ListEvaluators(ctx context.Context, projectID string) ([]*Evaluator, error)
+	// UpdateEvaluator saves an evaluator's name, config, and enabled flag
+	UpdateEvaluator(ctx context.Context, evaluator *Evaluator) error
+	DeleteEvaluator(ctx context.Context, projectID, id string) error
+	// ListEvaluatingProjects returns the IDs of projects with enabled evaluators
+	ListEvaluatingProjects(ctx context.Context) ([]string, error)
+	// EvaluateNext passes up to limit of the project's unevaluated traces,
+	// oldest first, to evaluate, saves the results, and marks the traces
+	// evaluated. Replacing a trace marks it unevaluated again. It returns how
+	// many traces were evaluated, or 0 without waiting if another instance is
+	// evaluating the project.
+	EvaluateNext(ctx context.Context, projectID string, limit int, evaluate EvaluateFunc) (int, error)
+	ListResults(ctx context.Context, projectID, traceID string) ([]*EvaluationResult, error)
+	// PassRates aggregates the results created in [from, to) per evaluator.
+	// Zero times leave the range open.
+	PassRates(ctx context.Context, projectID string, from, to time.Time) ([]EvaluatorPassRate, error)
+}
+
+// Judge is an LLM-as-judge evaluator: a prompt template sent to a judge
+// model, which scores each item of a run on the judge's scale
+type Judge struct {
+	ID             string    `json:"id"`
+	ProjectID      string    `json:"project_id"`
+	Name           string    `json:"name"`
+	PromptTemplate string    `json:"prompt_template"`
+	Model          string    `json:"model"`
+	ScaleMin       int       `json:"scale_min"`
+	ScaleMax       int       `json:"scale_max"`
+	PassThreshold  *float64  `json:"pass_threshold,omitempty"` // scores at or above it pass
+	CreatedBy      string    `json:"created_by,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// JudgeTarget is what a judge run scores
+type JudgeTarget string
+
+const (
+	JudgeTraces  JudgeTarget = "traces"
+	JudgeDataset JudgeTarget = "dataset"
+)
+
+// JudgeRunStatus is the state of a judge run
+type JudgeRunStatus string
+
+const (
+	JudgeRunPending   JudgeRunStatus = "pending"
+	JudgeRunRunning   JudgeRunStatus = "running"
+	JudgeRunCompleted JudgeRunStatus = "completed"
+	JudgeRunFailed    JudgeRunStatus = "failed"
+)
+
+// JudgeRun is an asynchronous run of a judge over the traces matching a
+// filter or the items of a dataset version. Its totals cover the scores
+// saved so far.
+type JudgeRun struct {
+	ID             string         `json:"id"`
+	ProjectID      string         `json:"project_id"`
+	JudgeID        string         `json:"judge_id"`
+	RequestedBy    string         `json:"requested_by,omitempty"`
+	Target         JudgeTarget    `json:"target"`
+	TraceFilter    *TraceFilter   `json:"trace_filter,omitempty"`
+	DatasetID      string         `json:"dataset_id,omitempty"`
+	DatasetVersion int            `json:"dataset_version,omitempty"` // 0 for the version current when the run starts
+	MaxItems       int            `json:"max_items"`
+	Status         JudgeRunStatus `json:"status"`
+	Scored         int            `json:"scored"`
+	Errored        int            `json:"errored"`
+	Cached         int            `json:"cached"` // scores taken from the cache, at no cost
+	Passed         int            `json:"passed"`
+	MeanScore      *float64       `json:"mean_score,omitempty"`
+	TokensIn       int64          `json:"tokens_in"`
+	TokensOut      int64          `json:"tokens_out"`
+	CostUSD        float64        `json:"cost_usd"`
+	Error          string         `json:"error,omitempty"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	CompletedAt    *time.Time     `json:"completed_at,omitempty"`
+}
+
+// JudgeScore is a judge's score for one trace or dataset item of a run.
+// Error is set instead of Score if the item could not be scored.
+type JudgeScore struct {
+	RunID     string    `json:"run_id"`
+	ProjectID string    `json:"-"`
+	JudgeID   string    `json:"judge_id"`
+	TargetID  string    `json:"target_id"` // trace ID or dataset item ID
+	Score     *float64  `json:"score,omitempty"`
+	Passed    *bool     `json:"passed,omitempty"`
+	Reasoning string    `json:"reasoning,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	Cached    bool      `json:"cached"`
+	TokensIn  int       `json:"tokens_in"`
+	TokensOut int       `json:"tokens_out"`
+	CostUSD   float64   `json:"cost_usd"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// JudgeVerdict is a cached judge response
+type JudgeVerdict struct {
+	Score     float64
+	Reasoning string
+}
+
+// JudgeCost aggregates the judge calls of one judge
+type JudgeCost struct {
+	JudgeID   string  `json:"judge_id"`
+	JudgeName string  `json:"judge_name"`
+	Model     string  `json:"model"`
+	Scores    int     `json:"scores"`
+	Cached    int     `json:"cached"`
+	TokensIn  int64   `json:"tokens_in"`
+	TokensOut int64   `json:"tokens_out"`
+	CostUSD   float64 `json:"cost_usd"`
+}
+
+// JudgeRepository handles judges, their runs and scores, and the judge cache
+type JudgeRepository interface {
+	CreateJudge(ctx context.Context, judge *Judge) error
+	GetJudge(ctx context.Context, projectID, id string) (*Judge, error)
+	ListJudges(ctx context.Context, projectID string) ([]*Judge, error)
+	// UpdateJudge saves a judge's name, prompt template, model, scale, and
+	// pass threshold
+	UpdateJudge(ctx context.Context, judge *Judge) error
+	DeleteJudge(ctx context.Context, projectID, id string) error
+
+	CreateRun(ctx context.Context, run *JudgeRun) error
+	GetRun(ctx context.Context, projectID, id string) (*JudgeRun, error)
+	ListRuns(ctx context.Context, projectID, judgeID string, limit int) ([]*JudgeRun, error)
+	// ClaimRun marks the oldest pending run, or a running run not updated
+	// within staleAfter, as running and returns it. It returns nil if there is
+	// nothing to do.
+	ClaimRun(ctx context.Context, staleAfter time.Duration) (*JudgeRun, error)
+	// UpdateRun saves a run's status, dataset version, and error, and
+	// recomputes its totals from its scores
+	UpdateRun(ctx context.Context, run *JudgeRun) error
+
+	// SaveScore saves a score, unless the run already has one for the item
+	SaveScore(ctx context.Context, score *JudgeScore) error
+	// ScoredTargets returns the IDs of the items a run has already scored
+	ScoredTargets(ctx context.Context, runID string) (map[string]bool, error)
+	ListScores(ctx context.Context, runID string, limit, offset int) ([]*JudgeScore, error)
+
+	// GetCached returns the cached verdict for an input hash, or nil
+	GetCached(ctx context.Context, projectID, inputHash string) (*JudgeVerdict, error)
+	PutCached(ctx context.Context, projectID, inputHash string, verdict *JudgeVerdict) error
+
+	// Costs aggregates the scores created in [from, to) per judge. Zero times
+	// leave the range open.
+	Costs(ctx context.Context, projectID string, from, to time.Time) ([]JudgeCost, error)
+}
+
+// PolicySeverity is how serious a policy violation is
+type PolicySeverity string
+
+const (
+	PolicyInfo  PolicySeverity = "info"
+	PolicyWarn  PolicySeverity = "warn"
+	PolicyError PolicySeverity = "error"
+)
+
+// Policy is a registered policy that test run violations refer to by
+// PolicyID. Organization-wide policies have no ProjectID; a project policy
+// with the same PolicyID takes precedence within its project.
+type Policy struct {
+	OrganizationID string         `json:"organization_id"`
+	ProjectID      string         `json:"project_id,omitempty"`
+	PolicyID       string         `json:"policy_id"`
+	Description    string         `json:"description,omitempty"`
+	Severity       PolicySeverity `json:"severity"`
+	Owner          string         `json:"owner,omitempty"`
+	Enabled        bool           `json:"enabled"`
+	// Blocking forces test runs that violate the policy to failed
+	Blocking  bool      `json:"blocking"`
+	CreatedBy string    `json:"created_by,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ViolationInterval is the bucket width of violation analytics
+type ViolationInterval string
+
+const (
+	ViolationsByDay  ViolationInterval = "day"
+	ViolationsByWeek ViolationInterval = "week"
+)
+
+// ViolationCount counts the violations of one policy in one time bucket
+type ViolationCount struct {
+	PolicyID string    `json:"policy_id"`
+	Bucket   time.Time `json:"bucket"` // start of the day or week, in UTC
+	Count    int       `json:"count"`
+	Runs     int       `json:"runs"` // test runs with at least one violation
+}
+
+// PolicyRepository handles the policy registry
+type PolicyRepository interface {
+	// Upsert creates a policy or replaces the one with the same scope and
+	// PolicyID, reporting whether it was created
+	Upsert(ctx context.Context, policy *Policy) (bool, error)
+	// Get returns a policy of the organization, or of the project if
+	// projectID is set
+	Get(ctx context.Context, orgID, projectID, policyID string) (*Policy, error)
+	// List returns the organization's policies, or the project's own
+	// policies if projectID is set
+	List(ctx context.Context, orgID, projectID string) ([]*Policy, error)
+	// Effective returns the policies that apply to a project: its own and
+	// its organization's, minus organization policies it overrides
+	Effective(ctx context.Context, orgID, projectID string) ([]*Policy, error)
+	Delete(ctx context.Context, orgID, projectID, policyID string) error
+	// ViolationCounts counts the violations in the project's test runs with
+	// timestamps in [from, to) per policy and bucket. Zero times leave the
+	// range open.
+	ViolationCounts(ctx context.Context, projectID string, from, to time.Time, interval ViolationInterval) ([]ViolationCount, error)
+}
+
+// Prompt is a named prompt template. Its versions never change once
+// created; labels such as production point at the version to use.
+type Prompt struct {
+	ID            string         `json:"id"`
+	ProjectID     string         `json:"project_id"`
+	Name          string         `json:"name"`
+	Description   string         `json:"description,omitempty"`
+	LatestVersion int            `json:"latest_version"` // 0 until the first version is created
+	Labels        map[string]int `json:"labels"`         // label to version
+	CreatedBy     string         `json:"created_by,omitempty"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+}
+
+// PromptVersion is one immutable version of a prompt
+type PromptVersion struct {
+	ID        string                 `json:"id"`
+	PromptID  string                 `json:"prompt_id"`
+	Name      string                 `json:"name"` // the prompt's name
+	Version   int                    `json:"version"`
+	Template  string                 `json:"template"`
+	Variables []string               `json:"variables"`
+	Params    *domain.SamplingParams `json:"params,omitempty"` // default sampling parameters
+	Model     string                 `json:"model,omitempty"`  // default model
+	Labels    []string               `json:"labels"`           // labels pointing at this version
+	CreatedBy string                 `json:"created_by,omitempty"`
+	CreatedAt time.Time              `json:"created_at"`
+}
+
+// PromptVersionStats aggregates the traces and test case results that
+// report a prompt version
+type PromptVersionStats struct {
+	PromptName    string  `json:"prompt_name"`
+	PromptVersion int     `json:"prompt_version"` // 0 for traces and cases that report no version
+	Traces        int     `json:"traces"`
+	AvgLatencyMS  float64 `json:"avg_latency_ms"`
+	P95LatencyMS  float64 `json:"p95_latency_ms"`
+	TokensIn      int64   `json:"tokens_in"`
+	TokensOut     int64   `json:"tokens_out"`
+	Cases         int     `json:"cases"`          // test case results
+	CasePassRate  float64 `json:"case_pass_rate"` // mean pass rate of the cases
+}
+
+// PromptRepository handles the prompt registry. Prompts are addressed by
+// name within their project.
+type PromptRepository interface {
+	CreatePrompt(ctx context.Context, prompt *Prompt) error
+	GetPrompt(ctx context.Context, projectID, name string) (*Prompt, error)
+	ListPrompts(ctx context.Context, projectID string) ([]*Prompt, error)
+	// UpdatePrompt saves a prompt's description
+	UpdatePrompt(ctx context.Context, prompt *Prompt) error
+	DeletePrompt(ctx context.Context, projectID, name string) error
+	// CreateVersion adds the prompt's next version and points the version's
+	// labels at it
+	CreateVersion(ctx context.Context, projectID, name string, version *PromptVersion) error
+	// GetVersion returns a version of the prompt, or its latest if version is 0
+	GetVersion(ctx context.Context, projectID, name string, version int) (*PromptVersion, error)
+	// GetLabeled returns the version a label points at
+	GetLabeled(ctx context.Context, projectID, name, label string) (*PromptVersion, error)
+	ListVersions(ctx context.Context, projectID, name string) ([]*PromptVersion, error)
+	// SetLabel points a label at a version, moving it if it exists
+	SetLabel(ctx context.Context, projectID, name, label string, version int) error
+	DeleteLabel(ctx context.Context, projectID, name, label string) error
+	// Stats aggregates the project's traces and test case results with
+	// timestamps in [from, to) per prompt version, for one prompt or every
+	// prompt if name is empty. Zero times leave the range open.
+	Stats(ctx context.Context, projectID, name string, from, to time.Time) ([]PromptVersionStats, error)
+}
+
+// GateConditionType is a kind of quality gate condition
+type GateConditionType string
+
+const (
+	// GateMinPassRate requires the run's share of passed cases to be at
+	// least Threshold (0-1)
+	GateMinPassRate GateConditionType = "min_pass_rate"
+	// GateMaxViolations allows at most Threshold violations of Severity or
+	// above
+	GateMaxViolations GateConditionType = "max_violations"
+	// GateMaxCasePassRateDrop fails cases whose pass rate fell by more than
+	// Threshold (0-1) from the baseline run
+	GateMaxCasePassRateDrop GateConditionType = "max_case_pass_rate_drop"
+	// GateMaxP95LatencyIncrease allows the run's p95 latency to exceed the
+	// baseline run's by at most Threshold (0.2 is 20%)
+	GateMaxP95LatencyIncrease GateConditionType = "max_p95_latency_increase"
+)
+
+// GateCondition is one condition of a quality gate
+type GateCondition struct {
+	Type      GateConditionType `json:"type"`
+	Threshold float64           `json:"threshold"`
+	Severity  string            `json:"severity,omitempty"` // max_violations only, default error
+	// RequireSignificance fails a baseline comparison only if the change is
+	// also significant. Off by default, since few runs per case rarely reach
+	// significance.
+	RequireSignificance bool `json:"require_significance,omitempty"`
+}
+
+// QualityGate is the set of conditions a project's uploaded test runs are
+// checked against. Conditions comparing against a baseline use the latest
+// completed run on BaselineBranch.
+type QualityGate struct {
+	ProjectID      string          `json:"project_id"`
+	Enabled        bool            `json:"enabled"`
+	BaselineBranch string          `json:"baseline_branch"`
+	Conditions     []GateCondition `json:"conditions"`
+	UpdatedBy      string          `json:"updated_by,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+}
+
+// QualityGateRepository handles per-project quality gates and the
+// significance level regressions are tested at
+type QualityGateRepository interface {
+	Get(ctx context.Context, projectID string) (*QualityGate, error)
+	Upsert(ctx context.Context, gate *QualityGate) error
+	Delete(ctx context.Context, projectID string) error
+	// GetAlpha returns the project's significance level, nil for the default
+	GetAlpha(ctx context.Context, projectID string) (*float64, error)
+	SetAlpha(ctx context.Context, projectID string, alpha *float64) error
+}
+
 // Organization represents an organization
 type Organization struct {
 	ID                  string    `json:"id"`
@@ -100,7 +1104,10 @@ type OrganizationRepository interface {
 	GetByUser(ctx context.Context, userID string) ([]*Organization, error)
 	Update(ctx context.Context, org *Organization) error
 	Delete(ctx context.Context, id string) error
-	IncrementRequestCount(ctx context.Context, id string) (*Organization, error)
+	IncrementRequestCount(ctx context.Context, id string, n int64) (*Organization, error)
+	// ReserveUsage atomically adds up to n to the monthly request count
+	// without taking it past ceiling, and returns how much was added
+	ReserveUsage(ctx context.Context, id string, n, ceiling int64) (int64, error)
 	ResetMonthlyUsage(ctx context.Context, id string) error
 }
 
@@ -173,9 +1180,143 @@ type Invite struct {
 // InviteRepository handles invite operations
 type InviteRepository interface {
 	Create(ctx context.Context, invite *Invite) error
+	GetByID(ctx context.Context, id string) (*Invite, error)
 	GetByToken(ctx context.Context, token string) (*Invite, error)
 	GetByEmailAndOrg(ctx context.Context, email, orgID string) (*Invite, error)
 	ListByOrganization(ctx context.Context, orgID string) ([]*Invite, error)
 	Accept(ctx context.Context, token, userID string) error
 	Revoke(ctx context.Context, id string) error
 }
+
+// CaseObservation is a case's result in one test run
+type CaseObservation struct {
+	RunID     string    `json:"run_id"`
+	GitSHA    string    `json:"git_sha"`
+	Timestamp time.Time `json:"timestamp"`
+	CaseID    string    `json:"case_id"`
+	Provider  string    `json:"provider"`
+	Model     string    `json:"model"`
+	Runs      int       `json:"runs"` // individual runs of the case
+	PassRate  float64   `json:"pass_rate"`
+}
+
+// CaseQuarantine marks a flaky test case of a project. Quarantined cases are
+// left out of quality gates.
+type CaseQuarantine struct {
+	ProjectID     string    `json:"project_id"`
+	CaseID        string    `json:"case_id"`
+	Reason        string    `json:"reason,omitempty"`
+	QuarantinedBy string    `json:"quarantined_by,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// QuarantineRepository handles quarantined test cases
+type QuarantineRepository interface {
+	List(ctx context.Context, projectID string) ([]*CaseQuarantine, error)
+	// Upsert quarantines a case or updates its quarantine, and reports
+	// whether the case was newly quarantined
+	Upsert(ctx context.Context, quarantine *CaseQuarantine) (bool, error)
+	Delete(ctx context.Context, projectID, caseID string) error
+}
+
+// Regression types
+const (
+	// RegressionPassRate is a case whose pass rate fell below the passing
+	// threshold
+	RegressionPassRate = "pass_rate"
+)
+
+// RegressionDetection is a test case regression located on a branch.
+// RegressionGitSHA is the first SHA the case failed at and LastGoodGitSHA the
+// last it passed at before that.
+type RegressionDetection struct {
+	ID               string          `json:"id"`
+	ProjectID        string          `json:"project_id"`
+	CaseID           string          `json:"case_id"`
+	Branch           string          `json:"branch"`
+	RegressionGitSHA string          `json:"regression_git_sha"`
+	LastGoodGitSHA   string          `json:"last_good_git_sha,omitempty"`
+	RegressionType   string          `json:"regression_type"`
+	Severity         string          `json:"severity"`
+	Details          json.RawMessage `json:"details"`
+	DetectedAt       time.Time       `json:"detected_at"`
+	ResolvedAt       *time.Time      `json:"resolved_at,omitempty"`
+}
+
+// RegressionRepository handles detected test case regressions
+type RegressionRepository interface {
+	// List returns the project's regressions, newest first, only the open
+	// ones unless includeResolved is set
+	List(ctx context.Context, projectID string, includeResolved bool, limit, offset int) ([]*RegressionDetection, error)
+	// Upsert records a regression, or updates the open regression of the
+	// same branch, case, and type
+	Upsert(ctx context.Context, detection *RegressionDetection) error
+	// Resolve closes the open regressions of a case on the branch
+	Resolve(ctx context.Context, projectID, branch, caseID string) error
+	// CountOpen counts the open regressions located on the branch
+	CountOpen(ctx context.Context, projectID, branch string) (int, error)
+}
+
+// BadgeToken grants unauthenticated access to a project's status badges.
+// The token itself is only shown when it is created.
+type BadgeToken struct {
+	ID          string     `json:"id"`
+	ProjectID   string     `json:"project_id"`
+	TokenHash   string     `json:"-"`
+	TokenPrefix string     `json:"token_prefix"`
+	Name        string     `json:"name,omitempty"`
+	CreatedBy   string     `json:"created_by,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	RevokedAt   *time.Time `json:"revoked_at,omitempty"`
+}
+
+// BadgeRepository handles status badge tokens
+type BadgeRepository interface {
+	Create(ctx context.Context, token *BadgeToken) error
+	List(ctx context.Context, projectID string) ([]*BadgeToken, error)
+	// GetByHash returns the unrevoked token with the hash
+	GetByHash(ctx context.Context, tokenHash string) (*BadgeToken, error)
+	// Revoke revokes a token and returns it
+	Revoke(ctx context.Context, projectID, id string) (*BadgeToken, error)
+}
+
+// Audit log actor types
+const (
+	AuditActorUser   = "user"
+	AuditActorAPIKey = "api_key"
+)
+
+// AuditLogEntry records an administrative action in an organization
+type AuditLogEntry struct {
+	ID             string `json:"id"`
+	OrganizationID string `json:"organization_id"`
+	ActorType      string `json:"actor_type"`
+	ActorID        string `json:"actor_id,omitempty"`
+	ActorName      string `json:"actor_name,omitempty"`
+	Action         string `json:"action"`
+	TargetType     string `json:"target_type"`
+	TargetID       string `json:"target_id,omitempty"`
+	// Changes maps each changed field to its before and after values
+	Changes   json.RawMessage `json:"changes"`
+	IPAddress string          `json:"ip_address,omitempty"`
+	UserAgent string          `json:"user_agent,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// AuditLogFilter narrows an audit log listing. Empty fields match anything.
+type AuditLogFilter struct {
+	ActorID    string
+	Action     string
+	TargetType string
+	TargetID   string
+	From       time.Time
+	To         time.Time
+}
+
+// AuditLogRepository handles the append-only audit log
+type AuditLogRepository interface {
+	Append(ctx context.Context, entry *AuditLogEntry) error
+	// List returns an organization's entries, newest first
+	List(ctx context.Context, orgID string, filter AuditLogFilter, limit, offset int) ([]*AuditLogEntry, error)
+}
@@ -3,46 +3,67 @@ package s3
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"mime/multipart"
-	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 	"github.com/regrada-ai/regrada-be/internal/storage"
 )
 
 // Ensure Service implements storage.FileStorageService interface at compile time
 var _ storage.FileStorageService = (*Service)(nil)
 
+// maxPresignExpiry is the longest expiry SigV4 presigned URLs support
+const maxPresignExpiry = 7 * 24 * time.Hour
+
+// Config holds the settings for an S3 or S3-compatible (e.g. MinIO) bucket
+type Config struct {
+	Region           string
+	Bucket           string
+	CloudFrontDomain string // optional; public URLs are served from this domain when set
+	Endpoint         string // optional; custom endpoint for S3-compatible stores, e.g. "http://minio:9000"
+	UsePathStyle     bool   // address buckets as endpoint/bucket/key instead of bucket.endpoint/key
+	PublicBaseURL    string // optional; public base URL for objects when CloudFront is not used
+}
+
 type Service struct {
 	client           *s3.Client
 	presignClient    *s3.PresignClient
 	bucket           string
 	cloudFrontDomain string
+	publicBaseURL    string
 }
 
 // NewService creates a new S3 service
-func NewService(region, bucket, cloudFrontDomain string) (*Service, error) {
-	cfg, err := config.LoadDefaultConfig(context.Background(),
-		config.WithRegion(region),
+func NewService(cfg Config) (*Service, error) {
+	awsCfg, err := config.LoadDefaultConfig(context.Background(),
+		config.WithRegion(cfg.Region),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load AWS config: %w", err)
 	}
 
-	client := s3.NewFromConfig(cfg)
+	client := s3.NewFromConfig(awsCfg, func(o *s3.Options) {
+		if cfg.Endpoint != "" {
+			o.BaseEndpoint = aws.String(cfg.Endpoint)
+		}
+		o.UsePathStyle = cfg.UsePathStyle
+	})
 	presignClient := s3.NewPresignClient(client)
 
 	return &Service{
 		client:           client,
 		presignClient:    presignClient,
-		bucket:           bucket,
-		cloudFrontDomain: cloudFrontDomain,
+		bucket:           cfg.Bucket,
+		cloudFrontDomain: cfg.CloudFrontDomain,
+		publicBaseURL:    strings.TrimSuffix(cfg.PublicBaseURL, "/"),
 	}, nil
 }
 
@@ -81,6 +102,23 @@ func (s *Service) DeleteFile(ctx context.Context, key string) error {
 	return nil
 }
 
+// DownloadFile streams an object from S3
+func (s *Service) DownloadFile(ctx context.Context, key string) (io.ReadCloser, error) {
+	out, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		var noSuchKey *types.NoSuchKey
+		if errors.As(err, &noSuchKey) {
+			return nil, storage.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to download from S3: %w", err)
+	}
+
+	return out.Body, nil
+}
+
 // GetPresignedURL generates a presigned URL for accessing an S3 object
 func (s *Service) GetPresignedURL(ctx context.Context, key string, expiresIn time.Duration) (string, error) {
 	if key == "" {
@@ -99,43 +137,26 @@ func (s *Service) GetPresignedURL(ctx context.Context, key string, expiresIn tim
 	return req.URL, nil
 }
 
-// GetCloudFrontURL returns the CloudFront URL for a given S3 key
+// GetCloudFrontURL returns the public URL for a given S3 key. It uses the
+// CloudFront domain when configured, then the public base URL, and otherwise
+// falls back to a presigned URL with the maximum allowed expiry.
 func (s *Service) GetCloudFrontURL(key string) string {
 	if key == "" {
 		return ""
 	}
 	// Remove leading slash if present
 	key = strings.TrimPrefix(key, "/")
-	return fmt.Sprintf("https://%s/%s", s.cloudFrontDomain, key)
-}
 
-// ValidateImageFile validates that the uploaded file is an image
-func ValidateImageFile(header *multipart.FileHeader) error {
-	// Check file size (max 5MB)
-	const maxSize = 5 * 1024 * 1024
-	if header.Size > maxSize {
-		return fmt.Errorf("file size exceeds 5MB limit")
+	if s.cloudFrontDomain != "" {
+		return fmt.Sprintf("https://%s/%s", s.cloudFrontDomain, key)
 	}
-
-	// Check content type
-	contentType := header.Header.Get("Content-Type")
-	allowedTypes := map[string]bool{
-		"image/jpeg": true,
-		"image/jpg":  true,
-		"image/png":  true,
-		"image/gif":  true,
-		"image/webp": true,
+	if s.publicBaseURL != "" {
+		return fmt.Sprintf("%s/%s", s.publicBaseURL, key)
 	}
 
-	if !allowedTypes[contentType] {
-		return fmt.Errorf("invalid file type: %s. Allowed types: jpeg, jpg, png, gif, webp", contentType)
+	url, err := s.GetPresignedURL(context.Background(), key, maxPresignExpiry)
+	if err != nil {
+		return ""
 	}
-
-	return nil
-}
-
-// GetFileExtension returns the file extension from the filename
-func GetFileExtension(filename string) string {
-	ext := filepath.Ext(filename)
-	return strings.ToLower(ext)
+	return url
 }